@@ -1,9 +1,11 @@
 """FastAPI server for NeoGraph agents."""
 from fastapi import FastAPI
 from fastapi.middleware.cors import CORSMiddleware
+from fastapi.responses import StreamingResponse
 from pydantic import BaseModel
 from typing import Optional, List, Dict, Any
 import anthropic
+import json
 import os
 import logging
 
@@ -32,12 +34,20 @@ app.add_middleware(
 client = anthropic.Anthropic(api_key=settings.anthropic_api_key)
 
 
+class ChatMessage(BaseModel):
+    """Earlier message of a conversation."""
+
+    role: str  # user or assistant
+    content: str
+
+
 class ChatRequest(BaseModel):
     """Request model for chat endpoint."""
 
     message: str
     repo_id: Optional[str] = None
     agent_type: str = "explorer"
+    history: List[ChatMessage] = []
 
 
 class ChatResponse(BaseModel):
@@ -93,6 +103,91 @@ def get_system_prompt(agent_type: str, repo_id: Optional[str] = None) -> str:
     return prompt_func(repo_id=repo_id)
 
 
+def conversation_messages(request: ChatRequest) -> List[Dict[str, Any]]:
+    """
+    Build the messages sent to Claude: the earlier messages of the
+    conversation, then the new one.
+
+    Args:
+        request: Chat request with message and history
+
+    Returns:
+        Messages alternating between user and assistant
+    """
+    messages = []
+    for message in request.history:
+        role = "assistant" if message.role == "assistant" else "user"
+        if not message.content:
+            continue
+        # Claude expects turns to alternate; merge repeated ones, such as a
+        # question whose answer failed
+        if messages and messages[-1]["role"] == role:
+            messages[-1]["content"] += "\n\n" + message.content
+        else:
+            messages.append({"role": role, "content": message.content})
+    # The conversation must start with the user
+    if messages and messages[0]["role"] == "assistant":
+        messages.pop(0)
+    if messages and messages[-1]["role"] == "user":
+        messages[-1]["content"] += "\n\n" + request.message
+    else:
+        messages.append({"role": "user", "content": request.message})
+    return messages
+
+
+def run_tool_calls(content, tool_calls_log: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
+    """
+    Execute the tool calls of an assistant turn.
+
+    Args:
+        content: Content blocks of the assistant's response
+        tool_calls_log: Log of tool calls, appended to
+
+    Returns:
+        Tool results to send back to Claude
+    """
+    tool_results = []
+    for block in content:
+        if block.type == "tool_use":
+            tool_name = block.name
+            tool_input = block.input
+            tool_use_id = block.id
+
+            logger.info(f"Executing tool: {tool_name} with input: {tool_input}")
+
+            try:
+                # Execute the tool
+                result = execute_tool(tool_name, tool_input)
+
+                # Log tool call
+                tool_calls_log.append({
+                    "tool": tool_name,
+                    "input": tool_input,
+                    "result": result
+                })
+
+                # Add tool result to messages
+                tool_results.append({
+                    "type": "tool_result",
+                    "tool_use_id": tool_use_id,
+                    "content": str(result)
+                })
+            except Exception as e:
+                logger.error(f"Error executing tool {tool_name}: {e}")
+                tool_results.append({
+                    "type": "tool_result",
+                    "tool_use_id": tool_use_id,
+                    "content": f"Error: {str(e)}",
+                    "is_error": True
+                })
+    return tool_results
+
+
+def sse_event(event: str, data: Any) -> str:
+    """Format a server-sent event with a JSON payload."""
+    return f"event: {event}\ndata: {json.dumps(data, default=str)}\n\n"
+
+
 @app.post("/chat", response_model=ChatResponse)
 async def chat(request: ChatRequest):
     """
@@ -108,8 +203,8 @@ async def chat(request: ChatRequest):
     tools = get_tools()
     system_prompt = get_system_prompt(request.agent_type, request.repo_id)
 
-    # Start conversation with user message
-    messages = [{"role": "user", "content": request.message}]
+    # Continue the conversation with the user message
+    messages = conversation_messages(request)
     tool_calls_log = []
 
     # Agentic loop: handle tool use until we get a final response
@@ -142,40 +237,7 @@ async def chat(request: ChatRequest):
             messages.append({"role": "assistant", "content": response.content})
 
             # Execute all tool calls
-            tool_results = []
-            for block in response.content:
-                if block.type == "tool_use":
-                    tool_name = block.name
-                    tool_input = block.input
-                    tool_use_id = block.id
-
-                    logger.info(f"Executing tool: {tool_name} with input: {tool_input}")
-
-                    try:
-                        # Execute the tool
-                        result = execute_tool(tool_name, tool_input)
-
-                        # Log tool call
-                        tool_calls_log.append({
-                            "tool": tool_name,
-                            "input": tool_input,
-                            "result": result
-                        })
-
-                        # Add tool result to messages
-                        tool_results.append({
-                            "type": "tool_result",
-                            "tool_use_id": tool_use_id,
-                            "content": str(result)
-                        })
-                    except Exception as e:
-                        logger.error(f"Error executing tool {tool_name}: {e}")
-                        tool_results.append({
-                            "type": "tool_result",
-                            "tool_use_id": tool_use_id,
-                            "content": f"Error: {str(e)}",
-                            "is_error": True
-                        })
+            tool_results = run_tool_calls(response.content, tool_calls_log)
 
             # Add tool results to messages for next iteration
             messages.append({"role": "user", "content": tool_results})
@@ -192,6 +254,74 @@ async def chat(request: ChatRequest):
     )
 
 
+@app.post("/chat/stream")
+def chat_stream(request: ChatRequest):
+    """
+    Handle chat requests like /chat, streaming the answer as server-sent
+    events while Claude writes it.
+
+    Events:
+        token: {"text": ...}, a piece of the answer
+        tool_call: {"tool", "input", "result"}, once a tool has run
+        done: {"response", "tool_calls"}, the whole answer, last
+        error: {"message": ...}, when the conversation failed, last
+
+    Args:
+        request: Chat request with message, optional repo_id, and agent_type
+
+    Returns:
+        StreamingResponse of the events
+    """
+    tools = get_tools()
+    system_prompt = get_system_prompt(request.agent_type, request.repo_id)
+
+    def events():
+        messages = conversation_messages(request)
+        tool_calls_log = []
+        response_text = ""
+
+        try:
+            max_iterations = 10
+            for iteration in range(max_iterations):
+                with client.messages.stream(
+                    model=settings.model,
+                    max_tokens=4096,
+                    tools=tools,
+                    messages=messages,
+                    system=system_prompt,
+                ) as stream:
+                    for text in stream.text_stream:
+                        response_text += text
+                        yield sse_event("token", {"text": text})
+                    response = stream.get_final_message()
+
+                if response.stop_reason != "tool_use":
+                    if response.stop_reason != "end_turn":
+                        logger.warning(f"Unexpected stop reason: {response.stop_reason}")
+                    break
+
+                messages.append({"role": "assistant", "content": response.content})
+                logged = len(tool_calls_log)
+                tool_results = run_tool_calls(response.content, tool_calls_log)
+                for call in tool_calls_log[logged:]:
+                    yield sse_event("tool_call", call)
+                messages.append({"role": "user", "content": tool_results})
+            else:
+                text = "\n\nMaximum iterations reached. Please try rephrasing your question."
+                response_text += text
+                yield sse_event("token", {"text": text})
+        except Exception as e:
+            logger.error(f"Streaming chat failed: {e}", exc_info=True)
+            yield sse_event("error", {"message": "the agent failed to answer"})
+            return
+
+        yield sse_event("done", {"response": response_text, "tool_calls": tool_calls_log})
+
+    # A plain generator is iterated in a thread pool, as the Anthropic
+    # client blocks
+    return StreamingResponse(events(), media_type="text/event-stream")
+
+
 @app.post("/wiki/generate", response_model=WikiGenerateResponse)
 async def wiki_generate(request: WikiGenerateRequest):
     """
@@ -203,7 +203,7 @@ def find_function(pattern: str) -> List[Dict[str, Any]]:
     MATCH (fn:Function)
     WHERE fn.name =~ $pattern
     OPTIONAL MATCH (fn)<-[:DECLARES]-(file:File)
-    OPTIONAL MATCH (file)<-[:CONTAINS]-(repo:Repository)
+    OPTIONAL MATCH (file)<-[:CONTAINS*]-(repo:Repository)
 
     // Count incoming and outgoing calls
     OPTIONAL MATCH (fn)-[:CALLS]->(called)
@@ -50,7 +50,7 @@ def get_code_structure(repo_id: str) -> dict[str, Any]:
         Dictionary with files grouped by directory, including function details
     """
     query = """
-    MATCH (repo:Repository {id: $repo_id})-[:CONTAINS]->(file:File)
+    MATCH (repo:Repository {id: $repo_id})-[:CONTAINS*]->(file:File)
     OPTIONAL MATCH (file)-[:DECLARES]->(fn:Function|Method)
     WITH file, collect({
         name: fn.name,
@@ -5,44 +5,82 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 
 	"github.com/dpolishuk/neograph/backend/internal/api"
 	"github.com/dpolishuk/neograph/backend/internal/config"
 	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/dpolishuk/neograph/backend/internal/metrics"
+	"github.com/dpolishuk/neograph/backend/internal/scheduler"
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/cors"
+	"github.com/gofiber/fiber/v3/middleware/helmet"
 	"github.com/gofiber/fiber/v3/middleware/logger"
 )
 
 func main() {
 	cfg := config.Load()
+	if cfg.CORSAllowCredentials && slices.Contains(cfg.CORSAllowedOrigins, "*") {
+		log.Fatal("CORS_ALLOW_CREDENTIALS needs CORS_ALLOWED_ORIGINS to list origins rather than *")
+	}
 
 	// Connect to Neo4j
 	dbClient, err := db.NewNeo4jClient(context.Background(), db.Neo4jConfig{
 		URI:      cfg.Neo4jURI,
 		Username: cfg.Neo4jUser,
 		Password: cfg.Neo4jPass,
+
+		WriteAttempts:         cfg.Neo4jWriteAttempts,
+		Database:              cfg.Neo4jDatabase,
+		DatabasePerRepository: cfg.Neo4jDatabasePerRepo,
+		Dialect:               db.Dialect(cfg.Neo4jDialect),
 	})
 	if err != nil {
 		log.Fatalf("Failed to connect to Neo4j: %v", err)
 	}
 	defer dbClient.Close()
 
-	// Create Fiber app
+	// Create Fiber app. Forwarded headers are only honoured for requests from
+	// a trusted proxy, so with none configured they are always ignored.
 	app := fiber.New(fiber.Config{
-		AppName: "NeoGraph API",
+		AppName:      "NeoGraph API",
+		BodyLimit:    cfg.BodyLimit,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+		TrustProxy:   true,
+		TrustProxyConfig: fiber.TrustProxyConfig{
+			Proxies: cfg.TrustedProxies,
+		},
+		ProxyHeader: cfg.ProxyHeader,
+		// Unknown routes and oversized bodies get the API's error responses
+		ErrorHandler: api.ErrorHandler,
 	})
+	app.Server().HeaderReceived = api.UploadBodyLimit(cfg.UploadBodyLimit)
 
 	// Middleware
 	app.Use(logger.New())
+	// Responses are JSON, never framed or run as a page; the API docs relax
+	// this for Swagger UI
+	app.Use(helmet.New(helmet.Config{
+		XFrameOptions:         "DENY",
+		ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+		HSTSMaxAge:            cfg.HSTSMaxAge,
+	}))
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: []string{"*"},
-		AllowHeaders: []string{"Origin", "Content-Type", "Accept"},
-		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowOrigins:     cfg.CORSAllowedOrigins,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", api.APIKeyHeader, api.IdempotencyKeyHeader, fiber.HeaderIfNoneMatch},
+		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		// Read by the frontend to report which search result was opened,
+		// whether a search fell back to keywords and when to retry rate
+		// limited requests, and by clients to notice deprecated API versions
+		ExposeHeaders: []string{api.SearchIDHeader, api.SearchModeHeader, api.TotalCountHeader, fiber.HeaderRetryAfter,
+			fiber.HeaderETag, "Deprecation", fiber.HeaderLink},
 	}))
 
-	// Health check
+	// Liveness check, up while the process is; /health/ready checks dependencies
 	app.Get("/health", func(c fiber.Ctx) error {
 		return c.JSON(fiber.Map{
 			"status":  "ok",
@@ -50,11 +88,42 @@ func main() {
 		})
 	})
 
+	// Prometheus metrics
+	app.Get("/metrics", func(c fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, metrics.ContentType)
+		return metrics.WriteText(c)
+	})
+
 	// Setup API routes
-	handler := api.NewHandler(cfg, dbClient)
+	handler, err := api.NewHandler(cfg, dbClient)
+	if err != nil {
+		log.Fatalf("Failed to create API handler: %v", err)
+	}
 	defer handler.Close()
+
+	// Readiness check of the backend's dependencies
+	app.Get("/health/ready", handler.GetReadiness)
+
 	api.SetupRoutes(app, handler)
 
+	// Vector indexes, and re-embedding when the embedding model changed
+	if err := handler.StartVectorMigration(context.Background()); err != nil {
+		log.Printf("Failed to prepare vector indexes: %v", err)
+	}
+
+	// Full-text index for keyword search
+	if err := dbClient.CreateFulltextIndexes(context.Background()); err != nil {
+		log.Printf("Failed to create full-text indexes: %v", err)
+	}
+
+	// Scheduled reindexing
+	if cfg.ReindexInterval > 0 {
+		sched := scheduler.New(cfg.ReindexInterval, handler.SchedulerRunner())
+		sched.Start()
+		defer sched.Stop()
+		log.Printf("Scheduled reindexing every %s", cfg.ReindexInterval)
+	}
+
 	// Graceful shutdown
 	go func() {
 		sigChan := make(chan os.Signal, 1)
@@ -62,11 +131,20 @@ func main() {
 		<-sigChan
 
 		log.Println("Shutting down...")
+		// Event streams stay open until told to end
+		handler.CloseEventStreams()
 		app.Shutdown()
 	}()
 
+	listenCfg := fiber.ListenConfig{}
+	if cfg.TLSCertFile != "" && cfg.TLSKeyFile != "" {
+		listenCfg.CertFile = cfg.TLSCertFile
+		listenCfg.CertKeyFile = cfg.TLSKeyFile
+		log.Printf("Serving HTTPS with certificate %s", cfg.TLSCertFile)
+	}
+
 	log.Printf("Starting NeoGraph backend on port %s", cfg.Port)
-	if err := app.Listen(":" + cfg.Port); err != nil {
+	if err := app.Listen(":"+cfg.Port, listenCfg); err != nil {
 		log.Fatalf("Server error: %v", err)
 	}
 }
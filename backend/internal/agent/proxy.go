@@ -15,6 +15,15 @@ type ChatRequest struct {
 	Message   string  `json:"message"`
 	RepoID    *string `json:"repo_id,omitempty"`
 	AgentType string  `json:"agent_type"`
+	// earlier messages of the conversation, oldest first
+	History []ChatMessage `json:"history,omitempty"`
+}
+
+// ChatMessage is an earlier message of a conversation continued with the
+// agent
+type ChatMessage struct {
+	Role    string `json:"role"` // user or assistant
+	Content string `json:"content"`
 }
 
 // ChatResponse represents the response from the agent service
@@ -67,13 +76,16 @@ func NewAgentProxy(baseURL string) *AgentProxy {
 
 // Chat sends a message to the agent service and returns the response
 func (p *AgentProxy) Chat(ctx context.Context, message string, repoID *string, agentType string) (*ChatResponse, error) {
-	// Construct request
-	reqBody := ChatRequest{
+	return p.Send(ctx, ChatRequest{
 		Message:   message,
 		RepoID:    repoID,
 		AgentType: agentType,
-	}
+	})
+}
 
+// Send sends a chat request, which may continue a conversation, to the
+// agent service and returns the response
+func (p *AgentProxy) Send(ctx context.Context, reqBody ChatRequest) (*ChatResponse, error) {
 	// Marshal to JSON
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
@@ -109,6 +121,44 @@ func (p *AgentProxy) Chat(ctx context.Context, message string, repoID *string, a
 	return &chatResp, nil
 }
 
+// ChatStream sends a chat request to the agent service's streaming endpoint
+// and returns its server-sent events as they arrive: token, tool_call, and
+// done or error last. The caller closes the stream; cancelling ctx ends it.
+func (p *AgentProxy) ChatStream(ctx context.Context, reqBody ChatRequest) (io.ReadCloser, error) {
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/chat/stream", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("agent service returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return resp.Body, nil
+}
+
+// Complete answers a prompt with the doc writer agent, outside of any
+// repository
+func (p *AgentProxy) Complete(ctx context.Context, prompt string) (string, error) {
+	response, err := p.Chat(ctx, prompt, nil, "doc_writer")
+	if err != nil {
+		return "", err
+	}
+	return response.Response, nil
+}
+
 // GenerateWiki calls the agent service to generate wiki pages
 func (p *AgentProxy) GenerateWiki(ctx context.Context, repoID, repoName string) (*WikiGenerateResponse, error) {
 	// Construct request
@@ -152,3 +202,22 @@ func (p *AgentProxy) GenerateWiki(ctx context.Context, repoID, repoName string)
 
 	return &wikiResp, nil
 }
+
+// Health checks the agent service answers its health check
+func (p *AgentProxy) Health(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+"/health", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("agent service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
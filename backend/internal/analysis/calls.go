@@ -0,0 +1,426 @@
+package analysis
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// AmbiguousCall is a call whose name matched several in-scope candidates
+type AmbiguousCall struct {
+	CallerID   string
+	Name       string
+	Candidates int
+}
+
+// UnresolvedCall is a call matching no entity of the repository: a builtin,
+// a standard library or third-party function, or a method of a type the
+// resolver cannot tell
+type UnresolvedCall struct {
+	CallerID   string
+	Name       string // as called through an import's local name, like "json.Marshal"
+	ImportPath string // of that import, "" when not called through one
+	Count      int    // call sites
+}
+
+// selfQualifiers refer to the caller's own class
+var selfQualifiers = map[string]bool{"self": true, "this": true, "cls": true}
+
+// callScope is everything about one file needed to resolve its calls
+type callScope struct {
+	language   string
+	namespaces map[string]string                // local name -> imported module/package path
+	symbols    map[string]models.ImportRelation // local name -> imported symbol
+}
+
+// ResolveCalls links the call names recorded on each entity to the entity
+// they refer to, using class membership, file and package scope and the
+// caller file's imports. Calls matching several candidates in the first scope
+// that has any are reported as ambiguous rather than guessed; calls matching
+// nothing are assumed to be external and reported as unresolved. Entities
+// must have IDs.
+//
+// Each relation carries the lines of the call sites that resolved to the
+// callee, taken from CallSites when the extractor recorded them. Call sites
+// a language server found the definition of are linked to the function
+// declared there instead, or unresolved when it lies outside the repository.
+func ResolveCalls(files []*models.File, entities []models.CodeEntity) ([]models.CallRelation, []AmbiguousCall, []UnresolvedCall) {
+	r := newCallResolver(files, entities)
+
+	var calls []models.CallRelation
+	var ambiguous []AmbiguousCall
+	var unresolved []UnresolvedCall
+	for i := range entities {
+		caller := &entities[i]
+		if caller.ID == "" {
+			continue
+		}
+		byCallee := make(map[string]int) // callee ID -> index into calls
+		byName := make(map[string]int)   // unresolved name -> index into unresolved
+		addUnresolved := func(call string, count int) {
+			name, importPath := r.calledThrough(caller, call)
+			if name == "" {
+				return
+			}
+			idx, seen := byName[name]
+			if !seen {
+				idx = len(unresolved)
+				byName[name] = idx
+				unresolved = append(unresolved, UnresolvedCall{CallerID: caller.ID, Name: name, ImportPath: importPath})
+			}
+			unresolved[idx].Count += count
+		}
+
+		for _, site := range caller.CallSites {
+			if site.Definition == nil {
+				continue
+			}
+			callee, ok := r.entityAt(*site.Definition, models.EntityFunction, models.EntityMethod)
+			if !ok {
+				addUnresolved(site.Name, 1)
+				continue
+			}
+			calleeID := entities[callee].ID
+			idx, seen := byCallee[calleeID]
+			if !seen {
+				idx = len(calls)
+				byCallee[calleeID] = idx
+				calls = append(calls, models.CallRelation{CallerID: caller.ID, CalleeID: calleeID})
+			}
+			addCallLines(&calls[idx], []int{site.Line})
+		}
+
+		names, lines := callSitesByName(caller)
+		for _, call := range names {
+			candidates, ok := r.resolve(caller, call)
+			switch {
+			case !ok || len(candidates) == 0:
+				addUnresolved(call, len(lines[call]))
+			case len(candidates) == 1:
+				calleeID := entities[candidates[0]].ID
+				idx, seen := byCallee[calleeID]
+				if !seen {
+					idx = len(calls)
+					byCallee[calleeID] = idx
+					calls = append(calls, models.CallRelation{CallerID: caller.ID, CalleeID: calleeID})
+				}
+				addCallLines(&calls[idx], lines[call])
+			default:
+				name, _ := splitCall(call)
+				ambiguous = append(ambiguous, AmbiguousCall{
+					CallerID:   caller.ID,
+					Name:       name,
+					Candidates: len(candidates),
+				})
+			}
+		}
+	}
+	return calls, ambiguous, unresolved
+}
+
+// callSitesByName groups an entity's call sites by called name, keeping the
+// order names are first called in, skipping those a language server
+// resolved. Without call sites every name in Calls counts once, with no line.
+func callSitesByName(entity *models.CodeEntity) ([]string, map[string][]int) {
+	lines := make(map[string][]int)
+	var names []string
+	if len(entity.CallSites) == 0 {
+		for _, call := range entity.Calls {
+			if _, ok := lines[call]; !ok {
+				names = append(names, call)
+				lines[call] = []int{0}
+			}
+		}
+		return names, lines
+	}
+	for _, site := range entity.CallSites {
+		if site.Definition != nil {
+			continue
+		}
+		if _, ok := lines[site.Name]; !ok {
+			names = append(names, site.Name)
+		}
+		lines[site.Name] = append(lines[site.Name], site.Line)
+	}
+	return names, lines
+}
+
+// addCallLines merges call site lines into a relation, keeping Lines sorted
+// and Line at the first call
+func addCallLines(call *models.CallRelation, lines []int) {
+	call.Count += len(lines)
+	for _, line := range lines {
+		if line > 0 {
+			call.Lines = append(call.Lines, line)
+		}
+	}
+	sort.Ints(call.Lines)
+	if len(call.Lines) > 0 {
+		call.Line = call.Lines[0]
+	}
+}
+
+type callResolver struct {
+	entities []models.CodeEntity
+	byName   map[string][]int // callable name -> entity indexes
+	byFile   map[string][]int // file path -> entity indexes
+	scopes   map[string]*callScope
+	dirs     map[string]bool
+}
+
+func newCallResolver(files []*models.File, entities []models.CodeEntity) *callResolver {
+	r := &callResolver{
+		entities: entities,
+		byName:   make(map[string][]int),
+		byFile:   make(map[string][]int),
+		scopes:   make(map[string]*callScope, len(files)),
+		dirs:     make(map[string]bool, len(files)),
+	}
+
+	for i, entity := range entities {
+		if entity.Type == models.EntityFunction || entity.Type == models.EntityMethod {
+			r.byName[entity.Name] = append(r.byName[entity.Name], i)
+		}
+		path := filepathToSlash(entity.FilePath)
+		r.byFile[path] = append(r.byFile[path], i)
+	}
+
+	for _, file := range files {
+		r.dirs[path.Dir(filepathToSlash(file.Path))] = true
+
+		scope := &callScope{
+			language:   file.Language,
+			namespaces: make(map[string]string),
+			symbols:    make(map[string]models.ImportRelation),
+		}
+		for _, imp := range file.Imports {
+			if imp.Symbol != "" {
+				local := imp.Symbol
+				if imp.Alias != "" {
+					local = imp.Alias
+				}
+				scope.symbols[local] = imp
+				continue
+			}
+			local := imp.Alias
+			if local == "" {
+				local = importBaseName(imp.ImportPath, file.Language)
+			}
+			scope.namespaces[local] = imp.ImportPath
+		}
+		r.scopes[filepathToSlash(file.Path)] = scope
+	}
+
+	return r
+}
+
+// entityAt returns the innermost entity of one of the given types whose
+// lines span a location
+func (r *callResolver) entityAt(loc models.SourceLocation, types ...models.CodeEntityType) (int, bool) {
+	best, found := 0, false
+	for _, i := range r.byFile[filepathToSlash(loc.FilePath)] {
+		entity := r.entities[i]
+		if loc.Line < entity.StartLine || loc.Line > entity.EndLine || entity.ID == "" {
+			continue
+		}
+		for _, t := range types {
+			if entity.Type != t {
+				continue
+			}
+			if !found || entity.EndLine-entity.StartLine < r.entities[best].EndLine-r.entities[best].StartLine {
+				best, found = i, true
+			}
+		}
+	}
+	return best, found
+}
+
+// resolve returns the candidates of the first scope that matches the call.
+// ok is false when the call is known to leave the repository.
+func (r *callResolver) resolve(caller *models.CodeEntity, call string) ([]int, bool) {
+	name, qualifier := splitCall(call)
+	candidates := r.byName[name]
+	if name == "" || len(candidates) == 0 {
+		return nil, false
+	}
+
+	callerFile := filepathToSlash(caller.FilePath)
+	callerDir := path.Dir(callerFile)
+	scope := r.scopes[callerFile]
+	if scope == nil {
+		scope = &callScope{}
+	}
+
+	sameClass := func(e models.CodeEntity) bool {
+		return caller.ClassName != "" && e.Type == models.EntityMethod &&
+			e.ClassName == caller.ClassName && path.Dir(filepathToSlash(e.FilePath)) == callerDir
+	}
+	inDir := func(dir string, methods bool) func(models.CodeEntity) bool {
+		return func(e models.CodeEntity) bool {
+			return path.Dir(filepathToSlash(e.FilePath)) == dir && (e.Type == models.EntityMethod) == methods
+		}
+	}
+
+	var filters []func(models.CodeEntity) bool
+	if qualifier == "" {
+		filters = append(filters,
+			sameClass,
+			func(e models.CodeEntity) bool {
+				return filepathToSlash(e.FilePath) == callerFile && e.Type != models.EntityMethod
+			},
+		)
+		if imp, ok := scope.symbols[name]; ok {
+			dir, inRepo := ResolveImportDir(imp.ImportPath, callerFile, scope.language, r.dirs)
+			if !inRepo {
+				return nil, false
+			}
+			filters = append(filters, inDir(dir, false))
+		}
+		filters = append(filters, inDir(callerDir, false))
+		return r.firstMatch(candidates, filters), true
+	}
+
+	if selfQualifiers[qualifier] {
+		return r.firstMatch(candidates, []func(models.CodeEntity) bool{sameClass}), true
+	}
+
+	if importPath, ok := scope.namespaces[qualifier]; ok {
+		dir, inRepo := ResolveImportDir(importPath, callerFile, scope.language, r.dirs)
+		if !inRepo {
+			return nil, false
+		}
+		return r.firstMatch(candidates, []func(models.CodeEntity) bool{inDir(dir, false)}), true
+	}
+
+	classMethods := func(dir string) func(models.CodeEntity) bool {
+		return func(e models.CodeEntity) bool {
+			return e.Type == models.EntityMethod && e.ClassName == qualifier &&
+				(dir == "" || path.Dir(filepathToSlash(e.FilePath)) == dir)
+		}
+	}
+	if imp, ok := scope.symbols[qualifier]; ok {
+		dir, inRepo := ResolveImportDir(imp.ImportPath, callerFile, scope.language, r.dirs)
+		if !inRepo {
+			return nil, false
+		}
+		filters = append(filters, classMethods(dir))
+	}
+	filters = append(filters, classMethods(callerDir), classMethods(""))
+
+	// Go methods call siblings through their receiver variable
+	if scope.language == "go" && !strings.Contains(qualifier, ".") {
+		filters = append(filters, sameClass)
+	}
+
+	// Unknown receiver: any method of that name
+	filters = append(filters, func(e models.CodeEntity) bool {
+		return e.Type == models.EntityMethod
+	})
+	return r.firstMatch(candidates, filters), true
+}
+
+// calledThrough returns the name a call is reported under when unresolved
+// and the import it goes through, if any: the called name qualified by the
+// import's local name, or the bare name for builtins and methods of unknown
+// receivers, so calls of the same function group together
+func (r *callResolver) calledThrough(caller *models.CodeEntity, call string) (name, importPath string) {
+	name, qualifier := splitCall(call)
+	if name == "" {
+		return "", ""
+	}
+	scope := r.scopes[filepathToSlash(caller.FilePath)]
+	if scope == nil {
+		return name, ""
+	}
+	if qualifier == "" {
+		if imp, ok := scope.symbols[name]; ok {
+			return name, imp.ImportPath
+		}
+		return name, ""
+	}
+	if importPath, ok := scope.namespaces[qualifier]; ok {
+		return qualifier + "." + name, importPath
+	}
+	if imp, ok := scope.symbols[qualifier]; ok {
+		return qualifier + "." + name, imp.ImportPath
+	}
+	return name, ""
+}
+
+// firstMatch applies the filters in order and returns the candidates kept by
+// the first filter that keeps any
+func (r *callResolver) firstMatch(candidates []int, filters []func(models.CodeEntity) bool) []int {
+	for _, filter := range filters {
+		var matched []int
+		for _, idx := range candidates {
+			if filter(r.entities[idx]) {
+				matched = append(matched, idx)
+			}
+		}
+		if len(matched) > 0 {
+			return matched
+		}
+	}
+	return nil
+}
+
+// splitCall splits a call expression like "s.store.Get" into the called name
+// "Get" and its qualifier "s.store"
+func splitCall(call string) (name, qualifier string) {
+	segments := strings.Split(call, ".")
+	for i, segment := range segments {
+		segments[i] = strings.TrimSpace(trimCallSuffix(segment))
+	}
+	name = segments[len(segments)-1]
+	qualifier = strings.Join(segments[:len(segments)-1], ".")
+	return name, qualifier
+}
+
+// trimCallSuffix drops call arguments, indexes and type arguments from a
+// call segment, as in "get()" or "items[0]"
+func trimCallSuffix(segment string) string {
+	if i := strings.IndexAny(segment, "([<"); i >= 0 {
+		return segment[:i]
+	}
+	return segment
+}
+
+// importBaseName is the name an unaliased import is referred to by: the last
+// path element for Go, Java and Kotlin, the full dotted path for Python
+func importBaseName(importPath, language string) string {
+	if language == "python" {
+		return importPath
+	}
+	if i := strings.LastIndexAny(importPath, "/."); i >= 0 {
+		return importPath[i+1:]
+	}
+	return importPath
+}
+
+// ResolveImplementations links each class to the classes a language server
+// found implementing it. Entities must have IDs.
+func ResolveImplementations(entities []models.CodeEntity) []models.ImplementsRelation {
+	r := newCallResolver(nil, entities)
+
+	var relations []models.ImplementsRelation
+	seen := make(map[models.ImplementsRelation]bool)
+	for _, iface := range entities {
+		if iface.ID == "" {
+			continue
+		}
+		for _, loc := range iface.Implementations {
+			impl, ok := r.entityAt(loc, models.EntityClass)
+			if !ok || entities[impl].ID == iface.ID {
+				continue
+			}
+			rel := models.ImplementsRelation{TypeID: entities[impl].ID, InterfaceID: iface.ID}
+			if !seen[rel] {
+				seen[rel] = true
+				relations = append(relations, rel)
+			}
+		}
+	}
+	return relations
+}
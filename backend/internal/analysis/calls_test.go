@@ -0,0 +1,203 @@
+package analysis
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+func TestResolveCalls(t *testing.T) {
+	files := []*models.File{
+		{Path: "cmd/main.go", Language: "go", Imports: []models.ImportRelation{
+			{ImportPath: "github.com/acme/app/internal/store"},
+			{ImportPath: "fmt"},
+		}},
+		{Path: "internal/store/store.go", Language: "go"},
+		{Path: "internal/cache/cache.go", Language: "go"},
+		{Path: "internal/server/server.go", Language: "go"},
+		{Path: "internal/server/routes.go", Language: "go"},
+		{Path: "app/service.py", Language: "python", Imports: []models.ImportRelation{
+			{ImportPath: "app.repo", Symbol: "Repo"},
+			{ImportPath: "app.util", Symbol: "slugify"},
+		}},
+		{Path: "app/repo.py", Language: "python"},
+		{Path: "app/util.py", Language: "python"},
+		{Path: "lib/other.py", Language: "python"},
+	}
+
+	entities := []models.CodeEntity{
+		// Go: package-qualified, receiver and same-package calls
+		{ID: "main", Type: models.EntityFunction, Name: "main", FilePath: "cmd/main.go",
+			Calls: []string{"store.New", "fmt.Println", "Get"}},
+		{ID: "store.New", Type: models.EntityFunction, Name: "New", FilePath: "internal/store/store.go"},
+		{ID: "cache.New", Type: models.EntityFunction, Name: "New", FilePath: "internal/cache/cache.go"},
+		{ID: "store.Get", Type: models.EntityMethod, Name: "Get", ClassName: "Store", FilePath: "internal/store/store.go"},
+		{ID: "cache.Get", Type: models.EntityMethod, Name: "Get", ClassName: "Cache", FilePath: "internal/cache/cache.go"},
+		{ID: "server.Start", Type: models.EntityMethod, Name: "Start", ClassName: "Server", FilePath: "internal/server/server.go",
+			Calls: []string{"s.routes", "s.cache.Get", "listen"}},
+		{ID: "server.routes", Type: models.EntityMethod, Name: "routes", ClassName: "Server", FilePath: "internal/server/routes.go"},
+		{ID: "server.listen", Type: models.EntityFunction, Name: "listen", FilePath: "internal/server/routes.go"},
+
+		// Python: self, imported class and imported function calls
+		{ID: "svc.run", Type: models.EntityMethod, Name: "run", ClassName: "Service", FilePath: "app/service.py",
+			Calls: []string{"self.load", "Repo.find", "slugify", "print"}},
+		{ID: "svc.load", Type: models.EntityMethod, Name: "load", ClassName: "Service", FilePath: "app/service.py"},
+		{ID: "repo.find", Type: models.EntityMethod, Name: "find", ClassName: "Repo", FilePath: "app/repo.py"},
+		{ID: "util.slugify", Type: models.EntityFunction, Name: "slugify", FilePath: "app/util.py"},
+		{ID: "other.slugify", Type: models.EntityFunction, Name: "slugify", FilePath: "lib/other.py"},
+		{ID: "other.load", Type: models.EntityMethod, Name: "load", ClassName: "Other", FilePath: "lib/other.py"},
+	}
+
+	calls, ambiguous, unresolved := ResolveCalls(files, entities)
+
+	got := make([]string, len(calls))
+	for i, call := range calls {
+		got[i] = call.CallerID + " -> " + call.CalleeID
+	}
+	sort.Strings(got)
+
+	expected := []string{
+		"main -> store.New",
+		"server.Start -> server.listen",
+		"server.Start -> server.routes",
+		"svc.run -> repo.find",
+		"svc.run -> svc.load",
+		"svc.run -> util.slugify",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("calls:\n got %v\nwant %v", got, expected)
+	}
+
+	// s.cache.Get could be either Get method; main's bare Get has no
+	// candidate in scope and is dropped as external
+	expectedAmbiguous := []AmbiguousCall{
+		{CallerID: "server.Start", Name: "Get", Candidates: 2},
+	}
+	if !reflect.DeepEqual(ambiguous, expectedAmbiguous) {
+		t.Errorf("ambiguous:\n got %v\nwant %v", ambiguous, expectedAmbiguous)
+	}
+
+	expectedUnresolved := []UnresolvedCall{
+		{CallerID: "main", Name: "fmt.Println", ImportPath: "fmt", Count: 1},
+		{CallerID: "main", Name: "Get", Count: 1},
+		{CallerID: "svc.run", Name: "print", Count: 1},
+	}
+	if !reflect.DeepEqual(unresolved, expectedUnresolved) {
+		t.Errorf("unresolved:\n got %v\nwant %v", unresolved, expectedUnresolved)
+	}
+}
+
+func TestResolveCallsLines(t *testing.T) {
+	files := []*models.File{{Path: "main.go", Language: "go"}}
+	entities := []models.CodeEntity{
+		{ID: "main", Type: models.EntityFunction, Name: "main", FilePath: "main.go",
+			CallSites: []models.CallSite{
+				{Name: "run", Line: 12},
+				{Name: "fmt.Println", Line: 13},
+				{Name: "run", Line: 9},
+				{Name: "helper", Line: 15},
+			}},
+		{ID: "run", Type: models.EntityFunction, Name: "run", FilePath: "main.go"},
+		{ID: "helper", Type: models.EntityFunction, Name: "helper", FilePath: "main.go",
+			Calls: []string{"run"}},
+	}
+
+	calls, _, unresolved := ResolveCalls(files, entities)
+
+	expected := []models.CallRelation{
+		{CallerID: "main", CalleeID: "run", Line: 9, Lines: []int{9, 12}, Count: 2},
+		{CallerID: "main", CalleeID: "helper", Line: 15, Lines: []int{15}, Count: 1},
+		// Calls without recorded sites still count once
+		{CallerID: "helper", CalleeID: "run", Count: 1},
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("calls:\n got %+v\nwant %+v", calls, expected)
+	}
+
+	// fmt is not imported in this file, so only the name is known
+	expectedUnresolved := []UnresolvedCall{{CallerID: "main", Name: "Println", Count: 1}}
+	if !reflect.DeepEqual(unresolved, expectedUnresolved) {
+		t.Errorf("unresolved:\n got %+v\nwant %+v", unresolved, expectedUnresolved)
+	}
+}
+
+func TestResolveCallsDefinitions(t *testing.T) {
+	files := []*models.File{
+		{Path: "a/a.go", Language: "go"},
+		{Path: "b/b.go", Language: "go"},
+	}
+	entities := []models.CodeEntity{
+		{ID: "main", Type: models.EntityFunction, Name: "main", FilePath: "a/a.go", StartLine: 1, EndLine: 9,
+			CallSites: []models.CallSite{
+				// Two functions named New in scope: the language server picks
+				{Name: "New", Line: 3, Definition: &models.SourceLocation{FilePath: "b/b.go", Line: 12}},
+				// Outside the repository
+				{Name: "Println", Line: 4, Definition: &models.SourceLocation{}},
+				// Unresolved by the server, left to name matching
+				{Name: "helper", Line: 5},
+			}},
+		{ID: "helper", Type: models.EntityFunction, Name: "helper", FilePath: "a/a.go", StartLine: 11, EndLine: 13},
+		{ID: "a.New", Type: models.EntityFunction, Name: "New", FilePath: "a/a.go", StartLine: 15, EndLine: 17},
+		{ID: "b.New", Type: models.EntityFunction, Name: "New", FilePath: "b/b.go", StartLine: 10, EndLine: 20},
+		{ID: "b.inner", Type: models.EntityMethod, Name: "inner", FilePath: "b/b.go", StartLine: 30, EndLine: 32},
+	}
+
+	calls, ambiguous, unresolved := ResolveCalls(files, entities)
+
+	expected := []models.CallRelation{
+		{CallerID: "main", CalleeID: "b.New", Line: 3, Lines: []int{3}, Count: 1},
+		{CallerID: "main", CalleeID: "helper", Line: 5, Lines: []int{5}, Count: 1},
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("calls:\n got %+v\nwant %+v", calls, expected)
+	}
+	if len(ambiguous) != 0 {
+		t.Errorf("expected no ambiguous calls, got %+v", ambiguous)
+	}
+	expectedUnresolved := []UnresolvedCall{{CallerID: "main", Name: "Println", Count: 1}}
+	if !reflect.DeepEqual(unresolved, expectedUnresolved) {
+		t.Errorf("unresolved:\n got %+v\nwant %+v", unresolved, expectedUnresolved)
+	}
+}
+
+func TestResolveImplementations(t *testing.T) {
+	entities := []models.CodeEntity{
+		{ID: "Shape", Type: models.EntityClass, Name: "Shape", FilePath: "Shape.java", StartLine: 1, EndLine: 5,
+			Implementations: []models.SourceLocation{
+				{FilePath: "Circle.java", Line: 3},
+				{FilePath: "Circle.java", Line: 3},
+				{FilePath: "Shape.java", Line: 1}, // itself
+				{FilePath: "Missing.java", Line: 1},
+			}},
+		{ID: "Circle", Type: models.EntityClass, Name: "Circle", FilePath: "Circle.java", StartLine: 3, EndLine: 20},
+		{ID: "Circle.area", Type: models.EntityMethod, Name: "area", FilePath: "Circle.java", StartLine: 3, EndLine: 5},
+	}
+
+	expected := []models.ImplementsRelation{{TypeID: "Circle", InterfaceID: "Shape"}}
+	if got := ResolveImplementations(entities); !reflect.DeepEqual(got, expected) {
+		t.Errorf("ResolveImplementations() = %+v, want %+v", got, expected)
+	}
+}
+
+func TestSplitCall(t *testing.T) {
+	tests := []struct {
+		call      string
+		name      string
+		qualifier string
+	}{
+		{"helper", "helper", ""},
+		{"fmt.Println", "Println", "fmt"},
+		{"s.store.Get", "Get", "s.store"},
+		{"client.get().json", "json", "client.get"},
+		{"items[0].save", "save", "items"},
+	}
+
+	for _, tt := range tests {
+		name, qualifier := splitCall(tt.call)
+		if name != tt.name || qualifier != tt.qualifier {
+			t.Errorf("splitCall(%q) = %q, %q; want %q, %q", tt.call, name, qualifier, tt.name, tt.qualifier)
+		}
+	}
+}
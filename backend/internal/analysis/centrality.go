@@ -0,0 +1,99 @@
+package analysis
+
+import (
+	"math"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// PageRank parameters: the usual damping factor, and when to stop iterating
+const (
+	pageRankDamping    = 0.85
+	pageRankIterations = 100
+	pageRankTolerance  = 1e-9
+)
+
+// Centrality is how load-bearing a function is in the call graph
+type Centrality struct {
+	ID        string
+	InDegree  int     // distinct functions calling it
+	OutDegree int     // distinct functions it calls
+	PageRank  float64 // scores of all functions sum to 1
+}
+
+// ComputeCentrality scores every function by degree and by PageRank over
+// the CALLS edges between them, so a function called by other heavily called
+// functions ranks above one called from many leaves. Calls to or from
+// functions not in ids are ignored. Results are in the order of ids.
+func ComputeCentrality(ids []string, calls []models.CallRelation) []Centrality {
+	index := make(map[string]int, len(ids))
+	for i, id := range ids {
+		index[id] = i
+	}
+
+	scores := make([]Centrality, len(ids))
+	for i, id := range ids {
+		scores[i].ID = id
+	}
+	if len(ids) == 0 {
+		return scores
+	}
+
+	// Callees of each function, deduplicated
+	callees := make([][]int, len(ids))
+	seen := make(map[[2]int]bool, len(calls))
+	for _, call := range calls {
+		from, ok := index[call.CallerID]
+		if !ok {
+			continue
+		}
+		to, ok := index[call.CalleeID]
+		if !ok || seen[[2]int{from, to}] {
+			continue
+		}
+		seen[[2]int{from, to}] = true
+		callees[from] = append(callees[from], to)
+		scores[from].OutDegree++
+		scores[to].InDegree++
+	}
+
+	n := float64(len(ids))
+	rank := make([]float64, len(ids))
+	for i := range rank {
+		rank[i] = 1 / n
+	}
+	next := make([]float64, len(ids))
+	for iter := 0; iter < pageRankIterations; iter++ {
+		// Functions calling nothing spread their rank over every function
+		dangling := 0.0
+		for i, out := range callees {
+			if len(out) == 0 {
+				dangling += rank[i]
+			}
+		}
+		base := (1-pageRankDamping)/n + pageRankDamping*dangling/n
+		for i := range next {
+			next[i] = base
+		}
+		for i, out := range callees {
+			share := pageRankDamping * rank[i] / float64(len(out))
+			for _, j := range out {
+				next[j] += share
+			}
+		}
+
+		delta := 0.0
+		for i := range rank {
+			delta += math.Abs(next[i] - rank[i])
+		}
+		rank, next = next, rank
+		if delta < pageRankTolerance {
+			break
+		}
+	}
+
+	for i := range scores {
+		scores[i].PageRank = rank[i]
+	}
+	return scores
+}
@@ -0,0 +1,63 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+func TestComputeCentrality(t *testing.T) {
+	// main and serve both call handle; handle calls parse; log is called by
+	// nobody and calls nothing
+	ids := []string{"main", "serve", "handle", "parse", "log"}
+	calls := []models.CallRelation{
+		{CallerID: "main", CalleeID: "handle"},
+		{CallerID: "serve", CalleeID: "handle"},
+		{CallerID: "serve", CalleeID: "handle"}, // duplicate edge counts once
+		{CallerID: "handle", CalleeID: "parse"},
+		{CallerID: "handle", CalleeID: "external"},
+	}
+
+	scores := ComputeCentrality(ids, calls)
+	if len(scores) != len(ids) {
+		t.Fatalf("got %d scores, want %d", len(scores), len(ids))
+	}
+
+	byID := make(map[string]Centrality)
+	total := 0.0
+	for i, s := range scores {
+		if s.ID != ids[i] {
+			t.Errorf("score %d is for %s, want %s", i, s.ID, ids[i])
+		}
+		byID[s.ID] = s
+		total += s.PageRank
+	}
+	if math.Abs(total-1) > 1e-6 {
+		t.Errorf("PageRank sums to %f, want 1", total)
+	}
+
+	if got := byID["handle"]; got.InDegree != 2 || got.OutDegree != 1 {
+		t.Errorf("handle degree = in %d out %d, want in 2 out 1", got.InDegree, got.OutDegree)
+	}
+	if got := byID["serve"]; got.InDegree != 0 || got.OutDegree != 1 {
+		t.Errorf("serve degree = in %d out %d, want in 0 out 1", got.InDegree, got.OutDegree)
+	}
+
+	// parse is only called by handle, but inherits its rank
+	if byID["parse"].PageRank <= byID["handle"].PageRank {
+		t.Errorf("parse rank %f should exceed handle rank %f", byID["parse"].PageRank, byID["handle"].PageRank)
+	}
+	if byID["handle"].PageRank <= byID["main"].PageRank {
+		t.Errorf("handle rank %f should exceed main rank %f", byID["handle"].PageRank, byID["main"].PageRank)
+	}
+	if byID["main"].PageRank != byID["log"].PageRank {
+		t.Errorf("uncalled functions should rank equally, got main %f and log %f", byID["main"].PageRank, byID["log"].PageRank)
+	}
+}
+
+func TestComputeCentralityEmpty(t *testing.T) {
+	if scores := ComputeCentrality(nil, nil); len(scores) != 0 {
+		t.Errorf("expected no scores, got %v", scores)
+	}
+}
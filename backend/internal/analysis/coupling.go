@@ -0,0 +1,69 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+)
+
+// Coupling is how many distinct functions call a function and how many it
+// calls
+type Coupling struct {
+	ID     string
+	FanIn  int
+	FanOut int
+}
+
+// CouplingOutliers are the functions whose fan-in or fan-out is unusually
+// high for their repository
+type CouplingOutliers struct {
+	FanInFence  float64 // fan-in above this is an outlier
+	FanOutFence float64 // fan-out above this is an outlier
+	Outliers    []Coupling
+}
+
+// FindCouplingOutliers flags functions whose fan-in or fan-out lies above
+// the upper Tukey fence, Q3 + 1.5 * IQR, of that count over all functions.
+// Call graphs are heavily skewed, which the fence tolerates where a bound
+// based on the standard deviation would not. Outliers are ordered by how far
+// past a fence they are relative to it, furthest first.
+func FindCouplingOutliers(functions []Coupling) CouplingOutliers {
+	fanIn := make([]int, len(functions))
+	fanOut := make([]int, len(functions))
+	for i, fn := range functions {
+		fanIn[i], fanOut[i] = fn.FanIn, fn.FanOut
+	}
+	result := CouplingOutliers{FanInFence: upperFence(fanIn), FanOutFence: upperFence(fanOut)}
+
+	excess := func(value int, fence float64) float64 {
+		return (float64(value) - fence) / math.Max(fence, 1)
+	}
+	score := make(map[string]float64)
+	for _, fn := range functions {
+		if float64(fn.FanIn) > result.FanInFence || float64(fn.FanOut) > result.FanOutFence {
+			score[fn.ID] = math.Max(excess(fn.FanIn, result.FanInFence), excess(fn.FanOut, result.FanOutFence))
+			result.Outliers = append(result.Outliers, fn)
+		}
+	}
+	sort.SliceStable(result.Outliers, func(i, j int) bool {
+		return score[result.Outliers[i].ID] > score[result.Outliers[j].ID]
+	})
+	return result
+}
+
+// upperFence returns Q3 + 1.5 * IQR of values, with quartiles interpolated
+// between the closest ranks; 0 without values
+func upperFence(values []int) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	quartile := func(q float64) float64 {
+		pos := q * float64(len(sorted)-1)
+		lo := int(math.Floor(pos))
+		hi := int(math.Ceil(pos))
+		return float64(sorted[lo]) + (pos-float64(lo))*float64(sorted[hi]-sorted[lo])
+	}
+	q1, q3 := quartile(0.25), quartile(0.75)
+	return q3 + 1.5*(q3-q1)
+}
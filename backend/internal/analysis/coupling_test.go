@@ -0,0 +1,37 @@
+package analysis
+
+import "testing"
+
+func TestFindCouplingOutliers(t *testing.T) {
+	// Nine ordinary functions and two outliers: a utility everything calls
+	// and a coordinator calling everything
+	functions := []Coupling{
+		{ID: "a", FanIn: 1, FanOut: 1},
+		{ID: "b", FanIn: 2, FanOut: 1},
+		{ID: "c", FanIn: 1, FanOut: 2},
+		{ID: "d", FanIn: 0, FanOut: 1},
+		{ID: "e", FanIn: 1, FanOut: 0},
+		{ID: "f", FanIn: 2, FanOut: 2},
+		{ID: "g", FanIn: 1, FanOut: 1},
+		{ID: "h", FanIn: 3, FanOut: 1},
+		{ID: "i", FanIn: 1, FanOut: 3},
+		{ID: "util", FanIn: 9, FanOut: 0},
+		{ID: "coordinator", FanIn: 1, FanOut: 20},
+	}
+
+	got := FindCouplingOutliers(functions)
+	// Quartiles of fan-in are 1 and 2
+	if got.FanInFence != 3.5 {
+		t.Errorf("fan-in fence is %v, want 3.5", got.FanInFence)
+	}
+	if len(got.Outliers) != 2 {
+		t.Fatalf("got %d outliers, want 2: %+v", len(got.Outliers), got.Outliers)
+	}
+	if got.Outliers[0].ID != "coordinator" || got.Outliers[1].ID != "util" {
+		t.Errorf("outliers are %s, %s, want coordinator then util", got.Outliers[0].ID, got.Outliers[1].ID)
+	}
+
+	if got := FindCouplingOutliers(nil); got.FanInFence != 0 || len(got.Outliers) != 0 {
+		t.Errorf("no functions gave %+v", got)
+	}
+}
@@ -0,0 +1,120 @@
+package analysis
+
+import (
+	"path"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// ExternalCall is a call into an imported package that lives outside the
+// repository. Calls on an imported class carry its name in ClassName.
+type ExternalCall struct {
+	CallerID   string
+	ImportPath string
+	ClassName  string
+	Name       string
+}
+
+// ExternalCalls lists the calls of each entity that go through an import
+// resolving outside the repository, so they can later be linked to another
+// indexed repository providing that import. Entities must have IDs.
+func ExternalCalls(files []*models.File, entities []models.CodeEntity) []ExternalCall {
+	r := newCallResolver(files, entities)
+
+	var calls []ExternalCall
+	for i := range entities {
+		caller := &entities[i]
+		if caller.ID == "" {
+			continue
+		}
+		callerFile := filepathToSlash(caller.FilePath)
+		scope := r.scopes[callerFile]
+		if scope == nil {
+			continue
+		}
+		external := func(importPath string) bool {
+			_, inRepo := ResolveImportDir(importPath, callerFile, scope.language, r.dirs)
+			return !inRepo
+		}
+
+		seen := make(map[ExternalCall]bool)
+		names, _ := callSitesByName(caller)
+		for _, call := range names {
+			name, qualifier := splitCall(call)
+			if name == "" {
+				continue
+			}
+
+			var ext ExternalCall
+			if qualifier == "" {
+				imp, ok := scope.symbols[name]
+				if !ok || !external(imp.ImportPath) {
+					continue
+				}
+				ext = ExternalCall{ImportPath: imp.ImportPath, Name: imp.Symbol}
+			} else if importPath, ok := scope.namespaces[qualifier]; ok {
+				if !external(importPath) {
+					continue
+				}
+				ext = ExternalCall{ImportPath: importPath, Name: name}
+			} else if imp, ok := scope.symbols[qualifier]; ok {
+				if !external(imp.ImportPath) {
+					continue
+				}
+				ext = ExternalCall{ImportPath: imp.ImportPath, ClassName: imp.Symbol, Name: name}
+			} else {
+				continue
+			}
+
+			ext.CallerID = caller.ID
+			if !seen[ext] {
+				seen[ext] = true
+				calls = append(calls, ext)
+			}
+		}
+	}
+	return calls
+}
+
+// ModuleDirs maps an import of another repository's module to the
+// directories, relative to that repository, whose entities the import may
+// refer to, most specific first. ok is false when the import is not part of
+// the module.
+func ModuleDirs(importPath, module, language string) ([]string, bool) {
+	if module == "" {
+		return nil, false
+	}
+
+	switch language {
+	case "python":
+		// Python packages sit in a directory named after the module
+		if importPath != module && !strings.HasPrefix(importPath, module+".") {
+			return nil, false
+		}
+		dir := strings.ReplaceAll(importPath, ".", "/")
+		if parent := path.Dir(dir); parent != "." {
+			return []string{dir, parent}, true
+		}
+		return []string{dir}, true
+	case "java", "kotlin":
+		// Modules are only detected from go.mod, package.json and pyproject.toml
+		return nil, false
+	}
+
+	var sub string
+	switch {
+	case importPath == module:
+		sub = "."
+	case strings.HasPrefix(importPath, module+"/"):
+		sub = strings.TrimPrefix(importPath, module+"/")
+	default:
+		return nil, false
+	}
+
+	if language == "typescript" || language == "javascript" {
+		// Packages commonly keep their sources under src/
+		return []string{sub, path.Join("src", sub)}, true
+	}
+	return []string{sub}, true
+}
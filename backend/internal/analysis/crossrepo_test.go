@@ -0,0 +1,67 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+func TestExternalCalls(t *testing.T) {
+	files := []*models.File{
+		{Path: "cmd/main.go", Language: "go", Imports: []models.ImportRelation{
+			{ImportPath: "github.com/acme/billing/invoice"},
+			{ImportPath: "github.com/acme/app/internal/store"},
+		}},
+		{Path: "internal/store/store.go", Language: "go"},
+		{Path: "app/service.py", Language: "python", Imports: []models.ImportRelation{
+			{ImportPath: "payments.client", Symbol: "charge"},
+			{ImportPath: "payments.models", Symbol: "Card"},
+		}},
+	}
+
+	entities := []models.CodeEntity{
+		{ID: "main", Type: models.EntityFunction, Name: "main", FilePath: "cmd/main.go",
+			Calls: []string{"invoice.Create", "store.New", "invoice.Create", "helper"}},
+		{ID: "store.New", Type: models.EntityFunction, Name: "New", FilePath: "internal/store/store.go"},
+		{ID: "svc.pay", Type: models.EntityFunction, Name: "pay", FilePath: "app/service.py",
+			CallSites: []models.CallSite{{Name: "charge", Line: 3}, {Name: "Card.load", Line: 4}}},
+	}
+
+	got := ExternalCalls(files, entities)
+	expected := []ExternalCall{
+		{CallerID: "main", ImportPath: "github.com/acme/billing/invoice", Name: "Create"},
+		{CallerID: "svc.pay", ImportPath: "payments.client", Name: "charge"},
+		{CallerID: "svc.pay", ImportPath: "payments.models", ClassName: "Card", Name: "load"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ExternalCalls:\n got %+v\nwant %+v", got, expected)
+	}
+}
+
+func TestModuleDirs(t *testing.T) {
+	tests := []struct {
+		importPath string
+		module     string
+		language   string
+		dirs       []string
+		ok         bool
+	}{
+		{"github.com/acme/billing", "github.com/acme/billing", "go", []string{"."}, true},
+		{"github.com/acme/billing/invoice", "github.com/acme/billing", "go", []string{"invoice"}, true},
+		{"github.com/acme/billingx", "github.com/acme/billing", "go", nil, false},
+		{"payments.client", "payments", "python", []string{"payments/client", "payments"}, true},
+		{"payments", "payments", "python", []string{"payments"}, true},
+		{"paymentsx.client", "payments", "python", nil, false},
+		{"@acme/ui/button", "@acme/ui", "typescript", []string{"button", "src/button"}, true},
+		{"com.acme.Billing", "com.acme", "java", nil, false},
+	}
+
+	for _, tt := range tests {
+		dirs, ok := ModuleDirs(tt.importPath, tt.module, tt.language)
+		if ok != tt.ok || !reflect.DeepEqual(dirs, tt.dirs) {
+			t.Errorf("ModuleDirs(%q, %q, %q) = %v, %v; want %v, %v",
+				tt.importPath, tt.module, tt.language, dirs, ok, tt.dirs, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package analysis
+
+import (
+	"sort"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// FindCallCycles returns the strongly connected components of the call
+// graph that contain a cycle: groups of functions that all reach each other
+// through calls, and functions calling themselves. Each group lists its
+// function IDs sorted; groups are largest first.
+func FindCallCycles(calls []models.CallRelation) [][]string {
+	callees := make(map[string][]string)
+	selfCalls := make(map[string]bool)
+	var ids []string
+	addNode := func(id string) {
+		if _, ok := callees[id]; !ok {
+			callees[id] = nil
+			ids = append(ids, id)
+		}
+	}
+	for _, call := range calls {
+		addNode(call.CallerID)
+		addNode(call.CalleeID)
+		callees[call.CallerID] = append(callees[call.CallerID], call.CalleeID)
+		if call.CallerID == call.CalleeID {
+			selfCalls[call.CallerID] = true
+		}
+	}
+
+	// Tarjan's algorithm, iterative so deep call chains cannot overflow the
+	// stack
+	index := make(map[string]int, len(ids))
+	lowlink := make(map[string]int, len(ids))
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+
+	type frame struct {
+		id   string
+		next int // index of the next callee to visit
+	}
+	for _, root := range ids {
+		if _, visited := index[root]; visited {
+			continue
+		}
+		work := []frame{{id: root}}
+		index[root], lowlink[root] = len(index), len(index)
+		stack = append(stack, root)
+		onStack[root] = true
+
+		for len(work) > 0 {
+			top := &work[len(work)-1]
+			if top.next < len(callees[top.id]) {
+				callee := callees[top.id][top.next]
+				top.next++
+				if _, visited := index[callee]; !visited {
+					index[callee], lowlink[callee] = len(index), len(index)
+					stack = append(stack, callee)
+					onStack[callee] = true
+					work = append(work, frame{id: callee})
+				} else if onStack[callee] {
+					lowlink[top.id] = min(lowlink[top.id], index[callee])
+				}
+				continue
+			}
+
+			id := top.id
+			work = work[:len(work)-1]
+			if len(work) > 0 {
+				parent := work[len(work)-1].id
+				lowlink[parent] = min(lowlink[parent], lowlink[id])
+			}
+			if lowlink[id] != index[id] {
+				continue
+			}
+
+			var component []string
+			for {
+				member := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[member] = false
+				component = append(component, member)
+				if member == id {
+					break
+				}
+			}
+			if len(component) > 1 || selfCalls[id] {
+				sort.Strings(component)
+				cycles = append(cycles, component)
+			}
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool {
+		if len(cycles[i]) != len(cycles[j]) {
+			return len(cycles[i]) > len(cycles[j])
+		}
+		return cycles[i][0] < cycles[j][0]
+	})
+	return cycles
+}
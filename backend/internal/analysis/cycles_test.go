@@ -0,0 +1,61 @@
+package analysis
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+func TestFindCallCycles(t *testing.T) {
+	calls := []models.CallRelation{
+		// a -> b -> c -> a, with d hanging off the cycle
+		{CallerID: "a", CalleeID: "b"},
+		{CallerID: "b", CalleeID: "c"},
+		{CallerID: "c", CalleeID: "a"},
+		{CallerID: "c", CalleeID: "d"},
+		// mutual recursion
+		{CallerID: "even", CalleeID: "odd"},
+		{CallerID: "odd", CalleeID: "even"},
+		// direct recursion
+		{CallerID: "walk", CalleeID: "walk"},
+		// no cycle
+		{CallerID: "main", CalleeID: "a"},
+		{CallerID: "main", CalleeID: "walk"},
+	}
+
+	want := [][]string{
+		{"a", "b", "c"},
+		{"even", "odd"},
+		{"walk"},
+	}
+	if got := FindCallCycles(calls); !reflect.DeepEqual(got, want) {
+		t.Errorf("FindCallCycles() = %v, want %v", got, want)
+	}
+}
+
+func TestFindCallCyclesDeepChain(t *testing.T) {
+	// A long chain closing back on itself must not overflow the stack
+	const n = 100000
+	calls := make([]models.CallRelation, n)
+	for i := 0; i < n; i++ {
+		calls[i] = models.CallRelation{CallerID: fmt.Sprint(i), CalleeID: fmt.Sprint((i + 1) % n)}
+	}
+
+	cycles := FindCallCycles(calls)
+	if len(cycles) != 1 || len(cycles[0]) != n {
+		t.Fatalf("expected one cycle of %d functions, got %d cycles", n, len(cycles))
+	}
+}
+
+func TestFindCallCyclesAcyclic(t *testing.T) {
+	calls := []models.CallRelation{
+		{CallerID: "a", CalleeID: "b"},
+		{CallerID: "b", CalleeID: "c"},
+		{CallerID: "a", CalleeID: "c"},
+	}
+	if got := FindCallCycles(calls); len(got) != 0 {
+		t.Errorf("expected no cycles, got %v", got)
+	}
+}
@@ -0,0 +1,323 @@
+package analysis
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// CodeFunction is a function or method considered by the dead code report
+type CodeFunction struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	FilePath  string `json:"filePath"`
+	StartLine int    `json:"startLine,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	// Used outside this repository's CALLS graph: called from another
+	// repository or seen at runtime
+	UsedElsewhere bool `json:"-"`
+	// A method of a class found implementing an interface, which may be
+	// called through the interface
+	Implements bool `json:"-"`
+}
+
+// UnreferencedFile is a file nothing else in the repository imports or calls
+type UnreferencedFile struct {
+	Path     string `json:"path"`
+	Language string `json:"language"`
+}
+
+// DeadCodeReport lists dead code candidates of a repository
+type DeadCodeReport struct {
+	Functions []CodeFunction     `json:"functions"` // exported but never called
+	Files     []UnreferencedFile `json:"files"`
+}
+
+// entryPointFiles are base names of files run or loaded by convention
+var entryPointFiles = map[string]bool{
+	"main.go":     true,
+	"__init__.py": true,
+	"__main__.py": true,
+	"main.py":     true,
+	"setup.py":    true,
+	"manage.py":   true,
+	"conftest.py": true,
+	"wsgi.py":     true,
+	"asgi.py":     true,
+	"index.ts":    true,
+	"index.tsx":   true,
+	"index.js":    true,
+	"index.jsx":   true,
+	"main.ts":     true,
+	"main.tsx":    true,
+	"main.js":     true,
+	"main.kt":     true,
+}
+
+// interfaceMethods are method names commonly called only through a
+// standard interface or protocol, such as fmt.Stringer or Object.toString
+var interfaceMethods = map[string]bool{
+	"String": true, "Error": true, "Unwrap": true, "ServeHTTP": true,
+	"MarshalJSON": true, "UnmarshalJSON": true, "MarshalText": true, "UnmarshalText": true,
+	"Len": true, "Less": true, "Swap": true, "Read": true, "Write": true, "Close": true,
+	"Scan": true, "Value": true,
+	"toString": true, "equals": true, "hashCode": true, "compareTo": true,
+	"run": true, "call": true, "close": true, "constructor": true, "render": true, "toJSON": true,
+}
+
+// tsExtensions are tried, in order, when resolving relative TS/JS imports
+var tsExtensions = []string{".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs"}
+
+// FindDeadCode reports exported functions and methods nothing calls, and
+// files no other file imports or calls into. Test files, entry points and
+// methods likely called through an interface are left out; includeTests
+// also reports the helpers of test files nothing calls, though never the
+// tests the runner calls. The CALLS graph misses dynamic dispatch and
+// reflection, so the results are candidates to review rather than code safe
+// to delete.
+func FindDeadCode(files []FileLayer, functions []CodeFunction, calls []models.CallRelation, includeTests bool) *DeadCodeReport {
+	report := &DeadCodeReport{Functions: []CodeFunction{}, Files: []UnreferencedFile{}}
+
+	fileOf := make(map[string]string, len(functions))
+	for _, fn := range functions {
+		fileOf[fn.ID] = fn.FilePath
+	}
+
+	called := make(map[string]bool)
+	referenced := make(map[string]bool) // file paths used from another file
+	for _, call := range calls {
+		if call.CallerID == call.CalleeID {
+			continue // recursion alone does not keep a function alive
+		}
+		called[call.CalleeID] = true
+		if from, to := fileOf[call.CallerID], fileOf[call.CalleeID]; to != "" && from != to {
+			referenced[to] = true
+		}
+	}
+	for _, targets := range fileImports(files) {
+		for _, file := range targets {
+			referenced[file] = true
+		}
+	}
+
+	entryFiles := make(map[string]bool)
+	for _, fn := range functions {
+		language := models.DetectLanguage(fn.FilePath)
+		if isEntryPoint(fn, language) {
+			entryFiles[fn.FilePath] = true
+		}
+		if called[fn.ID] || fn.UsedElsewhere || fn.Implements ||
+			isEntryPoint(fn, language) || isImplicitlyCalled(fn, language) || !isExported(fn, language) {
+			continue
+		}
+		if IsTestFile(fn.FilePath) && (!includeTests || isTestFunction(fn, language)) {
+			continue
+		}
+		report.Functions = append(report.Functions, fn)
+	}
+
+	for _, f := range files {
+		p := filepathToSlash(f.Path)
+		if referenced[p] || entryFiles[p] || IsTestFile(p) || isEntryPointFile(p) {
+			continue
+		}
+		report.Files = append(report.Files, UnreferencedFile{Path: f.Path, Language: f.Language})
+	}
+
+	sort.Slice(report.Functions, func(i, j int) bool {
+		a, b := report.Functions[i], report.Functions[j]
+		if a.FilePath != b.FilePath {
+			return a.FilePath < b.FilePath
+		}
+		return a.StartLine < b.StartLine
+	})
+	sort.Slice(report.Files, func(i, j int) bool {
+		return report.Files[i].Path < report.Files[j].Path
+	})
+	return report
+}
+
+// fileImports maps each file to the other repository files its imports
+// refer to. Go, Java and Kotlin import whole packages, so every file of an
+// imported directory counts; relative TS/JS imports and Python modules name
+// single files.
+func fileImports(files []FileLayer) map[string][]string {
+	paths := make(map[string]bool, len(files))
+	byDir := make(map[string][]string)
+	for _, f := range files {
+		p := filepathToSlash(f.Path)
+		paths[p] = true
+		byDir[path.Dir(p)] = append(byDir[path.Dir(p)], p)
+	}
+	dirs := make(map[string]bool, len(byDir))
+	for dir := range byDir {
+		dirs[dir] = true
+	}
+
+	imports := make(map[string][]string)
+	for _, f := range files {
+		from := filepathToSlash(f.Path)
+		for _, imp := range f.Imports {
+			var targets []string
+			switch f.Language {
+			case "typescript", "javascript":
+				if file := tsModuleFile(imp, from, paths); file != "" {
+					targets = []string{file}
+				}
+			case "python":
+				file := pythonModuleFile(imp, from, paths)
+				if file != "" {
+					targets = []string{file}
+				}
+				// from pkg import module names files inside the package
+				if file == "" || path.Base(file) == "__init__.py" {
+					if dir, ok := ResolveImportDir(imp, from, f.Language, dirs); ok {
+						targets = append(targets, byDir[dir]...)
+					}
+				}
+			default:
+				if dir, ok := ResolveImportDir(imp, from, f.Language, dirs); ok {
+					targets = byDir[dir]
+				}
+			}
+			for _, target := range targets {
+				if target != from {
+					imports[from] = append(imports[from], target)
+				}
+			}
+		}
+	}
+	return imports
+}
+
+// tsModuleFile finds the repository file a relative TS/JS import refers
+// to, or ""
+func tsModuleFile(importPath, fromFile string, paths map[string]bool) string {
+	if !strings.HasPrefix(importPath, ".") {
+		return ""
+	}
+	candidate := path.Join(path.Dir(fromFile), importPath)
+	if paths[candidate] {
+		return candidate
+	}
+	// "./util.js" may name util.ts when compiled from TypeScript
+	stem := strings.TrimSuffix(candidate, path.Ext(candidate))
+	for _, base := range []string{candidate, stem, candidate + "/index"} {
+		for _, ext := range tsExtensions {
+			if paths[base+ext] {
+				return base + ext
+			}
+		}
+	}
+	return ""
+}
+
+// IsTestFile reports whether a path looks like a test file in any of the
+// indexed languages
+func IsTestFile(filePath string) bool {
+	p := filepathToSlash(filePath)
+	base := path.Base(p)
+	switch {
+	case strings.HasSuffix(base, "_test.go"),
+		strings.HasPrefix(base, "test_") && strings.HasSuffix(base, ".py"),
+		strings.HasSuffix(base, "_test.py"),
+		strings.Contains(base, ".test."), strings.Contains(base, ".spec."),
+		strings.HasSuffix(base, "Test.java"), strings.HasSuffix(base, "Tests.java"),
+		strings.HasSuffix(base, "Test.kt"), strings.HasSuffix(base, "Tests.kt"):
+		return true
+	}
+	for _, segment := range strings.Split(path.Dir(p), "/") {
+		if segment == "__tests__" || segment == "tests" || segment == "test" || segment == "testdata" {
+			return true
+		}
+	}
+	return false
+}
+
+// isEntryPointFile reports whether a file is run or loaded by convention
+// rather than imported, including declaration and build config files
+func isEntryPointFile(p string) bool {
+	base := path.Base(p)
+	return entryPointFiles[base] || strings.HasSuffix(base, ".d.ts") ||
+		strings.Contains(base, ".config.")
+}
+
+// isEntryPoint reports whether a function is run by a runtime or framework,
+// which makes its file an entry point too
+func isEntryPoint(fn CodeFunction, language string) bool {
+	switch {
+	case fn.Name == "main":
+		return true
+	case language == "go" && fn.Name == "init" && fn.Type == string(models.EntityFunction):
+		return true
+	case strings.HasPrefix(strings.TrimSpace(fn.Signature), "@"):
+		return true // annotated or decorated: @GetMapping, @app.route
+	}
+	return false
+}
+
+// isTestFunction reports whether a function of a test file is a test the
+// test runner calls by its name
+func isTestFunction(fn CodeFunction, language string) bool {
+	switch language {
+	case "go":
+		for _, prefix := range []string{"Test", "Benchmark", "Example", "Fuzz"} {
+			if strings.HasPrefix(fn.Name, prefix) {
+				return true
+			}
+		}
+	case "python", "java", "kotlin":
+		return strings.HasPrefix(fn.Name, "test")
+	}
+	return false
+}
+
+// isImplicitlyCalled reports whether a method is called by the language
+// itself, like Python's __init__ or Go's String through fmt.Stringer
+func isImplicitlyCalled(fn CodeFunction, language string) bool {
+	if language == "python" && strings.HasPrefix(fn.Name, "__") && strings.HasSuffix(fn.Name, "__") {
+		return true
+	}
+	return fn.Type == string(models.EntityMethod) && interfaceMethods[fn.Name]
+}
+
+// isExported reports whether a function is visible outside its package or
+// module. TS/JS signatures do not include the export keyword, so every
+// function that is not private counts.
+func isExported(fn CodeFunction, language string) bool {
+	switch language {
+	case "go":
+		r, _ := utf8.DecodeRuneInString(fn.Name)
+		return unicode.IsUpper(r)
+	case "python":
+		return !strings.HasPrefix(fn.Name, "_")
+	case "java":
+		return hasModifier(fn.Signature, "public")
+	case "kotlin":
+		return !hasModifier(fn.Signature, "private", "internal", "protected")
+	case "typescript", "javascript":
+		return !strings.HasPrefix(fn.Name, "#") && !hasModifier(fn.Signature, "private", "protected")
+	}
+	return false
+}
+
+// hasModifier reports whether any of modifiers appears as a word before the
+// parameter list of a signature
+func hasModifier(signature string, modifiers ...string) bool {
+	if i := strings.Index(signature, "("); i >= 0 {
+		signature = signature[:i]
+	}
+	for _, word := range strings.Fields(signature) {
+		for _, m := range modifiers {
+			if word == m {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+func TestFindDeadCode(t *testing.T) {
+	files := []FileLayer{
+		{Path: "cmd/server/main.go", Language: "go", Imports: []string{"github.com/acme/app/internal/store"}},
+		{Path: "internal/store/store.go", Language: "go"},
+		{Path: "internal/store/cache.go", Language: "go"},
+		{Path: "internal/legacy/old.go", Language: "go"},
+		{Path: "internal/legacy/old_test.go", Language: "go"},
+		{Path: "web/src/app.ts", Language: "typescript", Imports: []string{"./util", "react"}},
+		{Path: "web/src/util.ts", Language: "typescript"},
+		{Path: "web/src/unused.ts", Language: "typescript"},
+		{Path: "web/src/index.ts", Language: "typescript", Imports: []string{"./app.js"}},
+		{Path: "pkg/service.py", Language: "python", Imports: []string{"pkg.models"}},
+		{Path: "pkg/models.py", Language: "python"},
+		{Path: "pkg/orphan.py", Language: "python"},
+	}
+	functions := []CodeFunction{
+		{ID: "main", Name: "main", Type: "Function", FilePath: "cmd/server/main.go", StartLine: 5},
+		{ID: "open", Name: "Open", Type: "Function", FilePath: "internal/store/store.go", StartLine: 3},
+		{ID: "get", Name: "Get", Type: "Method", FilePath: "internal/store/store.go", StartLine: 10},
+		{ID: "unusedExported", Name: "Purge", Type: "Method", FilePath: "internal/store/cache.go", StartLine: 20},
+		{ID: "unusedPrivate", Name: "purge", Type: "Function", FilePath: "internal/store/cache.go", StartLine: 30},
+		{ID: "stringer", Name: "String", Type: "Method", FilePath: "internal/store/cache.go", StartLine: 40},
+		{ID: "recursive", Name: "Walk", Type: "Function", FilePath: "internal/legacy/old.go", StartLine: 1},
+		{ID: "remote", Name: "Export", Type: "Function", FilePath: "internal/legacy/old.go", StartLine: 9, UsedElsewhere: true},
+		{ID: "test", Name: "TestWalk", Type: "Function", FilePath: "internal/legacy/old_test.go", StartLine: 1},
+		{ID: "fixture", Name: "NewFixture", Type: "Function", FilePath: "internal/legacy/old_test.go", StartLine: 20},
+		{ID: "format", Name: "format", Type: "Function", FilePath: "web/src/util.ts", StartLine: 1},
+		{ID: "secret", Name: "secret", Type: "Method", FilePath: "web/src/util.ts", StartLine: 8, Signature: "private secret()"},
+		{ID: "handler", Name: "handle", Type: "Method", FilePath: "web/src/unused.ts", StartLine: 3, Signature: "@Get('/') handle()"},
+		{ID: "init", Name: "__init__", Type: "Method", FilePath: "pkg/models.py", StartLine: 2},
+		{ID: "helper", Name: "_helper", Type: "Function", FilePath: "pkg/orphan.py", StartLine: 1},
+		{ID: "report", Name: "report", Type: "Function", FilePath: "pkg/orphan.py", StartLine: 5},
+	}
+	calls := []models.CallRelation{
+		{CallerID: "main", CalleeID: "open"},
+		{CallerID: "open", CalleeID: "get"},
+		{CallerID: "recursive", CalleeID: "recursive"},
+	}
+
+	report := FindDeadCode(files, functions, calls, false)
+
+	var gotFunctions []string
+	for _, fn := range report.Functions {
+		gotFunctions = append(gotFunctions, fn.ID)
+	}
+	wantFunctions := []string{"recursive", "unusedExported", "report", "format"}
+	if !reflect.DeepEqual(gotFunctions, wantFunctions) {
+		t.Errorf("functions = %v, want %v", gotFunctions, wantFunctions)
+	}
+
+	var gotFiles []string
+	for _, f := range report.Files {
+		gotFiles = append(gotFiles, f.Path)
+	}
+	// The legacy package is imported by no one; unused.ts is kept alive by
+	// its decorated handler; orphan.py is imported by no one
+	wantFiles := []string{"internal/legacy/old.go", "pkg/orphan.py", "pkg/service.py"}
+	if !reflect.DeepEqual(gotFiles, wantFiles) {
+		t.Errorf("files = %v, want %v", gotFiles, wantFiles)
+	}
+
+	// Test helpers nothing calls are reported with tests included, the
+	// tests themselves never
+	gotFunctions = nil
+	for _, fn := range FindDeadCode(files, functions, calls, true).Functions {
+		gotFunctions = append(gotFunctions, fn.ID)
+	}
+	wantFunctions = []string{"recursive", "fixture", "unusedExported", "report", "format"}
+	if !reflect.DeepEqual(gotFunctions, wantFunctions) {
+		t.Errorf("functions with tests = %v, want %v", gotFunctions, wantFunctions)
+	}
+}
+
+func TestIsTestFile(t *testing.T) {
+	for p, want := range map[string]bool{
+		"pkg/store_test.go":            true,
+		"tests/conftest_helpers.py":    true,
+		"app/test_views.py":            true,
+		"web/src/Button.test.tsx":      true,
+		"web/src/__tests__/Button.tsx": true,
+		"src/test/java/a/FooTest.java": true,
+		"pkg/store.go":                 false,
+		"app/views.py":                 false,
+		"web/src/testing.ts":           false,
+	} {
+		if got := IsTestFile(p); got != want {
+			t.Errorf("IsTestFile(%q) = %v, want %v", p, got, want)
+		}
+	}
+}
+
+func TestTSModuleFile(t *testing.T) {
+	paths := map[string]bool{
+		"src/util.ts":             true,
+		"src/components/index.ts": true,
+		"src/styles.css":          true,
+	}
+	tests := []struct{ imp, want string }{
+		{"./util", "src/util.ts"},
+		{"./util.js", "src/util.ts"},
+		{"./components", "src/components/index.ts"},
+		{"./styles.css", "src/styles.css"},
+		{"react", ""},
+		{"./missing", ""},
+	}
+	for _, tt := range tests {
+		if got := tsModuleFile(tt.imp, "src/app.ts", paths); got != tt.want {
+			t.Errorf("tsModuleFile(%q) = %q, want %q", tt.imp, got, tt.want)
+		}
+	}
+}
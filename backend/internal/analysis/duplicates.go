@@ -0,0 +1,107 @@
+package analysis
+
+import (
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// SimilarPair is two functions whose embeddings are close enough for them
+// to possibly duplicate each other
+type SimilarPair struct {
+	A, B  string  // IDs, A before B
+	Score float64 // cosine similarity of their embeddings
+}
+
+// FindSimilarPairs compares the vectors of every pair of ids by cosine
+// similarity and returns the pairs scoring at least threshold, most similar
+// first, keeping at most maxPairs (0 keeps all). Zero vectors and vectors of
+// another length than the first are skipped. Rows are compared in parallel,
+// as a repository's functions make for millions of pairs.
+func FindSimilarPairs(ids []string, vectors [][]float32, threshold float64, maxPairs int) []SimilarPair {
+	type unit struct {
+		id     string
+		vector []float32
+	}
+	var units []unit
+	for i, id := range ids {
+		if i >= len(vectors) || len(vectors[i]) == 0 {
+			continue
+		}
+		if len(units) > 0 && len(vectors[i]) != len(units[0].vector) {
+			continue
+		}
+		if v := normalize(vectors[i]); v != nil {
+			units = append(units, unit{id, v})
+		}
+	}
+
+	workers := min(runtime.GOMAXPROCS(0), max(len(units)/64, 1))
+	found := make([][]SimilarPair, workers)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Rows are striped so the shrinking triangle is shared evenly
+			for i := w; i < len(units); i += workers {
+				for j := i + 1; j < len(units); j++ {
+					score := dot(units[i].vector, units[j].vector)
+					if score < threshold {
+						continue
+					}
+					a, b := units[i].id, units[j].id
+					if b < a {
+						a, b = b, a
+					}
+					found[w] = append(found[w], SimilarPair{A: a, B: b, Score: score})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var pairs []SimilarPair
+	for _, f := range found {
+		pairs = append(pairs, f...)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Score != pairs[j].Score {
+			return pairs[i].Score > pairs[j].Score
+		}
+		if pairs[i].A != pairs[j].A {
+			return pairs[i].A < pairs[j].A
+		}
+		return pairs[i].B < pairs[j].B
+	})
+	if maxPairs > 0 && len(pairs) > maxPairs {
+		pairs = pairs[:maxPairs]
+	}
+	return pairs
+}
+
+// normalize returns v scaled to unit length, or nil for a zero vector
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return nil
+	}
+	norm := math.Sqrt(sum)
+	unit := make([]float32, len(v))
+	for i, x := range v {
+		unit[i] = float32(float64(x) / norm)
+	}
+	return unit
+}
+
+func dot(a, b []float32) float64 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return float64(sum)
+}
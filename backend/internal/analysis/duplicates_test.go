@@ -0,0 +1,69 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestFindSimilarPairs(t *testing.T) {
+	ids := []string{"b", "a", "c", "zero", "short", "d"}
+	vectors := [][]float32{
+		{1, 0, 0},
+		{2, 0.1, 0}, // same direction as b, longer
+		{0, 1, 0},
+		{0, 0, 0},
+		{1, 0},
+		{1, 1, 0}, // 45 degrees from b and c
+	}
+
+	pairs := FindSimilarPairs(ids, vectors, 0.7, 0)
+	if len(pairs) != 4 {
+		t.Fatalf("got %d pairs, want 4: %+v", len(pairs), pairs)
+	}
+	if pairs[0].A != "a" || pairs[0].B != "b" {
+		t.Errorf("most similar pair is %s-%s, want a-b", pairs[0].A, pairs[0].B)
+	}
+	if pairs[0].Score < 0.99 || pairs[0].Score > 1.0001 {
+		t.Errorf("a-b scores %f, want about 1", pairs[0].Score)
+	}
+	for i, p := range pairs {
+		if p.A >= p.B {
+			t.Errorf("pair %d is %s-%s, want IDs in order", i, p.A, p.B)
+		}
+		if i > 0 && p.Score > pairs[i-1].Score {
+			t.Errorf("pair %d scores above the one before it", i)
+		}
+		if p.A == "zero" || p.B == "zero" || p.A == "short" || p.B == "short" {
+			t.Errorf("pair %s-%s includes a skipped vector", p.A, p.B)
+		}
+	}
+	last := pairs[len(pairs)-1]
+	if last.A != "c" || last.B != "d" || math.Abs(last.Score-math.Sqrt2/2) > 1e-6 {
+		t.Errorf("least similar pair is %+v, want c-d at 0.707", last)
+	}
+
+	if got := FindSimilarPairs(ids, vectors, 0.7, 2); len(got) != 2 || got[0] != pairs[0] {
+		t.Errorf("maxPairs 2 kept %+v", got)
+	}
+	if got := FindSimilarPairs(nil, nil, 0.5, 0); len(got) != 0 {
+		t.Errorf("no vectors gave %+v", got)
+	}
+}
+
+func TestFindSimilarPairsParallel(t *testing.T) {
+	// Enough vectors for several workers; each is similar only to its twin
+	var ids []string
+	var vectors [][]float32
+	for i := range 200 {
+		v := make([]float32, 200)
+		v[i/2] = 1
+		ids = append(ids, fmt.Sprintf("fn%03d", i))
+		vectors = append(vectors, v)
+	}
+
+	pairs := FindSimilarPairs(ids, vectors, 0.9, 0)
+	if len(pairs) != 100 {
+		t.Fatalf("got %d pairs, want 100", len(pairs))
+	}
+}
@@ -0,0 +1,42 @@
+package analysis
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// listMarker matches bullets, numbering and quoting around a line of a
+// model's list
+var listMarker = regexp.MustCompile("^\\s*(?:[-*•]|\\d+[.)])?\\s*[\"'`]*|[\"'`]*\\s*$")
+
+// ExpansionPrompt asks a model to rephrase a code search query as up to n
+// queries likely to match the wanted code, one per line
+func ExpansionPrompt(query string, n int) string {
+	return fmt.Sprintf(`Rewrite this code search query as %d alternative queries that would find the code it is looking for.
+Use programming terms, synonyms and likely function, method or type names, e.g. "parseConfig" or "load settings from file".
+Answer with the queries only, one per line, without numbering or explanation.
+
+Query: %s`, n, query)
+}
+
+// ParseExpansions reads the queries of a model's answer to ExpansionPrompt,
+// dropping list markers, blank lines and repeats of query or each other,
+// and keeps at most n of them
+func ParseExpansions(response, query string, n int) []string {
+	seen := map[string]bool{strings.ToLower(strings.TrimSpace(query)): true}
+	var expansions []string
+	for _, line := range strings.Split(response, "\n") {
+		if len(expansions) == n {
+			break
+		}
+		line = strings.TrimSpace(listMarker.ReplaceAllString(line, ""))
+		key := strings.ToLower(line)
+		if line == "" || seen[key] || strings.HasSuffix(line, ":") {
+			continue
+		}
+		seen[key] = true
+		expansions = append(expansions, line)
+	}
+	return expansions
+}
@@ -0,0 +1,34 @@
+package analysis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpansionPrompt(t *testing.T) {
+	prompt := ExpansionPrompt("where do we retry requests", 3)
+	if !strings.Contains(prompt, "3 alternative queries") || !strings.HasSuffix(prompt, "Query: where do we retry requests") {
+		t.Errorf("unexpected prompt:\n%s", prompt)
+	}
+}
+
+func TestParseExpansions(t *testing.T) {
+	response := "Here are some queries:\n" +
+		"1. retry with exponential backoff\n" +
+		"- `retryRequest`\n" +
+		"\n" +
+		"* \"Where do we retry requests\"\n" +
+		"2) RETRY WITH EXPONENTIAL BACKOFF\n" +
+		"http client retries\n" +
+		"withRetry"
+
+	got := ParseExpansions(response, "where do we retry requests", 3)
+	want := []string{"retry with exponential backoff", "retryRequest", "http client retries"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseExpansions = %q, want %q", got, want)
+	}
+	if got := ParseExpansions("", "query", 3); len(got) != 0 {
+		t.Errorf("empty response gave %q", got)
+	}
+}
@@ -0,0 +1,134 @@
+package analysis
+
+import (
+	"sort"
+	"unicode"
+)
+
+// Score contributions of a fuzzy match
+const (
+	fuzzyMatchScore        = 16 // each matched character
+	fuzzyBoundaryBonus     = 8  // match at the start of a word
+	fuzzyConsecutiveBonus  = 4  // match right after the previous one
+	fuzzyCaseBonus         = 1  // match in the same case as typed
+	fuzzyLeadingPenaltyMax = 4  // cap on the penalty for skipped leading characters
+)
+
+// FuzzyMatch is a candidate matching a fuzzy pattern
+type FuzzyMatch struct {
+	Index     int   // of the candidate
+	Score     int   // higher is better
+	Positions []int // rune indexes of the matched characters, for highlighting
+}
+
+// MatchFuzzy reports whether the characters of pattern appear in order in
+// candidate, ignoring case, as editors' go-to-symbol does. The match is
+// narrowed to the shortest window ending at the earliest complete match,
+// then scored: matched characters at word starts (after a separator, at a
+// camelCase hump or a digit boundary) and runs of consecutive characters
+// score higher, gaps and skipped leading characters lower.
+func MatchFuzzy(pattern, candidate string) (FuzzyMatch, bool) {
+	p, c := []rune(pattern), []rune(candidate)
+	if len(p) == 0 {
+		return FuzzyMatch{}, true
+	}
+
+	// Forward to the earliest position completing the match
+	end, k := -1, 0
+	for i := 0; i < len(c); i++ {
+		if equalFold(c[i], p[k]) {
+			k++
+			if k == len(p) {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return FuzzyMatch{}, false
+	}
+
+	// Backward from there to the latest start still matching
+	start, k := end, len(p)-1
+	for i := end; i >= 0; i-- {
+		if equalFold(c[i], p[k]) {
+			k--
+			if k < 0 {
+				start = i
+				break
+			}
+		}
+	}
+
+	match := FuzzyMatch{Positions: make([]int, 0, len(p))}
+	k = 0
+	for i := start; i <= end && k < len(p); i++ {
+		if !equalFold(c[i], p[k]) {
+			continue
+		}
+		match.Score += fuzzyMatchScore
+		if isWordStart(c, i) {
+			match.Score += fuzzyBoundaryBonus
+		}
+		if n := len(match.Positions); n > 0 && match.Positions[n-1] == i-1 {
+			match.Score += fuzzyConsecutiveBonus
+		}
+		if c[i] == p[k] {
+			match.Score += fuzzyCaseBonus
+		}
+		match.Positions = append(match.Positions, i)
+		k++
+	}
+	match.Score -= end - start + 1 - len(p)
+	match.Score -= min(start, fuzzyLeadingPenaltyMax)
+	return match, true
+}
+
+// RankFuzzy returns the candidates matching pattern, best first, at most
+// limit of them. Ties go to the shorter candidate, then alphabetically.
+func RankFuzzy(pattern string, candidates []string, limit int) []FuzzyMatch {
+	matches := []FuzzyMatch{}
+	for i, candidate := range candidates {
+		if match, ok := MatchFuzzy(pattern, candidate); ok {
+			match.Index = i
+			matches = append(matches, match)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		ca, cb := candidates[a.Index], candidates[b.Index]
+		if len(ca) != len(cb) {
+			return len(ca) < len(cb)
+		}
+		return ca < cb
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+func equalFold(a, b rune) bool {
+	return a == b || unicode.ToLower(a) == unicode.ToLower(b)
+}
+
+// isWordStart reports whether the rune at i begins a word of an identifier
+// or path
+func isWordStart(c []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, cur := c[i-1], c[i]
+	switch {
+	case !unicode.IsLetter(prev) && !unicode.IsDigit(prev):
+		return true
+	case unicode.IsUpper(cur) && unicode.IsLower(prev):
+		return true
+	case unicode.IsDigit(cur) != unicode.IsDigit(prev):
+		return true
+	}
+	return false
+}
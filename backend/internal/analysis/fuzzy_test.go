@@ -0,0 +1,51 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchFuzzy(t *testing.T) {
+	match, ok := MatchFuzzy("grphRdr", "GraphReader")
+	if !ok {
+		t.Fatal("grphRdr does not match GraphReader")
+	}
+	if want := []int{0, 1, 3, 4, 5, 8, 10}; !reflect.DeepEqual(match.Positions, want) {
+		t.Errorf("positions = %v, want %v", match.Positions, want)
+	}
+
+	if _, ok := MatchFuzzy("grphRdr", "getRepositoryHandler"); ok {
+		t.Error("grphRdr matches getRepositoryHandler, which lacks a d after the last r")
+	}
+	if _, ok := MatchFuzzy("", "anything"); !ok {
+		t.Error("an empty pattern should match")
+	}
+
+	// The match is narrowed to the shortest window
+	match, _ = MatchFuzzy("ab", "a_xab")
+	if want := []int{3, 4}; !reflect.DeepEqual(match.Positions, want) {
+		t.Errorf("positions = %v, want %v", match.Positions, want)
+	}
+}
+
+func TestRankFuzzy(t *testing.T) {
+	candidates := []string{
+		"grandchild",
+		"GetNodeDetail",
+		"Config",
+		"getNd",
+	}
+	var got []string
+	for _, match := range RankFuzzy("gnd", candidates, 0) {
+		got = append(got, candidates[match.Index])
+	}
+	// Word starts beat scattered letters; at equal scores shorter wins
+	want := []string{"getNd", "GetNodeDetail", "grandchild"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ranked %v, want %v", got, want)
+	}
+
+	if got := RankFuzzy("gnd", candidates, 2); len(got) != 2 {
+		t.Errorf("limit 2 returned %d matches", len(got))
+	}
+}
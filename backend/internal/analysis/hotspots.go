@@ -0,0 +1,41 @@
+package analysis
+
+import "sort"
+
+// Hotspot is a file scored by how often it changes and how complex its
+// code is
+type Hotspot struct {
+	Path       string
+	Commits    int     // commits changing the file in the indexed history
+	Complexity int     // summed cyclomatic complexity of its functions
+	Score      float64 // 0 to 1
+}
+
+// RankHotspots scores files by change frequency times complexity, each
+// relative to the highest in the repository, so a file that is both the
+// most changed and the most complex scores 1. Files that never changed or
+// have no complex code score 0 and are dropped; the rest are returned
+// highest first.
+func RankHotspots(files []Hotspot) []Hotspot {
+	maxCommits, maxComplexity := 0, 0
+	for _, f := range files {
+		maxCommits = max(maxCommits, f.Commits)
+		maxComplexity = max(maxComplexity, f.Complexity)
+	}
+
+	ranked := []Hotspot{}
+	for _, f := range files {
+		if f.Commits <= 0 || f.Complexity <= 0 {
+			continue
+		}
+		f.Score = float64(f.Commits) / float64(maxCommits) * float64(f.Complexity) / float64(maxComplexity)
+		ranked = append(ranked, f)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Path < ranked[j].Path
+	})
+	return ranked
+}
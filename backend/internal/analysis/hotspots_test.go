@@ -0,0 +1,39 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRankHotspots(t *testing.T) {
+	ranked := RankHotspots([]Hotspot{
+		{Path: "config.go", Commits: 40, Complexity: 5},
+		{Path: "parser.go", Commits: 20, Complexity: 100},
+		{Path: "legacy.go", Commits: 2, Complexity: 90},
+		{Path: "server.go", Commits: 10, Complexity: 50},
+		{Path: "unchanged.go", Commits: 0, Complexity: 70},
+		{Path: "types.go", Commits: 30, Complexity: 0},
+	})
+
+	want := []struct {
+		path  string
+		score float64
+	}{
+		{"parser.go", 0.5},   // 20/40 * 100/100
+		{"server.go", 0.125}, // 10/40 * 50/100
+		{"config.go", 0.05},  // 40/40 * 5/100
+		{"legacy.go", 0.045}, // 2/40 * 90/100
+	}
+	if len(ranked) != len(want) {
+		t.Fatalf("got %d hotspots, want %d: %+v", len(ranked), len(want), ranked)
+	}
+	for i, w := range want {
+		if ranked[i].Path != w.path || math.Abs(ranked[i].Score-w.score) > 1e-9 {
+			t.Errorf("hotspot %d is %s at %v, want %s at %v", i, ranked[i].Path, ranked[i].Score, w.path, w.score)
+		}
+	}
+
+	if got := RankHotspots(nil); len(got) != 0 {
+		t.Errorf("no files gave %+v", got)
+	}
+}
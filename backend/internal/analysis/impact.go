@@ -0,0 +1,149 @@
+package analysis
+
+import (
+	"path"
+	"sort"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// ImpactedFunction is a function a change may affect, Depth calls away
+// from a changed function; changed functions have depth 0
+type ImpactedFunction struct {
+	CodeFunction
+	Depth int `json:"depth"`
+}
+
+// ImpactReport is what a change may affect
+type ImpactReport struct {
+	Functions []ImpactedFunction `json:"functions"` // by depth, then file and line
+	Files     []string           `json:"files"`     // sorted
+	Packages  []string           `json:"packages"`  // directories of the files, sorted
+	Tests     []string           `json:"tests"`     // test files among the files
+}
+
+// FindImpact follows CALLS edges backwards from the changed functions and
+// every function of the changed files, up to maxDepth calls away (0 for no
+// bound), and imports backwards from the changed files and the files of the
+// changed functions, transitively. The affected files are those of the
+// reached functions and the importing files.
+func FindImpact(files []FileLayer, functions []CodeFunction, calls []models.CallRelation, changedFunctions, changedFiles []string, maxDepth int) *ImpactReport {
+	byID := make(map[string]CodeFunction, len(functions))
+	for _, fn := range functions {
+		byID[fn.ID] = fn
+	}
+	callers := make(map[string][]string)
+	for _, call := range calls {
+		callers[call.CalleeID] = append(callers[call.CalleeID], call.CallerID)
+	}
+
+	changedFile := make(map[string]bool, len(changedFiles))
+	for _, file := range changedFiles {
+		changedFile[filepathToSlash(file)] = true
+	}
+
+	// Breadth-first over callers, so each function gets its shortest depth
+	depth := make(map[string]int)
+	var frontier []string
+	seed := func(id string) {
+		if _, ok := byID[id]; !ok {
+			return
+		}
+		if _, seen := depth[id]; !seen {
+			depth[id] = 0
+			frontier = append(frontier, id)
+		}
+	}
+	for _, id := range changedFunctions {
+		seed(id)
+	}
+	for _, fn := range functions {
+		if changedFile[filepathToSlash(fn.FilePath)] {
+			seed(fn.ID)
+		}
+	}
+	for d := 1; len(frontier) > 0 && (maxDepth <= 0 || d <= maxDepth); d++ {
+		var next []string
+		for _, id := range frontier {
+			for _, caller := range callers[id] {
+				if _, seen := depth[caller]; !seen {
+					depth[caller] = d
+					next = append(next, caller)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	affected := make(map[string]bool)
+	var importFrontier []string
+	addFile := func(file string, followImports bool) {
+		if !affected[file] {
+			affected[file] = true
+			if followImports {
+				importFrontier = append(importFrontier, file)
+			}
+		}
+	}
+	for file := range changedFile {
+		addFile(file, true)
+	}
+	for _, id := range changedFunctions {
+		if fn, ok := byID[id]; ok {
+			addFile(filepathToSlash(fn.FilePath), true)
+		}
+	}
+	importers := make(map[string][]string)
+	for from, targets := range fileImports(files) {
+		for _, target := range targets {
+			importers[target] = append(importers[target], from)
+		}
+	}
+	for len(importFrontier) > 0 {
+		file := importFrontier[0]
+		importFrontier = importFrontier[1:]
+		for _, importer := range importers[file] {
+			addFile(importer, true)
+		}
+	}
+
+	report := &ImpactReport{
+		Functions: []ImpactedFunction{},
+		Files:     []string{},
+		Packages:  []string{},
+		Tests:     []string{},
+	}
+	for id, d := range depth {
+		report.Functions = append(report.Functions, ImpactedFunction{CodeFunction: byID[id], Depth: d})
+		addFile(filepathToSlash(byID[id].FilePath), false)
+	}
+	sort.Slice(report.Functions, func(i, j int) bool {
+		a, b := report.Functions[i], report.Functions[j]
+		if a.Depth != b.Depth {
+			return a.Depth < b.Depth
+		}
+		if a.FilePath != b.FilePath {
+			return a.FilePath < b.FilePath
+		}
+		if a.StartLine != b.StartLine {
+			return a.StartLine < b.StartLine
+		}
+		return a.ID < b.ID
+	})
+
+	packages := make(map[string]bool)
+	for file := range affected {
+		report.Files = append(report.Files, file)
+		packages[path.Dir(file)] = true
+		if IsTestFile(file) {
+			report.Tests = append(report.Tests, file)
+		}
+	}
+	for dir := range packages {
+		report.Packages = append(report.Packages, dir)
+	}
+	sort.Strings(report.Files)
+	sort.Strings(report.Packages)
+	sort.Strings(report.Tests)
+	return report
+}
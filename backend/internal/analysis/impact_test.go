@@ -0,0 +1,77 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+func TestFindImpact(t *testing.T) {
+	files := []FileLayer{
+		{Path: "cmd/server/main.go", Language: "go", Imports: []string{"github.com/acme/app/internal/api"}},
+		{Path: "internal/api/handlers.go", Language: "go", Imports: []string{"github.com/acme/app/internal/store"}},
+		{Path: "internal/api/handlers_test.go", Language: "go"},
+		{Path: "internal/store/store.go", Language: "go"},
+		{Path: "internal/store/store_test.go", Language: "go"},
+		{Path: "internal/report/report.go", Language: "go"},
+	}
+	functions := []CodeFunction{
+		{ID: "main", Name: "main", FilePath: "cmd/server/main.go", StartLine: 5},
+		{ID: "handle", Name: "Handle", FilePath: "internal/api/handlers.go", StartLine: 10},
+		{ID: "testHandle", Name: "TestHandle", FilePath: "internal/api/handlers_test.go", StartLine: 3},
+		{ID: "get", Name: "Get", FilePath: "internal/store/store.go", StartLine: 7},
+		{ID: "put", Name: "Put", FilePath: "internal/store/store.go", StartLine: 20},
+		{ID: "testGet", Name: "TestGet", FilePath: "internal/store/store_test.go", StartLine: 3},
+		{ID: "render", Name: "Render", FilePath: "internal/report/report.go", StartLine: 1},
+	}
+	calls := []models.CallRelation{
+		{CallerID: "main", CalleeID: "handle"},
+		{CallerID: "handle", CalleeID: "get"},
+		{CallerID: "testHandle", CalleeID: "handle"},
+		{CallerID: "testGet", CalleeID: "get"},
+		{CallerID: "render", CalleeID: "put"},
+	}
+
+	ids := func(report *ImpactReport) map[string]int {
+		depths := make(map[string]int)
+		for _, fn := range report.Functions {
+			depths[fn.ID] = fn.Depth
+		}
+		return depths
+	}
+
+	// Changing Get reaches its callers and the packages importing its file
+	report := FindImpact(files, functions, calls, []string{"get"}, nil, 0)
+	if got, want := ids(report), map[string]int{"get": 0, "handle": 1, "testGet": 1, "main": 2, "testHandle": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("functions = %v, want %v", got, want)
+	}
+	if report.Functions[0].ID != "get" {
+		t.Errorf("first function is %s, want the changed one", report.Functions[0].ID)
+	}
+	wantFiles := []string{
+		"cmd/server/main.go", "internal/api/handlers.go", "internal/api/handlers_test.go",
+		"internal/store/store.go", "internal/store/store_test.go",
+	}
+	if !reflect.DeepEqual(report.Files, wantFiles) {
+		t.Errorf("files = %v, want %v", report.Files, wantFiles)
+	}
+	if want := []string{"cmd/server", "internal/api", "internal/store"}; !reflect.DeepEqual(report.Packages, want) {
+		t.Errorf("packages = %v, want %v", report.Packages, want)
+	}
+	if want := []string{"internal/api/handlers_test.go", "internal/store/store_test.go"}; !reflect.DeepEqual(report.Tests, want) {
+		t.Errorf("tests = %v, want %v", report.Tests, want)
+	}
+
+	// A changed file seeds all its functions; depth bounds the callers
+	report = FindImpact(files, functions, calls, nil, []string{"internal/store/store.go"}, 1)
+	if got, want := ids(report), map[string]int{"get": 0, "put": 0, "handle": 1, "testGet": 1, "render": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("functions = %v, want %v", got, want)
+	}
+
+	// Unknown functions and files affect nothing
+	report = FindImpact(files, functions, calls, []string{"missing"}, nil, 0)
+	if len(report.Functions) != 0 || len(report.Files) != 0 {
+		t.Errorf("unknown function affected %+v", report)
+	}
+}
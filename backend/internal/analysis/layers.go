@@ -0,0 +1,310 @@
+package analysis
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// Architecture layers assigned to files and their entities
+const (
+	LayerAPI         = "api"
+	LayerDomain      = "domain"
+	LayerPersistence = "persistence"
+	LayerInfra       = "infra"
+)
+
+// Layers lists the known layers in dependency order (outermost first)
+var Layers = []string{LayerAPI, LayerDomain, LayerPersistence, LayerInfra}
+
+// layerByDirName maps well-known directory/file name segments to layers
+var layerByDirName = map[string]string{
+	"api":         LayerAPI,
+	"handler":     LayerAPI,
+	"handlers":    LayerAPI,
+	"controller":  LayerAPI,
+	"controllers": LayerAPI,
+	"routes":      LayerAPI,
+	"router":      LayerAPI,
+	"rest":        LayerAPI,
+	"grpc":        LayerAPI,
+	"http":        LayerAPI,
+	"endpoints":   LayerAPI,
+	"views":       LayerAPI,
+	"web":         LayerAPI,
+
+	"domain":    LayerDomain,
+	"model":     LayerDomain,
+	"models":    LayerDomain,
+	"entity":    LayerDomain,
+	"entities":  LayerDomain,
+	"core":      LayerDomain,
+	"service":   LayerDomain,
+	"services":  LayerDomain,
+	"usecase":   LayerDomain,
+	"usecases":  LayerDomain,
+	"business":  LayerDomain,
+	"aggregate": LayerDomain,
+
+	"db":           LayerPersistence,
+	"database":     LayerPersistence,
+	"repository":   LayerPersistence,
+	"repositories": LayerPersistence,
+	"store":        LayerPersistence,
+	"storage":      LayerPersistence,
+	"dao":          LayerPersistence,
+	"dal":          LayerPersistence,
+	"persistence":  LayerPersistence,
+	"migrations":   LayerPersistence,
+
+	"infra":          LayerInfra,
+	"infrastructure": LayerInfra,
+	"config":         LayerInfra,
+	"configs":        LayerInfra,
+	"cmd":            LayerInfra,
+	"deploy":         LayerInfra,
+	"logging":        LayerInfra,
+	"logger":         LayerInfra,
+	"metrics":        LayerInfra,
+	"cache":          LayerInfra,
+	"queue":          LayerInfra,
+	"messaging":      LayerInfra,
+	"platform":       LayerInfra,
+	"adapters":       LayerInfra,
+}
+
+// layerByImportPrefix maps framework/driver imports to the layer they imply
+var layerByImportPrefix = []struct {
+	prefix string
+	layer  string
+}{
+	{"net/http", LayerAPI},
+	{"github.com/gofiber/", LayerAPI},
+	{"github.com/gin-gonic/", LayerAPI},
+	{"github.com/labstack/echo", LayerAPI},
+	{"flask", LayerAPI},
+	{"fastapi", LayerAPI},
+	{"django.http", LayerAPI},
+	{"express", LayerAPI},
+	{"org.springframework.web", LayerAPI},
+	{"database/sql", LayerPersistence},
+	{"github.com/neo4j/", LayerPersistence},
+	{"gorm.io/", LayerPersistence},
+	{"sqlalchemy", LayerPersistence},
+	{"mongoose", LayerPersistence},
+	{"pg", LayerPersistence},
+	{"java.sql", LayerPersistence},
+	{"javax.persistence", LayerPersistence},
+	{"neo4j", LayerPersistence},
+}
+
+// forbiddenDependencies lists, per layer, the layers it must not import
+var forbiddenDependencies = map[string][]string{
+	LayerDomain:      {LayerAPI, LayerPersistence},
+	LayerPersistence: {LayerAPI},
+	LayerInfra:       {LayerAPI},
+}
+
+// ClassifyLayer assigns a layer to a file from its path, falling back to
+// its imports. Returns an empty string when no heuristic matches.
+func ClassifyLayer(filePath string, imports []models.ImportRelation) string {
+	if layer := layerFromPath(filePath); layer != "" {
+		return layer
+	}
+
+	for _, imp := range imports {
+		for _, rule := range layerByImportPrefix {
+			if imp.ImportPath == rule.prefix || strings.HasPrefix(imp.ImportPath, rule.prefix) {
+				return rule.layer
+			}
+		}
+	}
+
+	return ""
+}
+
+// layerFromPath matches path segments from the innermost directory outwards,
+// so internal/api/models is classified as domain rather than api
+func layerFromPath(filePath string) string {
+	segments := strings.Split(path.Dir(filepathToSlash(filePath)), "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if layer, ok := layerByDirName[strings.ToLower(segments[i])]; ok {
+			return layer
+		}
+	}
+
+	// Single-file layouts like handlers.go or repository.py
+	base := strings.ToLower(path.Base(filepathToSlash(filePath)))
+	base = strings.TrimSuffix(base, path.Ext(base))
+	for _, part := range strings.FieldsFunc(base, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+		if layer, ok := layerByDirName[part]; ok {
+			return layer
+		}
+	}
+
+	return ""
+}
+
+// FileLayer is the layer and import information of one indexed file
+type FileLayer struct {
+	Path     string
+	Language string
+	Layer    string
+	Imports  []string
+}
+
+// LayerViolation describes a file importing a layer it must not depend on
+type LayerViolation struct {
+	FilePath    string `json:"filePath"`
+	Layer       string `json:"layer"`
+	ImportPath  string `json:"importPath"`
+	TargetLayer string `json:"targetLayer"`
+	TargetDir   string `json:"targetDir"`
+}
+
+// LayerReport summarizes layer assignments and violations for a repository
+type LayerReport struct {
+	Files      map[string]int   `json:"files"` // layer -> file count ("" = unclassified)
+	Violations []LayerViolation `json:"violations"`
+}
+
+// FindLayerViolations resolves imports to directories inside the repository
+// and reports those that cross a forbidden layer boundary
+func FindLayerViolations(files []FileLayer) *LayerReport {
+	report := &LayerReport{
+		Files:      make(map[string]int),
+		Violations: []LayerViolation{},
+	}
+
+	// Layer of each directory is the most common layer of its files
+	dirCounts := make(map[string]map[string]int)
+	for _, f := range files {
+		report.Files[f.Layer]++
+		dir := path.Dir(filepathToSlash(f.Path))
+		if dirCounts[dir] == nil {
+			dirCounts[dir] = make(map[string]int)
+		}
+		dirCounts[dir][f.Layer]++
+	}
+
+	dirLayers := make(map[string]string, len(dirCounts))
+	dirs := make(map[string]bool, len(dirCounts))
+	for dir, counts := range dirCounts {
+		dirs[dir] = true
+		best, bestCount := "", 0
+		for layer, n := range counts {
+			if n > bestCount || (n == bestCount && layer < best) {
+				best, bestCount = layer, n
+			}
+		}
+		dirLayers[dir] = best
+	}
+
+	for _, f := range files {
+		forbidden := forbiddenDependencies[f.Layer]
+		if len(forbidden) == 0 {
+			continue
+		}
+		for _, imp := range f.Imports {
+			dir, ok := ResolveImportDir(imp, f.Path, f.Language, dirs)
+			if !ok {
+				continue
+			}
+			target := dirLayers[dir]
+			for _, bad := range forbidden {
+				if target == bad {
+					report.Violations = append(report.Violations, LayerViolation{
+						FilePath:    f.Path,
+						Layer:       f.Layer,
+						ImportPath:  imp,
+						TargetLayer: target,
+						TargetDir:   dir,
+					})
+				}
+			}
+		}
+	}
+
+	sort.Slice(report.Violations, func(i, j int) bool {
+		if report.Violations[i].FilePath != report.Violations[j].FilePath {
+			return report.Violations[i].FilePath < report.Violations[j].FilePath
+		}
+		return report.Violations[i].ImportPath < report.Violations[j].ImportPath
+	})
+
+	return report
+}
+
+// ResolveImportDir maps an import path to a repository directory, if the
+// import refers to code inside the repository
+func ResolveImportDir(importPath, fromFile, language string, dirs map[string]bool) (string, bool) {
+	fromDir := path.Dir(filepathToSlash(fromFile))
+
+	var candidate string
+	switch language {
+	case "typescript", "javascript":
+		if !strings.HasPrefix(importPath, ".") {
+			return "", false
+		}
+		candidate = path.Clean(path.Join(fromDir, importPath))
+		// "./api/handlers" usually names a file; try its directory too
+		if !dirs[candidate] {
+			candidate = path.Dir(candidate)
+		}
+		if dirs[candidate] {
+			return candidate, true
+		}
+		return "", false
+	case "python":
+		if strings.HasPrefix(importPath, ".") {
+			trimmed := strings.TrimLeft(importPath, ".")
+			up := len(importPath) - len(trimmed) - 1
+			base := fromDir
+			for i := 0; i < up; i++ {
+				base = path.Dir(base)
+			}
+			candidate = path.Join(base, strings.ReplaceAll(trimmed, ".", "/"))
+		} else {
+			candidate = strings.ReplaceAll(importPath, ".", "/")
+		}
+	case "java", "kotlin":
+		candidate = strings.ReplaceAll(importPath, ".", "/")
+	default:
+		candidate = importPath
+	}
+
+	// Symbol imports (Java classes, Python names) name something inside
+	// the package directory, so also try the parent path
+	candidates := []string{strings.Trim(candidate, "/")}
+	if i := strings.LastIndex(candidates[0], "/"); i > 0 {
+		candidates = append(candidates, candidates[0][:i])
+	}
+
+	// Match the longest repository directory that lines up with the import:
+	// Go module paths carry a prefix the repo does not, Java/Kotlin source
+	// roots carry a prefix the import does not
+	for _, c := range candidates {
+		best := ""
+		for dir := range dirs {
+			if dir == "." || dir == "" {
+				continue
+			}
+			if dir == c || strings.HasSuffix(c, "/"+dir) || strings.HasSuffix(dir, "/"+c) {
+				if len(dir) > len(best) || (len(dir) == len(best) && dir < best) {
+					best = dir
+				}
+			}
+		}
+		if best != "" {
+			return best, true
+		}
+	}
+
+	return "", false
+}
+
+func filepathToSlash(p string) string {
+	return strings.ReplaceAll(p, "\\", "/")
+}
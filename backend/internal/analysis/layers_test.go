@@ -0,0 +1,88 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+func TestClassifyLayer(t *testing.T) {
+	tests := []struct {
+		path     string
+		imports  []models.ImportRelation
+		expected string
+	}{
+		{"internal/api/handlers.go", nil, LayerAPI},
+		{"internal/db/graph_writer.go", nil, LayerPersistence},
+		{"internal/models/file.go", nil, LayerDomain},
+		{"internal/config/config.go", nil, LayerInfra},
+		{"internal/api/models/dto.go", nil, LayerDomain},
+		{"app/user_repository.py", nil, LayerPersistence},
+		{"src/main.go", []models.ImportRelation{{ImportPath: "net/http"}}, LayerAPI},
+		{"src/main.go", []models.ImportRelation{{ImportPath: "database/sql"}}, LayerPersistence},
+		{"src/main.go", nil, ""},
+	}
+
+	for _, tt := range tests {
+		got := ClassifyLayer(tt.path, tt.imports)
+		if got != tt.expected {
+			t.Errorf("ClassifyLayer(%s) = %q, want %q", tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestFindLayerViolations(t *testing.T) {
+	files := []FileLayer{
+		{Path: "internal/api/handlers.go", Language: "go", Layer: LayerAPI,
+			Imports: []string{"github.com/acme/app/internal/models"}},
+		{Path: "internal/models/user.go", Language: "go", Layer: LayerDomain,
+			Imports: []string{"github.com/acme/app/internal/api", "fmt"}},
+		{Path: "internal/db/users.go", Language: "go", Layer: LayerPersistence,
+			Imports: []string{"github.com/acme/app/internal/models"}},
+	}
+
+	report := FindLayerViolations(files)
+
+	if len(report.Violations) != 1 {
+		t.Fatalf("Expected 1 violation, got %d: %+v", len(report.Violations), report.Violations)
+	}
+	v := report.Violations[0]
+	if v.FilePath != "internal/models/user.go" || v.TargetLayer != LayerAPI {
+		t.Errorf("Unexpected violation: %+v", v)
+	}
+	if report.Files[LayerAPI] != 1 || report.Files[LayerDomain] != 1 || report.Files[LayerPersistence] != 1 {
+		t.Errorf("Unexpected layer counts: %v", report.Files)
+	}
+}
+
+func TestResolveImportDir(t *testing.T) {
+	dirs := map[string]bool{
+		"internal/api":            true,
+		"api":                     true,
+		"src/main/java/com/x/api": true,
+		"app/services":            true,
+		"web/components":          true,
+	}
+
+	tests := []struct {
+		importPath string
+		fromFile   string
+		language   string
+		expected   string
+		ok         bool
+	}{
+		{"github.com/acme/app/internal/api", "cmd/main.go", "go", "internal/api", true},
+		{"fmt", "cmd/main.go", "go", "", false},
+		{"com.x.api", "src/main/java/com/x/Main.java", "java", "src/main/java/com/x/api", true},
+		{"app.services.users", "app/main.py", "python", "app/services", true},
+		{"./components/Button", "web/App.tsx", "typescript", "web/components", true},
+		{"react", "web/App.tsx", "typescript", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ResolveImportDir(tt.importPath, tt.fromFile, tt.language, dirs)
+		if got != tt.expected || ok != tt.ok {
+			t.Errorf("ResolveImportDir(%s) = (%q, %v), want (%q, %v)", tt.importPath, got, ok, tt.expected, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package analysis
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Diversify reorders ranked items by maximal marginal relevance: each pick
+// maximizes lambda*relevance - (1-lambda)*its highest similarity to the
+// items already picked, so near-identical items do not crowd the top.
+// lambda 1 keeps the relevance order. Returns the indexes of at most limit
+// items; ties go to the earlier item.
+func Diversify(relevance []float64, similarity func(i, j int) float64, lambda float64, limit int) []int {
+	n := len(relevance)
+	if limit <= 0 || limit > n {
+		limit = n
+	}
+
+	picked := make([]int, 0, limit)
+	maxSim := make([]float64, n) // highest similarity to a picked item
+	used := make([]bool, n)
+	for len(picked) < limit {
+		best, bestScore := -1, 0.0
+		for i := 0; i < n; i++ {
+			if used[i] {
+				continue
+			}
+			score := lambda*relevance[i] - (1-lambda)*maxSim[i]
+			if best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		used[best] = true
+		picked = append(picked, best)
+		for i := 0; i < n; i++ {
+			if !used[i] {
+				maxSim[i] = max(maxSim[i], similarity(best, i))
+			}
+		}
+	}
+	return picked
+}
+
+// TokenSet returns the lowercased identifier words of source text, split at
+// punctuation, underscores and camelCase humps
+func TokenSet(text string) map[string]bool {
+	tokens := make(map[string]bool)
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(field)
+		start := 0
+		for i := 1; i <= len(runes); i++ {
+			if i == len(runes) || isWordStart(runes, i) {
+				tokens[strings.ToLower(string(runes[start:i]))] = true
+				start = i
+			}
+		}
+	}
+	return tokens
+}
+
+// Jaccard is the share of tokens two sets have in common, 0 for two empty
+// sets
+func Jaccard(a, b map[string]bool) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	common := 0
+	for token := range a {
+		if b[token] {
+			common++
+		}
+	}
+	union := len(a) + len(b) - common
+	if union == 0 {
+		return 0
+	}
+	return float64(common) / float64(union)
+}
@@ -0,0 +1,48 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiversify(t *testing.T) {
+	// 0, 1 and 2 are clones; 3 is less relevant but different
+	relevance := []float64{1.0, 0.98, 0.97, 0.8}
+	clones := map[[2]int]bool{{0, 1}: true, {0, 2}: true, {1, 2}: true}
+	similarity := func(i, j int) float64 {
+		if clones[[2]int{min(i, j), max(i, j)}] {
+			return 1
+		}
+		return 0
+	}
+
+	if got, want := Diversify(relevance, similarity, 0.5, 2), []int{0, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("diversified = %v, want %v", got, want)
+	}
+	if got, want := Diversify(relevance, similarity, 1, 0), []int{0, 1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lambda 1 = %v, want the relevance order %v", got, want)
+	}
+	if got := Diversify(nil, similarity, 0.5, 5); len(got) != 0 {
+		t.Errorf("no items = %v", got)
+	}
+}
+
+func TestTokenSet(t *testing.T) {
+	got := TokenSet("func (s *HttpServer) parseJsonBody(req_body []byte)")
+	for _, token := range []string{"func", "s", "http", "server", "parse", "json", "body", "req", "byte"} {
+		if !got[token] {
+			t.Errorf("missing token %q in %v", token, got)
+		}
+	}
+}
+
+func TestJaccard(t *testing.T) {
+	a := TokenSet("get user by id")
+	b := TokenSet("get user by name")
+	if got := Jaccard(a, b); got != 0.6 {
+		t.Errorf("Jaccard = %v, want 0.6", got)
+	}
+	if got := Jaccard(map[string]bool{}, map[string]bool{}); got != 0 {
+		t.Errorf("Jaccard of empty sets = %v", got)
+	}
+}
@@ -0,0 +1,142 @@
+package analysis
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// Kinds of entry points, in the order they are reported
+const (
+	EntryMain = "main"
+	EntryHTTP = "http"
+	EntryCLI  = "cli"
+)
+
+var entryKindOrder = map[string]int{EntryMain: 0, EntryHTTP: 1, EntryCLI: 2}
+
+// httpSignature matches signatures of HTTP handlers: Go net/http, Fiber,
+// Gin and Echo parameters, Flask/FastAPI route decorators, Spring and JAX-RS
+// annotations, NestJS decorators and Express (req, res) handlers
+var httpSignature = regexp.MustCompile(`http\.ResponseWriter|\*http\.Request|fiber\.Ctx|\*gin\.Context|echo\.Context|` +
+	`@\w+\.(route|get|post|put|patch|delete|api_route|websocket)\(|` +
+	`@(Get|Post|Put|Patch|Delete|Request)Mapping\b|@(GET|POST|PUT|PATCH|DELETE|Path)\b|` +
+	`@(Get|Post|Put|Patch|Delete)\(|\(\s*req\b[^,()]*,\s*res\b`)
+
+// cliSignature matches signatures of CLI commands: Cobra and urfave/cli
+// parameters, Click and Typer decorators and picocli annotations
+var cliSignature = regexp.MustCompile(`\*cobra\.Command|\*cli\.Context|@\w+\.(command|group)\(|@Command\b`)
+
+// CalledFunction is a function ranked by how many functions call it
+type CalledFunction struct {
+	CodeFunction
+	Callers int `json:"callers"` // distinct functions calling it
+	Calls   int `json:"calls"`   // call sites across them
+}
+
+// EntryPoint is a function a runtime or framework runs
+type EntryPoint struct {
+	CodeFunction
+	Kind string `json:"kind"` // EntryMain, EntryHTTP or EntryCLI
+}
+
+// OrientationReport is a first look at a codebase: the functions most of
+// it relies on and where execution starts
+type OrientationReport struct {
+	MostCalled  []CalledFunction `json:"mostCalled"`
+	EntryPoints []EntryPoint     `json:"entryPoints"`
+}
+
+// EntryPointKind returns the kind of entry point a function is, or "" for
+// none. Handlers are recognized by their signatures, which include
+// decorators and annotations.
+func EntryPointKind(fn CodeFunction) string {
+	if IsTestFile(fn.FilePath) {
+		return ""
+	}
+	switch {
+	case fn.Name == "main" || fn.Name == "Main":
+		return EntryMain
+	case fn.Name == "ServeHTTP" && fn.Type == string(models.EntityMethod),
+		httpSignature.MatchString(fn.Signature):
+		return EntryHTTP
+	case cliSignature.MatchString(fn.Signature):
+		return EntryCLI
+	}
+	return ""
+}
+
+// BuildOrientation ranks the functions of a repository by their distinct
+// callers, then call sites, and lists its entry points, main first, then
+// HTTP handlers and CLI commands. Tests neither rank nor count as callers.
+// Each list holds at most limit functions.
+func BuildOrientation(functions []CodeFunction, calls []models.CallRelation, limit int) *OrientationReport {
+	report := &OrientationReport{MostCalled: []CalledFunction{}, EntryPoints: []EntryPoint{}}
+
+	inTest := make(map[string]bool)
+	for _, fn := range functions {
+		if IsTestFile(fn.FilePath) {
+			inTest[fn.ID] = true
+		}
+	}
+
+	callers := make(map[string]map[string]bool)
+	sites := make(map[string]int)
+	for _, call := range calls {
+		if call.CallerID == call.CalleeID || inTest[call.CallerID] {
+			continue
+		}
+		if callers[call.CalleeID] == nil {
+			callers[call.CalleeID] = make(map[string]bool)
+		}
+		callers[call.CalleeID][call.CallerID] = true
+		sites[call.CalleeID] += max(call.Count, 1)
+	}
+
+	for _, fn := range functions {
+		if inTest[fn.ID] {
+			continue
+		}
+		if n := len(callers[fn.ID]); n > 0 {
+			report.MostCalled = append(report.MostCalled, CalledFunction{CodeFunction: fn, Callers: n, Calls: sites[fn.ID]})
+		}
+		if kind := EntryPointKind(fn); kind != "" {
+			report.EntryPoints = append(report.EntryPoints, EntryPoint{CodeFunction: fn, Kind: kind})
+		}
+	}
+
+	sort.Slice(report.MostCalled, func(i, j int) bool {
+		a, b := report.MostCalled[i], report.MostCalled[j]
+		if a.Callers != b.Callers {
+			return a.Callers > b.Callers
+		}
+		if a.Calls != b.Calls {
+			return a.Calls > b.Calls
+		}
+		return lessPosition(a.CodeFunction, b.CodeFunction)
+	})
+	sort.Slice(report.EntryPoints, func(i, j int) bool {
+		a, b := report.EntryPoints[i], report.EntryPoints[j]
+		if a.Kind != b.Kind {
+			return entryKindOrder[a.Kind] < entryKindOrder[b.Kind]
+		}
+		return lessPosition(a.CodeFunction, b.CodeFunction)
+	})
+
+	if limit > 0 && len(report.MostCalled) > limit {
+		report.MostCalled = report.MostCalled[:limit]
+	}
+	if limit > 0 && len(report.EntryPoints) > limit {
+		report.EntryPoints = report.EntryPoints[:limit]
+	}
+	return report
+}
+
+// lessPosition orders functions by file, then line
+func lessPosition(a, b CodeFunction) bool {
+	if a.FilePath != b.FilePath {
+		return a.FilePath < b.FilePath
+	}
+	return a.StartLine < b.StartLine
+}
@@ -0,0 +1,85 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+func TestEntryPointKind(t *testing.T) {
+	tests := []struct {
+		fn   CodeFunction
+		want string
+	}{
+		{CodeFunction{Name: "main", FilePath: "cmd/server/main.go", Signature: "func main()"}, EntryMain},
+		{CodeFunction{Name: "main", FilePath: "src/App.java", Type: "Method", Signature: "public static void main(String[] args)"}, EntryMain},
+		{CodeFunction{Name: "ListRepos", FilePath: "api/handlers.go", Type: "Method", Signature: "func (h *Handler) ListRepos(c fiber.Ctx) error"}, EntryHTTP},
+		{CodeFunction{Name: "health", FilePath: "server.go", Signature: "func health(w http.ResponseWriter, r *http.Request)"}, EntryHTTP},
+		{CodeFunction{Name: "ServeHTTP", FilePath: "mux.go", Type: "Method", Signature: "func (m *Mux) ServeHTTP(w Writer, r *Request)"}, EntryHTTP},
+		{CodeFunction{Name: "index", FilePath: "app/views.py", Signature: "@app.route(\"/\")\ndef index():"}, EntryHTTP},
+		{CodeFunction{Name: "create", FilePath: "app/api.py", Signature: "@router.post(\"/items\")\nasync def create(item: Item):"}, EntryHTTP},
+		{CodeFunction{Name: "list", FilePath: "src/UserController.java", Type: "Method", Signature: "@GetMapping(\"/users\")\npublic List<User> list()"}, EntryHTTP},
+		{CodeFunction{Name: "find", FilePath: "src/users.controller.ts", Type: "Method", Signature: "@Get(':id') find(@Param('id') id: string)"}, EntryHTTP},
+		{CodeFunction{Name: "handler", FilePath: "src/routes.js", Signature: "function handler(req, res)"}, EntryHTTP},
+		{CodeFunction{Name: "runServe", FilePath: "cmd/serve.go", Signature: "func runServe(cmd *cobra.Command, args []string) error"}, EntryCLI},
+		{CodeFunction{Name: "sync", FilePath: "tool/cli.py", Signature: "@cli.command()\n@click.option(\"--force\")\ndef sync(force):"}, EntryCLI},
+		{CodeFunction{Name: "format", FilePath: "util.go", Signature: "func format(s string) string"}, ""},
+		{CodeFunction{Name: "TestList", FilePath: "api/handlers_test.go", Signature: "func TestList(t *testing.T)"}, ""},
+		{CodeFunction{Name: "main", FilePath: "tests/fixtures/main.go", Signature: "func main()"}, ""},
+	}
+	for _, tt := range tests {
+		if got := EntryPointKind(tt.fn); got != tt.want {
+			t.Errorf("EntryPointKind(%s in %s) = %q, want %q", tt.fn.Name, tt.fn.FilePath, got, tt.want)
+		}
+	}
+}
+
+func TestBuildOrientation(t *testing.T) {
+	functions := []CodeFunction{
+		{ID: "main", Name: "main", Type: "Function", FilePath: "cmd/app/main.go", StartLine: 5, Signature: "func main()"},
+		{ID: "list", Name: "List", Type: "Method", FilePath: "api/handlers.go", StartLine: 10, Signature: "func (h *Handler) List(c fiber.Ctx) error"},
+		{ID: "get", Name: "Get", Type: "Method", FilePath: "api/handlers.go", StartLine: 30, Signature: "func (h *Handler) Get(c fiber.Ctx) error"},
+		{ID: "query", Name: "query", Type: "Function", FilePath: "store/store.go", StartLine: 1},
+		{ID: "log", Name: "log", Type: "Function", FilePath: "store/log.go", StartLine: 1},
+		{ID: "walk", Name: "walk", Type: "Function", FilePath: "store/walk.go", StartLine: 1},
+		{ID: "test", Name: "TestGet", Type: "Function", FilePath: "api/handlers_test.go", StartLine: 1},
+		{ID: "helper", Name: "helper", Type: "Function", FilePath: "api/helpers_test.go", StartLine: 1},
+	}
+	calls := []models.CallRelation{
+		{CallerID: "main", CalleeID: "log", Count: 1},
+		{CallerID: "list", CalleeID: "query", Count: 3},
+		{CallerID: "get", CalleeID: "query", Count: 1},
+		{CallerID: "list", CalleeID: "log", Count: 1},
+		{CallerID: "walk", CalleeID: "walk", Count: 2}, // recursion
+		{CallerID: "test", CalleeID: "get", Count: 1},
+		{CallerID: "test", CalleeID: "helper", Count: 1},
+	}
+
+	report := BuildOrientation(functions, calls, 10)
+
+	var called []string
+	for _, fn := range report.MostCalled {
+		called = append(called, fn.ID)
+	}
+	// query and log both have two callers; query has more call sites
+	if want := []string{"query", "log"}; !reflect.DeepEqual(called, want) {
+		t.Errorf("most called = %v, want %v", called, want)
+	}
+	if got := report.MostCalled[0]; got.Callers != 2 || got.Calls != 4 {
+		t.Errorf("query has %d callers and %d calls, want 2 and 4", got.Callers, got.Calls)
+	}
+
+	var entries []string
+	for _, entry := range report.EntryPoints {
+		entries = append(entries, entry.ID+":"+entry.Kind)
+	}
+	if want := []string{"main:main", "list:http", "get:http"}; !reflect.DeepEqual(entries, want) {
+		t.Errorf("entry points = %v, want %v", entries, want)
+	}
+
+	limited := BuildOrientation(functions, calls, 1)
+	if len(limited.MostCalled) != 1 || len(limited.EntryPoints) != 1 {
+		t.Errorf("limit 1 kept %d most called and %d entry points", len(limited.MostCalled), len(limited.EntryPoints))
+	}
+}
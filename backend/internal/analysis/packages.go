@@ -0,0 +1,92 @@
+package analysis
+
+import (
+	"path"
+	"sort"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// FileCalls is the number of calls from the functions of one file to those
+// of another
+type FileCalls struct {
+	Source string
+	Target string
+	Calls  int
+}
+
+// PackageDependency is a dependency of one package on another through
+// imports, calls or both
+type PackageDependency struct {
+	Source  string // package directories, "" for the top of the repository
+	Target  string
+	Imports int  // files of Source importing Target
+	Calls   int  // calls from functions of Source to functions of Target
+	Cyclic  bool // Source and Target depend on each other, maybe indirectly
+}
+
+// PackageDependencies aggregates the imports and calls between files into
+// dependencies between the directories holding them, the packages of most
+// languages. Dependencies are sorted by source, then target. Cycles lists
+// the groups of packages that all depend on each other, as FindCallCycles
+// does for functions.
+func PackageDependencies(files []FileLayer, calls []FileCalls) (deps []PackageDependency, cycles [][]string) {
+	packageOf := func(file string) string {
+		dir := path.Dir(filepathToSlash(file))
+		if dir == "." || dir == "/" {
+			return ""
+		}
+		return dir
+	}
+
+	type pair struct{ source, target string }
+	byPair := make(map[pair]*PackageDependency)
+	dependency := func(source, target string) *PackageDependency {
+		p := pair{source, target}
+		if byPair[p] == nil {
+			byPair[p] = &PackageDependency{Source: source, Target: target}
+		}
+		return byPair[p]
+	}
+
+	for from, targets := range fileImports(files) {
+		source := packageOf(from)
+		imported := make(map[string]bool)
+		for _, to := range targets {
+			if target := packageOf(to); target != source && !imported[target] {
+				imported[target] = true
+				dependency(source, target).Imports++
+			}
+		}
+	}
+	for _, c := range calls {
+		if source, target := packageOf(c.Source), packageOf(c.Target); source != target {
+			dependency(source, target).Calls += c.Calls
+		}
+	}
+
+	relations := make([]models.CallRelation, 0, len(byPair))
+	for p := range byPair {
+		relations = append(relations, models.CallRelation{CallerID: p.source, CalleeID: p.target})
+	}
+	cycles = FindCallCycles(relations)
+	cycleOf := make(map[string]int)
+	for i, cycle := range cycles {
+		for _, pkg := range cycle {
+			cycleOf[pkg] = i + 1
+		}
+	}
+
+	deps = make([]PackageDependency, 0, len(byPair))
+	for _, dep := range byPair {
+		dep.Cyclic = cycleOf[dep.Source] > 0 && cycleOf[dep.Source] == cycleOf[dep.Target]
+		deps = append(deps, *dep)
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].Source != deps[j].Source {
+			return deps[i].Source < deps[j].Source
+		}
+		return deps[i].Target < deps[j].Target
+	})
+	return deps, cycles
+}
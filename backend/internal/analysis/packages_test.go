@@ -0,0 +1,41 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPackageDependencies(t *testing.T) {
+	files := []FileLayer{
+		{Path: "main.go", Language: "go", Imports: []string{"github.com/acme/app/api"}},
+		{Path: "api/handlers.go", Language: "go", Imports: []string{"github.com/acme/app/store", "fmt"}},
+		{Path: "api/routes.go", Language: "go", Imports: []string{"github.com/acme/app/store"}},
+		{Path: "store/store.go", Language: "go"},
+		{Path: "store/hooks.go", Language: "go"},
+		{Path: "web/app.ts", Language: "typescript", Imports: []string{"./lib/util"}},
+		{Path: "web/lib/util.ts", Language: "typescript", Imports: []string{"../app"}},
+	}
+	calls := []FileCalls{
+		{Source: "api/handlers.go", Target: "store/store.go", Calls: 3},
+		{Source: "store/hooks.go", Target: "api/routes.go", Calls: 1},  // a call back makes a cycle
+		{Source: "store/hooks.go", Target: "store/store.go", Calls: 2}, // within a package
+	}
+
+	deps, cycles := PackageDependencies(files, calls)
+
+	expected := []PackageDependency{
+		{Source: "", Target: "api", Imports: 1},
+		{Source: "api", Target: "store", Imports: 2, Calls: 3, Cyclic: true},
+		{Source: "store", Target: "api", Calls: 1, Cyclic: true},
+		{Source: "web", Target: "web/lib", Imports: 1, Cyclic: true},
+		{Source: "web/lib", Target: "web", Imports: 1, Cyclic: true},
+	}
+	if !reflect.DeepEqual(deps, expected) {
+		t.Errorf("dependencies:\n got %+v\nwant %+v", deps, expected)
+	}
+
+	expectedCycles := [][]string{{"api", "store"}, {"web", "web/lib"}}
+	if !reflect.DeepEqual(cycles, expectedCycles) {
+		t.Errorf("cycles = %v, want %v", cycles, expectedCycles)
+	}
+}
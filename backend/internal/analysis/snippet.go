@@ -0,0 +1,79 @@
+package analysis
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Snippet returns a window of at most maxLines lines of content mentioning
+// the most words of query, case-insensitively, centered on the mentions, with
+// the index of its first line. Without a mention the window starts at the
+// first line. Leading and trailing blank lines are trimmed.
+func Snippet(content, query string, maxLines int) (string, int) {
+	if content == "" || maxLines < 1 {
+		return "", 0
+	}
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	words := queryWords(query)
+
+	hits := make([]int, len(lines))
+	for i, line := range lines {
+		lower := strings.ToLower(line)
+		for _, word := range words {
+			if strings.Contains(lower, word) {
+				hits[i]++
+			}
+		}
+	}
+
+	// Slide the window, keeping the earliest with the most hits
+	window := min(maxLines, len(lines))
+	best, sum := 0, 0
+	for i := 0; i < window; i++ {
+		sum += hits[i]
+	}
+	bestSum := sum
+	for start := 1; start+window <= len(lines); start++ {
+		sum += hits[start+window-1] - hits[start-1]
+		if sum > bestSum {
+			best, bestSum = start, sum
+		}
+	}
+
+	// Center the window on the hits it holds
+	if bestSum > 0 {
+		first, last := -1, -1
+		for i := best; i < best+window; i++ {
+			if hits[i] > 0 {
+				if first < 0 {
+					first = i
+				}
+				last = i
+			}
+		}
+		best = max(0, min((first+last+1)/2-window/2, len(lines)-window))
+	}
+
+	end := best + window
+	for best < end && strings.TrimSpace(lines[best]) == "" {
+		best++
+	}
+	for end > best && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return strings.Join(lines[best:end], "\n"), best
+}
+
+// queryWords splits a query into lowercased words of letters and digits,
+// ignoring single characters
+func queryWords(query string) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		if len([]rune(word)) > 1 {
+			words = append(words, word)
+		}
+	}
+	return words
+}
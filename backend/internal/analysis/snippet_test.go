@@ -0,0 +1,46 @@
+package analysis
+
+import "testing"
+
+func TestSnippet(t *testing.T) {
+	content := `func (s *Store) Save(ctx context.Context, repo *Repo) error {
+	if repo == nil {
+		return errNil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.Insert(repo); err != nil {
+		return err
+	}
+	return tx.Commit()
+}`
+
+	tests := []struct {
+		query     string
+		maxLines  int
+		wantFirst int
+		wantLines string
+	}{
+		// No mention: the start, without the trailing blank line
+		{"unrelated words", 5, 0, "func (s *Store) Save(ctx context.Context, repo *Repo) error {\n\tif repo == nil {\n\t\treturn errNil\n\t}"},
+		// Centered on the only mention, the trailing blank line trimmed
+		{"transaction commit rollback", 3, 8, "\t}\n\tdefer tx.Rollback()"},
+		// The window mentioning both words
+		{"Commit Insert", 4, 11, "\tif err := tx.Insert(repo); err != nil {\n\t\treturn err\n\t}\n\treturn tx.Commit()"},
+	}
+	for _, tt := range tests {
+		got, first := Snippet(content, tt.query, tt.maxLines)
+		if got != tt.wantLines || first != tt.wantFirst {
+			t.Errorf("Snippet(%q, %d) = %q at %d, want %q at %d", tt.query, tt.maxLines, got, first, tt.wantLines, tt.wantFirst)
+		}
+	}
+
+	if got, _ := Snippet("", "save", 5); got != "" {
+		t.Errorf("Snippet of no content = %q", got)
+	}
+}
@@ -0,0 +1,99 @@
+package analysis
+
+import "sort"
+
+// UnresolvedName is a name called somewhere in a repository that matched
+// none of its entities
+type UnresolvedName struct {
+	Name       string `json:"name"`
+	ImportPath string `json:"importPath,omitempty"`
+	Calls      int    `json:"calls"`   // call sites
+	Callers    int    `json:"callers"` // distinct functions calling it
+}
+
+// UnresolvedModule is an import whose functions a repository calls
+type UnresolvedModule struct {
+	ImportPath string `json:"importPath"`
+	Names      int    `json:"names"` // distinct names called
+	Calls      int    `json:"calls"`
+	Callers    int    `json:"callers"`
+}
+
+// UnresolvedCallReport summarizes the calls of a repository leaving it or
+// unknown to the resolver. Modules shows which libraries are hot paths.
+type UnresolvedCallReport struct {
+	Calls   int                `json:"calls"` // call sites in all
+	Names   []UnresolvedName   `json:"names"`
+	Modules []UnresolvedModule `json:"modules"`
+}
+
+// SummarizeUnresolved groups unresolved calls by name and by the import
+// they go through, most called first, keeping at most limit of each
+func SummarizeUnresolved(calls []UnresolvedCall, limit int) *UnresolvedCallReport {
+	report := &UnresolvedCallReport{Names: []UnresolvedName{}, Modules: []UnresolvedModule{}}
+
+	type nameKey struct{ name, importPath string }
+	names := make(map[nameKey]int) // -> index into report.Names
+	modules := make(map[string]int)
+	moduleCallers := make(map[string]map[string]bool)
+	for _, call := range calls {
+		report.Calls += call.Count
+
+		key := nameKey{call.Name, call.ImportPath}
+		i, ok := names[key]
+		if !ok {
+			i = len(report.Names)
+			names[key] = i
+			report.Names = append(report.Names, UnresolvedName{Name: call.Name, ImportPath: call.ImportPath})
+		}
+		report.Names[i].Calls += call.Count
+		report.Names[i].Callers++ // a caller records each name once
+
+		if call.ImportPath == "" {
+			continue
+		}
+		j, ok := modules[call.ImportPath]
+		if !ok {
+			j = len(report.Modules)
+			modules[call.ImportPath] = j
+			moduleCallers[call.ImportPath] = make(map[string]bool)
+			report.Modules = append(report.Modules, UnresolvedModule{ImportPath: call.ImportPath})
+		}
+		report.Modules[j].Calls += call.Count
+		moduleCallers[call.ImportPath][call.CallerID] = true
+	}
+	for _, name := range report.Names {
+		if name.ImportPath != "" {
+			report.Modules[modules[name.ImportPath]].Names++
+		}
+	}
+	for i := range report.Modules {
+		report.Modules[i].Callers = len(moduleCallers[report.Modules[i].ImportPath])
+	}
+
+	sort.Slice(report.Names, func(i, j int) bool {
+		a, b := report.Names[i], report.Names[j]
+		if a.Calls != b.Calls {
+			return a.Calls > b.Calls
+		}
+		if a.Callers != b.Callers {
+			return a.Callers > b.Callers
+		}
+		return a.Name < b.Name
+	})
+	sort.Slice(report.Modules, func(i, j int) bool {
+		a, b := report.Modules[i], report.Modules[j]
+		if a.Calls != b.Calls {
+			return a.Calls > b.Calls
+		}
+		return a.ImportPath < b.ImportPath
+	})
+
+	if limit > 0 && len(report.Names) > limit {
+		report.Names = report.Names[:limit]
+	}
+	if limit > 0 && len(report.Modules) > limit {
+		report.Modules = report.Modules[:limit]
+	}
+	return report
+}
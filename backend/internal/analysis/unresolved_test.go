@@ -0,0 +1,44 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSummarizeUnresolved(t *testing.T) {
+	calls := []UnresolvedCall{
+		{CallerID: "a", Name: "json.Marshal", ImportPath: "encoding/json", Count: 2},
+		{CallerID: "a", Name: "json.Unmarshal", ImportPath: "encoding/json", Count: 1},
+		{CallerID: "a", Name: "len", Count: 4},
+		{CallerID: "b", Name: "json.Marshal", ImportPath: "encoding/json", Count: 1},
+		{CallerID: "b", Name: "fmt.Sprintf", ImportPath: "fmt", Count: 1},
+		{CallerID: "b", Name: "len", Count: 1},
+	}
+
+	report := SummarizeUnresolved(calls, 10)
+
+	if report.Calls != 10 {
+		t.Errorf("calls = %d, want 10", report.Calls)
+	}
+	expectedNames := []UnresolvedName{
+		{Name: "len", Calls: 5, Callers: 2},
+		{Name: "json.Marshal", ImportPath: "encoding/json", Calls: 3, Callers: 2},
+		{Name: "fmt.Sprintf", ImportPath: "fmt", Calls: 1, Callers: 1},
+		{Name: "json.Unmarshal", ImportPath: "encoding/json", Calls: 1, Callers: 1},
+	}
+	if !reflect.DeepEqual(report.Names, expectedNames) {
+		t.Errorf("names:\n got %+v\nwant %+v", report.Names, expectedNames)
+	}
+	expectedModules := []UnresolvedModule{
+		{ImportPath: "encoding/json", Names: 2, Calls: 4, Callers: 2},
+		{ImportPath: "fmt", Names: 1, Calls: 1, Callers: 1},
+	}
+	if !reflect.DeepEqual(report.Modules, expectedModules) {
+		t.Errorf("modules:\n got %+v\nwant %+v", report.Modules, expectedModules)
+	}
+
+	limited := SummarizeUnresolved(calls, 1)
+	if len(limited.Names) != 1 || len(limited.Modules) != 1 || limited.Calls != 10 {
+		t.Errorf("limit 1 kept %d names and %d modules of %d calls", len(limited.Names), len(limited.Modules), limited.Calls)
+	}
+}
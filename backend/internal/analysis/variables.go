@@ -0,0 +1,177 @@
+package analysis
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// ResolveVariableAccesses links the variable accesses recorded on each
+// entity to the package-level variable they refer to. Go names resolve
+// within the accessing package or, qualified, within an imported one;
+// Python names within the accessing module or through its imports. A use
+// of a field or attribute of an unresolved qualifier, as in cfg.Debug = x,
+// is taken as a use of the qualifier itself, so mutating a global struct
+// or object counts as writing it. Names that match no variable, or several,
+// are dropped. Entities must have IDs.
+func ResolveVariableAccesses(files []*models.File, entities []models.CodeEntity) []models.VariableRelation {
+	r := newCallResolver(files, entities)
+
+	variables := make(map[string][]int)
+	for i, entity := range entities {
+		if entity.Type == models.EntityVariable && entity.ID != "" {
+			variables[entity.Name] = append(variables[entity.Name], i)
+		}
+	}
+	if len(variables) == 0 {
+		return nil
+	}
+	paths := make(map[string]bool, len(files))
+	for _, file := range files {
+		paths[filepathToSlash(file.Path)] = true
+	}
+
+	var relations []models.VariableRelation
+	for i := range entities {
+		accessor := &entities[i]
+		if accessor.ID == "" || len(accessor.Accesses) == 0 {
+			continue
+		}
+		index := make(map[[2]string]int) // variable ID and kind -> index into relations
+		for _, access := range accessor.Accesses {
+			variable := r.resolveVariable(accessor, access.Name, variables, paths)
+			if variable < 0 {
+				continue
+			}
+			key := [2]string{entities[variable].ID, "read"}
+			if access.Write {
+				key[1] = "write"
+			}
+			idx, seen := index[key]
+			if !seen {
+				idx = len(relations)
+				index[key] = idx
+				relations = append(relations, models.VariableRelation{
+					EntityID:   accessor.ID,
+					VariableID: entities[variable].ID,
+					Write:      access.Write,
+				})
+			}
+			relations[idx].Count++
+			if access.Line > 0 {
+				relations[idx].Lines = append(relations[idx].Lines, access.Line)
+				sort.Ints(relations[idx].Lines)
+			}
+		}
+	}
+	return relations
+}
+
+// resolveVariable returns the index of the single variable an access names,
+// or -1
+func (r *callResolver) resolveVariable(accessor *models.CodeEntity, name string, variables map[string][]int, paths map[string]bool) int {
+	file := filepathToSlash(accessor.FilePath)
+	scope := r.scopes[file]
+	if scope == nil {
+		scope = &callScope{}
+	}
+
+	inDir := func(dir, name string) int {
+		return r.single(variables[name], func(e models.CodeEntity) bool {
+			return path.Dir(filepathToSlash(e.FilePath)) == dir
+		})
+	}
+	inFile := func(file, name string) int {
+		if file == "" {
+			return -1
+		}
+		return r.single(variables[name], func(e models.CodeEntity) bool {
+			return filepathToSlash(e.FilePath) == file
+		})
+	}
+	unqualified := func(name string) int {
+		if scope.language != "python" {
+			return inDir(path.Dir(file), name)
+		}
+		if idx := inFile(file, name); idx >= 0 {
+			return idx
+		}
+		if imp, ok := scope.symbols[name]; ok {
+			return inFile(pythonModuleFile(imp.ImportPath, file, paths), imp.Symbol)
+		}
+		return -1
+	}
+
+	qualifier, attr, qualified := strings.Cut(name, ".")
+	if !qualified {
+		return unqualified(name)
+	}
+
+	if importPath, ok := scope.namespaces[qualifier]; ok {
+		if scope.language == "python" {
+			return inFile(pythonModuleFile(importPath, file, paths), attr)
+		}
+		dir, inRepo := ResolveImportDir(importPath, file, scope.language, r.dirs)
+		if !inRepo {
+			return -1
+		}
+		return inDir(dir, attr)
+	}
+	// from app import settings; settings.DEBUG
+	if imp, ok := scope.symbols[qualifier]; ok && scope.language == "python" {
+		if idx := inFile(pythonModuleFile(imp.ImportPath+"."+imp.Symbol, file, paths), attr); idx >= 0 {
+			return idx
+		}
+	}
+	return unqualified(qualifier)
+}
+
+// single returns the one candidate the filter keeps, or -1
+func (r *callResolver) single(candidates []int, filter func(models.CodeEntity) bool) int {
+	match := -1
+	for _, idx := range candidates {
+		if filter(r.entities[idx]) {
+			if match >= 0 {
+				return -1
+			}
+			match = idx
+		}
+	}
+	return match
+}
+
+// pythonModuleFile finds the repository file a Python module path refers
+// to, relative imports included, or ""
+func pythonModuleFile(importPath, fromFile string, paths map[string]bool) string {
+	module := importPath
+	base := ""
+	if strings.HasPrefix(importPath, ".") {
+		module = strings.TrimLeft(importPath, ".")
+		base = path.Dir(fromFile)
+		for i := 1; i < len(importPath)-len(module); i++ {
+			base = path.Dir(base)
+		}
+	}
+	candidate := path.Join(base, strings.ReplaceAll(module, ".", "/"))
+
+	var found string
+	for file := range paths {
+		trimmed := strings.TrimSuffix(strings.TrimSuffix(file, ".py"), "/__init__")
+		if trimmed == file {
+			continue // not a Python file
+		}
+		if trimmed == candidate {
+			return file
+		}
+		// Modules below a source root, such as src/
+		if base == "" && strings.HasSuffix(trimmed, "/"+candidate) {
+			if found != "" {
+				return ""
+			}
+			found = file
+		}
+	}
+	return found
+}
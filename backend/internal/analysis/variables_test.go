@@ -0,0 +1,111 @@
+package analysis
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+func TestResolveVariableAccesses(t *testing.T) {
+	files := []*models.File{
+		{Path: "internal/config/config.go", Language: "go"},
+		{Path: "internal/config/load.go", Language: "go"},
+		{Path: "cmd/main.go", Language: "go", Imports: []models.ImportRelation{
+			{ImportPath: "github.com/acme/app/internal/config"},
+			{ImportPath: "os"},
+		}},
+		{Path: "app/settings.py", Language: "python"},
+		{Path: "app/jobs.py", Language: "python", Imports: []models.ImportRelation{
+			{ImportPath: "app.settings", Symbol: "DEBUG"},
+			{ImportPath: "app", Symbol: "settings"},
+		}},
+		{Path: "app/state.py", Language: "python"},
+	}
+
+	entities := []models.CodeEntity{
+		{ID: "config.Debug", Type: models.EntityVariable, Name: "Debug", FilePath: "internal/config/config.go"},
+		{ID: "config.current", Type: models.EntityVariable, Name: "current", FilePath: "internal/config/config.go"},
+		{ID: "config.Load", Type: models.EntityFunction, Name: "Load", FilePath: "internal/config/load.go",
+			Accesses: []models.VariableAccess{
+				{Name: "current.Level", Line: 5, Write: true},
+				{Name: "current", Line: 7},
+				{Name: "current", Line: 9},
+				{Name: "unknown", Line: 10},
+			}},
+		{ID: "main", Type: models.EntityFunction, Name: "main", FilePath: "cmd/main.go",
+			Accesses: []models.VariableAccess{
+				{Name: "config.Debug", Line: 3, Write: true},
+				{Name: "os.Args", Line: 4},
+				{Name: "Debug", Line: 5}, // not in this package
+			}},
+
+		{ID: "settings.DEBUG", Type: models.EntityVariable, Name: "DEBUG", FilePath: "app/settings.py"},
+		{ID: "settings.LEVEL", Type: models.EntityVariable, Name: "LEVEL", FilePath: "app/settings.py"},
+		{ID: "state.DEBUG", Type: models.EntityVariable, Name: "DEBUG", FilePath: "app/state.py"},
+		{ID: "jobs.run", Type: models.EntityFunction, Name: "run", FilePath: "app/jobs.py",
+			Accesses: []models.VariableAccess{
+				{Name: "DEBUG", Line: 4},
+				{Name: "settings.LEVEL", Line: 5, Write: true},
+			}},
+	}
+
+	relations := ResolveVariableAccesses(files, entities)
+
+	got := make([]string, len(relations))
+	for i, rel := range relations {
+		kind := "reads"
+		if rel.Write {
+			kind = "writes"
+		}
+		got[i] = rel.EntityID + " " + kind + " " + rel.VariableID
+	}
+	sort.Strings(got)
+
+	want := []string{
+		"config.Load reads config.current",
+		"config.Load writes config.current",
+		"jobs.run reads settings.DEBUG",
+		"jobs.run writes settings.LEVEL",
+		"main writes config.Debug",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("relations = %v, want %v", got, want)
+	}
+
+	for _, rel := range relations {
+		if rel.EntityID == "config.Load" && !rel.Write {
+			if rel.Count != 2 || !reflect.DeepEqual(rel.Lines, []int{7, 9}) {
+				t.Errorf("config.Load reads current: count %d lines %v, want 2 [7 9]", rel.Count, rel.Lines)
+			}
+		}
+	}
+}
+
+func TestPythonModuleFile(t *testing.T) {
+	paths := map[string]bool{
+		"src/app/settings.py":  true,
+		"src/app/__init__.py":  true,
+		"src/app/jobs/run.py":  true,
+		"src/app/jobs/util.py": true,
+		"README.md":            true,
+	}
+
+	tests := []struct {
+		importPath string
+		fromFile   string
+		want       string
+	}{
+		{"app.settings", "src/app/jobs/run.py", "src/app/settings.py"},
+		{"app", "src/app/jobs/run.py", "src/app/__init__.py"},
+		{".util", "src/app/jobs/run.py", "src/app/jobs/util.py"},
+		{"..settings", "src/app/jobs/run.py", "src/app/settings.py"},
+		{"requests", "src/app/jobs/run.py", ""},
+	}
+	for _, tt := range tests {
+		if got := pythonModuleFile(tt.importPath, tt.fromFile, paths); got != tt.want {
+			t.Errorf("pythonModuleFile(%q, %q) = %q, want %q", tt.importPath, tt.fromFile, got, tt.want)
+		}
+	}
+}
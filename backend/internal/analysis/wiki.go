@@ -0,0 +1,61 @@
+package analysis
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// WikiSources returns the indexed file paths a wiki page's content mentions,
+// sorted. Files are matched by their full path, or by their base name when
+// no other indexed file shares it.
+func WikiSources(content string, paths []string) []string {
+	baseCount := make(map[string]int, len(paths))
+	for _, p := range paths {
+		baseCount[path.Base(p)]++
+	}
+
+	var sources []string
+	for _, p := range paths {
+		if mentions(content, p) || (baseCount[path.Base(p)] == 1 && mentions(content, path.Base(p))) {
+			sources = append(sources, p)
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// mentions reports whether name occurs in content as a whole path, not as
+// part of a longer one
+func mentions(content, name string) bool {
+	for offset := 0; ; {
+		i := strings.Index(content[offset:], name)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(name)
+		if (start == 0 || !isPathChar(content[start-1])) && (end == len(content) || !isPathChar(content[end])) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+func isPathChar(c byte) bool {
+	return c == '/' || c == '_' || c == '-' ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// ChangedFiles returns the paths whose content hash differs between two
+// indexes of a repository, or that the later one no longer has, sorted.
+// Files only the later index has cannot be mentioned by earlier pages.
+func ChangedFiles(before, after map[string]string) []string {
+	var changed []string
+	for p, hash := range before {
+		if after[p] != hash {
+			changed = append(changed, p)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
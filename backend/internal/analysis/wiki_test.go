@@ -0,0 +1,39 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWikiSources(t *testing.T) {
+	paths := []string{
+		"internal/api/handlers.go",
+		"internal/db/graph_writer.go",
+		"internal/db/config.go",
+		"internal/config/config.go",
+		"cmd/server/main.go",
+	}
+	content := "Requests enter through `handlers.go`, which uses internal/db/graph_writer.go.\n" +
+		"Settings come from config.go. See also internal/api/handlers.go_old and cmd/server/main.gox."
+
+	got := WikiSources(content, paths)
+	want := []string{"internal/api/handlers.go", "internal/db/graph_writer.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WikiSources() = %v, want %v", got, want)
+	}
+
+	if got := WikiSources("nothing to see", paths); got != nil {
+		t.Errorf("WikiSources() = %v, want nil", got)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	before := map[string]string{"a.go": "1", "b.go": "2", "c.go": "3"}
+	after := map[string]string{"a.go": "1", "b.go": "20", "d.go": "4"}
+
+	got := ChangedFiles(before, after)
+	want := []string{"b.go", "c.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ChangedFiles() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/gofiber/fiber/v3"
+)
+
+// CollectGarbage removes nodes that are no longer attached to a repository.
+// Pass ?dryRun=true to only report what would be removed.
+func (h *Handler) CollectGarbage(c fiber.Ctx) error {
+	dryRun := fiber.Query[bool](c, "dryRun", false)
+
+	report, err := db.CollectOrphans(c.Context(), h.dbClient, dryRun)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(report)
+}
+
+// GetJobs lists running, queued and recently finished background jobs with
+// the state of the queue
+func (h *Handler) GetJobs(c fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"status": h.jobs.Status(),
+		"jobs":   h.jobs.Jobs(),
+	})
+}
+
+// PauseJobs stops queued jobs from starting; running jobs finish
+func (h *Handler) PauseJobs(c fiber.Ctx) error {
+	h.jobs.Pause()
+	return c.JSON(h.jobs.Status())
+}
+
+// ResumeJobs starts queued jobs again after a pause or drain
+func (h *Handler) ResumeJobs(c fiber.Ctx) error {
+	h.jobs.Resume()
+	return c.JSON(h.jobs.Status())
+}
+
+// JobConcurrencyRequest sets how many jobs run at once
+type JobConcurrencyRequest struct {
+	Concurrency int `json:"concurrency"`
+}
+
+// SetJobConcurrency changes how many jobs run at once
+func (h *Handler) SetJobConcurrency(c fiber.Ctx) error {
+	var input JobConcurrencyRequest
+	if err := c.Bind().Body(&input); err != nil {
+		return badRequest(c, "invalid request body")
+	}
+	if err := h.jobs.SetConcurrency(input.Concurrency); err != nil {
+		return badRequest(c, err.Error())
+	}
+	return c.JSON(h.jobs.Status())
+}
+
+// DrainJobs pauses the queue and waits for running jobs to finish, up to
+// ?timeout= (default 10m), so the backend can be redeployed without
+// interrupting work. Queued jobs run again after resume.
+func (h *Handler) DrainJobs(c fiber.Ctx) error {
+	timeout, err := time.ParseDuration(c.Query("timeout", "10m"))
+	if err != nil || timeout <= 0 {
+		return badRequest(c, "timeout must be a positive duration")
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), timeout)
+	defer cancel()
+	if err := h.jobs.Drain(ctx); err != nil {
+		return sendError(c, 504, CodeTimeout, "jobs still running: "+err.Error(), h.jobs.Status())
+	}
+	return c.JSON(h.jobs.Status())
+}
+
+// GetSearchAnalytics summarizes the searches logged over the last ?since=
+// (default 168h): the most frequent queries, those finding nothing and the
+// results most often opened, ?limit= of each (default 20). ?repo= narrows
+// them to the searches of one repository.
+func (h *Handler) GetSearchAnalytics(c fiber.Ctx) error {
+	since, err := time.ParseDuration(c.Query("since", "168h"))
+	if err != nil || since <= 0 {
+		return badRequest(c, "since must be a positive duration")
+	}
+	limit := fiber.Query[int](c, "limit", 20)
+	if limit < 1 || limit > 1000 {
+		limit = 20
+	}
+
+	analytics, err := db.GetSearchAnalytics(c.Context(), h.dbClient, time.Now().Add(-since), c.Query("repo"), limit)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"enabled":   h.cfg.SearchAnalytics,
+		"analytics": analytics,
+	})
+}
+
+// DeleteSearchAnalytics deletes the searches logged more than ?olderThan=
+// ago, all of them by default
+func (h *Handler) DeleteSearchAnalytics(c fiber.Ctx) error {
+	olderThan, err := time.ParseDuration(c.Query("olderThan", "0"))
+	if err != nil || olderThan < 0 {
+		return badRequest(c, "olderThan must be a duration")
+	}
+
+	deleted, err := db.DeleteSearchLogs(c.Context(), h.dbClient, time.Now().Add(-olderThan))
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(fiber.Map{"deleted": deleted})
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/gofiber/fiber/v3"
+)
+
+// GetLayerAnalysis returns layer assignments and layering violations
+func (h *Handler) GetLayerAnalysis(c fiber.Ctx) error {
+	repoID := c.Params("id")
+
+	files, err := h.graphReader.GetFileLayers(c.Context(), repoID)
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	return c.JSON(analysis.FindLayerViolations(files))
+}
+
+// GetChurnAnalysis returns the files changed most often in the indexed
+// history, with their line churn and owner
+func (h *Handler) GetChurnAnalysis(c fiber.Ctx) error {
+	limit := fiber.Query[int](c, "limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	churn, err := h.graphReader.GetFileChurn(c.Context(), c.Params("id"), limit)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(churn)
+}
+
+// GetHotspots returns the ?limit= (default 20) files that both change most
+// often and hold the most complex code, where refactoring pays off first.
+// ?depth= ranks directories at that depth instead.
+func (h *Handler) GetHotspots(c fiber.Ctx) error {
+	limit := fiber.Query[int](c, "limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	depth := fiber.Query[int](c, "depth", 0)
+	if depth < 0 {
+		return badRequest(c, "depth must not be negative")
+	}
+
+	hotspots, err := h.graphReader.GetHotspots(c.Context(), c.Params("id"), depth, limit)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(hotspots)
+}
+
+// GetTopCentral returns the ?limit= (default 20) functions with the highest
+// PageRank over the call graph, the architectural load-bearing ones
+func (h *Handler) GetTopCentral(c fiber.Ctx) error {
+	limit := fiber.Query[int](c, "limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	functions, err := h.graphReader.GetTopCentral(c.Context(), c.Params("id"), limit)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(functions)
+}
+
+// GetOrientation returns the ?limit= (default 20) most called functions of
+// a repository and its entry points: main, HTTP handlers and CLI commands
+func (h *Handler) GetOrientation(c fiber.Ctx) error {
+	limit := fiber.Query[int](c, "limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	report, err := h.graphReader.GetOrientation(c.Context(), c.Params("id"), limit)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(report)
+}
+
+// GetUnresolvedCalls returns the calls of a repository matching none of its
+// functions, builtins and library calls, grouped by name and by import with
+// the ?limit= (default 50) most called of each
+func (h *Handler) GetUnresolvedCalls(c fiber.Ctx) error {
+	limit := fiber.Query[int](c, "limit", 50)
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	report, err := h.graphReader.GetUnresolvedCalls(c.Context(), c.Params("id"), limit)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(report)
+}
+
+// GetMostComplex returns the ?limit= (default 20) functions with the
+// highest cyclomatic complexity
+func (h *Handler) GetMostComplex(c fiber.Ctx) error {
+	limit := fiber.Query[int](c, "limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	functions, err := h.graphReader.GetMostComplex(c.Context(), c.Params("id"), limit)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(functions)
+}
+
+// GetDuplicates returns pairs of functions whose embeddings are nearly the
+// same, most similar first; ?minScore= raises the cosine similarity reported
+// above the threshold they were found with and ?limit= bounds the pairs
+// (default 50)
+func (h *Handler) GetDuplicates(c fiber.Ctx) error {
+	limit := fiber.Query[int](c, "limit", 50)
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+	minScore := fiber.Query[float64](c, "minScore", 0)
+
+	pairs, err := h.graphReader.GetDuplicates(c.Context(), c.Params("id"), minScore, limit)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(pairs)
+}
+
+// FindDuplicates queues finding the possible duplicates of a repository
+// again, as is done after every index
+func (h *Handler) FindDuplicates(c fiber.Ctx) error {
+	repo, err := db.GetRepository(c.Context(), h.dbClient, c.Params("id"))
+	if err != nil {
+		return serverError(c, err)
+	}
+	if repo == nil {
+		return notFound(c, "repository not found")
+	}
+
+	return c.Status(202).JSON(h.enqueueDuplicates(repo))
+}
+
+// GetCouplingOutliers returns the functions with unusually many distinct
+// callers (fan-in) or callees (fan-out), the most extreme first, with the
+// fences they exceed; ?limit= bounds the functions (default 20)
+func (h *Handler) GetCouplingOutliers(c fiber.Ctx) error {
+	limit := fiber.Query[int](c, "limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	report, err := h.graphReader.GetCouplingOutliers(c.Context(), c.Params("id"), limit)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(report)
+}
+
+// ImpactRequest lists what changed for an impact analysis
+type ImpactRequest struct {
+	NodeIDs  []string `json:"nodeIds"`
+	Files    []string `json:"files"`
+	MaxDepth int      `json:"maxDepth"`
+}
+
+// GetImpact reports what changing functions or files may affect, for CI to
+// pick the tests to run. The body lists changed function IDs as nodeIds
+// and changed file paths as files; maxDepth bounds how many calls away
+// callers are followed (default 0, all of them).
+func (h *Handler) GetImpact(c fiber.Ctx) error {
+	var input ImpactRequest
+	if err := c.Bind().Body(&input); err != nil {
+		return badRequest(c, "invalid request body")
+	}
+	if len(input.NodeIDs) == 0 && len(input.Files) == 0 {
+		return badRequest(c, "nodeIds or files is required")
+	}
+	if input.MaxDepth < 0 {
+		return badRequest(c, "maxDepth must not be negative")
+	}
+
+	id := c.Params("id")
+	repo, err := db.GetRepository(c.Context(), h.dbClient, id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if repo == nil {
+		return notFound(c, "repository not found")
+	}
+
+	report, err := h.graphReader.GetImpact(c.Context(), id, input.NodeIDs, input.Files, input.MaxDepth)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(report)
+}
+
+// GetCallCycles returns groups of functions that call each other in a
+// cycle, largest first; ?limit= bounds the groups (default 50)
+func (h *Handler) GetCallCycles(c fiber.Ctx) error {
+	limit := fiber.Query[int](c, "limit", 50)
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	cycles, err := h.graphReader.GetCallCycles(c.Context(), c.Params("id"), limit)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(cycles)
+}
+
+// GetDeadCode returns exported functions nothing calls and files nothing
+// imports, leaving out tests and entry points. ?includeTests=true also
+// returns the test helpers nothing calls.
+func (h *Handler) GetDeadCode(c fiber.Ctx) error {
+	includeTests := fiber.Query[bool](c, "includeTests", false)
+	report, err := h.graphReader.GetDeadCode(c.Context(), c.Params("id"), includeTests)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(report)
+}
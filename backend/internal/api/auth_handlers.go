@@ -0,0 +1,195 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/auth"
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/gofiber/fiber/v3"
+)
+
+// APIKeyHeader carries the API key of a request; an Authorization: Bearer
+// header is accepted too
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyQuery carries the API key of GET requests that cannot set headers,
+// such as event streams and download links
+const apiKeyQuery = "apiKey"
+
+// keyPrefixLength is how much of a new key is kept to recognize it by
+const keyPrefixLength = 8
+
+// roleLocal holds the role of the caller of a request, callerLocal who
+// they are and orgLocal the organization they are scoped to, when they
+// authenticated, see authenticate
+const (
+	roleLocal   = "role"
+	callerLocal = "caller"
+	orgLocal    = "org"
+)
+
+// authEnabled reports whether callers must authenticate, which they must
+// once API keys or an OIDC issuer are configured
+func (h *Handler) authEnabled() bool {
+	return h.keys.Enabled() || h.tokens != nil
+}
+
+// authenticate identifies the caller of a request by its API key or OIDC
+// bearer token and records their role for require and their organization
+// for callerOrg. Requests without credentials pass as anonymous; invalid
+// credentials are rejected.
+func (h *Handler) authenticate(c fiber.Ctx) error {
+	if !h.authEnabled() {
+		return c.Next()
+	}
+
+	var role, org string
+	bearer, _ := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	bearer = strings.TrimSpace(bearer)
+	if h.tokens != nil && auth.LooksLikeToken(bearer) {
+		identity, err := h.tokens.Verify(c.Context(), bearer)
+		if errors.Is(err, auth.ErrInvalidToken) {
+			return statusError(c, 401, err.Error())
+		}
+		if err != nil {
+			log.Printf("Failed to verify a token: %v", err)
+			return statusError(c, 503, "the identity provider is unavailable, retry later")
+		}
+		role, org = identity.Role, identity.OrgID
+		c.Locals(callerLocal, "sub:"+identity.Subject)
+	} else if secret := requestKey(c, bearer); secret != "" {
+		grant, err := h.keys.Grant(c.Context(), secret)
+		if err != nil {
+			return serverError(c, err)
+		}
+		if grant.Scope == "" {
+			return statusError(c, 401, "invalid API key")
+		}
+		role, org = auth.ScopeRole(grant.Scope), grant.OrgID
+		c.Locals(callerLocal, "key:"+auth.Hash(secret)[:16])
+	}
+	c.Locals(roleLocal, role)
+	c.Locals(orgLocal, org)
+	return c.Next()
+}
+
+// require only lets callers with at least role through, see auth.Allows.
+// Anyone may read unless AUTH_REQUIRED_FOR_READS is set. The admin API
+// spans organizations, so callers scoped to one cannot use it.
+func (h *Handler) require(role string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if !h.authEnabled() || (role == auth.RoleViewer && !h.cfg.AuthRequiredForReads) {
+			return c.Next()
+		}
+		caller, _ := c.Locals(roleLocal).(string)
+		if caller == "" {
+			return statusError(c, 401, "authentication required")
+		}
+		if !auth.Allows(caller, role) {
+			return statusError(c, 403, "requires the "+role+" role")
+		}
+		if role == auth.RoleAdmin && callerOrg(c) != "" {
+			return statusError(c, 403, "requires the admin role of the whole instance")
+		}
+		return c.Next()
+	}
+}
+
+// requestKey returns the API key sent with a request, if any, given its
+// bearer credential
+func requestKey(c fiber.Ctx, bearer string) string {
+	if key := c.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	if bearer != "" {
+		return bearer
+	}
+	if c.Method() == fiber.MethodGet {
+		return c.Query(apiKeyQuery)
+	}
+	return ""
+}
+
+// ListAPIKeys lists the keys provisioned through the admin API, without
+// their secrets. Keys configured in API_KEYS are not listed.
+func (h *Handler) ListAPIKeys(c fiber.Ctx) error {
+	keys, err := db.ListAPIKeys(c.Context(), h.dbClient)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(keys)
+}
+
+// APIKeyRequest provisions an API key, scoped to an organization when
+// OrgID is set
+type APIKeyRequest struct {
+	Name  string `json:"name"`
+	Scope string `json:"scope"` // read, write or admin
+	OrgID string `json:"orgId,omitempty"`
+}
+
+// APIKeyResponse returns a provisioned key with the key itself
+type APIKeyResponse struct {
+	APIKey *models.APIKey `json:"apiKey"`
+	Key    string         `json:"key"`
+}
+
+// CreateAPIKey provisions a key of a scope: read, write or admin. Keys
+// scoped to an organization only see its projects and repositories, and
+// cannot be admin keys. The key is only returned in this response.
+func (h *Handler) CreateAPIKey(c fiber.Ctx) error {
+	var input APIKeyRequest
+	if err := c.Bind().Body(&input); err != nil {
+		return badRequest(c, "invalid request body")
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		return badRequest(c, "name is required")
+	}
+	if !auth.ValidScope(input.Scope) {
+		return badRequest(c, "scope must be read, write or admin")
+	}
+	if input.OrgID != "" {
+		if input.Scope == auth.ScopeAdmin {
+			return badRequest(c, "keys scoped to an organization cannot have the admin scope")
+		}
+		org, err := db.GetOrganization(c.Context(), h.dbClient, input.OrgID)
+		if err != nil {
+			return serverError(c, err)
+		}
+		if org == nil {
+			return badRequest(c, "organization not found")
+		}
+	}
+
+	secret, err := auth.Generate()
+	if err != nil {
+		return serverError(c, err)
+	}
+	key := &models.APIKey{
+		Name:   strings.TrimSpace(input.Name),
+		Scope:  input.Scope,
+		Prefix: secret[:keyPrefixLength],
+		OrgID:  input.OrgID,
+	}
+	if err := db.CreateAPIKey(c.Context(), h.dbClient, key, auth.Hash(secret)); err != nil {
+		return serverError(c, err)
+	}
+	return c.Status(201).JSON(APIKeyResponse{APIKey: key, Key: secret})
+}
+
+// DeleteAPIKey revokes a provisioned key. Other backend instances may accept
+// it for a few more seconds, until their cached lookup expires.
+func (h *Handler) DeleteAPIKey(c fiber.Ctx) error {
+	hash, err := db.DeleteAPIKey(c.Context(), h.dbClient, c.Params("keyId"))
+	if err != nil {
+		return serverError(c, err)
+	}
+	if hash == "" {
+		return notFound(c, "API key not found")
+	}
+	h.keys.Forget(hash)
+	return c.SendStatus(204)
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/cache"
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/gofiber/fiber/v3"
+)
+
+// Cache keys of the responses served through the response cache
+const (
+	cacheKeyTree              = "tree"
+	cacheKeyGraphStructure    = "graph:structure"
+	cacheKeyGraphCalls        = "graph:calls"
+	cacheKeyGraphPackages     = "graph:package"
+	cacheKeyGraphArchitecture = "graph:architecture"
+	cacheKeyGraphPackageDeps  = "graph:packages"
+	cacheKeyWikiNav           = "wiki:nav"
+	cacheKeySymbols           = "symbols"
+)
+
+// responseLoader computes a cacheable response for a repository
+type responseLoader func(ctx context.Context, repoID string) (any, error)
+
+// loaders returns what each cache key is computed from
+func (h *Handler) loaders() map[string]responseLoader {
+	return map[string]responseLoader{
+		cacheKeyTree: func(ctx context.Context, repoID string) (any, error) {
+			return h.graphReader.GetDirectoryTree(ctx, repoID)
+		},
+		cacheKeyGraphStructure: func(ctx context.Context, repoID string) (any, error) {
+			return h.graphReader.GetGraph(ctx, repoID, "structure")
+		},
+		cacheKeyGraphCalls: func(ctx context.Context, repoID string) (any, error) {
+			return h.graphReader.GetGraph(ctx, repoID, "calls")
+		},
+		cacheKeyGraphPackages: func(ctx context.Context, repoID string) (any, error) {
+			return h.graphReader.GetPackageGraph(ctx, repoID)
+		},
+		cacheKeyGraphArchitecture: func(ctx context.Context, repoID string) (any, error) {
+			return h.graphReader.GetArchitectureGraph(ctx, repoID, 0)
+		},
+		cacheKeyGraphPackageDeps: func(ctx context.Context, repoID string) (any, error) {
+			return h.graphReader.GetPackageDependencyGraph(ctx, repoID)
+		},
+		cacheKeyWikiNav: func(ctx context.Context, repoID string) (any, error) {
+			return h.wikiReader.GetNavigation(ctx, repoID)
+		},
+		cacheKeySymbols: func(ctx context.Context, repoID string) (any, error) {
+			return h.graphReader.ListSymbols(ctx, repoID)
+		},
+	}
+}
+
+// skipCache reports whether a request asks for a fresh response with
+// ?cache=false. The fresh response replaces the cached one.
+func skipCache(c fiber.Ctx) bool {
+	return !fiber.Query[bool](c, "cache", true)
+}
+
+// filterKey is the part of a cache key naming the filters of a graph
+func filterKey(filter db.GraphFilter) string {
+	params := url.Values{}
+	params.Set("path", filter.PathPrefix)
+	params.Set("language", filter.Language)
+	params.Set("entityType", strings.Join(filter.Types, ","))
+	params.Set("name", filter.NamePattern)
+	params.Set("minDegree", strconv.Itoa(filter.MinDegree))
+	return params.Encode()
+}
+
+// sendTagged sends an encoded JSON response with its ETag, or 304 Not
+// Modified without a body when the request's If-None-Match holds the ETag.
+// Caches may store the response but must revalidate it, as it changes with
+// every reindex; only private caches when reads need an API key.
+func (h *Handler) sendTagged(c fiber.Ctx, data []byte, etag string) error {
+	c.Set(fiber.HeaderETag, etag)
+	if h.cfg.AuthRequiredForReads {
+		c.Set(fiber.HeaderCacheControl, "private, no-cache")
+	} else {
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+	}
+	if c.Fresh() {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(data)
+}
+
+// sendTaggedJSON encodes v and sends it as sendTagged does, for responses
+// not cached in their encoded form
+func (h *Handler) sendTaggedJSON(c fiber.Ctx, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return h.sendTagged(c, data, cache.ETag(data))
+}
+
+// cachedJSON serves the response for key from the cache, computing and
+// storing it on a miss
+func (h *Handler) cachedJSON(c fiber.Ctx, repoID, key string) error {
+	if data, etag, ok := h.cache.GetTagged(repoID, key); ok && !skipCache(c) {
+		return h.sendTagged(c, data, etag)
+	}
+
+	data, etag, err := h.loadResponse(c.Context(), repoID, key)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return h.sendTagged(c, data, etag)
+}
+
+// cachedGraphPage serves a page of the graph cached under key, caching the
+// page alongside it
+func (h *Handler) cachedGraphPage(c fiber.Ctx, repoID, key string, offset, limit int) error {
+	pageKey := fmt.Sprintf("%s:%d:%d", key, offset, limit)
+	if data, etag, ok := h.cache.GetTagged(repoID, pageKey); ok && !skipCache(c) {
+		return h.sendTagged(c, data, etag)
+	}
+
+	graph, err := h.cachedGraph(c.Context(), repoID, key, skipCache(c))
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	page, err := json.Marshal(db.PageGraph(graph, offset, limit))
+	if err != nil {
+		return serverError(c, err)
+	}
+	return h.sendTagged(c, page, h.cache.Set(repoID, pageKey, page))
+}
+
+// cachedClusteredGraph serves the whole graph cached under key, folded
+// into directory clusters when it has more than GraphClusterNodes nodes and
+// paged by limit otherwise. The response is cached alongside the graph.
+func (h *Handler) cachedClusteredGraph(c fiber.Ctx, repoID, key string, limit int) error {
+	clusteredKey := key + ":clustered"
+	if data, etag, ok := h.cache.GetTagged(repoID, clusteredKey); ok && !skipCache(c) {
+		return h.sendTagged(c, data, etag)
+	}
+
+	graph, err := h.cachedGraph(c.Context(), repoID, key, skipCache(c))
+	if err != nil {
+		return serverError(c, err)
+	}
+	switch {
+	case len(graph.Nodes) > h.cfg.GraphClusterNodes:
+		graph = db.ClusterGraph(graph, nil)
+	case limit > 0:
+		graph = db.PageGraph(graph, 0, limit)
+	}
+
+	data, err := json.Marshal(graph)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return h.sendTagged(c, data, h.cache.Set(repoID, clusteredKey, data))
+}
+
+// cachedGraph decodes the graph cached under key, computing it on a miss
+// or when fresh
+func (h *Handler) cachedGraph(ctx context.Context, repoID, key string, fresh bool) (*db.GraphData, error) {
+	var graph db.GraphData
+	if err := h.cachedValue(ctx, repoID, key, fresh, h.loaders()[key], &graph); err != nil {
+		return nil, err
+	}
+	return &graph, nil
+}
+
+// cachedValue decodes the response cached under key into v, computing it
+// with load on a miss or when fresh. Keys of responses depending on request
+// parameters must include them.
+func (h *Handler) cachedValue(ctx context.Context, repoID, key string, fresh bool, load responseLoader, v any) error {
+	data, ok := h.cache.Get(repoID, key)
+	if !ok || fresh {
+		var err error
+		if data, _, err = h.storeResponse(ctx, repoID, key, load); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(data, v)
+}
+
+// loadResponse computes, encodes and caches the response for key,
+// returning it with its ETag
+func (h *Handler) loadResponse(ctx context.Context, repoID, key string) ([]byte, string, error) {
+	return h.storeResponse(ctx, repoID, key, h.loaders()[key])
+}
+
+// storeResponse computes a response with load and caches it under key,
+// returning it with its ETag
+func (h *Handler) storeResponse(ctx context.Context, repoID, key string, load responseLoader) ([]byte, string, error) {
+	value, err := load(ctx, repoID)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, h.cache.Set(repoID, key, data), nil
+}
+
+// warmCache precomputes every cached response of a freshly indexed
+// repository so the first visitor doesn't pay for cold queries
+func (h *Handler) warmCache(ctx context.Context, repoID string) {
+	start := time.Now()
+	for key := range h.loaders() {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, _, err := h.loadResponse(ctx, repoID, key); err != nil {
+			log.Printf("Cache warmup of %s for %s failed: %v", key, repoID, err)
+		}
+	}
+	log.Printf("Cache warmed for %s in %s", repoID, time.Since(start).Round(time.Millisecond))
+}
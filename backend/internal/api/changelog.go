@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/artifact"
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// changelogHeader opens the changelog wiki page
+const changelogHeader = "# What Changed\n\nChanges to the code graph found by each reindex, newest first.\n"
+
+// changelogOrder places the changelog after the generated pages
+const changelogOrder = 10000
+
+// recordChangelog adds a "What changed" section to the repository's
+// changelog wiki page, comparing the artifact of a run with the previous
+// run's. Nothing is written for the first run or when nothing changed.
+func (h *Handler) recordChangelog(ctx context.Context, repo *models.Repository, run *models.IndexRun, doc *artifact.Document) error {
+	runs, err := db.ListIndexRuns(ctx, h.dbClient, repo.ID, 20)
+	if err != nil {
+		return err
+	}
+	var prevRunID string
+	for _, prev := range runs {
+		if prev.ID != run.ID && prev.Status == "ready" && prev.Artifact {
+			prevRunID = prev.ID
+			break
+		}
+	}
+	if prevRunID == "" {
+		return nil
+	}
+
+	prev, err := h.readArtifact(repo.ID, prevRunID)
+	if err != nil {
+		return err
+	}
+
+	changes := artifact.Diff(prev, doc)
+	if changes.Empty() {
+		return nil
+	}
+
+	existing, err := h.wikiReader.GetPage(ctx, repo.ID, db.ChangelogSlug)
+	if err != nil {
+		return err
+	}
+	content := ""
+	if existing != nil {
+		content = existing.Content
+	}
+
+	defer h.cache.Delete(repo.ID, cacheKeyWikiNav)
+	return h.wikiWriter.WritePage(ctx, &models.WikiPage{
+		RepoID:  repo.ID,
+		Slug:    db.ChangelogSlug,
+		Title:   "What Changed",
+		Content: prependChangelog(content, changes.Markdown(time.Now())),
+		Order:   changelogOrder,
+	})
+}
+
+// readArtifact decodes the stored artifact of a run
+func (h *Handler) readArtifact(repoID, runID string) (*artifact.Document, error) {
+	data, err := h.artifacts.Read(repoID, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact of run %s: %w", runID, err)
+	}
+	var doc artifact.Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode artifact of run %s: %w", runID, err)
+	}
+	return &doc, nil
+}
+
+// prependChangelog adds a section to the top of the changelog page content
+func prependChangelog(content, section string) string {
+	rest := strings.TrimSpace(strings.TrimPrefix(content, changelogHeader))
+	if rest == "" {
+		return changelogHeader + "\n" + section
+	}
+	return changelogHeader + "\n" + section + "\n" + rest + "\n"
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/agent"
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/dpolishuk/neograph/backend/internal/metrics"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/gofiber/fiber/v3"
+)
+
+// agentTypes are the agents conversations can be held with
+var agentTypes = []string{"explorer", "analyzer", "doc_writer"}
+
+// conversationHistory bounds how many earlier messages of a conversation
+// the agent is sent, latest first, so long conversations still fit its
+// context
+const conversationHistory = 40
+
+// ConversationRequest starts a conversation on a repository
+type ConversationRequest struct {
+	Title     string `json:"title"`     // the first message, shortened, by default
+	AgentType string `json:"agentType"` // explorer by default, analyzer or doc_writer
+}
+
+// ConversationMessageRequest continues a conversation
+type ConversationMessageRequest struct {
+	Message string `json:"message"`
+}
+
+// ListConversations returns the conversations held on a repository, most
+// recently continued first, without their messages
+func (h *Handler) ListConversations(c fiber.Ctx) error {
+	convs, err := db.ListConversations(c.Context(), h.dbClient, c.Params("id"))
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(convs)
+}
+
+// CreateConversation starts a conversation with an agent on a repository,
+// to be continued with SendConversationMessage
+func (h *Handler) CreateConversation(c fiber.Ctx) error {
+	id := c.Params("id")
+
+	var input ConversationRequest
+	if err := c.Bind().Body(&input); err != nil {
+		return badRequest(c, "invalid request body")
+	}
+	if input.AgentType == "" {
+		input.AgentType = "explorer"
+	}
+	if !slices.Contains(agentTypes, input.AgentType) {
+		return badRequest(c, "agentType must be one of "+strings.Join(agentTypes, ", "))
+	}
+
+	repo, err := db.GetRepository(c.Context(), h.dbClient, id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if repo == nil {
+		return notFound(c, "repository not found")
+	}
+
+	conv := &models.Conversation{RepoID: id, Title: strings.TrimSpace(input.Title), AgentType: input.AgentType}
+	if err := db.CreateConversation(c.Context(), h.dbClient, conv); err != nil {
+		return serverError(c, err)
+	}
+	return c.Status(201).JSON(conv)
+}
+
+// GetConversation returns a conversation with its messages, oldest first
+func (h *Handler) GetConversation(c fiber.Ctx) error {
+	conv, err := db.GetConversation(c.Context(), h.dbClient, c.Params("id"), c.Params("conversationId"))
+	if err != nil {
+		return serverError(c, err)
+	}
+	if conv == nil {
+		return notFound(c, "conversation not found")
+	}
+	return c.JSON(conv)
+}
+
+// DeleteConversation deletes a conversation and its messages
+func (h *Handler) DeleteConversation(c fiber.Ctx) error {
+	found, err := db.DeleteConversation(c.Context(), h.dbClient, c.Params("id"), c.Params("conversationId"))
+	if err != nil {
+		return serverError(c, err)
+	}
+	if !found {
+		return notFound(c, "conversation not found")
+	}
+	return c.SendStatus(204)
+}
+
+// SendConversationMessage continues a conversation: the agent answers the
+// message knowing the earlier ones, and both are saved. Returns the two new
+// messages, the user's and the agent's.
+func (h *Handler) SendConversationMessage(c fiber.Ctx) error {
+	conv, message, err := h.conversationMessage(c)
+	if err != nil {
+		return err
+	}
+
+	response, err := h.agentProxy.Send(c.Context(), conversationChatRequest(conv, message))
+	if err != nil {
+		metrics.AgentErrors.Inc(conv.RepoID)
+		return upstreamError(c, "failed to communicate with agent service", err)
+	}
+
+	messages, err := h.saveConversationTurn(c.Context(), conv, message, response)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(messages)
+}
+
+// StreamConversationMessage continues a conversation like
+// SendConversationMessage, streaming the answer as ProxyAgentChatStream
+// does. The message and answer are saved once the agent is done; a chat
+// that fails or that the client leaves is not.
+func (h *Handler) StreamConversationMessage(c fiber.Ctx) error {
+	conv, message, err := h.conversationMessage(c)
+	if err != nil {
+		return err
+	}
+
+	// The body is streamed after the handler returns, so the agent is
+	// called, and the answer saved, on a context of its own
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := h.agentProxy.ChatStream(ctx, conversationChatRequest(conv, message))
+	if err != nil {
+		cancel()
+		metrics.AgentErrors.Inc(conv.RepoID)
+		return upstreamError(c, "failed to communicate with agent service", err)
+	}
+
+	setEventStreamHeaders(c)
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		defer stream.Close()
+		answer := relayAgentEvents(w, stream, conv.RepoID)
+		if answer == nil {
+			return
+		}
+		if _, err := h.saveConversationTurn(ctx, conv, message, answer); err != nil {
+			log.Printf("Failed to save conversation %s: %v", conv.ID, err)
+		}
+	})
+}
+
+// conversationMessage reads the conversation a message is sent to and the
+// message. A missing conversation or message is returned as a *fiber.Error.
+func (h *Handler) conversationMessage(c fiber.Ctx) (*models.Conversation, string, error) {
+	var input ConversationMessageRequest
+	if err := c.Bind().Body(&input); err != nil {
+		return nil, "", fiber.NewError(400, "invalid request body")
+	}
+	if strings.TrimSpace(input.Message) == "" {
+		return nil, "", fiber.NewError(400, "message is required")
+	}
+
+	conv, err := db.GetConversation(c.Context(), h.dbClient, c.Params("id"), c.Params("conversationId"))
+	if err != nil {
+		return nil, "", err
+	}
+	if conv == nil {
+		return nil, "", fiber.NewError(404, "conversation not found")
+	}
+	return conv, input.Message, nil
+}
+
+// conversationChatRequest asks the agent of a conversation about its
+// repository, with the latest earlier messages
+func conversationChatRequest(conv *models.Conversation, message string) agent.ChatRequest {
+	earlier := conv.Messages
+	if len(earlier) > conversationHistory {
+		earlier = earlier[len(earlier)-conversationHistory:]
+	}
+	history := make([]agent.ChatMessage, len(earlier))
+	for i, m := range earlier {
+		history[i] = agent.ChatMessage{Role: m.Role, Content: m.Content}
+	}
+	return agent.ChatRequest{
+		Message:   message,
+		RepoID:    &conv.RepoID,
+		AgentType: conv.AgentType,
+		History:   history,
+	}
+}
+
+// saveConversationTurn saves a message sent to a conversation and the
+// agent's answer, returning them
+func (h *Handler) saveConversationTurn(ctx context.Context, conv *models.Conversation, message string, answer *agent.ChatResponse) ([]models.ConversationMessage, error) {
+	reply := models.ConversationMessage{Role: "assistant", Content: answer.Response}
+	if len(answer.ToolCalls) > 0 {
+		toolCalls, err := json.Marshal(answer.ToolCalls)
+		if err != nil {
+			return nil, err
+		}
+		reply.ToolCalls = toolCalls
+	}
+
+	messages := []models.ConversationMessage{{Role: "user", Content: message}, reply}
+	if err := db.AppendConversationMessages(ctx, h.dbClient, conv, messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"github.com/dpolishuk/neograph/backend/internal/coverage"
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/gofiber/fiber/v3"
+)
+
+// maxUnmatchedFiles caps the unmatched report files listed in an upload
+// response
+const maxUnmatchedFiles = 20
+
+// CoverageUploadResult reports how an uploaded coverage report mapped onto
+// the graph
+type CoverageUploadResult struct {
+	Format         string   `json:"format"`
+	Files          int      `json:"files"`    // repository files annotated
+	Entities       int      `json:"entities"` // entities annotated
+	Unmatched      int      `json:"unmatched"`
+	UnmatchedFiles []string `json:"unmatchedFiles"` // report paths without a file
+}
+
+// UploadCoverage annotates a repository's files and entities with the line
+// coverage in a Go coverprofile, lcov or Cobertura report sent as the
+// request body. ?format=go|lcov|cobertura overrides detection. Each upload
+// replaces the previous annotations.
+func (h *Handler) UploadCoverage(c fiber.Ctx) error {
+	id := c.Params("id")
+
+	repo, err := db.GetRepository(c.Context(), h.dbClient, id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if repo == nil {
+		return notFound(c, "repository not found")
+	}
+
+	body := c.Body()
+	if len(body) == 0 {
+		return badRequest(c, "request body must hold a coverage report")
+	}
+	report, format, err := coverage.Parse(body, c.Query("format"))
+	if err != nil {
+		return badRequest(c, err.Error())
+	}
+
+	paths, err := h.graphReader.GetFilePaths(c.Context(), id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	entities, err := h.graphReader.GetEntityLocations(c.Context(), id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	files, stats, unmatched := coverage.Match(report, paths, entities)
+
+	if err := h.writer.WriteCoverage(c.Context(), id, format, files, stats); err != nil {
+		return serverError(c, err)
+	}
+	h.cache.Invalidate(id)
+
+	listed := unmatched
+	if len(listed) > maxUnmatchedFiles {
+		listed = listed[:maxUnmatchedFiles]
+	}
+	if listed == nil {
+		listed = []string{}
+	}
+	return c.JSON(CoverageUploadResult{
+		Format:         format,
+		Files:          len(files),
+		Entities:       len(stats),
+		Unmatched:      len(unmatched),
+		UnmatchedFiles: listed,
+	})
+}
+
+// GetCoverageGaps lists functions covered at most ?maxCoverage percent
+// (default 0), the most called first, so untested code many others depend
+// on stands out
+func (h *Handler) GetCoverageGaps(c fiber.Ctx) error {
+	id := c.Params("id")
+
+	maxCoverage := fiber.Query[float64](c, "maxCoverage", 0)
+	if maxCoverage < 0 || maxCoverage > 100 {
+		return badRequest(c, "maxCoverage must be between 0 and 100")
+	}
+	limit := fiber.Query[int](c, "limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	gaps, err := h.graphReader.GetCoverageGaps(c.Context(), id, maxCoverage, limit)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(gaps)
+}
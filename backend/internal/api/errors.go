@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/dpolishuk/neograph/backend/internal/embedding"
+	"github.com/gofiber/fiber/v3"
+)
+
+// Codes of error responses, which clients tell errors apart by; each
+// status has one, and a few errors a more specific one
+const (
+	CodeInvalidRequest = "invalid_request"   // 400
+	CodeUnauthorized   = "unauthorized"      // 401
+	CodeForbidden      = "forbidden"         // 403
+	CodeNotFound       = "not_found"         // 404
+	CodeConflict       = "conflict"          // 409
+	CodeTooLarge       = "payload_too_large" // 413
+	CodeRateLimited    = "rate_limited"      // 429
+	CodeInternal       = "internal_error"    // 500
+	CodeUpstream       = "upstream_error"    // 502, from GitHub, the agent or the embedding service
+	CodeUnavailable    = "unavailable"       // 503
+	CodeTimeout        = "timeout"           // 504
+
+	CodeIdempotencyKeyReused   = "idempotency_key_reused"   // 422
+	CodeSemanticSearchDisabled = "semantic_search_disabled" // 503
+)
+
+// ErrorBody describes why a request failed
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+}
+
+// ErrorResponse is the body of every error response
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// statusCodes are the codes of statuses without a more specific one
+var statusCodes = map[int]string{
+	400: CodeInvalidRequest,
+	401: CodeUnauthorized,
+	403: CodeForbidden,
+	404: CodeNotFound,
+	409: CodeConflict,
+	413: CodeTooLarge,
+	429: CodeRateLimited,
+	500: CodeInternal,
+	502: CodeUpstream,
+	503: CodeUnavailable,
+	504: CodeTimeout,
+}
+
+// sendError responds with an error of a status and code
+func sendError(c fiber.Ctx, status int, code, message string, details any) error {
+	return c.Status(status).JSON(ErrorResponse{Error: ErrorBody{Code: code, Message: message, Details: details}})
+}
+
+// statusError responds with an error of a status, coded by the status
+func statusError(c fiber.Ctx, status int, message string) error {
+	code, ok := statusCodes[status]
+	if !ok {
+		code = CodeInternal
+	}
+	return sendError(c, status, code, message, nil)
+}
+
+func badRequest(c fiber.Ctx, message string) error {
+	return statusError(c, 400, message)
+}
+
+func notFound(c fiber.Ctx, message string) error {
+	return statusError(c, 404, message)
+}
+
+// serverError responds to an unexpected error without revealing it, as it
+// may hold queries or internal addresses; it is logged instead. Timeouts,
+// an unreachable database or embedding service and constraint violations
+// get their own statuses.
+func serverError(c fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return statusError(c, 504, "request timed out")
+	case errors.Is(err, embedding.ErrUnavailable):
+		return statusError(c, 503, "the embedding service is unavailable")
+	case db.IsUnavailable(err):
+		log.Printf("%s %s: database unavailable: %v", c.Method(), c.Path(), err)
+		return statusError(c, 503, "the database is unavailable, retry later")
+	case db.IsConflict(err):
+		return statusError(c, 409, "conflicts with existing data")
+	}
+	log.Printf("%s %s: %v", c.Method(), c.Path(), err)
+	return statusError(c, 500, "internal error")
+}
+
+// upstreamError responds to a service the backend relies on failing; what
+// failed is logged rather than revealed
+func upstreamError(c fiber.Ctx, message string, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, embedding.ErrUnavailable) {
+		return serverError(c, err)
+	}
+	log.Printf("%s %s: %s: %v", c.Method(), c.Path(), message, err)
+	return statusError(c, 502, message)
+}
+
+// ErrorHandler answers errors returned by handlers and Fiber itself, such
+// as unknown routes and bodies over the limit, with an error response
+func ErrorHandler(c fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return statusError(c, fiberErr.Code, fiberErr.Message)
+	}
+	return serverError(c, err)
+}
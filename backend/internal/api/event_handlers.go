@@ -0,0 +1,183 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/dpolishuk/neograph/backend/internal/events"
+	"github.com/dpolishuk/neograph/backend/internal/indexer"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/gofiber/fiber/v3"
+)
+
+// Index stages run by the handler around the pipeline's, see indexer.StageParse
+const (
+	stageClone   = "clone"   // cloning or updating the repository
+	stageWrite   = "write"   // writing the graph
+	stageHistory = "history" // indexing recent commits
+	stageLink    = "link"    // linking with other repositories
+)
+
+// eventKeepalive is how often an idle event stream gets a comment, which
+// keeps proxies from closing it and notices clients that left
+const eventKeepalive = 15 * time.Second
+
+// progressInterval throttles the progress events of one index stage
+const progressInterval = 500 * time.Millisecond
+
+// GetRepositoryEvents streams the status transitions of a repository as
+// server-sent events: repository status, index stages and their progress,
+// and wiki generation, see events.Event. The current repository and wiki
+// statuses are sent first, so clients need no request of their own.
+func (h *Handler) GetRepositoryEvents(c fiber.Ctx) error {
+	id := c.Params("id")
+	repo, err := db.GetRepository(c.Context(), h.dbClient, id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if repo == nil {
+		return notFound(c, "repository not found")
+	}
+	wiki, err := h.wikiWriter.GetWikiStatus(c.Context(), id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if wiki == nil {
+		wiki = &models.WikiStatus{Status: "none"}
+	}
+
+	// Subscribe before responding so no transition falls in between
+	stream, unsubscribe := h.events.Subscribe(id)
+	initial := []events.Event{
+		{Type: events.TypeRepository, RepoID: id, Status: repo.Status},
+		wikiEvent(id, wiki),
+	}
+
+	setEventStreamHeaders(c)
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+		for _, event := range initial {
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		}
+
+		keepalive := time.NewTicker(eventKeepalive)
+		defer keepalive.Stop()
+		for {
+			select {
+			case event, open := <-stream:
+				if !open {
+					return
+				}
+				if err := writeEvent(w, event); err != nil {
+					return
+				}
+			case <-keepalive.C:
+				// A write to a client that left fails
+				if _, err := w.WriteString(": keepalive\n\n"); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+// CloseEventStreams ends the event streams of all clients, which otherwise
+// stay open and keep the server from shutting down
+func (h *Handler) CloseEventStreams() {
+	h.events.Close()
+}
+
+// setEventStreamHeaders marks a response as server-sent events, which
+// proxies must pass on as they come
+func setEventStreamHeaders(c fiber.Ctx) {
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set("X-Accel-Buffering", "no") // nginx would hold events back
+}
+
+// writeEvent writes an event named by its type and flushes it to the client
+func writeEvent(w *bufio.Writer, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to encode %s event of %s: %v", event.Type, event.RepoID, err)
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// setRepositoryStatus records a repository's status and tells its event
+// subscribers, with the index stage that failed for an error status. The
+// error itself is logged by the caller, as it may hold internal addresses.
+func (h *Handler) setRepositoryStatus(ctx context.Context, repoID, status, failedStage string) {
+	if err := db.UpdateRepositoryStatus(ctx, h.dbClient, repoID, status); err != nil {
+		log.Printf("Failed to update status of %s: %v", repoID, err)
+	}
+	event := events.Event{Type: events.TypeRepository, RepoID: repoID, Status: status}
+	if failedStage != "" {
+		event.Stage = failedStage
+		event.Error = indexFailure(failedStage)
+	}
+	h.events.Publish(event)
+}
+
+// indexFailure describes an index failing at a stage to clients
+func indexFailure(stage string) string {
+	return "indexing failed at the " + stage + " stage"
+}
+
+// setWikiStatus records a repository's wiki generation status and tells its
+// event subscribers
+func (h *Handler) setWikiStatus(ctx context.Context, repoID string, status *models.WikiStatus) {
+	if err := h.wikiWriter.UpdateWikiStatus(ctx, repoID, status); err != nil {
+		log.Printf("Failed to update wiki status of %s: %v", repoID, err)
+	}
+	h.events.Publish(wikiEvent(repoID, status))
+}
+
+// wikiEvent reports a wiki generation status
+func wikiEvent(repoID string, status *models.WikiStatus) events.Event {
+	return events.Event{
+		Type:        events.TypeWiki,
+		RepoID:      repoID,
+		Status:      status.Status,
+		Progress:    status.Progress,
+		CurrentPage: status.CurrentPage,
+		Total:       status.TotalPages,
+		Error:       status.ErrorMessage,
+	}
+}
+
+// indexProgress returns a listener publishing the index progress of a
+// repository: each new stage at once, progress within a stage at most every
+// progressInterval and when it completes
+func (h *Handler) indexProgress(repoID string) indexer.ProgressFunc {
+	var mu sync.Mutex
+	var stage string
+	var last time.Time
+	return func(s string, done, total int) {
+		mu.Lock()
+		now := time.Now()
+		if s == stage && done < total && now.Sub(last) < progressInterval {
+			mu.Unlock()
+			return
+		}
+		stage, last = s, now
+		mu.Unlock()
+
+		h.events.Publish(events.Event{Type: events.TypeIndex, RepoID: repoID, Stage: s, Done: done, Total: total})
+	}
+}
@@ -0,0 +1,332 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/archive"
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/dpolishuk/neograph/backend/internal/jobs"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/gofiber/fiber/v3"
+)
+
+// ExportEmbeddings downloads a repository's entities with their embedding
+// vectors and the edges between them as NDJSON, one JSON object per line:
+// "node" records first, then "edge" records. Meant for external ML
+// pipelines training on the code graph.
+func (h *Handler) ExportEmbeddings(c fiber.Ctx) error {
+	id := c.Params("id")
+
+	if format := c.Query("format", "ndjson"); format != "ndjson" {
+		return badRequest(c, "unsupported format "+format+", must be 'ndjson'")
+	}
+
+	repo, err := db.GetRepository(c.Context(), h.dbClient, id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if repo == nil {
+		return notFound(c, "repository not found")
+	}
+
+	c.Attachment(fmt.Sprintf("neograph-%s-embeddings.ndjson", id))
+	c.Set(fiber.HeaderContentType, "application/x-ndjson")
+
+	// The body is streamed after the handler returns, so the export runs
+	// on its own context rather than the request's
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		enc := json.NewEncoder(w)
+		err := h.graphReader.ExportEmbeddings(context.Background(), id, func(record any) error {
+			return enc.Encode(record)
+		})
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil {
+			log.Printf("Failed to export embeddings of %s: %v", repo.Name, err)
+		}
+	})
+}
+
+// ExportGraph downloads a repository's structure, calls or architecture
+// graph (?type=) for Graphviz, yEd or Gephi as ?format=dot, graphml or gexf.
+// The filters and architecture ?depth= of GetRepositoryGraph apply; the node
+// limit does not.
+func (h *Handler) ExportGraph(c fiber.Ctx) error {
+	id := c.Params("id")
+	format := c.Query("format", "dot")
+	contentType, ok := db.GraphExportFormats[format]
+	if !ok {
+		return badRequest(c, "unsupported format "+format+", must be 'dot', 'graphml' or 'gexf'")
+	}
+	graphType := c.Query("type", "structure")
+	if graphType != "structure" && graphType != "calls" && graphType != "architecture" {
+		return badRequest(c, "invalid graph type, must be 'structure', 'calls' or 'architecture'")
+	}
+	depth := fiber.Query[int](c, "depth", 0)
+	if depth < 0 {
+		return badRequest(c, "depth must not be negative")
+	}
+	filter, err := graphFilter(c)
+	if err != nil {
+		return badRequest(c, err.Error())
+	}
+	if graphType == "architecture" && !filter.IsZero() {
+		return badRequest(c, "filters are not supported with type=architecture")
+	}
+
+	repo, err := db.GetRepository(c.Context(), h.dbClient, id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if repo == nil {
+		return notFound(c, "repository not found")
+	}
+
+	var graph *db.GraphData
+	if graphType == "architecture" {
+		graph, err = h.graphReader.GetArchitectureGraph(c.Context(), id, depth)
+	} else {
+		graph, err = h.graphReader.GetFilteredGraph(c.Context(), id, graphType, filter)
+	}
+	if err != nil {
+		return serverError(c, err)
+	}
+	var buf bytes.Buffer
+	if err := db.WriteGraph(&buf, graph, format); err != nil {
+		return serverError(c, err)
+	}
+
+	c.Attachment(fmt.Sprintf("neograph-%s-%s.%s", id, graphType, format))
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.Send(buf.Bytes())
+}
+
+// ExportSnapshot downloads a repository's code graph and history as an
+// NDJSON snapshot that ImportSnapshot can load into another NeoGraph
+// instance without cloning and indexing the repository again
+func (h *Handler) ExportSnapshot(c fiber.Ctx) error {
+	id := c.Params("id")
+
+	repo, err := db.GetRepository(c.Context(), h.dbClient, id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if repo == nil {
+		return notFound(c, "repository not found")
+	}
+
+	c.Attachment(fmt.Sprintf("neograph-%s-snapshot.ndjson", id))
+	c.Set(fiber.HeaderContentType, "application/x-ndjson")
+
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		enc := json.NewEncoder(w)
+		err := h.graphReader.ExportSnapshot(context.Background(), repo, func(record any) error {
+			return enc.Encode(record)
+		})
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil {
+			log.Printf("Failed to export snapshot of %s: %v", repo.Name, err)
+		}
+	})
+}
+
+// ExportArchive downloads a repository's full index as a tar.gz archive:
+// metadata, the entities and relationships of its code graph and its wiki
+// pages, embeddings included, see archive.Writer. Another NeoGraph instance
+// can import it without access to the repository, for air-gapped
+// environments and backups.
+func (h *Handler) ExportArchive(c fiber.Ctx) error {
+	id := c.Params("id")
+
+	repo, err := db.GetRepository(c.Context(), h.dbClient, id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if repo == nil {
+		return notFound(c, "repository not found")
+	}
+
+	// The records are spooled before responding, so a failed export still
+	// gets an error response
+	w, err := archive.NewWriter()
+	if err != nil {
+		return serverError(c, err)
+	}
+	err = h.graphReader.ExportSnapshot(c.Context(), repo, func(record any) error {
+		switch record.(type) {
+		case db.SnapshotHeader:
+			return nil // the archive's metadata describes the repository
+		case db.SnapshotNode:
+			return w.Add(archive.EntitiesFile, record)
+		default:
+			return w.Add(archive.RelationshipsFile, record)
+		}
+	})
+	if err == nil {
+		err = h.wikiReader.ExportWiki(c.Context(), id, func(record any) error {
+			return w.Add(archive.WikiFile, record)
+		})
+	}
+	if err != nil {
+		w.Close()
+		return serverError(c, err)
+	}
+
+	meta := archive.Metadata{
+		ExportedAt:      time.Now().UTC(),
+		Repository:      *repo,
+		SnapshotVersion: db.SnapshotVersion,
+	}
+	if space, _ := h.dbClient.VectorSpaces(); space.Property != "" {
+		meta.Embeddings = &archive.Embeddings{Model: space.Model, Dimension: space.Dimension, Property: space.Property}
+	}
+
+	c.Attachment(fmt.Sprintf("neograph-%s.tar.gz", id))
+	c.Set(fiber.HeaderContentType, "application/gzip")
+	return c.SendStreamWriter(func(bw *bufio.Writer) {
+		defer w.Close()
+		err := w.WriteTo(bw, meta)
+		if err == nil {
+			err = bw.Flush()
+		}
+		if err != nil {
+			log.Printf("Failed to export archive of %s: %v", repo.Name, err)
+		}
+	})
+}
+
+// SnapshotImportResult is the repository created from a snapshot or
+// archive and what was written into it
+type SnapshotImportResult struct {
+	Repository *models.Repository `json:"repository"`
+	db.SnapshotImport
+	WikiPages int `json:"wikiPages,omitempty"` // imported from an archive
+	// whether the archive's embeddings are of another model, so its
+	// entities and pages are being re-embedded in the background
+	Reembedding bool `json:"reembedding,omitempty"`
+}
+
+// gzipMagic starts gzip data, which tells an archive from an NDJSON
+// snapshot
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// ImportSnapshot creates a repository from a snapshot, or an archive
+// ExportArchive packed, uploaded as the request body, which UPLOAD_BODY_LIMIT
+// bounds, in the ?project= given, see repositoryProject. An archive brings
+// the wiki and embeddings along; see reembedArchive for embeddings of
+// another model. A snapshot or archive that fails to import leaves no
+// repository behind.
+func (h *Handler) ImportSnapshot(c fiber.Ctx) error {
+	body := c.Body()
+	var graph io.Reader = bytes.NewReader(body)
+	var ar *archive.Reader
+	if bytes.HasPrefix(body, gzipMagic) {
+		var err error
+		if ar, err = archive.NewReader(bytes.NewReader(body)); err != nil {
+			return badRequest(c, err.Error())
+		}
+		header, err := json.Marshal(db.SnapshotHeader{
+			Kind:       "snapshot",
+			Version:    ar.Metadata.SnapshotVersion,
+			Repository: ar.Metadata.Repository,
+		})
+		if err != nil {
+			return serverError(c, err)
+		}
+		graph = io.MultiReader(bytes.NewReader(append(header, '\n')), ar.Records(archive.EntitiesFile, archive.RelationshipsFile))
+	}
+
+	snapshot, err := db.NewSnapshotReader(graph)
+	if err != nil {
+		return badRequest(c, err.Error())
+	}
+	project, err := h.repositoryProject(c, c.Query("project"))
+	if err != nil {
+		return err
+	}
+
+	// The project and organization the snapshot was exported from are not
+	// kept; it belongs to the importer's
+	source := snapshot.Header.Repository
+	repo := &models.Repository{
+		URL:           source.URL,
+		Name:          source.Name,
+		DefaultBranch: source.DefaultBranch,
+		Status:        "indexing", // keeps the scheduler away until it is written
+	}
+	if project != nil {
+		repo.ProjectID, repo.OrgID = project.ID, project.OrgID
+	}
+	repo, err = db.CreateRepository(c.Context(), h.dbClient, repo)
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	result := SnapshotImportResult{Repository: repo}
+	stats, err := h.writer.ImportSnapshot(c.Context(), repo.ID, snapshot)
+	if err == nil {
+		result.SnapshotImport = *stats
+		err = h.writer.UpdateRepositoryStats(c.Context(), repo.ID, source.FilesCount, source.FunctionsCount)
+	}
+	if err == nil && ar != nil {
+		result.WikiPages, err = h.wikiWriter.ImportWiki(c.Context(), repo.ID, ar.Records(archive.WikiFile))
+	}
+	if err != nil {
+		if delErr := db.DeleteRepository(context.Background(), h.dbClient, repo.ID); delErr != nil {
+			log.Printf("Failed to remove partly imported repository %s: %v", repo.ID, delErr)
+		}
+		if errors.Is(err, db.ErrInvalidSnapshot) || errors.Is(err, archive.ErrInvalidArchive) {
+			return badRequest(c, err.Error())
+		}
+		return serverError(c, err)
+	}
+
+	repo.Status = "ready"
+	repo.FilesCount = source.FilesCount
+	repo.FunctionsCount = source.FunctionsCount
+	if result.WikiPages > 0 {
+		h.setWikiStatus(c.Context(), repo.ID, &models.WikiStatus{Status: "ready", Progress: 100, TotalPages: result.WikiPages})
+	}
+	if ar != nil {
+		result.Reembedding = h.reembedArchive(repo, ar.Metadata.Embeddings)
+	}
+	return c.Status(201).JSON(result)
+}
+
+// reembedArchive queues re-embedding an imported repository when its
+// archive carries embeddings of another model or dimension than the one
+// stored here, as they cannot be searched. The archive's vectors are
+// removed once replaced. Returns whether a job was queued.
+func (h *Handler) reembedArchive(repo *models.Repository, carried *archive.Embeddings) bool {
+	if carried == nil {
+		return false
+	}
+	from := db.VectorSpace{Model: carried.Model, Dimension: carried.Dimension, Property: carried.Property}
+	to := h.dbClient.WriteSpace()
+	if from.Property == to.Property {
+		return false
+	}
+
+	h.jobs.Enqueue(jobs.KindEmbeddings, repo.ID, repo.Name, func(ctx context.Context) error {
+		if err := h.reembedRepository(ctx, repo, from, to); err != nil {
+			return err
+		}
+		// The searched space keeps its vectors while a migration builds another
+		if active, _ := h.dbClient.VectorSpaces(); from.Property == active.Property {
+			return nil
+		}
+		return h.dbClient.RemoveRepositoryEmbeddings(ctx, repo.ID, from)
+	})
+	return true
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"sort"
+
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/dpolishuk/neograph/backend/internal/findings"
+	"github.com/gofiber/fiber/v3"
+)
+
+// FindingsUploadResult reports how the results of an uploaded SARIF log
+// mapped onto the graph
+type FindingsUploadResult struct {
+	Tools      []string `json:"tools"`
+	Findings   int      `json:"findings"`   // results read from the log
+	Located    int      `json:"located"`    // stored, in a repository file
+	InEntities int      `json:"inEntities"` // of those, inside a function, method or class
+	Unmatched  int      `json:"unmatched"`  // in files the repository does not have
+}
+
+// UploadFindings stores the results of a SARIF log sent as the request body,
+// as produced by golangci-lint, ESLint or Semgrep, as Finding nodes. Each
+// upload replaces the previous findings of the tools it holds results of.
+func (h *Handler) UploadFindings(c fiber.Ctx) error {
+	id := c.Params("id")
+
+	repo, err := db.GetRepository(c.Context(), h.dbClient, id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if repo == nil {
+		return notFound(c, "repository not found")
+	}
+
+	body := c.Body()
+	if len(body) == 0 {
+		return badRequest(c, "request body must hold a SARIF log")
+	}
+	results, err := findings.ParseSARIF(body)
+	if err != nil {
+		return badRequest(c, err.Error())
+	}
+
+	paths, err := h.graphReader.GetFilePaths(c.Context(), id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	entities, err := h.graphReader.GetEntityLocations(c.Context(), id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	located, unmatched := findings.Locate(results, paths, entities)
+
+	seen := make(map[string]bool)
+	tools := []string{}
+	for _, f := range results {
+		if !seen[f.Tool] {
+			seen[f.Tool] = true
+			tools = append(tools, f.Tool)
+		}
+	}
+	sort.Strings(tools)
+
+	if err := h.writer.WriteFindings(c.Context(), id, tools, located); err != nil {
+		return serverError(c, err)
+	}
+	h.cache.Invalidate(id)
+
+	inEntities := 0
+	for _, f := range located {
+		if f.EntityID != "" {
+			inEntities++
+		}
+	}
+	return c.JSON(FindingsUploadResult{
+		Tools:      tools,
+		Findings:   len(results),
+		Located:    len(located),
+		InEntities: inEntities,
+		Unmatched:  len(unmatched),
+	})
+}
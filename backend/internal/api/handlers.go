@@ -1,15 +1,35 @@
 package api
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/dpolishuk/neograph/backend/internal/agent"
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/dpolishuk/neograph/backend/internal/artifact"
+	"github.com/dpolishuk/neograph/backend/internal/auth"
+	"github.com/dpolishuk/neograph/backend/internal/cache"
 	"github.com/dpolishuk/neograph/backend/internal/config"
 	"github.com/dpolishuk/neograph/backend/internal/db"
 	"github.com/dpolishuk/neograph/backend/internal/embedding"
+	"github.com/dpolishuk/neograph/backend/internal/events"
 	"github.com/dpolishuk/neograph/backend/internal/git"
+	"github.com/dpolishuk/neograph/backend/internal/github"
 	"github.com/dpolishuk/neograph/backend/internal/indexer"
+	"github.com/dpolishuk/neograph/backend/internal/jobs"
+	"github.com/dpolishuk/neograph/backend/internal/lsp"
+	"github.com/dpolishuk/neograph/backend/internal/metrics"
 	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/dpolishuk/neograph/backend/internal/notify"
+	"github.com/dpolishuk/neograph/backend/internal/ratelimit"
 	"github.com/gofiber/fiber/v3"
 )
 
@@ -22,38 +42,146 @@ type Handler struct {
 	graphReader *db.GraphReader
 	wikiReader  *db.WikiReader
 	wikiWriter  *db.WikiWriter
-	teiClient   *embedding.TEIClient
+	embedder    embedding.Embedder
+	teiPrevious embedding.Embedder // old model during a vector migration, nil if not served
 	agentProxy  *agent.AgentProxy
+	cache       *cache.Cache
+	artifacts   *artifact.Store
+	webhook     *notify.Webhook
+	github      *github.Client
+	jobs        *jobs.Queue
+	events      *events.Broker
+	keys        *auth.Keyring
+	tokens      *auth.Verifier // nil unless OIDC is configured
+	searchLimit *ratelimit.Limiter
+	graphLimit  *ratelimit.Limiter
+
+	openapiOnce sync.Once
+	openapiJSON []byte // built from the routes on first request
 }
 
-func NewHandler(cfg *config.Config, dbClient *db.Neo4jClient) *Handler {
+func NewHandler(cfg *config.Config, dbClient *db.Neo4jClient) (*Handler, error) {
+	embedder, err := embedding.New(embedding.Config{
+		Provider: cfg.EmbeddingsProvider,
+		TEIURL:   cfg.TEI_URL,
+		URL:      cfg.EmbeddingsURL,
+		Model:    cfg.EmbeddingModel,
+		APIKey:   cfg.EmbeddingsAPIKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	embedder = embedding.NewLimited(embedder, cfg.EmbeddingAttempts, cfg.EmbeddingConcurrency)
+	if embedder, err = reduceEmbeddings(cfg, embedder); err != nil {
+		return nil, err
+	}
+
+	writer := db.NewGraphWriter(dbClient)
+	writer.SetMaxContentBytes(cfg.MaxEntityContentBytes)
+
+	pipeline := indexer.NewPipeline(dbClient)
+	pipeline.SetLanguageServers(lsp.ParseServers(cfg.LanguageServers), cfg.LanguageServerTimeout)
+	pipeline.SetTEIClient(embedder, cfg.EmbeddingConcurrency)
+	agentProxy := agent.NewAgentProxy(cfg.AgentURL)
+	if cfg.EntityDescriptions {
+		pipeline.SetDescriber(agentProxy)
+	}
+
+	configuredKeys, err := auth.ParseKeys(cfg.APIKeys)
+	if err != nil {
+		return nil, err
+	}
+	var tokens *auth.Verifier
+	if cfg.OIDCIssuer != "" {
+		tokens = auth.NewVerifier(auth.OIDCConfig{
+			Issuer:     cfg.OIDCIssuer,
+			JWKSURL:    cfg.OIDCJWKSURL,
+			Audience:   cfg.OIDCAudience,
+			RolesClaim: cfg.OIDCRolesClaim,
+			OrgClaim:   cfg.OIDCOrgClaim,
+		})
+	}
+
+	var teiPrevious embedding.Embedder
+	if cfg.TEIPreviousURL != "" {
+		teiPrevious = embedding.NewLimited(embedding.NewTEIClient(cfg.TEIPreviousURL), cfg.EmbeddingAttempts, cfg.EmbeddingConcurrency)
+		if teiPrevious, err = reduceEmbeddings(cfg, teiPrevious); err != nil {
+			return nil, err
+		}
+	}
+
 	return &Handler{
 		cfg:         cfg,
 		dbClient:    dbClient,
 		gitSvc:      git.NewGitService(cfg.ReposPath),
-		pipeline:    indexer.NewPipeline(dbClient),
-		writer:      db.NewGraphWriter(dbClient),
+		pipeline:    pipeline,
+		writer:      writer,
 		graphReader: db.NewGraphReader(dbClient),
 		wikiReader:  db.NewWikiReader(dbClient),
 		wikiWriter:  db.NewWikiWriter(dbClient),
-		teiClient:   embedding.NewTEIClient(cfg.TEI_URL),
-		agentProxy:  agent.NewAgentProxy(cfg.AgentURL),
-	}
+		embedder:    embedder,
+		teiPrevious: teiPrevious,
+		agentProxy:  agentProxy,
+		cache:       cache.New(cfg.CacheTTL, cfg.CacheMaxBytes),
+		artifacts:   artifact.NewStore(cfg.ArtifactsPath),
+		webhook:     notify.NewWebhook(cfg.WikiWebhookURL),
+		github:      github.NewClient(cfg.GitHubAPIURL, cfg.GitHubToken),
+		jobs:        jobs.New(cfg.WorkerConcurrency),
+		events:      events.NewBroker(),
+		keys: auth.NewKeyring(configuredKeys, func(ctx context.Context, hash string) (auth.Grant, error) {
+			scope, orgID, err := db.GetAPIKeyScope(ctx, dbClient, hash)
+			return auth.Grant{Scope: scope, OrgID: orgID}, err
+		}),
+		tokens:      tokens,
+		searchLimit: newLimiter(cfg.SearchRateLimit, cfg.SearchRateBurst),
+		graphLimit:  newLimiter(cfg.GraphRateLimit, cfg.GraphRateBurst),
+	}, nil
 }
 
 func (h *Handler) Close() {
+	h.events.Close()
+	h.jobs.Close()
 	h.pipeline.Close()
 }
 
-// ListRepositories returns all repositories
+// TotalCountHeader carries how many items a paged list holds in all
+const TotalCountHeader = "X-Total-Count"
+
+// maxRepositoryPageSize caps the ?limit= of ListRepositories
+const maxRepositoryPageSize = 500
+
+// ListRepositories returns the repositories of the caller's organization,
+// or of none for anonymous callers, narrowed to a ?project=, a ?status= and
+// those whose name contains ?q=, sorted by ?sort= (a field such as name or
+// -lastIndexed). All are returned unless ?limit= is set, in pages numbered
+// from 1 by ?page=; the X-Total-Count header counts all that match.
 func (h *Handler) ListRepositories(c fiber.Ctx) error {
-	repos, err := db.ListRepositories(c.Context(), h.dbClient)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	query := db.RepositoryQuery{
+		Status:      c.Query("status"),
+		Name:        strings.TrimSpace(c.Query("q")),
+		OrgID:       callerOrg(c),
+		ProjectID:   c.Query("project"),
+		Unorganized: h.anonymous(c),
+		Sort:        c.Query("sort"),
+		Limit:       fiber.Query[int](c, "limit", 0),
+	}
+	if !db.ValidRepositorySort(query.Sort) {
+		return badRequest(c, "sort must be name, status, lastIndexed, filesCount or functionsCount, prefixed with - to sort descending")
+	}
+	if query.Limit < 0 || query.Limit > maxRepositoryPageSize {
+		return badRequest(c, fmt.Sprintf("limit must be between 1 and %d", maxRepositoryPageSize))
 	}
-	if repos == nil {
-		repos = []*models.Repository{}
+	page := fiber.Query[int](c, "page", 1)
+	if page < 1 {
+		return badRequest(c, "page must be at least 1")
 	}
+	query.Offset = (page - 1) * query.Limit
+
+	repos, total, err := db.QueryRepositories(c.Context(), h.dbClient, query)
+	if err != nil {
+		return serverError(c, err)
+	}
+	c.Set(TotalCountHeader, strconv.Itoa(total))
 	return c.JSON(repos)
 }
 
@@ -62,46 +190,155 @@ func (h *Handler) GetRepository(c fiber.Ctx) error {
 	id := c.Params("id")
 	repo, err := db.GetRepository(c.Context(), h.dbClient, id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return serverError(c, err)
 	}
 	if repo == nil {
-		return c.Status(404).JSON(fiber.Map{"error": "repository not found"})
+		return notFound(c, "repository not found")
 	}
 	return c.JSON(repo)
 }
 
-// CreateRepository adds a new repository and starts indexing
+// IdempotencyKeyHeader lets clients retry creating a repository safely: a
+// request repeating a key returns the repository the first one created
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength caps the Idempotency-Key header
+const maxIdempotencyKeyLength = 255
+
+// CreateRepository adds a new repository to a project and starts indexing,
+// see repositoryProject. A repository already registered in the
+// organization with the URL, however it is written, or created with the
+// same Idempotency-Key, is returned with 200 instead of added again.
 func (h *Handler) CreateRepository(c fiber.Ctx) error {
 	var input models.CreateRepositoryInput
 	if err := c.Bind().Body(&input); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+		return badRequest(c, "invalid request body")
 	}
 
-	if input.URL == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "url is required"})
+	if strings.TrimSpace(input.URL) == "" {
+		return badRequest(c, "url is required")
+	}
+	key := c.Get(IdempotencyKeyHeader)
+	if len(key) > maxIdempotencyKeyLength {
+		return badRequest(c, fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength))
+	}
+	project, err := h.repositoryProject(c, input.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	repo, created, err := h.registerRepository(c.Context(), strings.TrimSpace(input.URL), input.DefaultBranch, project, key)
+	if errors.Is(err, db.ErrIdempotencyKeyReused) {
+		return sendError(c, 422, CodeIdempotencyKeyReused, err.Error(), nil)
+	}
+	if err != nil {
+		return serverError(c, err)
+	}
+	if !created {
+		return c.JSON(repo)
 	}
+	return c.Status(201).JSON(repo)
+}
 
-	// Create repository record
+// registerRepository creates a repository of a URL in a project, if any,
+// and queues indexing it, unless it is registered already, see
+// db.CreateRepositoryOnce
+func (h *Handler) registerRepository(ctx context.Context, url, branch string, project *models.Project, idempotencyKey string) (*models.Repository, bool, error) {
+	if branch == "" {
+		branch = "main"
+	}
 	repo := &models.Repository{
-		URL:           input.URL,
-		Name:          git.ExtractRepoName(input.URL),
-		DefaultBranch: input.DefaultBranch,
+		URL:           url,
+		Name:          git.ExtractRepoName(url),
+		DefaultBranch: branch,
 		Status:        "pending",
 	}
-
-	if repo.DefaultBranch == "" {
-		repo.DefaultBranch = "main"
+	if project != nil {
+		repo.ProjectID, repo.OrgID = project.ID, project.OrgID
+	}
+	repo, created, err := db.CreateRepositoryOnce(ctx, h.dbClient, repo, idempotencyKey)
+	if err != nil || !created {
+		return repo, false, err
 	}
 
-	created, err := db.CreateRepository(c.Context(), h.dbClient, repo)
+	// Start indexing in background
+	h.enqueueIndex(repo)
+	return repo, true, nil
+}
+
+// maxBulkRepositories caps the repositories registered by one bulk request
+const maxBulkRepositories = 500
+
+// BulkCreateRepositories registers a list of URLs, or the repositories of a
+// GitHub organization, and queues indexing each. URLs already registered are
+// skipped; every repository gets its own result, so one failing does not
+// fail the rest.
+func (h *Handler) BulkCreateRepositories(c fiber.Ctx) error {
+	var input models.BulkRepositoryInput
+	if err := c.Bind().Body(&input); err != nil {
+		return badRequest(c, "invalid request body")
+	}
+	if (len(input.URLs) == 0) == (input.Org == "") {
+		return badRequest(c, "either urls or org is required")
+	}
+	project, err := h.repositoryProject(c, input.ProjectID)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
-	// Start indexing in background
-	go h.indexRepository(created)
+	type candidate struct{ url, branch string }
+	var candidates []candidate
+	if input.Org != "" {
+		orgRepos, err := h.github.ListOrgRepos(c.Context(), input.Org)
+		if err != nil {
+			if errors.Is(err, github.ErrNotFound) {
+				return badRequest(c, err.Error())
+			}
+			return upstreamError(c, "failed to list the repositories of "+input.Org+" on GitHub", err)
+		}
+		for _, r := range orgRepos {
+			if (r.Fork && !input.IncludeForks) || (r.Archived && !input.IncludeArchived) {
+				continue
+			}
+			candidates = append(candidates, candidate{r.CloneURL, r.DefaultBranch})
+		}
+	} else {
+		for _, url := range input.URLs {
+			candidates = append(candidates, candidate{strings.TrimSpace(url), input.DefaultBranch})
+		}
+	}
+	if len(candidates) > maxBulkRepositories {
+		return badRequest(c, fmt.Sprintf("at most %d repositories can be registered at once, got %d", maxBulkRepositories, len(candidates)))
+	}
+
+	results := make([]models.BulkRepositoryResult, 0, len(candidates))
+	created := 0
+	for _, cand := range candidates {
+		result := models.BulkRepositoryResult{URL: cand.url}
+		if cand.url == "" {
+			result.Status, result.Error = "error", "url is required"
+			results = append(results, result)
+			continue
+		}
+
+		repo, isNew, err := h.registerRepository(c.Context(), cand.url, cand.branch, project, "")
+		switch {
+		case err != nil:
+			log.Printf("Failed to register %s: %v", cand.url, err)
+			result.Status, result.Error = "error", "failed to register the repository"
+		case isNew:
+			result.Status, result.Repository = "created", repo
+			created++
+		default:
+			result.Status, result.Repository = "exists", repo
+		}
+		results = append(results, result)
+	}
 
-	return c.Status(201).JSON(created)
+	return c.JSON(fiber.Map{
+		"created": created,
+		"results": results,
+	})
 }
 
 // DeleteRepository removes a repository
@@ -109,96 +346,711 @@ func (h *Handler) DeleteRepository(c fiber.Ctx) error {
 	id := c.Params("id")
 
 	if err := db.DeleteRepository(c.Context(), h.dbClient, id); err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return serverError(c, err)
+	}
+	h.cache.Invalidate(id)
+	if err := h.artifacts.RemoveRepository(id); err != nil {
+		log.Printf("Failed to remove artifacts of %s: %v", id, err)
 	}
 
 	return c.SendStatus(204)
 }
 
+// GetRepositorySettings returns a repository's options
+func (h *Handler) GetRepositorySettings(c fiber.Ctx) error {
+	settings, err := db.GetRepositorySettings(c.Context(), h.dbClient, c.Params("id"))
+	if err != nil {
+		return serverError(c, err)
+	}
+	if settings == nil {
+		return notFound(c, "repository not found")
+	}
+	return c.JSON(settings)
+}
+
+// UpdateRepositorySettings updates a repository's options. Options left out
+// of the body keep their values; indexing options apply from the next index.
+func (h *Handler) UpdateRepositorySettings(c fiber.Ctx) error {
+	id := c.Params("id")
+
+	settings, err := db.GetRepositorySettings(c.Context(), h.dbClient, id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if settings == nil {
+		return notFound(c, "repository not found")
+	}
+	if err := c.Bind().Body(settings); err != nil {
+		return badRequest(c, "invalid request body")
+	}
+	if err := settings.Validate(); err != nil {
+		return badRequest(c, err.Error())
+	}
+
+	if err := db.UpdateRepositorySettings(c.Context(), h.dbClient, id, settings); err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(settings)
+}
+
 // ReindexRepository triggers re-indexing
 func (h *Handler) ReindexRepository(c fiber.Ctx) error {
 	id := c.Params("id")
 
 	repo, err := db.GetRepository(c.Context(), h.dbClient, id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return serverError(c, err)
 	}
 	if repo == nil {
-		return c.Status(404).JSON(fiber.Map{"error": "repository not found"})
+		return notFound(c, "repository not found")
 	}
 
 	// Update status and reindex
-	db.UpdateRepositoryStatus(c.Context(), h.dbClient, id, "indexing")
-	go h.indexRepository(repo)
+	h.setRepositoryStatus(c.Context(), id, "indexing", "")
+	h.enqueueIndex(repo)
 
 	return c.JSON(fiber.Map{"status": "indexing started"})
 }
 
-func (h *Handler) indexRepository(repo *models.Repository) {
-	ctx := context.Background()
+// enqueueIndex queues a reindex of a repository, followed by generating
+// its wiki and finding duplicate functions once indexing succeeds
+func (h *Handler) enqueueIndex(repo *models.Repository) {
+	h.jobs.Enqueue(jobs.KindIndex, repo.ID, repo.Name, func(ctx context.Context) error {
+		if err := h.reindex(ctx, repo, ""); err != nil {
+			return err
+		}
+
+		// Auto-generate wiki after successful indexing
+		h.enqueueWiki(repo)
+		h.enqueueDuplicates(repo)
+		return nil
+	})
+}
+
+// enqueueDuplicates queues comparing a repository's function embeddings
+// for possible duplicates
+func (h *Handler) enqueueDuplicates(repo *models.Repository) jobs.Job {
+	job, _ := h.jobs.Enqueue(jobs.KindDuplicates, repo.ID, repo.Name, func(ctx context.Context) error {
+		found, err := h.writer.WriteDuplicates(ctx, repo.ID, h.cfg.DuplicateThreshold)
+		if err != nil {
+			return err
+		}
+		log.Printf("Found %d possible duplicates in %s", found, repo.Name)
+		return nil
+	})
+	return job
+}
+
+// enqueueWiki queues generating a repository's wiki
+func (h *Handler) enqueueWiki(repo *models.Repository) {
+	h.jobs.Enqueue(jobs.KindWiki, repo.ID, repo.Name, func(ctx context.Context) error {
+		return h.generateWikiPages(repo)
+	})
+}
+
+// reindex clones or updates a repository and rebuilds its graph, recording
+// the run and the indexed commit. Given the commit the graph was indexed at,
+// only the files changed since are indexed again, unless the clone lacks
+// that commit.
+func (h *Handler) reindex(ctx context.Context, repo *models.Repository, since string) error {
+	progress := h.indexProgress(repo.ID)
+	ctx = indexer.WithProgress(ctx, progress)
+
+	run := &models.IndexRun{RepoID: repo.ID}
+	if err := db.CreateIndexRun(ctx, h.dbClient, run); err != nil {
+		log.Printf("Failed to record index run for %s: %v", repo.Name, err)
+	}
+
+	fail := func(stage string, err error) error {
+		log.Printf("Failed to index %s at the %s stage: %v", repo.Name, stage, err)
+		h.cache.Invalidate(repo.ID)
+		metrics.IndexRunsFailed.Inc(repo.ID, stage)
+		h.setRepositoryStatus(ctx, repo.ID, "error", stage)
+		run.Status = "error"
+		run.Error = indexFailure(stage)
+		db.FinishIndexRun(ctx, h.dbClient, run)
+		return err
+	}
 
 	// Clone or update repository
+	progress(stageClone, 0, 0)
 	repoPath, err := h.gitSvc.Clone(ctx, repo.URL, repo.DefaultBranch)
 	if err != nil {
-		db.UpdateRepositoryStatus(ctx, h.dbClient, repo.ID, "error")
-		return
+		return fail("clone", err)
+	}
+
+	commit, err := h.gitSvc.GetCurrentCommit(ctx, repoPath)
+	if err != nil {
+		log.Printf("Failed to read commit for %s: %v", repo.Name, err)
+	}
+	run.Commit = commit
+
+	// Repositories added before per-repository databases were enabled
+	// get theirs on their next index
+	if err := db.CreateRepositoryDatabase(ctx, h.dbClient, repo); err != nil {
+		return fail("database", err)
+	}
+
+	// Remember what was indexed to tell which wiki pages this index outdates
+	previous, err := h.graphReader.GetFileHashes(ctx, repo.ID)
+	if err != nil {
+		log.Printf("Failed to read indexed files of %s: %v", repo.Name, err)
 	}
 
-	// Clear existing data
-	h.writer.ClearRepository(ctx, repo.ID)
+	// Index the changes since the indexed commit, or clear existing data to
+	// index everything
+	var changed []string
+	if since != "" && since == repo.Commit && previous != nil {
+		changed, err = h.gitSvc.ChangedFiles(ctx, repoPath, since)
+		if err != nil {
+			log.Printf("Reindexing all of %s: %v", repo.Name, err)
+		}
+	}
+	if changed == nil {
+		h.writer.ClearRepository(ctx, repo.ID)
+	}
+	h.cache.Invalidate(repo.ID)
 
 	// Update status
-	db.UpdateRepositoryStatus(ctx, h.dbClient, repo.ID, "indexing")
+	h.setRepositoryStatus(ctx, repo.ID, "indexing", "")
 
-	// Run indexing pipeline
-	result, err := h.pipeline.IndexDirectory(ctx, repoPath, repo.ID)
+	// Run indexing pipeline with the repository's indexing options
+	settings, err := db.GetRepositorySettings(ctx, h.dbClient, repo.ID)
 	if err != nil {
-		db.UpdateRepositoryStatus(ctx, h.dbClient, repo.ID, "error")
-		return
+		return fail("settings", err)
+	}
+	if settings == nil {
+		settings = &models.RepositorySettings{}
+	}
+	var result *models.IndexResult
+	if changed != nil {
+		result, err = h.pipeline.IndexChanges(ctx, repoPath, repo.ID, settings.IndexSettings, changed, previous)
+	} else {
+		result, err = h.pipeline.IndexDirectory(ctx, repoPath, repo.ID, settings.IndexSettings)
+	}
+	if err != nil {
+		return fail("index", err)
 	}
 
 	// Write to Neo4j
-	if err := h.writer.WriteIndexResult(ctx, result); err != nil {
-		db.UpdateRepositoryStatus(ctx, h.dbClient, repo.ID, "error")
-		return
+	progress(stageWrite, 0, 0)
+	if changed != nil {
+		err = h.writer.WriteIndexChanges(ctx, result, changed)
+	} else {
+		err = h.writer.WriteIndexResult(ctx, result)
+	}
+	if err != nil {
+		return fail("write", err)
+	}
+
+	// Score functions by how much of the call graph leans on them
+	if err := h.writer.WriteCentrality(ctx, repo.ID); err != nil {
+		log.Printf("Failed to score centrality of %s: %v", repo.Name, err)
+	}
+
+	if previous != nil {
+		current := make(map[string]string, len(result.Files))
+		for _, file := range result.Files {
+			current[file.Path] = file.Hash
+		}
+		if err := h.wikiWriter.MarkPagesStale(ctx, repo.ID, analysis.ChangedFiles(previous, current)); err != nil {
+			log.Printf("Failed to mark wiki pages of %s stale: %v", repo.Name, err)
+		}
+	}
+
+	// Record recent history for churn and ownership queries
+	if h.cfg.GitHistoryDepth > 0 {
+		progress(stageHistory, 0, 0)
+		if commits, err := h.gitSvc.History(ctx, repoPath, h.cfg.GitHistoryDepth); err != nil {
+			log.Printf("Failed to read history of %s: %v", repo.Name, err)
+		} else if err := h.writer.WriteHistory(ctx, repo.ID, commits); err != nil {
+			log.Printf("Failed to write history of %s: %v", repo.Name, err)
+		}
+	}
+
+	// Export dependencies and graph for compliance tooling
+	doc := artifact.Build(artifact.Input{
+		Repo:         repo,
+		Run:          run,
+		Result:       result,
+		Dependencies: artifact.ReadDependencies(repoPath),
+		License:      artifact.DetectLicense(repoPath),
+	})
+	if err := h.artifacts.Save(doc); err != nil {
+		log.Printf("Failed to store artifact of %s: %v", repo.Name, err)
+	} else {
+		run.Artifact = true
+	}
+
+	// Summarize what changed since the last run in the wiki changelog
+	if err := h.recordChangelog(ctx, repo, run, doc); err != nil {
+		log.Printf("Failed to record changelog of %s: %v", repo.Name, err)
 	}
 
-	// Auto-generate wiki after successful indexing
-	go h.generateWikiPages(repo)
+	// Status was updated to 'ready' by WriteIndexResult
+	run.Status = "ready"
+	run.FilesCount = len(result.Files)
+	run.EntitiesCount = result.EntitiesFound
+	if err := db.FinishIndexRun(ctx, h.dbClient, run); err != nil {
+		log.Printf("Failed to record index run for %s: %v", repo.Name, err)
+	}
+	repo.Commit = commit
+
+	// Link the graph with the other indexed repositories
+	progress(stageLink, 0, 0)
+	if err := db.SetRepositoryModules(ctx, h.dbClient, repo.ID, indexer.DetectModules(repoPath, repo.URL)); err != nil {
+		log.Printf("Failed to record modules of %s: %v", repo.Name, err)
+	} else if links, err := h.writer.LinkCrossRepository(ctx, repo.ID); err != nil {
+		log.Printf("Failed to link %s with other repositories: %v", repo.Name, err)
+	} else if links.Dependencies > 0 || links.ExternalCalls > 0 {
+		log.Printf("Linked %s: %d cross-repository dependencies, %d external calls", repo.Name, links.Dependencies, links.ExternalCalls)
+	}
 
-	// Status will be updated to 'ready' by WriteIndexResult
+	// Drop anything cached from the partial graph while indexing ran
+	h.cache.Invalidate(repo.ID)
+	if h.cfg.WarmupAfterIndex {
+		h.warmCache(ctx, repo.ID)
+	}
+	h.events.Publish(events.Event{Type: events.TypeRepository, RepoID: repo.ID, Status: "ready"})
+	return nil
 }
 
-// GetRepositoryFiles returns file tree with functions for a repository
+// GetIndexRuns returns the recent index runs of a repository
+func (h *Handler) GetIndexRuns(c fiber.Ctx) error {
+	id := c.Params("id")
+
+	limit := fiber.Query[int](c, "limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	runs, err := db.ListIndexRuns(c.Context(), h.dbClient, id, limit)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(runs)
+}
+
+// GetIndexRunArtifact downloads the SBOM and graph export of an index run
+func (h *Handler) GetIndexRunArtifact(c fiber.Ctx) error {
+	id := c.Params("id")
+	runID := c.Params("runId")
+
+	data, err := h.artifacts.Read(id, runID)
+	if errors.Is(err, artifact.ErrNotFound) {
+		return notFound(c, "artifact not found")
+	}
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	c.Attachment(fmt.Sprintf("neograph-%s-%s.json", id, runID))
+	return c.Send(data)
+}
+
+// GetGraphDiff compares the functions and calls of two index runs, given as
+// ?from=&to= run IDs. to defaults to the latest run with an artifact and
+// from to the one before it.
+func (h *Handler) GetGraphDiff(c fiber.Ctx) error {
+	id := c.Params("id")
+
+	runs, err := db.ListIndexRuns(c.Context(), h.dbClient, id, 100)
+	if err != nil {
+		return serverError(c, err)
+	}
+	var indexed []models.IndexRun
+	for _, run := range runs {
+		if run.Status == "ready" && run.Artifact {
+			indexed = append(indexed, run)
+		}
+	}
+	if c.Query("from") == "" && c.Query("to") == "" && len(indexed) < 2 {
+		return badRequest(c, "the repository needs two index runs to compare")
+	}
+
+	find := func(runID string) int {
+		for i := range indexed {
+			if indexed[i].ID == runID {
+				return i
+			}
+		}
+		return -1
+	}
+	to := 0
+	if toID := c.Query("to"); toID != "" {
+		to = find(toID)
+	}
+	from := to + 1
+	if fromID := c.Query("from"); fromID != "" {
+		from = find(fromID)
+	}
+	if to < 0 || from < 0 || from >= len(indexed) {
+		return notFound(c, "run not found")
+	}
+
+	prev, err := h.readArtifact(id, indexed[from].ID)
+	if err != nil {
+		return serverError(c, err)
+	}
+	next, err := h.readArtifact(id, indexed[to].ID)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(artifact.GraphDiff(prev, next))
+}
+
+// GetRepositoryFiles returns the files of a repository with their functions
+// a directory level at a time: the directory at ?path=, the root by
+// default, with its files and the counts of its subdirectories, whose
+// contents are fetched in turn. ?depth= fills in more levels, 0 all of them.
 func (h *Handler) GetRepositoryFiles(c fiber.Ctx) error {
+	return h.directoryTree(c, 1)
+}
+
+// GetRepositoryTree returns the files of a repository nested in their
+// directories. ?path= returns the tree below a directory and ?depth= limits
+// how many levels of directories are filled in, so huge repositories can be
+// browsed a level at a time.
+func (h *Handler) GetRepositoryTree(c fiber.Ctx) error {
+	return h.directoryTree(c, 0)
+}
+
+// directoryTree responds with the directory tree below ?path=, ?depth=
+// levels deep, defaultDepth when not given. Subtrees are cut from the
+// cached tree, so browsing a level at a time never rebuilds it from the
+// graph.
+func (h *Handler) directoryTree(c fiber.Ctx, defaultDepth int) error {
 	id := c.Params("id")
-	files, err := h.graphReader.GetFileTree(c.Context(), id)
+	dir := c.Query("path")
+	depth := fiber.Query[int](c, "depth", defaultDepth)
+	if depth < 0 {
+		return badRequest(c, "depth must not be negative")
+	}
+	if dir == "" && depth == 0 {
+		return h.cachedJSON(c, id, cacheKeyTree)
+	}
+
+	var tree db.DirectoryNode
+	err := h.cachedValue(c.Context(), id, cacheKeyTree, skipCache(c), h.loaders()[cacheKeyTree], &tree)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return serverError(c, err)
 	}
-	if files == nil {
-		files = []db.FileNode{}
+	subtree := tree.Find(dir)
+	if subtree == nil {
+		return notFound(c, "directory not found")
 	}
-	return c.JSON(files)
+	return c.JSON(subtree.Prune(depth))
 }
 
-// GetRepositoryGraph returns graph data for visualization
+// GetRepositoryGraph returns graph data for visualization, tagged with an
+// ETag so clients revalidate rather than download it again until a reindex
 func (h *Handler) GetRepositoryGraph(c fiber.Ctx) error {
 	id := c.Params("id")
-	graphType := c.Query("type", "structure") // "structure" or "calls"
+	graphType := c.Query("type", "structure") // "structure", "calls", "architecture" or "packages"
+	// collapse=package folds the structure graph into directories and packages;
+	// see graphFilter for the filters
 
 	// Validate graph type
-	if graphType != "structure" && graphType != "calls" {
-		return c.Status(400).JSON(fiber.Map{"error": "invalid graph type, must be 'structure' or 'calls'"})
+	if graphType != "structure" && graphType != "calls" && graphType != "architecture" && graphType != "packages" {
+		return badRequest(c, "invalid graph type, must be 'structure', 'calls', 'architecture' or 'packages'")
+	}
+	// depth folds the architecture graph's directories below that depth
+	// into their ancestors; 0 keeps every directory
+	depth := fiber.Query[int](c, "depth", 0)
+	if depth < 0 {
+		return badRequest(c, "depth must not be negative")
 	}
 
-	graph, err := h.graphReader.GetGraph(c.Context(), id, graphType)
+	// limit and offset page the nodes; responses never exceed GraphMaxNodes
+	limit := fiber.Query[int](c, "limit", 0)
+	offset := fiber.Query[int](c, "offset", 0)
+	if limit < 0 || offset < 0 {
+		return badRequest(c, "limit and offset must not be negative")
+	}
+	if maxNodes := h.cfg.GraphMaxNodes; maxNodes > 0 && (limit == 0 || limit > maxNodes) {
+		limit = maxNodes
+	}
+
+	filter, err := graphFilter(c)
+	if err != nil {
+		return badRequest(c, err.Error())
+	}
+	if !filter.IsZero() {
+		if c.Query("collapse") == "package" {
+			return badRequest(c, "filters are not supported with collapse=package")
+		}
+		if graphType == "architecture" || graphType == "packages" {
+			return badRequest(c, "filters are not supported with type="+graphType)
+		}
+		// Filtered graphs are cached per filter
+		var graph *db.GraphData
+		key := "graph:" + graphType + "?" + filterKey(filter)
+		err := h.cachedValue(c.Context(), id, key, skipCache(c), func(ctx context.Context, repoID string) (any, error) {
+			return h.graphReader.GetFilteredGraph(ctx, repoID, graphType, filter)
+		}, &graph)
+		if err != nil {
+			return serverError(c, err)
+		}
+		if limit > 0 || offset > 0 {
+			graph = db.PageGraph(graph, offset, limit)
+		}
+		return h.sendTaggedJSON(c, graph)
+	}
+
+	// Folded architecture graphs are cached per depth
+	if graphType == "architecture" && depth > 0 {
+		var graph *db.GraphData
+		key := fmt.Sprintf("%s?depth=%d", cacheKeyGraphArchitecture, depth)
+		err := h.cachedValue(c.Context(), id, key, skipCache(c), func(ctx context.Context, repoID string) (any, error) {
+			return h.graphReader.GetArchitectureGraph(ctx, repoID, depth)
+		}, &graph)
+		if err != nil {
+			return serverError(c, err)
+		}
+		if limit > 0 || offset > 0 {
+			graph = db.PageGraph(graph, offset, limit)
+		}
+		return h.sendTaggedJSON(c, graph)
+	}
+
+	key := cacheKeyGraphStructure
+	switch {
+	case graphType == "structure" && c.Query("collapse") == "package":
+		key = cacheKeyGraphPackages
+	case graphType == "calls":
+		key = cacheKeyGraphCalls
+	case graphType == "architecture":
+		key = cacheKeyGraphArchitecture
+	case graphType == "packages":
+		key = cacheKeyGraphPackageDeps
+	}
+
+	// Whole node-level graphs too large to draw are folded into directory
+	// clusters, expanded with GetGraphCluster; ?cluster=false pages them
+	paged := c.Query("limit") != "" || c.Query("offset") != ""
+	if (key == cacheKeyGraphStructure || key == cacheKeyGraphCalls) && !paged &&
+		h.cfg.GraphClusterNodes > 0 && fiber.Query[bool](c, "cluster", true) {
+		return h.cachedClusteredGraph(c, id, key, limit)
+	}
+
+	if limit == 0 && offset == 0 {
+		return h.cachedJSON(c, id, key)
+	}
+	return h.cachedGraphPage(c, id, key, offset, limit)
+}
+
+// GetGraphCluster returns the nodes of a directory cluster of a clustered
+// graph, given as ?path=, with the edges touching them. Their other ends are
+// cluster nodes unless in a directory listed in ?expanded=, which the client
+// has already expanded.
+func (h *Handler) GetGraphCluster(c fiber.Ctx) error {
+	id := c.Params("id")
+	dir := c.Query("path")
+	if dir == "" {
+		return badRequest(c, "query parameter 'path' is required")
+	}
+
+	key := cacheKeyGraphStructure
+	switch c.Query("type", "structure") {
+	case "structure":
+	case "calls":
+		key = cacheKeyGraphCalls
+	default:
+		return badRequest(c, "invalid graph type, must be 'structure' or 'calls'")
+	}
+
+	expanded := make(map[string]bool)
+	for _, d := range strings.Split(c.Query("expanded"), ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			expanded[d] = true
+		}
+	}
+
+	graph, err := h.cachedGraph(c.Context(), id, key, skipCache(c))
+	if err != nil {
+		return serverError(c, err)
+	}
+	cluster := db.ExpandCluster(graph, dir, expanded)
+	if cluster == nil {
+		return notFound(c, "cluster not found")
+	}
+	return h.sendTaggedJSON(c, cluster)
+}
+
+// GetGraphStream streams the structure, calls, architecture or packages
+// graph (?type=) as NDJSON, one db.GraphChunk of ?batch= (default 500)
+// nodes per line with the edges they complete, so clients draw large
+// graphs as they arrive. ?cursor= resumes after the last chunk received.
+func (h *Handler) GetGraphStream(c fiber.Ctx) error {
+	id := c.Params("id")
+
+	key := cacheKeyGraphStructure
+	switch graphType := c.Query("type", "structure"); graphType {
+	case "structure":
+	case "calls":
+		key = cacheKeyGraphCalls
+	case "architecture":
+		key = cacheKeyGraphArchitecture
+	case "packages":
+		key = cacheKeyGraphPackageDeps
+	default:
+		return badRequest(c, "invalid graph type, must be 'structure', 'calls', 'architecture' or 'packages'")
+	}
+
+	cursor := fiber.Query[int](c, "cursor", 0)
+	if cursor < 0 {
+		return badRequest(c, "cursor must not be negative")
+	}
+	batch := fiber.Query[int](c, "batch", 500)
+	if batch < 1 || (h.cfg.GraphMaxNodes > 0 && batch > h.cfg.GraphMaxNodes) {
+		batch = 500
+	}
+
+	graph, err := h.cachedGraph(c.Context(), id, key, skipCache(c))
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return serverError(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, "application/x-ndjson")
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		enc := json.NewEncoder(w)
+		err := db.StreamGraph(graph, cursor, batch, func(chunk *db.GraphChunk) error {
+			if err := enc.Encode(chunk); err != nil {
+				return err
+			}
+			// Flush each chunk so the client can draw it right away
+			return w.Flush()
+		})
+		if err != nil {
+			log.Printf("Failed to stream graph of %s: %v", id, err)
+		}
+	})
+}
+
+// graphFilter reads the graph filters of a request: ?path= prefix,
+// ?language=, ?entityType= as a comma-separated list of Function, Method and
+// Class, ?name= glob and ?minDegree=
+func graphFilter(c fiber.Ctx) (db.GraphFilter, error) {
+	filter := db.GraphFilter{
+		PathPrefix:  c.Query("path"),
+		Language:    c.Query("language"),
+		NamePattern: c.Query("name"),
+		MinDegree:   fiber.Query[int](c, "minDegree", 0),
+	}
+	if filter.MinDegree < 0 {
+		return filter, errors.New("minDegree must not be negative")
+	}
+	if types := c.Query("entityType"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			switch t = strings.TrimSpace(t); t {
+			case "Function", "Method", "Class":
+				filter.Types = append(filter.Types, t)
+			default:
+				return filter, fmt.Errorf("invalid entityType %q, must be Function, Method or Class", t)
+			}
+		}
+	}
+	return filter, nil
+}
+
+// GetNodeNeighborhood returns the subgraph within ?depth= hops (default 2,
+// at most 3) of a node, for expanding the graph around it. ?limit= bounds
+// the nodes, closest first (default 200).
+func (h *Handler) GetNodeNeighborhood(c fiber.Ctx) error {
+	depth := fiber.Query[int](c, "depth", 2)
+	if depth < 1 || depth > db.MaxNeighborhoodDepth {
+		return badRequest(c, fmt.Sprintf("depth must be between 1 and %d", db.MaxNeighborhoodDepth))
+	}
+	limit := fiber.Query[int](c, "limit", 200)
+	if limit < 1 {
+		return badRequest(c, "limit must be positive")
+	}
+	if maxNodes := h.cfg.GraphMaxNodes; maxNodes > 0 && limit > maxNodes {
+		limit = maxNodes
+	}
+
+	graph, err := h.graphReader.GetNeighborhood(c.Context(), c.Params("id"), c.Params("nodeId"), depth, limit)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if graph == nil {
+		return notFound(c, "node not found")
 	}
 	return c.JSON(graph)
 }
 
+// GetCallChain returns the transitive callers (?direction=upstream) or
+// callees (?direction=downstream, the default) of a function up to ?depth=
+// calls away (default 5), each annotated with its depth, for reviewing the
+// impact of a change. ?limit= bounds the functions (default 500).
+func (h *Handler) GetCallChain(c fiber.Ctx) error {
+	direction := c.Query("direction", db.CalleesDirection)
+	if direction != db.CallersDirection && direction != db.CalleesDirection {
+		return badRequest(c, "invalid direction, must be 'upstream' or 'downstream'")
+	}
+	depth := fiber.Query[int](c, "depth", 5)
+	if depth < 1 || depth > db.MaxCallChainDepth {
+		return badRequest(c, fmt.Sprintf("depth must be between 1 and %d", db.MaxCallChainDepth))
+	}
+	limit := fiber.Query[int](c, "limit", 500)
+	if limit < 1 {
+		return badRequest(c, "limit must be positive")
+	}
+	if maxNodes := h.cfg.GraphMaxNodes; maxNodes > 0 && limit > maxNodes {
+		limit = maxNodes
+	}
+
+	chain, err := h.graphReader.GetCallChain(c.Context(), c.Params("id"), c.Params("nodeId"), direction, depth, limit)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if chain == nil {
+		return notFound(c, "function not found")
+	}
+	return c.JSON(chain)
+}
+
+// GetClassHierarchy returns the tree of classes implementing or extending
+// ?root=, the ID of a class or interface, as JSON or, with ?format=mermaid,
+// as a Mermaid class diagram
+func (h *Handler) GetClassHierarchy(c fiber.Ctx) error {
+	rootID := c.Query("root")
+	if rootID == "" {
+		return badRequest(c, "root is required")
+	}
+	format := c.Query("format", "json")
+	if format != "json" && format != "mermaid" {
+		return badRequest(c, "invalid format, must be 'json' or 'mermaid'")
+	}
+
+	hierarchy, err := h.graphReader.GetClassHierarchy(c.Context(), c.Params("id"), rootID)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if hierarchy == nil {
+		return notFound(c, "class not found")
+	}
+	if format == "mermaid" {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+		return c.SendString(db.HierarchyMermaid(hierarchy))
+	}
+	return c.JSON(hierarchy)
+}
+
+// GetSystemGraph returns the repositories of the caller's organization and
+// their cross-repository dependencies
+func (h *Handler) GetSystemGraph(c fiber.Ctx) error {
+	tenant, err := h.tenantRepoIDs(c)
+	if err != nil {
+		return serverError(c, err)
+	}
+	graph, err := h.graphReader.GetSystemGraph(c.Context(), tenant)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return h.sendTaggedJSON(c, graph)
+}
+
 // GetNodeDetail returns detailed information about a specific node
 func (h *Handler) GetNodeDetail(c fiber.Ctx) error {
 	repoID := c.Params("id")
@@ -206,19 +1058,55 @@ func (h *Handler) GetNodeDetail(c fiber.Ctx) error {
 
 	nodeDetail, err := h.graphReader.GetNodeDetail(c.Context(), repoID, nodeID)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return serverError(c, err)
 	}
 	if nodeDetail == nil {
-		return c.Status(404).JSON(fiber.Map{"error": "node not found"})
+		return notFound(c, "node not found")
+	}
+
+	// Source isn't stored when disabled or for entities indexed before it
+	// was; the clone still has it
+	if nodeDetail.Content == "" && nodeDetail.StartLine > 0 {
+		repo, err := db.GetRepository(c.Context(), h.dbClient, repoID)
+		if err != nil {
+			return serverError(c, err)
+		}
+		if repo != nil {
+			nodeDetail.Content, nodeDetail.ContentTruncated = h.readLines(
+				repo.URL, nodeDetail.FilePath, nodeDetail.StartLine, nodeDetail.EndLine, maxDetailLines)
+		}
 	}
 	return c.JSON(nodeDetail)
 }
 
-// GlobalSearch performs semantic search across all repositories
+// GlobalSearch performs semantic search across all repositories, those of
+// the caller's organization if they are scoped to one, keyword search with
+// ?mode=keyword, or both fused with ?mode=hybrid. Results can be narrowed
+// with ?lang=, ?type= and ?path=, see parseSearchFilter, and kept
+// from repeating near-identical entities with ?diversity=, see diversify.
+// ?expand=true also searches for variants of the query, see expandedSearch,
+// and ?scope=docs or all searches documentation, see embeddingSearch.
+// Without the embedding service semantic and hybrid searches fall back to
+// keyword search, reported in SearchModeHeader; documentation is only
+// searched by embedding, so ?scope=docs fails instead. Searches are logged
+// when search analytics are enabled, see respondSearch.
 func (h *Handler) GlobalSearch(c fiber.Ctx) error {
+	return h.search(c, "")
+}
+
+// RepoSearch performs semantic search within a specific repository,
+// keyword search with ?mode=keyword, or both fused with ?mode=hybrid,
+// narrowed like GlobalSearch
+func (h *Handler) RepoSearch(c fiber.Ctx) error {
+	return h.search(c, c.Params("id"))
+}
+
+// search answers GlobalSearch and RepoSearch, in one repository or all the
+// caller may read when repoID is empty
+func (h *Handler) search(c fiber.Ctx, repoID string) error {
 	query := c.Query("q")
 	if query == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "query parameter 'q' is required"})
+		return badRequest(c, "query parameter 'q' is required")
 	}
 
 	// Get optional limit parameter
@@ -227,99 +1115,188 @@ func (h *Handler) GlobalSearch(c fiber.Ctx) error {
 		limit = 10
 	}
 
+	filter, err := parseSearchFilter(c)
+	if err != nil {
+		return badRequest(c, err.Error())
+	}
+	if repoID == "" {
+		if filter.RepoIDs, err = h.tenantRepoIDs(c); err != nil {
+			return serverError(c, err)
+		}
+	}
+
+	diversity, err := parseDiversity(c)
+	if err != nil {
+		return badRequest(c, err.Error())
+	}
+
+	mode := c.Query("mode", searchSemantic)
+	switch mode {
+	case searchSemantic, searchKeyword, searchHybrid:
+	default:
+		return badRequest(c, "mode must be semantic, keyword or hybrid")
+	}
+	scope, err := parseSearchScope(c, mode)
+	if err != nil {
+		return badRequest(c, err.Error())
+	}
+	if fiber.Query[bool](c, "expand", false) {
+		return h.expandedSearch(c, query, mode, scope, limit, repoID, filter, diversity)
+	}
+	if mode == searchKeyword {
+		return h.keywordSearch(c, query, limit, repoID, filter, diversity)
+	}
+
 	// Generate embedding for the query
-	embeddings, err := h.teiClient.Embed(c.Context(), []string{query})
+	embedder, space := h.searchSpace()
+	embeddings, err := embedder.Embed(c.Context(), []string{query})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "failed to generate embedding: " + err.Error()})
+		metrics.TEIErrors.Inc(repoID)
+		if errors.Is(err, embedding.ErrUnavailable) {
+			if scope == scopeDocs {
+				return semanticSearchDisabled(c, err)
+			}
+			log.Printf("Searching %q by keyword: %v", query, err)
+			return h.keywordSearch(c, query, limit, repoID, filter, diversity)
+		}
+		return upstreamError(c, "failed to generate embedding", err)
 	}
 
 	if len(embeddings) == 0 {
-		return c.Status(500).JSON(fiber.Map{"error": "no embedding generated"})
+		return statusError(c, 500, "no embedding generated")
 	}
 
-	// Search Neo4j vector index (empty repoID means search all repos)
-	results, err := h.graphReader.VectorSearch(c.Context(), embeddings[0], limit, "")
+	// Search Neo4j vector index, filtered by repository unless repoID is empty
+	results, err := h.embeddingSearch(c.Context(), mode, scope, space, embeddings[0], query, searchCandidates(limit, diversity), repoID, filter)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "search failed: " + err.Error()})
+		return serverError(c, fmt.Errorf("search failed: %w", err))
 	}
 
 	if results == nil {
 		results = []db.SearchResult{}
 	}
 
-	return c.JSON(results)
+	return h.respondSearch(c, repoID, mode, withSnippets(diversify(results, diversity, limit), query))
 }
 
-// RepoSearch performs semantic search within a specific repository
-func (h *Handler) RepoSearch(c fiber.Ctx) error {
-	repoID := c.Params("id")
-	query := c.Query("q")
-
-	if query == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "query parameter 'q' is required"})
-	}
-
-	// Get optional limit parameter
-	limit := fiber.Query[int](c, "limit", 10)
-	if limit < 1 || limit > 100 {
-		limit = 10
+// ProxyAgentChat forwards chat requests to the Python agent service. The
+// agent reads the whole graph, so callers scoped to an organization must
+// name one of its repositories for it to explore.
+func (h *Handler) ProxyAgentChat(c fiber.Ctx) error {
+	req, err := h.agentChatRequest(c)
+	if err != nil {
+		return err
 	}
 
-	// Generate embedding for the query
-	embeddings, err := h.teiClient.Embed(c.Context(), []string{query})
+	// Forward to agent service
+	response, err := h.agentProxy.Chat(c.Context(), req.Message, req.RepoID, req.AgentType)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "failed to generate embedding: " + err.Error()})
+		metrics.AgentErrors.Inc(derefString(req.RepoID))
+		return upstreamError(c, "failed to communicate with agent service", err)
 	}
 
-	if len(embeddings) == 0 {
-		return c.Status(500).JSON(fiber.Map{"error": "no embedding generated"})
-	}
+	return c.JSON(response)
+}
 
-	// Search Neo4j vector index filtered by repository
-	results, err := h.graphReader.VectorSearch(c.Context(), embeddings[0], limit, repoID)
+// ProxyAgentChatStream forwards chat requests like ProxyAgentChat, relaying
+// the agent's server-sent events as they arrive so answers render while
+// they are written: token, tool_call, and done or error last
+func (h *Handler) ProxyAgentChatStream(c fiber.Ctx) error {
+	req, err := h.agentChatRequest(c)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "search failed: " + err.Error()})
+		return err
 	}
 
-	if results == nil {
-		results = []db.SearchResult{}
+	// The body is streamed after the handler returns, so the agent is
+	// called on a context of its own, cancelled once the client leaves
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := h.agentProxy.ChatStream(ctx, req)
+	if err != nil {
+		cancel()
+		metrics.AgentErrors.Inc(derefString(req.RepoID))
+		return upstreamError(c, "failed to communicate with agent service", err)
 	}
 
-	return c.JSON(results)
+	setEventStreamHeaders(c)
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		defer stream.Close()
+		relayAgentEvents(w, stream, derefString(req.RepoID))
+	})
 }
 
-// ProxyAgentChat forwards chat requests to the Python agent service
-func (h *Handler) ProxyAgentChat(c fiber.Ctx) error {
+// relayAgentEvents copies the server-sent events of an agent chat to the
+// client, flushing each as it arrives, and returns the answer of its done
+// event; nil if the chat did not finish. A stream that breaks off ends with
+// an error event, as the client would otherwise wait for a done event.
+func relayAgentEvents(w *bufio.Writer, stream io.Reader, repoID string) *agent.ChatResponse {
+	r := bufio.NewReader(stream)
+	var event string
+	var data strings.Builder
+	var answer *agent.ChatResponse
+	for {
+		line, err := r.ReadString('\n')
+		if line != "" {
+			// A write to a client that left fails, which ends the chat
+			if _, err := w.WriteString(line); err != nil {
+				return nil
+			}
+			switch field := strings.TrimRight(line, "\r\n"); {
+			case field == "":
+				// A blank line ends an event
+				if event == "done" {
+					answer = new(agent.ChatResponse)
+					if err := json.Unmarshal([]byte(data.String()), answer); err != nil {
+						log.Printf("Failed to decode the answer of an agent chat: %v", err)
+						answer = nil
+					}
+				}
+				event = ""
+				data.Reset()
+				if err := w.Flush(); err != nil {
+					return nil
+				}
+			case strings.HasPrefix(field, "event:"):
+				event = strings.TrimSpace(strings.TrimPrefix(field, "event:"))
+			case strings.HasPrefix(field, "data:"):
+				data.WriteString(strings.TrimPrefix(strings.TrimPrefix(field, "data:"), " "))
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			w.Flush()
+			return answer
+		}
+		if err != nil {
+			metrics.AgentErrors.Inc(repoID)
+			log.Printf("Agent chat stream broke off: %v", err)
+			fmt.Fprintf(w, "event: error\ndata: {\"message\":\"the agent service stopped answering\"}\n\n")
+			w.Flush()
+			return nil
+		}
+	}
+}
+
+// agentChatRequest reads a chat request, defaulting to the explorer agent.
+// Invalid requests are returned as a *fiber.Error.
+func (h *Handler) agentChatRequest(c fiber.Ctx) (agent.ChatRequest, error) {
 	var req agent.ChatRequest
 	if err := c.Bind().Body(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+		return req, fiber.NewError(400, "invalid request body")
 	}
 
 	// Validate required fields
 	if req.Message == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "message is required"})
+		return req, fiber.NewError(400, "message is required")
 	}
 	if req.AgentType == "" {
 		req.AgentType = "explorer" // Default agent type
 	}
-
-	// Forward to agent service
-	response, err := h.agentProxy.Chat(c.Context(), req.Message, req.RepoID, req.AgentType)
-	if err != nil {
-		return c.Status(502).JSON(fiber.Map{"error": "failed to communicate with agent service: " + err.Error()})
-	}
-
-	return c.JSON(response)
+	return req, h.checkAgentRepository(c, req.RepoID)
 }
 
 // GetWikiNavigation returns the wiki navigation tree
 func (h *Handler) GetWikiNavigation(c fiber.Ctx) error {
-	id := c.Params("id")
-	nav, err := h.wikiReader.GetNavigation(c.Context(), id)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
-	}
-	return c.JSON(nav)
+	return h.cachedJSON(c, c.Params("id"), cacheKeyWikiNav)
 }
 
 // GetWikiPage returns a specific wiki page by slug
@@ -329,12 +1306,12 @@ func (h *Handler) GetWikiPage(c fiber.Ctx) error {
 
 	page, err := h.wikiReader.GetPage(c.Context(), repoID, slug)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return serverError(c, err)
 	}
 	if page == nil {
-		return c.Status(404).JSON(fiber.Map{"error": "wiki page not found"})
+		return notFound(c, "wiki page not found")
 	}
-	return c.JSON(page)
+	return h.sendTaggedJSON(c, page)
 }
 
 // GenerateWiki triggers wiki generation for a repository
@@ -344,10 +1321,10 @@ func (h *Handler) GenerateWiki(c fiber.Ctx) error {
 	// Verify repository exists
 	repo, err := db.GetRepository(c.Context(), h.dbClient, repoID)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return serverError(c, err)
 	}
 	if repo == nil {
-		return c.Status(404).JSON(fiber.Map{"error": "repository not found"})
+		return notFound(c, "repository not found")
 	}
 
 	// Update status to generating
@@ -356,10 +1333,10 @@ func (h *Handler) GenerateWiki(c fiber.Ctx) error {
 		Progress:   0,
 		TotalPages: 5, // Estimate
 	}
-	h.wikiWriter.UpdateWikiStatus(c.Context(), repoID, status)
+	h.setWikiStatus(c.Context(), repoID, status)
 
 	// Start generation in background
-	go h.generateWikiPages(repo)
+	h.enqueueWiki(repo)
 
 	return c.JSON(fiber.Map{"status": "generation started"})
 }
@@ -369,45 +1346,63 @@ func (h *Handler) GetWikiStatus(c fiber.Ctx) error {
 	repoID := c.Params("id")
 	status, err := h.wikiWriter.GetWikiStatus(c.Context(), repoID)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+		return serverError(c, err)
 	}
 	return c.JSON(status)
 }
 
 // generateWikiPages generates all wiki pages for a repository using Claude
-func (h *Handler) generateWikiPages(repo *models.Repository) {
+func (h *Handler) generateWikiPages(repo *models.Repository) error {
 	ctx := context.Background()
 
-	setError := func(msg string) {
+	// What failed is logged; the status only says which step did
+	setError := func(msg string, err error) error {
+		log.Printf("Failed to generate the wiki of %s: %s: %v", repo.Name, msg, err)
 		status := &models.WikiStatus{
 			Status:       "error",
 			Progress:     0,
 			ErrorMessage: msg,
 		}
-		h.wikiWriter.UpdateWikiStatus(ctx, repo.ID, status)
+		h.setWikiStatus(ctx, repo.ID, status)
+		metrics.WikiGenerationsFailed.Inc(repo.ID)
+		return fmt.Errorf("%s: %w", msg, err)
 	}
 
 	// Set status to generating
-	h.wikiWriter.UpdateWikiStatus(ctx, repo.ID, &models.WikiStatus{
+	h.setWikiStatus(ctx, repo.ID, &models.WikiStatus{
 		Status:   "generating",
 		Progress: 0,
 	})
 
+	// Navigation is rebuilt from the new pages once they are written
+	defer h.cache.Delete(repo.ID, cacheKeyWikiNav)
+
 	// Clear existing wiki
 	if err := h.wikiWriter.ClearWiki(ctx, repo.ID); err != nil {
-		setError("failed to clear existing wiki: " + err.Error())
-		return
+		return setError("failed to clear existing wiki", err)
 	}
 
 	// Call agents service to generate wiki
 	wikiResp, err := h.agentProxy.GenerateWiki(ctx, repo.ID, repo.Name)
 	if err != nil {
-		setError("failed to generate wiki: " + err.Error())
-		return
+		metrics.AgentErrors.Inc(repo.ID)
+		return setError("failed to generate wiki", err)
+	}
+
+	// Pages record the indexed files they mention, so a reindex changing
+	// those files can mark them stale
+	var paths []string
+	if hashes, err := h.graphReader.GetFileHashes(ctx, repo.ID); err != nil {
+		log.Printf("Failed to read indexed files of %s: %v", repo.Name, err)
+	} else {
+		for path := range hashes {
+			paths = append(paths, path)
+		}
 	}
 
 	// Store each page
 	totalPages := len(wikiResp.Pages)
+	written := make([]models.WikiPage, 0, totalPages)
 	for i, page := range wikiResp.Pages {
 		// Convert diagrams
 		diagrams := make([]models.Diagram, len(page.Diagrams))
@@ -428,19 +1423,20 @@ func (h *Handler) generateWikiPages(repo *models.Repository) {
 			Order:      page.Order,
 			ParentSlug: "",
 			Diagrams:   diagrams,
+			Sources:    analysis.WikiSources(page.Content, paths),
 		}
 		if page.ParentSlug != nil {
 			wikiPage.ParentSlug = *page.ParentSlug
 		}
 
 		if err := h.wikiWriter.WritePage(ctx, wikiPage); err != nil {
-			setError("failed to write page: " + err.Error())
-			return
+			return setError("failed to write page", err)
 		}
+		written = append(written, *wikiPage)
 
 		// Update progress
 		progress := ((i + 1) * 100) / totalPages
-		h.wikiWriter.UpdateWikiStatus(ctx, repo.ID, &models.WikiStatus{
+		h.setWikiStatus(ctx, repo.ID, &models.WikiStatus{
 			Status:      "generating",
 			Progress:    progress,
 			CurrentPage: page.Title,
@@ -448,10 +1444,55 @@ func (h *Handler) generateWikiPages(repo *models.Repository) {
 		})
 	}
 
+	// Pages stay readable when they cannot be searched
+	if err := h.embedWikiPages(ctx, repo.ID, written); err != nil {
+		metrics.TEIErrors.Inc(repo.ID)
+		log.Printf("Failed to embed the wiki of %s: %v", repo.Name, err)
+	}
+
 	// Set status to ready
-	h.wikiWriter.UpdateWikiStatus(ctx, repo.ID, &models.WikiStatus{
+	h.setWikiStatus(ctx, repo.ID, &models.WikiStatus{
 		Status:     "ready",
 		Progress:   100,
 		TotalPages: totalPages,
 	})
+
+	return nil
+}
+
+// embedWikiPages stores the vectors of a repository's wiki pages for
+// documentation search
+func (h *Handler) embedWikiPages(ctx context.Context, repoID string, pages []models.WikiPage) error {
+	for start := 0; start < len(pages); start += reembedBatchSize {
+		batch := pages[start:min(start+reembedBatchSize, len(pages))]
+		slugs := make([]string, len(batch))
+		texts := make([]string, len(batch))
+		for i, page := range batch {
+			slugs[i], texts[i] = page.Slug, indexer.WikiEmbeddingText(page)
+		}
+		vectors, err := h.embedder.Embed(ctx, texts)
+		if err != nil {
+			return err
+		}
+		if err := h.wikiWriter.SetPageEmbeddings(ctx, repoID, h.dbClient.WriteSpace(), slugs, vectors); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// semanticSearchDisabled answers a search that needs the embedding service
+// while it is unconfigured or unreachable; why is logged rather than
+// revealed, as it names the service's address
+func semanticSearchDisabled(c fiber.Ctx, err error) error {
+	log.Printf("%s %s: %v", c.Method(), c.Path(), err)
+	return sendError(c, 503, CodeSemanticSearchDisabled, "semantic search disabled: the embedding service is unconfigured or unreachable", nil)
+}
+
+// derefString returns the pointed-to string, or an empty string for nil
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
 }
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/embedding"
+	"github.com/gofiber/fiber/v3"
+)
+
+// ComponentHealth is the outcome of checking one dependency. Errors are
+// summarized, as the check is public; the cause is logged.
+type ComponentHealth struct {
+	Status    string `json:"status"` // ok, error or disabled
+	Required  bool   `json:"required"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ReadinessResponse reports the backend ok when every dependency is,
+// degraded when an optional one is down and unavailable when a required one
+// is, by component: neo4j, embeddings and agent
+type ReadinessResponse struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components"`
+}
+
+// healthCheck checks a dependency is up. Only the database is required;
+// without the embedding or agent service searches and wikis fail, but
+// graphs are still served.
+type healthCheck struct {
+	name     string
+	required bool
+	check    func(ctx context.Context) error
+}
+
+func (h *Handler) healthChecks() []healthCheck {
+	return []healthCheck{
+		{name: "neo4j", required: true, check: h.dbClient.Ping},
+		{name: "embeddings", check: func(ctx context.Context) error {
+			return embedding.Ping(ctx, h.embedder)
+		}},
+		{name: "agent", check: h.agentProxy.Health},
+	}
+}
+
+// GetReadiness pings every dependency at once, each within
+// HealthCheckTimeout, answering 503 while a required one is down so load
+// balancers stop routing to the backend
+func (h *Handler) GetReadiness(c fiber.Ctx) error {
+	checks := h.healthChecks()
+	results := make([]ComponentHealth, len(checks))
+
+	var wg sync.WaitGroup
+	for i, hc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = h.checkHealth(c.Context(), hc)
+		}()
+	}
+	wg.Wait()
+
+	resp := ReadinessResponse{Status: "ok", Components: make(map[string]ComponentHealth, len(checks))}
+	for i, hc := range checks {
+		result := results[i]
+		resp.Components[hc.name] = result
+		switch {
+		case result.Status != "error":
+		case result.Required:
+			resp.Status = "unavailable"
+		case resp.Status == "ok":
+			resp.Status = "degraded"
+		}
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	if resp.Status == "unavailable" {
+		return c.Status(503).JSON(resp)
+	}
+	return c.JSON(resp)
+}
+
+// checkHealth runs a dependency check and times it
+func (h *Handler) checkHealth(ctx context.Context, hc healthCheck) ComponentHealth {
+	if h.cfg.HealthCheckTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.cfg.HealthCheckTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := hc.check(ctx)
+	result := ComponentHealth{Status: "ok", Required: hc.required, LatencyMs: time.Since(start).Milliseconds()}
+	switch {
+	case err == nil:
+	case embedding.IsUnconfigured(err):
+		result.Status = "disabled"
+	case errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil:
+		result.Status, result.Error = "error", "timed out"
+		log.Printf("Health check of %s timed out: %v", hc.name, err)
+	default:
+		result.Status, result.Error = "error", "unreachable"
+		log.Printf("Health check of %s failed: %v", hc.name, err)
+	}
+	return result
+}
@@ -0,0 +1,477 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/agent"
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/dpolishuk/neograph/backend/internal/artifact"
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/dpolishuk/neograph/backend/internal/events"
+	"github.com/dpolishuk/neograph/backend/internal/github"
+	"github.com/dpolishuk/neograph/backend/internal/jobs"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/dpolishuk/neograph/backend/internal/openapi"
+	"github.com/gofiber/fiber/v3"
+)
+
+// apiVersion is the version of the API the OpenAPI document describes
+const apiVersion = "1.0.0"
+
+// routeDoc describes a route for the OpenAPI document. Bodies are given as
+// values of the Go types sent, from which their schemas are derived.
+type routeDoc struct {
+	summary  string
+	tag      string
+	query    []queryParam
+	body     any    // JSON request body
+	bodyType string // content type of a body that is not JSON
+	response any    // JSON response body
+	produces string // content type of a response that is not JSON
+	status   string // success status, 200 by default
+	// conditional responses carry an ETag and answer a matching
+	// If-None-Match with 304 Not Modified
+	conditional bool
+}
+
+type queryParam struct {
+	name, typ, description string
+}
+
+// Bodies of responses built with fiber.Map
+type (
+	statusResponse struct {
+		Status string `json:"status"`
+	}
+	deletedResponse struct {
+		Deleted int64 `json:"deleted"`
+	}
+	jobsResponse struct {
+		Status jobs.Status `json:"status"`
+		Jobs   []jobs.Job  `json:"jobs"`
+	}
+	searchAnalyticsResponse struct {
+		Enabled   bool                    `json:"enabled"`
+		Analytics *models.SearchAnalytics `json:"analytics"`
+	}
+	vectorSpaceStatus struct {
+		Space    db.VectorSpace `json:"space"`
+		Embedded int            `json:"embedded"`
+	}
+	vectorSpacesResponse struct {
+		Active   vectorSpaceStatus  `json:"active"`
+		Building *vectorSpaceStatus `json:"building"`
+	}
+	webhookResponse struct {
+		Status       string   `json:"status"` // queued, ignored or pong
+		Repositories []string `json:"repositories,omitempty"`
+	}
+	bulkRepositoriesResponse struct {
+		Created int                           `json:"created"`
+		Results []models.BulkRepositoryResult `json:"results"`
+	}
+)
+
+func limitParam(description string) queryParam {
+	return queryParam{"limit", "integer", description}
+}
+
+var searchParams = []queryParam{
+	{"q", "string", "Search query"},
+	{"mode", "string", "semantic (default), keyword or hybrid; the mode searched is returned in X-Search-Mode, keyword while the embedding service is unavailable"},
+	{"scope", "string", "code (default), docs or all"},
+	{"limit", "integer", "Max results, 10 by default"},
+	{"type", "string", "Entity types to match, comma-separated"},
+	{"lang", "string", "Languages to match, comma-separated"},
+	{"path", "string", "Path prefixes to match, comma-separated"},
+	{"minScore", "number", "Lowest score, 0 to 1, of results returned"},
+	{"diversity", "number", "Trade relevance for variety, 0 to 1"},
+	{"expand", "boolean", "Also search for related terms the agent suggests"},
+}
+
+var graphFilterParams = []queryParam{
+	{"entityType", "string", "Entity types to keep, comma-separated"},
+	{"language", "string", "Languages to keep, comma-separated"},
+	{"path", "string", "Path prefixes to keep, comma-separated"},
+	{"name", "string", "Name substring to keep"},
+	{"minDegree", "integer", "Fewest edges of nodes kept"},
+}
+
+// routeDocs describes each API route by method and path as registered
+var routeDocs = map[string]routeDoc{
+	"GET /api/v1/openapi.json": {summary: "This OpenAPI document", tag: "Docs"},
+	"GET /api/v1/docs":         {summary: "Swagger UI for this API", tag: "Docs", produces: "text/html"},
+
+	"GET /api/v1/search": {summary: "Search all repositories", tag: "Search", query: searchParams,
+		response: []db.SearchResult(nil)},
+	"POST /api/v1/search/chat": {summary: "Ask the agent about selected search results", tag: "Search",
+		body: SearchChatRequest{}, response: agent.ChatResponse{}},
+	"POST /api/v1/search/selections": {summary: "Record which result of a logged search was opened", tag: "Search",
+		body: SearchSelectionRequest{}, status: "204"},
+	"GET /api/v1/graph/system": {summary: "Dependencies between the indexed repositories of the caller's organization", tag: "Graph",
+		response: db.GraphData{}, conditional: true},
+	"POST /api/v1/webhooks/github": {summary: "Receive GitHub push events, signed with the webhook secret, to reindex", tag: "Webhooks",
+		body: github.PushEvent{}, response: webhookResponse{}, status: "202"},
+	"POST /api/v1/agents/chat": {summary: "Chat with an agent", tag: "Agents",
+		body: agent.ChatRequest{}, response: agent.ChatResponse{}},
+	"POST /api/v1/agents/chat/stream": {summary: "Chat with an agent, streaming its answer as token, tool_call and done (or error) events", tag: "Agents",
+		body: agent.ChatRequest{}, produces: "text/event-stream"},
+
+	"GET /api/v1/projects": {summary: "List projects of the caller's organization", tag: "Projects",
+		query:    []queryParam{{"org", "string", "Only projects of an organization, for callers of the whole instance"}},
+		response: []models.Project(nil)},
+	"POST /api/v1/projects": {summary: "Create a project", tag: "Projects",
+		body: ProjectRequest{}, response: models.Project{}, status: "201"},
+	"GET /api/v1/projects/:projectId": {summary: "Get a project", tag: "Projects",
+		response: models.Project{}},
+	"DELETE /api/v1/projects/:projectId": {summary: "Delete a project without repositories", tag: "Projects",
+		status: "204"},
+
+	"GET /api/v1/repositories": {summary: "List repositories", tag: "Repositories",
+		query: []queryParam{
+			{"project", "string", "Only repositories of a project"},
+			{"status", "string", "Only repositories of a status: pending, indexing, ready or error"},
+			{"q", "string", "Only repositories whose name contains this, ignoring case"},
+			{"sort", "string", "name, status, lastIndexed, filesCount or functionsCount, prefixed with - to sort descending; -lastIndexed by default"},
+			{"page", "integer", "Page from 1, when limit is set"},
+			limitParam("Repositories per page, all by default; the X-Total-Count header counts all that match"),
+		},
+		response: []models.Repository(nil)},
+	"POST /api/v1/repositories": {summary: "Add and index a repository; one registered with the URL or Idempotency-Key is returned with 200", tag: "Repositories",
+		body: models.CreateRepositoryInput{}, response: models.Repository{}, status: "201"},
+	"POST /api/v1/repositories/bulk": {summary: "Register repositories by URL or GitHub organization", tag: "Repositories",
+		body: models.BulkRepositoryInput{}, response: bulkRepositoriesResponse{}},
+	"POST /api/v1/repositories/import": {summary: "Import a repository archive or snapshot", tag: "Repositories",
+		query:    []queryParam{{"project", "string", "Project to import into, required of callers scoped to an organization"}},
+		bodyType: "application/gzip", response: SnapshotImportResult{}, status: "201"},
+	"GET /api/v1/repositories/:id": {summary: "Get a repository", tag: "Repositories",
+		response: models.Repository{}},
+	"DELETE /api/v1/repositories/:id": {summary: "Delete a repository and its graph", tag: "Repositories",
+		status: "204"},
+	"GET /api/v1/repositories/:id/settings": {summary: "Get a repository's settings", tag: "Repositories",
+		response: models.RepositorySettings{}},
+	"PUT /api/v1/repositories/:id/settings": {summary: "Change a repository's settings, keeping those left out", tag: "Repositories",
+		body: models.RepositorySettings{}, response: models.RepositorySettings{}},
+	"POST /api/v1/repositories/:id/reindex": {summary: "Reindex a repository", tag: "Repositories",
+		response: statusResponse{}},
+	"GET /api/v1/repositories/:id/events": {summary: "Status and progress of a repository as server-sent events", tag: "Repositories",
+		response: events.Event{}, produces: "text/event-stream"},
+	"GET /api/v1/repositories/:id/runs": {summary: "Index runs of a repository, latest first", tag: "Repositories",
+		query: []queryParam{limitParam("Max runs, 20 by default")}, response: []models.IndexRun(nil)},
+	"GET /api/v1/repositories/:id/runs/:runId/artifact": {summary: "SBOM and graph export of an index run", tag: "Repositories",
+		response: json.RawMessage(nil)},
+	"GET /api/v1/repositories/:id/summary": {summary: "Summary of a repository written by the agent", tag: "Repositories",
+		query: []queryParam{{"refresh", "boolean", "Write the summary again"}}, response: models.RepositorySummary{}},
+	"GET /api/v1/repositories/:id/stats": {summary: "Entity and relationship counts of a repository", tag: "Repositories",
+		response: db.RepositoryStats{}},
+	"GET /api/v1/repositories/:id/files": {summary: "Files of a directory with their functions", tag: "Repositories",
+		query:    []queryParam{{"path", "string", "Directory, the root by default"}, {"depth", "integer", "Levels filled in, 1 by default, 0 all"}},
+		response: db.DirectoryNode{}},
+	"GET /api/v1/repositories/:id/tree": {summary: "Files nested in their directories", tag: "Repositories",
+		query:    []queryParam{{"path", "string", "Directory, the root by default"}, {"depth", "integer", "Levels filled in, 0 (default) all"}},
+		response: db.DirectoryNode{}},
+
+	"GET /api/v1/repositories/:id/graph": {summary: "Graph of a repository for visualization", tag: "Graph",
+		query: append([]queryParam{
+			{"type", "string", "structure (default), calls, architecture or packages"},
+			{"collapse", "string", "package folds the structure graph into directories and packages"},
+			{"depth", "integer", "Directory depth of the architecture graph"},
+			{"cluster", "boolean", "Fold large graphs into directory clusters, true by default"},
+			{"limit", "integer", "Nodes per page"},
+			{"offset", "integer", "Nodes to skip"},
+		}, graphFilterParams...), response: db.GraphData{}, conditional: true},
+	"GET /api/v1/repositories/:id/graph/export": {summary: "Export a graph for Graphviz, yEd or Gephi", tag: "Graph",
+		query:    []queryParam{{"format", "string", "dot (default), graphml or gexf"}, {"type", "string", "structure (default) or calls"}, {"depth", "integer", "Directory depth of the architecture graph"}},
+		produces: "text/plain"},
+	"GET /api/v1/repositories/:id/graph/cluster": {summary: "Expand a directory cluster of a folded graph", tag: "Graph",
+		query:    []queryParam{{"type", "string", "structure (default) or calls"}, {"path", "string", "Directory to expand"}, {"expanded", "string", "Directories already expanded, comma-separated"}},
+		response: db.GraphData{}, conditional: true},
+	"GET /api/v1/repositories/:id/graph/stream": {summary: "Stream a graph in chunks as NDJSON", tag: "Graph",
+		query: append([]queryParam{
+			{"type", "string", "structure (default) or calls"},
+			{"cursor", "integer", "Chunk to resume from"},
+			{"batch", "integer", "Nodes per chunk, 500 by default"},
+		}, graphFilterParams...), response: db.GraphChunk{}, produces: "application/x-ndjson"},
+	"GET /api/v1/repositories/:id/graph/diff": {summary: "Graph changes between two index runs", tag: "Graph",
+		query:    []queryParam{{"from", "string", "Earlier run, the previous by default"}, {"to", "string", "Later run, the latest by default"}},
+		response: artifact.GraphChanges{}},
+	"GET /api/v1/repositories/:id/nodes/:nodeId": {summary: "Details of a node with its source", tag: "Graph",
+		response: db.NodeDetail{}},
+	"GET /api/v1/repositories/:id/nodes/:nodeId/neighborhood": {summary: "Nodes within some hops of a node", tag: "Graph",
+		query:    []queryParam{{"depth", "integer", "Hops, 2 by default"}, limitParam("Max nodes, 200 by default")},
+		response: db.GraphData{}},
+	"GET /api/v1/repositories/:id/nodes/:nodeId/call-chain": {summary: "Callers or callees of a function, transitively", tag: "Graph",
+		query:    []queryParam{{"direction", "string", "downstream (default), the callees, or upstream, the callers"}, {"depth", "integer", "Calls followed, 5 by default"}, limitParam("Max functions, 500 by default")},
+		response: db.CallChain{}},
+	"GET /api/v1/repositories/:id/hierarchy": {summary: "Class inheritance hierarchy", tag: "Graph",
+		query:    []queryParam{{"root", "string", "Class to start from"}, {"format", "string", "json (default) or mermaid"}},
+		response: db.HierarchyNode{}},
+	"GET /api/v1/repositories/:id/search": {summary: "Search a repository", tag: "Search", query: searchParams,
+		response: []db.SearchResult(nil)},
+	"GET /api/v1/repositories/:id/symbols": {summary: "Fuzzy search of symbol names", tag: "Search",
+		query:    []queryParam{{"q", "string", "Symbol name or abbreviation"}, limitParam("Max symbols, 50 by default")},
+		response: []db.SymbolMatch(nil)},
+	"GET /api/v1/repositories/:id/export": {summary: "Full index as a tar.gz archive of metadata, entities, relationships and wiki pages, for import", tag: "Repositories",
+		produces: "application/gzip"},
+	"GET /api/v1/repositories/:id/export/embeddings": {summary: "Entities with their embedding vectors", tag: "Repositories",
+		query: []queryParam{{"format", "string", "ndjson (default)"}}, produces: "application/x-ndjson"},
+	"GET /api/v1/repositories/:id/export/snapshot": {summary: "Snapshot of the graph and history, for import", tag: "Repositories",
+		produces: "application/x-ndjson"},
+
+	"GET /api/v1/repositories/:id/analysis/layers": {summary: "Dependencies violating architecture layers", tag: "Analysis",
+		response: analysis.LayerReport{}},
+	"GET /api/v1/repositories/:id/analysis/coverage-gaps": {summary: "Central functions lacking test coverage", tag: "Analysis",
+		query:    []queryParam{{"maxCoverage", "number", "Highest coverage percent reported"}, limitParam("Max functions, 20 by default")},
+		response: []db.CoverageGap(nil)},
+	"GET /api/v1/repositories/:id/analysis/churn": {summary: "Files changed most often", tag: "Analysis",
+		query: []queryParam{limitParam("Max files, 20 by default")}, response: []db.FileChurn(nil)},
+	"GET /api/v1/repositories/:id/analysis/hotspots": {summary: "Files both complex and often changed", tag: "Analysis",
+		query:    []queryParam{{"depth", "integer", "Rank directories at this depth instead of files"}, limitParam("Max files, 20 by default")},
+		response: []db.FileHotspot(nil)},
+	"GET /api/v1/repositories/:id/analysis/top-central": {summary: "Most central functions", tag: "Analysis",
+		query: []queryParam{limitParam("Max functions, 20 by default")}, response: []db.CentralFunction(nil)},
+	"GET /api/v1/repositories/:id/analysis/orientation": {summary: "Where to start reading a repository", tag: "Analysis",
+		query: []queryParam{limitParam("Max entries per list, 20 by default")}, response: analysis.OrientationReport{}},
+	"GET /api/v1/repositories/:id/analysis/complexity": {summary: "Most complex functions", tag: "Analysis",
+		query: []queryParam{limitParam("Max functions, 20 by default")}, response: []db.ComplexFunction(nil)},
+	"GET /api/v1/repositories/:id/analysis/coupling": {summary: "Most coupled files and packages", tag: "Analysis",
+		query: []queryParam{limitParam("Max entries, 20 by default")}, response: db.CouplingReport{}},
+	"GET /api/v1/repositories/:id/analysis/cycles": {summary: "Cycles of calls", tag: "Analysis",
+		query: []queryParam{limitParam("Max cycles, 50 by default")}, response: db.CallCycles{}},
+	"GET /api/v1/repositories/:id/analysis/dead-code": {summary: "Functions nothing calls", tag: "Analysis",
+		query:    []queryParam{{"includeTests", "boolean", "Also report test helpers nothing calls"}},
+		response: analysis.DeadCodeReport{}},
+	"GET /api/v1/repositories/:id/analysis/unresolved-calls": {summary: "Calls not resolved to a function", tag: "Analysis",
+		query: []queryParam{limitParam("Max names, 50 by default")}, response: analysis.UnresolvedCallReport{}},
+	"POST /api/v1/repositories/:id/analysis/impact": {summary: "What changing functions or files may affect", tag: "Analysis",
+		body: ImpactRequest{}, response: analysis.ImpactReport{}},
+	"GET /api/v1/repositories/:id/analysis/duplicates": {summary: "Possibly duplicated functions", tag: "Analysis",
+		query:    []queryParam{{"minScore", "number", "Lowest similarity reported"}, limitParam("Max pairs, 50 by default")},
+		response: []db.DuplicatePair(nil)},
+	"POST /api/v1/repositories/:id/analysis/duplicates": {summary: "Find possibly duplicated functions again", tag: "Analysis",
+		response: jobs.Job{}, status: "202"},
+
+	"GET /api/v1/repositories/:id/reports": {summary: "Saved analysis reports", tag: "Reports",
+		response: []models.Report(nil)},
+	"POST /api/v1/repositories/:id/reports": {summary: "Save an analysis report", tag: "Reports",
+		body: models.Report{}, response: models.Report{}, status: "201"},
+	"DELETE /api/v1/repositories/:id/reports/:reportId": {summary: "Delete a report and its runs", tag: "Reports",
+		status: "204"},
+	"POST /api/v1/repositories/:id/reports/:reportId/run": {summary: "Run a report", tag: "Reports",
+		response: models.ReportRun{}},
+	"GET /api/v1/repositories/:id/reports/:reportId/runs": {summary: "Runs of a report, latest first", tag: "Reports",
+		query: []queryParam{limitParam("Max runs, 20 by default")}, response: []models.ReportRun(nil)},
+	"GET /api/v1/repositories/:id/reports/:reportId/compare": {summary: "Compare two runs of a report", tag: "Reports",
+		query:    []queryParam{{"from", "string", "Earlier run, the previous by default"}, {"to", "string", "Later run, the latest by default"}},
+		response: models.ReportComparison{}},
+
+	"GET /api/v1/repositories/:id/conversations": {summary: "Conversations with the agent about a repository, latest first", tag: "Conversations",
+		response: []models.Conversation(nil)},
+	"POST /api/v1/repositories/:id/conversations": {summary: "Start a conversation with the agent", tag: "Conversations",
+		body: ConversationRequest{}, response: models.Conversation{}, status: "201"},
+	"GET /api/v1/repositories/:id/conversations/:conversationId": {summary: "Get a conversation with its messages", tag: "Conversations",
+		response: models.Conversation{}},
+	"DELETE /api/v1/repositories/:id/conversations/:conversationId": {summary: "Delete a conversation and its messages", tag: "Conversations",
+		status: "204"},
+	"POST /api/v1/repositories/:id/conversations/:conversationId/messages": {summary: "Continue a conversation, returning the message and the agent's answer", tag: "Conversations",
+		body: ConversationMessageRequest{}, response: []models.ConversationMessage(nil)},
+	"POST /api/v1/repositories/:id/conversations/:conversationId/messages/stream": {summary: "Continue a conversation, streaming the agent's answer as token, tool_call and done (or error) events", tag: "Conversations",
+		body: ConversationMessageRequest{}, produces: "text/event-stream"},
+
+	"POST /api/v1/repositories/:id/traces": {summary: "Upload runtime profiles or traces", tag: "Overlays",
+		query:    []queryParam{{"format", "string", "Format, detected when not given"}},
+		bodyType: "application/octet-stream", response: TraceUploadResult{}},
+	"POST /api/v1/repositories/:id/coverage": {summary: "Upload a test coverage report", tag: "Overlays",
+		query:    []queryParam{{"format", "string", "Format, detected when not given"}},
+		bodyType: "application/octet-stream", response: CoverageUploadResult{}},
+	"POST /api/v1/repositories/:id/findings": {summary: "Upload static analysis findings in SARIF", tag: "Overlays",
+		bodyType: "application/sarif+json", response: FindingsUploadResult{}},
+
+	"GET /api/v1/repositories/:id/wiki": {summary: "Wiki navigation", tag: "Wiki",
+		response: models.WikiNavigation{}, conditional: true},
+	"GET /api/v1/repositories/:id/wiki/status": {summary: "Wiki generation status", tag: "Wiki",
+		response: models.WikiStatus{}},
+	"POST /api/v1/repositories/:id/wiki/generate": {summary: "Generate the wiki", tag: "Wiki",
+		response: statusResponse{}},
+	"GET /api/v1/repositories/:id/wiki/:slug": {summary: "A wiki page", tag: "Wiki",
+		response: models.WikiPageResponse{}, conditional: true},
+
+	"POST /api/v1/admin/gc": {summary: "Remove nodes no longer attached to a repository", tag: "Admin",
+		query: []queryParam{{"dryRun", "boolean", "Only report what would be removed"}}, response: db.OrphanReport{}},
+	"GET /api/v1/admin/jobs": {summary: "Background jobs and the state of their queue", tag: "Admin",
+		response: jobsResponse{}},
+	"POST /api/v1/admin/jobs/pause": {summary: "Stop queued jobs from starting", tag: "Admin",
+		response: jobs.Status{}},
+	"POST /api/v1/admin/jobs/resume": {summary: "Start queued jobs again", tag: "Admin",
+		response: jobs.Status{}},
+	"PUT /api/v1/admin/jobs/concurrency": {summary: "Change how many jobs run at once", tag: "Admin",
+		body: JobConcurrencyRequest{}, response: jobs.Status{}},
+	"POST /api/v1/admin/jobs/drain": {summary: "Pause and wait for running jobs to finish", tag: "Admin",
+		query: []queryParam{{"timeout", "string", "Longest wait, 10m by default"}}, response: jobs.Status{}},
+	"GET /api/v1/admin/vector-spaces": {summary: "Embedding vector indexes and their migration", tag: "Admin",
+		response: vectorSpacesResponse{}},
+	"GET /api/v1/admin/search-analytics": {summary: "Summary of logged searches", tag: "Admin",
+		query:    []queryParam{{"since", "string", "Period, 168h by default"}, limitParam("Max entries per list, 20 by default"), {"repo", "string", "Repository searched"}},
+		response: searchAnalyticsResponse{}},
+	"DELETE /api/v1/admin/search-analytics": {summary: "Delete logged searches", tag: "Admin",
+		query: []queryParam{{"olderThan", "string", "Age of searches deleted, all by default"}}, response: deletedResponse{}},
+	"GET /api/v1/admin/organizations": {summary: "Organizations", tag: "Admin",
+		response: []models.Organization(nil)},
+	"POST /api/v1/admin/organizations": {summary: "Create an organization", tag: "Admin",
+		body: OrganizationRequest{}, response: models.Organization{}, status: "201"},
+	"DELETE /api/v1/admin/organizations/:orgId": {summary: "Delete an organization without projects and revoke its API keys", tag: "Admin",
+		status: "204"},
+	"GET /api/v1/admin/api-keys": {summary: "Provisioned API keys", tag: "Admin",
+		response: []models.APIKey(nil)},
+	"POST /api/v1/admin/api-keys": {summary: "Provision an API key", tag: "Admin",
+		body: APIKeyRequest{}, response: APIKeyResponse{}, status: "201"},
+	"DELETE /api/v1/admin/api-keys/:keyId": {summary: "Revoke an API key", tag: "Admin",
+		status: "204"},
+}
+
+// GetOpenAPI serves the OpenAPI document of the API, built from the
+// registered routes on first request
+func (h *Handler) GetOpenAPI(c fiber.Ctx) error {
+	h.openapiOnce.Do(func() {
+		data, err := json.Marshal(buildOpenAPI(c.App().GetRoutes(true)))
+		if err != nil {
+			log.Printf("Failed to encode the OpenAPI document: %v", err)
+		}
+		h.openapiJSON = data
+	})
+	if h.openapiJSON == nil {
+		return statusError(c, 500, "OpenAPI document unavailable")
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(h.openapiJSON)
+}
+
+// GetAPIDocs serves Swagger UI for the OpenAPI document
+func (h *Handler) GetAPIDocs(c fiber.Ctx) error {
+	// Swagger UI runs scripts and styles from its CDN
+	c.Set(fiber.HeaderContentSecurityPolicy, swaggerUIPolicy)
+	c.Set("Cross-Origin-Embedder-Policy", "unsafe-none")
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.SendString(swaggerUI)
+}
+
+// buildOpenAPI documents the routes of the current version of the API among
+// routes. Routes missing from routeDocs are still listed, so the document
+// never lacks a route.
+func buildOpenAPI(routes []fiber.Route) *openapi.Document {
+	b := openapi.NewBuilder(openapi.Info{
+		Title:   "NeoGraph API",
+		Version: apiVersion,
+		Description: "Code knowledge graphs of Git repositories: indexing, graphs, " +
+			"search, analyses and wikis.",
+	})
+	b.AddSecurityScheme("apiKey", openapi.SecurityScheme{Type: "apiKey", In: "header", Name: APIKeyHeader}, true)
+	b.AddSecurityScheme("bearer", openapi.SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"}, true)
+	errorContent := b.JSON(ErrorResponse{})
+
+	prefix := currentAPIPrefix
+	documented := make(map[string]bool)
+	for _, route := range routes {
+		path := strings.TrimSuffix(route.Path, "/")
+		if !strings.HasPrefix(path, prefix+"/") || route.Method == fiber.MethodHead {
+			continue
+		}
+		key := route.Method + " " + path
+		if documented[key] {
+			// Changed by the version, which routes it first
+			continue
+		}
+		documented[key] = true
+		doc := routeDocs[key]
+
+		op := openapi.Operation{
+			OperationID: operationID(route.Method, strings.TrimPrefix(path, prefix)),
+			Summary:     doc.summary,
+			Responses:   map[string]openapi.Response{"default": {Description: "Error", Content: errorContent}},
+		}
+		if doc.tag != "" {
+			op.Tags = []string{doc.tag}
+		}
+		for _, param := range doc.query {
+			op.Parameters = append(op.Parameters, openapi.Parameter{
+				Name:        param.name,
+				In:          "query",
+				Description: param.description,
+				Schema:      &openapi.Schema{Type: param.typ},
+			})
+		}
+		switch {
+		case doc.body != nil:
+			op.RequestBody = &openapi.RequestBody{Required: true, Content: b.JSON(doc.body)}
+		case doc.bodyType != "":
+			op.RequestBody = &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{
+				doc.bodyType: {Schema: &openapi.Schema{Type: "string", Format: "binary"}},
+			}}
+		}
+
+		status := doc.status
+		if status == "" {
+			status = "200"
+		}
+		success := openapi.Response{Description: "OK"}
+		switch {
+		case doc.produces != "" && doc.response != nil:
+			// Streams of JSON records, described by the record
+			success.Content = map[string]openapi.MediaType{doc.produces: b.JSON(doc.response)["application/json"]}
+		case doc.produces != "":
+			success.Content = map[string]openapi.MediaType{doc.produces: {Schema: &openapi.Schema{Type: "string"}}}
+		case doc.response != nil:
+			success.Content = b.JSON(doc.response)
+		}
+		op.Responses[status] = success
+		if doc.conditional {
+			op.Parameters = append(op.Parameters, openapi.Parameter{
+				Name:        fiber.HeaderIfNoneMatch,
+				In:          "header",
+				Description: "ETag of a cached response, answered with 304 if still current",
+				Schema:      &openapi.Schema{Type: "string"},
+			})
+			op.Responses["304"] = openapi.Response{Description: "Not Modified"}
+		}
+
+		b.Add(route.Method, path, op)
+	}
+	return b.Document()
+}
+
+// operationID names an operation after its method and path within the
+// version, for SDK generators, e.g. get_repositories_id_graph
+func operationID(method, path string) string {
+	var parts []string
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.NewReplacer(":", "", "-", "_", ".", "_").Replace(segment)
+		if segment != "" {
+			parts = append(parts, segment)
+		}
+	}
+	return strings.ToLower(method) + "_" + strings.Join(parts, "_")
+}
+
+// swaggerUIPolicy is the content security policy allowing swaggerUI
+const swaggerUIPolicy = "default-src 'none'; script-src https://unpkg.com 'unsafe-inline'; " +
+	"style-src https://unpkg.com 'unsafe-inline'; img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'"
+
+// swaggerUI loads Swagger UI from a CDN and points it at the document
+const swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>NeoGraph API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: 'openapi.json', dom_id: '#swagger-ui' })
+  </script>
+</body>
+</html>
+`
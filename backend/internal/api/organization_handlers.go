@@ -0,0 +1,272 @@
+package api
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/gofiber/fiber/v3"
+)
+
+// callerOrg returns the organization the caller of a request is scoped to,
+// "" for callers of the whole instance and anonymous ones, see authenticate
+func callerOrg(c fiber.Ctx) string {
+	org, _ := c.Locals(orgLocal).(string)
+	return org
+}
+
+// anonymous reports whether the caller of a request sent no credentials
+// though callers authenticate. Anonymous callers may read when
+// AUTH_REQUIRED_FOR_READS is unset, but only repositories of no
+// organization.
+func (h *Handler) anonymous(c fiber.Ctx) bool {
+	role, _ := c.Locals(roleLocal).(string)
+	return h.authEnabled() && role == ""
+}
+
+// scoped reports whether the caller of a request only reaches the
+// repositories of callerOrg: those of their organization, or of none for
+// anonymous callers
+func (h *Handler) scoped(c fiber.Ctx) bool {
+	return callerOrg(c) != "" || h.anonymous(c)
+}
+
+// tenantRepository keeps scoped callers to their repositories: those of
+// other organizations, and for callers scoped to an organization those
+// registered before organizations, answer 404 as if they did not exist.
+// Paths naming no repository, such as /repositories/bulk, pass through.
+func (h *Handler) tenantRepository(c fiber.Ctx) error {
+	if !h.scoped(c) {
+		return c.Next()
+	}
+	repo, err := db.GetRepository(c.Context(), h.dbClient, c.Params("id"))
+	if err != nil {
+		return serverError(c, err)
+	}
+	if repo != nil && repo.OrgID != callerOrg(c) {
+		return notFound(c, "repository not found")
+	}
+	return c.Next()
+}
+
+// tenantRepoIDs returns the repositories a caller may search and read
+// across: those they are scoped to, or nil for callers of the whole
+// instance, who may read all of them
+func (h *Handler) tenantRepoIDs(c fiber.Ctx) ([]string, error) {
+	if !h.scoped(c) {
+		return nil, nil
+	}
+	return db.ListOrganizationRepositoryIDs(c.Context(), h.dbClient, callerOrg(c))
+}
+
+// canReadRepository reports whether a caller may read a repository, given
+// the repositories they are scoped to as returned by tenantRepoIDs
+func canReadRepository(tenant []string, repoID string) bool {
+	return tenant == nil || slices.Contains(tenant, repoID)
+}
+
+// checkAgentRepository checks that a scoped caller names one of their
+// repositories for the agent to explore, returning a *fiber.Error
+// otherwise
+func (h *Handler) checkAgentRepository(c fiber.Ctx, repoID *string) error {
+	if !h.scoped(c) {
+		return nil
+	}
+	if repoID == nil || *repoID == "" {
+		return fiber.NewError(400, "repo_id is required")
+	}
+	repo, err := db.GetRepository(c.Context(), h.dbClient, *repoID)
+	if err != nil {
+		return err
+	}
+	if repo == nil || repo.OrgID != callerOrg(c) {
+		return fiber.NewError(404, "repository not found")
+	}
+	return nil
+}
+
+// repositoryProject returns the project a caller registers repositories
+// in. Callers scoped to an organization must name a project of it;
+// callers of the whole instance may name any project, or none for a nil
+// project. Invalid projects are returned as a 400 *fiber.Error.
+func (h *Handler) repositoryProject(c fiber.Ctx, projectID string) (*models.Project, error) {
+	org := callerOrg(c)
+	if projectID == "" {
+		if org != "" {
+			return nil, fiber.NewError(400, "projectId is required")
+		}
+		return nil, nil
+	}
+	project, err := db.GetProject(c.Context(), h.dbClient, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil || (org != "" && project.OrgID != org) {
+		return nil, fiber.NewError(400, "project not found")
+	}
+	return project, nil
+}
+
+// ListOrganizations lists the organizations
+func (h *Handler) ListOrganizations(c fiber.Ctx) error {
+	orgs, err := db.ListOrganizations(c.Context(), h.dbClient)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(orgs)
+}
+
+// OrganizationRequest creates an organization under a chosen ID
+type OrganizationRequest struct {
+	ID   string `json:"id"` // lowercase letters, digits and dashes
+	Name string `json:"name"`
+}
+
+// CreateOrganization adds an organization. Its ID is what OIDC tokens name
+// it by in OIDC_ORG_CLAIM.
+func (h *Handler) CreateOrganization(c fiber.Ctx) error {
+	var input OrganizationRequest
+	if err := c.Bind().Body(&input); err != nil {
+		return badRequest(c, "invalid request body")
+	}
+	if !db.ValidOrganizationID(input.ID) {
+		return badRequest(c, "id must be lowercase letters, digits and dashes, at most 63 characters")
+	}
+	org := &models.Organization{ID: input.ID, Name: strings.TrimSpace(input.Name)}
+	if org.Name == "" {
+		org.Name = org.ID
+	}
+
+	if err := db.CreateOrganization(c.Context(), h.dbClient, org); err != nil {
+		if errors.Is(err, db.ErrOrganizationExists) {
+			return statusError(c, 409, err.Error())
+		}
+		return serverError(c, err)
+	}
+	return c.Status(201).JSON(org)
+}
+
+// DeleteOrganization deletes an organization once its projects are, and
+// revokes the API keys scoped to it
+func (h *Handler) DeleteOrganization(c fiber.Ctx) error {
+	found, hashes, err := db.DeleteOrganization(c.Context(), h.dbClient, c.Params("orgId"))
+	if errors.Is(err, db.ErrOrganizationNotEmpty) {
+		return statusError(c, 409, err.Error())
+	}
+	if err != nil {
+		return serverError(c, err)
+	}
+	if !found {
+		return notFound(c, "organization not found")
+	}
+	for _, hash := range hashes {
+		h.keys.Forget(hash)
+	}
+	return c.SendStatus(204)
+}
+
+// ListProjects lists the projects of the caller's organization; callers of
+// the whole instance see all of them, or those of ?org=, and anonymous
+// callers none
+func (h *Handler) ListProjects(c fiber.Ctx) error {
+	if h.anonymous(c) {
+		return c.JSON([]models.Project{})
+	}
+	org := callerOrg(c)
+	if org == "" {
+		org = c.Query("org")
+	}
+	projects, err := db.ListProjects(c.Context(), h.dbClient, org)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(projects)
+}
+
+// ProjectRequest creates a project in an organization
+type ProjectRequest struct {
+	OrgID string `json:"orgId"` // the caller's organization by default
+	Name  string `json:"name"`
+}
+
+// CreateProject adds a project to an organization: the caller's, if they
+// are scoped to one
+func (h *Handler) CreateProject(c fiber.Ctx) error {
+	var input ProjectRequest
+	if err := c.Bind().Body(&input); err != nil {
+		return badRequest(c, "invalid request body")
+	}
+	project := &models.Project{OrgID: input.OrgID, Name: strings.TrimSpace(input.Name)}
+	if project.Name == "" {
+		return badRequest(c, "name is required")
+	}
+	if org := callerOrg(c); org != "" {
+		if project.OrgID != "" && project.OrgID != org {
+			return statusError(c, 403, "projects can only be created in your organization")
+		}
+		project.OrgID = org
+	}
+	if project.OrgID == "" {
+		return badRequest(c, "orgId is required")
+	}
+
+	org, err := db.GetOrganization(c.Context(), h.dbClient, project.OrgID)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if org == nil {
+		return badRequest(c, "organization not found")
+	}
+	if err := db.CreateProject(c.Context(), h.dbClient, project); err != nil {
+		if errors.Is(err, db.ErrProjectExists) {
+			return statusError(c, 409, err.Error())
+		}
+		return serverError(c, err)
+	}
+	return c.Status(201).JSON(project)
+}
+
+// GetProject returns a project of the caller's organization
+func (h *Handler) GetProject(c fiber.Ctx) error {
+	project, err := db.GetProject(c.Context(), h.dbClient, c.Params("projectId"))
+	if err != nil {
+		return serverError(c, err)
+	}
+	if project == nil || !h.canReadProject(c, project) {
+		return notFound(c, "project not found")
+	}
+	return c.JSON(project)
+}
+
+// DeleteProject deletes a project once its repositories are
+func (h *Handler) DeleteProject(c fiber.Ctx) error {
+	id := c.Params("projectId")
+	project, err := db.GetProject(c.Context(), h.dbClient, id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if project == nil || !h.canReadProject(c, project) {
+		return notFound(c, "project not found")
+	}
+
+	found, err := db.DeleteProject(c.Context(), h.dbClient, id)
+	if errors.Is(err, db.ErrProjectNotEmpty) {
+		return statusError(c, 409, err.Error())
+	}
+	if err != nil {
+		return serverError(c, err)
+	}
+	if !found {
+		return notFound(c, "project not found")
+	}
+	return c.SendStatus(204)
+}
+
+// canReadProject reports whether a project is in the caller's organization,
+// or the caller is not scoped; projects all belong to an organization, so
+// anonymous callers read none
+func (h *Handler) canReadProject(c fiber.Ctx, project *models.Project) bool {
+	return !h.scoped(c) || project.OrgID == callerOrg(c)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/auth"
+	"github.com/dpolishuk/neograph/backend/internal/config"
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTenantRepository_Anonymous tests that anonymous callers, who may read
+// by default, only reach repositories of no organization once callers
+// authenticate
+func TestTenantRepository_Anonymous(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+	client, err := db.NewNeo4jClient(ctx, db.Neo4jConfig{
+		URI:      "bolt://localhost:7687",
+		Username: "neo4j",
+		Password: "password",
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	orgRepo, err := db.CreateRepository(ctx, client, &models.Repository{URL: "https://example.com/acme/api", Name: "api", Status: "ready", OrgID: "acme"})
+	require.NoError(t, err)
+	defer db.DeleteRepository(ctx, client, orgRepo.ID)
+	openRepo, err := db.CreateRepository(ctx, client, &models.Repository{URL: "https://example.com/open/tools", Name: "tools", Status: "ready"})
+	require.NoError(t, err)
+	defer db.DeleteRepository(ctx, client, openRepo.ID)
+
+	keys, err := auth.ParseKeys([]string{"instance-key:read"})
+	require.NoError(t, err)
+	h := &Handler{cfg: &config.Config{}, dbClient: client, keys: auth.NewKeyring(keys, nil)}
+	app := fiber.New()
+	SetupRoutes(app, h)
+
+	get := func(path, key string) int {
+		req := httptest.NewRequest("GET", path, nil)
+		if key != "" {
+			req.Header.Set(APIKeyHeader, key)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, 404, get("/api/v1/repositories/"+orgRepo.ID, ""))
+	assert.Equal(t, 200, get("/api/v1/repositories/"+openRepo.ID, ""))
+	assert.Equal(t, 200, get("/api/v1/repositories/"+orgRepo.ID, "instance-key"))
+}
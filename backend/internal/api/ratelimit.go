@@ -0,0 +1,47 @@
+package api
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/dpolishuk/neograph/backend/internal/metrics"
+	"github.com/dpolishuk/neograph/backend/internal/ratelimit"
+	"github.com/gofiber/fiber/v3"
+)
+
+// newLimiter returns a limiter of perMinute requests in bursts of burst, or
+// nil when perMinute disables it
+func newLimiter(perMinute, burst int) *ratelimit.Limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return ratelimit.New(perMinute, burst)
+}
+
+// rateLimited refuses requests with 429 once their client exhausted its
+// share of limiter, named by limit in the rate limiting metric. Clients are
+// told apart by their API key or token subject, or else by IP. A nil
+// limiter lets every request through.
+func rateLimited(limiter *ratelimit.Limiter, limit string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if limiter == nil {
+			return c.Next()
+		}
+		client, _ := c.Locals(callerLocal).(string)
+		if client == "" {
+			client = "ip:" + c.IP()
+		}
+
+		allowed, remaining, retryAfter := limiter.Allow(client)
+		c.Set("X-RateLimit-Limit", strconv.Itoa(limiter.Burst()))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if !allowed {
+			metrics.RateLimited.Inc(limit)
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			return sendError(c, 429, CodeRateLimited, "rate limit exceeded, retry later", fiber.Map{
+				"retryAfter": retryAfter.Seconds(),
+			})
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,332 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/gofiber/fiber/v3"
+)
+
+// reportAnalysis is an analysis that can be saved as a report. run returns
+// the result as the analysis endpoint would, and the keys of the items in
+// it that runs are compared by.
+type reportAnalysis struct {
+	params map[string]func(string) error // accepted params and their validation
+	run    func(ctx context.Context, h *Handler, repoID string, params map[string]string) (any, []string, error)
+}
+
+// reportAnalyses are the analyses reports can be saved for, by name
+var reportAnalyses = map[string]reportAnalysis{
+	"layers": {
+		run: func(ctx context.Context, h *Handler, repoID string, _ map[string]string) (any, []string, error) {
+			files, err := h.graphReader.GetFileLayers(ctx, repoID)
+			if err != nil {
+				return nil, nil, err
+			}
+			result := analysis.FindLayerViolations(files)
+			keys := make([]string, len(result.Violations))
+			for i, v := range result.Violations {
+				keys[i] = v.FilePath + " -> " + v.ImportPath
+			}
+			return result, keys, nil
+		},
+	},
+	"churn": {
+		params: map[string]func(string) error{
+			"limit": intParam(1, 100),
+		},
+		run: func(ctx context.Context, h *Handler, repoID string, params map[string]string) (any, []string, error) {
+			limit := 20
+			if v, ok := params["limit"]; ok {
+				limit, _ = strconv.Atoi(v)
+			}
+			churn, err := h.graphReader.GetFileChurn(ctx, repoID, limit)
+			if err != nil {
+				return nil, nil, err
+			}
+			keys := make([]string, len(churn))
+			for i, file := range churn {
+				keys[i] = file.Path
+			}
+			return churn, keys, nil
+		},
+	},
+	"hotspots": {
+		params: map[string]func(string) error{
+			"depth": intParam(0, 10),
+			"limit": intParam(1, 100),
+		},
+		run: func(ctx context.Context, h *Handler, repoID string, params map[string]string) (any, []string, error) {
+			depth, limit := 0, 20
+			if v, ok := params["depth"]; ok {
+				depth, _ = strconv.Atoi(v)
+			}
+			if v, ok := params["limit"]; ok {
+				limit, _ = strconv.Atoi(v)
+			}
+			hotspots, err := h.graphReader.GetHotspots(ctx, repoID, depth, limit)
+			if err != nil {
+				return nil, nil, err
+			}
+			keys := make([]string, len(hotspots))
+			for i, hotspot := range hotspots {
+				keys[i] = hotspot.Path
+			}
+			return hotspots, keys, nil
+		},
+	},
+	"dead-code": {
+		params: map[string]func(string) error{
+			"includeTests": boolParam,
+		},
+		run: func(ctx context.Context, h *Handler, repoID string, params map[string]string) (any, []string, error) {
+			includeTests, _ := strconv.ParseBool(params["includeTests"])
+			report, err := h.graphReader.GetDeadCode(ctx, repoID, includeTests)
+			if err != nil {
+				return nil, nil, err
+			}
+			keys := make([]string, 0, len(report.Functions)+len(report.Files))
+			for _, fn := range report.Functions {
+				keys = append(keys, fn.FilePath+":"+fn.Name)
+			}
+			for _, file := range report.Files {
+				keys = append(keys, file.Path)
+			}
+			return report, keys, nil
+		},
+	},
+	"coverage-gaps": {
+		params: map[string]func(string) error{
+			"maxCoverage": floatParam(0, 100),
+			"limit":       intParam(1, 100),
+		},
+		run: func(ctx context.Context, h *Handler, repoID string, params map[string]string) (any, []string, error) {
+			maxCoverage, limit := 0.0, 20
+			if v, ok := params["maxCoverage"]; ok {
+				maxCoverage, _ = strconv.ParseFloat(v, 64)
+			}
+			if v, ok := params["limit"]; ok {
+				limit, _ = strconv.Atoi(v)
+			}
+			gaps, err := h.graphReader.GetCoverageGaps(ctx, repoID, maxCoverage, limit)
+			if err != nil {
+				return nil, nil, err
+			}
+			keys := make([]string, len(gaps))
+			for i, gap := range gaps {
+				keys[i] = gap.FilePath + ":" + gap.Name
+			}
+			return gaps, keys, nil
+		},
+	},
+}
+
+func floatParam(min, max float64) func(string) error {
+	return func(v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < min || f > max {
+			return fmt.Errorf("must be a number between %g and %g", min, max)
+		}
+		return nil
+	}
+}
+
+func boolParam(v string) error {
+	if _, err := strconv.ParseBool(v); err != nil {
+		return errors.New("must be true or false")
+	}
+	return nil
+}
+
+func intParam(min, max int) func(string) error {
+	return func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < min || n > max {
+			return fmt.Errorf("must be an integer between %d and %d", min, max)
+		}
+		return nil
+	}
+}
+
+// validateReport checks a report names a known analysis and only the
+// params it accepts, with valid values
+func validateReport(report *models.Report) error {
+	if strings.TrimSpace(report.Name) == "" {
+		return errors.New("name is required")
+	}
+	spec, ok := reportAnalyses[report.Analysis]
+	if !ok {
+		names := make([]string, 0, len(reportAnalyses))
+		for name := range reportAnalyses {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("analysis must be one of %s", strings.Join(names, ", "))
+	}
+	for key, value := range report.Params {
+		validate, ok := spec.params[key]
+		if !ok {
+			return fmt.Errorf("unknown param %q for analysis %s", key, report.Analysis)
+		}
+		if err := validate(value); err != nil {
+			return fmt.Errorf("param %s %w", key, err)
+		}
+	}
+	return nil
+}
+
+// ListReports returns the reports saved on a repository
+func (h *Handler) ListReports(c fiber.Ctx) error {
+	reports, err := db.ListReports(c.Context(), h.dbClient, c.Params("id"))
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(reports)
+}
+
+// CreateReport saves a named analysis with its params on a repository
+func (h *Handler) CreateReport(c fiber.Ctx) error {
+	id := c.Params("id")
+
+	var report models.Report
+	if err := c.Bind().Body(&report); err != nil {
+		return badRequest(c, "invalid request body")
+	}
+	report.Name = strings.TrimSpace(report.Name)
+	if err := validateReport(&report); err != nil {
+		return badRequest(c, err.Error())
+	}
+
+	repo, err := db.GetRepository(c.Context(), h.dbClient, id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if repo == nil {
+		return notFound(c, "repository not found")
+	}
+
+	report.RepoID = id
+	if err := db.CreateReport(c.Context(), h.dbClient, &report); err != nil {
+		if errors.Is(err, db.ErrReportExists) {
+			return statusError(c, 409, err.Error())
+		}
+		return serverError(c, err)
+	}
+	return c.Status(201).JSON(report)
+}
+
+// DeleteReport deletes a saved report and its runs
+func (h *Handler) DeleteReport(c fiber.Ctx) error {
+	if err := db.DeleteReport(c.Context(), h.dbClient, c.Params("id"), c.Params("reportId")); err != nil {
+		return serverError(c, err)
+	}
+	return c.SendStatus(204)
+}
+
+// RunReport runs a saved report against the repository's current graph and
+// stores the result
+func (h *Handler) RunReport(c fiber.Ctx) error {
+	id := c.Params("id")
+
+	report, err := db.GetReport(c.Context(), h.dbClient, id, c.Params("reportId"))
+	if err != nil {
+		return serverError(c, err)
+	}
+	if report == nil {
+		return notFound(c, "report not found")
+	}
+	spec, ok := reportAnalyses[report.Analysis]
+	if !ok {
+		return badRequest(c, "unknown analysis "+report.Analysis)
+	}
+	repo, err := db.GetRepository(c.Context(), h.dbClient, id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if repo == nil {
+		return notFound(c, "repository not found")
+	}
+
+	result, keys, err := spec.run(c.Context(), h, id, report.Params)
+	if err != nil {
+		return serverError(c, err)
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	run := &models.ReportRun{ReportID: report.ID, Commit: repo.Commit, Items: keys, Result: data}
+	if err := db.SaveReportRun(c.Context(), h.dbClient, run); err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(run)
+}
+
+// GetReportRuns returns the runs of a saved report, newest first
+func (h *Handler) GetReportRuns(c fiber.Ctx) error {
+	report, err := db.GetReport(c.Context(), h.dbClient, c.Params("id"), c.Params("reportId"))
+	if err != nil {
+		return serverError(c, err)
+	}
+	if report == nil {
+		return notFound(c, "report not found")
+	}
+
+	limit := fiber.Query[int](c, "limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	runs, err := db.ListReportRuns(c.Context(), h.dbClient, report.ID, limit)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(runs)
+}
+
+// CompareReportRuns lists the items that appeared and disappeared between
+// two runs of a report, given as ?from=&to= run IDs; by default the two
+// latest runs
+func (h *Handler) CompareReportRuns(c fiber.Ctx) error {
+	report, err := db.GetReport(c.Context(), h.dbClient, c.Params("id"), c.Params("reportId"))
+	if err != nil {
+		return serverError(c, err)
+	}
+	if report == nil {
+		return notFound(c, "report not found")
+	}
+
+	runs, err := db.ListReportRuns(c.Context(), h.dbClient, report.ID, 100)
+	if err != nil {
+		return serverError(c, err)
+	}
+	fromID, toID := c.Query("from"), c.Query("to")
+	if fromID == "" && toID == "" {
+		if len(runs) < 2 {
+			return badRequest(c, "the report needs two runs to compare")
+		}
+		return c.JSON(db.CompareReportRuns(runs[1], runs[0]))
+	}
+
+	find := func(runID string) *models.ReportRun {
+		for i := range runs {
+			if runs[i].ID == runID {
+				return &runs[i]
+			}
+		}
+		return nil
+	}
+	from, to := find(fromID), find(toID)
+	if from == nil || to == nil {
+		return notFound(c, "run not found")
+	}
+	return c.JSON(db.CompareReportRuns(*from, *to))
+}
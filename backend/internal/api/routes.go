@@ -1,34 +1,265 @@
 package api
 
 import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/auth"
 	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/timeout"
+	"github.com/valyala/fasthttp"
 )
 
+// withTimeout bounds a handler to d. The deadline is set on c.Context(), so it
+// also cancels the Neo4j transaction the handler is waiting on.
+func withTimeout(handler fiber.Handler, d time.Duration) fiber.Handler {
+	return timeout.New(handler, timeout.Config{
+		Timeout: d,
+		OnTimeout: func(c fiber.Ctx) error {
+			return statusError(c, 504, "request timed out")
+		},
+	})
+}
+
+// versionRoutes are the routes of a version of the API, served under
+// /api/<name>. A version registers only the routes it adds or changes;
+// requests matching none of them fall through to the routes of the versions
+// before it. Superseded versions answer with deprecation headers pointing
+// to the current one.
+type versionRoutes struct {
+	name       string
+	routes     func(h *Handler, api fiber.Router)
+	deprecated time.Time // when superseded; zero for the current version
+}
+
+// apiVersions are the versions of the API, oldest first; the last is the
+// current one, at currentAPIPrefix
+var apiVersions = []versionRoutes{
+	{name: "v1", routes: (*Handler).routesV1},
+}
+
+// currentAPIPrefix is the path prefix of the current version of the API
+const currentAPIPrefix = "/api/v1"
+
+// unversionedDeprecated is when the API moved under /api/v1; the paths
+// before, without a version, are still served as v1
+var unversionedDeprecated = time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+
+// versionedPath matches the paths of a version of the API
+var versionedPath = regexp.MustCompile(`^/api/v[0-9]+(/|$)`)
+
+// uploadPath matches the routes taking uploaded files, in every version of
+// the API: snapshot and archive imports, and trace, coverage and SARIF
+// uploads
+var uploadPath = regexp.MustCompile(`^/api(/v[0-9]+)?/repositories/(import|[^/]+/(traces|coverage|findings))/?$`)
+
+// UploadBodyLimit is the server's HeaderReceived hook letting uploads send
+// bodies of up to limit bytes, as an index archive outgrows BODY_LIMIT,
+// which the other routes keep
+func UploadBodyLimit(limit int) func(header *fasthttp.RequestHeader) fasthttp.RequestConfig {
+	return func(header *fasthttp.RequestHeader) fasthttp.RequestConfig {
+		if !header.IsPost() {
+			return fasthttp.RequestConfig{}
+		}
+		path, _, _ := strings.Cut(string(header.RequestURI()), "?")
+		if !uploadPath.MatchString(path) {
+			return fasthttp.RequestConfig{}
+		}
+		return fasthttp.RequestConfig{MaxRequestBodySize: limit}
+	}
+}
+
+// deprecated marks a response deprecated since a time, linking the route
+// that supersedes it
+func deprecated(c fiber.Ctx, since time.Time, successor string) {
+	c.Set("Deprecation", "@"+strconv.FormatInt(since.Unix(), 10))
+	c.Append(fiber.HeaderLink, "<"+successor+`>; rel="successor-version"`)
+}
+
+// unversioned serves the paths of the API without a version, used by
+// clients and webhooks set up before /api/v1, as v1
+func unversioned(c fiber.Ctx) error {
+	path := c.Path()
+	if versionedPath.MatchString(path) {
+		return c.Next()
+	}
+	successor := "/api/" + apiVersions[0].name + strings.TrimPrefix(path, "/api")
+	deprecated(c, unversionedDeprecated, successor)
+	c.Path(successor)
+	return c.Next()
+}
+
+// supersededBy marks the responses of a superseded version deprecated,
+// linking the same path under the current version
+func supersededBy(v versionRoutes) fiber.Handler {
+	prefix := "/api/" + v.name
+	return func(c fiber.Ctx) error {
+		deprecated(c, v.deprecated, currentAPIPrefix+strings.TrimPrefix(c.Path(), prefix))
+		return c.Next()
+	}
+}
+
 func SetupRoutes(app *fiber.App, h *Handler) {
-	api := app.Group("/api")
+	app.Use("/api", unversioned)
+
+	// Webhooks authenticate by their signature, so they are routed before
+	// the API's authentication
+	app.Post("/api/v1/webhooks/github", h.GitHubWebhook)
+
+	// Callers are identified before anything else. Reading needs the viewer
+	// role, changing anything editor and the admin API admin, see require.
+	// Callers scoped to an organization only reach its repositories, see
+	// tenantRepository.
+	for i, v := range apiVersions {
+		api := app.Group("/api/"+v.name, h.authenticate, h.require(auth.RoleViewer))
+		if i < len(apiVersions)-1 {
+			api.Use(supersededBy(v))
+		}
+		api.Use("/repositories/:id", h.tenantRepository)
+		for j := i; j >= 0; j-- {
+			apiVersions[j].routes(h, api)
+		}
+	}
+}
+
+// routesV1 registers the routes of /api/v1
+func (h *Handler) routesV1(api fiber.Router) {
+	editor := h.require(auth.RoleEditor)
+
+	// Endpoints embedding queries or running heavy Cypher are rate limited
+	searchLimit := rateLimited(h.searchLimit, "search")
+	graphLimit := rateLimited(h.graphLimit, "graph")
+
+	// API description
+	api.Get("/openapi.json", h.GetOpenAPI)
+	api.Get("/docs", h.GetAPIDocs)
 
 	// Search endpoints
-	api.Get("/search", h.GlobalSearch)
+	api.Get("/search", searchLimit, withTimeout(h.GlobalSearch, h.cfg.SearchTimeout))
+	api.Post("/search/chat", searchLimit, h.SearchChat)
+	api.Post("/search/selections", h.LogSearchSelection)
+
+	// Dependencies between all indexed repositories
+	api.Get("/graph/system", graphLimit, withTimeout(h.GetSystemGraph, h.cfg.GraphTimeout))
 
 	// Agent proxy endpoints
 	agents := api.Group("/agents")
 	agents.Post("/chat", h.ProxyAgentChat)
+	agents.Post("/chat/stream", h.ProxyAgentChatStream)
+
+	// Projects of organizations, which repositories belong to
+	api.Get("/projects", h.ListProjects)
+	api.Post("/projects", editor, h.CreateProject)
+	api.Get("/projects/:projectId", h.GetProject)
+	api.Delete("/projects/:projectId", editor, h.DeleteProject)
 
 	// Repositories
 	repos := api.Group("/repositories")
 	repos.Get("/", h.ListRepositories)
-	repos.Post("/", h.CreateRepository)
+	repos.Post("/", editor, h.CreateRepository)
+	repos.Post("/bulk", editor, h.BulkCreateRepositories)
+	repos.Post("/import", editor, h.ImportSnapshot)
 	repos.Get("/:id", h.GetRepository)
-	repos.Delete("/:id", h.DeleteRepository)
-	repos.Post("/:id/reindex", h.ReindexRepository)
-	repos.Get("/:id/files", h.GetRepositoryFiles)
-	repos.Get("/:id/graph", h.GetRepositoryGraph)
-	repos.Get("/:id/nodes/:nodeId", h.GetNodeDetail)
-	repos.Get("/:id/search", h.RepoSearch)
+	repos.Delete("/:id", editor, h.DeleteRepository)
+	repos.Get("/:id/settings", h.GetRepositorySettings)
+	repos.Put("/:id/settings", editor, h.UpdateRepositorySettings)
+	repos.Post("/:id/reindex", editor, h.ReindexRepository)
+	repos.Get("/:id/events", h.GetRepositoryEvents)
+	repos.Get("/:id/runs", h.GetIndexRuns)
+	repos.Get("/:id/runs/:runId/artifact", h.GetIndexRunArtifact)
+	repos.Get("/:id/summary", h.GetRepositorySummary)
+	repos.Get("/:id/stats", withTimeout(h.GetRepositoryStats, h.cfg.GraphTimeout))
+	repos.Get("/:id/files", withTimeout(h.GetRepositoryFiles, h.cfg.GraphTimeout))
+	repos.Get("/:id/tree", withTimeout(h.GetRepositoryTree, h.cfg.GraphTimeout))
+	repos.Get("/:id/graph", graphLimit, withTimeout(h.GetRepositoryGraph, h.cfg.GraphTimeout))
+	repos.Get("/:id/graph/export", graphLimit, withTimeout(h.ExportGraph, h.cfg.GraphTimeout))
+	repos.Get("/:id/graph/cluster", graphLimit, withTimeout(h.GetGraphCluster, h.cfg.GraphTimeout))
+	repos.Get("/:id/graph/stream", graphLimit, h.GetGraphStream)
+	repos.Get("/:id/graph/diff", graphLimit, withTimeout(h.GetGraphDiff, h.cfg.GraphTimeout))
+	repos.Get("/:id/nodes/:nodeId", withTimeout(h.GetNodeDetail, h.cfg.NodeTimeout))
+	repos.Get("/:id/nodes/:nodeId/neighborhood", withTimeout(h.GetNodeNeighborhood, h.cfg.GraphTimeout))
+	repos.Get("/:id/nodes/:nodeId/call-chain", withTimeout(h.GetCallChain, h.cfg.GraphTimeout))
+	repos.Get("/:id/hierarchy", withTimeout(h.GetClassHierarchy, h.cfg.GraphTimeout))
+	repos.Get("/:id/search", searchLimit, withTimeout(h.RepoSearch, h.cfg.SearchTimeout))
+	repos.Get("/:id/symbols", searchLimit, withTimeout(h.SearchSymbols, h.cfg.SearchTimeout))
+	repos.Get("/:id/export", h.ExportArchive)
+	repos.Get("/:id/export/embeddings", h.ExportEmbeddings)
+	repos.Get("/:id/export/snapshot", h.ExportSnapshot)
+
+	// Analysis endpoints
+	repos.Get("/:id/analysis/layers", h.GetLayerAnalysis)
+	repos.Get("/:id/analysis/coverage-gaps", h.GetCoverageGaps)
+	repos.Get("/:id/analysis/churn", h.GetChurnAnalysis)
+	repos.Get("/:id/analysis/hotspots", h.GetHotspots)
+	repos.Get("/:id/analysis/top-central", h.GetTopCentral)
+	repos.Get("/:id/analysis/orientation", withTimeout(h.GetOrientation, h.cfg.GraphTimeout))
+	repos.Get("/:id/analysis/complexity", h.GetMostComplex)
+	repos.Get("/:id/analysis/coupling", h.GetCouplingOutliers)
+	repos.Get("/:id/analysis/cycles", withTimeout(h.GetCallCycles, h.cfg.GraphTimeout))
+	repos.Get("/:id/analysis/dead-code", withTimeout(h.GetDeadCode, h.cfg.GraphTimeout))
+	repos.Get("/:id/analysis/unresolved-calls", h.GetUnresolvedCalls)
+	repos.Post("/:id/analysis/impact", withTimeout(h.GetImpact, h.cfg.GraphTimeout))
+	repos.Get("/:id/analysis/duplicates", h.GetDuplicates)
+	repos.Post("/:id/analysis/duplicates", editor, h.FindDuplicates)
+
+	// Saved analysis reports, re-run and compared over time
+	repos.Get("/:id/reports", h.ListReports)
+	repos.Post("/:id/reports", editor, h.CreateReport)
+	repos.Delete("/:id/reports/:reportId", editor, h.DeleteReport)
+	repos.Post("/:id/reports/:reportId/run", editor, h.RunReport)
+	repos.Get("/:id/reports/:reportId/runs", h.GetReportRuns)
+	repos.Get("/:id/reports/:reportId/compare", h.CompareReportRuns)
+
+	// Conversations with the agent about a repository, kept to be continued
+	repos.Get("/:id/conversations", h.ListConversations)
+	repos.Post("/:id/conversations", editor, h.CreateConversation)
+	repos.Get("/:id/conversations/:conversationId", h.GetConversation)
+	repos.Delete("/:id/conversations/:conversationId", editor, h.DeleteConversation)
+	repos.Post("/:id/conversations/:conversationId/messages", editor, h.SendConversationMessage)
+	repos.Post("/:id/conversations/:conversationId/messages/stream", editor, h.StreamConversationMessage)
+
+	// Runtime profiles and traces overlaid on the graph
+	repos.Post("/:id/traces", editor, h.UploadTraces)
+
+	// Test coverage reports overlaid on the graph
+	repos.Post("/:id/coverage", editor, h.UploadCoverage)
+
+	// Static analysis findings in SARIF
+	repos.Post("/:id/findings", editor, h.UploadFindings)
 
 	// Wiki endpoints
 	repos.Get("/:id/wiki", h.GetWikiNavigation)
 	repos.Get("/:id/wiki/status", h.GetWikiStatus)
-	repos.Post("/:id/wiki/generate", h.GenerateWiki)
+	repos.Post("/:id/wiki/generate", editor, h.GenerateWiki)
 	repos.Get("/:id/wiki/:slug", h.GetWikiPage)
+
+	// Admin endpoints
+	admin := api.Group("/admin", h.require(auth.RoleAdmin))
+	admin.Post("/gc", h.CollectGarbage)
+
+	// Background index and wiki jobs
+	admin.Get("/jobs", h.GetJobs)
+	admin.Post("/jobs/pause", h.PauseJobs)
+	admin.Post("/jobs/resume", h.ResumeJobs)
+	admin.Put("/jobs/concurrency", h.SetJobConcurrency)
+	admin.Post("/jobs/drain", h.DrainJobs)
+
+	// Embedding vector indexes and migrations between them
+	admin.Get("/vector-spaces", h.GetVectorSpaces)
+
+	// Logged searches, when search analytics are enabled
+	admin.Get("/search-analytics", h.GetSearchAnalytics)
+	admin.Delete("/search-analytics", h.DeleteSearchAnalytics)
+
+	// Organizations, the tenants of the instance
+	admin.Get("/organizations", h.ListOrganizations)
+	admin.Post("/organizations", h.CreateOrganization)
+	admin.Delete("/organizations/:orgId", h.DeleteOrganization)
+
+	// API keys, besides those configured in API_KEYS
+	admin.Get("/api-keys", h.ListAPIKeys)
+	admin.Post("/api-keys", h.CreateAPIKey)
+	admin.Delete("/api-keys/:keyId", h.DeleteAPIKey)
 }
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"log"
+
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/dpolishuk/neograph/backend/internal/jobs"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/dpolishuk/neograph/backend/internal/scheduler"
+)
+
+// schedulerRunner adapts the handler's indexing and wiki generation to the scheduler
+type schedulerRunner struct {
+	h *Handler
+}
+
+// SchedulerRunner returns the scheduler.Runner backed by this handler
+func (h *Handler) SchedulerRunner() scheduler.Runner {
+	return &schedulerRunner{h: h}
+}
+
+func (r *schedulerRunner) ListRepositories(ctx context.Context) ([]*models.Repository, error) {
+	return db.ListRepositories(ctx, r.h.dbClient)
+}
+
+// Reindex and RefreshWiki run through the job queue, so scheduled work
+// shows up, pauses and drains with the rest
+
+func (r *schedulerRunner) Reindex(ctx context.Context, repo *models.Repository) error {
+	_, done := r.h.jobs.Enqueue(jobs.KindIndex, repo.ID, repo.Name, func(ctx context.Context) error {
+		return r.h.reindex(ctx, repo, "")
+	})
+	return wait(ctx, done)
+}
+
+func (r *schedulerRunner) RefreshWiki(ctx context.Context, repo *models.Repository) error {
+	_, done := r.h.jobs.Enqueue(jobs.KindWiki, repo.ID, repo.Name, func(ctx context.Context) error {
+		return r.h.generateWikiPages(repo)
+	})
+	return wait(ctx, done)
+}
+
+// wait returns the result of a queued job, or the context's error if it is
+// done first; the job then still runs
+func wait(ctx context.Context, done <-chan error) error {
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// MarkWikiStale flags the wiki stale and tells the wiki webhook, if one is
+// configured, which pages mention files the reindex changed
+func (r *schedulerRunner) MarkWikiStale(ctx context.Context, repo *models.Repository) error {
+	if err := r.h.wikiWriter.MarkWikiStale(ctx, repo.ID); err != nil {
+		return err
+	}
+	if !r.h.webhook.Enabled() {
+		return nil
+	}
+
+	pages, err := r.h.wikiReader.GetStalePages(ctx, repo.ID)
+	if err != nil || len(pages) == 0 {
+		return err
+	}
+	// The reindex recorded the commit the pages are now stale against
+	if current, err := db.GetRepository(ctx, r.h.dbClient, repo.ID); err == nil && current != nil {
+		repo = current
+	}
+	if err := r.h.webhook.WikiStale(ctx, repo, pages); err != nil {
+		log.Printf("Failed to notify wiki webhook for %s: %v", repo.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,493 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/dpolishuk/neograph/backend/internal/embedding"
+	"github.com/dpolishuk/neograph/backend/internal/git"
+	"github.com/dpolishuk/neograph/backend/internal/metrics"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/gofiber/fiber/v3"
+)
+
+// maxSearchChatResults caps how many selected results are sent to the agent
+const maxSearchChatResults = 20
+
+// maxSnippetLines caps the source lines included per selected result
+const maxSnippetLines = 80
+
+// maxResultSnippetLines caps the lines of the preview of each search result
+const maxResultSnippetLines = 8
+
+// diversityCandidates is how many times the requested number of results are
+// fetched for ?diversity= to choose from
+const diversityCandidates = 3
+
+// maxQueryExpansions caps the variants of a query the agent suggests for
+// ?expand=true
+const maxQueryExpansions = 4
+
+// maxDetailLines caps the source lines a node detail reads from the clone
+const maxDetailLines = 500
+
+// Search modes: by embedding similarity, by the words of names, signatures
+// and docstrings, or by both with their rankings fused
+const (
+	searchSemantic = "semantic"
+	searchKeyword  = "keyword"
+	searchHybrid   = "hybrid"
+)
+
+// Search scopes: functions, methods and classes, documentation (wiki pages
+// and long docstrings), or both
+const (
+	scopeCode = "code"
+	scopeDocs = "docs"
+	scopeAll  = "all"
+)
+
+// SearchChatRequest asks the agent about a selection of search results
+type SearchChatRequest struct {
+	Query     string   `json:"query"`
+	Message   string   `json:"message"`
+	ResultIDs []string `json:"result_ids"`
+	RepoID    *string  `json:"repo_id,omitempty"`
+	AgentType string   `json:"agent_type"`
+}
+
+// SearchChat forwards a chat request seeded with the selected search results
+func (h *Handler) SearchChat(c fiber.Ctx) error {
+	var req SearchChatRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return badRequest(c, "invalid request body")
+	}
+
+	if len(req.ResultIDs) == 0 {
+		return badRequest(c, "result_ids is required")
+	}
+	if len(req.ResultIDs) > maxSearchChatResults {
+		return badRequest(c, fmt.Sprintf("at most %d results can be selected", maxSearchChatResults))
+	}
+	if req.Message == "" {
+		req.Message = "Explain how these results relate to the search and to each other."
+	}
+	if req.AgentType == "" {
+		req.AgentType = "explorer" // Default agent type
+	}
+
+	entities, err := h.graphReader.GetEntitiesByIDs(c.Context(), req.ResultIDs)
+	if err != nil {
+		return serverError(c, err)
+	}
+	tenant, err := h.tenantRepoIDs(c)
+	if err != nil {
+		return serverError(c, err)
+	}
+	entities = slices.DeleteFunc(entities, func(e db.EntitySummary) bool {
+		return !canReadRepository(tenant, e.RepoID)
+	})
+	if len(entities) == 0 {
+		return notFound(c, "no selected results found")
+	}
+
+	// Scope the agent to the results' repository when they share one, and
+	// always for callers scoped to an organization
+	repoID := req.RepoID
+	if repoID == nil && (sameRepository(entities) || tenant != nil) {
+		repoID = &entities[0].RepoID
+	}
+	if err := h.checkAgentRepository(c, repoID); err != nil {
+		return err
+	}
+
+	message := h.buildSearchChatMessage(req.Query, req.Message, entities)
+	response, err := h.agentProxy.Chat(c.Context(), message, repoID, req.AgentType)
+	if err != nil {
+		metrics.AgentErrors.Inc(derefString(repoID))
+		return upstreamError(c, "failed to communicate with agent service", err)
+	}
+
+	return c.JSON(response)
+}
+
+// buildSearchChatMessage prefixes the user's question with the selected
+// entities, using their source from the checkout when available
+func (h *Handler) buildSearchChatMessage(query, question string, entities []db.EntitySummary) string {
+	var b strings.Builder
+	if query != "" {
+		fmt.Fprintf(&b, "I searched for %q and selected these results:\n\n", query)
+	} else {
+		b.WriteString("I selected these code entities:\n\n")
+	}
+
+	for i, entity := range entities {
+		fmt.Fprintf(&b, "%d. %s %s (%s:%d-%d)\n", i+1, entity.Type, entity.Name, entity.FilePath, entity.StartLine, entity.EndLine)
+
+		snippet := h.readSnippet(entity)
+		if snippet == "" {
+			snippet = entity.Signature
+			if entity.Docstring != "" {
+				snippet = entity.Docstring + "\n" + snippet
+			}
+		}
+		fmt.Fprintf(&b, "```\n%s\n```\n\n", snippet)
+	}
+
+	b.WriteString(question)
+	return b.String()
+}
+
+// SearchSymbols matches ?q= fuzzily against the qualified names of a
+// repository's functions, methods and classes, like an editor's go-to-symbol.
+// The symbols are listed once per index and kept in the response cache.
+func (h *Handler) SearchSymbols(c fiber.Ctx) error {
+	repoID := c.Params("id")
+	query := c.Query("q")
+	if query == "" {
+		return badRequest(c, "query parameter 'q' is required")
+	}
+
+	limit := fiber.Query[int](c, "limit", 50)
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	var symbols []db.Symbol
+	err := h.cachedValue(c.Context(), repoID, cacheKeySymbols, skipCache(c), h.loaders()[cacheKeySymbols], &symbols)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(db.MatchSymbols(symbols, query, limit))
+}
+
+// keywordSearch responds with the entities matching the words of query, in
+// one repository or all of them when repoID is empty
+func (h *Handler) keywordSearch(c fiber.Ctx, query string, limit int, repoID string, filter db.SearchFilter, diversity float64) error {
+	results, err := h.graphReader.KeywordSearch(c.Context(), query, searchCandidates(limit, diversity), repoID, filter)
+	if err != nil {
+		return serverError(c, fmt.Errorf("search failed: %w", err))
+	}
+	return h.respondSearch(c, repoID, searchKeyword, withSnippets(diversify(results, diversity, limit), query))
+}
+
+// expandedSearch searches in mode for query and the variants of it the agent
+// suggests, in one repository or all of them when repoID is empty, and fuses
+// the rankings, so vague questions also find code named otherwise. Only the
+// query is searched when the agent is unavailable, and only by keyword when
+// the embedding service is, as GlobalSearch falls back.
+func (h *Handler) expandedSearch(c fiber.Ctx, query, mode, scope string, limit int, repoID string, filter db.SearchFilter, diversity float64) error {
+	queries := append([]string{query}, h.expandQuery(c.Context(), query, repoID)...)
+	candidates := searchCandidates(limit, diversity)
+
+	// Embed the query and its variants in one request
+	var space db.VectorSpace
+	var embeddings [][]float32
+	if mode != searchKeyword {
+		embedder, active := h.searchSpace()
+		vectors, err := embedder.Embed(c.Context(), queries)
+		if err != nil {
+			metrics.TEIErrors.Inc(repoID)
+		}
+		switch {
+		case errors.Is(err, embedding.ErrUnavailable) && scope != scopeDocs:
+			log.Printf("Searching %q by keyword: %v", query, err)
+			mode = searchKeyword
+		case errors.Is(err, embedding.ErrUnavailable):
+			return semanticSearchDisabled(c, err)
+		case err != nil:
+			return upstreamError(c, "failed to generate embedding", err)
+		case len(vectors) != len(queries):
+			return statusError(c, 500, "no embedding generated")
+		}
+		space, embeddings = active, vectors
+	}
+
+	lists := make([][]db.SearchResult, len(queries))
+	for i, text := range queries {
+		var err error
+		if mode == searchKeyword {
+			lists[i], err = h.graphReader.KeywordSearch(c.Context(), text, candidates, repoID, filter)
+		} else {
+			lists[i], err = h.embeddingSearch(c.Context(), mode, scope, space, embeddings[i], text, candidates, repoID, filter)
+		}
+		if err != nil {
+			return serverError(c, fmt.Errorf("search failed: %w", err))
+		}
+	}
+
+	results := db.FuseRanks(lists, candidates)
+	return h.respondSearch(c, repoID, mode, withSnippets(diversify(results, diversity, limit), query))
+}
+
+// SearchIDHeader carries the ID of a logged search, which selections of its
+// results refer to
+const SearchIDHeader = "X-Search-Id"
+
+// SearchModeHeader carries the mode a search ran in: keyword rather than
+// the requested one when semantic search is unavailable
+const SearchModeHeader = "X-Search-Mode"
+
+// respondSearch responds with the results of the search for ?q= run in
+// mode. With search analytics enabled the search is logged first and its ID
+// sent in SearchIDHeader; a search that cannot be logged still succeeds.
+func (h *Handler) respondSearch(c fiber.Ctx, repoID, mode string, results []db.SearchResult) error {
+	c.Set(SearchModeHeader, mode)
+	if h.cfg.SearchAnalytics {
+		entry := models.SearchLog{
+			Query:   c.Query("q"),
+			Mode:    mode,
+			Scope:   c.Query("scope", scopeCode),
+			RepoID:  repoID,
+			Results: len(results),
+		}
+		if err := db.LogSearch(c.Context(), h.dbClient, &entry); err != nil {
+			log.Printf("Failed to log search: %v", err)
+		} else {
+			c.Set(SearchIDHeader, entry.ID)
+		}
+	}
+	return c.JSON(results)
+}
+
+// SearchSelectionRequest reports the result opened from a logged search
+type SearchSelectionRequest struct {
+	SearchID string `json:"searchId"`
+	ResultID string `json:"resultId"`
+	Rank     int    `json:"rank"`
+}
+
+// LogSearchSelection records which result of a logged search was opened,
+// from a body of {searchId, resultId, rank}, rank 1 being the first result
+func (h *Handler) LogSearchSelection(c fiber.Ctx) error {
+	if !h.cfg.SearchAnalytics {
+		return notFound(c, "search analytics are disabled")
+	}
+
+	var input SearchSelectionRequest
+	if err := c.Bind().Body(&input); err != nil {
+		return badRequest(c, "invalid request body")
+	}
+	if input.SearchID == "" || input.ResultID == "" || input.Rank < 1 {
+		return badRequest(c, "searchId, resultId and a rank of at least 1 are required")
+	}
+
+	err := db.LogSearchSelection(c.Context(), h.dbClient, input.SearchID, input.ResultID, input.Rank)
+	if errors.Is(err, db.ErrSearchNotFound) {
+		return notFound(c, err.Error())
+	}
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.SendStatus(204)
+}
+
+// embeddingSearch runs a semantic or hybrid search for text and its
+// embedding in scope, in one repository or all of them when repoID is
+// empty. filter narrows code results, and documentation results to its
+// repositories. With scopeAll the code and documentation rankings are
+// fused, as their similarities are not comparable.
+func (h *Handler) embeddingSearch(ctx context.Context, mode, scope string, space db.VectorSpace, embedding []float32, text string, limit int, repoID string, filter db.SearchFilter) ([]db.SearchResult, error) {
+	var code []db.SearchResult
+	var err error
+	if scope != scopeDocs {
+		if mode == searchHybrid {
+			code, err = h.graphReader.HybridSearch(ctx, space, embedding, text, limit, repoID, filter)
+		} else {
+			code, err = h.graphReader.VectorSearch(ctx, space, embedding, limit, repoID, filter)
+		}
+		if err != nil || scope == scopeCode {
+			return code, err
+		}
+	}
+
+	docs, err := h.graphReader.DocSearch(ctx, space, embedding, limit, repoID, filter)
+	if err != nil || scope == scopeDocs {
+		return docs, err
+	}
+	return db.FuseRanks([][]db.SearchResult{code, docs}, limit), nil
+}
+
+// parseSearchScope reads ?scope=, code by default. Documentation is only
+// searched by embedding, so docs and all need the semantic or hybrid mode.
+func parseSearchScope(c fiber.Ctx, mode string) (string, error) {
+	switch scope := c.Query("scope", scopeCode); scope {
+	case scopeCode:
+		return scope, nil
+	case scopeDocs, scopeAll:
+		if mode == searchKeyword {
+			return "", fmt.Errorf("scope %s needs semantic or hybrid mode", scope)
+		}
+		return scope, nil
+	default:
+		return "", fmt.Errorf("scope must be code, docs or all")
+	}
+}
+
+// expandQuery asks the agent for variants of a search query, returning none
+// when it cannot be reached. The agent is not scoped to repoID: looking
+// through the repository would make every search wait for its tools.
+func (h *Handler) expandQuery(ctx context.Context, query, repoID string) []string {
+	response, err := h.agentProxy.Chat(ctx, analysis.ExpansionPrompt(query, maxQueryExpansions), nil, "explorer")
+	if err != nil {
+		metrics.AgentErrors.Inc(repoID)
+		log.Printf("Searching %q without expansion: %v", query, err)
+		return nil
+	}
+	return analysis.ParseExpansions(response.Response, query, maxQueryExpansions)
+}
+
+// parseDiversity reads ?diversity=, from 0 for results ranked by relevance
+// alone to 1 for results as unlike each other as possible
+func parseDiversity(c fiber.Ctx) (float64, error) {
+	diversity := fiber.Query[float64](c, "diversity", 0)
+	if diversity < 0 || diversity > 1 {
+		return 0, fmt.Errorf("diversity must be between 0 and 1")
+	}
+	return diversity, nil
+}
+
+// searchCandidates returns how many results to fetch for limit of them to
+// be kept after diversifying
+func searchCandidates(limit int, diversity float64) int {
+	if diversity == 0 {
+		return limit
+	}
+	return limit * diversityCandidates
+}
+
+// diversify keeps limit of the ranked results by maximal marginal relevance,
+// so near-identical entities such as generated clones do not fill the top.
+// Entities are compared by the identifier words of their name, signature and
+// source, which every search mode returns. Scores are relative to the best
+// result since each mode scores on its own scale.
+func diversify(results []db.SearchResult, diversity float64, limit int) []db.SearchResult {
+	if diversity == 0 || len(results) == 0 {
+		return results
+	}
+
+	best := results[0].Score
+	relevance := make([]float64, len(results))
+	tokens := make([]map[string]bool, len(results))
+	for i, result := range results {
+		if best > 0 {
+			relevance[i] = result.Score / best
+		}
+		tokens[i] = analysis.TokenSet(result.Name + " " + result.Signature + " " + result.Content)
+	}
+	similarity := func(i, j int) float64 { return analysis.Jaccard(tokens[i], tokens[j]) }
+
+	picked := analysis.Diversify(relevance, similarity, 1-diversity, limit)
+	diverse := make([]db.SearchResult, len(picked))
+	for i, index := range picked {
+		diverse[i] = results[index]
+	}
+	return diverse
+}
+
+// withSnippets previews each result with the lines of its stored source
+// around the words of query, or its first lines when none appear
+func withSnippets(results []db.SearchResult, query string) []db.SearchResult {
+	for i := range results {
+		result := &results[i]
+		if result.Content == "" {
+			continue
+		}
+		snippet, offset := analysis.Snippet(result.Content, query, maxResultSnippetLines)
+		result.Snippet = snippet
+		result.SnippetLine = result.StartLine + offset
+	}
+	return results
+}
+
+// parseSearchFilter reads the search filter: ?lang= a language such as go,
+// ?type= comma-separated entity types (function, method, class), ?path= a
+// path prefix and ?minScore= the lowest score kept, from 0 to 1
+func parseSearchFilter(c fiber.Ctx) (db.SearchFilter, error) {
+	filter := db.SearchFilter{
+		Language:   strings.ToLower(c.Query("lang")),
+		PathPrefix: c.Query("path"),
+		MinScore:   fiber.Query[float64](c, "minScore", 0),
+	}
+	if filter.MinScore < 0 || filter.MinScore > 1 {
+		return filter, fmt.Errorf("minScore must be between 0 and 1")
+	}
+	if filter.Language != "" && len(db.LanguageExtensions(filter.Language)) == 0 {
+		return filter, fmt.Errorf("unknown lang %q", filter.Language)
+	}
+	if types := c.Query("type"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			switch t = strings.ToLower(strings.TrimSpace(t)); t {
+			case "function", "method", "class":
+				filter.Types = append(filter.Types, strings.ToUpper(t[:1])+t[1:])
+			default:
+				return filter, fmt.Errorf("invalid type %q, must be function, method or class", t)
+			}
+		}
+	}
+	return filter, nil
+}
+
+// readSnippet reads an entity's lines from the cloned repository. Returns an
+// empty string if the checkout or file is unavailable.
+func (h *Handler) readSnippet(entity db.EntitySummary) string {
+	snippet, _ := h.readLines(entity.RepoURL, entity.FilePath, entity.StartLine, entity.EndLine, maxSnippetLines)
+	return snippet
+}
+
+// readLines reads lines start to end of a file in the cloned repository, at
+// most maxLines of them, reporting whether the range was cut. Returns an
+// empty string if the checkout or file is unavailable.
+func (h *Handler) readLines(repoURL, path string, start, end, maxLines int) (string, bool) {
+	if repoURL == "" || start < 1 {
+		return "", false
+	}
+
+	repoPath := h.gitSvc.GetRepoPath(git.ExtractRepoName(repoURL))
+	filePath := filepath.Join(repoPath, filepath.FromSlash(path))
+	if !strings.HasPrefix(filePath, repoPath+string(filepath.Separator)) {
+		return "", false
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	truncated := false
+	if end < start {
+		end = start + maxLines - 1
+	} else if end-start >= maxLines {
+		end = start + maxLines - 1
+		truncated = true
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan() && n <= end; n++ {
+		if n >= start {
+			lines = append(lines, scanner.Text())
+		}
+	}
+	return strings.Join(lines, "\n"), truncated
+}
+
+// sameRepository reports whether all entities belong to one repository
+func sameRepository(entities []db.EntitySummary) bool {
+	for _, entity := range entities[1:] {
+		if entity.RepoID != entities[0].RepoID {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"log"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/dpolishuk/neograph/backend/internal/git"
+	"github.com/dpolishuk/neograph/backend/internal/metrics"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/dpolishuk/neograph/backend/internal/summary"
+	"github.com/gofiber/fiber/v3"
+)
+
+// GetRepositoryStats returns the numbers behind a repository's dashboard:
+// languages, lines of code, entity counts, function length, the largest
+// packages and how long indexing took
+func (h *Handler) GetRepositoryStats(c fiber.Ctx) error {
+	id := c.Params("id")
+
+	repo, err := db.GetRepository(c.Context(), h.dbClient, id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if repo == nil {
+		return notFound(c, "repository not found")
+	}
+
+	stats, err := h.graphReader.GetRepositoryStats(c.Context(), id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(stats)
+}
+
+// GetRepositorySummary returns a short summary of a repository, generated
+// from its README, graph stats and entry points. The summary is cached on the
+// repository until the indexed commit changes; ?refresh=true regenerates it.
+func (h *Handler) GetRepositorySummary(c fiber.Ctx) error {
+	id := c.Params("id")
+
+	repo, err := db.GetRepository(c.Context(), h.dbClient, id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if repo == nil {
+		return notFound(c, "repository not found")
+	}
+
+	if !fiber.Query[bool](c, "refresh", false) {
+		cached, err := db.GetRepositorySummary(c.Context(), h.dbClient, id)
+		if err != nil {
+			return serverError(c, err)
+		}
+		if cached != nil && cached.Commit == repo.Commit {
+			return c.JSON(cached)
+		}
+	}
+
+	stats, err := h.graphReader.GetRepositoryStats(c.Context(), id)
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	input := summary.Input{
+		RepoName: repo.Name,
+		Readme:   summary.ReadReadme(h.gitSvc.GetRepoPath(git.ExtractRepoName(repo.URL))),
+		Stats:    stats,
+	}
+
+	result := &models.RepositorySummary{
+		Commit:      repo.Commit,
+		GeneratedAt: time.Now().UTC(),
+	}
+
+	// Fall back to a summary built from the README and stats when the agent
+	// service is unavailable
+	response, err := h.agentProxy.Chat(c.Context(), summary.Prompt(input), &repo.ID, "doc_writer")
+	if err != nil || response.Response == "" {
+		if err != nil {
+			metrics.AgentErrors.Inc(repo.ID)
+			log.Printf("Summary generation for %s fell back: %v", repo.Name, err)
+		}
+		result.Summary = summary.Fallback(input)
+		result.Source = "fallback"
+	} else {
+		result.Summary = response.Response
+		result.Source = "agent"
+	}
+
+	if err := db.SaveRepositorySummary(c.Context(), h.dbClient, id, result); err != nil {
+		return serverError(c, err)
+	}
+	return c.JSON(result)
+}
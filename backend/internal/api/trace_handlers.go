@@ -0,0 +1,76 @@
+package api
+
+import (
+	"sort"
+
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/dpolishuk/neograph/backend/internal/traces"
+	"github.com/gofiber/fiber/v3"
+)
+
+// maxUnmatchedFrames caps the unmatched functions listed in an upload response
+const maxUnmatchedFrames = 20
+
+// TraceUploadResult reports how an uploaded profile or trace mapped onto the
+// graph
+type TraceUploadResult struct {
+	Format       string   `json:"format"`
+	Observations int      `json:"observations"`
+	Matched      int      `json:"matched"`   // entities annotated
+	Unmatched    int      `json:"unmatched"` // observed functions without an entity
+	TopUnmatched []string `json:"topUnmatched"`
+}
+
+// UploadTraces annotates a repository's entities with the call counts and
+// time observed in a pprof profile or OTLP/JSON trace sent as the request
+// body. ?format=pprof|otlp overrides detection. Each upload replaces the
+// previous annotations.
+func (h *Handler) UploadTraces(c fiber.Ctx) error {
+	id := c.Params("id")
+
+	repo, err := db.GetRepository(c.Context(), h.dbClient, id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	if repo == nil {
+		return notFound(c, "repository not found")
+	}
+
+	body := c.Body()
+	if len(body) == 0 {
+		return badRequest(c, "request body must hold a profile or trace")
+	}
+	observations, format, err := traces.Parse(body, c.Query("format"))
+	if err != nil {
+		return badRequest(c, err.Error())
+	}
+
+	entities, err := h.graphReader.GetEntityLocations(c.Context(), id)
+	if err != nil {
+		return serverError(c, err)
+	}
+	stats, unmatched := traces.Match(observations, entities)
+
+	if err := h.writer.WriteRuntimeStats(c.Context(), id, format, stats); err != nil {
+		return serverError(c, err)
+	}
+	h.cache.Invalidate(id)
+
+	// The busiest functions that could not be mapped hint at what is missing
+	sort.SliceStable(unmatched, func(i, j int) bool { return unmatched[i].Count > unmatched[j].Count })
+	top := []string{}
+	for _, obs := range unmatched {
+		if len(top) == maxUnmatchedFrames {
+			break
+		}
+		top = append(top, obs.Function)
+	}
+
+	return c.JSON(TraceUploadResult{
+		Format:       format,
+		Observations: len(observations),
+		Matched:      len(stats),
+		Unmatched:    len(unmatched),
+		TopUnmatched: top,
+	})
+}
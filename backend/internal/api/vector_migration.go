@@ -0,0 +1,249 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/dpolishuk/neograph/backend/internal/config"
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/dpolishuk/neograph/backend/internal/embedding"
+	"github.com/dpolishuk/neograph/backend/internal/indexer"
+	"github.com/dpolishuk/neograph/backend/internal/jobs"
+	"github.com/dpolishuk/neograph/backend/internal/metrics"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/gofiber/fiber/v3"
+)
+
+// reembedBatchSize is how many entities are re-embedded per request to the
+// embedding service
+const reembedBatchSize = 32
+
+// dimensionProbe is embedded to learn the dimension of the model's vectors
+const dimensionProbe = "func main()"
+
+// StartVectorMigration loads the vector spaces and, when the configured
+// embedding model or dimension changed, queues re-embedding every entity into
+// a new vector index. Search switches to it once the job completes and the
+// old index and vectors are dropped.
+func (h *Handler) StartVectorMigration(ctx context.Context) error {
+	dimension, err := h.embeddingDimension(ctx)
+	if err != nil {
+		log.Printf("Keeping the searched vector space: %v", err)
+	}
+	configured := db.NewVectorSpace(h.embeddingModel(), dimension)
+	building, err := h.dbClient.LoadVectorSpaces(ctx, configured)
+	if err != nil {
+		return err
+	}
+	if building == nil {
+		return nil
+	}
+
+	active, _ := h.dbClient.VectorSpaces()
+	log.Printf("Migrating embeddings from %s to %s", active.Index, building.Index)
+	h.jobs.Enqueue(jobs.KindEmbeddings, "", building.Model, func(ctx context.Context) error {
+		return h.migrateVectorSpace(ctx, active, *building)
+	})
+	return nil
+}
+
+// reduceEmbeddings wraps embedder to reduce its vectors as configured by
+// EMBEDDING_REDUCTION, see embedding.Reduced
+func reduceEmbeddings(cfg *config.Config, embedder embedding.Embedder) (embedding.Embedder, error) {
+	if cfg.EmbeddingReduction == "" {
+		return embedder, nil
+	}
+	return embedding.NewReduced(embedder, cfg.EmbeddingReduction, cfg.EmbeddingReducedDimension)
+}
+
+// embeddingModel names the vectors stored: the embedding model's, or its
+// reduced vectors
+func (h *Handler) embeddingModel() string {
+	if reduced, ok := h.embedder.(*embedding.Reduced); ok {
+		return reduced.Model(h.cfg.EmbeddingModel)
+	}
+	return h.cfg.EmbeddingModel
+}
+
+// embeddingDimension returns the dimension of the vectors stored, see
+// modelDimension, after any reduction
+func (h *Handler) embeddingDimension(ctx context.Context) (int, error) {
+	dimension, err := h.modelDimension(ctx)
+	if reduced, ok := h.embedder.(*embedding.Reduced); ok && err == nil {
+		return reduced.Dimension(dimension)
+	}
+	return dimension, err
+}
+
+// modelDimension returns the dimension of the embedding model's vectors,
+// detected from an embedding and checked against EMBEDDING_DIMENSION when
+// that is set. When the service cannot be reached the configured dimension
+// is trusted; 0 is returned when there is none or it is wrong.
+func (h *Handler) modelDimension(ctx context.Context) (int, error) {
+	configured := h.cfg.EmbeddingDimension
+	model := h.embedder
+	if reduced, ok := model.(*embedding.Reduced); ok {
+		model = reduced.Unwrap()
+	}
+	embeddings, err := model.Embed(ctx, []string{dimensionProbe})
+	if err == nil && len(embeddings) == 0 {
+		err = fmt.Errorf("no embedding generated")
+	}
+	if err != nil {
+		if configured > 0 {
+			return configured, nil
+		}
+		return 0, fmt.Errorf("cannot detect the embedding dimension, set EMBEDDING_DIMENSION: %w", err)
+	}
+
+	detected := len(embeddings[0])
+	if configured > 0 && configured != detected {
+		return 0, fmt.Errorf("%w: EMBEDDING_DIMENSION is %d but %s returns %d dimensions",
+			db.ErrDimensionMismatch, configured, h.cfg.EmbeddingModel, detected)
+	}
+	if configured == 0 {
+		log.Printf("Detected %d-dimensional embeddings from %s", detected, h.cfg.EmbeddingModel)
+	}
+	return detected, nil
+}
+
+// migrateVectorSpace re-embeds every entity with a vector in from into to,
+// then switches search over and drops from
+func (h *Handler) migrateVectorSpace(ctx context.Context, from, to db.VectorSpace) error {
+	repos, err := db.ListRepositories(ctx, h.dbClient)
+	if err != nil {
+		return err
+	}
+
+	for _, repo := range repos {
+		if err := h.reembedRepository(ctx, repo, from, to); err != nil {
+			return err
+		}
+	}
+
+	retired, err := h.dbClient.CompleteVectorMigration(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("Search switched to %s", to.Index)
+
+	// A failed cleanup is retried on the next start
+	if err := h.dbClient.DropVectorSpace(ctx, retired); err != nil {
+		log.Printf("Failed to drop %s: %v", retired.Index, err)
+	}
+	return nil
+}
+
+// reembedRepository re-embeds a repository's entities, docstrings and wiki
+// pages with a vector in from into to
+func (h *Handler) reembedRepository(ctx context.Context, repo *models.Repository, from, to db.VectorSpace) error {
+	entities, err := h.reembed(ctx, repo, func() ([]string, []string, error) {
+		batch, err := h.graphReader.PendingEmbeddings(ctx, repo.ID, from, to, reembedBatchSize)
+		ids, texts := make([]string, len(batch)), make([]string, len(batch))
+		for i, entity := range batch {
+			ids[i], texts[i] = entity.ID, indexer.EmbeddingText(entity)
+		}
+		return ids, texts, err
+	}, func(ids []string, vectors [][]float32) error {
+		return h.writer.SetEmbeddings(ctx, repo.ID, to, ids, vectors)
+	})
+	if err != nil {
+		return err
+	}
+
+	docstrings, err := h.reembed(ctx, repo, func() ([]string, []string, error) {
+		batch, err := h.graphReader.PendingDocEmbeddings(ctx, repo.ID, from, to, reembedBatchSize)
+		ids, texts := make([]string, len(batch)), make([]string, len(batch))
+		for i, entity := range batch {
+			ids[i], texts[i] = entity.ID, entity.Docstring
+		}
+		return ids, texts, err
+	}, func(ids []string, vectors [][]float32) error {
+		return h.writer.SetDocEmbeddings(ctx, repo.ID, to, ids, vectors)
+	})
+	if err != nil {
+		return err
+	}
+
+	pages, err := h.reembed(ctx, repo, func() ([]string, []string, error) {
+		batch, err := h.wikiReader.PendingPageEmbeddings(ctx, repo.ID, from, to, reembedBatchSize)
+		slugs, texts := make([]string, len(batch)), make([]string, len(batch))
+		for i, page := range batch {
+			slugs[i], texts[i] = page.Slug, indexer.WikiEmbeddingText(page)
+		}
+		return slugs, texts, err
+	}, func(slugs []string, vectors [][]float32) error {
+		return h.wikiWriter.SetPageEmbeddings(ctx, repo.ID, to, slugs, vectors)
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Re-embedded %d entities, %d docstrings and %d wiki pages of %s into %s",
+		entities, docstrings, pages, repo.Name, to.Index)
+	return nil
+}
+
+// reembed embeds the texts pending returns, a batch at a time until none
+// are left, and stores the vectors of the ids they belong to with store.
+// Returns how many were embedded.
+func (h *Handler) reembed(ctx context.Context, repo *models.Repository, pending func() ([]string, []string, error), store func([]string, [][]float32) error) (int, error) {
+	embedded := 0
+	for {
+		ids, texts, err := pending()
+		if err != nil {
+			return embedded, err
+		}
+		if len(ids) == 0 {
+			return embedded, nil
+		}
+
+		vectors, err := h.embedder.Embed(ctx, texts)
+		if err != nil {
+			metrics.TEIErrors.Inc(repo.ID)
+			return embedded, fmt.Errorf("failed to re-embed %s: %w", repo.Name, err)
+		}
+		if err := store(ids, vectors); err != nil {
+			return embedded, fmt.Errorf("failed to store embeddings of %s: %w", repo.Name, err)
+		}
+		embedded += len(ids)
+	}
+}
+
+// searchSpace returns the vector space searches run against with the
+// embedding service producing its vectors. During a migration that is the
+// old space as long as its model is still served; otherwise the new one,
+// covering what has been re-embedded so far.
+func (h *Handler) searchSpace() (embedding.Embedder, db.VectorSpace) {
+	active, building := h.dbClient.VectorSpaces()
+	if building == nil {
+		return h.embedder, active
+	}
+	if h.teiPrevious != nil {
+		return h.teiPrevious, active
+	}
+	return h.embedder, *building
+}
+
+// GetVectorSpaces reports the searched vector space and the one a migration
+// is building, with how many entities have vectors in each
+func (h *Handler) GetVectorSpaces(c fiber.Ctx) error {
+	active, building := h.dbClient.VectorSpaces()
+	activeCount, err := h.dbClient.CountEmbeddings(c.Context(), active)
+	if err != nil {
+		return serverError(c, err)
+	}
+
+	response := fiber.Map{
+		"active":   fiber.Map{"space": active, "embedded": activeCount},
+		"building": nil,
+	}
+	if building != nil {
+		buildingCount, err := h.dbClient.CountEmbeddings(c.Context(), *building)
+		if err != nil {
+			return serverError(c, err)
+		}
+		response["building"] = fiber.Map{"space": building, "embedded": buildingCount}
+	}
+	return c.JSON(response)
+}
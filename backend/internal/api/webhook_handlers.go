@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/dpolishuk/neograph/backend/internal/github"
+	"github.com/dpolishuk/neograph/backend/internal/jobs"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/gofiber/fiber/v3"
+)
+
+// GitHubWebhook receives the deliveries of a GitHub webhook, authenticated
+// by their HMAC signature rather than an API key. A push to the branch a
+// registered repository indexes queues reindexing the files it changed,
+// which pulls the new commits; pushes of commits already indexed, other
+// branches and tags are ignored, as are other events.
+func (h *Handler) GitHubWebhook(c fiber.Ctx) error {
+	if h.cfg.GitHubWebhookSecret == "" {
+		return statusError(c, 503, "GitHub webhooks are not configured")
+	}
+	body := c.Body()
+	if !github.VerifySignature(h.cfg.GitHubWebhookSecret, body, c.Get(github.SignatureHeader)) {
+		return statusError(c, 401, "invalid signature")
+	}
+
+	switch c.Get(github.EventHeader) {
+	case "ping":
+		return c.JSON(fiber.Map{"status": "pong"})
+	case "push":
+	default:
+		return c.JSON(fiber.Map{"status": "ignored"})
+	}
+
+	var event github.PushEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return badRequest(c, "invalid push event")
+	}
+	branch := event.Branch()
+	if branch == "" || event.Deleted {
+		return c.JSON(fiber.Map{"status": "ignored"})
+	}
+
+	repos, err := db.ListRepositories(c.Context(), h.dbClient)
+	if err != nil {
+		return serverError(c, err)
+	}
+	pushed := make(map[string]bool)
+	for _, url := range event.URLs() {
+		pushed[db.RepositoryURLKey(url)] = true
+	}
+
+	queued := []string{}
+	for _, repo := range repos {
+		if !pushed[db.RepositoryURLKey(repo.URL)] || repo.DefaultBranch != branch {
+			continue
+		}
+		if repo.Commit != "" && repo.Commit == event.After {
+			continue
+		}
+		if h.indexQueued(repo.ID) {
+			// The queued index will pull this push too
+			continue
+		}
+		log.Printf("Push of %s to %s (delivery %s), reindexing", event.After, repo.Name, c.Get(github.DeliveryHeader))
+		h.enqueuePushReindex(repo, &event)
+		queued = append(queued, repo.ID)
+	}
+
+	if len(queued) == 0 {
+		return c.JSON(fiber.Map{"status": "ignored"})
+	}
+	return c.Status(202).JSON(fiber.Map{"status": "queued", "repositories": queued})
+}
+
+// indexQueued reports whether an index job of a repository waits to run
+func (h *Handler) indexQueued(repoID string) bool {
+	for _, job := range h.jobs.Jobs() {
+		if job.Kind == jobs.KindIndex && job.RepoID == repoID && job.State == jobs.StateQueued {
+			return true
+		}
+	}
+	return false
+}
+
+// enqueuePushReindex queues reindexing a repository pushed to, then treats
+// its wiki as a scheduled reindex does: regenerated if it refreshes
+// automatically, otherwise marked stale. Only the files changed since the
+// commit the push built on are indexed again, when that is the indexed
+// commit; a force push, which may drop it, reindexes everything.
+func (h *Handler) enqueuePushReindex(repo *models.Repository, event *github.PushEvent) {
+	since := event.Before
+	if event.Forced {
+		since = ""
+	}
+	h.jobs.Enqueue(jobs.KindIndex, repo.ID, repo.Name, func(ctx context.Context) error {
+		if err := h.reindex(ctx, repo, since); err != nil {
+			return err
+		}
+		if repo.WikiAutoRefresh {
+			h.enqueueWiki(repo)
+			return nil
+		}
+		return (&schedulerRunner{h: h}).MarkWikiStale(ctx, repo)
+	})
+}
@@ -0,0 +1,276 @@
+// Package archive packs a repository's index into a portable tar.gz
+// archive: metadata describing the repository, its code graph as entity and
+// relationship records and its wiki pages, one JSON record per line. Another
+// NeoGraph instance can import it without access to the repository, which
+// suits air-gapped environments and backups.
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// Format identifies the archive layout so importers can detect changes
+const Format = "neograph-archive/1"
+
+// Files of an archive, in the order they are packed
+const (
+	MetadataFile      = "metadata.json"
+	EntitiesFile      = "entities.jsonl"
+	RelationshipsFile = "relationships.jsonl"
+	WikiFile          = "wiki.jsonl"
+)
+
+// ErrInvalidArchive is returned for input that is not an archive this
+// version can read
+var ErrInvalidArchive = errors.New("invalid archive")
+
+// recordFiles are the files holding one record per line
+var recordFiles = []string{EntitiesFile, RelationshipsFile, WikiFile}
+
+// Metadata describes an archive and the repository it was exported from
+type Metadata struct {
+	Format     string            `json:"format"`
+	ExportedAt time.Time         `json:"exportedAt"`
+	Repository models.Repository `json:"repository"`
+	// version of the snapshot records of the entity, relationship and wiki
+	// files, see db.SnapshotVersion
+	SnapshotVersion int `json:"snapshotVersion"`
+	// embeddings the entities and wiki pages carry, if any
+	Embeddings *Embeddings `json:"embeddings,omitempty"`
+	// number of records per file
+	Records map[string]int `json:"records"`
+}
+
+// Embeddings names the model whose vectors an archive carries and the
+// property holding them. They can only be searched on an instance
+// embedding with the same model and dimension.
+type Embeddings struct {
+	Model     string `json:"model"`
+	Dimension int    `json:"dimension"`
+	Property  string `json:"property"`
+}
+
+// Writer collects the records of an archive. Tar headers need each file's
+// size up front, so records are spooled to temporary files until the
+// archive is written. Close removes them.
+type Writer struct {
+	dir   string
+	files map[string]*spool
+}
+
+type spool struct {
+	file    *os.File
+	buf     *bufio.Writer
+	enc     *json.Encoder
+	records int
+}
+
+// NewWriter creates a Writer spooling to a new temporary directory
+func NewWriter() (*Writer, error) {
+	dir, err := os.MkdirTemp("", "neograph-archive-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive spool: %w", err)
+	}
+	return &Writer{dir: dir, files: make(map[string]*spool)}, nil
+}
+
+// Add appends a record to one of the record files
+func (w *Writer) Add(name string, record any) error {
+	s, ok := w.files[name]
+	if !ok {
+		if !isRecordFile(name) {
+			return fmt.Errorf("unknown archive file %s", name)
+		}
+		file, err := os.Create(filepath.Join(w.dir, name))
+		if err != nil {
+			return fmt.Errorf("failed to create archive spool: %w", err)
+		}
+		buf := bufio.NewWriter(file)
+		s = &spool{file: file, buf: buf, enc: json.NewEncoder(buf)}
+		w.files[name] = s
+	}
+	if err := s.enc.Encode(record); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	s.records++
+	return nil
+}
+
+// Records returns how many records were added to each file
+func (w *Writer) Records() map[string]int {
+	counts := make(map[string]int, len(recordFiles))
+	for _, name := range recordFiles {
+		counts[name] = 0
+		if s, ok := w.files[name]; ok {
+			counts[name] = s.records
+		}
+	}
+	return counts
+}
+
+// WriteTo writes the archive: the metadata, with its format and record
+// counts filled in, then every record file, empty ones included
+func (w *Writer) WriteTo(out io.Writer, meta Metadata) error {
+	meta.Format = Format
+	meta.Records = w.Records()
+	metadata, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	modTime := meta.ExportedAt
+	if modTime.IsZero() {
+		modTime = time.Now()
+	}
+	header := func(name string, size int64) *tar.Header {
+		return &tar.Header{Name: name, Mode: 0o644, Size: size, ModTime: modTime, Typeflag: tar.TypeReg}
+	}
+
+	if err := tw.WriteHeader(header(MetadataFile, int64(len(metadata)))); err != nil {
+		return err
+	}
+	if _, err := tw.Write(metadata); err != nil {
+		return err
+	}
+	for _, name := range recordFiles {
+		if err := w.pack(tw, name, header); err != nil {
+			return fmt.Errorf("failed to pack %s: %w", name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// pack copies a spooled record file into the archive
+func (w *Writer) pack(tw *tar.Writer, name string, header func(string, int64) *tar.Header) error {
+	s, ok := w.files[name]
+	if !ok {
+		return tw.WriteHeader(header(name, 0))
+	}
+	if err := s.buf.Flush(); err != nil {
+		return err
+	}
+	info, err := s.file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(header(name, info.Size())); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, s.file)
+	return err
+}
+
+// Close removes the spooled records
+func (w *Writer) Close() error {
+	for _, s := range w.files {
+		s.file.Close()
+	}
+	return os.RemoveAll(w.dir)
+}
+
+// Reader reads an archive as WriteTo packed it. Files come one after
+// another from the compressed stream, so record files must be read in
+// their packing order.
+type Reader struct {
+	Metadata Metadata
+	tr       *tar.Reader
+}
+
+// NewReader reads and checks the metadata of an archive
+func NewReader(r io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	ar := &Reader{tr: tar.NewReader(gz)}
+	metadata, err := ar.next(MetadataFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.NewDecoder(metadata).Decode(&ar.Metadata); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, MetadataFile, err)
+	}
+	if ar.Metadata.Format != Format {
+		return nil, fmt.Errorf("%w: unsupported format %q, expected %q", ErrInvalidArchive, ar.Metadata.Format, Format)
+	}
+	return ar, nil
+}
+
+// Records returns the named record files read one after the other. They
+// are opened as the previous one is read to its end.
+func (r *Reader) Records(names ...string) io.Reader {
+	return &records{r: r, names: names}
+}
+
+// next moves to the next file of the archive, which must be name
+func (r *Reader) next(name string) (io.Reader, error) {
+	header, err := r.tr.Next()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidArchive, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if header.Name != name {
+		return nil, fmt.Errorf("%w: found %s where %s was expected", ErrInvalidArchive, header.Name, name)
+	}
+	return r.tr, nil
+}
+
+// records reads record files in turn, see Reader.Records
+type records struct {
+	r     *Reader
+	names []string
+	file  io.Reader
+}
+
+func (rs *records) Read(p []byte) (int, error) {
+	for {
+		if rs.file == nil {
+			if len(rs.names) == 0 {
+				return 0, io.EOF
+			}
+			file, err := rs.r.next(rs.names[0])
+			if err != nil {
+				return 0, err
+			}
+			rs.file, rs.names = file, rs.names[1:]
+		}
+		n, err := rs.file.Read(p)
+		if errors.Is(err, io.EOF) {
+			rs.file = nil
+			err = nil
+		}
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+func isRecordFile(name string) bool {
+	for _, f := range recordFiles {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// readArchive returns the files of a tar.gz archive in order, by name
+func readArchive(t *testing.T, data []byte) ([]string, map[string]string) {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("not gzipped: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	files := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return names, files
+		}
+		if err != nil {
+			t.Fatalf("invalid tar: %v", err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+		files[header.Name] = string(content)
+	}
+}
+
+// TestWriter tests packing spooled records with their metadata
+func TestWriter(t *testing.T) {
+	w, err := NewWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range []map[string]string{{"key": "a"}, {"key": "b"}} {
+		if err := w.Add(EntitiesFile, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Add(WikiFile, map[string]string{"slug": "overview"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Add("notes.txt", "x"); err == nil {
+		t.Error("expected an unknown file to be refused")
+	}
+
+	exported := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	var out bytes.Buffer
+	err = w.WriteTo(&out, Metadata{
+		ExportedAt:      exported,
+		Repository:      models.Repository{ID: "repo-1", Name: "app"},
+		SnapshotVersion: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names, files := readArchive(t, out.Bytes())
+	want := []string{MetadataFile, EntitiesFile, RelationshipsFile, WikiFile}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("files = %v, want %v", names, want)
+	}
+	if files[EntitiesFile] != "{\"key\":\"a\"}\n{\"key\":\"b\"}\n" {
+		t.Errorf("unexpected entities %q", files[EntitiesFile])
+	}
+	if files[RelationshipsFile] != "" {
+		t.Errorf("expected no relationships, got %q", files[RelationshipsFile])
+	}
+
+	var meta Metadata
+	if err := json.Unmarshal([]byte(files[MetadataFile]), &meta); err != nil {
+		t.Fatal(err)
+	}
+	if meta.Format != Format || meta.Repository.Name != "app" || !meta.ExportedAt.Equal(exported) {
+		t.Errorf("unexpected metadata %+v", meta)
+	}
+	if meta.Records[EntitiesFile] != 2 || meta.Records[RelationshipsFile] != 0 || meta.Records[WikiFile] != 1 {
+		t.Errorf("unexpected record counts %v", meta.Records)
+	}
+
+	dir := w.dir
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected the spool to be removed, got %v", err)
+	}
+}
+
+// TestReader tests reading back a packed archive and refusing other input
+func TestReader(t *testing.T) {
+	w, err := NewWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	for _, add := range []struct {
+		file string
+		key  string
+	}{{EntitiesFile, "a"}, {EntitiesFile, "b"}, {RelationshipsFile, "ab"}, {WikiFile, "overview"}} {
+		if err := w.Add(add.file, map[string]string{"key": add.key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var packed bytes.Buffer
+	if err := w.WriteTo(&packed, Metadata{Repository: models.Repository{Name: "app"}, SnapshotVersion: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(bytes.NewReader(packed.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Metadata.Repository.Name != "app" || r.Metadata.Records[EntitiesFile] != 2 {
+		t.Errorf("unexpected metadata %+v", r.Metadata)
+	}
+	graph, err := io.ReadAll(r.Records(EntitiesFile, RelationshipsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(graph) != "{\"key\":\"a\"}\n{\"key\":\"b\"}\n{\"key\":\"ab\"}\n" {
+		t.Errorf("unexpected graph records %q", graph)
+	}
+	wiki, err := io.ReadAll(r.Records(WikiFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(wiki) != "{\"key\":\"overview\"}\n" {
+		t.Errorf("unexpected wiki records %q", wiki)
+	}
+
+	skipped, err := NewReader(bytes.NewReader(packed.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(skipped.Records(WikiFile)); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("expected reading out of order to fail, got %v", err)
+	}
+
+	if _, err := NewReader(strings.NewReader("{\"kind\":\"snapshot\"}\n")); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("expected a snapshot to be refused, got %v", err)
+	}
+	var other bytes.Buffer
+	gz := gzip.NewWriter(&other)
+	tw := tar.NewWriter(gz)
+	metadata := []byte(`{"format":"neograph-archive/99"}`)
+	tw.WriteHeader(&tar.Header{Name: MetadataFile, Mode: 0o644, Size: int64(len(metadata)), Typeflag: tar.TypeReg})
+	tw.Write(metadata)
+	tw.Close()
+	gz.Close()
+	if _, err := NewReader(&other); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("expected an unknown format to be refused, got %v", err)
+	}
+}
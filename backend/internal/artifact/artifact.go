@@ -0,0 +1,334 @@
+// Package artifact builds the machine-readable export of an index run: an
+// SPDX 2.3 style SBOM of the repository's declared dependencies next to the
+// graph of its files, entities and relationships, for compliance tooling.
+package artifact
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/dpolishuk/neograph/backend/internal/db"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// Format identifies the artifact layout so consumers can detect changes
+const Format = "neograph-artifact/1"
+
+// Document is the artifact of one index run
+type Document struct {
+	Format      string       `json:"format"`
+	Repository  Repository   `json:"repository"`
+	SBOM        SBOM         `json:"sbom"`
+	Graph       db.GraphData `json:"graph"`
+	GeneratedAt time.Time    `json:"generatedAt"`
+}
+
+// Repository identifies the indexed repository and run
+type Repository struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Branch  string `json:"branch,omitempty"`
+	Commit  string `json:"commit,omitempty"`
+	RunID   string `json:"runId"`
+	License string `json:"license"`
+}
+
+// SBOM is an SPDX 2.3 JSON document describing the repository as a package
+// and its declared dependencies
+type SBOM struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      SPDXCreationInfo   `json:"creationInfo"`
+	Packages          []SPDXPackage      `json:"packages"`
+	Relationships     []SPDXRelationship `json:"relationships"`
+}
+
+type SPDXCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type SPDXPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	Comment          string            `json:"comment,omitempty"`
+	ExternalRefs     []SPDXExternalRef `json:"externalRefs,omitempty"`
+}
+
+type SPDXExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type SPDXRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// repositorySPDXID is the SPDX element of the indexed repository itself
+const repositorySPDXID = "SPDXRef-Repository"
+
+// spdxIDInvalid matches characters SPDX identifiers may not contain
+var spdxIDInvalid = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// purlTypes maps dependency ecosystems to package URL types
+var purlTypes = map[string]string{
+	"go":   "golang",
+	"npm":  "npm",
+	"pypi": "pypi",
+}
+
+// Input is what an artifact is built from. Result must have been written, so
+// that files and entities carry the IDs they have in the graph.
+type Input struct {
+	Repo         *models.Repository
+	Run          *models.IndexRun
+	Result       *models.IndexResult
+	Dependencies []Dependency
+	License      string
+}
+
+// Build assembles the artifact of an index run
+func Build(in Input) *Document {
+	now := time.Now().UTC()
+	license := in.License
+	if license == "" {
+		license = NoAssertion
+	}
+
+	return &Document{
+		Format: Format,
+		Repository: Repository{
+			ID:      in.Repo.ID,
+			Name:    in.Repo.Name,
+			URL:     in.Repo.URL,
+			Branch:  in.Repo.DefaultBranch,
+			Commit:  in.Run.Commit,
+			RunID:   in.Run.ID,
+			License: license,
+		},
+		SBOM:        buildSBOM(in, license, now),
+		Graph:       buildGraph(in.Repo, in.Result),
+		GeneratedAt: now,
+	}
+}
+
+// buildSBOM describes the repository and its dependencies. Dependency
+// licenses are NOASSERTION: manifests do not declare them.
+func buildSBOM(in Input, license string, now time.Time) SBOM {
+	root := SPDXPackage{
+		SPDXID:           repositorySPDXID,
+		Name:             in.Repo.Name,
+		VersionInfo:      in.Run.Commit,
+		DownloadLocation: downloadLocation(in.Repo.URL),
+		LicenseConcluded: NoAssertion,
+		LicenseDeclared:  license,
+		CopyrightText:    NoAssertion,
+	}
+
+	sbom := SBOM{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              in.Repo.Name,
+		DocumentNamespace: fmt.Sprintf("https://neograph.local/spdx/%s/%s", in.Repo.ID, in.Run.ID),
+		CreationInfo: SPDXCreationInfo{
+			Created:  now.Format(time.RFC3339),
+			Creators: []string{"Tool: neograph"},
+		},
+		Packages: []SPDXPackage{root},
+		Relationships: []SPDXRelationship{
+			{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: repositorySPDXID},
+		},
+	}
+
+	for i, dep := range in.Dependencies {
+		id := fmt.Sprintf("SPDXRef-Package-%s-%s-%d", dep.Ecosystem, spdxIDInvalid.ReplaceAllString(dep.Name, "-"), i+1)
+		pkg := SPDXPackage{
+			SPDXID:           id,
+			Name:             dep.Name,
+			VersionInfo:      dep.Version,
+			DownloadLocation: NoAssertion,
+			LicenseConcluded: NoAssertion,
+			LicenseDeclared:  NoAssertion,
+			CopyrightText:    NoAssertion,
+			Comment:          fmt.Sprintf("%s dependency declared in %s", dep.Scope, dep.Manifest),
+		}
+		if purl := PackageURL(dep); purl != "" {
+			pkg.ExternalRefs = []SPDXExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  purl,
+			}}
+		}
+		sbom.Packages = append(sbom.Packages, pkg)
+
+		rel := SPDXRelationship{SPDXElementID: repositorySPDXID, RelationshipType: "DEPENDS_ON", RelatedSPDXElement: id}
+		if dep.Scope == "dev" {
+			rel = SPDXRelationship{SPDXElementID: id, RelationshipType: "DEV_DEPENDENCY_OF", RelatedSPDXElement: repositorySPDXID}
+		}
+		sbom.Relationships = append(sbom.Relationships, rel)
+	}
+	return sbom
+}
+
+// downloadLocation returns the clone URL without credentials, or
+// NOASSERTION for local paths
+func downloadLocation(repoURL string) string {
+	u, err := url.Parse(repoURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return NoAssertion
+	}
+	u.User = nil
+	return "git+" + u.String()
+}
+
+// PackageURL returns the purl of a dependency. The version is only included
+// when it names a single version rather than a range.
+func PackageURL(dep Dependency) string {
+	purlType, ok := purlTypes[dep.Ecosystem]
+	if !ok {
+		return ""
+	}
+
+	name := dep.Name
+	switch dep.Ecosystem {
+	case "npm":
+		// The scope of @scope/name is a purl namespace with an encoded @
+		name = strings.Replace(name, "@", "%40", 1)
+	case "pypi":
+		name = strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+	}
+
+	purl := "pkg:" + purlType + "/" + name
+	if version := exactVersion(dep); version != "" {
+		purl += "@" + version
+	}
+	return purl
+}
+
+// exactVersion returns the declared version when it pins a single version
+func exactVersion(dep Dependency) string {
+	version := dep.Version
+	if dep.Ecosystem == "pypi" {
+		pinned, ok := strings.CutPrefix(version, "==")
+		if !ok {
+			return ""
+		}
+		version = strings.TrimSpace(pinned)
+	}
+	if version == "" || strings.ContainsAny(version, "^~<>=*|, ") || strings.EqualFold(version, "latest") {
+		return ""
+	}
+	return version
+}
+
+// buildGraph exports the repository's files and entities with the
+// relationships the graph stores between them. Imports are listed on files
+// rather than linked, as most point outside the repository.
+func buildGraph(repo *models.Repository, result *models.IndexResult) db.GraphData {
+	graph := db.GraphData{Nodes: []db.GraphNode{}, Edges: []db.GraphEdge{}}
+	graph.Nodes = append(graph.Nodes, db.GraphNode{
+		ID:    repo.ID,
+		Label: repo.Name,
+		Type:  "Repository",
+		Props: map[string]any{"url": repo.URL},
+	})
+
+	fileIDs := make(map[string]string)
+	for _, file := range result.Files {
+		fileIDs[file.Path] = file.ID
+		imports := make([]string, 0, len(file.Imports))
+		for _, imp := range file.Imports {
+			imports = append(imports, imp.ImportPath)
+		}
+		graph.Nodes = append(graph.Nodes, db.GraphNode{
+			ID:    file.ID,
+			Label: file.Path,
+			Type:  "File",
+			Props: map[string]any{
+				"path":     file.Path,
+				"language": file.Language,
+				"hash":     file.Hash,
+				"size":     file.Size,
+				"lines":    file.Lines,
+				"imports":  imports,
+			},
+		})
+		graph.Edges = append(graph.Edges, edge(repo.ID, file.ID, "CONTAINS", nil))
+	}
+
+	for _, entity := range result.Entities {
+		if entity.ID == "" {
+			continue
+		}
+		props := map[string]any{
+			"filePath":  entity.FilePath,
+			"startLine": entity.StartLine,
+			"endLine":   entity.EndLine,
+			"signature": entity.Signature,
+			"layer":     entity.Layer,
+		}
+		if entity.ClassName != "" {
+			props["className"] = entity.ClassName
+		}
+		graph.Nodes = append(graph.Nodes, db.GraphNode{
+			ID:    entity.ID,
+			Label: entity.Name,
+			Type:  string(entity.Type),
+			Props: props,
+		})
+		if fileID, ok := fileIDs[entity.FilePath]; ok {
+			graph.Edges = append(graph.Edges, edge(fileID, entity.ID, "DECLARES", nil))
+		}
+	}
+
+	for _, pair := range db.Memberships(result.Entities) {
+		graph.Edges = append(graph.Edges, edge(pair[0], pair[1], "MEMBER_OF", nil))
+	}
+
+	calls, _, _ := analysis.ResolveCalls(result.Files, result.Entities)
+	for _, call := range calls {
+		graph.Edges = append(graph.Edges, edge(call.CallerID, call.CalleeID, "CALLS", map[string]any{
+			"line":  call.Line,
+			"count": call.Count,
+		}))
+	}
+
+	for _, access := range analysis.ResolveVariableAccesses(result.Files, result.Entities) {
+		relType := "READS"
+		if access.Write {
+			relType = "WRITES"
+		}
+		graph.Edges = append(graph.Edges, edge(access.EntityID, access.VariableID, relType, map[string]any{
+			"count": access.Count,
+		}))
+	}
+	return graph
+}
+
+func edge(source, target, relType string, props map[string]any) db.GraphEdge {
+	return db.GraphEdge{
+		ID:     fmt.Sprintf("%s-%s->%s", relType, source, target),
+		Source: source,
+		Target: target,
+		Type:   relType,
+		Props:  props,
+	}
+}
@@ -0,0 +1,108 @@
+package artifact
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+func TestPackageURL(t *testing.T) {
+	tests := []struct {
+		dep      Dependency
+		expected string
+	}{
+		{Dependency{Name: "github.com/google/uuid", Version: "v1.6.0", Ecosystem: "go"}, "pkg:golang/github.com/google/uuid@v1.6.0"},
+		{Dependency{Name: "@acme/ui", Version: "1.0.0", Ecosystem: "npm"}, "pkg:npm/%40acme/ui@1.0.0"},
+		{Dependency{Name: "react", Version: "^18.2.0", Ecosystem: "npm"}, "pkg:npm/react"},
+		{Dependency{Name: "Typing_Extensions", Version: "==4.12.0", Ecosystem: "pypi"}, "pkg:pypi/typing-extensions@4.12.0"},
+		{Dependency{Name: "httpx", Version: ">=0.27", Ecosystem: "pypi"}, "pkg:pypi/httpx"},
+		{Dependency{Name: "lib", Ecosystem: "cargo"}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := PackageURL(tt.dep); got != tt.expected {
+			t.Errorf("PackageURL(%+v) = %q, want %q", tt.dep, got, tt.expected)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	repo := &models.Repository{ID: "repo-1", Name: "app", URL: "https://token@github.com/acme/app.git"}
+	run := &models.IndexRun{ID: "run-1", RepoID: "repo-1", Commit: "abc123"}
+	result := &models.IndexResult{
+		RepoID: "repo-1",
+		Files:  []*models.File{{ID: "file-1", Path: "main.go", Language: "go"}},
+		Entities: []models.CodeEntity{
+			{ID: "fn-main", Type: models.EntityFunction, Name: "main", FilePath: "main.go", Calls: []string{"helper"}},
+			{ID: "fn-helper", Type: models.EntityFunction, Name: "helper", FilePath: "main.go"},
+		},
+	}
+
+	doc := Build(Input{
+		Repo:   repo,
+		Run:    run,
+		Result: result,
+		Dependencies: []Dependency{
+			{Name: "github.com/google/uuid", Version: "v1.6.0", Ecosystem: "go", Scope: "runtime", Manifest: "go.mod"},
+			{Name: "vite", Version: "5.0.0", Ecosystem: "npm", Scope: "dev", Manifest: "package.json"},
+		},
+		License: "MIT",
+	})
+
+	if doc.Repository.Commit != "abc123" || doc.Repository.RunID != "run-1" || doc.Repository.License != "MIT" {
+		t.Errorf("unexpected repository %+v", doc.Repository)
+	}
+
+	root := doc.SBOM.Packages[0]
+	if root.DownloadLocation != "git+https://github.com/acme/app.git" {
+		t.Errorf("download location %q keeps credentials or lacks the git+ prefix", root.DownloadLocation)
+	}
+	if len(doc.SBOM.Packages) != 3 {
+		t.Fatalf("expected repository and 2 dependency packages, got %d", len(doc.SBOM.Packages))
+	}
+
+	relations := make(map[string]SPDXRelationship)
+	for _, rel := range doc.SBOM.Relationships {
+		relations[rel.RelationshipType] = rel
+	}
+	if rel := relations["DEPENDS_ON"]; rel.SPDXElementID != repositorySPDXID || rel.RelatedSPDXElement != doc.SBOM.Packages[1].SPDXID {
+		t.Errorf("unexpected DEPENDS_ON %+v", rel)
+	}
+	if rel := relations["DEV_DEPENDENCY_OF"]; rel.SPDXElementID != doc.SBOM.Packages[2].SPDXID || rel.RelatedSPDXElement != repositorySPDXID {
+		t.Errorf("unexpected DEV_DEPENDENCY_OF %+v", rel)
+	}
+
+	edges := make(map[string]int)
+	for _, edge := range doc.Graph.Edges {
+		edges[edge.Type]++
+	}
+	if len(doc.Graph.Nodes) != 4 || edges["CONTAINS"] != 1 || edges["DECLARES"] != 2 || edges["CALLS"] != 1 {
+		t.Errorf("unexpected graph: %d nodes, edges %v", len(doc.Graph.Nodes), edges)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := NewStore(t.TempDir())
+	doc := &Document{Format: Format, Repository: Repository{ID: "repo-1", RunID: "run-1"}}
+
+	if _, err := store.Read("repo-1", "run-1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Read before Save: err = %v, want ErrNotFound", err)
+	}
+	if err := store.Save(doc); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := store.Read("repo-1", "run-1"); err != nil || len(data) == 0 {
+		t.Fatalf("Read after Save: %d bytes, err = %v", len(data), err)
+	}
+	if _, err := store.Read("repo-1", "../repo-2/run-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Read with a path in the run ID: err = %v, want ErrNotFound", err)
+	}
+
+	if err := store.RemoveRepository("repo-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Read("repo-1", "run-1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Read after RemoveRepository: err = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,257 @@
+package artifact
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Dependency is a package the repository declares in a manifest at its root
+type Dependency struct {
+	Name      string `json:"name"`
+	Version   string `json:"version,omitempty"` // as declared, possibly a range
+	Ecosystem string `json:"ecosystem"`         // go, npm or pypi
+	Scope     string `json:"scope"`             // runtime, dev or indirect
+	Manifest  string `json:"manifest"`
+}
+
+// pythonRequirement splits a PEP 508 requirement into name and version
+// specifier, dropping extras and environment markers
+var pythonRequirement = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:\[[^\]]*\])?\s*([^;]*)`)
+
+// tomlString matches a basic or literal TOML string
+var tomlString = regexp.MustCompile(`"([^"]*)"|'([^']*)'`)
+
+// ReadDependencies returns the dependencies declared in go.mod, package.json,
+// requirements.txt and pyproject.toml at the repository root. Licenses of
+// dependencies are not known without querying their registries.
+func ReadDependencies(repoPath string) []Dependency {
+	var deps []Dependency
+	seen := make(map[[2]string]bool)
+	add := func(dep Dependency) {
+		key := [2]string{dep.Ecosystem, dep.Name}
+		if dep.Name != "" && !seen[key] {
+			seen[key] = true
+			deps = append(deps, dep)
+		}
+	}
+
+	for _, dep := range goModDependencies(filepath.Join(repoPath, "go.mod")) {
+		add(dep)
+	}
+	for _, dep := range npmDependencies(filepath.Join(repoPath, "package.json")) {
+		add(dep)
+	}
+	for _, dep := range requirementsDependencies(filepath.Join(repoPath, "requirements.txt")) {
+		add(dep)
+	}
+	for _, dep := range pyprojectDependencies(filepath.Join(repoPath, "pyproject.toml")) {
+		add(dep)
+	}
+	return deps
+}
+
+// goModDependencies reads the require directives of a go.mod file, both the
+// single-line and the block form
+func goModDependencies(goModPath string) []Dependency {
+	f, err := os.Open(goModPath)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var deps []Dependency
+	inBlock := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "require (":
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case !inBlock:
+			rest, ok := strings.CutPrefix(line, "require ")
+			if !ok {
+				continue
+			}
+			line = strings.TrimSpace(rest)
+		}
+
+		scope := "runtime"
+		if spec, comment, ok := strings.Cut(line, "//"); ok {
+			line = strings.TrimSpace(spec)
+			if strings.TrimSpace(comment) == "indirect" {
+				scope = "indirect"
+			}
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		deps = append(deps, Dependency{
+			Name:      fields[0],
+			Version:   fields[1],
+			Ecosystem: "go",
+			Scope:     scope,
+			Manifest:  "go.mod",
+		})
+	}
+	return deps
+}
+
+// npmDependencies reads dependencies and devDependencies of a package.json
+// file, sorted by name as JSON objects carry no order
+func npmDependencies(packageJSONPath string) []Dependency {
+	data, err := os.ReadFile(packageJSONPath)
+	if err != nil {
+		return nil
+	}
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil
+	}
+
+	var deps []Dependency
+	for _, group := range []struct {
+		versions map[string]string
+		scope    string
+	}{{pkg.Dependencies, "runtime"}, {pkg.DevDependencies, "dev"}} {
+		names := make([]string, 0, len(group.versions))
+		for name := range group.versions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			deps = append(deps, Dependency{
+				Name:      name,
+				Version:   group.versions[name],
+				Ecosystem: "npm",
+				Scope:     group.scope,
+				Manifest:  "package.json",
+			})
+		}
+	}
+	return deps
+}
+
+// requirementsDependencies reads a pip requirements file, skipping options
+// and includes
+func requirementsDependencies(requirementsPath string) []Dependency {
+	f, err := os.Open(requirementsPath)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var deps []Dependency
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+		if dep, ok := pythonDependency(line, "requirements.txt"); ok {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// pyprojectDependencies reads the PEP 621 dependencies array of a
+// pyproject.toml file, or the Poetry dependency table
+func pyprojectDependencies(pyprojectPath string) []Dependency {
+	f, err := os.Open(pyprojectPath)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var deps []Dependency
+	section := ""
+	inArray := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if inArray {
+			// The array continues over several lines until its closing bracket
+			inArray = !strings.HasSuffix(line, "]")
+			deps = append(deps, pythonArray(line)...)
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case section == "project" && key == "dependencies":
+			inArray = !strings.HasSuffix(value, "]")
+			deps = append(deps, pythonArray(value)...)
+		case section == "tool.poetry.dependencies" && key != "python":
+			// Tables such as { version = "^1.0", extras = [...] } keep their version inside
+			version := strings.Trim(value, `"'`)
+			if strings.HasPrefix(value, "{") {
+				version = ""
+				if _, rest, ok := strings.Cut(value, "version"); ok {
+					if _, v, ok := strings.Cut(rest, "="); ok {
+						version, _, _ = strings.Cut(strings.TrimSpace(v), ",")
+						version = strings.Trim(strings.TrimSpace(strings.TrimSuffix(version, "}")), `"'`)
+					}
+				}
+			}
+			deps = append(deps, Dependency{
+				Name:      key,
+				Version:   version,
+				Ecosystem: "pypi",
+				Scope:     "runtime",
+				Manifest:  "pyproject.toml",
+			})
+		}
+	}
+	return deps
+}
+
+// pythonArray parses the quoted requirements of a TOML array line
+func pythonArray(items string) []Dependency {
+	var deps []Dependency
+	for _, m := range tomlString.FindAllStringSubmatch(items, -1) {
+		if dep, ok := pythonDependency(m[1]+m[2], "pyproject.toml"); ok {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// pythonDependency parses a PEP 508 requirement such as
+// "requests[socks]>=2.0; python_version > '3.8'"
+func pythonDependency(requirement, manifest string) (Dependency, bool) {
+	m := pythonRequirement.FindStringSubmatch(requirement)
+	if m == nil {
+		return Dependency{}, false
+	}
+	return Dependency{
+		Name:      strings.ToLower(m[1]),
+		Version:   strings.TrimSpace(m[2]),
+		Ecosystem: "pypi",
+		Scope:     "runtime",
+		Manifest:  manifest,
+	}, true
+}
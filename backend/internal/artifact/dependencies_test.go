@@ -0,0 +1,43 @@
+package artifact
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadDependencies(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module github.com/acme/app\n\ngo 1.22\n\nrequire github.com/google/uuid v1.6.0\n\nrequire (\n\tgithub.com/stretchr/testify v1.9.0\n\tgolang.org/x/sys v0.20.0 // indirect\n)\n")
+	write("package.json", `{"dependencies": {"react": "^18.2.0", "@acme/ui": "1.0.0"}, "devDependencies": {"vite": "5.0.0"}}`)
+	write("requirements.txt", "# pinned\nrequests[socks]==2.31.0 ; python_version > '3.8'\n-r dev.txt\nflask\n")
+	write("pyproject.toml", "[project]\nname = \"app\"\ndependencies = [\n  \"httpx>=0.27,<1\",\n  'Requests>=2',\n]\n\n[tool.poetry.dependencies]\npython = \"^3.11\"\npydantic = { version = \"^2.0\", extras = [\"email\"] }\n")
+
+	expected := []Dependency{
+		{Name: "github.com/google/uuid", Version: "v1.6.0", Ecosystem: "go", Scope: "runtime", Manifest: "go.mod"},
+		{Name: "github.com/stretchr/testify", Version: "v1.9.0", Ecosystem: "go", Scope: "runtime", Manifest: "go.mod"},
+		{Name: "golang.org/x/sys", Version: "v0.20.0", Ecosystem: "go", Scope: "indirect", Manifest: "go.mod"},
+		{Name: "@acme/ui", Version: "1.0.0", Ecosystem: "npm", Scope: "runtime", Manifest: "package.json"},
+		{Name: "react", Version: "^18.2.0", Ecosystem: "npm", Scope: "runtime", Manifest: "package.json"},
+		{Name: "vite", Version: "5.0.0", Ecosystem: "npm", Scope: "dev", Manifest: "package.json"},
+		{Name: "requests", Version: "==2.31.0", Ecosystem: "pypi", Scope: "runtime", Manifest: "requirements.txt"},
+		{Name: "flask", Ecosystem: "pypi", Scope: "runtime", Manifest: "requirements.txt"},
+		{Name: "httpx", Version: ">=0.27,<1", Ecosystem: "pypi", Scope: "runtime", Manifest: "pyproject.toml"},
+		{Name: "pydantic", Version: "^2.0", Ecosystem: "pypi", Scope: "runtime", Manifest: "pyproject.toml"},
+	}
+	if got := ReadDependencies(dir); !reflect.DeepEqual(got, expected) {
+		t.Errorf("ReadDependencies:\n got %+v\nwant %+v", got, expected)
+	}
+}
+
+func TestReadDependenciesNoManifests(t *testing.T) {
+	if got := ReadDependencies(t.TempDir()); len(got) != 0 {
+		t.Errorf("ReadDependencies() = %v, want none", got)
+	}
+}
@@ -0,0 +1,408 @@
+package artifact
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/dpolishuk/neograph/backend/internal/db"
+)
+
+// changelogListMax bounds how many items each changelog list shows
+const changelogListMax = 20
+
+// Symbol is a function, method or class of an index run
+type Symbol struct {
+	Name      string `json:"name"` // qualified with the class of a method
+	Type      string `json:"type"`
+	FilePath  string `json:"filePath"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// DependencyChange is a dependency whose declared version changed
+type DependencyChange struct {
+	Name string `json:"name"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Changes is what changed in the code graph and dependencies between two
+// index runs
+type Changes struct {
+	FromCommit          string             `json:"fromCommit,omitempty"`
+	ToCommit            string             `json:"toCommit,omitempty"`
+	AddedAPIs           []Symbol           `json:"addedApis"`        // new public functions, methods and classes
+	RemovedFunctions    []Symbol           `json:"removedFunctions"` // functions and methods gone, public or not
+	AddedDependencies   []Dependency       `json:"addedDependencies"`
+	RemovedDependencies []Dependency       `json:"removedDependencies"`
+	ChangedDependencies []DependencyChange `json:"changedDependencies"`
+}
+
+// Empty reports whether nothing worth noting changed
+func (c *Changes) Empty() bool {
+	return len(c.AddedAPIs) == 0 && len(c.RemovedFunctions) == 0 &&
+		len(c.AddedDependencies) == 0 && len(c.RemovedDependencies) == 0 &&
+		len(c.ChangedDependencies) == 0
+}
+
+// Diff compares the artifacts of two index runs of a repository
+func Diff(prev, next *Document) *Changes {
+	changes := &Changes{
+		FromCommit:          prev.Repository.Commit,
+		ToCommit:            next.Repository.Commit,
+		AddedAPIs:           []Symbol{},
+		RemovedFunctions:    []Symbol{},
+		AddedDependencies:   []Dependency{},
+		RemovedDependencies: []Dependency{},
+		ChangedDependencies: []DependencyChange{},
+	}
+
+	before, after := symbols(prev), symbols(next)
+	for key, sym := range after {
+		if _, ok := before[key]; !ok && isPublic(sym) {
+			changes.AddedAPIs = append(changes.AddedAPIs, sym)
+		}
+	}
+	for key, sym := range before {
+		if _, ok := after[key]; !ok && sym.Type != "Class" {
+			changes.RemovedFunctions = append(changes.RemovedFunctions, sym)
+		}
+	}
+	sortSymbols(changes.AddedAPIs)
+	sortSymbols(changes.RemovedFunctions)
+
+	oldDeps, newDeps := dependencies(prev), dependencies(next)
+	for name, dep := range newDeps {
+		old, ok := oldDeps[name]
+		switch {
+		case !ok:
+			changes.AddedDependencies = append(changes.AddedDependencies, dep)
+		case old.Version != dep.Version:
+			changes.ChangedDependencies = append(changes.ChangedDependencies, DependencyChange{
+				Name: name, From: old.Version, To: dep.Version,
+			})
+		}
+	}
+	for name, dep := range oldDeps {
+		if _, ok := newDeps[name]; !ok {
+			changes.RemovedDependencies = append(changes.RemovedDependencies, dep)
+		}
+	}
+	sort.Slice(changes.AddedDependencies, func(i, j int) bool {
+		return changes.AddedDependencies[i].Name < changes.AddedDependencies[j].Name
+	})
+	sort.Slice(changes.RemovedDependencies, func(i, j int) bool {
+		return changes.RemovedDependencies[i].Name < changes.RemovedDependencies[j].Name
+	})
+	sort.Slice(changes.ChangedDependencies, func(i, j int) bool {
+		return changes.ChangedDependencies[i].Name < changes.ChangedDependencies[j].Name
+	})
+	return changes
+}
+
+// symbols returns the functions, methods and classes of an artifact's graph
+// by file and qualified name; IDs change with every index
+func symbols(doc *Document) map[string]Symbol {
+	out := make(map[string]Symbol)
+	for _, node := range doc.Graph.Nodes {
+		if node.Type != "Function" && node.Type != "Method" && node.Type != "Class" {
+			continue
+		}
+		sym := symbol(node)
+		out[sym.FilePath+"#"+sym.Type+"#"+sym.Name] = sym
+	}
+	return out
+}
+
+// symbol describes a function, method or class node of an artifact's graph
+func symbol(node db.GraphNode) Symbol {
+	sym := Symbol{Name: node.Label, Type: node.Type}
+	sym.FilePath, _ = node.Props["filePath"].(string)
+	sym.Signature, _ = node.Props["signature"].(string)
+	if className, _ := node.Props["className"].(string); className != "" && node.Type == "Method" {
+		sym.Name = className + "." + node.Label
+	}
+	return sym
+}
+
+// symbolKey identifies a symbol across index runs
+func symbolKey(node db.GraphNode) string {
+	sym := symbol(node)
+	return sym.FilePath + "#" + sym.Type + "#" + sym.Name
+}
+
+// dependencies returns the packages of an artifact's SBOM by name
+func dependencies(doc *Document) map[string]Dependency {
+	out := make(map[string]Dependency)
+	for _, pkg := range doc.SBOM.Packages {
+		if pkg.SPDXID == repositorySPDXID {
+			continue
+		}
+		out[pkg.Name] = Dependency{Name: pkg.Name, Version: pkg.VersionInfo}
+	}
+	return out
+}
+
+// isPublic reports whether a symbol is visible outside its package: an
+// exported name in Go, a name without a leading underscore elsewhere
+func isPublic(sym Symbol) bool {
+	name := sym.Name[strings.LastIndex(sym.Name, ".")+1:]
+	if name == "" || strings.HasPrefix(name, "_") {
+		return false
+	}
+	if path.Ext(sym.FilePath) == ".go" {
+		return unicode.IsUpper([]rune(name)[0])
+	}
+	return true
+}
+
+func sortSymbols(syms []Symbol) {
+	sort.Slice(syms, func(i, j int) bool {
+		if syms[i].FilePath != syms[j].FilePath {
+			return syms[i].FilePath < syms[j].FilePath
+		}
+		return syms[i].Name < syms[j].Name
+	})
+}
+
+// Markdown renders the changes as a changelog section headed by when they
+// were indexed
+func (c *Changes) Markdown(at time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s", at.UTC().Format("2006-01-02 15:04 UTC"))
+	if c.FromCommit != "" && c.ToCommit != "" {
+		fmt.Fprintf(&b, " (`%s`..`%s`)", shortCommit(c.FromCommit), shortCommit(c.ToCommit))
+	}
+	b.WriteString("\n")
+
+	list := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", title)
+		for i, item := range items {
+			if i == changelogListMax {
+				fmt.Fprintf(&b, "- and %d more\n", len(items)-changelogListMax)
+				break
+			}
+			fmt.Fprintf(&b, "- %s\n", item)
+		}
+	}
+
+	symbolItems := func(syms []Symbol) []string {
+		items := make([]string, len(syms))
+		for i, sym := range syms {
+			items[i] = fmt.Sprintf("`%s` (%s) in `%s`", sym.Name, strings.ToLower(sym.Type), sym.FilePath)
+		}
+		return items
+	}
+	list("New public APIs", symbolItems(c.AddedAPIs))
+	list("Removed functions", symbolItems(c.RemovedFunctions))
+
+	var deps []string
+	for _, dep := range c.AddedDependencies {
+		deps = append(deps, fmt.Sprintf("Added `%s` %s", dep.Name, dep.Version))
+	}
+	for _, dep := range c.RemovedDependencies {
+		deps = append(deps, fmt.Sprintf("Removed `%s`", dep.Name))
+	}
+	for _, dep := range c.ChangedDependencies {
+		deps = append(deps, fmt.Sprintf("`%s` %s → %s", dep.Name, dep.From, dep.To))
+	}
+	for i := range deps {
+		deps[i] = strings.TrimSpace(deps[i])
+	}
+	list("Dependency changes", deps)
+	return b.String()
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// SymbolChange is a function or method present in both runs whose signature
+// or length changed
+type SymbolChange struct {
+	Symbol
+	FromSignature string `json:"fromSignature,omitempty"`
+	FromLines     int    `json:"fromLines"`
+	ToLines       int    `json:"toLines"`
+}
+
+// CallEdge is a call between two functions or methods
+type CallEdge struct {
+	Caller Symbol `json:"caller"`
+	Callee Symbol `json:"callee"`
+}
+
+// GraphChanges is how the functions and calls of the code graph changed
+// between two index runs
+type GraphChanges struct {
+	FromRunID        string         `json:"fromRunId"`
+	ToRunID          string         `json:"toRunId"`
+	FromCommit       string         `json:"fromCommit,omitempty"`
+	ToCommit         string         `json:"toCommit,omitempty"`
+	AddedFunctions   []Symbol       `json:"addedFunctions"`
+	RemovedFunctions []Symbol       `json:"removedFunctions"`
+	ChangedFunctions []SymbolChange `json:"changedFunctions"`
+	AddedCalls       []CallEdge     `json:"addedCalls"`
+	RemovedCalls     []CallEdge     `json:"removedCalls"`
+}
+
+// GraphDiff compares the functions, methods and call edges of the artifacts
+// of two index runs. Entities are matched by file and qualified name, as
+// IDs change with every index; moving a function within its file is not a
+// change, but a new signature or length is.
+func GraphDiff(prev, next *Document) *GraphChanges {
+	changes := &GraphChanges{
+		FromRunID:        prev.Repository.RunID,
+		ToRunID:          next.Repository.RunID,
+		FromCommit:       prev.Repository.Commit,
+		ToCommit:         next.Repository.Commit,
+		AddedFunctions:   []Symbol{},
+		RemovedFunctions: []Symbol{},
+		ChangedFunctions: []SymbolChange{},
+		AddedCalls:       []CallEdge{},
+		RemovedCalls:     []CallEdge{},
+	}
+
+	before, after := functionKeys(prev), functionKeys(next)
+	oldSyms, newSyms := symbols(prev), symbols(next)
+	oldLines, newLines := lineCounts(prev), lineCounts(next)
+	for key := range uniqueKeys(after) {
+		if _, ok := oldSyms[key]; !ok {
+			changes.AddedFunctions = append(changes.AddedFunctions, newSyms[key])
+			continue
+		}
+		old, sym := oldSyms[key], newSyms[key]
+		if old.Signature != sym.Signature || oldLines[key] != newLines[key] {
+			change := SymbolChange{Symbol: sym, FromLines: oldLines[key], ToLines: newLines[key]}
+			if old.Signature != sym.Signature {
+				change.FromSignature = old.Signature
+			}
+			changes.ChangedFunctions = append(changes.ChangedFunctions, change)
+		}
+	}
+	for key := range uniqueKeys(before) {
+		if _, ok := newSyms[key]; !ok {
+			changes.RemovedFunctions = append(changes.RemovedFunctions, oldSyms[key])
+		}
+	}
+	sortSymbols(changes.AddedFunctions)
+	sortSymbols(changes.RemovedFunctions)
+	sort.Slice(changes.ChangedFunctions, func(i, j int) bool {
+		a, b := changes.ChangedFunctions[i], changes.ChangedFunctions[j]
+		if a.FilePath != b.FilePath {
+			return a.FilePath < b.FilePath
+		}
+		return a.Name < b.Name
+	})
+
+	oldCalls, newCalls := callEdges(prev, before, oldSyms), callEdges(next, after, newSyms)
+	for key, call := range newCalls {
+		if _, ok := oldCalls[key]; !ok {
+			changes.AddedCalls = append(changes.AddedCalls, call)
+		}
+	}
+	for key, call := range oldCalls {
+		if _, ok := newCalls[key]; !ok {
+			changes.RemovedCalls = append(changes.RemovedCalls, call)
+		}
+	}
+	sortCalls(changes.AddedCalls)
+	sortCalls(changes.RemovedCalls)
+	return changes
+}
+
+// functionKeys maps the IDs of an artifact's functions and methods to their
+// symbol keys
+func functionKeys(doc *Document) map[string]string {
+	keys := make(map[string]string)
+	for _, node := range doc.Graph.Nodes {
+		if node.Type == "Function" || node.Type == "Method" {
+			keys[node.ID] = symbolKey(node)
+		}
+	}
+	return keys
+}
+
+// lineCounts returns how many lines each function and method spans, by
+// symbol key
+func lineCounts(doc *Document) map[string]int {
+	counts := make(map[string]int)
+	for _, node := range doc.Graph.Nodes {
+		if node.Type != "Function" && node.Type != "Method" {
+			continue
+		}
+		start, end := intProp(node.Props["startLine"]), intProp(node.Props["endLine"])
+		if end >= start {
+			counts[symbolKey(node)] = end - start + 1
+		}
+	}
+	return counts
+}
+
+// callEdges returns the CALLS edges of an artifact between its functions and
+// methods, keyed by the symbol keys of both ends
+func callEdges(doc *Document, keys map[string]string, syms map[string]Symbol) map[string]CallEdge {
+	out := make(map[string]CallEdge)
+	for _, e := range doc.Graph.Edges {
+		if e.Type != "CALLS" {
+			continue
+		}
+		caller, ok := keys[e.Source]
+		if !ok {
+			continue
+		}
+		callee, ok := keys[e.Target]
+		if !ok {
+			continue
+		}
+		out[caller+"->"+callee] = CallEdge{Caller: syms[caller], Callee: syms[callee]}
+	}
+	return out
+}
+
+// uniqueKeys returns the distinct symbol keys of an ID to key map
+func uniqueKeys(keys map[string]string) map[string]bool {
+	out := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		out[key] = true
+	}
+	return out
+}
+
+// intProp reads a number property, which decodes from JSON as a float
+func intProp(v any) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return 0
+}
+
+func sortCalls(calls []CallEdge) {
+	sort.Slice(calls, func(i, j int) bool {
+		a, b := calls[i], calls[j]
+		if a.Caller.FilePath != b.Caller.FilePath {
+			return a.Caller.FilePath < b.Caller.FilePath
+		}
+		if a.Caller.Name != b.Caller.Name {
+			return a.Caller.Name < b.Caller.Name
+		}
+		if a.Callee.FilePath != b.Callee.FilePath {
+			return a.Callee.FilePath < b.Callee.FilePath
+		}
+		return a.Callee.Name < b.Callee.Name
+	})
+}
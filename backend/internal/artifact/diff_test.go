@@ -0,0 +1,153 @@
+package artifact
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/db"
+)
+
+func entityNode(id, name, nodeType, filePath string) db.GraphNode {
+	return db.GraphNode{ID: id, Label: name, Type: nodeType, Props: map[string]any{"filePath": filePath}}
+}
+
+func TestDiff(t *testing.T) {
+	prev := &Document{
+		Repository: Repository{Commit: "1111111aaaa"},
+		SBOM: SBOM{Packages: []SPDXPackage{
+			{SPDXID: repositorySPDXID, Name: "app"},
+			{SPDXID: "p1", Name: "github.com/google/uuid", VersionInfo: "v1.5.0"},
+			{SPDXID: "p2", Name: "github.com/pkg/errors", VersionInfo: "v0.9.1"},
+		}},
+		Graph: db.GraphData{Nodes: []db.GraphNode{
+			{ID: "r", Label: "app", Type: "Repository"},
+			entityNode("a", "Parse", "Function", "parser.go"),
+			entityNode("b", "legacy", "Function", "parser.go"),
+			entityNode("c", "Old", "Class", "model.py"),
+		}},
+	}
+	next := &Document{
+		Repository: Repository{Commit: "2222222bbbb"},
+		SBOM: SBOM{Packages: []SPDXPackage{
+			{SPDXID: repositorySPDXID, Name: "app"},
+			{SPDXID: "p1", Name: "github.com/google/uuid", VersionInfo: "v1.6.0"},
+			{SPDXID: "p3", Name: "golang.org/x/sync", VersionInfo: "v0.7.0"},
+		}},
+		Graph: db.GraphData{Nodes: []db.GraphNode{
+			entityNode("a2", "Parse", "Function", "parser.go"),
+			entityNode("d", "Render", "Function", "render.go"),
+			entityNode("e", "helper", "Function", "render.go"),
+			entityNode("f", "_private", "Function", "util.py"),
+			entityNode("g", "load", "Function", "util.py"),
+		}},
+	}
+
+	changes := Diff(prev, next)
+
+	var added []string
+	for _, sym := range changes.AddedAPIs {
+		added = append(added, sym.Name)
+	}
+	if strings.Join(added, ",") != "Render,load" {
+		t.Errorf("AddedAPIs = %v, want [Render load]", added)
+	}
+	if len(changes.RemovedFunctions) != 1 || changes.RemovedFunctions[0].Name != "legacy" {
+		t.Errorf("RemovedFunctions = %+v, want legacy only", changes.RemovedFunctions)
+	}
+	if len(changes.AddedDependencies) != 1 || changes.AddedDependencies[0].Name != "golang.org/x/sync" {
+		t.Errorf("AddedDependencies = %+v", changes.AddedDependencies)
+	}
+	if len(changes.RemovedDependencies) != 1 || changes.RemovedDependencies[0].Name != "github.com/pkg/errors" {
+		t.Errorf("RemovedDependencies = %+v", changes.RemovedDependencies)
+	}
+	if len(changes.ChangedDependencies) != 1 || changes.ChangedDependencies[0].To != "v1.6.0" {
+		t.Errorf("ChangedDependencies = %+v", changes.ChangedDependencies)
+	}
+
+	md := changes.Markdown(time.Date(2025, 5, 2, 14, 30, 0, 0, time.UTC))
+	for _, want := range []string{
+		"## 2025-05-02 14:30 UTC (`1111111`..`2222222`)",
+		"### New public APIs",
+		"- `Render` (function) in `render.go`",
+		"### Removed functions",
+		"- Added `golang.org/x/sync` v0.7.0",
+		"- `github.com/google/uuid` v1.5.0 → v1.6.0",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown() missing %q in:\n%s", want, md)
+		}
+	}
+
+	if !Diff(next, next).Empty() {
+		t.Error("expected no changes between identical artifacts")
+	}
+}
+
+func TestGraphDiff(t *testing.T) {
+	function := func(id, name, filePath, signature string, start, end int) db.GraphNode {
+		node := entityNode(id, name, "Function", filePath)
+		node.Props["signature"] = signature
+		node.Props["startLine"] = start
+		node.Props["endLine"] = end
+		return node
+	}
+	calls := func(source, target string) db.GraphEdge {
+		return edge(source, target, "CALLS", nil)
+	}
+
+	prev := &Document{
+		Repository: Repository{RunID: "run-1", Commit: "1111111"},
+		Graph: db.GraphData{
+			Nodes: []db.GraphNode{
+				function("a", "Parse", "parser.go", "func Parse(s string) Node", 10, 20),
+				function("b", "lex", "parser.go", "func lex(s string) []Token", 30, 40),
+				function("c", "legacy", "parser.go", "func legacy()", 50, 55),
+				function("d", "Render", "render.go", "func Render(n Node) string", 1, 9),
+			},
+			Edges: []db.GraphEdge{calls("a", "b"), calls("a", "c"), calls("d", "a")},
+		},
+	}
+	next := &Document{
+		Repository: Repository{RunID: "run-2", Commit: "2222222"},
+		Graph: db.GraphData{
+			Nodes: []db.GraphNode{
+				// Moved down the file but otherwise the same
+				function("a2", "Parse", "parser.go", "func Parse(s string) Node", 15, 25),
+				function("b2", "lex", "parser.go", "func lex(s string, strict bool) []Token", 30, 40),
+				function("d2", "Render", "render.go", "func Render(n Node) string", 1, 14),
+				function("e2", "escape", "render.go", "func escape(s string) string", 20, 25),
+			},
+			Edges: []db.GraphEdge{calls("a2", "b2"), calls("d2", "a2"), calls("d2", "e2")},
+		},
+	}
+
+	changes := GraphDiff(prev, next)
+	if changes.FromRunID != "run-1" || changes.ToRunID != "run-2" {
+		t.Errorf("runs = %s..%s", changes.FromRunID, changes.ToRunID)
+	}
+	if len(changes.AddedFunctions) != 1 || changes.AddedFunctions[0].Name != "escape" {
+		t.Errorf("AddedFunctions = %+v, want escape only", changes.AddedFunctions)
+	}
+	if len(changes.RemovedFunctions) != 1 || changes.RemovedFunctions[0].Name != "legacy" {
+		t.Errorf("RemovedFunctions = %+v, want legacy only", changes.RemovedFunctions)
+	}
+
+	if len(changes.ChangedFunctions) != 2 {
+		t.Fatalf("ChangedFunctions = %+v, want lex and Render", changes.ChangedFunctions)
+	}
+	lex, render := changes.ChangedFunctions[0], changes.ChangedFunctions[1]
+	if lex.Name != "lex" || lex.FromSignature != "func lex(s string) []Token" || lex.Signature != "func lex(s string, strict bool) []Token" {
+		t.Errorf("lex change = %+v", lex)
+	}
+	if render.Name != "Render" || render.FromSignature != "" || render.FromLines != 9 || render.ToLines != 14 {
+		t.Errorf("Render change = %+v", render)
+	}
+
+	if len(changes.AddedCalls) != 1 || changes.AddedCalls[0].Caller.Name != "Render" || changes.AddedCalls[0].Callee.Name != "escape" {
+		t.Errorf("AddedCalls = %+v, want Render -> escape", changes.AddedCalls)
+	}
+	if len(changes.RemovedCalls) != 1 || changes.RemovedCalls[0].Caller.Name != "Parse" || changes.RemovedCalls[0].Callee.Name != "legacy" {
+		t.Errorf("RemovedCalls = %+v, want Parse -> legacy", changes.RemovedCalls)
+	}
+}
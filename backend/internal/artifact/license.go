@@ -0,0 +1,82 @@
+package artifact
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NoAssertion is the SPDX value for information that was not determined
+const NoAssertion = "NOASSERTION"
+
+// maxLicenseBytes caps how much of a license file is read
+const maxLicenseBytes = 32 * 1024
+
+// licenseNames are the license file names looked for, in order
+var licenseNames = []string{"LICENSE", "LICENSE.md", "LICENSE.txt", "LICENCE", "LICENCE.md", "COPYING", "COPYING.md"}
+
+// licenseMarkers identify well-known license texts by phrases they contain,
+// checked in order so that more specific texts win
+var licenseMarkers = []struct {
+	id      string
+	phrases []string
+}{
+	{"AGPL-3.0-only", []string{"GNU AFFERO GENERAL PUBLIC LICENSE", "Version 3"}},
+	{"LGPL-3.0-only", []string{"GNU LESSER GENERAL PUBLIC LICENSE", "Version 3"}},
+	{"LGPL-2.1-only", []string{"GNU LESSER GENERAL PUBLIC LICENSE", "Version 2.1"}},
+	{"GPL-3.0-only", []string{"GNU GENERAL PUBLIC LICENSE", "Version 3"}},
+	{"GPL-2.0-only", []string{"GNU GENERAL PUBLIC LICENSE", "Version 2"}},
+	{"Apache-2.0", []string{"Apache License", "Version 2.0"}},
+	{"MPL-2.0", []string{"Mozilla Public License", "2.0"}},
+	{"BSD-3-Clause", []string{"Redistribution and use in source and binary forms", "Neither the name"}},
+	{"BSD-2-Clause", []string{"Redistribution and use in source and binary forms"}},
+	{"MIT", []string{"Permission is hereby granted, free of charge"}},
+	{"ISC", []string{"Permission to use, copy, modify, and/or distribute this software for any purpose"}},
+	{"Unlicense", []string{"This is free and unencumbered software released into the public domain"}},
+}
+
+// DetectLicense returns the SPDX identifier of the license file at the
+// repository root, or NoAssertion when there is none or it is not recognized
+func DetectLicense(repoPath string) string {
+	for _, name := range licenseNames {
+		f, err := os.Open(filepath.Join(repoPath, name))
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(f, maxLicenseBytes))
+		f.Close()
+		if err == nil {
+			return IdentifyLicense(string(data))
+		}
+	}
+	return NoAssertion
+}
+
+// IdentifyLicense returns the SPDX identifier of a license text. An explicit
+// SPDX-License-Identifier line takes precedence over the text itself.
+func IdentifyLicense(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if _, id, ok := strings.Cut(line, "SPDX-License-Identifier:"); ok {
+			if id = strings.TrimSpace(id); id != "" {
+				return id
+			}
+		}
+	}
+
+	// Line breaks fall anywhere in license texts
+	normalized := strings.Join(strings.Fields(text), " ")
+	for _, marker := range licenseMarkers {
+		matched := true
+		for _, phrase := range marker.phrases {
+			if !strings.Contains(normalized, phrase) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return marker.id
+		}
+	}
+	return NoAssertion
+}
@@ -0,0 +1,44 @@
+package artifact
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIdentifyLicense(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"mit", "MIT License\n\nPermission is hereby granted, free of\ncharge, to any person", "MIT"},
+		{"apache", "                                 Apache License\n                           Version 2.0, January 2004", "Apache-2.0"},
+		{"lgpl before gpl", "GNU LESSER GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007\n... GNU GENERAL PUBLIC LICENSE", "LGPL-3.0-only"},
+		{"bsd-3", "Redistribution and use in source and binary forms, with or without\nmodification...\n3. Neither the name of the copyright holder", "BSD-3-Clause"},
+		{"spdx header", "SPDX-License-Identifier: MPL-2.0 OR MIT\n", "MPL-2.0 OR MIT"},
+		{"unknown", "All rights reserved.", NoAssertion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IdentifyLicense(tt.text); got != tt.expected {
+				t.Errorf("IdentifyLicense() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDetectLicense(t *testing.T) {
+	dir := t.TempDir()
+	if got := DetectLicense(dir); got != NoAssertion {
+		t.Errorf("DetectLicense() without a license file = %q, want %q", got, NoAssertion)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "LICENSE.md"), []byte("Permission is hereby granted, free of charge"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := DetectLicense(dir); got != "MIT" {
+		t.Errorf("DetectLicense() = %q, want MIT", got)
+	}
+}
@@ -0,0 +1,80 @@
+package artifact
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// ErrNotFound is returned for runs without a stored artifact
+var ErrNotFound = errors.New("artifact not found")
+
+// validID matches repository and run IDs, which are UUIDs; anything else
+// could escape the store directory
+var validID = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// Store keeps artifacts on disk as <root>/<repoID>/<runID>.json
+type Store struct {
+	root string
+}
+
+func NewStore(root string) *Store {
+	return &Store{root: root}
+}
+
+// Save writes the artifact of a run. The file is renamed into place so a
+// download never sees a partial artifact.
+func (s *Store) Save(doc *Document) error {
+	path, err := s.path(doc.Repository.ID, doc.Repository.RunID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode artifact: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write artifact: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write artifact: %w", err)
+	}
+	return nil
+}
+
+// Read returns the stored artifact of a run as JSON
+func (s *Store) Read(repoID, runID string) ([]byte, error) {
+	path, err := s.path(repoID, runID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// RemoveRepository deletes the artifacts of every run of a repository
+func (s *Store) RemoveRepository(repoID string) error {
+	if !validID.MatchString(repoID) {
+		return nil
+	}
+	return os.RemoveAll(filepath.Join(s.root, repoID))
+}
+
+func (s *Store) path(repoID, runID string) (string, error) {
+	if !validID.MatchString(repoID) || !validID.MatchString(runID) {
+		return "", fmt.Errorf("invalid artifact id %q/%q", repoID, runID)
+	}
+	return filepath.Join(s.root, repoID, runID+".json"), nil
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scopes of API keys, which act with the viewer, editor and admin roles
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+// secretPrefix starts generated keys so they are recognizable in configs
+// and secret scanners
+const secretPrefix = "ng_"
+
+// cacheTTL is how long a stored key's scope is trusted without looking it up
+// again, and so how long a key deleted on another instance keeps working
+const cacheTTL = 30 * time.Second
+
+// cacheSize bounds the cached lookups, which include every unknown key tried
+const cacheSize = 1024
+
+var scopeRoles = map[string]string{ScopeRead: RoleViewer, ScopeWrite: RoleEditor, ScopeAdmin: RoleAdmin}
+
+// ValidScope reports whether scope is one of read, write or admin
+func ValidScope(scope string) bool {
+	return scopeRoles[scope] != ""
+}
+
+// ScopeRole returns the role a key of scope acts with, "" for an unknown
+// scope
+func ScopeRole(scope string) string {
+	return scopeRoles[scope]
+}
+
+// Hash returns the hex SHA-256 of a key. Keys are random, so a plain hash
+// is enough to store them without the secret.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Generate returns a new random key
+func Generate() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// ParseKeys parses `key:scope` entries, such as configured in API_KEYS,
+// into scopes by key hash
+func ParseKeys(entries []string) (map[string]string, error) {
+	keys := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		i := strings.LastIndex(entry, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("API key entry must be key:scope")
+		}
+		secret, scope := entry[:i], strings.ToLower(entry[i+1:])
+		if !ValidScope(scope) {
+			return nil, fmt.Errorf("unknown API key scope %q, expected read, write or admin", scope)
+		}
+		keys[Hash(secret)] = scope
+	}
+	return keys, nil
+}
+
+// Grant is what a key allows: acting with the role of its scope, within
+// an organization unless OrgID is empty. Configured keys are never scoped
+// to an organization.
+type Grant struct {
+	Scope string
+	OrgID string
+}
+
+// LookupFunc returns the grant of a stored key by its hash, the zero Grant
+// when no key has that hash
+type LookupFunc func(ctx context.Context, hash string) (Grant, error)
+
+type cachedGrant struct {
+	grant   Grant
+	expires time.Time
+}
+
+// Keyring resolves keys to their grants: keys configured at startup and
+// keys provisioned at runtime, which are looked up through a LookupFunc and
+// cached briefly.
+type Keyring struct {
+	configured map[string]string // key hash -> scope
+	lookup     LookupFunc
+
+	mu    sync.Mutex
+	cache map[string]cachedGrant // key hash -> grant, zero for unknown keys
+}
+
+// NewKeyring creates a keyring of configured keys, by hash as returned by
+// ParseKeys, and stored keys found by lookup
+func NewKeyring(configured map[string]string, lookup LookupFunc) *Keyring {
+	return &Keyring{configured: configured, lookup: lookup, cache: make(map[string]cachedGrant)}
+}
+
+// Enabled reports whether any key is configured. Without one nobody could
+// provision the others, unless they authenticate otherwise.
+func (k *Keyring) Enabled() bool {
+	return len(k.configured) > 0
+}
+
+// Grant returns the grant of a key, the zero Grant for a key that is not
+// known
+func (k *Keyring) Grant(ctx context.Context, secret string) (Grant, error) {
+	hash := Hash(secret)
+	if scope, ok := k.configured[hash]; ok {
+		return Grant{Scope: scope}, nil
+	}
+
+	k.mu.Lock()
+	cached, ok := k.cache[hash]
+	k.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.grant, nil
+	}
+
+	grant, err := k.lookup(ctx, hash)
+	if err != nil {
+		return Grant{}, err
+	}
+	k.mu.Lock()
+	if len(k.cache) >= cacheSize {
+		k.cache = make(map[string]cachedGrant)
+	}
+	k.cache[hash] = cachedGrant{grant: grant, expires: time.Now().Add(cacheTTL)}
+	k.mu.Unlock()
+	return grant, nil
+}
+
+// Forget drops a stored key from the cache once it is deleted, so it stops
+// working on this instance at once
+func (k *Keyring) Forget(hash string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	delete(k.cache, hash)
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	keys, err := ParseKeys([]string{"secret:read", "with:colon:Admin"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if keys[Hash("secret")] != ScopeRead {
+		t.Errorf("expected secret to be read, got %q", keys[Hash("secret")])
+	}
+	if keys[Hash("with:colon")] != ScopeAdmin {
+		t.Errorf("expected with:colon to be admin, got %q", keys[Hash("with:colon")])
+	}
+
+	for _, entry := range []string{"secret", ":read", "secret:owner"} {
+		if _, err := ParseKeys([]string{entry}); err == nil {
+			t.Errorf("expected an error for %q", entry)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	a, err := Generate()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := Generate()
+	if a == b {
+		t.Error("expected distinct keys")
+	}
+	if !strings.HasPrefix(a, secretPrefix) || len(a) < 40 {
+		t.Errorf("unexpected key %q", a)
+	}
+}
+
+func TestKeyring_Grant(t *testing.T) {
+	stored := map[string]Grant{Hash("stored"): {Scope: ScopeWrite, OrgID: "acme"}}
+	lookups := 0
+	keyring := NewKeyring(map[string]string{Hash("configured"): ScopeAdmin}, func(ctx context.Context, hash string) (Grant, error) {
+		lookups++
+		return stored[hash], nil
+	})
+	ctx := context.Background()
+
+	if !keyring.Enabled() {
+		t.Error("expected a keyring with configured keys to be enabled")
+	}
+	if grant, _ := keyring.Grant(ctx, "configured"); grant != (Grant{Scope: ScopeAdmin}) {
+		t.Errorf("expected admin of the instance, got %+v", grant)
+	}
+	if lookups != 0 {
+		t.Errorf("expected configured keys not to be looked up, got %d lookups", lookups)
+	}
+
+	for range 2 {
+		if grant, _ := keyring.Grant(ctx, "stored"); grant != (Grant{Scope: ScopeWrite, OrgID: "acme"}) {
+			t.Errorf("expected write in acme, got %+v", grant)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("expected the stored key to be looked up once, got %d lookups", lookups)
+	}
+
+	delete(stored, Hash("stored"))
+	keyring.Forget(Hash("stored"))
+	if grant, _ := keyring.Grant(ctx, "stored"); grant.Scope != "" {
+		t.Errorf("expected a forgotten key to be unknown, got %+v", grant)
+	}
+}
+
+func TestKeyring_Disabled(t *testing.T) {
+	keyring := NewKeyring(nil, nil)
+	if keyring.Enabled() {
+		t.Error("expected a keyring without configured keys to be disabled")
+	}
+}
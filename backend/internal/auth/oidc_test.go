@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// signToken signs claims with an RSA key as RS256
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// oidcProvider serves a discovery document and the JWKS of an RSA key
+func oidcProvider(t *testing.T, key *rsa.PrivateKey, kid string) (*httptest.Server, *int) {
+	t.Helper()
+	fetches := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{"jwks_uri": server.URL + "/keys"})
+		case "/keys":
+			fetches++
+			json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": kid,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &fetches
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	server, fetches := oidcProvider(t, key, "k1")
+	verifier := NewVerifier(OIDCConfig{Issuer: server.URL + "/", Audience: "neograph", RolesClaim: "realm_access.roles", OrgClaim: "org"})
+
+	token := signToken(t, key, "k1", map[string]any{
+		"iss":          server.URL,
+		"sub":          "alice",
+		"aud":          []string{"account", "neograph"},
+		"exp":          time.Now().Add(time.Hour).Unix(),
+		"realm_access": map[string]any{"roles": []string{"offline_access", "editor"}},
+		"org":          "acme",
+	})
+	for range 2 {
+		identity, err := verifier.Verify(context.Background(), token)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if identity.Subject != "alice" || identity.Role != RoleEditor || identity.OrgID != "acme" {
+			t.Errorf("unexpected identity %+v", identity)
+		}
+	}
+	if *fetches != 1 {
+		t.Errorf("expected the keys to be fetched once, got %d", *fetches)
+	}
+}
+
+func TestVerifier_Rejects(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	server, _ := oidcProvider(t, key, "k1")
+	verifier := NewVerifier(OIDCConfig{Issuer: server.URL, Audience: "neograph"})
+
+	valid := func() map[string]any {
+		return map[string]any{"iss": server.URL, "aud": "neograph", "exp": time.Now().Add(time.Hour).Unix()}
+	}
+	cases := map[string]string{
+		"other key":      signToken(t, other, "k1", valid()),
+		"unknown key ID": signToken(t, key, "k2", valid()),
+		"not a JWT":      "ng_key",
+	}
+	expired := valid()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	cases["expired"] = signToken(t, key, "k1", expired)
+	issuer := valid()
+	issuer["iss"] = "https://elsewhere.example.com"
+	cases["other issuer"] = signToken(t, key, "k1", issuer)
+	audience := valid()
+	audience["aud"] = "other"
+	cases["other audience"] = signToken(t, key, "k1", audience)
+
+	for name, token := range cases {
+		if _, err := verifier.Verify(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+}
+
+func TestVerifySignature_ECDSA(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	digest := sha256.Sum256([]byte("header.payload"))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	if err := verifySignature("ES256", &key.PublicKey, "header.payload", signature); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := verifySignature("ES256", &key.PublicKey, "header.tampered", signature); err == nil {
+		t.Error("expected a tampered payload to fail")
+	}
+	if err := verifySignature("RS256", &key.PublicKey, "header.payload", signature); err == nil {
+		t.Error("expected RS256 to fail with an ECDSA key")
+	}
+}
+
+func TestClaimStrings(t *testing.T) {
+	claims := map[string]any{"scope": "openid editor", "groups": []any{"a", 1, "b"}}
+	if got := claimStrings(claims, "scope"); len(got) != 2 || got[1] != "editor" {
+		t.Errorf("unexpected scope %v", got)
+	}
+	if got := claimStrings(claims, "groups"); len(got) != 2 || got[1] != "b" {
+		t.Errorf("unexpected groups %v", got)
+	}
+	if got := claimStrings(claims, "missing.roles"); got != nil {
+		t.Errorf("expected nothing, got %v", got)
+	}
+}
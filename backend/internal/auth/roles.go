@@ -0,0 +1,31 @@
+package auth
+
+import "strings"
+
+// Roles of callers, each allowed what the ones before it are
+const (
+	RoleViewer = "viewer" // reading repositories, graphs, wikis and search
+	RoleEditor = "editor" // adding, reindexing, uploading and deleting
+	RoleAdmin  = "admin"  // the admin API, including provisioning keys
+)
+
+var roleRanks = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleAdmin: 3}
+
+// Allows reports whether a caller of role may do what needs required
+func Allows(role, required string) bool {
+	rank := roleRanks[role]
+	return rank > 0 && rank >= roleRanks[required]
+}
+
+// HighestRole returns the most privileged of names that is a role, compared
+// case-insensitively, or "" when none is
+func HighestRole(names []string) string {
+	highest := ""
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if roleRanks[name] > roleRanks[highest] {
+			highest = name
+		}
+	}
+	return highest
+}
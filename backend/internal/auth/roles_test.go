@@ -0,0 +1,32 @@
+package auth
+
+import "testing"
+
+func TestAllows(t *testing.T) {
+	cases := []struct {
+		role, required string
+		want           bool
+	}{
+		{RoleViewer, RoleViewer, true},
+		{RoleViewer, RoleEditor, false},
+		{RoleEditor, RoleViewer, true},
+		{RoleEditor, RoleAdmin, false},
+		{RoleAdmin, RoleEditor, true},
+		{"", RoleViewer, false},
+		{"owner", RoleViewer, false},
+	}
+	for _, tc := range cases {
+		if got := Allows(tc.role, tc.required); got != tc.want {
+			t.Errorf("Allows(%q, %q) = %v, want %v", tc.role, tc.required, got, tc.want)
+		}
+	}
+}
+
+func TestHighestRole(t *testing.T) {
+	if got := HighestRole([]string{"offline_access", "Editor", "viewer"}); got != RoleEditor {
+		t.Errorf("expected editor, got %q", got)
+	}
+	if got := HighestRole([]string{"offline_access"}); got != "" {
+		t.Errorf("expected no role, got %q", got)
+	}
+}
@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Cache holds encoded API responses per repository so repeated reads skip
+// the graph queries behind them. Entries live until the repository is
+// invalidated, which happens whenever its graph or wiki changes, or until
+// they are older than the cache's TTL, if it has one. Once the responses
+// outgrow the cache's size, the least recently used are evicted.
+type Cache struct {
+	mu       sync.Mutex
+	repos    map[string]map[string]*list.Element // values are *entry
+	lru      *list.List                          // most recently used first
+	size     int                                 // bytes of the cached responses
+	maxBytes int
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+type entry struct {
+	repoID string
+	key    string
+	data   []byte
+	etag   string
+	stored time.Time
+}
+
+// New creates an empty cache of up to maxBytes of responses, unbounded
+// with 0, whose entries expire after ttl, or only when invalidated or
+// evicted with a ttl of 0
+func New(ttl time.Duration, maxBytes int) *Cache {
+	return &Cache{
+		repos:    make(map[string]map[string]*list.Element),
+		lru:      list.New(),
+		maxBytes: maxBytes,
+		ttl:      ttl,
+		now:      time.Now,
+	}
+}
+
+// Get returns the cached response for key, if present and not expired
+func (c *Cache) Get(repoID, key string) ([]byte, bool) {
+	data, _, ok := c.GetTagged(repoID, key)
+	return data, ok
+}
+
+// GetTagged returns the cached response for key with its ETag, if present
+// and not expired. Expired entries are dropped.
+func (c *Cache) GetTagged(repoID, key string) ([]byte, string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.repos[repoID][key]
+	if !ok {
+		return nil, "", false
+	}
+	e := el.Value.(*entry)
+	if c.expired(e) {
+		c.remove(el)
+		return nil, "", false
+	}
+	c.lru.MoveToFront(el)
+	return e.data, e.etag, true
+}
+
+// Set stores the response for key and returns its ETag. Responses larger
+// than the whole cache are not stored.
+func (c *Cache) Set(repoID, key string, data []byte) string {
+	etag := ETag(data)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.repos[repoID][key]; ok {
+		c.remove(el)
+	}
+	if c.maxBytes > 0 && len(data) > c.maxBytes {
+		return etag
+	}
+
+	entries, ok := c.repos[repoID]
+	if !ok {
+		entries = make(map[string]*list.Element)
+		c.repos[repoID] = entries
+	}
+	entries[key] = c.lru.PushFront(&entry{repoID: repoID, key: key, data: data, etag: etag, stored: c.now()})
+	c.size += len(data)
+
+	// Evict the least recently used entries over the size, and those that
+	// expired without being read again
+	for back := c.lru.Back(); back != nil; back = c.lru.Back() {
+		if !c.expired(back.Value.(*entry)) && (c.maxBytes == 0 || c.size <= c.maxBytes) {
+			break
+		}
+		c.remove(back)
+	}
+	return etag
+}
+
+// Delete drops a single cached response
+func (c *Cache) Delete(repoID, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.repos[repoID][key]; ok {
+		c.remove(el)
+	}
+}
+
+// Invalidate drops every cached response of a repository
+func (c *Cache) Invalidate(repoID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, el := range c.repos[repoID] {
+		c.remove(el)
+	}
+}
+
+// expired reports whether an entry outlived the TTL
+func (c *Cache) expired(e *entry) bool {
+	return c.ttl > 0 && c.now().Sub(e.stored) > c.ttl
+}
+
+// remove drops an entry, and its repository once it holds no other
+func (c *Cache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*entry)
+	c.size -= len(e.data)
+	delete(c.repos[e.repoID], e.key)
+	if len(c.repos[e.repoID]) == 0 {
+		delete(c.repos, e.repoID)
+	}
+}
+
+// ETag is the strong entity tag of a response: a quoted digest of its
+// bytes, which are the same as long as the graph or wiki they were
+// computed from hasn't changed
+func ETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	c := New(0, 0)
+
+	if _, ok := c.Get("repo-1", "files"); ok {
+		t.Fatal("empty cache returned an entry")
+	}
+
+	c.Set("repo-1", "files", []byte(`[]`))
+	c.Set("repo-1", "graph:calls", []byte(`{}`))
+	c.Set("repo-2", "files", []byte(`[1]`))
+
+	if data, ok := c.Get("repo-1", "files"); !ok || string(data) != `[]` {
+		t.Errorf("Get(repo-1, files) = %q, %v", data, ok)
+	}
+
+	c.Delete("repo-1", "files")
+	if _, ok := c.Get("repo-1", "files"); ok {
+		t.Error("deleted entry still cached")
+	}
+	if _, ok := c.Get("repo-1", "graph:calls"); !ok {
+		t.Error("Delete dropped an unrelated entry")
+	}
+
+	c.Invalidate("repo-1")
+	if _, ok := c.Get("repo-1", "graph:calls"); ok {
+		t.Error("invalidated repository still cached")
+	}
+	if _, ok := c.Get("repo-2", "files"); !ok {
+		t.Error("Invalidate dropped another repository")
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	c := New(time.Minute, 0)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	c.Set("repo-1", "files", []byte(`[]`))
+	now = now.Add(59 * time.Second)
+	if _, ok := c.Get("repo-1", "files"); !ok {
+		t.Error("entry expired before its TTL")
+	}
+
+	now = now.Add(2 * time.Second)
+	if _, ok := c.Get("repo-1", "files"); ok {
+		t.Error("entry served after its TTL")
+	}
+
+	c.Set("repo-1", "files", []byte(`[1]`))
+	if data, ok := c.Get("repo-1", "files"); !ok || string(data) != `[1]` {
+		t.Errorf("refreshed entry = %q, %v", data, ok)
+	}
+
+	// Expired entries nobody reads again are dropped by later writes
+	c.Set("repo-2", "files", []byte(`[2]`))
+	now = now.Add(2 * time.Minute)
+	c.Set("repo-3", "files", []byte(`[3]`))
+	if _, ok := c.repos["repo-2"]; ok || c.size != 3 {
+		t.Errorf("expired entries kept: %d bytes cached", c.size)
+	}
+}
+
+func TestCacheMaxBytes(t *testing.T) {
+	c := New(0, 10)
+
+	c.Set("repo-1", "a", []byte(`1234`))
+	c.Set("repo-1", "b", []byte(`1234`))
+	c.Get("repo-1", "a") // b is now the least recently used
+	c.Set("repo-2", "c", []byte(`1234`))
+
+	if _, ok := c.Get("repo-1", "b"); ok {
+		t.Error("least recently used entry not evicted")
+	}
+	for _, key := range [][2]string{{"repo-1", "a"}, {"repo-2", "c"}} {
+		if _, ok := c.Get(key[0], key[1]); !ok {
+			t.Errorf("%s %s evicted", key[0], key[1])
+		}
+	}
+	if c.size != 8 {
+		t.Errorf("size = %d, want 8", c.size)
+	}
+
+	c.Set("repo-1", "big", []byte(`12345678901`))
+	if _, ok := c.Get("repo-1", "big"); ok || c.size != 8 {
+		t.Error("response larger than the cache stored")
+	}
+
+	c.Invalidate("repo-1")
+	c.Delete("repo-2", "c")
+	if c.size != 0 || len(c.repos) != 0 {
+		t.Errorf("emptied cache holds %d bytes of %d repositories", c.size, len(c.repos))
+	}
+}
+
+func TestCacheETag(t *testing.T) {
+	c := New(0, 0)
+
+	etag := c.Set("repo-1", "graph:calls", []byte(`{"nodes":[]}`))
+	if data, got, ok := c.GetTagged("repo-1", "graph:calls"); !ok || got != etag || string(data) != `{"nodes":[]}` {
+		t.Errorf("GetTagged = %q, %q, %v; want ETag %q", data, got, ok, etag)
+	}
+	if etag != ETag([]byte(`{"nodes":[]}`)) {
+		t.Error("ETag of the same response differs")
+	}
+
+	if changed := c.Set("repo-1", "graph:calls", []byte(`{"nodes":[1]}`)); changed == etag {
+		t.Error("ETag unchanged for a different response")
+	}
+}
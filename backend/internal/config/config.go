@@ -2,6 +2,9 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -12,6 +15,180 @@ type Config struct {
 	TEI_URL   string
 	ReposPath string
 	AgentURL  string
+
+	// EmbeddingModel and EmbeddingDimension describe the vectors TEI_URL
+	// serves; a dimension of 0 is detected from the model at startup.
+	// Changing them re-embeds every entity into a new vector index
+	// while search stays on the old one, which needs the old model served
+	// at TEIPreviousURL; without it search moves to the new index at once
+	// and covers only what has been re-embedded so far
+	EmbeddingModel     string
+	EmbeddingDimension int
+	TEIPreviousURL     string
+
+	// EmbeddingsProvider is tei, served at TEI_URL, openai for an
+	// OpenAI-compatible API (OpenAI, Azure OpenAI, vLLM) or ollama for a
+	// local Ollama server. The latter two are reached at EmbeddingsURL,
+	// empty for their default, and sent EmbeddingModel; EmbeddingsAPIKey
+	// authenticates to OpenAI-compatible APIs
+	EmbeddingsProvider string
+	EmbeddingsURL      string
+	EmbeddingsAPIKey   string
+
+	// EmbeddingReduction shortens the model's vectors to
+	// EmbeddingReducedDimension before they are stored and searched:
+	// truncate for models trained to front-load dimensions, projection for
+	// any other. Empty stores them whole; changing either re-embeds every
+	// entity like a model change
+	EmbeddingReduction        string
+	EmbeddingReducedDimension int
+
+	// EmbeddingAttempts bounds how often an embedding request is sent while
+	// the service is unreachable, overloaded or rate limiting, backing off
+	// between attempts; EmbeddingConcurrency caps the requests in flight
+	EmbeddingAttempts    int
+	EmbeddingConcurrency int
+
+	// EntityDescriptions has the agent describe each function, method and
+	// class in a sentence while indexing, embedded with its signature; it
+	// sends every entity to the agent's model on each index
+	EntityDescriptions bool
+
+	// SearchAnalytics logs each search, with its result count and the
+	// results opened, for review through the admin API
+	SearchAnalytics bool
+
+	// Neo4jWriteAttempts bounds how often a write transaction is run while
+	// it keeps failing with transient errors
+	Neo4jWriteAttempts int
+
+	// Neo4jDatabase holds repositories, runs and wikis, and the code graph
+	// unless Neo4jDatabasePerRepo places each repository's graph in its own
+	// database (Neo4j Enterprise only); empty uses the server's default
+	Neo4jDatabase        string
+	Neo4jDatabasePerRepo bool
+
+	// Neo4jDialect is the Cypher flavor of the Bolt database: neo4j or
+	// memgraph
+	Neo4jDialect string
+
+	// APIKeys are `key:scope` entries, scope being read, write or admin,
+	// which act with the viewer, editor and admin roles
+	APIKeys []string
+
+	// OIDCIssuer enables bearer tokens of an OpenID Connect provider, whose
+	// signing keys are discovered from it unless OIDCJWKSURL names them.
+	// Tokens must be meant for OIDCAudience when set and carry viewer,
+	// editor or admin in OIDCRolesClaim, a dotted path such as
+	// realm_access.roles. Callers are scoped to the organization whose ID
+	// is in OIDCOrgClaim when set; those whose tokens lack it are not.
+	OIDCIssuer     string
+	OIDCJWKSURL    string
+	OIDCAudience   string
+	OIDCRolesClaim string
+	OIDCOrgClaim   string
+
+	// Once API keys or OIDC are configured, requests changing anything need
+	// the editor role and the admin API the admin role; reads stay open
+	// unless AuthRequiredForReads
+	AuthRequiredForReads bool
+
+	// HTTP server hardening
+	BodyLimit       int           // max request body in bytes
+	UploadBodyLimit int           // max body of imports and trace, coverage and SARIF uploads
+	ReadTimeout     time.Duration // 0 disables
+	WriteTimeout    time.Duration // 0 disables
+	IdleTimeout     time.Duration // 0 falls back to ReadTimeout
+	TrustedProxies  []string      // IPs or CIDRs allowed to set ProxyHeader
+	ProxyHeader     string        // header holding the client IP behind a proxy
+	TLSCertFile     string        // serve HTTPS when set together with TLSKeyFile
+	TLSKeyFile      string
+	HSTSMaxAge      int // Strict-Transport-Security of HTTPS responses in seconds; 0 disables
+
+	// Origins of browsers allowed to call the API, * for any, and whether
+	// they may send cookies and HTTP authentication, which needs the
+	// origins listed
+	CORSAllowedOrigins   []string
+	CORSAllowCredentials bool
+
+	// Per-route timeouts of expensive reads; 0 disables
+	GraphTimeout  time.Duration // graph, file tree and system graph
+	SearchTimeout time.Duration // global and repository search
+	NodeTimeout   time.Duration // node detail
+
+	// HealthCheckTimeout bounds each dependency check of /health/ready
+	HealthCheckTimeout time.Duration
+
+	// Token bucket rate limits per client (API key, token subject or IP) of
+	// the search and graph endpoints, which embed queries and run heavy
+	// Cypher: requests a minute, sent in bursts of up to the burst. A limit
+	// of 0 disables it
+	SearchRateLimit int
+	SearchRateBurst int
+	GraphRateLimit  int
+	GraphRateBurst  int
+
+	// GraphMaxNodes caps the nodes of one graph response; larger graphs are
+	// served in pages. 0 disables the cap
+	GraphMaxNodes int
+
+	// GraphClusterNodes is the size above which a whole graph is served
+	// folded into directory clusters rather than paged. 0 disables it
+	GraphClusterNodes int
+
+	// WorkerConcurrency is how many index and wiki jobs run at once; it can
+	// be changed at runtime through the admin API
+	WorkerConcurrency int
+
+	// ReindexInterval enables scheduled reindexing of all repositories when non-zero
+	ReindexInterval time.Duration
+
+	// MaxEntityContentBytes caps the source stored on each entity node; 0
+	// disables storing source
+	MaxEntityContentBytes int
+
+	// GitHistoryDepth is how many recent commits are indexed as Commit and
+	// Author nodes; 0 disables history
+	GitHistoryDepth int
+
+	// GitHubToken authenticates calls to the GitHub API at GitHubAPIURL,
+	// which list the repositories of organizations registered in bulk; it
+	// is needed for private repositories
+	GitHubToken  string
+	GitHubAPIURL string
+
+	// GitHubWebhookSecret signs the push deliveries of GitHub webhooks,
+	// which reindex repositories pushed to; empty disables the receiver
+	GitHubWebhookSecret string
+
+	// WikiWebhookURL receives a POST listing the wiki pages a scheduled
+	// reindex made stale; empty disables the notification
+	WikiWebhookURL string
+
+	// LanguageServers are `language=command` entries, such as go=gopls, run
+	// while indexing to resolve calls and implementations precisely; calls
+	// of other languages are resolved by name. LanguageServerTimeout bounds
+	// each request to a server
+	LanguageServers       []string
+	LanguageServerTimeout time.Duration
+
+	// DuplicateThreshold is the cosine similarity of their embeddings at
+	// which two functions are reported as possible duplicates
+	DuplicateThreshold float64
+
+	// ArtifactsPath stores the SBOM/graph export of each index run
+	ArtifactsPath string
+
+	// WarmupAfterIndex precomputes cached graph, file tree and wiki
+	// navigation responses once a repository finishes indexing
+	WarmupAfterIndex bool
+
+	// CacheTTL expires cached graph, file tree and wiki navigation
+	// responses; 0 keeps them until the repository changes
+	CacheTTL time.Duration
+	// CacheMaxBytes bounds the cached responses, evicting the least
+	// recently used; 0 leaves them unbounded
+	CacheMaxBytes int
 }
 
 func Load() *Config {
@@ -20,9 +197,78 @@ func Load() *Config {
 		Neo4jURI:  getEnv("NEO4J_URI", "bolt://localhost:7687"),
 		Neo4jUser: getEnv("NEO4J_USER", "neo4j"),
 		Neo4jPass: getEnv("NEO4J_PASSWORD", "neograph_password"),
-		TEI_URL:   getEnv("TEI_URL", "http://localhost:8080"),
+		TEI_URL:   getEnv("TEI_URL", ""), // unset disables semantic search
 		ReposPath: getEnv("REPOS_PATH", "./repos"),
 		AgentURL:  getEnv("AGENT_URL", "http://localhost:8001"),
+
+		EmbeddingModel:     getEnv("EMBEDDING_MODEL", "Qodo/Qodo-Embed-1-1.5B"),
+		EmbeddingDimension: getEnvInt("EMBEDDING_DIMENSION", 0),
+		TEIPreviousURL:     getEnv("TEI_PREVIOUS_URL", ""),
+		EmbeddingsProvider: getEnv("EMBEDDINGS_PROVIDER", "tei"),
+		EmbeddingsURL:      getEnv("EMBEDDINGS_URL", ""),
+		EmbeddingsAPIKey:   getEnv("EMBEDDINGS_API_KEY", ""),
+
+		EmbeddingReduction:        getEnv("EMBEDDING_REDUCTION", ""),
+		EmbeddingReducedDimension: getEnvInt("EMBEDDING_REDUCED_DIMENSION", 256),
+
+		EmbeddingAttempts:    getEnvInt("EMBEDDING_ATTEMPTS", 4),
+		EmbeddingConcurrency: getEnvInt("EMBEDDING_CONCURRENCY", 4),
+		EntityDescriptions:   getEnvBool("ENTITY_DESCRIPTIONS", false),
+		SearchAnalytics:      getEnvBool("SEARCH_ANALYTICS", false),
+
+		Neo4jWriteAttempts:   getEnvInt("NEO4J_WRITE_ATTEMPTS", 3),
+		Neo4jDatabase:        getEnv("NEO4J_DATABASE", ""),
+		Neo4jDatabasePerRepo: getEnvBool("NEO4J_DATABASE_PER_REPO", false),
+		Neo4jDialect:         getEnv("NEO4J_DIALECT", "neo4j"),
+
+		APIKeys:              getEnvList("API_KEYS"),
+		OIDCIssuer:           getEnv("OIDC_ISSUER", ""),
+		OIDCJWKSURL:          getEnv("OIDC_JWKS_URL", ""),
+		OIDCAudience:         getEnv("OIDC_AUDIENCE", ""),
+		OIDCRolesClaim:       getEnv("OIDC_ROLES_CLAIM", "roles"),
+		OIDCOrgClaim:         getEnv("OIDC_ORG_CLAIM", ""),
+		AuthRequiredForReads: getEnvBool("AUTH_REQUIRED_FOR_READS", false),
+
+		BodyLimit:       getEnvInt("BODY_LIMIT", 4*1024*1024),
+		UploadBodyLimit: getEnvInt("UPLOAD_BODY_LIMIT", 1024*1024*1024),
+		ReadTimeout:     getEnvDuration("READ_TIMEOUT", 30*time.Second),
+		WriteTimeout:    getEnvDuration("WRITE_TIMEOUT", 0),
+		IdleTimeout:     getEnvDuration("IDLE_TIMEOUT", 120*time.Second),
+		TrustedProxies:  getEnvList("TRUSTED_PROXIES"),
+		ProxyHeader:     getEnv("PROXY_HEADER", "X-Forwarded-For"),
+		TLSCertFile:     getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:      getEnv("TLS_KEY_FILE", ""),
+		HSTSMaxAge:      getEnvInt("HSTS_MAX_AGE", 0),
+
+		CORSAllowedOrigins:   getEnvListOr("CORS_ALLOWED_ORIGINS", "http://localhost:5173"),
+		CORSAllowCredentials: getEnvBool("CORS_ALLOW_CREDENTIALS", false),
+
+		GraphTimeout:      getEnvDuration("GRAPH_TIMEOUT", 30*time.Second),
+		SearchTimeout:     getEnvDuration("SEARCH_TIMEOUT", 15*time.Second),
+		NodeTimeout:       getEnvDuration("NODE_TIMEOUT", 10*time.Second),
+		SearchRateLimit:   getEnvInt("SEARCH_RATE_LIMIT", 0),
+		SearchRateBurst:   getEnvInt("SEARCH_RATE_BURST", 10),
+		GraphRateLimit:    getEnvInt("GRAPH_RATE_LIMIT", 0),
+		GraphRateBurst:    getEnvInt("GRAPH_RATE_BURST", 20),
+		GraphMaxNodes:     getEnvInt("GRAPH_MAX_NODES", 5000),
+		GraphClusterNodes: getEnvInt("GRAPH_CLUSTER_NODES", 5000),
+
+		ReindexInterval:       getEnvDuration("REINDEX_INTERVAL", 0),
+		WorkerConcurrency:     getEnvInt("WORKER_CONCURRENCY", 2),
+		MaxEntityContentBytes: getEnvInt("MAX_ENTITY_CONTENT_BYTES", 16*1024),
+		WarmupAfterIndex:      getEnvBool("WARMUP_AFTER_INDEX", false),
+		CacheTTL:              getEnvDuration("CACHE_TTL", 0),
+		CacheMaxBytes:         getEnvInt("CACHE_MAX_BYTES", 256*1024*1024),
+		ArtifactsPath:         getEnv("ARTIFACTS_PATH", "./artifacts"),
+		GitHistoryDepth:       getEnvInt("GIT_HISTORY_DEPTH", 100),
+		WikiWebhookURL:        getEnv("WIKI_WEBHOOK_URL", ""),
+		GitHubToken:           getEnv("GITHUB_TOKEN", ""),
+		GitHubAPIURL:          getEnv("GITHUB_API_URL", "https://api.github.com"),
+		GitHubWebhookSecret:   getEnv("GITHUB_WEBHOOK_SECRET", ""),
+		LanguageServers:       getEnvList("LANGUAGE_SERVERS"),
+		LanguageServerTimeout: getEnvDuration("LANGUAGE_SERVER_TIMEOUT", 30*time.Second),
+		DuplicateThreshold:    getEnvFloat("DUPLICATE_THRESHOLD", 0.95),
+		HealthCheckTimeout:    getEnvDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
 	}
 }
 
@@ -32,3 +278,59 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value, ok := os.LookupEnv(key); ok {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	if value, ok := os.LookupEnv(key); ok {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	if value, ok := os.LookupEnv(key); ok {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return fallback
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	if value, ok := os.LookupEnv(key); ok {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return fallback
+}
+
+// getEnvList reads a comma-separated list, skipping empty items
+func getEnvList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvListOr reads a comma-separated list like getEnvList, falling back
+// to fallback when it is unset or empty
+func getEnvListOr(key string, fallback ...string) []string {
+	if items := getEnvList(key); len(items) > 0 {
+		return items
+	}
+	return fallback
+}
@@ -0,0 +1,74 @@
+// Package coverage reads test coverage reports and maps the lines they cover
+// onto indexed files and code entities, so the graph can show which central
+// code is untested.
+package coverage
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Formats accepted by Parse
+const (
+	FormatGo        = "go" // go test -coverprofile
+	FormatLCOV      = "lcov"
+	FormatCobertura = "cobertura"
+)
+
+// Lines maps each instrumented line of a file to the number of times it ran
+type Lines map[int]int64
+
+// Report holds the instrumented lines of every file a report covers, keyed
+// by the path as the report wrote it
+type Report map[string]Lines
+
+// add records hits for a line, adding up lines reported more than once
+func (r Report) add(file string, line int, hits int64) {
+	if file == "" || line <= 0 {
+		return
+	}
+	lines, ok := r[file]
+	if !ok {
+		lines = make(Lines)
+		r[file] = lines
+	}
+	lines[line] += hits
+}
+
+// DetectFormat guesses the format of a report from how it starts
+func DetectFormat(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("mode:")):
+		return FormatGo
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return FormatCobertura
+	default:
+		return FormatLCOV
+	}
+}
+
+// Parse reads a coverage report in the given format, detecting it when
+// format is empty, and returns the format used
+func Parse(data []byte, format string) (Report, string, error) {
+	if format == "" {
+		format = DetectFormat(data)
+	}
+
+	var report Report
+	var err error
+	switch format {
+	case FormatGo:
+		report, err = ParseGo(data)
+	case FormatLCOV:
+		report, err = ParseLCOV(data)
+	case FormatCobertura:
+		report, err = ParseCobertura(data)
+	default:
+		return nil, format, fmt.Errorf("unsupported format %q, must be %q, %q or %q", format, FormatGo, FormatLCOV, FormatCobertura)
+	}
+	if err == nil && len(report) == 0 {
+		err = fmt.Errorf("no covered files found in %s report", format)
+	}
+	return report, format, err
+}
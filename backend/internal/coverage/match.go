@@ -0,0 +1,123 @@
+package coverage
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// Stats is the line coverage of a file or entity
+type Stats struct {
+	Covered int // instrumented lines that ran
+	Lines   int // instrumented lines
+}
+
+// Percent is the share of instrumented lines that ran, 0 to 100
+func (s Stats) Percent() float64 {
+	if s.Lines == 0 {
+		return 0
+	}
+	return 100 * float64(s.Covered) / float64(s.Lines)
+}
+
+// FileStats is the coverage of one repository file
+type FileStats struct {
+	Path string
+	Stats
+}
+
+// EntityStats is the coverage of the lines of one entity
+type EntityStats struct {
+	EntityID string
+	Stats
+}
+
+// Match maps a report onto a repository's files and the entities declared
+// in them. Report paths are matched to repository paths by suffix, as
+// reports name files by absolute path, import path or relative to a source
+// root. Entities without instrumented lines, such as type declarations, get
+// no stats. The report paths no repository file was found for are returned
+// sorted.
+func Match(report Report, files []string, entities []models.CodeEntity) ([]FileStats, []EntityStats, []string) {
+	resolved := make(map[string]Lines)
+	var unmatched []string
+	for reportPath, lines := range report {
+		file := ResolveFile(reportPath, files)
+		if file == "" {
+			unmatched = append(unmatched, reportPath)
+			continue
+		}
+		if resolved[file] == nil {
+			resolved[file] = make(Lines)
+		}
+		for line, hits := range lines {
+			resolved[file][line] += hits
+		}
+	}
+	sort.Strings(unmatched)
+
+	fileStats := make([]FileStats, 0, len(resolved))
+	for file, lines := range resolved {
+		fileStats = append(fileStats, FileStats{Path: file, Stats: lineStats(lines, 0, 0)})
+	}
+	sort.Slice(fileStats, func(i, j int) bool { return fileStats[i].Path < fileStats[j].Path })
+
+	var entityStats []EntityStats
+	for _, entity := range entities {
+		lines, ok := resolved[entity.FilePath]
+		if !ok || entity.ID == "" || entity.StartLine <= 0 {
+			continue
+		}
+		if stats := lineStats(lines, entity.StartLine, entity.EndLine); stats.Lines > 0 {
+			entityStats = append(entityStats, EntityStats{EntityID: entity.ID, Stats: stats})
+		}
+	}
+	return fileStats, entityStats, unmatched
+}
+
+// lineStats counts the instrumented and covered lines from start to end,
+// or of the whole file when start is 0
+func lineStats(lines Lines, start, end int) Stats {
+	var stats Stats
+	for line, hits := range lines {
+		if start > 0 && (line < start || line > end) {
+			continue
+		}
+		stats.Lines++
+		if hits > 0 {
+			stats.Covered++
+		}
+	}
+	return stats
+}
+
+// ResolveFile finds the repository file a path from a report refers to, or
+// "" if none. A report path ending in a repository path matches the longest
+// such path; a report path the repository path ends in has to identify a
+// single file.
+func ResolveFile(reportPath string, files []string) string {
+	reportPath = strings.ReplaceAll(reportPath, "\\", "/")
+
+	var longest string
+	var within []string
+	for _, file := range files {
+		switch {
+		case file == reportPath:
+			return file
+		case strings.HasSuffix(reportPath, "/"+file):
+			if len(file) > len(longest) {
+				longest = file
+			}
+		case strings.HasSuffix(file, "/"+reportPath):
+			within = append(within, file)
+		}
+	}
+	if longest != "" {
+		return longest
+	}
+	if len(within) == 1 {
+		return within[0]
+	}
+	return ""
+}
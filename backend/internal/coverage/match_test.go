@@ -0,0 +1,68 @@
+package coverage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+func TestResolveFile(t *testing.T) {
+	files := []string{"main.go", "internal/store/store.go", "store/store.go", "a/util.py", "b/util.py"}
+
+	tests := []struct {
+		reportPath string
+		want       string
+	}{
+		{"main.go", "main.go"},
+		{"github.com/acme/app/internal/store/store.go", "internal/store/store.go"},
+		{"/home/ci/app/store/store.go", "store/store.go"},
+		{"C:\\ci\\app\\main.go", "main.go"},
+		{"store.go", ""},   // ambiguous
+		{"util.py", ""},    // ambiguous
+		{"other/x.go", ""}, // not in the repository
+	}
+
+	for _, tt := range tests {
+		if got := ResolveFile(tt.reportPath, files); got != tt.want {
+			t.Errorf("ResolveFile(%q) = %q, want %q", tt.reportPath, got, tt.want)
+		}
+	}
+
+	if got := ResolveFile("services/users.py", []string{"backend/app/services/users.py"}); got != "backend/app/services/users.py" {
+		t.Errorf("ResolveFile(relative) = %q", got)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	report := Report{
+		"github.com/acme/app/store/store.go": {10: 3, 11: 0, 12: 1, 20: 0, 21: 0},
+		"vendor/lib.go":                      {1: 1},
+	}
+	entities := []models.CodeEntity{
+		{ID: "Open", Name: "Open", FilePath: "store/store.go", StartLine: 9, EndLine: 13},
+		{ID: "Close", Name: "Close", FilePath: "store/store.go", StartLine: 19, EndLine: 22},
+		{ID: "Store", Name: "Store", FilePath: "store/store.go", StartLine: 3, EndLine: 6},
+		{ID: "main", Name: "main", FilePath: "main.go", StartLine: 1, EndLine: 5},
+	}
+
+	files, stats, unmatched := Match(report, []string{"main.go", "store/store.go"}, entities)
+
+	wantFiles := []FileStats{{Path: "store/store.go", Stats: Stats{Covered: 2, Lines: 5}}}
+	if !reflect.DeepEqual(files, wantFiles) {
+		t.Errorf("files = %+v, want %+v", files, wantFiles)
+	}
+	wantStats := []EntityStats{
+		{EntityID: "Open", Stats: Stats{Covered: 2, Lines: 3}},
+		{EntityID: "Close", Stats: Stats{Covered: 0, Lines: 2}},
+	}
+	if !reflect.DeepEqual(stats, wantStats) {
+		t.Errorf("stats = %+v, want %+v", stats, wantStats)
+	}
+	if !reflect.DeepEqual(unmatched, []string{"vendor/lib.go"}) {
+		t.Errorf("unmatched = %v", unmatched)
+	}
+	if pct := stats[0].Percent(); pct < 66.6 || pct > 66.7 {
+		t.Errorf("Percent() = %v", pct)
+	}
+}
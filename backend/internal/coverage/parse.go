@@ -0,0 +1,112 @@
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ParseGo reads a Go coverprofile. Each block marks every line it spans;
+// blocks written more than once, as in merged profiles, add up.
+func ParseGo(data []byte) (Report, error) {
+	report := make(Report)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+
+		// file.go:startLine.startCol,endLine.endCol numStatements count
+		colon := strings.LastIndex(line, ":")
+		if colon < 0 {
+			return nil, fmt.Errorf("line %d: invalid coverprofile block %q", n, line)
+		}
+		fields := strings.Fields(line[colon+1:])
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: invalid coverprofile block %q", n, line)
+		}
+		start, end, ok := strings.Cut(fields[0], ",")
+		startLine, err1 := strconv.Atoi(strings.SplitN(start, ".", 2)[0])
+		endLine, err2 := strconv.Atoi(strings.SplitN(end, ".", 2)[0])
+		count, err3 := strconv.ParseInt(fields[2], 10, 64)
+		if !ok || err1 != nil || err2 != nil || err3 != nil {
+			return nil, fmt.Errorf("line %d: invalid coverprofile block %q", n, line)
+		}
+
+		for l := startLine; l <= endLine; l++ {
+			report.add(line[:colon], l, count)
+		}
+	}
+	return report, scanner.Err()
+}
+
+// ParseLCOV reads an lcov tracefile, using its DA (line hit) records
+func ParseLCOV(data []byte) (Report, error) {
+	report := make(Report)
+	var file string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		key, value, _ := strings.Cut(line, ":")
+		switch key {
+		case "SF":
+			file = value
+		case "end_of_record":
+			file = ""
+		case "DA":
+			// DA:line,hits[,checksum]
+			fields := strings.Split(value, ",")
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: invalid DA record %q", n, line)
+			}
+			lineNo, err1 := strconv.Atoi(fields[0])
+			hits, err2 := strconv.ParseInt(fields[1], 10, 64)
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("line %d: invalid DA record %q", n, line)
+			}
+			report.add(file, lineNo, hits)
+		}
+	}
+	return report, scanner.Err()
+}
+
+// coberturaReport is the part of a Cobertura XML report that is read
+type coberturaReport struct {
+	Packages []struct {
+		Classes []struct {
+			Filename string `xml:"filename,attr"`
+			Lines    []struct {
+				Number int   `xml:"number,attr"`
+				Hits   int64 `xml:"hits,attr"`
+			} `xml:"lines>line"`
+		} `xml:"classes>class"`
+	} `xml:"packages>package"`
+}
+
+// ParseCobertura reads a Cobertura XML report. Filenames are kept relative,
+// since the sources usually name directories on the machine that ran the
+// tests; Match resolves them by suffix.
+func ParseCobertura(data []byte) (Report, error) {
+	var doc coberturaReport
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid Cobertura XML: %w", err)
+	}
+
+	report := make(Report)
+	for _, pkg := range doc.Packages {
+		for _, class := range pkg.Classes {
+			file := path.Clean(strings.ReplaceAll(class.Filename, "\\", "/"))
+			for _, line := range class.Lines {
+				report.add(file, line.Number, line.Hits)
+			}
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,96 @@
+package coverage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGo(t *testing.T) {
+	profile := `mode: count
+github.com/acme/app/store/store.go:10.20,12.3 2 4
+github.com/acme/app/store/store.go:14.2,14.15 1 0
+github.com/acme/app/store/store.go:10.20,12.3 2 1
+`
+	report, format, err := Parse([]byte(profile), "")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if format != FormatGo {
+		t.Errorf("format = %q, want %q", format, FormatGo)
+	}
+
+	want := Report{"github.com/acme/app/store/store.go": {10: 5, 11: 5, 12: 5, 14: 0}}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("report = %v, want %v", report, want)
+	}
+
+	if _, err := ParseGo([]byte("mode: set\nstore.go:10.20 2 4\n")); err == nil {
+		t.Error("expected an error for a malformed block")
+	}
+}
+
+func TestParseLCOV(t *testing.T) {
+	tracefile := `TN:
+SF:src/app.js
+FN:3,main
+DA:3,1
+DA:4,0,abc123
+end_of_record
+SF:src/util.js
+DA:1,7
+end_of_record
+`
+	report, format, err := Parse([]byte(tracefile), "")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if format != FormatLCOV {
+		t.Errorf("format = %q, want %q", format, FormatLCOV)
+	}
+
+	want := Report{"src/app.js": {3: 1, 4: 0}, "src/util.js": {1: 7}}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("report = %v, want %v", report, want)
+	}
+}
+
+func TestParseCobertura(t *testing.T) {
+	xml := `<?xml version="1.0" ?>
+<coverage line-rate="0.5">
+  <sources><source>/home/ci/app</source></sources>
+  <packages>
+    <package name="app.services">
+      <classes>
+        <class name="users.py" filename="app/services/users.py">
+          <methods/>
+          <lines>
+            <line number="1" hits="1"/>
+            <line number="2" hits="0"/>
+          </lines>
+        </class>
+      </classes>
+    </package>
+  </packages>
+</coverage>`
+	report, format, err := Parse([]byte(xml), "")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if format != FormatCobertura {
+		t.Errorf("format = %q, want %q", format, FormatCobertura)
+	}
+
+	want := Report{"app/services/users.py": {1: 1, 2: 0}}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("report = %v, want %v", report, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, _, err := Parse([]byte("TN:\n"), ""); err == nil {
+		t.Error("expected an error for a report without files")
+	}
+	if _, _, err := Parse([]byte("mode: set\n"), "jacoco"); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
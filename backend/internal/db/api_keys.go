@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// CreateAPIKey stores a provisioned key as an ApiKey node, by the hash of
+// its secret and with the organization it is scoped to, if any
+func CreateAPIKey(ctx context.Context, client *Neo4jClient, key *models.APIKey, hash string) error {
+	key.ID = uuid.New().String()
+	key.CreatedAt = time.Now().UTC()
+
+	_, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			CREATE (:ApiKey {
+				id: $id,
+				name: $name,
+				scope: $scope,
+				prefix: $prefix,
+				hash: $hash,
+				createdAt: $createdAt,
+				orgId: $orgId
+			})
+		`
+		_, err := tx.Run(ctx, query, map[string]any{
+			"id":        key.ID,
+			"name":      key.Name,
+			"scope":     key.Scope,
+			"prefix":    key.Prefix,
+			"hash":      hash,
+			"createdAt": key.CreatedAt,
+			"orgId":     nullIfEmpty(key.OrgID),
+		})
+		return nil, err
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+	return nil
+}
+
+// ListAPIKeys returns the provisioned keys, oldest first
+func ListAPIKeys(ctx context.Context, client *Neo4jClient) ([]models.APIKey, error) {
+	result, err := client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (k:ApiKey)
+			RETURN k
+			ORDER BY k.createdAt
+		`
+		records, err := tx.Run(ctx, query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		keys := []models.APIKey{}
+		for records.Next(ctx) {
+			raw, _ := records.Record().Get("k")
+			keys = append(keys, nodeToAPIKey(raw.(neo4j.Node)))
+		}
+		return keys, records.Err()
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	return result.([]models.APIKey), nil
+}
+
+// GetAPIKeyScope returns the scope of the key with a hash and the
+// organization it is scoped to, "" for a key of the whole instance; the
+// scope is "" when no key has the hash
+func GetAPIKeyScope(ctx context.Context, client *Neo4jClient, hash string) (string, string, error) {
+	result, err := client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (k:ApiKey {hash: $hash})
+			RETURN k.scope AS scope, k.orgId AS orgId
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"hash": hash})
+		if err != nil {
+			return nil, err
+		}
+		if !records.Next(ctx) {
+			return [2]string{}, records.Err()
+		}
+		record := records.Record()
+		return [2]string{recordString(record, "scope"), recordString(record, "orgId")}, nil
+	})
+
+	if err != nil {
+		return "", "", fmt.Errorf("failed to look up API key: %w", err)
+	}
+	found := result.([2]string)
+	return found[0], found[1], nil
+}
+
+// DeleteAPIKey deletes a provisioned key, returning its hash, or "" when no
+// key has the ID
+func DeleteAPIKey(ctx context.Context, client *Neo4jClient, id string) (string, error) {
+	result, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (k:ApiKey {id: $id})
+			WITH k, k.hash AS hash
+			DELETE k
+			RETURN hash
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		if !records.Next(ctx) {
+			return "", records.Err()
+		}
+		return recordString(records.Record(), "hash"), nil
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("failed to delete API key: %w", err)
+	}
+	return result.(string), nil
+}
+
+func nodeToAPIKey(node neo4j.Node) models.APIKey {
+	props := node.GetProperties()
+	key := models.APIKey{
+		ID:     stringProp(props, "id"),
+		Name:   stringProp(props, "name"),
+		Scope:  stringProp(props, "scope"),
+		Prefix: stringProp(props, "prefix"),
+		OrgID:  stringProp(props, "orgId"),
+	}
+	if t, ok := props["createdAt"].(time.Time); ok {
+		key.CreatedAt = t
+	}
+	return key
+}
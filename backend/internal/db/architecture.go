@@ -0,0 +1,210 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// architectureDir is a Directory node an architecture module can be named after
+type architectureDir struct {
+	id        string
+	isPackage bool
+}
+
+// fileCalls counts the CALLS edges from the functions of one file to those
+// of another
+type fileCalls struct {
+	source, target string // file paths
+	calls          int64
+}
+
+// architecture is what the module graphs of a repository are built from
+type architecture struct {
+	dirs  map[string]architectureDir // by path
+	files map[string]int64           // path -> functions declared
+	calls []fileCalls
+}
+
+// GetArchitectureGraph returns the repository collapsed to the directories
+// holding its files, with a DEPENDS_ON edge wherever functions of one call
+// functions of another, weighted by the number of CALLS edges. With depth
+// above 0, directories deeper than depth are folded into their ancestor at
+// that depth. Files at the top of the repository form a module of their
+// own, identified by the repository ID.
+func (r *GraphReader) GetArchitectureGraph(ctx context.Context, repoID string, depth int) (*GraphData, error) {
+	found, err := r.readArchitecture(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	return architectureGraph(repoID, found.dirs, found.files, found.calls, depth), nil
+}
+
+// readArchitecture reads the directories and files of a repository and the
+// calls between its files
+func (r *GraphReader) readArchitecture(ctx context.Context, repoID string) (*architecture, error) {
+	ctx = WithRepository(ctx, repoID)
+	params := map[string]any{"repoId": repoID}
+
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		found := &architecture{
+			dirs:  make(map[string]architectureDir),
+			files: make(map[string]int64),
+		}
+
+		records, err := tx.Run(ctx, `
+			MATCH (d:Directory {repoId: $repoId})
+			RETURN d.path AS path, d.id AS id, labels(d) AS labels
+		`, params)
+		if err != nil {
+			return nil, err
+		}
+		for records.Next(ctx) {
+			rec := records.Record()
+			dir := architectureDir{id: recordString(rec, "id")}
+			for _, label := range recordStrings(rec, "labels") {
+				if label == "Package" {
+					dir.isPackage = true
+				}
+			}
+			found.dirs[recordString(rec, "path")] = dir
+		}
+		if err := records.Err(); err != nil {
+			return nil, err
+		}
+
+		records, err = tx.Run(ctx, `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(f:File)
+			OPTIONAL MATCH (f)-[:DECLARES]->(fn:Function|Method)
+			RETURN f.path AS path, count(fn) AS functions
+		`, params)
+		if err != nil {
+			return nil, err
+		}
+		for records.Next(ctx) {
+			rec := records.Record()
+			functions, _ := rec.Get("functions")
+			found.files[recordString(rec, "path")] = functions.(int64)
+		}
+		if err := records.Err(); err != nil {
+			return nil, err
+		}
+
+		records, err = tx.Run(ctx, `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(f:File)-[:DECLARES]->(:Function|Method)-[c:CALLS]->(:Function|Method)<-[:DECLARES]-(target:File)
+			WHERE f <> target
+			RETURN f.path AS source, target.path AS target, count(c) AS calls
+		`, params)
+		if err != nil {
+			return nil, err
+		}
+		for records.Next(ctx) {
+			rec := records.Record()
+			calls, _ := rec.Get("calls")
+			found.calls = append(found.calls, fileCalls{
+				source: recordString(rec, "source"),
+				target: recordString(rec, "target"),
+				calls:  calls.(int64),
+			})
+		}
+		return found, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(*architecture), nil
+}
+
+// architectureGraph groups files into modules by directory, folded to depth
+// when above 0, and sums the calls between files of different modules.
+// Modules are ordered by path and edges by source then target path.
+func architectureGraph(repoID string, dirs map[string]architectureDir, files map[string]int64, calls []fileCalls, depth int) *GraphData {
+	moduleOf := func(file string) string {
+		dir := path.Dir(strings.ReplaceAll(file, "\\", "/"))
+		if dir == "." || dir == "/" {
+			return ""
+		}
+		if parts := strings.Split(dir, "/"); depth > 0 && len(parts) > depth {
+			dir = strings.Join(parts[:depth], "/")
+		}
+		return dir
+	}
+	moduleID := func(module string) string {
+		if module == "" {
+			return repoID
+		}
+		if dir, ok := dirs[module]; ok {
+			return dir.id
+		}
+		return module
+	}
+
+	type module struct {
+		files, functions int64
+	}
+	modules := make(map[string]*module)
+	for file, functions := range files {
+		m := modules[moduleOf(file)]
+		if m == nil {
+			m = &module{}
+			modules[moduleOf(file)] = m
+		}
+		m.files++
+		m.functions += functions
+	}
+
+	graph := &GraphData{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	for _, name := range propNames(modules) {
+		nodeType, label := "Directory", path.Base(name)
+		if name == "" {
+			label = "."
+		} else if dirs[name].isPackage {
+			nodeType = "Package"
+		}
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			ID:    moduleID(name),
+			Label: label,
+			Type:  nodeType,
+			Props: map[string]any{
+				"path":      name,
+				"files":     modules[name].files,
+				"functions": modules[name].functions,
+			},
+		})
+	}
+
+	weights := make(map[[2]string]int64)
+	for _, c := range calls {
+		from, to := moduleOf(c.source), moduleOf(c.target)
+		if from == to || modules[from] == nil || modules[to] == nil {
+			continue
+		}
+		weights[[2]string{from, to}] += c.calls
+	}
+	pairs := make([][2]string, 0, len(weights))
+	for pair := range weights {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	for _, pair := range pairs {
+		source, target := moduleID(pair[0]), moduleID(pair[1])
+		graph.Edges = append(graph.Edges, GraphEdge{
+			ID:     fmt.Sprintf("%s->%s", source, target),
+			Source: source,
+			Target: target,
+			Type:   "DEPENDS_ON",
+			Props:  map[string]any{"weight": weights[pair]},
+		})
+	}
+	return graph
+}
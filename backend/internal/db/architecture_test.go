@@ -0,0 +1,59 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestArchitectureGraph tests grouping files into directories and summing
+// the calls between them
+func TestArchitectureGraph(t *testing.T) {
+	dirs := map[string]architectureDir{
+		"internal":        {id: "d-internal"},
+		"internal/api":    {id: "d-api", isPackage: true},
+		"internal/db":     {id: "d-db", isPackage: true},
+		"internal/db/sql": {id: "d-sql", isPackage: true},
+	}
+	files := map[string]int64{
+		"main.go":                  1,
+		"internal/api/routes.go":   4,
+		"internal/api/handlers.go": 6,
+		"internal/db/reader.go":    3,
+		"internal/db/sql/query.go": 2,
+	}
+	calls := []fileCalls{
+		{source: "main.go", target: "internal/api/routes.go", calls: 1},
+		{source: "internal/api/routes.go", target: "internal/api/handlers.go", calls: 5},
+		{source: "internal/api/handlers.go", target: "internal/db/reader.go", calls: 3},
+		{source: "internal/api/routes.go", target: "internal/db/reader.go", calls: 1},
+		{source: "internal/db/reader.go", target: "internal/db/sql/query.go", calls: 2},
+	}
+
+	graph := architectureGraph("repo", dirs, files, calls, 0)
+	require.Len(t, graph.Nodes, 4)
+	assert.Equal(t, GraphNode{
+		ID: "repo", Label: ".", Type: "Directory",
+		Props: map[string]any{"path": "", "files": int64(1), "functions": int64(1)},
+	}, graph.Nodes[0])
+	assert.Equal(t, GraphNode{
+		ID: "d-api", Label: "api", Type: "Package",
+		Props: map[string]any{"path": "internal/api", "files": int64(2), "functions": int64(10)},
+	}, graph.Nodes[1])
+	assert.Equal(t, []GraphEdge{
+		{ID: "repo->d-api", Source: "repo", Target: "d-api", Type: "DEPENDS_ON", Props: map[string]any{"weight": int64(1)}},
+		{ID: "d-api->d-db", Source: "d-api", Target: "d-db", Type: "DEPENDS_ON", Props: map[string]any{"weight": int64(4)}},
+		{ID: "d-db->d-sql", Source: "d-db", Target: "d-sql", Type: "DEPENDS_ON", Props: map[string]any{"weight": int64(2)}},
+	}, graph.Edges)
+
+	// Folded to the top directory, only the call from main.go crosses modules
+	graph = architectureGraph("repo", dirs, files, calls, 1)
+	require.Len(t, graph.Nodes, 2)
+	assert.Equal(t, "d-internal", graph.Nodes[1].ID)
+	assert.Equal(t, "Directory", graph.Nodes[1].Type)
+	assert.Equal(t, int64(4), graph.Nodes[1].Props["files"])
+	assert.Equal(t, []GraphEdge{
+		{ID: "repo->d-internal", Source: "repo", Target: "d-internal", Type: "DEPENDS_ON", Props: map[string]any{"weight": int64(1)}},
+	}, graph.Edges)
+}
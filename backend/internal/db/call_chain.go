@@ -0,0 +1,222 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Directions of a call chain
+const (
+	CallersDirection = "upstream"   // functions that call the root, transitively
+	CalleesDirection = "downstream" // functions the root calls, transitively
+)
+
+// MaxCallChainDepth bounds how many calls deep a chain is followed
+const MaxCallChainDepth = 10
+
+// CallChainNode is a function in a call chain, Depth calls away from the
+// root; the root has depth 0
+type CallChainNode struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	FilePath  string `json:"filePath"`
+	Signature string `json:"signature,omitempty"`
+	Depth     int    `json:"depth"`
+}
+
+// CallChain is the transitive closure of callers or callees of a function
+type CallChain struct {
+	Root      string          `json:"root"`
+	Direction string          `json:"direction"`
+	MaxDepth  int             `json:"maxDepth"`
+	Nodes     []CallChainNode `json:"nodes"` // by depth
+	Edges     []GraphEdge     `json:"edges"` // CALLS edges, always caller to callee
+	Truncated bool            `json:"truncated,omitempty"`
+}
+
+// GetCallChain follows CALLS edges from a function, one depth at a time,
+// up to maxDepth calls away or until limit functions are found. Each
+// function is listed once, at the shortest depth it is reached. Returns nil
+// if the function is not in the repository.
+func (r *GraphReader) GetCallChain(ctx context.Context, repoID, nodeID, direction string, maxDepth, limit int) (*CallChain, error) {
+	ctx = WithRepository(ctx, repoID)
+	maxDepth = min(max(maxDepth, 1), MaxCallChainDepth)
+
+	// Callers are found along incoming CALLS edges
+	step := `
+		MATCH (from:Function|Method)-[c:CALLS]->(to:Function|Method)
+		WHERE from.id IN $frontier
+		RETURN from.id AS source, to AS next, c
+	`
+	if direction == CallersDirection {
+		step = `
+			MATCH (to:Function|Method)-[c:CALLS]->(from:Function|Method)
+			WHERE from.id IN $frontier
+			RETURN to.id AS source, from AS next, c
+		`
+	}
+
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		rootQuery := `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(:File)-[:DECLARES]->(root:Function|Method {id: $nodeId})
+			RETURN root
+		`
+		records, err := tx.Run(ctx, rootQuery, map[string]any{"repoId": repoID, "nodeId": nodeID})
+		if err != nil {
+			return nil, err
+		}
+		if !records.Next(ctx) {
+			return (*CallChain)(nil), records.Err()
+		}
+		rootRaw, _ := records.Record().Get("root")
+
+		chain := &CallChain{
+			Root:      nodeID,
+			Direction: direction,
+			MaxDepth:  maxDepth,
+			Nodes:     []CallChainNode{callChainNode(rootRaw.(neo4j.Node), 0)},
+			Edges:     []GraphEdge{},
+		}
+		depths := map[string]int{nodeID: 0}
+		edges := make(map[string]bool)
+		frontier := []string{nodeID}
+
+		for depth := 1; depth <= maxDepth && len(frontier) > 0; depth++ {
+			records, err := tx.Run(ctx, step, map[string]any{"frontier": frontier})
+			if err != nil {
+				return nil, err
+			}
+
+			var next []string
+			for records.Next(ctx) {
+				rec := records.Record()
+				source := recordString(rec, "source")
+				nextRaw, _ := rec.Get("next")
+				node := nextRaw.(neo4j.Node)
+				id, _ := node.GetProperties()["id"].(string)
+
+				if _, seen := depths[id]; !seen {
+					if len(chain.Nodes) >= limit {
+						chain.Truncated = true
+						continue
+					}
+					depths[id] = depth
+					chain.Nodes = append(chain.Nodes, callChainNode(node, depth))
+					next = append(next, id)
+				}
+
+				caller, callee := source, id
+				if direction == CallersDirection {
+					caller, callee = id, source
+				}
+				edgeID := fmt.Sprintf("%s->%s", caller, callee)
+				if !edges[edgeID] {
+					edges[edgeID] = true
+					cRaw, _ := rec.Get("c")
+					callProps := cRaw.(neo4j.Relationship).GetProperties()
+					chain.Edges = append(chain.Edges, GraphEdge{
+						ID:     edgeID,
+						Source: caller,
+						Target: callee,
+						Type:   "CALLS",
+						Props: map[string]any{
+							"line":  callProps["line"],
+							"count": callProps["count"],
+						},
+					})
+				}
+			}
+			if err := records.Err(); err != nil {
+				return nil, err
+			}
+			frontier = next
+		}
+		return chain, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(*CallChain), nil
+}
+
+// callChainNode converts a function or method node of a call chain
+func callChainNode(node neo4j.Node, depth int) CallChainNode {
+	props := node.GetProperties()
+	n := CallChainNode{
+		ID:        stringProp(props, "id"),
+		Name:      stringProp(props, "name"),
+		Type:      "Function",
+		FilePath:  stringProp(props, "filePath"),
+		Signature: stringProp(props, "signature"),
+		Depth:     depth,
+	}
+	for _, label := range node.Labels {
+		if label == "Method" {
+			n.Type = "Method"
+		}
+	}
+	return n
+}
+
+// callGraphFunction is a function or method of a repository's call graph
+type callGraphFunction struct {
+	ID        string
+	Name      string
+	Type      string
+	FilePath  string
+	StartLine int
+	Signature string
+}
+
+// readCallGraph returns every function and method of a repository and the
+// CALLS edges between them
+func readCallGraph(ctx context.Context, tx neo4j.ManagedTransaction, repoID string) ([]callGraphFunction, []models.CallRelation, error) {
+	query := `
+		MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(:File)-[:DECLARES]->(e:Function|Method)
+		OPTIONAL MATCH (e)-[c:CALLS]->(callee:Function|Method)
+		RETURN e.id AS id, e.name AS name, labels(e) AS labels, e.filePath AS filePath,
+		       e.startLine AS startLine, e.signature AS signature, collect(callee.id) AS callees,
+		       collect(CASE WHEN c IS NULL THEN null ELSE coalesce(c.count, 1) END) AS counts
+	`
+	records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var functions []callGraphFunction
+	var calls []models.CallRelation
+	for records.Next(ctx) {
+		rec := records.Record()
+		fn := callGraphFunction{
+			ID:        recordString(rec, "id"),
+			Name:      recordString(rec, "name"),
+			Type:      "Function",
+			FilePath:  recordString(rec, "filePath"),
+			Signature: recordString(rec, "signature"),
+		}
+		if sl, _ := rec.Get("startLine"); sl != nil {
+			fn.StartLine = int(sl.(int64))
+		}
+		labels, _ := rec.Get("labels")
+		for _, label := range labels.([]any) {
+			if label == "Method" {
+				fn.Type = "Method"
+			}
+		}
+		functions = append(functions, fn)
+		counts, _ := rec.Get("counts")
+		for i, callee := range recordStrings(rec, "callees") {
+			call := models.CallRelation{CallerID: fn.ID, CalleeID: callee, Count: 1}
+			if n, ok := counts.([]any)[i].(int64); ok {
+				call.Count = int(n)
+			}
+			calls = append(calls, call)
+		}
+	}
+	return functions, calls, records.Err()
+}
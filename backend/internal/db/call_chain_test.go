@@ -0,0 +1,26 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCallChainNode tests conversion of functions and methods in a call chain
+func TestCallChainNode(t *testing.T) {
+	fn := callChainNode(neo4j.Node{Labels: []string{"Function"}, Props: map[string]any{
+		"id":        "fn-1",
+		"name":      "Parse",
+		"filePath":  "parser.go",
+		"signature": "func Parse() error",
+	}}, 2)
+	assert.Equal(t, CallChainNode{
+		ID: "fn-1", Name: "Parse", Type: "Function", FilePath: "parser.go",
+		Signature: "func Parse() error", Depth: 2,
+	}, fn)
+
+	method := callChainNode(neo4j.Node{Labels: []string{"Method"}, Props: map[string]any{"id": "m-1", "name": "Run"}}, 0)
+	assert.Equal(t, "Method", method.Type)
+	assert.Zero(t, method.Depth)
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// CentralFunction is a function ranked by how much of the call graph
+// depends on it
+type CentralFunction struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Type      string  `json:"type"`
+	FilePath  string  `json:"filePath"`
+	StartLine int     `json:"startLine,omitempty"`
+	PageRank  float64 `json:"pageRank"`
+	InDegree  int64   `json:"inDegree"`  // distinct functions calling it
+	OutDegree int64   `json:"outDegree"` // distinct functions it calls
+}
+
+// WriteCentrality scores the functions and methods of a repository by degree
+// and PageRank over their CALLS edges and stores the scores on the nodes as
+// pageRank, inDegree and outDegree. Scores are computed here rather than by
+// the Graph Data Science plugin, which not every deployment has.
+func (w *GraphWriter) WriteCentrality(ctx context.Context, repoID string) error {
+	ctx = WithRepository(ctx, repoID)
+	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		functions, calls, err := readCallGraph(ctx, tx, repoID)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, len(functions))
+		for i, fn := range functions {
+			ids[i] = fn.ID
+		}
+
+		scores := analysis.ComputeCentrality(ids, calls)
+		rows := make([]map[string]any, len(scores))
+		for i, s := range scores {
+			rows[i] = map[string]any{
+				"id":        s.ID,
+				"pageRank":  s.PageRank,
+				"inDegree":  s.InDegree,
+				"outDegree": s.OutDegree,
+			}
+		}
+
+		query := `
+			UNWIND $rows AS row
+			MATCH (e:Function|Method {id: row.id, repoId: $repoId})
+			SET e.pageRank = row.pageRank, e.inDegree = row.inDegree, e.outDegree = row.outDegree
+		`
+		_, err = tx.Run(ctx, query, map[string]any{"repoId": repoID, "rows": rows})
+		return nil, err
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to write centrality: %w", err)
+	}
+	return nil
+}
+
+// GetTopCentral returns the functions and methods with the highest PageRank,
+// those with the most callers first on ties
+func (r *GraphReader) GetTopCentral(ctx context.Context, repoID string, limit int) ([]CentralFunction, error) {
+	ctx = WithRepository(ctx, repoID)
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(:File)-[:DECLARES]->(e:Function|Method)
+			WHERE e.pageRank IS NOT NULL
+			RETURN e.id AS id, e.name AS name, labels(e) AS labels, e.filePath AS filePath,
+			       e.startLine AS startLine, e.pageRank AS pageRank,
+			       e.inDegree AS inDegree, e.outDegree AS outDegree
+			ORDER BY pageRank DESC, inDegree DESC, e.filePath, e.startLine
+			LIMIT $limit
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+
+		functions := []CentralFunction{}
+		for records.Next(ctx) {
+			rec := records.Record()
+			fn := CentralFunction{
+				ID:       recordString(rec, "id"),
+				Name:     recordString(rec, "name"),
+				FilePath: recordString(rec, "filePath"),
+				Type:     "Function",
+			}
+			if sl, _ := rec.Get("startLine"); sl != nil {
+				fn.StartLine = int(sl.(int64))
+			}
+			if rank, _ := rec.Get("pageRank"); rank != nil {
+				fn.PageRank = rank.(float64)
+			}
+			if in, _ := rec.Get("inDegree"); in != nil {
+				fn.InDegree = in.(int64)
+			}
+			if out, _ := rec.Get("outDegree"); out != nil {
+				fn.OutDegree = out.(int64)
+			}
+			labels, _ := rec.Get("labels")
+			for _, label := range labels.([]any) {
+				if label == "Method" {
+					fn.Type = "Method"
+				}
+			}
+			functions = append(functions, fn)
+		}
+		return functions, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]CentralFunction), nil
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// ComplexFunction is a function ranked by its cyclomatic complexity
+type ComplexFunction struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	FilePath   string `json:"filePath"`
+	StartLine  int    `json:"startLine,omitempty"`
+	EndLine    int    `json:"endLine,omitempty"`
+	Complexity int64  `json:"complexity"`
+}
+
+// GetMostComplex returns the functions and methods with the highest
+// cyclomatic complexity, the longest first on ties
+func (r *GraphReader) GetMostComplex(ctx context.Context, repoID string, limit int) ([]ComplexFunction, error) {
+	ctx = WithRepository(ctx, repoID)
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(:File)-[:DECLARES]->(e:Function|Method)
+			WHERE e.complexity IS NOT NULL
+			RETURN e.id AS id, e.name AS name, labels(e) AS labels, e.filePath AS filePath,
+			       e.startLine AS startLine, e.endLine AS endLine, e.complexity AS complexity
+			ORDER BY complexity DESC, e.endLine - e.startLine DESC, e.filePath, e.startLine
+			LIMIT $limit
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+
+		functions := []ComplexFunction{}
+		for records.Next(ctx) {
+			rec := records.Record()
+			fn := ComplexFunction{
+				ID:       recordString(rec, "id"),
+				Name:     recordString(rec, "name"),
+				FilePath: recordString(rec, "filePath"),
+				Type:     "Function",
+			}
+			if sl, _ := rec.Get("startLine"); sl != nil {
+				fn.StartLine = int(sl.(int64))
+			}
+			if el, _ := rec.Get("endLine"); el != nil {
+				fn.EndLine = int(el.(int64))
+			}
+			if c, _ := rec.Get("complexity"); c != nil {
+				fn.Complexity = c.(int64)
+			}
+			labels, _ := rec.Get("labels")
+			for _, label := range labels.([]any) {
+				if label == "Method" {
+					fn.Type = "Method"
+				}
+			}
+			functions = append(functions, fn)
+		}
+		return functions, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]ComplexFunction), nil
+}
@@ -0,0 +1,226 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// CreateConversation starts a conversation with an agent on a repository
+func CreateConversation(ctx context.Context, client *Neo4jClient, conv *models.Conversation) error {
+	conv.ID = uuid.New().String()
+	conv.CreatedAt = time.Now().UTC()
+	conv.UpdatedAt = conv.CreatedAt
+
+	_, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})
+			CREATE (r)-[:HAS_CONVERSATION]->(c:Conversation {
+				id: $id,
+				repoId: $repoId,
+				title: $title,
+				agentType: $agentType,
+				createdAt: $createdAt,
+				updatedAt: $createdAt,
+				messageCount: 0
+			})
+		`
+		_, err := tx.Run(ctx, query, map[string]any{
+			"id":        conv.ID,
+			"repoId":    conv.RepoID,
+			"title":     conv.Title,
+			"agentType": conv.AgentType,
+			"createdAt": conv.CreatedAt,
+		})
+		return nil, err
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to create conversation: %w", err)
+	}
+	return nil
+}
+
+// ListConversations returns a repository's conversations without their
+// messages, most recently continued first
+func ListConversations(ctx context.Context, client *Neo4jClient, repoID string) ([]models.Conversation, error) {
+	result, err := client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:HAS_CONVERSATION]->(c:Conversation)
+			RETURN c
+			ORDER BY c.updatedAt DESC
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+
+		convs := []models.Conversation{}
+		for records.Next(ctx) {
+			raw, _ := records.Record().Get("c")
+			convs = append(convs, nodeToConversation(raw.(neo4j.Node)))
+		}
+		return convs, records.Err()
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	return result.([]models.Conversation), nil
+}
+
+// GetConversation returns a conversation with its messages, oldest first,
+// or nil if the repository has none with that ID
+func GetConversation(ctx context.Context, client *Neo4jClient, repoID, id string) (*models.Conversation, error) {
+	result, err := client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:HAS_CONVERSATION]->(c:Conversation {id: $id})
+			OPTIONAL MATCH (c)-[:HAS_MESSAGE]->(m:ConversationMessage)
+			WITH c, m ORDER BY m.seq
+			RETURN c, collect(m) AS messages
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "id": id})
+		if err != nil {
+			return nil, err
+		}
+		if !records.Next(ctx) {
+			return nil, records.Err()
+		}
+		record := records.Record()
+		raw, _ := record.Get("c")
+		conv := nodeToConversation(raw.(neo4j.Node))
+		conv.Messages = []models.ConversationMessage{}
+		if messages, ok := record.Get("messages"); ok {
+			for _, m := range messages.([]any) {
+				conv.Messages = append(conv.Messages, nodeToConversationMessage(m.(neo4j.Node)))
+			}
+		}
+		return &conv, nil
+	})
+
+	if err != nil || result == nil {
+		return nil, err
+	}
+	return result.(*models.Conversation), nil
+}
+
+// AppendConversationMessages adds messages to the end of a conversation,
+// naming it after its first message if it has no title yet
+func AppendConversationMessages(ctx context.Context, client *Neo4jClient, conv *models.Conversation, messages []models.ConversationMessage) error {
+	now := time.Now().UTC()
+	params := make([]map[string]any, len(messages))
+	for i := range messages {
+		messages[i].ID = uuid.New().String()
+		messages[i].CreatedAt = now
+		params[i] = map[string]any{
+			"id":        messages[i].ID,
+			"role":      messages[i].Role,
+			"content":   messages[i].Content,
+			"toolCalls": nullIfEmpty(string(messages[i].ToolCalls)),
+			"offset":    i,
+		}
+	}
+	if conv.Title == "" && len(messages) > 0 {
+		conv.Title = models.ConversationTitle(messages[0].Content)
+	}
+
+	_, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		// The count is read and raised under the write lock SET takes, so
+		// concurrent appends cannot reuse positions
+		query := `
+			MATCH (c:Conversation {id: $id})
+			SET c.updatedAt = $now, c.title = $title
+			WITH c, c.messageCount AS start
+			SET c.messageCount = start + size($messages)
+			WITH c, start
+			UNWIND $messages AS message
+			CREATE (c)-[:HAS_MESSAGE]->(:ConversationMessage {
+				id: message.id,
+				role: message.role,
+				content: message.content,
+				toolCalls: message.toolCalls,
+				seq: start + message.offset,
+				createdAt: $now
+			})
+		`
+		_, err := tx.Run(ctx, query, map[string]any{
+			"id":       conv.ID,
+			"now":      now,
+			"title":    conv.Title,
+			"messages": params,
+		})
+		return nil, err
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to save conversation messages: %w", err)
+	}
+	conv.UpdatedAt = now
+	conv.MessageCount += len(messages)
+	return nil
+}
+
+// DeleteConversation deletes a conversation and its messages, returning
+// whether the repository had it
+func DeleteConversation(ctx context.Context, client *Neo4jClient, repoID, id string) (bool, error) {
+	result, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:HAS_CONVERSATION]->(c:Conversation {id: $id})
+			OPTIONAL MATCH (c)-[:HAS_MESSAGE]->(m:ConversationMessage)
+			WITH c, collect(m) AS messages
+			FOREACH (m IN messages | DETACH DELETE m)
+			DETACH DELETE c
+			RETURN true AS deleted
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "id": id})
+		if err != nil {
+			return nil, err
+		}
+		return records.Next(ctx), records.Err()
+	})
+
+	if err != nil {
+		return false, fmt.Errorf("failed to delete conversation: %w", err)
+	}
+	return result.(bool), nil
+}
+
+func nodeToConversation(node neo4j.Node) models.Conversation {
+	props := node.GetProperties()
+	conv := models.Conversation{
+		ID:        stringProp(props, "id"),
+		RepoID:    stringProp(props, "repoId"),
+		Title:     stringProp(props, "title"),
+		AgentType: stringProp(props, "agentType"),
+	}
+	if t, ok := props["createdAt"].(time.Time); ok {
+		conv.CreatedAt = t
+	}
+	if t, ok := props["updatedAt"].(time.Time); ok {
+		conv.UpdatedAt = t
+	}
+	if n, ok := props["messageCount"].(int64); ok {
+		conv.MessageCount = int(n)
+	}
+	return conv
+}
+
+func nodeToConversationMessage(node neo4j.Node) models.ConversationMessage {
+	props := node.GetProperties()
+	message := models.ConversationMessage{
+		ID:      stringProp(props, "id"),
+		Role:    stringProp(props, "role"),
+		Content: stringProp(props, "content"),
+	}
+	if toolCalls := stringProp(props, "toolCalls"); toolCalls != "" {
+		message.ToolCalls = []byte(toolCalls)
+	}
+	if t, ok := props["createdAt"].(time.Time); ok {
+		message.CreatedAt = t
+	}
+	return message
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNodeToConversation tests conversion of stored Conversation properties
+func TestNodeToConversation(t *testing.T) {
+	created := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+
+	conv := nodeToConversation(neo4j.Node{Props: map[string]any{
+		"id":           "conv-1",
+		"repoId":       "repo-1",
+		"title":        "How is auth handled?",
+		"agentType":    "explorer",
+		"createdAt":    created,
+		"updatedAt":    created.Add(time.Minute),
+		"messageCount": int64(4),
+	}})
+
+	assert.Equal(t, "How is auth handled?", conv.Title)
+	assert.Equal(t, "explorer", conv.AgentType)
+	assert.Equal(t, created, conv.CreatedAt)
+	assert.Equal(t, created.Add(time.Minute), conv.UpdatedAt)
+	assert.Equal(t, 4, conv.MessageCount)
+	assert.Nil(t, conv.Messages)
+}
+
+// TestNodeToConversationMessage tests conversion of stored
+// ConversationMessage properties
+func TestNodeToConversationMessage(t *testing.T) {
+	message := nodeToConversationMessage(neo4j.Node{Props: map[string]any{
+		"id":        "message-1",
+		"role":      "assistant",
+		"content":   "Through middleware.",
+		"toolCalls": `[{"tool":"search_code"}]`,
+	}})
+
+	assert.Equal(t, "assistant", message.Role)
+	assert.Equal(t, "Through middleware.", message.Content)
+	assert.Equal(t, json.RawMessage(`[{"tool":"search_code"}]`), message.ToolCalls)
+
+	question := nodeToConversationMessage(neo4j.Node{Props: map[string]any{"id": "message-2", "role": "user"}})
+	assert.Nil(t, question.ToolCalls)
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// CoupledFunction is a function with an unusual number of callers or callees
+type CoupledFunction struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	FilePath  string `json:"filePath"`
+	StartLine int    `json:"startLine,omitempty"`
+	FanIn     int    `json:"fanIn"`  // distinct functions calling it
+	FanOut    int    `json:"fanOut"` // distinct functions it calls
+}
+
+// CouplingReport lists the coupling outliers of a repository with the
+// fences they exceed
+type CouplingReport struct {
+	FanInFence  float64           `json:"fanInFence"`
+	FanOutFence float64           `json:"fanOutFence"`
+	Functions   []CoupledFunction `json:"functions"`
+}
+
+// GetCouplingOutliers returns the functions and methods whose fan-in or
+// fan-out, as stored by WriteCentrality, is an outlier for the repository,
+// the most extreme first
+func (r *GraphReader) GetCouplingOutliers(ctx context.Context, repoID string, limit int) (*CouplingReport, error) {
+	ctx = WithRepository(ctx, repoID)
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(:File)-[:DECLARES]->(e:Function|Method)
+			WHERE e.inDegree IS NOT NULL
+			RETURN e.id AS id, e.name AS name, labels(e) AS labels, e.filePath AS filePath,
+			       e.startLine AS startLine, e.inDegree AS inDegree, e.outDegree AS outDegree
+			ORDER BY e.filePath, e.startLine
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+
+		var functions []CoupledFunction
+		for records.Next(ctx) {
+			rec := records.Record()
+			fn := CoupledFunction{
+				ID:       recordString(rec, "id"),
+				Name:     recordString(rec, "name"),
+				FilePath: recordString(rec, "filePath"),
+				Type:     "Function",
+			}
+			if sl, _ := rec.Get("startLine"); sl != nil {
+				fn.StartLine = int(sl.(int64))
+			}
+			if in, _ := rec.Get("inDegree"); in != nil {
+				fn.FanIn = int(in.(int64))
+			}
+			if out, _ := rec.Get("outDegree"); out != nil {
+				fn.FanOut = int(out.(int64))
+			}
+			for _, label := range recordStrings(rec, "labels") {
+				if label == "Method" {
+					fn.Type = "Method"
+				}
+			}
+			functions = append(functions, fn)
+		}
+		return functions, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	functions := result.([]CoupledFunction)
+	byID := make(map[string]CoupledFunction, len(functions))
+	counts := make([]analysis.Coupling, len(functions))
+	for i, fn := range functions {
+		byID[fn.ID] = fn
+		counts[i] = analysis.Coupling{ID: fn.ID, FanIn: fn.FanIn, FanOut: fn.FanOut}
+	}
+
+	outliers := analysis.FindCouplingOutliers(counts)
+	report := &CouplingReport{
+		FanInFence:  outliers.FanInFence,
+		FanOutFence: outliers.FanOutFence,
+		Functions:   []CoupledFunction{},
+	}
+	for _, o := range outliers.Outliers {
+		if len(report.Functions) == limit {
+			break
+		}
+		report.Functions = append(report.Functions, byID[o.ID])
+	}
+	return report, nil
+}
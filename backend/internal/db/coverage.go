@@ -0,0 +1,214 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/coverage"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// CoverageStats is the line coverage recorded for a file or entity by the
+// last uploaded coverage report
+type CoverageStats struct {
+	Covered    int64     `json:"covered"` // instrumented lines that ran
+	Lines      int64     `json:"lines"`   // instrumented lines
+	Percent    float64   `json:"percent"`
+	Source     string    `json:"source"` // go, lcov or cobertura
+	ObservedAt time.Time `json:"observedAt"`
+}
+
+// CoverageGap is a poorly covered function ranked by how much of the graph
+// depends on it
+type CoverageGap struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Type      string  `json:"type"`
+	FilePath  string  `json:"filePath"`
+	StartLine int     `json:"startLine,omitempty"`
+	Coverage  float64 `json:"coverage"` // percent of instrumented lines that ran
+	Callers   int64   `json:"callers"`  // distinct functions calling it
+	// samples or spans from the last uploaded profile or trace, if any
+	RuntimeCount int64 `json:"runtimeCount,omitempty"`
+}
+
+// coverageStats reads the coverage properties of a file or entity node, or
+// nil if none were recorded
+func coverageStats(props map[string]any) *CoverageStats {
+	lines, ok := props["coverageLines"].(int64)
+	if !ok {
+		return nil
+	}
+	covered, _ := props["coverageCovered"].(int64)
+	stats := &CoverageStats{
+		Covered: covered,
+		Lines:   lines,
+		Percent: coverage.Stats{Covered: int(covered), Lines: int(lines)}.Percent(),
+		Source:  stringProp(props, "coverageSource"),
+	}
+	if t, ok := props["coverageObservedAt"].(time.Time); ok {
+		stats.ObservedAt = t
+	}
+	return stats
+}
+
+// withCoverage adds the coverage of a file or entity, if any, to the props
+// of its graph node
+func withCoverage(nodeProps, props map[string]any) map[string]any {
+	if stats := coverageStats(props); stats != nil {
+		nodeProps["coveragePct"] = stats.Percent
+	}
+	return nodeProps
+}
+
+// GetFilePaths returns the paths of a repository's files
+func (r *GraphReader) GetFilePaths(ctx context.Context, repoID string) ([]string, error) {
+	ctx = WithRepository(ctx, repoID)
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(f:File)
+			RETURN f.path AS path
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+
+		paths := []string{}
+		for records.Next(ctx) {
+			paths = append(paths, recordString(records.Record(), "path"))
+		}
+		return paths, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]string), nil
+}
+
+// WriteCoverage replaces the coverage recorded on a repository's files and
+// entities with the stats of one report
+func (w *GraphWriter) WriteCoverage(ctx context.Context, repoID, source string, files []coverage.FileStats, entities []coverage.EntityStats) error {
+	ctx = WithRepository(ctx, repoID)
+
+	fileRows := make([]map[string]any, len(files))
+	for i, f := range files {
+		fileRows[i] = map[string]any{"path": f.Path, "covered": f.Covered, "lines": f.Lines}
+	}
+	entityRows := make([]map[string]any, len(entities))
+	for i, e := range entities {
+		entityRows[i] = map[string]any{"id": e.EntityID, "covered": e.Covered, "lines": e.Lines}
+	}
+
+	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS|DECLARES*]->(n)
+			WHERE n.coverageLines IS NOT NULL
+			REMOVE n.coverageCovered, n.coverageLines, n.coverageSource, n.coverageObservedAt
+		`
+		if _, err := tx.Run(ctx, query, map[string]any{"repoId": repoID}); err != nil {
+			return nil, err
+		}
+
+		params := map[string]any{
+			"repoId":     repoID,
+			"files":      fileRows,
+			"entities":   entityRows,
+			"source":     source,
+			"observedAt": time.Now().UTC(),
+		}
+		query = `
+			UNWIND $files AS row
+			MATCH (f:File {repoId: $repoId, path: row.path})
+			SET f.coverageCovered = row.covered,
+			    f.coverageLines = row.lines,
+			    f.coverageSource = $source,
+			    f.coverageObservedAt = $observedAt
+		`
+		if _, err := tx.Run(ctx, query, params); err != nil {
+			return nil, err
+		}
+
+		query = `
+			UNWIND $entities AS row
+			MATCH (e:Function|Method|Class {id: row.id, repoId: $repoId})
+			SET e.coverageCovered = row.covered,
+			    e.coverageLines = row.lines,
+			    e.coverageSource = $source,
+			    e.coverageObservedAt = $observedAt
+		`
+		_, err := tx.Run(ctx, query, params)
+		return nil, err
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to write coverage: %w", err)
+	}
+	return nil
+}
+
+// GetCoverageGaps returns the functions and methods covered at most
+// maxPercent, those called from the most places first
+func (r *GraphReader) GetCoverageGaps(ctx context.Context, repoID string, maxPercent float64, limit int) ([]CoverageGap, error) {
+	ctx = WithRepository(ctx, repoID)
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(:File)-[:DECLARES]->(e:Function|Method)
+			WHERE e.coverageLines > 0
+			WITH e, 100.0 * e.coverageCovered / e.coverageLines AS coverage
+			WHERE coverage <= $maxPercent
+			OPTIONAL MATCH (caller:Function|Method)-[:CALLS]->(e)
+			WITH e, coverage, count(DISTINCT caller) AS callers
+			RETURN e.id AS id, e.name AS name, labels(e) AS labels, e.filePath AS filePath,
+			       e.startLine AS startLine, coverage, callers, e.runtimeCount AS runtimeCount
+			ORDER BY callers DESC, coverage ASC, e.filePath, e.startLine
+			LIMIT $limit
+		`
+		records, err := tx.Run(ctx, query, map[string]any{
+			"repoId":     repoID,
+			"maxPercent": maxPercent,
+			"limit":      limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		gaps := []CoverageGap{}
+		for records.Next(ctx) {
+			rec := records.Record()
+			gap := CoverageGap{
+				ID:       recordString(rec, "id"),
+				Name:     recordString(rec, "name"),
+				FilePath: recordString(rec, "filePath"),
+				Type:     "Function",
+			}
+			if sl, _ := rec.Get("startLine"); sl != nil {
+				gap.StartLine = int(sl.(int64))
+			}
+			if pct, _ := rec.Get("coverage"); pct != nil {
+				gap.Coverage = pct.(float64)
+			}
+			if callers, _ := rec.Get("callers"); callers != nil {
+				gap.Callers = callers.(int64)
+			}
+			if count, _ := rec.Get("runtimeCount"); count != nil {
+				gap.RuntimeCount = count.(int64)
+			}
+			labels, _ := rec.Get("labels")
+			for _, label := range labels.([]any) {
+				if label == "Method" {
+					gap.Type = "Method"
+				}
+			}
+			gaps = append(gaps, gap)
+		}
+		return gaps, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]CoverageGap), nil
+}
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCoverageStats tests reading coverage annotations off node properties
+func TestCoverageStats(t *testing.T) {
+	observed := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	stats := coverageStats(map[string]any{
+		"coverageCovered":    int64(3),
+		"coverageLines":      int64(4),
+		"coverageSource":     "lcov",
+		"coverageObservedAt": observed,
+	})
+
+	assert.Equal(t, &CoverageStats{Covered: 3, Lines: 4, Percent: 75, Source: "lcov", ObservedAt: observed}, stats)
+	assert.Nil(t, coverageStats(map[string]any{"path": "main.go"}))
+
+	props := withCoverage(map[string]any{"language": "go"}, map[string]any{
+		"coverageCovered": int64(0),
+		"coverageLines":   int64(2),
+	})
+	assert.Equal(t, map[string]any{"language": "go", "coveragePct": 0.0}, props)
+}
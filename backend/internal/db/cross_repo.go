@@ -0,0 +1,552 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// CrossRepoLinks counts the edges LinkCrossRepository wrote
+type CrossRepoLinks struct {
+	Dependencies  int `json:"dependencies"`  // File DEPENDS_ON a package of another repository
+	ExternalCalls int `json:"externalCalls"` // CALLS_EXTERNAL edges
+}
+
+// SetRepositoryModules records the import paths a repository provides
+func SetRepositoryModules(ctx context.Context, client *Neo4jClient, id string, modules []string) error {
+	_, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $id})
+			SET r.modules = $modules
+		`
+		_, err := tx.Run(ctx, query, map[string]any{"id": id, "modules": modules})
+		return nil, err
+	})
+	return err
+}
+
+// encodeExternalCall stores an external call as one string property item
+func encodeExternalCall(call analysis.ExternalCall) string {
+	return call.ImportPath + "\t" + call.ClassName + "\t" + call.Name
+}
+
+func decodeExternalCall(s string) (analysis.ExternalCall, bool) {
+	parts := strings.Split(s, "\t")
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return analysis.ExternalCall{}, false
+	}
+	return analysis.ExternalCall{ImportPath: parts[0], ClassName: parts[1], Name: parts[2]}, true
+}
+
+// WriteExternalCalls records on each caller the calls it makes through
+// imports leaving the repository, so LinkCrossRepository can connect them
+// once the repository providing the import is indexed
+func (w *GraphWriter) WriteExternalCalls(ctx context.Context, files []*models.File, entities []models.CodeEntity) error {
+	byCaller := make(map[string][]string)
+	for _, call := range analysis.ExternalCalls(files, entities) {
+		byCaller[call.CallerID] = append(byCaller[call.CallerID], encodeExternalCall(call))
+	}
+	rows := make([]map[string]any, 0, len(byCaller))
+	for callerID, calls := range byCaller {
+		rows = append(rows, map[string]any{"callerId": callerID, "calls": calls})
+	}
+
+	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			UNWIND $rows AS row
+			MATCH (caller:Function|Method {id: row.callerId})
+			SET caller.externalCalls = row.calls
+		`
+		_, err := tx.Run(ctx, query, map[string]any{"rows": rows})
+		return nil, err
+	})
+	return err
+}
+
+// moduleMatch is a repository whose module an import refers to
+type moduleMatch struct {
+	repoID string
+	dirs   []string
+}
+
+// matchImport finds the repositories other than fromRepo providing an import
+func matchImport(importPath, language, fromRepo string, modules map[string][]string) []moduleMatch {
+	var matches []moduleMatch
+	for repoID, repoModules := range modules {
+		if repoID == fromRepo {
+			continue
+		}
+		// Prefer the longest module, as with nested Go modules
+		best, bestDirs := "", []string(nil)
+		for _, module := range repoModules {
+			if dirs, ok := analysis.ModuleDirs(importPath, module, language); ok && len(module) > len(best) {
+				best, bestDirs = module, dirs
+			}
+		}
+		if best != "" {
+			matches = append(matches, moduleMatch{repoID: repoID, dirs: bestDirs})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].repoID < matches[j].repoID })
+	return matches
+}
+
+// calleeCandidate is an entity an external call may refer to
+type calleeCandidate struct {
+	ID         string
+	RepoID     string
+	Name       string
+	FilePath   string
+	ClassName  string
+	IsFunction bool
+}
+
+// pickCallee returns the single candidate in the most specific directory
+// matching the call. Several matches in that directory are ambiguous and
+// left unlinked, as within a repository.
+func pickCallee(candidates []calleeCandidate, call analysis.ExternalCall, match moduleMatch) (string, bool) {
+	for _, dir := range match.dirs {
+		var found []string
+		for _, c := range candidates {
+			if c.RepoID != match.repoID || c.Name != call.Name || path.Dir(c.FilePath) != dir {
+				continue
+			}
+			if (call.ClassName == "" && !c.IsFunction) || (call.ClassName != "" && c.ClassName != call.ClassName) {
+				continue
+			}
+			found = append(found, c.ID)
+		}
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			return found[0], true
+		default:
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// LinkCrossRepository rebuilds the edges between a repository and the other
+// indexed repositories: files DEPENDS_ON the package directory (or the
+// repository) an import resolves to, entities CALLS_EXTERNAL the entities
+// they call through such imports, and the repositories themselves
+// DEPENDS_ON each other with import and call counts. Edges in both
+// directions are rebuilt, so it runs after every index of the repository.
+func (w *GraphWriter) LinkCrossRepository(ctx context.Context, repoID string) (*CrossRepoLinks, error) {
+	if w.client.PerRepositoryDatabases() {
+		// Relationships cannot span databases
+		return &CrossRepoLinks{}, nil
+	}
+
+	result, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		modules, tenant, err := readModules(ctx, tx, repoID)
+		if err != nil {
+			return nil, err
+		}
+		own := modules[repoID]
+		if own == nil {
+			own = []string{}
+		}
+		params := map[string]any{"repoId": repoID, "modules": own, "repoIds": tenant}
+
+		query := `
+			MATCH (a)-[e:DEPENDS_ON|CALLS_EXTERNAL]->(b)
+			WHERE a.repoId = $repoId OR a.id = $repoId OR b.repoId = $repoId OR b.id = $repoId
+			DELETE e
+		`
+		if _, err := tx.Run(ctx, query, params); err != nil {
+			return nil, err
+		}
+
+		// Count edges per repository pair for the repository-level edge
+		type repoPair struct{ from, to string }
+		imports := make(map[repoPair]int)
+		calls := make(map[repoPair]int)
+		relevant := func(from, to string) bool { return from == repoID || to == repoID }
+
+		// Imports of this repository, and imports of this repository by others
+		query = `
+			MATCH (f:File)
+			WHERE f.repoId IN $repoIds AND size(coalesce(f.imports, [])) > 0 AND (f.repoId = $repoId OR
+			      any(imp IN f.imports WHERE any(m IN $modules WHERE
+			          imp = m OR imp STARTS WITH m + '/' OR imp STARTS WITH m + '.')))
+			RETURN f.id AS id, f.repoId AS repoId, f.language AS language, f.imports AS imports
+		`
+		records, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+		type fileDep struct {
+			fileID string
+			match  moduleMatch
+		}
+		var deps []fileDep
+		for records.Next(ctx) {
+			rec := records.Record()
+			fileID, fileRepo := recordString(rec, "id"), recordString(rec, "repoId")
+			language := recordString(rec, "language")
+			importsRaw, _ := rec.Get("imports")
+			seen := make(map[string]bool)
+			for _, imp := range importsRaw.([]any) {
+				importPath, _ := imp.(string)
+				for _, match := range matchImport(importPath, language, fileRepo, modules) {
+					key := match.repoID + "\x00" + strings.Join(match.dirs, "\x00")
+					if !relevant(fileRepo, match.repoID) || seen[key] {
+						continue
+					}
+					seen[key] = true
+					deps = append(deps, fileDep{fileID: fileID, match: match})
+					imports[repoPair{fileRepo, match.repoID}]++
+				}
+			}
+		}
+		if err := records.Err(); err != nil {
+			return nil, err
+		}
+
+		// External calls of this repository, and into this repository
+		query = `
+			MATCH (f:File)-[:DECLARES]->(e:Function|Method)
+			WHERE e.repoId IN $repoIds AND size(coalesce(e.externalCalls, [])) > 0 AND (e.repoId = $repoId OR
+			      any(c IN e.externalCalls WHERE any(m IN $modules WHERE
+			          c STARTS WITH m + '\t' OR c STARTS WITH m + '/' OR c STARTS WITH m + '.')))
+			RETURN e.id AS id, e.repoId AS repoId, f.language AS language, e.externalCalls AS calls
+		`
+		records, err = tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+		type pendingCall struct {
+			callerID string
+			fromRepo string
+			call     analysis.ExternalCall
+			match    moduleMatch
+		}
+		var pending []pendingCall
+		repoIDs := make(map[string]bool)
+		names := make(map[string]bool)
+		for records.Next(ctx) {
+			rec := records.Record()
+			callerID, callerRepo := recordString(rec, "id"), recordString(rec, "repoId")
+			language := recordString(rec, "language")
+			callsRaw, _ := rec.Get("calls")
+			for _, raw := range callsRaw.([]any) {
+				encoded, _ := raw.(string)
+				call, ok := decodeExternalCall(encoded)
+				if !ok {
+					continue
+				}
+				for _, match := range matchImport(call.ImportPath, language, callerRepo, modules) {
+					if !relevant(callerRepo, match.repoID) {
+						continue
+					}
+					pending = append(pending, pendingCall{callerID, callerRepo, call, match})
+					repoIDs[match.repoID] = true
+					names[call.Name] = true
+				}
+			}
+		}
+		if err := records.Err(); err != nil {
+			return nil, err
+		}
+
+		candidates, err := readCalleeCandidates(ctx, tx, sortedKeys(repoIDs), sortedKeys(names))
+		if err != nil {
+			return nil, err
+		}
+		var callRows []map[string]any
+		for _, p := range pending {
+			calleeID, ok := pickCallee(candidates, p.call, p.match)
+			if !ok {
+				continue
+			}
+			callRows = append(callRows, map[string]any{"callerId": p.callerID, "calleeId": calleeID})
+			calls[repoPair{p.fromRepo, p.match.repoID}]++
+		}
+
+		// Directories that exist in the target repositories
+		dirRepoIDs := make(map[string]bool)
+		for _, dep := range deps {
+			dirRepoIDs[dep.match.repoID] = true
+		}
+		dirs, err := readDirectories(ctx, tx, sortedKeys(dirRepoIDs))
+		if err != nil {
+			return nil, err
+		}
+		var dirRows, rootRows []map[string]any
+		for _, dep := range deps {
+			target := ""
+			for _, dir := range dep.match.dirs {
+				if dir != "." && dirs[dep.match.repoID][dir] {
+					target = dir
+					break
+				}
+			}
+			row := map[string]any{"fileId": dep.fileID, "repoId": dep.match.repoID, "path": target}
+			if target == "" {
+				// Root package, or a directory we cannot place
+				rootRows = append(rootRows, row)
+			} else {
+				dirRows = append(dirRows, row)
+			}
+		}
+
+		type statement struct {
+			query string
+			rows  []map[string]any
+		}
+		statements := []statement{
+			{`
+				UNWIND $rows AS row
+				MATCH (f:File {id: row.fileId})
+				MATCH (d:Directory {repoId: row.repoId, path: row.path})
+				MERGE (f)-[:DEPENDS_ON]->(d)
+			`, dirRows},
+			{`
+				UNWIND $rows AS row
+				MATCH (f:File {id: row.fileId})
+				MATCH (r:Repository {id: row.repoId})
+				MERGE (f)-[:DEPENDS_ON]->(r)
+			`, rootRows},
+			{`
+				UNWIND $rows AS row
+				MATCH (caller:Function|Method {id: row.callerId})
+				MATCH (callee:Function|Method {id: row.calleeId})
+				MERGE (caller)-[:CALLS_EXTERNAL]->(callee)
+			`, callRows},
+		}
+
+		pairs := make(map[repoPair]bool)
+		for pair := range imports {
+			pairs[pair] = true
+		}
+		for pair := range calls {
+			pairs[pair] = true
+		}
+		repoRows := make([]map[string]any, 0, len(pairs))
+		for pair := range pairs {
+			repoRows = append(repoRows, map[string]any{
+				"from":    pair.from,
+				"to":      pair.to,
+				"imports": imports[pair],
+				"calls":   calls[pair],
+			})
+		}
+		statements = append(statements, statement{`
+			UNWIND $rows AS row
+			MATCH (a:Repository {id: row.from})
+			MATCH (b:Repository {id: row.to})
+			MERGE (a)-[d:DEPENDS_ON]->(b)
+			SET d.imports = row.imports, d.calls = row.calls
+		`, repoRows})
+
+		for _, stmt := range statements {
+			if len(stmt.rows) == 0 {
+				continue
+			}
+			if _, err := tx.Run(ctx, stmt.query, map[string]any{"rows": stmt.rows}); err != nil {
+				return nil, err
+			}
+		}
+
+		return &CrossRepoLinks{Dependencies: len(deps), ExternalCalls: len(callRows)}, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to link repositories: %w", err)
+	}
+	return result.(*CrossRepoLinks), nil
+}
+
+// readModules returns the modules of every repository of the same
+// organization as a repository that provides any, and the IDs of all the
+// repositories of that organization. Repositories of different
+// organizations are never linked, so neither sees the other's code.
+func readModules(ctx context.Context, tx neo4j.ManagedTransaction, repoID string) (map[string][]string, []string, error) {
+	records, err := tx.Run(ctx, `
+		MATCH (own:Repository {id: $repoId})
+		MATCH (r:Repository)
+		WHERE coalesce(r.orgId, '') = coalesce(own.orgId, '')
+		RETURN r.id AS id, coalesce(r.modules, []) AS modules
+	`, map[string]any{"repoId": repoID})
+	if err != nil {
+		return nil, nil, err
+	}
+	modules := make(map[string][]string)
+	tenant := []string{}
+	for records.Next(ctx) {
+		rec := records.Record()
+		id := recordString(rec, "id")
+		tenant = append(tenant, id)
+		raw, _ := rec.Get("modules")
+		for _, m := range raw.([]any) {
+			if s, ok := m.(string); ok {
+				modules[id] = append(modules[id], s)
+			}
+		}
+	}
+	return modules, tenant, records.Err()
+}
+
+// readCalleeCandidates returns the functions and methods with one of the
+// names in the given repositories
+func readCalleeCandidates(ctx context.Context, tx neo4j.ManagedTransaction, repoIDs, names []string) ([]calleeCandidate, error) {
+	if len(repoIDs) == 0 || len(names) == 0 {
+		return nil, nil
+	}
+	records, err := tx.Run(ctx, `
+		MATCH (e:Function|Method)
+		WHERE e.repoId IN $repoIds AND e.name IN $names
+		RETURN e.id AS id, e.repoId AS repoId, e.name AS name, e.filePath AS filePath,
+		       e.className AS className, e:Function AS isFunction
+	`, map[string]any{"repoIds": repoIDs, "names": names})
+	if err != nil {
+		return nil, err
+	}
+	var candidates []calleeCandidate
+	for records.Next(ctx) {
+		rec := records.Record()
+		isFunction, _ := rec.Get("isFunction")
+		fn, _ := isFunction.(bool)
+		candidates = append(candidates, calleeCandidate{
+			ID:         recordString(rec, "id"),
+			RepoID:     recordString(rec, "repoId"),
+			Name:       recordString(rec, "name"),
+			FilePath:   recordString(rec, "filePath"),
+			ClassName:  recordString(rec, "className"),
+			IsFunction: fn,
+		})
+	}
+	return candidates, records.Err()
+}
+
+// readDirectories returns the directory paths of the given repositories
+func readDirectories(ctx context.Context, tx neo4j.ManagedTransaction, repoIDs []string) (map[string]map[string]bool, error) {
+	dirs := make(map[string]map[string]bool)
+	if len(repoIDs) == 0 {
+		return dirs, nil
+	}
+	records, err := tx.Run(ctx, `
+		MATCH (d:Directory)
+		WHERE d.repoId IN $repoIds
+		RETURN d.repoId AS repoId, d.path AS path
+	`, map[string]any{"repoIds": repoIDs})
+	if err != nil {
+		return nil, err
+	}
+	for records.Next(ctx) {
+		rec := records.Record()
+		repoID := recordString(rec, "repoId")
+		if dirs[repoID] == nil {
+			dirs[repoID] = make(map[string]bool)
+		}
+		dirs[repoID][recordString(rec, "path")] = true
+	}
+	return dirs, records.Err()
+}
+
+// recordString returns a string column, or "" when it is null
+func recordString(rec *neo4j.Record, key string) string {
+	v, _ := rec.Get(key)
+	s, _ := v.(string)
+	return s
+}
+
+// recordStrings returns a list-of-strings column, or nil when it is null
+func recordStrings(rec *neo4j.Record, key string) []string {
+	v, _ := rec.Get(key)
+	items, _ := v.([]any)
+	var out []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// sortedKeys returns the keys of a set, sorted
+func sortedKeys(set map[string]bool) []string {
+	out := make([]string, 0, len(set))
+	for k := range set {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// GetSystemGraph returns every repository, or those of repoIDs unless nil,
+// and the DEPENDS_ON edges between them, with the number of imports and
+// calls behind each edge
+func (r *GraphReader) GetSystemGraph(ctx context.Context, repoIDs []string) (*GraphData, error) {
+	params := map[string]any{"repoIds": nil}
+	if repoIDs != nil {
+		params["repoIds"] = repoIDs
+	}
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		graph := &GraphData{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+
+		records, err := tx.Run(ctx, `
+			MATCH (r:Repository)
+			WHERE $repoIds IS NULL OR r.id IN $repoIds
+			RETURN r.id AS id, r.name AS name, r.filesCount AS filesCount, r.status AS status
+			ORDER BY r.name
+		`, params)
+		if err != nil {
+			return nil, err
+		}
+		for records.Next(ctx) {
+			rec := records.Record()
+			filesCount, _ := rec.Get("filesCount")
+			graph.Nodes = append(graph.Nodes, GraphNode{
+				ID:    recordString(rec, "id"),
+				Label: recordString(rec, "name"),
+				Type:  "Repository",
+				Props: map[string]any{
+					"filesCount": filesCount,
+					"status":     recordString(rec, "status"),
+				},
+			})
+		}
+		if err := records.Err(); err != nil {
+			return nil, err
+		}
+
+		records, err = tx.Run(ctx, `
+			MATCH (a:Repository)-[d:DEPENDS_ON]->(b:Repository)
+			WHERE $repoIds IS NULL OR (a.id IN $repoIds AND b.id IN $repoIds)
+			RETURN a.id AS source, b.id AS target, d.imports AS imports, d.calls AS calls
+		`, params)
+		if err != nil {
+			return nil, err
+		}
+		for records.Next(ctx) {
+			rec := records.Record()
+			source, target := recordString(rec, "source"), recordString(rec, "target")
+			importsCount, _ := rec.Get("imports")
+			callsCount, _ := rec.Get("calls")
+			graph.Edges = append(graph.Edges, GraphEdge{
+				ID:     fmt.Sprintf("%s->%s", source, target),
+				Source: source,
+				Target: target,
+				Type:   "DEPENDS_ON",
+				Props: map[string]any{
+					"imports": importsCount,
+					"calls":   callsCount,
+				},
+			})
+		}
+		return graph, records.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*GraphData), nil
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestExternalCallEncoding tests that external calls survive storage
+func TestExternalCallEncoding(t *testing.T) {
+	call := analysis.ExternalCall{ImportPath: "payments.models", ClassName: "Card", Name: "load"}
+	decoded, ok := decodeExternalCall(encodeExternalCall(call))
+	assert.True(t, ok)
+	assert.Equal(t, call, decoded)
+
+	_, ok = decodeExternalCall("garbage")
+	assert.False(t, ok)
+}
+
+// TestMatchImport tests that imports resolve to the longest module of other repositories
+func TestMatchImport(t *testing.T) {
+	modules := map[string][]string{
+		"app":     {"github.com/acme/app"},
+		"billing": {"github.com/acme/billing", "github.com/acme/billing/sdk"},
+		"other":   {"github.com/acme/other"},
+	}
+
+	matches := matchImport("github.com/acme/billing/sdk/invoice", "go", "app", modules)
+	assert.Equal(t, []moduleMatch{{repoID: "billing", dirs: []string{"invoice"}}}, matches)
+
+	// Imports of a repository's own module are not cross-repository
+	assert.Empty(t, matchImport("github.com/acme/app/store", "go", "app", modules))
+	assert.Empty(t, matchImport("fmt", "go", "app", modules))
+}
+
+// TestPickCallee tests that only a single candidate in the imported package is linked
+func TestPickCallee(t *testing.T) {
+	candidates := []calleeCandidate{
+		{ID: "create", RepoID: "billing", Name: "Create", FilePath: "invoice/invoice.go", IsFunction: true},
+		{ID: "nested", RepoID: "billing", Name: "Create", FilePath: "invoice/pdf/pdf.go", IsFunction: true},
+		{ID: "method", RepoID: "billing", Name: "Create", FilePath: "invoice/store.go", ClassName: "Store"},
+		{ID: "load-a", RepoID: "billing", Name: "Load", FilePath: "cards/a.go", IsFunction: true},
+		{ID: "load-b", RepoID: "billing", Name: "Load", FilePath: "cards/b.go", IsFunction: true},
+	}
+	invoice := moduleMatch{repoID: "billing", dirs: []string{"invoice"}}
+
+	id, ok := pickCallee(candidates, analysis.ExternalCall{Name: "Create"}, invoice)
+	assert.True(t, ok)
+	assert.Equal(t, "create", id)
+
+	id, ok = pickCallee(candidates, analysis.ExternalCall{ClassName: "Store", Name: "Create"}, invoice)
+	assert.True(t, ok)
+	assert.Equal(t, "method", id)
+
+	// Two functions of the same name in a package are ambiguous
+	_, ok = pickCallee(candidates, analysis.ExternalCall{Name: "Load"}, moduleMatch{repoID: "billing", dirs: []string{"cards"}})
+	assert.False(t, ok)
+
+	// Python imports fall back to the parent package directory
+	id, ok = pickCallee(candidates, analysis.ExternalCall{Name: "Create"}, moduleMatch{repoID: "billing", dirs: []string{"invoice/invoice", "invoice"}})
+	assert.True(t, ok)
+	assert.Equal(t, "create", id)
+}
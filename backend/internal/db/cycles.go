@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// CycleFunction is a function taking part in a call cycle
+type CycleFunction struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	FilePath  string `json:"filePath"`
+	StartLine int    `json:"startLine,omitempty"`
+}
+
+// CallCycle is a group of functions that all reach each other through calls,
+// or a single function calling itself
+type CallCycle struct {
+	Size      int             `json:"size"`
+	Functions []CycleFunction `json:"functions"`
+	Edges     []GraphEdge     `json:"edges"` // CALLS edges within the group
+}
+
+// CallCycles are the call cycles of a repository, largest first
+type CallCycles struct {
+	Cycles    []CallCycle `json:"cycles"`
+	Total     int         `json:"total"`     // cycles found, even past the limit
+	Functions int         `json:"functions"` // functions in any cycle
+}
+
+// GetCallCycles finds the strongly connected components of a repository's
+// call graph and returns up to limit of them with their functions and the
+// calls between them
+func (r *GraphReader) GetCallCycles(ctx context.Context, repoID string, limit int) (*CallCycles, error) {
+	ctx = WithRepository(ctx, repoID)
+	type callGraph struct {
+		functions []callGraphFunction
+		calls     []models.CallRelation
+	}
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		functions, calls, err := readCallGraph(ctx, tx, repoID)
+		return callGraph{functions, calls}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	graph := result.(callGraph)
+
+	byID := make(map[string]callGraphFunction, len(graph.functions))
+	for _, fn := range graph.functions {
+		byID[fn.ID] = fn
+	}
+
+	groups := analysis.FindCallCycles(graph.calls)
+	cycles := &CallCycles{Cycles: []CallCycle{}, Total: len(groups)}
+	group := make(map[string]int) // function ID -> index into cycles
+	for i, ids := range groups {
+		cycles.Functions += len(ids)
+		if i >= limit {
+			continue
+		}
+		cycle := CallCycle{Size: len(ids), Functions: make([]CycleFunction, len(ids)), Edges: []GraphEdge{}}
+		for j, id := range ids {
+			fn := byID[id]
+			cycle.Functions[j] = CycleFunction{ID: id, Name: fn.Name, Type: fn.Type, FilePath: fn.FilePath, StartLine: fn.StartLine}
+			group[id] = i
+		}
+		cycles.Cycles = append(cycles.Cycles, cycle)
+	}
+
+	for _, call := range graph.calls {
+		i, ok := group[call.CallerID]
+		if j, same := group[call.CalleeID]; !ok || !same || i != j {
+			continue
+		}
+		cycles.Cycles[i].Edges = append(cycles.Cycles[i].Edges, GraphEdge{
+			ID:     fmt.Sprintf("%s->%s", call.CallerID, call.CalleeID),
+			Source: call.CallerID,
+			Target: call.CalleeID,
+			Type:   "CALLS",
+		})
+	}
+	return cycles, nil
+}
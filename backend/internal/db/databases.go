@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// repositoryDatabasePrefix starts the name of every repository database
+const repositoryDatabasePrefix = "repo-"
+
+// databaseNameInvalid matches characters Neo4j database names may not contain
+var databaseNameInvalid = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+type repositoryKey struct{}
+
+// WithRepository scopes ctx to a repository's code graph. With per-repository
+// databases, sessions opened with the returned context use that repository's
+// database; otherwise it has no effect. Methods that take a repository ID
+// scope themselves.
+func WithRepository(ctx context.Context, repoID string) context.Context {
+	return context.WithValue(ctx, repositoryKey{}, repoID)
+}
+
+// catalog scopes ctx back to the shared database holding repositories, index
+// runs and wikis
+func catalog(ctx context.Context) context.Context {
+	return context.WithValue(ctx, repositoryKey{}, "")
+}
+
+func (c *Neo4jClient) databaseFor(ctx context.Context) string {
+	if c.perRepository {
+		if repoID, _ := ctx.Value(repositoryKey{}).(string); repoID != "" {
+			return RepositoryDatabase(repoID)
+		}
+	}
+	return c.database
+}
+
+// PerRepositoryDatabases reports whether each repository's code graph lives in
+// its own database
+func (c *Neo4jClient) PerRepositoryDatabases() bool {
+	return c.perRepository
+}
+
+// RepositoryDatabase names the database of a repository: lowercase letters,
+// digits, dots and dashes, at most 63 characters
+func RepositoryDatabase(repoID string) string {
+	name := repositoryDatabasePrefix + databaseNameInvalid.ReplaceAllString(strings.ToLower(repoID), "-")
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return name
+}
+
+// CreateRepositoryDatabase creates a repository's database if it does not
+// exist yet, with the Repository node its graph hangs off and the vector
+// and full-text indexes. It does nothing unless repositories have their own
+// databases.
+func CreateRepositoryDatabase(ctx context.Context, client *Neo4jClient, repo *models.Repository) error {
+	if !client.perRepository {
+		return nil
+	}
+
+	name := RepositoryDatabase(repo.ID)
+	if err := client.runSystem(ctx, "CREATE DATABASE $name IF NOT EXISTS WAIT", name); err != nil {
+		return fmt.Errorf("failed to create database %s: %w", name, err)
+	}
+
+	ctx = WithRepository(ctx, repo.ID)
+	_, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MERGE (r:Repository {id: $id})
+			SET r.url = $url, r.name = $name
+		`
+		_, err := tx.Run(ctx, query, map[string]any{
+			"id":   repo.ID,
+			"url":  repo.URL,
+			"name": repo.Name,
+		})
+		return nil, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize database %s: %w", name, err)
+	}
+	if err := client.CreateVectorIndex(ctx); err != nil {
+		return err
+	}
+	return client.CreateFulltextIndex(ctx)
+}
+
+// DropRepositoryDatabase drops a repository's database with everything in
+// it. It does nothing unless repositories have their own databases.
+func DropRepositoryDatabase(ctx context.Context, client *Neo4jClient, repoID string) error {
+	if !client.perRepository {
+		return nil
+	}
+
+	name := RepositoryDatabase(repoID)
+	if err := client.runSystem(ctx, "DROP DATABASE $name IF EXISTS", name); err != nil {
+		return fmt.Errorf("failed to drop database %s: %w", name, err)
+	}
+	return nil
+}
+
+// runSystem runs an administration command on the system database. These
+// cannot run inside managed transactions.
+func (c *Neo4jClient) runSystem(ctx context.Context, command, database string) error {
+	session := c.driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: "system"})
+	defer session.Close(ctx)
+
+	result, err := session.Run(ctx, command, map[string]any{"name": database})
+	if err != nil {
+		return err
+	}
+	_, err = result.Consume(ctx)
+	return err
+}
+
+// forEachRepositoryDatabase runs fn against every repository database, or
+// once against the shared database when repositories do not have their own
+func (c *Neo4jClient) forEachRepositoryDatabase(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !c.perRepository {
+		return fn(ctx)
+	}
+
+	repos, err := ListRepositories(catalog(ctx), c)
+	if err != nil {
+		return err
+	}
+	for _, repo := range repos {
+		if err := fn(WithRepository(ctx, repo.ID)); err != nil {
+			return fmt.Errorf("repository %s: %w", repo.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// GetDeadCode reports the exported functions and methods of a repository
+// that nothing calls, and the files nothing imports or calls into. Calls
+// from other repositories and runtime samples count as uses. includeTests
+// also reports unused test helpers, see analysis.FindDeadCode.
+func (r *GraphReader) GetDeadCode(ctx context.Context, repoID string, includeTests bool) (*analysis.DeadCodeReport, error) {
+	files, err := r.GetFileLayers(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = WithRepository(ctx, repoID)
+	type callGraph struct {
+		functions []analysis.CodeFunction
+		calls     []models.CallRelation
+	}
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(f:File)-[:DECLARES]->(e:Function|Method)
+			OPTIONAL MATCH (e)-[:CALLS]->(callee:Function|Method)
+			WITH f, e, collect(callee.id) AS callees
+			RETURN e.id AS id, e.name AS name, labels(e) AS labels, e.filePath AS filePath,
+			       e.startLine AS startLine, coalesce(e.signature, '') AS signature, callees,
+			       coalesce(e.runtimeCount, 0) > 0
+			         OR ` + r.client.dialect.patternExists("()-[:CALLS_EXTERNAL]->(e)") + ` AS usedElsewhere,
+			       e.className IS NOT NULL
+			         AND ` + r.client.dialect.patternExists("(f)-[:DECLARES]->(:Class {name: e.className})-[:IMPLEMENTS]->()") + ` AS implements
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+
+		graph := callGraph{}
+		for records.Next(ctx) {
+			rec := records.Record()
+			fn := analysis.CodeFunction{
+				ID:        recordString(rec, "id"),
+				Name:      recordString(rec, "name"),
+				Type:      "Function",
+				FilePath:  recordString(rec, "filePath"),
+				Signature: recordString(rec, "signature"),
+			}
+			if sl, _ := rec.Get("startLine"); sl != nil {
+				fn.StartLine = int(sl.(int64))
+			}
+			if used, _ := rec.Get("usedElsewhere"); used != nil {
+				fn.UsedElsewhere = used.(bool)
+			}
+			if implements, _ := rec.Get("implements"); implements != nil {
+				fn.Implements = implements.(bool)
+			}
+			labels, _ := rec.Get("labels")
+			for _, label := range labels.([]any) {
+				if label == "Method" {
+					fn.Type = "Method"
+				}
+			}
+			graph.functions = append(graph.functions, fn)
+			for _, callee := range recordStrings(rec, "callees") {
+				graph.calls = append(graph.calls, models.CallRelation{CallerID: fn.ID, CalleeID: callee})
+			}
+		}
+		return graph, records.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	graph := result.(callGraph)
+	return analysis.FindDeadCode(files, graph.functions, graph.calls, includeTests), nil
+}
@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Dialect is the Cypher flavor spoken by the database behind the Bolt
+// connection. Queries are written for Neo4j; the few that differ elsewhere
+// are chosen by dialect.
+type Dialect string
+
+const (
+	DialectNeo4j    Dialect = "neo4j"
+	DialectMemgraph Dialect = "memgraph"
+)
+
+// ParseDialect validates a configured dialect; empty means Neo4j
+func ParseDialect(s string) (Dialect, error) {
+	switch d := Dialect(strings.ToLower(strings.TrimSpace(s))); d {
+	case "":
+		return DialectNeo4j, nil
+	case DialectNeo4j, DialectMemgraph:
+		return d, nil
+	default:
+		return "", fmt.Errorf("unknown database dialect %q, expected neo4j or memgraph", s)
+	}
+}
+
+// Dialect returns the Cypher flavor the client speaks
+func (c *Neo4jClient) Dialect() Dialect {
+	return c.dialect
+}
+
+// vectorIndexQuery creates the index of a vector space over the entities of
+// a label. Memgraph has no IF NOT EXISTS for it, see createVectorIndex.
+// Names of spaces are derived from [a-z0-9_] only, labels are fixed.
+func (d Dialect) vectorIndexQuery(space VectorSpace, label string) string {
+	if d == DialectMemgraph {
+		return fmt.Sprintf(`
+			CREATE VECTOR INDEX %s ON :%s(%s)
+			WITH CONFIG {"dimension": %d, "capacity": 1000000, "metric": "cos"}
+		`, space.LabelIndex(label), label, space.Property, space.Dimension)
+	}
+	return fmt.Sprintf(`
+		CREATE VECTOR INDEX %s IF NOT EXISTS
+		FOR (n:%s) ON (n.%s)
+		OPTIONS {indexConfig: {
+			`+"`"+`vector.dimensions`+"`"+`: %d,
+			`+"`"+`vector.similarity_function`+"`"+`: 'cosine'
+		}}
+	`, space.LabelIndex(label), label, space.Property, space.Dimension)
+}
+
+// dropVectorIndexQuery drops the index of a vector space over a label
+func (d Dialect) dropVectorIndexQuery(space VectorSpace, label string) string {
+	if d == DialectMemgraph {
+		return `DROP VECTOR INDEX ` + space.LabelIndex(label)
+	}
+	return `DROP INDEX ` + space.LabelIndex(label) + ` IF EXISTS`
+}
+
+// vectorQueryCall yields the nearest nodes of a label and their cosine
+// similarity to $embedding in a vector space as score. Neo4j scores cosine
+// indexes (1 + cosine) / 2, which leaves unrelated nodes above 0.5;
+// Memgraph's similarity is the cosine.
+func (d Dialect) vectorQueryCall(space VectorSpace, label string) string {
+	if d == DialectMemgraph {
+		return `
+			CALL vector_search.search('` + space.LabelIndex(label) + `', $limit, $embedding)
+			YIELD node, similarity
+			WITH node, similarity AS score
+		`
+	}
+	return `
+		CALL db.index.vector.queryNodes('` + space.LabelIndex(label) + `', $limit, $embedding)
+		YIELD node, score AS indexScore
+		WITH node, 2 * indexScore - 1 AS score
+	`
+}
+
+// keywordQueryCall yields the functions, methods and classes matching the
+// Lucene $query in a full-text index, with their score. Memgraph has no
+// full-text index, so the lowercased $text is looked for in the indexed
+// properties instead, exact names first.
+func (d Dialect) keywordQueryCall(index string) string {
+	if d == DialectMemgraph {
+		return `
+			MATCH (node)
+			WHERE (node:Function OR node:Method OR node:Class)
+			  AND any(p IN [node.name, node.signature, node.docstring] WHERE toLower(p) CONTAINS $text)
+			WITH node, CASE
+			    WHEN toLower(node.name) = $text THEN 2.0
+			    WHEN toLower(node.name) STARTS WITH $text THEN 1.5
+			    ELSE 1.0
+			END AS score
+			ORDER BY score DESC
+			LIMIT $limit
+		`
+	}
+	return `
+		CALL db.index.fulltext.queryNodes('` + index + `', $query, {limit: $limit})
+		YIELD node, score
+	`
+}
+
+// patternExists is a predicate holding when pattern matches. Memgraph has no
+// EXISTS subqueries; Neo4j 5 dropped the exists() function form.
+func (d Dialect) patternExists(pattern string) string {
+	if d == DialectMemgraph {
+		return `exists(` + pattern + `)`
+	}
+	return `EXISTS { MATCH ` + pattern + ` }`
+}
+
+// elementID is an expression identifying a node or relationship within
+// the database. Memgraph has no elementId().
+func (d Dialect) elementID(variable string) string {
+	if d == DialectMemgraph {
+		return `toString(id(` + variable + `))`
+	}
+	return `elementId(` + variable + `)`
+}
+
+// isExistingIndex reports whether creating an index failed because it exists
+func isExistingIndex(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "already exists")
+}
+
+// isMissingIndex reports whether dropping an index failed because it does
+// not exist
+func isMissingIndex(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "does not exist") || strings.Contains(msg, "doesn't exist")
+}
+
+// runAutoCommit runs a query outside a managed transaction, which Memgraph
+// requires for index changes
+func (c *Neo4jClient) runAutoCommit(ctx context.Context, query string) error {
+	session := c.Session(ctx)
+	defer session.Close(ctx)
+
+	result, err := session.Run(ctx, query, nil)
+	if err != nil {
+		return err
+	}
+	_, err = result.Consume(ctx)
+	return err
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// docLabels are the documentation labels with a vector index in every
+// space: wiki pages and the long docstrings of entities, embedded on their
+// own. Separate indexes keep prose from crowding code out of code search.
+var docLabels = []string{"WikiPage", "Docstring"}
+
+// Kinds of search results besides functions, methods and classes
+const (
+	ResultWiki      = "wiki"      // a wiki page, ID and WikiSlug naming it
+	ResultDocstring = "docstring" // an entity's docstring, ID naming the entity
+)
+
+// docKinds are the result kinds of docLabels
+var docKinds = map[string]string{"WikiPage": ResultWiki, "Docstring": ResultDocstring}
+
+// docReturns are the properties doc search returns of each of docLabels,
+// named as readDocResult reads them
+var docReturns = map[string]string{
+	"WikiPage": `node.id AS id, node.title AS name, node.slug AS wikiSlug, node.content AS content`,
+	"Docstring": `node.entityId AS id, node.name AS name, node.signature AS signature,
+		node.filePath AS filePath, node.docstring AS content,
+		node.startLine AS startLine, node.endLine AS endLine`,
+}
+
+// DocSearch performs semantic search over the wiki pages and docstrings of
+// one repository, or all of them when repoID is empty, keeping those of
+// the filter's repositories scoring at least its MinScore. Docstrings live
+// with the code graph and wiki pages in the catalog database.
+func (r *GraphReader) DocSearch(ctx context.Context, space VectorSpace, embedding []float32, limit int, repoID string, filter SearchFilter) ([]SearchResult, error) {
+	if err := space.CheckDimension(embedding); err != nil {
+		return nil, err
+	}
+
+	// Wiki pages have no language, type or path to narrow them by
+	filter = SearchFilter{RepoIDs: filter.RepoIDs, MinScore: filter.MinScore}
+	results, err := r.docSearch(catalog(ctx), space, "WikiPage", embedding, limit, repoID, filter)
+	if err != nil {
+		return nil, err
+	}
+	collect := func(ctx context.Context) error {
+		found, err := r.docSearch(ctx, space, "Docstring", embedding, limit, repoID, filter)
+		results = append(results, found...)
+		return err
+	}
+	if repoID != "" || !r.client.PerRepositoryDatabases() {
+		err = collect(WithRepository(ctx, repoID))
+	} else {
+		err = r.client.forEachRepositoryDatabase(ctx, collect)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return filter.aboveMinScore(results), nil
+}
+
+// docSearch queries the index of one of docLabels for the nodes passing
+// filter
+func (r *GraphReader) docSearch(ctx context.Context, space VectorSpace, label string, embedding []float32, limit int, repoID string, filter SearchFilter) ([]SearchResult, error) {
+	where, params := filter.whereClause()
+	query := r.client.dialect.vectorQueryCall(space, label) + `
+		MATCH (r:Repository {id: node.repoId})
+		WHERE ($repoId IS NULL OR r.id = $repoId) AND ` + where + `
+		RETURN ` + docReturns[label] + `, r.id AS repoId, r.name AS repoName, score
+		ORDER BY score DESC
+	`
+	params["embedding"] = embedding
+	params["limit"] = filter.candidates(limit)
+	params["repoId"] = nil
+	if repoID != "" {
+		params["repoId"] = repoID
+	}
+
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run doc search query: %w", err)
+		}
+
+		results := []SearchResult{}
+		for records.Next(ctx) {
+			results = append(results, readDocResult(records.Record(), docKinds[label]))
+		}
+		return results, records.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]SearchResult), nil
+}
+
+// readDocResult reads a doc search result of a kind
+func readDocResult(rec *neo4j.Record, kind string) SearchResult {
+	result := SearchResult{
+		ID:        recordString(rec, "id"),
+		Name:      recordString(rec, "name"),
+		Signature: recordString(rec, "signature"),
+		FilePath:  recordString(rec, "filePath"),
+		RepoID:    recordString(rec, "repoId"),
+		RepoName:  recordString(rec, "repoName"),
+		Kind:      kind,
+		WikiSlug:  recordString(rec, "wikiSlug"),
+	}
+	if score, _ := rec.Get("score"); score != nil {
+		result.Score, _ = score.(float64)
+	}
+	readSearchDetails(rec, &result)
+	return result
+}
@@ -0,0 +1,168 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// duplicatesKept bounds the SIMILAR_TO edges stored per repository, so a
+// low threshold cannot flood the graph
+const duplicatesKept = 1000
+
+// DuplicateFunction is one side of a possible duplicate
+type DuplicateFunction struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	FilePath  string `json:"filePath"`
+	StartLine int    `json:"startLine,omitempty"`
+	EndLine   int    `json:"endLine,omitempty"`
+}
+
+// DuplicatePair is two functions whose embeddings are nearly the same
+type DuplicatePair struct {
+	Score float64           `json:"score"` // cosine similarity
+	A     DuplicateFunction `json:"a"`
+	B     DuplicateFunction `json:"b"`
+}
+
+// WriteDuplicates compares the embeddings of a repository's functions and
+// methods in the searched vector space and replaces its SIMILAR_TO edges
+// with one per pair scoring at least threshold, directed from the lower ID
+// and carrying the score. It returns how many pairs were stored.
+func (w *GraphWriter) WriteDuplicates(ctx context.Context, repoID string, threshold float64) (int, error) {
+	ctx = WithRepository(ctx, repoID)
+	space, _ := w.client.VectorSpaces()
+
+	type embeddings struct {
+		ids     []string
+		vectors [][]float32
+	}
+	result, err := w.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (e:Function|Method {repoId: $repoId})
+			WHERE e.` + "`" + space.Property + "`" + ` IS NOT NULL
+			RETURN e.id AS id, e.` + "`" + space.Property + "`" + ` AS embedding
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+
+		found := embeddings{}
+		for records.Next(ctx) {
+			rec := records.Record()
+			v, _ := rec.Get("embedding")
+			values, _ := v.([]any)
+			vector := make([]float32, 0, len(values))
+			for _, x := range values {
+				if f, ok := x.(float64); ok {
+					vector = append(vector, float32(f))
+				}
+			}
+			found.ids = append(found.ids, recordString(rec, "id"))
+			found.vectors = append(found.vectors, vector)
+		}
+		return found, records.Err()
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read embeddings: %w", err)
+	}
+
+	found := result.(embeddings)
+	pairs := analysis.FindSimilarPairs(found.ids, found.vectors, threshold, duplicatesKept)
+	rows := make([]map[string]any, len(pairs))
+	for i, p := range pairs {
+		rows[i] = map[string]any{"a": p.A, "b": p.B, "score": p.Score}
+	}
+
+	_, err = w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		stale := `
+			MATCH (:Function|Method {repoId: $repoId})-[s:SIMILAR_TO]->()
+			DELETE s
+		`
+		if _, err := tx.Run(ctx, stale, map[string]any{"repoId": repoID}); err != nil {
+			return nil, err
+		}
+
+		query := `
+			UNWIND $rows AS row
+			MATCH (a:Function|Method {id: row.a, repoId: $repoId})
+			MATCH (b:Function|Method {id: row.b, repoId: $repoId})
+			CREATE (a)-[:SIMILAR_TO {score: row.score}]->(b)
+		`
+		_, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "rows": rows})
+		return nil, err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to write duplicates: %w", err)
+	}
+	return len(pairs), nil
+}
+
+// GetDuplicates returns the pairs of possibly duplicated functions found by
+// the last WriteDuplicates scoring at least minScore, most similar first
+func (r *GraphReader) GetDuplicates(ctx context.Context, repoID string, minScore float64, limit int) ([]DuplicatePair, error) {
+	ctx = WithRepository(ctx, repoID)
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (a:Function|Method {repoId: $repoId})-[s:SIMILAR_TO]->(b:Function|Method)
+			WHERE s.score >= $minScore
+			RETURN s.score AS score,
+			       a.id AS aId, a.name AS aName, labels(a) AS aLabels, a.filePath AS aFilePath,
+			       a.startLine AS aStartLine, a.endLine AS aEndLine,
+			       b.id AS bId, b.name AS bName, labels(b) AS bLabels, b.filePath AS bFilePath,
+			       b.startLine AS bStartLine, b.endLine AS bEndLine
+			ORDER BY score DESC, aFilePath, aStartLine
+			LIMIT $limit
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "minScore": minScore, "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+
+		pairs := []DuplicatePair{}
+		for records.Next(ctx) {
+			rec := records.Record()
+			pair := DuplicatePair{
+				A: duplicateFunction(rec, "a"),
+				B: duplicateFunction(rec, "b"),
+			}
+			if score, _ := rec.Get("score"); score != nil {
+				pair.Score = score.(float64)
+			}
+			pairs = append(pairs, pair)
+		}
+		return pairs, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]DuplicatePair), nil
+}
+
+// duplicateFunction reads the function returned under a prefix
+func duplicateFunction(rec *neo4j.Record, prefix string) DuplicateFunction {
+	fn := DuplicateFunction{
+		ID:       recordString(rec, prefix+"Id"),
+		Name:     recordString(rec, prefix+"Name"),
+		FilePath: recordString(rec, prefix+"FilePath"),
+		Type:     "Function",
+	}
+	if sl, _ := rec.Get(prefix + "StartLine"); sl != nil {
+		fn.StartLine = int(sl.(int64))
+	}
+	if el, _ := rec.Get(prefix + "EndLine"); el != nil {
+		fn.EndLine = int(el.(int64))
+	}
+	for _, label := range recordStrings(rec, prefix+"Labels") {
+		if label == "Method" {
+			fn.Type = "Method"
+		}
+	}
+	return fn
+}
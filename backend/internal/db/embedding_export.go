@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// exportBatchSize bounds how many records are read per transaction
+const exportBatchSize = 1000
+
+// EmbeddingNode is an entity in an embedding export; Embedding is empty for
+// entities indexed without the embedding service
+type EmbeddingNode struct {
+	Kind      string    `json:"kind"` // "node"
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Name      string    `json:"name"`
+	FilePath  string    `json:"filePath"`
+	Embedding []float64 `json:"embedding,omitempty"`
+}
+
+// EmbeddingEdge is a relationship between two exported entities
+type EmbeddingEdge struct {
+	Kind   string `json:"kind"` // "edge"
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"`
+}
+
+// ExportEmbeddings passes every entity of a repository to emit, ordered by
+// ID, with its vector in the searched space, then every CALLS, MEMBER_OF,
+// READS and WRITES edge between them.
+// Records are read in batches, each in its own transaction, so a repository
+// of any size is exported without holding it in memory.
+func (r *GraphReader) ExportEmbeddings(ctx context.Context, repoID string, emit func(record any) error) error {
+	ctx = WithRepository(ctx, repoID)
+	space, _ := r.client.VectorSpaces()
+
+	nodeQuery := `
+		MATCH (n:Function|Method|Class|Variable {repoId: $repoId})
+		RETURN n.id as id, labels(n) as labels, n.name as name,
+		       n.filePath as filePath, n.` + "`" + space.Property + "`" + ` as embedding
+		ORDER BY n.id
+		SKIP $skip LIMIT $limit
+	`
+	err := r.exportBatches(ctx, repoID, nodeQuery, emit, func(rec *neo4j.Record) any {
+		node := EmbeddingNode{
+			Kind:     "node",
+			ID:       recordString(rec, "id"),
+			Name:     recordString(rec, "name"),
+			FilePath: recordString(rec, "filePath"),
+		}
+		if labels := recordStrings(rec, "labels"); len(labels) > 0 {
+			node.Type = labels[0]
+		}
+		if v, _ := rec.Get("embedding"); v != nil {
+			for _, x := range v.([]any) {
+				if f, ok := x.(float64); ok {
+					node.Embedding = append(node.Embedding, f)
+				}
+			}
+		}
+		return node
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export entities: %w", err)
+	}
+
+	edgeQuery := `
+		MATCH (a:Function|Method|Class|Variable {repoId: $repoId})-[e:CALLS|MEMBER_OF|READS|WRITES]->(b:Function|Method|Class|Variable {repoId: $repoId})
+		RETURN a.id as source, b.id as target, type(e) as type
+		ORDER BY a.id, b.id, type
+		SKIP $skip LIMIT $limit
+	`
+	err = r.exportBatches(ctx, repoID, edgeQuery, emit, func(rec *neo4j.Record) any {
+		return EmbeddingEdge{
+			Kind:   "edge",
+			Source: recordString(rec, "source"),
+			Target: recordString(rec, "target"),
+			Type:   recordString(rec, "type"),
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export edges: %w", err)
+	}
+	return nil
+}
+
+// exportBatches runs a paged query batch by batch, emitting each record
+// once its batch's transaction has finished
+func (r *GraphReader) exportBatches(ctx context.Context, repoID, query string, emit func(any) error, convert func(*neo4j.Record) any) error {
+	for skip := 0; ; skip += exportBatchSize {
+		result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			records, err := tx.Run(ctx, query, map[string]any{
+				"repoId": repoID,
+				"skip":   skip,
+				"limit":  exportBatchSize,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			batch := make([]any, 0, exportBatchSize)
+			for records.Next(ctx) {
+				batch = append(batch, convert(records.Record()))
+			}
+			return batch, records.Err()
+		})
+		if err != nil {
+			return err
+		}
+
+		batch := result.([]any)
+		for _, record := range batch {
+			if err := emit(record); err != nil {
+				return err
+			}
+		}
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+	}
+}
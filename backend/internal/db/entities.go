@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// EntitySummary is the stored description of a code entity
+type EntitySummary struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Signature string `json:"signature,omitempty"`
+	Docstring string `json:"docstring,omitempty"`
+	FilePath  string `json:"filePath"`
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+	RepoID    string `json:"repoId"`
+	RepoURL   string `json:"repoUrl"`
+}
+
+// GetEntitiesByIDs returns the functions, methods and classes with the given
+// IDs, in the order requested. Unknown IDs are skipped.
+func (r *GraphReader) GetEntitiesByIDs(ctx context.Context, ids []string) ([]EntitySummary, error) {
+	if !r.client.PerRepositoryDatabases() {
+		return r.getEntitiesByIDs(ctx, ids)
+	}
+
+	// The IDs may belong to any repository database
+	byID := make(map[string]EntitySummary)
+	err := r.client.forEachRepositoryDatabase(ctx, func(ctx context.Context) error {
+		found, err := r.getEntitiesByIDs(ctx, ids)
+		for _, entity := range found {
+			byID[entity.ID] = entity
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entities := []EntitySummary{}
+	for _, id := range ids {
+		if entity, ok := byID[id]; ok {
+			entities = append(entities, entity)
+		}
+	}
+	return entities, nil
+}
+
+func (r *GraphReader) getEntitiesByIDs(ctx context.Context, ids []string) ([]EntitySummary, error) {
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			UNWIND range(0, size($ids) - 1) AS i
+			MATCH (e:Function|Method|Class {id: $ids[i]})
+			MATCH (r:Repository {id: e.repoId})
+			RETURN e, labels(e) AS labels, r.url AS repoUrl
+			ORDER BY i
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"ids": ids})
+		if err != nil {
+			return nil, err
+		}
+
+		entities := []EntitySummary{}
+		for records.Next(ctx) {
+			rec := records.Record()
+
+			nodeRaw, _ := rec.Get("e")
+			props := nodeRaw.(neo4j.Node).GetProperties()
+
+			entity := EntitySummary{
+				ID:        stringProp(props, "id"),
+				Name:      stringProp(props, "name"),
+				Signature: stringProp(props, "signature"),
+				Docstring: stringProp(props, "docstring"),
+				FilePath:  stringProp(props, "filePath"),
+				RepoID:    stringProp(props, "repoId"),
+			}
+			if sl, ok := props["startLine"].(int64); ok {
+				entity.StartLine = int(sl)
+			}
+			if el, ok := props["endLine"].(int64); ok {
+				entity.EndLine = int(el)
+			}
+
+			labelsRaw, _ := rec.Get("labels")
+			for _, label := range labelsRaw.([]any) {
+				entity.Type = label.(string)
+			}
+
+			if repoURL, _ := rec.Get("repoUrl"); repoURL != nil {
+				entity.RepoURL = repoURL.(string)
+			}
+
+			entities = append(entities, entity)
+		}
+
+		if err := records.Err(); err != nil {
+			return nil, err
+		}
+		return entities, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]EntitySummary), nil
+}
+
+// stringProp returns a string property, or an empty string if it is unset
+func stringProp(props map[string]any, key string) string {
+	if v, ok := props[key].(string); ok {
+		return v
+	}
+	return ""
+}
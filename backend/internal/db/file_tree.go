@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// DirectoryNode is a directory of a repository's file tree, the root having
+// an empty path. Counts cover everything below the directory, including
+// what a depth-limited tree leaves out.
+type DirectoryNode struct {
+	ID            string           `json:"id,omitempty"` // empty for directories without a node
+	Name          string           `json:"name"`
+	Path          string           `json:"path"`
+	IsPackage     bool             `json:"isPackage,omitempty"`
+	FileCount     int              `json:"fileCount"`
+	FunctionCount int              `json:"functionCount"`
+	Directories   []*DirectoryNode `json:"directories"`
+	Files         []FileNode       `json:"files"`
+	// set when the directory's contents were left out to limit depth
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// GetDirectoryTree returns a repository's files nested in their directories
+func (r *GraphReader) GetDirectoryTree(ctx context.Context, repoID string) (*DirectoryNode, error) {
+	files, err := r.GetFileTree(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = WithRepository(ctx, repoID)
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(d:Directory)
+			RETURN d.id AS id, d.path AS path, d:Package AS isPackage
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+
+		var dirs []DirectoryNode
+		for records.Next(ctx) {
+			rec := records.Record()
+			dir := DirectoryNode{ID: recordString(rec, "id"), Path: recordString(rec, "path")}
+			if isPackage, _ := rec.Get("isPackage"); isPackage != nil {
+				dir.IsPackage = isPackage.(bool)
+			}
+			dirs = append(dirs, dir)
+		}
+		return dirs, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return BuildDirectoryTree(result.([]DirectoryNode), files), nil
+}
+
+// BuildDirectoryTree nests files in their directories and adds up the
+// counts. Directories missing from dirs, as in repositories indexed before
+// directories became nodes, are made up from the file paths.
+func BuildDirectoryTree(dirs []DirectoryNode, files []FileNode) *DirectoryNode {
+	root := &DirectoryNode{Directories: []*DirectoryNode{}, Files: []FileNode{}}
+	byPath := map[string]*DirectoryNode{"": root}
+
+	// dir returns the node for a directory path, creating it and its
+	// ancestors as needed
+	var dir func(p string) *DirectoryNode
+	dir = func(p string) *DirectoryNode {
+		if node, ok := byPath[p]; ok {
+			return node
+		}
+		node := &DirectoryNode{Name: path.Base(p), Path: p, Directories: []*DirectoryNode{}, Files: []FileNode{}}
+		byPath[p] = node
+		parent := dir(parentDir(p))
+		parent.Directories = append(parent.Directories, node)
+		return node
+	}
+
+	for _, d := range dirs {
+		node := dir(strings.Trim(d.Path, "/"))
+		node.ID = d.ID
+		node.IsPackage = d.IsPackage
+	}
+	for _, f := range files {
+		p := parentDir(strings.ReplaceAll(f.Path, "\\", "/"))
+		node := dir(p)
+		node.Files = append(node.Files, f)
+		for ; ; p = parentDir(p) {
+			byPath[p].FileCount++
+			byPath[p].FunctionCount += len(f.Functions)
+			if p == "" {
+				break
+			}
+		}
+	}
+
+	for _, node := range byPath {
+		sort.Slice(node.Directories, func(i, j int) bool { return node.Directories[i].Name < node.Directories[j].Name })
+		sort.Slice(node.Files, func(i, j int) bool { return node.Files[i].Path < node.Files[j].Path })
+	}
+	return root
+}
+
+// parentDir is the directory of a slash-separated path, "" at the top
+func parentDir(p string) string {
+	if i := strings.LastIndex(p, "/"); i >= 0 {
+		return p[:i]
+	}
+	return ""
+}
+
+// Find returns the directory at path below d, or nil
+func (d *DirectoryNode) Find(p string) *DirectoryNode {
+	p = strings.Trim(p, "/")
+	if p == d.Path {
+		return d
+	}
+	for _, child := range d.Directories {
+		if p == child.Path || strings.HasPrefix(p, child.Path+"/") {
+			return child.Find(p)
+		}
+	}
+	return nil
+}
+
+// Prune returns a copy of d holding depth levels of directories; the
+// contents of the directories at the last level are left out. A depth of 0
+// or less returns d itself.
+func (d *DirectoryNode) Prune(depth int) *DirectoryNode {
+	if depth <= 0 {
+		return d
+	}
+	pruned := *d
+	pruned.Directories = make([]*DirectoryNode, len(d.Directories))
+	for i, child := range d.Directories {
+		if depth == 1 {
+			leaf := *child
+			leaf.Directories = []*DirectoryNode{}
+			leaf.Files = []FileNode{}
+			leaf.Truncated = child.FileCount > 0 || len(child.Directories) > 0
+			pruned.Directories[i] = &leaf
+		} else {
+			pruned.Directories[i] = child.Prune(depth - 1)
+		}
+	}
+	return &pruned
+}
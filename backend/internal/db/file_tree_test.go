@@ -0,0 +1,66 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBuildDirectoryTree tests nesting files in directories, made up
+// directories and the aggregate counts
+func TestBuildDirectoryTree(t *testing.T) {
+	dirs := []DirectoryNode{
+		{ID: "d-internal", Path: "internal"},
+		{ID: "d-store", Path: "internal/store", IsPackage: true},
+	}
+	files := []FileNode{
+		{ID: "f-main", Path: "main.go", Functions: []FunctionRef{{ID: "main"}}},
+		{ID: "f-store", Path: "internal/store/store.go", Functions: []FunctionRef{{ID: "Open"}, {ID: "Close"}}},
+		{ID: "f-cache", Path: "internal/cache/cache.go", Functions: []FunctionRef{{ID: "Get"}}},
+	}
+
+	root := BuildDirectoryTree(dirs, files)
+
+	assert.Equal(t, 3, root.FileCount)
+	assert.Equal(t, 4, root.FunctionCount)
+	require.Len(t, root.Files, 1)
+	assert.Equal(t, "main.go", root.Files[0].Path)
+
+	internal := root.Find("internal")
+	require.NotNil(t, internal)
+	assert.Equal(t, "d-internal", internal.ID)
+	assert.Equal(t, 2, internal.FileCount)
+	assert.Equal(t, 3, internal.FunctionCount)
+	require.Len(t, internal.Directories, 2)
+	assert.Equal(t, "cache", internal.Directories[0].Name) // made up, sorted by name
+	assert.Empty(t, internal.Directories[0].ID)
+	assert.True(t, internal.Directories[1].IsPackage)
+
+	assert.Same(t, internal.Directories[1], root.Find("/internal/store/"))
+	assert.Nil(t, root.Find("internal/missing"))
+}
+
+// TestDirectoryNodePrune tests that pruning keeps counts but drops contents
+// below the requested depth without changing the tree
+func TestDirectoryNodePrune(t *testing.T) {
+	root := BuildDirectoryTree(nil, []FileNode{
+		{ID: "f-a", Path: "a/b/c.go"},
+		{ID: "f-d", Path: "d.go"},
+	})
+
+	pruned := root.Prune(1)
+	require.Len(t, pruned.Directories, 1)
+	a := pruned.Directories[0]
+	assert.True(t, a.Truncated)
+	assert.Empty(t, a.Directories)
+	assert.Equal(t, 1, a.FileCount)
+	assert.Len(t, pruned.Files, 1)
+
+	deeper := root.Prune(2)
+	assert.False(t, deeper.Directories[0].Truncated)
+	assert.True(t, deeper.Directories[0].Directories[0].Truncated)
+
+	assert.Len(t, root.Directories[0].Directories, 1)
+	assert.Same(t, root, root.Prune(0))
+}
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/findings"
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// FindingDetail is a static analysis finding stored in the graph
+type FindingDetail struct {
+	ID        string `json:"id"`
+	Tool      string `json:"tool"`
+	RuleID    string `json:"ruleId"`
+	Level     string `json:"level"`
+	Message   string `json:"message"`
+	FilePath  string `json:"filePath"`
+	StartLine int    `json:"startLine,omitempty"`
+	EndLine   int    `json:"endLine,omitempty"`
+}
+
+// WriteFindings replaces the findings a repository has from the given tools
+// with new ones. Findings hang off the Repository and point at the entity
+// or file they were reported in with LOCATED_IN.
+func (w *GraphWriter) WriteFindings(ctx context.Context, repoID string, tools []string, located []findings.Located) error {
+	ctx = WithRepository(ctx, repoID)
+
+	rows := make([]map[string]any, len(located))
+	for i, f := range located {
+		rows[i] = map[string]any{
+			"id":        uuid.New().String(),
+			"tool":      f.Tool,
+			"ruleId":    f.RuleID,
+			"level":     f.Level,
+			"message":   f.Message,
+			"filePath":  f.FilePath,
+			"startLine": f.StartLine,
+			"endLine":   f.EndLine,
+			"entityId":  f.EntityID,
+		}
+	}
+
+	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:HAS_FINDING]->(f:Finding)
+			WHERE f.tool IN $tools
+			DETACH DELETE f
+		`
+		if _, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "tools": tools}); err != nil {
+			return nil, err
+		}
+
+		query = `
+			MATCH (r:Repository {id: $repoId})
+			UNWIND $rows AS row
+			CREATE (r)-[:HAS_FINDING]->(f:Finding {
+				id: row.id, repoId: $repoId, tool: row.tool, ruleId: row.ruleId,
+				level: row.level, message: row.message, filePath: row.filePath,
+				startLine: row.startLine, endLine: row.endLine, reportedAt: $reportedAt
+			})
+			WITH f, row
+			OPTIONAL MATCH (e:Function|Method|Class {id: row.entityId, repoId: $repoId})
+			OPTIONAL MATCH (file:File {repoId: $repoId, path: row.filePath})
+			WITH f, coalesce(e, file) AS target
+			WHERE target IS NOT NULL
+			SET f.entityId = target.id
+			MERGE (f)-[:LOCATED_IN]->(target)
+		`
+		_, err := tx.Run(ctx, query, map[string]any{
+			"repoId":     repoID,
+			"rows":       rows,
+			"reportedAt": time.Now().UTC(),
+		})
+		return nil, err
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to write findings: %w", err)
+	}
+	return nil
+}
+
+// readFindings returns the findings located in a node, and for a file also
+// those in the entities it declares, most severe first
+func readFindings(ctx context.Context, tx neo4j.ManagedTransaction, repoID, nodeID, filePath string) ([]FindingDetail, error) {
+	query := `
+		MATCH (r:Repository {id: $repoId})-[:HAS_FINDING]->(f:Finding)
+		WHERE f.entityId = $nodeId OR f.filePath = $filePath
+		RETURN f.id AS id, f.tool AS tool, f.ruleId AS ruleId, f.level AS level,
+		       f.message AS message, f.filePath AS filePath,
+		       f.startLine AS startLine, f.endLine AS endLine
+		ORDER BY CASE f.level WHEN 'error' THEN 0 WHEN 'warning' THEN 1 ELSE 2 END, f.startLine
+	`
+	params := map[string]any{"repoId": repoID, "nodeId": nodeID, "filePath": nil}
+	if filePath != "" {
+		params["filePath"] = filePath
+	}
+	records, err := tx.Run(ctx, query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []FindingDetail
+	for records.Next(ctx) {
+		rec := records.Record()
+		finding := FindingDetail{
+			ID:       recordString(rec, "id"),
+			Tool:     recordString(rec, "tool"),
+			RuleID:   recordString(rec, "ruleId"),
+			Level:    recordString(rec, "level"),
+			Message:  recordString(rec, "message"),
+			FilePath: recordString(rec, "filePath"),
+		}
+		if sl, _ := rec.Get("startLine"); sl != nil {
+			finding.StartLine = int(sl.(int64))
+		}
+		if el, _ := rec.Get("endLine"); el != nil {
+			finding.EndLine = int(el.(int64))
+		}
+		result = append(result, finding)
+	}
+	return result, records.Err()
+}
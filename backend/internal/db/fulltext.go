@@ -0,0 +1,165 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// fulltextIndex indexes the names, signatures and docstrings of functions,
+// methods and classes for keyword search
+const fulltextIndex = "entity_text"
+
+// luceneSpecial are the characters the Lucene query syntax reserves
+const luceneSpecial = `+-&|!(){}[]^"~*?:\/`
+
+// CreateFulltextIndexes creates the keyword search index in every
+// repository database
+func (c *Neo4jClient) CreateFulltextIndexes(ctx context.Context) error {
+	return c.forEachRepositoryDatabase(ctx, c.CreateFulltextIndex)
+}
+
+// CreateFulltextIndex creates the keyword search index. Memgraph has no
+// full-text index without experimental flags; keyword search scans instead.
+func (c *Neo4jClient) CreateFulltextIndex(ctx context.Context) error {
+	if c.dialect == DialectMemgraph {
+		return nil
+	}
+
+	_, err := c.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			CREATE FULLTEXT INDEX ` + fulltextIndex + ` IF NOT EXISTS
+			FOR (n:Function|Method|Class) ON EACH [n.name, n.signature, n.docstring]
+		`
+		_, err := tx.Run(ctx, query, nil)
+		return nil, err
+	})
+	return err
+}
+
+// keywordQuery turns search text into a Lucene query matching each word
+// exactly or as a prefix, exact matches scoring higher. Wildcard terms are
+// not analyzed, so they are lowercased like the index.
+func keywordQuery(text string) string {
+	var terms []string
+	for _, word := range strings.Fields(text) {
+		var b strings.Builder
+		for _, r := range word {
+			if strings.ContainsRune(luceneSpecial, r) {
+				b.WriteRune('\\')
+			}
+			b.WriteRune(r)
+		}
+		escaped := b.String()
+		terms = append(terms, fmt.Sprintf("(%s^2 OR %s*)", escaped, strings.ToLower(escaped)))
+	}
+	return strings.Join(terms, " AND ")
+}
+
+// KeywordSearch finds functions, methods and classes whose name, signature
+// or docstring contain the words of text, for exact identifier lookups
+// semantic search misses, keeping results passing filter
+func (r *GraphReader) KeywordSearch(ctx context.Context, text string, limit int, repoID string, filter SearchFilter) ([]SearchResult, error) {
+	if repoID != "" || !r.client.PerRepositoryDatabases() {
+		results, err := r.keywordSearch(WithRepository(ctx, repoID), text, limit, repoID, filter)
+		if err != nil {
+			return nil, err
+		}
+		return filter.aboveMinScore(relativeScores(results)), nil
+	}
+
+	results := []SearchResult{}
+	err := r.client.forEachRepositoryDatabase(ctx, func(ctx context.Context) error {
+		found, err := r.keywordSearch(ctx, text, limit, "", filter)
+		results = append(results, found...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return filter.aboveMinScore(relativeScores(results)), nil
+}
+
+// relativeScores scales the scores of a ranking to the best one's. Keyword
+// scores grow with term frequencies and have no fixed range; every result
+// matched the words searched for.
+func relativeScores(results []SearchResult) []SearchResult {
+	if len(results) == 0 || results[0].Score <= 0 {
+		return results
+	}
+	best := results[0].Score
+	for i := range results {
+		results[i].Score /= best
+	}
+	return results
+}
+
+func (r *GraphReader) keywordSearch(ctx context.Context, text string, limit int, repoID string, filter SearchFilter) ([]SearchResult, error) {
+	query := keywordQuery(text)
+	if query == "" {
+		return []SearchResult{}, nil
+	}
+
+	where, params := filter.whereClause()
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		cypher := r.client.dialect.keywordQueryCall(fulltextIndex) + `
+			MATCH (r:Repository {id: node.repoId})
+			WHERE ($repoId IS NULL OR r.id = $repoId) AND ` + where + `
+			RETURN node.id AS id, node.name AS name, node.signature AS signature,
+			       node.filePath AS filePath, r.id AS repoId, r.name AS repoName, score,
+			       node.docstring AS docstring, node.description AS description, node.startLine AS startLine,
+			       node.endLine AS endLine, node.content AS content
+			ORDER BY score DESC
+			LIMIT $results
+		`
+		params["query"] = query
+		params["text"] = strings.ToLower(strings.TrimSpace(text))
+		params["limit"] = filter.candidates(limit)
+		params["results"] = limit
+		params["repoId"] = nil
+		if repoID != "" {
+			params["repoId"] = repoID
+		}
+
+		records, err := tx.Run(ctx, cypher, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run keyword search query: %w", err)
+		}
+
+		results := []SearchResult{}
+		for records.Next(ctx) {
+			rec := records.Record()
+			result := SearchResult{
+				ID:        recordString(rec, "id"),
+				Name:      recordString(rec, "name"),
+				Signature: recordString(rec, "signature"),
+				FilePath:  recordString(rec, "filePath"),
+				RepoID:    recordString(rec, "repoId"),
+				RepoName:  recordString(rec, "repoName"),
+			}
+			if score, _ := rec.Get("score"); score != nil {
+				switch v := score.(type) {
+				case float64:
+					result.Score = v
+				case int64:
+					result.Score = float64(v)
+				}
+			}
+			readSearchDetails(rec, &result)
+			results = append(results, result)
+		}
+		return results, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]SearchResult), nil
+}
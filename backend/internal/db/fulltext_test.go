@@ -0,0 +1,15 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeywordQuery(t *testing.T) {
+	assert.Equal(t, "(NewNeo4jClient^2 OR newneo4jclient*)", keywordQuery("NewNeo4jClient"))
+	assert.Equal(t, "(graph^2 OR graph*) AND (reader^2 OR reader*)", keywordQuery("  graph reader "))
+	// Lucene syntax in the text is matched literally
+	assert.Equal(t, `(Get\(\)^2 OR get\(\)*) AND (a\:b^2 OR a\:b*)`, keywordQuery("Get() a:b"))
+	assert.Equal(t, "", keywordQuery("   "))
+}
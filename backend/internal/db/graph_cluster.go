@@ -0,0 +1,143 @@
+package db
+
+import (
+	"fmt"
+	"path"
+)
+
+// clusterPrefix starts the IDs of cluster nodes, followed by the directory
+const clusterPrefix = "cluster:"
+
+// ClusterID is the ID of the cluster node of a directory
+func ClusterID(dir string) string {
+	return clusterPrefix + dir
+}
+
+// ClusterGraph folds the nodes of a graph into one Cluster node per
+// directory, for graphs too large to draw node by node. Files belong to
+// their directory and entities to their file's; nodes of the expanded
+// directories are kept, as are nodes outside any file such as the
+// repository. Edges within a cluster are dropped and edges between the same
+// ends merged, with how many were merged in props.count.
+func ClusterGraph(graph *GraphData, expanded map[string]bool) *GraphData {
+	dirs := nodeDirectories(graph)
+	clustered := &GraphData{Nodes: []GraphNode{}, Edges: []GraphEdge{}, Clustered: true}
+
+	clusters := make(map[string]int) // directory -> index of its node
+	for _, node := range graph.Nodes {
+		dir, ok := dirs[node.ID]
+		if !ok || expanded[dir] {
+			clustered.Nodes = append(clustered.Nodes, node)
+			continue
+		}
+		if i, seen := clusters[dir]; seen {
+			clustered.Nodes[i].Props["nodes"] = clustered.Nodes[i].Props["nodes"].(int) + 1
+			continue
+		}
+		clusters[dir] = len(clustered.Nodes)
+		clustered.Nodes = append(clustered.Nodes, GraphNode{
+			ID:    ClusterID(dir),
+			Label: dir,
+			Type:  "Cluster",
+			Props: map[string]any{"path": dir, "nodes": 1},
+		})
+	}
+
+	clustered.Edges = clusterEdges(graph.Edges, dirs, expanded, nil)
+	return clustered
+}
+
+// ExpandCluster returns the nodes of a directory's cluster and the edges
+// touching them, with their other ends clustered as ClusterGraph would with
+// the directory and the already expanded ones expanded. Returns nil when no
+// node belongs to the directory.
+func ExpandCluster(graph *GraphData, dir string, expanded map[string]bool) *GraphData {
+	dirs := nodeDirectories(graph)
+	open := map[string]bool{dir: true}
+	for d := range expanded {
+		open[d] = true
+	}
+
+	members := make(map[string]bool)
+	sub := &GraphData{Nodes: []GraphNode{}, Edges: []GraphEdge{}, Clustered: true}
+	for _, node := range graph.Nodes {
+		if d, ok := dirs[node.ID]; ok && d == dir {
+			members[node.ID] = true
+			sub.Nodes = append(sub.Nodes, node)
+		}
+	}
+	if len(members) == 0 {
+		return nil
+	}
+
+	sub.Edges = clusterEdges(graph.Edges, dirs, open, members)
+	return sub
+}
+
+// clusterEdges moves the ends of edges in folded directories to their
+// cluster node, merging edges between the same ends. Only edges with an end
+// in touching are kept unless it is nil.
+func clusterEdges(edges []GraphEdge, dirs map[string]string, expanded map[string]bool, touching map[string]bool) []GraphEdge {
+	end := func(id string) string {
+		if dir, ok := dirs[id]; ok && !expanded[dir] {
+			return ClusterID(dir)
+		}
+		return id
+	}
+
+	out := []GraphEdge{}
+	merged := make(map[string]int) // ID -> index of a merged edge
+	for _, edge := range edges {
+		if touching != nil && !touching[edge.Source] && !touching[edge.Target] {
+			continue
+		}
+		source, target := end(edge.Source), end(edge.Target)
+		if source == edge.Source && target == edge.Target {
+			out = append(out, edge)
+			continue
+		}
+		if source == target {
+			continue
+		}
+
+		id := fmt.Sprintf("%s-%s->%s", edge.Type, source, target)
+		if i, ok := merged[id]; ok {
+			out[i].Props["count"] = out[i].Props["count"].(int) + 1
+			continue
+		}
+		merged[id] = len(out)
+		out = append(out, GraphEdge{
+			ID:     id,
+			Source: source,
+			Target: target,
+			Type:   edge.Type,
+			Props:  map[string]any{"count": 1},
+		})
+	}
+	return out
+}
+
+// nodeDirectories returns the directory each node of a graph belongs to:
+// a file's own, or that of the file of an entity, known from its filePath
+// or the file declaring it
+func nodeDirectories(graph *GraphData) map[string]string {
+	dirs := make(map[string]string, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		if node.Type == "File" {
+			dirs[node.ID] = path.Dir(node.Label)
+		} else if filePath, _ := node.Props["filePath"].(string); filePath != "" {
+			dirs[node.ID] = path.Dir(filePath)
+		}
+	}
+	for _, edge := range graph.Edges {
+		if edge.Type != "DECLARES" {
+			continue
+		}
+		if dir, ok := dirs[edge.Source]; ok {
+			if _, known := dirs[edge.Target]; !known {
+				dirs[edge.Target] = dir
+			}
+		}
+	}
+	return dirs
+}
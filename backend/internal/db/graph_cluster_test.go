@@ -0,0 +1,73 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clusterTestGraph is a structure graph of two directories, with calls
+// between their functions
+func clusterTestGraph() *GraphData {
+	return &GraphData{
+		Nodes: []GraphNode{
+			{ID: "repo", Label: "app", Type: "Repository"},
+			{ID: "f1", Label: "api/handlers.go", Type: "File"},
+			{ID: "h", Label: "Handle", Type: "Function"},
+			{ID: "f2", Label: "store/store.go", Type: "File"},
+			{ID: "g", Label: "Get", Type: "Function"},
+			{ID: "p", Label: "Put", Type: "Function", Props: map[string]any{"filePath": "store/store.go"}},
+		},
+		Edges: []GraphEdge{
+			{ID: "f1->h", Source: "f1", Target: "h", Type: "DECLARES"},
+			{ID: "f2->g", Source: "f2", Target: "g", Type: "DECLARES"},
+			{ID: "h->g", Source: "h", Target: "g", Type: "CALLS"},
+			{ID: "h->p", Source: "h", Target: "p", Type: "CALLS"},
+			{ID: "g->p", Source: "g", Target: "p", Type: "CALLS"},
+		},
+	}
+}
+
+// TestClusterGraph tests folding a graph into directory clusters
+func TestClusterGraph(t *testing.T) {
+	clustered := ClusterGraph(clusterTestGraph(), nil)
+	assert.True(t, clustered.Clustered)
+	assert.Equal(t, []GraphNode{
+		{ID: "repo", Label: "app", Type: "Repository"},
+		{ID: "cluster:api", Label: "api", Type: "Cluster", Props: map[string]any{"path": "api", "nodes": 2}},
+		{ID: "cluster:store", Label: "store", Type: "Cluster", Props: map[string]any{"path": "store", "nodes": 3}},
+	}, clustered.Nodes)
+	// Both calls out of api merge; calls within store are dropped
+	assert.Equal(t, []GraphEdge{
+		{ID: "CALLS-cluster:api->cluster:store", Source: "cluster:api", Target: "cluster:store", Type: "CALLS", Props: map[string]any{"count": 2}},
+	}, clustered.Edges)
+
+	// Expanded directories keep their nodes
+	clustered = ClusterGraph(clusterTestGraph(), map[string]bool{"api": true})
+	assert.Len(t, clustered.Nodes, 4)
+	assert.Equal(t, "f1->h", clustered.Edges[0].ID)
+	assert.Equal(t, "cluster:store", clustered.Edges[1].Target)
+	assert.Equal(t, 2, clustered.Edges[1].Props["count"])
+}
+
+// TestExpandCluster tests returning the nodes of one cluster
+func TestExpandCluster(t *testing.T) {
+	sub := ExpandCluster(clusterTestGraph(), "store", nil)
+	var ids []string
+	for _, node := range sub.Nodes {
+		ids = append(ids, node.ID)
+	}
+	assert.Equal(t, []string{"f2", "g", "p"}, ids)
+	assert.Equal(t, []GraphEdge{
+		{ID: "f2->g", Source: "f2", Target: "g", Type: "DECLARES"},
+		{ID: "CALLS-cluster:api->g", Source: "cluster:api", Target: "g", Type: "CALLS", Props: map[string]any{"count": 1}},
+		{ID: "CALLS-cluster:api->p", Source: "cluster:api", Target: "p", Type: "CALLS", Props: map[string]any{"count": 1}},
+		{ID: "g->p", Source: "g", Target: "p", Type: "CALLS"},
+	}, sub.Edges)
+
+	// Edges to an already expanded cluster reach its nodes
+	sub = ExpandCluster(clusterTestGraph(), "store", map[string]bool{"api": true})
+	assert.Equal(t, "h->g", sub.Edges[1].ID)
+
+	assert.Nil(t, ExpandCluster(clusterTestGraph(), "missing", nil))
+}
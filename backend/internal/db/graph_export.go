@@ -0,0 +1,344 @@
+package db
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GraphExportFormats maps the formats WriteGraph can produce, which are
+// also their file extensions, to their content types
+var GraphExportFormats = map[string]string{
+	"dot":     "text/vnd.graphviz",
+	"graphml": "application/graphml+xml",
+	"gexf":    "application/gexf+xml",
+}
+
+// WriteGraph serializes a graph for Graphviz (dot), yEd (graphml) or Gephi
+// (gexf). Node labels and types, edge types and all their props are kept.
+func WriteGraph(w io.Writer, graph *GraphData, format string) error {
+	switch format {
+	case "dot":
+		return writeDOT(w, graph)
+	case "graphml":
+		return writeGraphML(w, graph)
+	case "gexf":
+		return writeGEXF(w, graph)
+	default:
+		return fmt.Errorf("unsupported graph format %q, must be dot, graphml or gexf", format)
+	}
+}
+
+func writeDOT(w io.Writer, graph *GraphData) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph neograph {")
+	for _, node := range graph.Nodes {
+		attrs := []string{"label=" + dotQuote(node.Label), "type=" + dotQuote(node.Type)}
+		fmt.Fprintf(bw, "  %s [%s];\n", dotQuote(node.ID), strings.Join(append(attrs, dotAttrs(node.Props)...), ", "))
+	}
+	for _, edge := range graph.Edges {
+		attrs := []string{"label=" + dotQuote(edge.Type)}
+		fmt.Fprintf(bw, "  %s -> %s [%s];\n", dotQuote(edge.Source), dotQuote(edge.Target), strings.Join(append(attrs, dotAttrs(edge.Props)...), ", "))
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// dotAttrs renders props as DOT attributes in name order, skipping nulls
+func dotAttrs(props map[string]any) []string {
+	var attrs []string
+	for _, name := range propNames(props) {
+		if value, ok := propString(props[name]); ok {
+			attrs = append(attrs, dotQuote(name)+"="+dotQuote(value))
+		}
+	}
+	return attrs
+}
+
+func dotQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "").Replace(s)
+	return `"` + s + `"`
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+func writeGraphML(w io.Writer, graph *GraphData) error {
+	nodeProps, edgeProps := graphProps(graph)
+	doc := graphMLDocument{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "label", For: "node", Name: "label", Type: "string"},
+			{ID: "type", For: "node", Name: "type", Type: "string"},
+			{ID: "edgeType", For: "edge", Name: "type", Type: "string"},
+		},
+		Graph: graphMLGraph{EdgeDefault: "directed"},
+	}
+	for _, p := range nodeProps {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: "n_" + p.name, For: "node", Name: p.name, Type: p.kind})
+	}
+	for _, p := range edgeProps {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: "e_" + p.name, For: "edge", Name: p.name, Type: p.kind})
+	}
+
+	for _, node := range graph.Nodes {
+		n := graphMLNode{ID: node.ID, Data: []graphMLData{{"label", node.Label}, {"type", node.Type}}}
+		for _, p := range nodeProps {
+			if value, ok := propString(node.Props[p.name]); ok {
+				n.Data = append(n.Data, graphMLData{"n_" + p.name, value})
+			}
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, n)
+	}
+	for _, edge := range graph.Edges {
+		e := graphMLEdge{ID: edge.ID, Source: edge.Source, Target: edge.Target, Data: []graphMLData{{"edgeType", edge.Type}}}
+		for _, p := range edgeProps {
+			if value, ok := propString(edge.Props[p.name]); ok {
+				e.Data = append(e.Data, graphMLData{"e_" + p.name, value})
+			}
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, e)
+	}
+	return writeXML(w, doc)
+}
+
+type gexfDocument struct {
+	XMLName xml.Name  `xml:"gexf"`
+	Xmlns   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfGraph struct {
+	DefaultEdgeType string           `xml:"defaultedgetype,attr"`
+	Attributes      []gexfAttributes `xml:"attributes"`
+	Nodes           []gexfNode       `xml:"nodes>node"`
+	Edges           []gexfEdge       `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfEdge struct {
+	ID        string         `xml:"id,attr"`
+	Source    string         `xml:"source,attr"`
+	Target    string         `xml:"target,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+func writeGEXF(w io.Writer, graph *GraphData) error {
+	nodeProps, edgeProps := graphProps(graph)
+	nodeAttrs := gexfAttributes{Class: "node", Attributes: []gexfAttribute{{ID: "type", Title: "type", Type: "string"}}}
+	for _, p := range nodeProps {
+		nodeAttrs.Attributes = append(nodeAttrs.Attributes, gexfAttribute{ID: "n_" + p.name, Title: p.name, Type: p.kind})
+	}
+	edgeAttrs := gexfAttributes{Class: "edge"}
+	for _, p := range edgeProps {
+		edgeAttrs.Attributes = append(edgeAttrs.Attributes, gexfAttribute{ID: "e_" + p.name, Title: p.name, Type: p.kind})
+	}
+	doc := gexfDocument{
+		Xmlns:   "http://gexf.net/1.3",
+		Version: "1.3",
+		Graph: gexfGraph{
+			DefaultEdgeType: "directed",
+			Attributes:      []gexfAttributes{nodeAttrs, edgeAttrs},
+		},
+	}
+
+	for _, node := range graph.Nodes {
+		n := gexfNode{ID: node.ID, Label: node.Label, AttValues: []gexfAttValue{{"type", node.Type}}}
+		for _, p := range nodeProps {
+			if value, ok := propString(node.Props[p.name]); ok {
+				n.AttValues = append(n.AttValues, gexfAttValue{"n_" + p.name, value})
+			}
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, n)
+	}
+	for _, edge := range graph.Edges {
+		e := gexfEdge{ID: edge.ID, Source: edge.Source, Target: edge.Target, Label: edge.Type}
+		for _, p := range edgeProps {
+			if value, ok := propString(edge.Props[p.name]); ok {
+				e.AttValues = append(e.AttValues, gexfAttValue{"e_" + p.name, value})
+			}
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, e)
+	}
+	return writeXML(w, doc)
+}
+
+func writeXML(w io.Writer, doc any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// graphProp is a property name used by some nodes or edges and the type of
+// its values. GraphML and GEXF share the names long, double, boolean and
+// string.
+type graphProp struct {
+	name string
+	kind string
+}
+
+// graphProps lists the props of the graph's nodes and edges by name, typed
+// by their values: long or double when all are numbers, boolean when all
+// are booleans and string otherwise
+func graphProps(graph *GraphData) (nodeProps, edgeProps []graphProp) {
+	nodeMaps := make([]map[string]any, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		nodeMaps[i] = node.Props
+	}
+	edgeMaps := make([]map[string]any, len(graph.Edges))
+	for i, edge := range graph.Edges {
+		edgeMaps[i] = edge.Props
+	}
+	return propKinds(nodeMaps), propKinds(edgeMaps)
+}
+
+func propKinds(maps []map[string]any) []graphProp {
+	kinds := make(map[string]string)
+	for _, props := range maps {
+		for name, value := range props {
+			if value == nil {
+				continue
+			}
+			kinds[name] = mergeKind(kinds[name], valueKind(value))
+		}
+	}
+	props := make([]graphProp, 0, len(kinds))
+	for _, name := range propNames(kinds) {
+		props = append(props, graphProp{name: name, kind: kinds[name]})
+	}
+	return props
+}
+
+func valueKind(value any) string {
+	switch value.(type) {
+	case int, int32, int64:
+		return "long"
+	case float32, float64:
+		return "double"
+	case bool:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+// mergeKind widens the kind seen so far to also fit another value's kind
+func mergeKind(seen, kind string) string {
+	switch {
+	case seen == "" || seen == kind:
+		return kind
+	case (seen == "long" && kind == "double") || (seen == "double" && kind == "long"):
+		return "double"
+	default:
+		return "string"
+	}
+}
+
+// propString formats a prop value, lists and maps as JSON; false for null
+func propString(value any) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int:
+		return strconv.Itoa(v), true
+	case int32:
+		return strconv.FormatInt(int64(v), 10), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), true
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value), true
+	}
+	var s string
+	if json.Unmarshal(encoded, &s) == nil {
+		return s, true // a value encoding as a string, such as a time
+	}
+	return string(encoded), true
+}
+
+func propNames[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
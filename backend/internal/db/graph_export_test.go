@@ -0,0 +1,67 @@
+package db
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportTestGraph() *GraphData {
+	return &GraphData{
+		Nodes: []GraphNode{
+			{ID: "f1", Label: "main.go", Type: "File", Props: map[string]any{"language": "go"}},
+			{ID: "fn1", Label: `say "hi"`, Type: "Function", Props: map[string]any{"startLine": int64(3), "coveragePct": 50.0, "signature": nil}},
+			{ID: "fn2", Label: "run", Type: "Function", Props: map[string]any{"startLine": int64(9), "coveragePct": int64(100)}},
+		},
+		Edges: []GraphEdge{
+			{ID: "fn1->fn2", Source: "fn1", Target: "fn2", Type: "CALLS", Props: map[string]any{"line": int64(4)}},
+		},
+	}
+}
+
+// TestWriteGraphDOT tests escaping and attributes in Graphviz output
+func TestWriteGraphDOT(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteGraph(&buf, exportTestGraph(), "dot"))
+
+	assert.Equal(t, `digraph neograph {
+  "f1" [label="main.go", type="File", "language"="go"];
+  "fn1" [label="say \"hi\"", type="Function", "coveragePct"="50", "startLine"="3"];
+  "fn2" [label="run", type="Function", "coveragePct"="100", "startLine"="9"];
+  "fn1" -> "fn2" [label="CALLS", "line"="4"];
+}
+`, buf.String())
+}
+
+// TestWriteGraphXML tests that GraphML and GEXF output parses and declares
+// each prop once with a type fitting all its values
+func TestWriteGraphXML(t *testing.T) {
+	for _, format := range []string{"graphml", "gexf"} {
+		var buf bytes.Buffer
+		require.NoError(t, WriteGraph(&buf, exportTestGraph(), format), format)
+
+		var doc struct{}
+		require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc), format)
+		out := buf.String()
+		assert.Contains(t, out, `say &#34;hi&#34;`, format)
+		assert.NotContains(t, out, "signature", format)
+	}
+
+	var graphml bytes.Buffer
+	require.NoError(t, WriteGraph(&graphml, exportTestGraph(), "graphml"))
+	assert.Contains(t, graphml.String(), `<key id="n_coveragePct" for="node" attr.name="coveragePct" attr.type="double"></key>`)
+	assert.Contains(t, graphml.String(), `<data key="e_line">4</data>`)
+
+	var gexf bytes.Buffer
+	require.NoError(t, WriteGraph(&gexf, exportTestGraph(), "gexf"))
+	assert.Contains(t, gexf.String(), `<attribute id="n_startLine" title="startLine" type="long"></attribute>`)
+	assert.Contains(t, gexf.String(), `<edge id="fn1-&gt;fn2" source="fn1" target="fn2" label="CALLS">`)
+}
+
+// TestWriteGraphUnsupported tests rejecting unknown formats
+func TestWriteGraphUnsupported(t *testing.T) {
+	assert.Error(t, WriteGraph(&bytes.Buffer{}, exportTestGraph(), "csv"))
+}
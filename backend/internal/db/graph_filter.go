@@ -0,0 +1,81 @@
+package db
+
+import (
+	"regexp"
+	"strings"
+)
+
+// GraphFilter narrows a repository graph. Files are kept by path prefix
+// and language; entities by type, name and how many calls they make or
+// receive. The zero value keeps everything.
+type GraphFilter struct {
+	PathPrefix  string
+	Language    string
+	Types       []string // Function, Method or Class
+	NamePattern string   // glob matched case-insensitively, * and ? wildcards
+	MinDegree   int      // CALLS edges in or out
+}
+
+// IsZero reports whether the filter keeps the whole graph
+func (f GraphFilter) IsZero() bool {
+	return f.PathPrefix == "" && f.Language == "" && len(f.Types) == 0 &&
+		f.NamePattern == "" && f.MinDegree <= 0
+}
+
+// filtersEntities reports whether entities are filtered beyond their files
+func (f GraphFilter) filtersEntities() bool {
+	return len(f.Types) > 0 || f.NamePattern != "" || f.MinDegree > 0
+}
+
+// whereClauses returns the conditions on file f and entity fn, each
+// "true" when unfiltered, with the parameters they use
+func (f GraphFilter) whereClauses() (fileWhere, entityWhere string, params map[string]any) {
+	params = map[string]any{}
+
+	var fileConds []string
+	if f.PathPrefix != "" {
+		fileConds = append(fileConds, "f.path STARTS WITH $pathPrefix")
+		params["pathPrefix"] = strings.TrimPrefix(f.PathPrefix, "/")
+	}
+	if f.Language != "" {
+		fileConds = append(fileConds, "f.language = $language")
+		params["language"] = f.Language
+	}
+
+	var entityConds []string
+	if len(f.Types) > 0 {
+		entityConds = append(entityConds, "any(l IN labels(fn) WHERE l IN $types)")
+		params["types"] = f.Types
+	}
+	if f.NamePattern != "" {
+		entityConds = append(entityConds, "fn.name =~ $namePattern")
+		params["namePattern"] = globToRegex(f.NamePattern)
+	}
+
+	return joinConds(fileConds), joinConds(entityConds), params
+}
+
+func joinConds(conds []string) string {
+	if len(conds) == 0 {
+		return "true"
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// globToRegex translates a glob into a case-insensitive regular expression
+// matching whole names
+func globToRegex(glob string) string {
+	var b strings.Builder
+	b.WriteString("(?i)")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGraphFilterWhereClauses tests translating graph filters to Cypher conditions
+func TestGraphFilterWhereClauses(t *testing.T) {
+	fileWhere, entityWhere, params := GraphFilter{}.whereClauses()
+	assert.Equal(t, "true", fileWhere)
+	assert.Equal(t, "true", entityWhere)
+	assert.Empty(t, params)
+
+	filter := GraphFilter{
+		PathPrefix:  "/pkg/api",
+		Language:    "go",
+		Types:       []string{"Function", "Method"},
+		NamePattern: "Get*.v?",
+		MinDegree:   2,
+	}
+	fileWhere, entityWhere, params = filter.whereClauses()
+	assert.Equal(t, "f.path STARTS WITH $pathPrefix AND f.language = $language", fileWhere)
+	assert.Equal(t, "any(l IN labels(fn) WHERE l IN $types) AND fn.name =~ $namePattern", entityWhere)
+	assert.Equal(t, "pkg/api", params["pathPrefix"])
+	assert.Equal(t, "go", params["language"])
+	assert.Equal(t, []string{"Function", "Method"}, params["types"])
+	assert.Equal(t, `(?i)Get.*\.v.`, params["namePattern"])
+	assert.False(t, filter.IsZero())
+	assert.True(t, GraphFilter{}.IsZero())
+}
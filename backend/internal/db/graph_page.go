@@ -0,0 +1,38 @@
+package db
+
+// PageGraph returns limit nodes of a graph starting at offset, in the
+// graph's order. Edges are kept when one end is on the page and the other
+// on it or an earlier page, so a client appending pages ends up with every
+// edge exactly once. A limit of 0 or less returns the rest of the graph.
+func PageGraph(graph *GraphData, offset, limit int) *GraphData {
+	total := len(graph.Nodes)
+	offset = min(max(offset, 0), total)
+	end := total
+	if limit > 0 {
+		end = min(offset+limit, total)
+	}
+
+	// Position of each node seen so far, to tell this page from earlier ones
+	seen := make(map[string]int, end)
+	for i, node := range graph.Nodes[:end] {
+		seen[node.ID] = i
+	}
+
+	page := &GraphData{
+		Nodes:      graph.Nodes[offset:end],
+		Edges:      []GraphEdge{},
+		TotalNodes: total,
+	}
+	for _, edge := range graph.Edges {
+		source, okSource := seen[edge.Source]
+		target, okTarget := seen[edge.Target]
+		if okSource && okTarget && (source >= offset || target >= offset) {
+			page.Edges = append(page.Edges, edge)
+		}
+	}
+	if end < total {
+		page.Truncated = true
+		page.NextOffset = end
+	}
+	return page
+}
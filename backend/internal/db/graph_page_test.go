@@ -0,0 +1,47 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPageGraph tests paging graph nodes with the edges between pages
+func TestPageGraph(t *testing.T) {
+	graph := &GraphData{
+		Nodes: []GraphNode{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}, {ID: "e"}},
+		Edges: []GraphEdge{
+			{ID: "a->b", Source: "a", Target: "b"},
+			{ID: "c->a", Source: "c", Target: "a"},
+			{ID: "d->e", Source: "d", Target: "e"},
+			{ID: "b->x", Source: "b", Target: "x"},
+		},
+	}
+
+	first := PageGraph(graph, 0, 2)
+	assert.Equal(t, []GraphNode{{ID: "a"}, {ID: "b"}}, first.Nodes)
+	assert.Equal(t, []GraphEdge{{ID: "a->b", Source: "a", Target: "b"}}, first.Edges)
+	assert.True(t, first.Truncated)
+	assert.Equal(t, 2, first.NextOffset)
+	assert.Equal(t, 5, first.TotalNodes)
+
+	second := PageGraph(graph, 2, 2)
+	assert.Equal(t, []GraphNode{{ID: "c"}, {ID: "d"}}, second.Nodes)
+	assert.Equal(t, []GraphEdge{{ID: "c->a", Source: "c", Target: "a"}}, second.Edges)
+	assert.Equal(t, 4, second.NextOffset)
+
+	last := PageGraph(graph, 4, 2)
+	assert.Equal(t, []GraphNode{{ID: "e"}}, last.Nodes)
+	assert.Equal(t, []GraphEdge{{ID: "d->e", Source: "d", Target: "e"}}, last.Edges)
+	assert.False(t, last.Truncated)
+	assert.Zero(t, last.NextOffset)
+
+	all := PageGraph(graph, 0, 0)
+	assert.Len(t, all.Nodes, 5)
+	assert.Len(t, all.Edges, 3)
+	assert.False(t, all.Truncated)
+
+	past := PageGraph(graph, 10, 2)
+	assert.Empty(t, past.Nodes)
+	assert.Empty(t, past.Edges)
+}
@@ -3,7 +3,9 @@ package db
 import (
 	"context"
 	"fmt"
+	"sort"
 
+	"github.com/dpolishuk/neograph/backend/internal/models"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
@@ -33,6 +35,16 @@ type FunctionRef struct {
 type GraphData struct {
 	Nodes []GraphNode `json:"nodes"`
 	Edges []GraphEdge `json:"edges"`
+	// Set on a page of a graph: whether nodes follow, where they start
+	// and how many the whole graph has
+	Truncated  bool `json:"truncated,omitempty"`
+	NextOffset int  `json:"nextOffset,omitempty"`
+	TotalNodes int  `json:"totalNodes,omitempty"`
+	// Set when nodes are folded into Cluster nodes, see ClusterGraph
+	Clustered bool `json:"clustered,omitempty"`
+	// Node IDs of each group of packages depending on each other, see
+	// GetPackageDependencyGraph
+	Cycles [][]string `json:"cycles,omitempty"`
 }
 
 type GraphNode struct {
@@ -43,17 +55,19 @@ type GraphNode struct {
 }
 
 type GraphEdge struct {
-	ID     string `json:"id"`
-	Source string `json:"source"`
-	Target string `json:"target"`
-	Type   string `json:"type"`
+	ID     string         `json:"id"`
+	Source string         `json:"source"`
+	Target string         `json:"target"`
+	Type   string         `json:"type"`
+	Props  map[string]any `json:"props,omitempty"`
 }
 
 // GetFileTree returns all files with their functions for a repository
 func (r *GraphReader) GetFileTree(ctx context.Context, repoID string) ([]FileNode, error) {
+	ctx = WithRepository(ctx, repoID)
 	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		query := `
-			MATCH (r:Repository {id: $repoId})-[:CONTAINS]->(f:File)
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(f:File)
 			OPTIONAL MATCH (f)-[:DECLARES]->(fn:Function|Method)
 			WITH f, fn
 			ORDER BY fn.startLine
@@ -127,34 +141,115 @@ func (r *GraphReader) GetFileTree(ctx context.Context, repoID string) ([]FileNod
 	return result.([]FileNode), nil
 }
 
+// GetFileHashes returns the content hash of each indexed file by path
+func (r *GraphReader) GetFileHashes(ctx context.Context, repoID string) (map[string]string, error) {
+	ctx = WithRepository(ctx, repoID)
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(f:File)
+			RETURN f.path as path, f.hash as hash
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+
+		hashes := make(map[string]string)
+		for records.Next(ctx) {
+			rec := records.Record()
+			hashes[recordString(rec, "path")] = recordString(rec, "hash")
+		}
+		return hashes, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]string), nil
+}
+
 // GetGraph returns graph data for visualization
 func (r *GraphReader) GetGraph(ctx context.Context, repoID, graphType string) (*GraphData, error) {
+	return r.GetFilteredGraph(ctx, repoID, graphType, GraphFilter{})
+}
+
+// GetFilteredGraph returns the part of a repository's graph the filter
+// keeps. Calls are only shown between kept functions, and files only with
+// kept entities when entities are filtered.
+func (r *GraphReader) GetFilteredGraph(ctx context.Context, repoID, graphType string, filter GraphFilter) (*GraphData, error) {
+	ctx = WithRepository(ctx, repoID)
 	var query string
 
+	fileWhere, entityWhere, params := filter.whereClauses()
+	params["repoId"] = repoID
+	params["minDegree"] = filter.MinDegree
+
+	// Entities kept for their CALLS degree
+	degreeClause := ""
+	if filter.MinDegree > 0 {
+		degreeClause = `
+			OPTIONAL MATCH (fn)-[d:CALLS]-()
+			WITH f, fn, count(d) AS degree
+			WHERE degree >= $minDegree
+		`
+	}
+
 	if graphType == "calls" {
 		// Call graph: show functions and their call relationships
-		query = `
-			MATCH (r:Repository {id: $repoId})-[:CONTAINS]->(f:File)-[:DECLARES]->(fn:Function|Method)
-			OPTIONAL MATCH (fn)-[c:CALLS]->(target:Function|Method)
-			RETURN fn, f, c, target
-		`
+		if filter.IsZero() {
+			query = `
+				MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(f:File)-[:DECLARES]->(fn:Function|Method)
+				OPTIONAL MATCH (fn)-[c:CALLS]->(target:Function|Method)
+				RETURN fn, f, c, target
+				ORDER BY f.path, fn.startLine, fn.id, target.id
+			`
+		} else {
+			query = `
+				MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(f:File)-[:DECLARES]->(fn:Function|Method)
+				WHERE ` + fileWhere + ` AND ` + entityWhere + `
+				` + degreeClause + `
+				WITH collect({f: f, fn: fn}) AS rows, collect(fn) AS kept
+				UNWIND rows AS row
+				WITH row.f AS f, row.fn AS fn, kept
+				OPTIONAL MATCH (fn)-[c:CALLS]->(target:Function|Method)
+				WHERE target IN kept
+				RETURN fn, f, c, target
+				ORDER BY f.path, fn.startLine, fn.id, target.id
+			`
+		}
 	} else {
-		// Structure graph: show files and the functions they declare
+		// Structure graph: show files, the entities they declare and the
+		// classes methods belong to
+		keepEntities := ""
+		if filter.filtersEntities() {
+			keepEntities = `
+				WITH f, fn
+				WHERE fn IS NOT NULL
+			`
+		}
 		query = `
-			MATCH (r:Repository {id: $repoId})-[:CONTAINS]->(f:File)
-			OPTIONAL MATCH (f)-[:DECLARES]->(fn:Function|Method)
-			RETURN f, fn, null as c, null as target
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(f:File)
+			WHERE ` + fileWhere + `
+			OPTIONAL MATCH (f)-[:DECLARES]->(fn:Function|Method|Class)
+			WHERE ` + entityWhere + `
+			` + degreeClause + keepEntities + `
+			OPTIONAL MATCH (fn)-[:MEMBER_OF]->(cls:Class)
+			RETURN f, fn, null as c, null as target, cls.id as classId
+			ORDER BY f.path, fn.startLine, fn.id
 		`
 	}
 
 	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		records, err := tx.Run(ctx, query, params)
 		if err != nil {
 			return nil, err
 		}
 
+		// Nodes and edges keep the order they are first seen in, files by
+		// path and entities by line, so graphs page deterministically
 		nodesMap := make(map[string]GraphNode)
 		edgesMap := make(map[string]GraphEdge)
+		var nodeOrder, edgeOrder []string
 
 		for records.Next(ctx) {
 			rec := records.Record()
@@ -168,14 +263,15 @@ func (r *GraphReader) GetGraph(ctx context.Context, repoID, graphType string) (*
 
 					nodeID := fnProps["id"].(string)
 					if _, exists := nodesMap[nodeID]; !exists {
+						nodeOrder = append(nodeOrder, nodeID)
 						nodesMap[nodeID] = GraphNode{
 							ID:    nodeID,
 							Label: fnProps["name"].(string),
 							Type:  "Function",
-							Props: map[string]any{
+							Props: withCoverage(withRuntime(map[string]any{
 								"signature": fnProps["signature"],
 								"filePath":  fnProps["filePath"],
-							},
+							}, fnProps), fnProps),
 						}
 					}
 				}
@@ -187,14 +283,15 @@ func (r *GraphReader) GetGraph(ctx context.Context, repoID, graphType string) (*
 
 					targetID := targetProps["id"].(string)
 					if _, exists := nodesMap[targetID]; !exists {
+						nodeOrder = append(nodeOrder, targetID)
 						nodesMap[targetID] = GraphNode{
 							ID:    targetID,
 							Label: targetProps["name"].(string),
 							Type:  "Function",
-							Props: map[string]any{
+							Props: withCoverage(withRuntime(map[string]any{
 								"signature": targetProps["signature"],
 								"filePath":  targetProps["filePath"],
-							},
+							}, targetProps), targetProps),
 						}
 					}
 
@@ -205,13 +302,20 @@ func (r *GraphReader) GetGraph(ctx context.Context, repoID, graphType string) (*
 						fnNode := fnRaw.(neo4j.Node)
 						fnProps := fnNode.GetProperties()
 
+						callProps := callRaw.(neo4j.Relationship).GetProperties()
+
 						edgeID := fmt.Sprintf("%s->%s", fnProps["id"].(string), targetID)
 						if _, exists := edgesMap[edgeID]; !exists {
+							edgeOrder = append(edgeOrder, edgeID)
 							edgesMap[edgeID] = GraphEdge{
 								ID:     edgeID,
 								Source: fnProps["id"].(string),
 								Target: targetID,
 								Type:   "CALLS",
+								Props: map[string]any{
+									"line":  callProps["line"],
+									"count": callProps["count"],
+								},
 							}
 						}
 					}
@@ -225,13 +329,14 @@ func (r *GraphReader) GetGraph(ctx context.Context, repoID, graphType string) (*
 
 					fileID := fileProps["id"].(string)
 					if _, exists := nodesMap[fileID]; !exists {
+						nodeOrder = append(nodeOrder, fileID)
 						nodesMap[fileID] = GraphNode{
 							ID:    fileID,
 							Label: fileProps["path"].(string),
 							Type:  "File",
-							Props: map[string]any{
+							Props: withCoverage(map[string]any{
 								"language": fileProps["language"],
-							},
+							}, fileProps),
 						}
 					}
 				}
@@ -241,15 +346,23 @@ func (r *GraphReader) GetGraph(ctx context.Context, repoID, graphType string) (*
 					fnNode := fnRaw.(neo4j.Node)
 					fnProps := fnNode.GetProperties()
 
+					nodeType := "Function"
+					for _, label := range fnNode.Labels {
+						if label == "Class" {
+							nodeType = "Class"
+						}
+					}
+
 					fnID := fnProps["id"].(string)
 					if _, exists := nodesMap[fnID]; !exists {
+						nodeOrder = append(nodeOrder, fnID)
 						nodesMap[fnID] = GraphNode{
 							ID:    fnID,
 							Label: fnProps["name"].(string),
-							Type:  "Function",
-							Props: map[string]any{
+							Type:  nodeType,
+							Props: withCoverage(withRuntime(map[string]any{
 								"signature": fnProps["signature"],
-							},
+							}, fnProps), fnProps),
 						}
 					}
 
@@ -261,6 +374,7 @@ func (r *GraphReader) GetGraph(ctx context.Context, repoID, graphType string) (*
 
 					edgeID := fmt.Sprintf("%s->%s", fileID, fnID)
 					if _, exists := edgesMap[edgeID]; !exists {
+						edgeOrder = append(edgeOrder, edgeID)
 						edgesMap[edgeID] = GraphEdge{
 							ID:     edgeID,
 							Source: fileID,
@@ -268,6 +382,20 @@ func (r *GraphReader) GetGraph(ctx context.Context, repoID, graphType string) (*
 							Type:   "DECLARES",
 						}
 					}
+
+					// Add MEMBER_OF edge
+					if classID, _ := rec.Get("classId"); classID != nil {
+						edgeID := fmt.Sprintf("%s->%s", fnID, classID.(string))
+						if _, exists := edgesMap[edgeID]; !exists {
+							edgeOrder = append(edgeOrder, edgeID)
+							edgesMap[edgeID] = GraphEdge{
+								ID:     edgeID,
+								Source: fnID,
+								Target: classID.(string),
+								Type:   "MEMBER_OF",
+							}
+						}
+					}
 				}
 			}
 		}
@@ -277,13 +405,22 @@ func (r *GraphReader) GetGraph(ctx context.Context, repoID, graphType string) (*
 		}
 
 		// Convert maps to slices
-		nodes := make([]GraphNode, 0, len(nodesMap))
-		for _, node := range nodesMap {
-			nodes = append(nodes, node)
+		nodes := make([]GraphNode, 0, len(nodeOrder))
+		for _, id := range nodeOrder {
+			nodes = append(nodes, nodesMap[id])
 		}
 
-		edges := make([]GraphEdge, 0, len(edgesMap))
-		for _, edge := range edgesMap {
+		// Filters can leave an edge's end out, such as the class of a
+		// method when classes are filtered out
+		edges := make([]GraphEdge, 0, len(edgeOrder))
+		for _, id := range edgeOrder {
+			edge := edgesMap[id]
+			if _, ok := nodesMap[edge.Source]; !ok {
+				continue
+			}
+			if _, ok := nodesMap[edge.Target]; !ok {
+				continue
+			}
 			edges = append(edges, edge)
 		}
 
@@ -303,28 +440,75 @@ func (r *GraphReader) GetGraph(ctx context.Context, repoID, graphType string) (*
 type NodeDetail struct {
 	ID        string   `json:"id"`
 	Name      string   `json:"name"`
-	Type      string   `json:"type"` // "File", "Function", or "Method"
+	Type      string   `json:"type"` // "Directory", "Package", "File", "Class", "Function", "Method" or "Variable"
 	Signature string   `json:"signature,omitempty"`
+	// generated sentence saying what the entity does, see ENTITY_DESCRIPTIONS
+	Description string `json:"description,omitempty"`
 	FilePath  string   `json:"filePath,omitempty"`
 	StartLine int      `json:"startLine,omitempty"`
 	EndLine   int      `json:"endLine,omitempty"`
 	Calls     []string `json:"calls,omitempty"`     // names of functions this node calls
 	CalledBy  []string `json:"calledBy,omitempty"`  // names of functions that call this node
+	MemberOf  string   `json:"memberOf,omitempty"`  // class a method belongs to
+	// names of calls left unlinked because several entities matched
+	AmbiguousCalls []string `json:"ambiguousCalls,omitempty"`
+	Members   []string `json:"members,omitempty"`   // methods of a class
+	Commit    string   `json:"commit,omitempty"`    // indexed commit the line numbers refer to
+	Content   string   `json:"content,omitempty"`   // source of the entity, possibly truncated
+	// set when Content was cut at the configured size cap
+	ContentTruncated bool `json:"contentTruncated,omitempty"`
+	// language of the source, for syntax highlighting
+	Language string `json:"language,omitempty"`
+	// outgoing calls with the lines they are made on
+	CallSites []CallSiteDetail `json:"callSites,omitempty"`
+	// distinct functions calling and called by a function, scored after indexing
+	FanIn  int `json:"fanIn,omitempty"`
+	FanOut int `json:"fanOut,omitempty"`
+	// behavior observed in the last uploaded profile or trace
+	Runtime *RuntimeStats `json:"runtime,omitempty"`
+	// line coverage from the last uploaded coverage report
+	Coverage *CoverageStats `json:"coverage,omitempty"`
+	// static analysis findings reported in the node
+	Findings []FindingDetail `json:"findings,omitempty"`
+	// package-level variables a function reads and writes
+	Reads  []VariableAccessDetail `json:"reads,omitempty"`
+	Writes []VariableAccessDetail `json:"writes,omitempty"`
+	// functions reading and writing a variable
+	ReadBy    []VariableAccessDetail `json:"readBy,omitempty"`
+	WrittenBy []VariableAccessDetail `json:"writtenBy,omitempty"`
+}
+
+// CallSiteDetail is an outgoing CALLS edge of a node
+type CallSiteDetail struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Line  int    `json:"line,omitempty"`  // first call
+	Lines []int  `json:"lines,omitempty"` // every call
+	Count int    `json:"count"`
 }
 
 // GetNodeDetail returns detailed information about a specific node
 func (r *GraphReader) GetNodeDetail(ctx context.Context, repoID, nodeID string) (*NodeDetail, error) {
+	ctx = WithRepository(ctx, repoID)
 	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		// First, get the node details
 		query := `
-			MATCH (r:Repository {id: $repoId})-[:CONTAINS*1..2]->(node)
-			WHERE node.id = $nodeId
-			OPTIONAL MATCH (node)-[:CALLS]->(target:Function|Method)
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS|DECLARES*]->(node {id: $nodeId})
+			OPTIONAL MATCH (node)-[call:CALLS]->(target:Function|Method)
 			OPTIONAL MATCH (caller:Function|Method)-[:CALLS]->(node)
+			OPTIONAL MATCH (node)-[:MEMBER_OF]->(cls:Class)
+			OPTIONAL MATCH (member:Method)-[:MEMBER_OF]->(node)
 			RETURN node,
+			       r.commit as commit,
 			       labels(node) as labels,
 			       collect(DISTINCT target.name) as calls,
-			       collect(DISTINCT caller.name) as calledBy
+			       collect(DISTINCT CASE WHEN target IS NULL THEN null ELSE {
+			           id: target.id, name: target.name,
+			           line: call.line, lines: call.lines, count: call.count
+			       } END) as callSites,
+			       collect(DISTINCT caller.name) as calledBy,
+			       head(collect(DISTINCT cls.name)) as memberOf,
+			       collect(DISTINCT member.name) as members
 		`
 		records, err := tx.Run(ctx, query, map[string]any{
 			"repoId": repoID,
@@ -355,10 +539,12 @@ func (r *GraphReader) GetNodeDetail(ctx context.Context, repoID, nodeID string)
 
 		var nodeType string
 		for _, label := range labels {
-			labelStr := label.(string)
-			if labelStr == "File" || labelStr == "Function" || labelStr == "Method" {
-				nodeType = labelStr
-				break
+			switch labelStr := label.(string); labelStr {
+			case "File", "Class", "Function", "Method", "Variable", "Directory", "Package":
+				// Package directories also carry the Directory label
+				if nodeType == "" || labelStr == "Package" {
+					nodeType = labelStr
+				}
 			}
 		}
 
@@ -366,6 +552,10 @@ func (r *GraphReader) GetNodeDetail(ctx context.Context, repoID, nodeID string)
 			ID:   props["id"].(string),
 			Type: nodeType,
 		}
+		if commit, _ := rec.Get("commit"); commit != nil {
+			detail.Commit = commit.(string)
+		}
+		detail.Coverage = coverageStats(props)
 
 		// Set name based on type
 		if nameVal, ok := props["name"]; ok && nameVal != nil {
@@ -375,7 +565,7 @@ func (r *GraphReader) GetNodeDetail(ctx context.Context, repoID, nodeID string)
 		}
 
 		// Set optional fields based on node type
-		if nodeType == "Function" || nodeType == "Method" {
+		if nodeType == "Function" || nodeType == "Method" || nodeType == "Class" || nodeType == "Variable" {
 			if sig, ok := props["signature"]; ok && sig != nil {
 				detail.Signature = sig.(string)
 			}
@@ -388,6 +578,23 @@ func (r *GraphReader) GetNodeDetail(ctx context.Context, repoID, nodeID string)
 			if el, ok := props["endLine"]; ok && el != nil {
 				detail.EndLine = int(el.(int64))
 			}
+			if content, ok := props["content"]; ok && content != nil {
+				detail.Content = content.(string)
+			}
+			if truncated, ok := props["contentTruncated"].(bool); ok {
+				detail.ContentTruncated = truncated
+			}
+			if description, ok := props["description"].(string); ok {
+				detail.Description = description
+			}
+			detail.Language = models.DetectLanguage(detail.FilePath)
+			detail.Runtime = runtimeStats(props)
+			if in, ok := props["inDegree"].(int64); ok {
+				detail.FanIn = int(in)
+			}
+			if out, ok := props["outDegree"].(int64); ok {
+				detail.FanOut = int(out)
+			}
 
 			// Get calls
 			callsRaw, _ := rec.Get("calls")
@@ -400,6 +607,19 @@ func (r *GraphReader) GetNodeDetail(ctx context.Context, repoID, nodeID string)
 				}
 			}
 
+			if callSitesRaw, _ := rec.Get("callSites"); callSitesRaw != nil {
+				for _, raw := range callSitesRaw.([]any) {
+					site, ok := raw.(map[string]any)
+					if !ok {
+						continue
+					}
+					detail.CallSites = append(detail.CallSites, callSiteDetail(site))
+				}
+				sort.Slice(detail.CallSites, func(i, j int) bool {
+					return detail.CallSites[i].Line < detail.CallSites[j].Line
+				})
+			}
+
 			// Get calledBy
 			calledByRaw, _ := rec.Get("calledBy")
 			if calledByRaw != nil {
@@ -410,16 +630,57 @@ func (r *GraphReader) GetNodeDetail(ctx context.Context, repoID, nodeID string)
 					}
 				}
 			}
-		} else if nodeType == "File" {
+
+			if ambiguousRaw, ok := props["ambiguousCalls"].([]any); ok {
+				for _, name := range ambiguousRaw {
+					detail.AmbiguousCalls = append(detail.AmbiguousCalls, name.(string))
+				}
+			}
+
+			// Get class membership
+			if memberOf, _ := rec.Get("memberOf"); memberOf != nil {
+				detail.MemberOf = memberOf.(string)
+			}
+			membersRaw, _ := rec.Get("members")
+			if membersRaw != nil {
+				for _, member := range membersRaw.([]any) {
+					if member != nil {
+						detail.Members = append(detail.Members, member.(string))
+					}
+				}
+			}
+		} else if nodeType == "File" || nodeType == "Directory" || nodeType == "Package" {
 			if path, ok := props["path"]; ok && path != nil {
 				detail.FilePath = path.(string)
 			}
+			if nodeType == "File" {
+				detail.Language, _ = props["language"].(string)
+			}
 		}
 
 		if err := records.Err(); err != nil {
 			return nil, err
 		}
 
+		if nodeType != "Directory" && nodeType != "Package" {
+			filePath := ""
+			if nodeType == "File" {
+				filePath = detail.FilePath
+			}
+			if detail.Findings, err = readFindings(ctx, tx, repoID, detail.ID, filePath); err != nil {
+				return nil, err
+			}
+		}
+
+		if nodeType == "Function" || nodeType == "Method" || nodeType == "Variable" {
+			accesses, err := readVariableAccesses(ctx, tx, detail.ID)
+			if err != nil {
+				return nil, err
+			}
+			detail.Reads, detail.Writes = accesses.reads, accesses.writes
+			detail.ReadBy, detail.WrittenBy = accesses.readBy, accesses.writtenBy
+		}
+
 		return detail, nil
 	})
 
@@ -431,3 +692,29 @@ func (r *GraphReader) GetNodeDetail(ctx context.Context, repoID, nodeID string)
 	}
 	return result.(*NodeDetail), nil
 }
+
+// callSiteDetail converts a collected call site map; edges written before
+// call sites were recorded have no line and count once
+func callSiteDetail(site map[string]any) CallSiteDetail {
+	detail := CallSiteDetail{Count: 1}
+	if id, ok := site["id"].(string); ok {
+		detail.ID = id
+	}
+	if name, ok := site["name"].(string); ok {
+		detail.Name = name
+	}
+	if line, ok := site["line"].(int64); ok {
+		detail.Line = int(line)
+	}
+	if lines, ok := site["lines"].([]any); ok {
+		for _, line := range lines {
+			if n, ok := line.(int64); ok {
+				detail.Lines = append(detail.Lines, int(n))
+			}
+		}
+	}
+	if count, ok := site["count"].(int64); ok {
+		detail.Count = int(count)
+	}
+	return detail
+}
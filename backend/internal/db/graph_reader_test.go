@@ -230,3 +230,18 @@ func getEnvOrDefault(key, defaultValue string) string {
 	// In real implementation, use os.Getenv
 	return defaultValue
 }
+
+func TestCallSiteDetail(t *testing.T) {
+	site := callSiteDetail(map[string]any{
+		"id":    "fn-1",
+		"name":  "run",
+		"line":  int64(9),
+		"lines": []any{int64(9), int64(12)},
+		"count": int64(2),
+	})
+	assert.Equal(t, CallSiteDetail{ID: "fn-1", Name: "run", Line: 9, Lines: []int{9, 12}, Count: 2}, site)
+
+	// Edges written before call sites were recorded count once
+	site = callSiteDetail(map[string]any{"id": "fn-2", "name": "stop", "line": nil, "count": nil})
+	assert.Equal(t, CallSiteDetail{ID: "fn-2", Name: "stop", Count: 1}, site)
+}
@@ -0,0 +1,60 @@
+package db
+
+// GraphChunk is one line of a streamed graph: a batch of nodes and the
+// edges they complete
+type GraphChunk struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+	// Where the next chunk starts; a client cut off resumes from the cursor
+	// of the last chunk it received
+	Cursor     int  `json:"cursor"`
+	TotalNodes int  `json:"totalNodes"`
+	Done       bool `json:"done,omitempty"` // last chunk
+}
+
+// StreamGraph passes the nodes of a graph from position cursor on to emit
+// in chunks of size, stopping at the first error. Each edge goes with the
+// chunk holding the later of its ends, so a client appending chunks, from
+// the start or a cursor, gets every edge once and only once both ends are
+// drawn, as with PageGraph. Edges to nodes outside the graph are dropped.
+func StreamGraph(graph *GraphData, cursor, size int, emit func(*GraphChunk) error) error {
+	total := len(graph.Nodes)
+	cursor = min(max(cursor, 0), total)
+	if size <= 0 {
+		size = max(total, 1)
+	}
+
+	position := make(map[string]int, total)
+	for i, node := range graph.Nodes {
+		position[node.ID] = i
+	}
+	// Edges by the position of their later end
+	completed := make(map[int][]GraphEdge)
+	for _, edge := range graph.Edges {
+		source, okSource := position[edge.Source]
+		target, okTarget := position[edge.Target]
+		if okSource && okTarget && max(source, target) >= cursor {
+			completed[max(source, target)] = append(completed[max(source, target)], edge)
+		}
+	}
+
+	for start := cursor; ; start += size {
+		end := min(start+size, total)
+		chunk := &GraphChunk{
+			Nodes:      graph.Nodes[start:end],
+			Edges:      []GraphEdge{},
+			Cursor:     end,
+			TotalNodes: total,
+			Done:       end == total,
+		}
+		for i := start; i < end; i++ {
+			chunk.Edges = append(chunk.Edges, completed[i]...)
+		}
+		if err := emit(chunk); err != nil {
+			return err
+		}
+		if chunk.Done {
+			return nil
+		}
+	}
+}
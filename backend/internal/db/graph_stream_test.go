@@ -0,0 +1,65 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStreamGraph tests streaming graph nodes in chunks with the edges they
+// complete, from the start and from a cursor
+func TestStreamGraph(t *testing.T) {
+	graph := &GraphData{
+		Nodes: []GraphNode{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}, {ID: "e"}},
+		Edges: []GraphEdge{
+			{ID: "a->b", Source: "a", Target: "b"},
+			{ID: "c->a", Source: "c", Target: "a"},
+			{ID: "d->e", Source: "d", Target: "e"},
+			{ID: "b->x", Source: "b", Target: "x"},
+		},
+	}
+	collect := func(cursor, size int) []*GraphChunk {
+		var chunks []*GraphChunk
+		require.NoError(t, StreamGraph(graph, cursor, size, func(chunk *GraphChunk) error {
+			chunks = append(chunks, chunk)
+			return nil
+		}))
+		return chunks
+	}
+
+	chunks := collect(0, 2)
+	require.Len(t, chunks, 3)
+	assert.Equal(t, []GraphNode{{ID: "a"}, {ID: "b"}}, chunks[0].Nodes)
+	assert.Equal(t, []GraphEdge{{ID: "a->b", Source: "a", Target: "b"}}, chunks[0].Edges)
+	assert.Equal(t, 2, chunks[0].Cursor)
+	assert.Equal(t, 5, chunks[0].TotalNodes)
+	assert.False(t, chunks[0].Done)
+	assert.Equal(t, []GraphEdge{{ID: "c->a", Source: "c", Target: "a"}}, chunks[1].Edges)
+	assert.Equal(t, []GraphNode{{ID: "e"}}, chunks[2].Nodes)
+	assert.Equal(t, []GraphEdge{{ID: "d->e", Source: "d", Target: "e"}}, chunks[2].Edges)
+	assert.Equal(t, 5, chunks[2].Cursor)
+	assert.True(t, chunks[2].Done)
+
+	// Resuming sends the edges completed after the cursor only
+	resumed := collect(3, 10)
+	require.Len(t, resumed, 1)
+	assert.Equal(t, []GraphNode{{ID: "d"}, {ID: "e"}}, resumed[0].Nodes)
+	assert.Equal(t, []GraphEdge{{ID: "d->e", Source: "d", Target: "e"}}, resumed[0].Edges)
+	assert.True(t, resumed[0].Done)
+
+	past := collect(10, 2)
+	require.Len(t, past, 1)
+	assert.Empty(t, past[0].Nodes)
+	assert.True(t, past[0].Done)
+
+	failed := errors.New("client gone")
+	calls := 0
+	err := StreamGraph(graph, 0, 1, func(*GraphChunk) error {
+		calls++
+		return failed
+	})
+	assert.ErrorIs(t, err, failed)
+	assert.Equal(t, 1, calls)
+}
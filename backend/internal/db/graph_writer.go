@@ -3,43 +3,105 @@ package db
 import (
 	"context"
 	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
 	"github.com/dpolishuk/neograph/backend/internal/models"
 	"github.com/google/uuid"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
+// DefaultMaxContentBytes is how much entity source is stored unless
+// overridden with SetMaxContentBytes
+const DefaultMaxContentBytes = 16 * 1024
+
 type GraphWriter struct {
-	client *Neo4jClient
+	client     *Neo4jClient
+	maxContent int
 }
 
 func NewGraphWriter(client *Neo4jClient) *GraphWriter {
-	return &GraphWriter{client: client}
+	return &GraphWriter{client: client, maxContent: DefaultMaxContentBytes}
+}
+
+// SetMaxContentBytes caps the source stored on entity nodes. Longer source is
+// truncated and flagged; 0 stops source being stored at all.
+func (w *GraphWriter) SetMaxContentBytes(n int) {
+	w.maxContent = n
 }
 
-// WriteIndexResult writes all indexed data to Neo4j
+// truncateContent cuts content to at most max bytes without splitting a UTF-8
+// sequence, reporting whether anything was cut
+func truncateContent(content string, max int) (string, bool) {
+	if len(content) <= max {
+		return content, false
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(content[cut]) {
+		cut--
+	}
+	return content[:cut], true
+}
+
+// WriteIndexResult writes all indexed data to Neo4j. Each batch is its own
+// transaction, retried on transient errors by the client; errors name the
+// batch that kept failing.
 func (w *GraphWriter) WriteIndexResult(ctx context.Context, result *models.IndexResult) error {
+	ctx = WithRepository(ctx, result.RepoID)
+
+	// Write the directory/package hierarchy files hang off
+	if err := w.WriteDirectories(ctx, result.RepoID, result.Files); err != nil {
+		return fmt.Errorf("failed to write directories of %d files: %w", len(result.Files), err)
+	}
+
 	// Write files
-	for _, file := range result.Files {
+	for i, file := range result.Files {
 		if err := w.WriteFile(ctx, file); err != nil {
-			return fmt.Errorf("failed to write file %s: %w", file.Path, err)
+			return fmt.Errorf("failed to write file %s (%d of %d): %w", file.Path, i+1, len(result.Files), err)
 		}
 	}
 
 	// Write entities
 	for i := range result.Entities {
-		if err := w.WriteEntity(ctx, result.RepoID, &result.Entities[i]); err != nil {
-			return fmt.Errorf("failed to write entity %s: %w", result.Entities[i].Name, err)
+		entity := &result.Entities[i]
+		if err := w.WriteEntity(ctx, result.RepoID, entity); err != nil {
+			return fmt.Errorf("failed to write %s %s at %s:%d (%d of %d): %w",
+				entity.Type, entity.Name, entity.FilePath, entity.StartLine, i+1, len(result.Entities), err)
 		}
 	}
 
+	return w.writeRelationships(ctx, result)
+}
+
+// writeRelationships links the written entities of an index result and
+// records its counts on the repository
+func (w *GraphWriter) writeRelationships(ctx context.Context, result *models.IndexResult) error {
+	// Attach methods to their classes
+	if err := w.WriteMemberships(ctx, result.Entities); err != nil {
+		return fmt.Errorf("failed to write class memberships: %w", err)
+	}
+
+	// Link classes to the interfaces a language server found them implementing
+	if err := w.WriteImplementations(ctx, result.Entities); err != nil {
+		return fmt.Errorf("failed to write implementations: %w", err)
+	}
+
 	// Write call relationships
-	for i := range result.Entities {
-		if len(result.Entities[i].Calls) > 0 {
-			if err := w.WriteCallRelationships(ctx, &result.Entities[i]); err != nil {
-				return fmt.Errorf("failed to write calls for %s: %w", result.Entities[i].Name, err)
-			}
-		}
+	if err := w.WriteCallRelationships(ctx, result.Files, result.Entities); err != nil {
+		return fmt.Errorf("failed to write calls of %d entities: %w", len(result.Entities), err)
+	}
+
+	// Link functions to the package-level variables they read and write
+	if err := w.WriteVariableAccesses(ctx, result.Files, result.Entities); err != nil {
+		return fmt.Errorf("failed to write variable accesses of %d entities: %w", len(result.Entities), err)
+	}
+
+	// Keep calls leaving the repository for cross-repository linking
+	if err := w.WriteExternalCalls(ctx, result.Files, result.Entities); err != nil {
+		return fmt.Errorf("failed to write external calls: %w", err)
 	}
 
 	// Update repository stats
@@ -47,25 +109,41 @@ func (w *GraphWriter) WriteIndexResult(ctx context.Context, result *models.Index
 }
 
 func (w *GraphWriter) WriteFile(ctx context.Context, file *models.File) error {
+	ctx = WithRepository(ctx, file.RepoID)
 	file.ID = uuid.New().String()
 
+	imports := make([]string, 0, len(file.Imports))
+	for _, imp := range file.Imports {
+		imports = append(imports, imp.ImportPath)
+	}
+
 	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		// Files hang off their directory, or the repository at the root
 		query := `
 			MATCH (r:Repository {id: $repoId})
+			OPTIONAL MATCH (d:Directory {repoId: $repoId, path: $dir})
 			MERGE (f:File {repoId: $repoId, path: $path})
 			SET f.id = $id,
 			    f.language = $language,
 			    f.hash = $hash,
-			    f.size = $size
-			MERGE (r)-[:CONTAINS]->(f)
+			    f.size = $size,
+			    f.lines = $lines,
+			    f.layer = $layer,
+			    f.imports = $imports
+			WITH f, coalesce(d, r) AS parent
+			MERGE (parent)-[:CONTAINS]->(f)
 		`
 		_, err := tx.Run(ctx, query, map[string]any{
 			"id":       file.ID,
 			"repoId":   file.RepoID,
 			"path":     file.Path,
+			"dir":      fileDir(file.Path),
 			"language": file.Language,
 			"hash":     file.Hash,
 			"size":     file.Size,
+			"lines":    file.Lines,
+			"layer":    file.Layer,
+			"imports":  imports,
 		})
 		return nil, err
 	})
@@ -73,14 +151,127 @@ func (w *GraphWriter) WriteFile(ctx context.Context, file *models.File) error {
 	return err
 }
 
+// directoryRow is a Directory node to be written
+type directoryRow struct {
+	Path      string
+	Name      string
+	Parent    string
+	IsPackage bool
+}
+
+// fileDir returns the Directory path a file hangs off, "." at the root.
+// Windows separators are normalized so files and directories agree.
+func fileDir(filePath string) string {
+	return path.Dir(strings.ReplaceAll(filePath, "\\", "/"))
+}
+
+// directoriesFor derives every directory above the given files, parents
+// first. Directories that directly contain source files are packages.
+func directoriesFor(files []*models.File) []directoryRow {
+	rows := make(map[string]*directoryRow)
+	for _, file := range files {
+		dir := fileDir(file.Path)
+		isPackage := true
+		for dir != "." && dir != "/" && dir != "" {
+			row, exists := rows[dir]
+			if !exists {
+				row = &directoryRow{
+					Path:   dir,
+					Name:   path.Base(dir),
+					Parent: path.Dir(dir),
+				}
+				rows[dir] = row
+			}
+			if isPackage {
+				row.IsPackage = true
+			}
+			isPackage = false
+			dir = path.Dir(dir)
+		}
+	}
+
+	result := make([]directoryRow, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		di, dj := strings.Count(result[i].Path, "/"), strings.Count(result[j].Path, "/")
+		if di != dj {
+			return di < dj
+		}
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// WriteDirectories writes Directory nodes (labelled Package when they hold
+// source files) with CONTAINS edges from their parent directory or the repository
+func (w *GraphWriter) WriteDirectories(ctx context.Context, repoID string, files []*models.File) error {
+	ctx = WithRepository(ctx, repoID)
+	rows := directoriesFor(files)
+	if len(rows) == 0 {
+		return nil
+	}
+
+	dirs := make([]map[string]any, len(rows))
+	for i, row := range rows {
+		dirs[i] = map[string]any{
+			"id":        uuid.New().String(),
+			"path":      row.Path,
+			"name":      row.Name,
+			"parent":    row.Parent,
+			"isPackage": row.IsPackage,
+		}
+	}
+
+	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			UNWIND $dirs AS d
+			MERGE (n:Directory {repoId: $repoId, path: d.path})
+			ON CREATE SET n.id = d.id
+			SET n.name = d.name
+			FOREACH (_ IN CASE WHEN d.isPackage THEN [1] ELSE [] END | SET n:Package)
+		`
+		if _, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "dirs": dirs}); err != nil {
+			return nil, err
+		}
+
+		// Parents sort before children, so every parent exists by now
+		query = `
+			UNWIND $dirs AS d
+			MATCH (r:Repository {id: $repoId})
+			MATCH (n:Directory {repoId: $repoId, path: d.path})
+			OPTIONAL MATCH (p:Directory {repoId: $repoId, path: d.parent})
+			WITH n, coalesce(p, r) AS parent
+			MERGE (parent)-[:CONTAINS]->(n)
+		`
+		_, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "dirs": dirs})
+		return nil, err
+	})
+
+	return err
+}
+
+// entityLabels maps entity types to their node labels
+var entityLabels = map[models.CodeEntityType]string{
+	models.EntityFunction: "Function",
+	models.EntityClass:    "Class",
+	models.EntityMethod:   "Method",
+	models.EntityVariable: "Variable",
+}
+
 func (w *GraphWriter) WriteEntity(ctx context.Context, repoID string, entity *models.CodeEntity) error {
-	entityID := uuid.New().String()
+	ctx = WithRepository(ctx, repoID)
+	label, ok := entityLabels[entity.Type]
+	if !ok {
+		return nil
+	}
+	if entity.ID == "" {
+		entity.ID = uuid.New().String()
+	}
 
 	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		// Create entity node with appropriate label
-		var query string
-		params := map[string]any{
-			"id":        entityID,
+		props := map[string]any{
 			"name":      entity.Name,
 			"signature": entity.Signature,
 			"docstring": entity.Docstring,
@@ -88,147 +279,239 @@ func (w *GraphWriter) WriteEntity(ctx context.Context, repoID string, entity *mo
 			"endLine":   entity.EndLine,
 			"filePath":  entity.FilePath,
 			"repoId":    repoID,
+			"layer":     entity.Layer,
+		}
+		if entity.ClassName != "" {
+			props["className"] = entity.ClassName
+		}
+		if entity.Complexity > 0 {
+			props["complexity"] = entity.Complexity
+		}
+		if entity.NLDescription != "" {
+			props["description"] = entity.NLDescription
+		}
+		if w.maxContent > 0 && entity.Content != "" {
+			content, truncated := truncateContent(entity.Content, w.maxContent)
+			props["content"] = content
+			props["contentTruncated"] = truncated
 		}
 
 		// Add embedding if available
 		if len(entity.Embedding) > 0 {
-			params["embedding"] = entity.Embedding
+			space := w.client.WriteSpace()
+			if err := space.CheckDimension(entity.Embedding); err != nil {
+				return nil, fmt.Errorf("embedding of %s: %w", entity.Name, err)
+			}
+			props[space.Property] = entity.Embedding
 		}
 
-		switch entity.Type {
-		case models.EntityFunction:
-			if len(entity.Embedding) > 0 {
-				query = `
-					MATCH (f:File {repoId: $repoId, path: $filePath})
-					CREATE (e:Function {
-						id: $id,
-						name: $name,
-						signature: $signature,
-						docstring: $docstring,
-						startLine: $startLine,
-						endLine: $endLine,
-						filePath: $filePath,
-						repoId: $repoId,
-						embedding: $embedding
-					})
-					CREATE (f)-[:DECLARES]->(e)
-				`
-			} else {
-				query = `
-					MATCH (f:File {repoId: $repoId, path: $filePath})
-					CREATE (e:Function {
-						id: $id,
-						name: $name,
-						signature: $signature,
-						docstring: $docstring,
-						startLine: $startLine,
-						endLine: $endLine,
-						filePath: $filePath,
-						repoId: $repoId
-					})
-					CREATE (f)-[:DECLARES]->(e)
-				`
-			}
-		case models.EntityClass:
-			if len(entity.Embedding) > 0 {
-				query = `
-					MATCH (f:File {repoId: $repoId, path: $filePath})
-					CREATE (e:Class {
-						id: $id,
-						name: $name,
-						docstring: $docstring,
-						startLine: $startLine,
-						endLine: $endLine,
-						filePath: $filePath,
-						repoId: $repoId,
-						embedding: $embedding
-					})
-					CREATE (f)-[:DECLARES]->(e)
-				`
-			} else {
-				query = `
-					MATCH (f:File {repoId: $repoId, path: $filePath})
-					CREATE (e:Class {
-						id: $id,
-						name: $name,
-						docstring: $docstring,
-						startLine: $startLine,
-						endLine: $endLine,
-						filePath: $filePath,
-						repoId: $repoId
-					})
-					CREATE (f)-[:DECLARES]->(e)
-				`
-			}
-		case models.EntityMethod:
-			if len(entity.Embedding) > 0 {
-				query = `
-					MATCH (f:File {repoId: $repoId, path: $filePath})
-					CREATE (e:Method {
-						id: $id,
-						name: $name,
-						signature: $signature,
-						docstring: $docstring,
-						startLine: $startLine,
-						endLine: $endLine,
-						filePath: $filePath,
-						repoId: $repoId,
-						embedding: $embedding
-					})
-					CREATE (f)-[:DECLARES]->(e)
-				`
-			} else {
-				query = `
-					MATCH (f:File {repoId: $repoId, path: $filePath})
-					CREATE (e:Method {
-						id: $id,
-						name: $name,
-						signature: $signature,
-						docstring: $docstring,
-						startLine: $startLine,
-						endLine: $endLine,
-						filePath: $filePath,
-						repoId: $repoId
-					})
-					CREATE (f)-[:DECLARES]->(e)
-				`
-			}
-		default:
-			return nil, nil
+		// Label comes from the fixed entityLabels map, never from input
+		query := `
+			MATCH (f:File {repoId: $repoId, path: $filePath})
+			CREATE (e:` + label + ` {id: $id})
+			SET e += $props
+			CREATE (f)-[:DECLARES]->(e)
+		`
+		_, err := tx.Run(ctx, query, map[string]any{
+			"id":       entity.ID,
+			"repoId":   repoID,
+			"filePath": entity.FilePath,
+			"props":    props,
+		})
+		if err != nil || len(entity.DocEmbedding) == 0 {
+			return nil, err
 		}
 
-		_, err := tx.Run(ctx, query, params)
+		// A long docstring is searched as documentation on its own
+		space := w.client.WriteSpace()
+		if err := space.CheckDimension(entity.DocEmbedding); err != nil {
+			return nil, fmt.Errorf("docstring embedding of %s: %w", entity.Name, err)
+		}
+		query = `
+			MATCH (e:` + label + ` {id: $id})
+			CREATE (e)-[:DOCUMENTED_BY]->(d:Docstring {entityId: $id})
+			SET d += $props
+		`
+		_, err = tx.Run(ctx, query, map[string]any{
+			"id": entity.ID,
+			"props": map[string]any{
+				"repoId":       repoID,
+				"name":         entity.Name,
+				"signature":    entity.Signature,
+				"docstring":    entity.Docstring,
+				"filePath":     entity.FilePath,
+				"startLine":    entity.StartLine,
+				"endLine":      entity.EndLine,
+				space.Property: entity.DocEmbedding,
+			},
+		})
 		return nil, err
 	})
 
 	return err
 }
 
-func (w *GraphWriter) WriteCallRelationships(ctx context.Context, entity *models.CodeEntity) error {
+// Memberships pairs each method with the class it belongs to, as
+// [methodID, classID]. A class in the same file wins; otherwise a class in the
+// same directory is used, as with Go methods declared apart from their type.
+func Memberships(entities []models.CodeEntity) [][2]string {
+	byFile := make(map[[2]string]string)
+	byDir := make(map[[2]string]string)
+	for _, entity := range entities {
+		if entity.Type != models.EntityClass || entity.ID == "" {
+			continue
+		}
+		byFile[[2]string{entity.FilePath, entity.Name}] = entity.ID
+		dirKey := [2]string{path.Dir(entity.FilePath), entity.Name}
+		if _, exists := byDir[dirKey]; !exists {
+			byDir[dirKey] = entity.ID
+		}
+	}
+
+	var pairs [][2]string
+	for _, entity := range entities {
+		if entity.Type != models.EntityMethod || entity.ClassName == "" || entity.ID == "" {
+			continue
+		}
+		classID, ok := byFile[[2]string{entity.FilePath, entity.ClassName}]
+		if !ok {
+			classID, ok = byDir[[2]string{path.Dir(entity.FilePath), entity.ClassName}]
+		}
+		if ok {
+			pairs = append(pairs, [2]string{entity.ID, classID})
+		}
+	}
+	return pairs
+}
+
+// WriteMemberships writes MEMBER_OF edges from methods to their classes
+func (w *GraphWriter) WriteMemberships(ctx context.Context, entities []models.CodeEntity) error {
+	pairs := Memberships(entities)
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	rows := make([]map[string]any, len(pairs))
+	for i, pair := range pairs {
+		rows[i] = map[string]any{"methodId": pair[0], "classId": pair[1]}
+	}
+
 	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		for _, calledName := range entity.Calls {
-			query := `
-				MATCH (caller:Function|Method {name: $callerName, filePath: $filePath})
-				MATCH (callee:Function|Method {name: $calleeName})
-				WHERE callee.repoId = caller.repoId
-				MERGE (caller)-[:CALLS]->(callee)
-			`
-			_, err := tx.Run(ctx, query, map[string]any{
-				"callerName": entity.Name,
-				"filePath":   entity.FilePath,
-				"calleeName": calledName,
-			})
-			if err != nil {
-				return nil, err
-			}
+		query := `
+			UNWIND $rows AS row
+			MATCH (m:Method {id: row.methodId})
+			MATCH (c:Class {id: row.classId})
+			MERGE (m)-[:MEMBER_OF]->(c)
+		`
+		_, err := tx.Run(ctx, query, map[string]any{"rows": rows})
+		return nil, err
+	})
+
+	return err
+}
+
+// WriteImplementations writes IMPLEMENTS edges from classes to the
+// interfaces and base classes a language server found them implementing
+func (w *GraphWriter) WriteImplementations(ctx context.Context, entities []models.CodeEntity) error {
+	relations := analysis.ResolveImplementations(entities)
+	if len(relations) == 0 {
+		return nil
+	}
+
+	rows := make([]map[string]any, len(relations))
+	for i, rel := range relations {
+		rows[i] = map[string]any{"typeId": rel.TypeID, "interfaceId": rel.InterfaceID}
+	}
+
+	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			UNWIND $rows AS row
+			MATCH (t:Class {id: row.typeId})
+			MATCH (i:Class {id: row.interfaceId})
+			MERGE (t)-[:IMPLEMENTS]->(i)
+		`
+		_, err := tx.Run(ctx, query, map[string]any{"rows": rows})
+		return nil, err
+	})
+
+	return err
+}
+
+// WriteCallRelationships resolves each entity's calls against the indexed
+// entities and writes CALLS edges carrying the call site lines and count.
+// Calls with several plausible callees are recorded on the caller as
+// ambiguousCalls instead of linked, and calls matching no entity as
+// unresolvedCalls.
+func (w *GraphWriter) WriteCallRelationships(ctx context.Context, files []*models.File, entities []models.CodeEntity) error {
+	calls, ambiguous, unresolved := analysis.ResolveCalls(files, entities)
+
+	rows := make([]map[string]any, len(calls))
+	for i, call := range calls {
+		rows[i] = map[string]any{
+			"callerId": call.CallerID,
+			"calleeId": call.CalleeID,
+			"line":     call.Line,
+			"lines":    call.Lines,
+			"count":    call.Count,
 		}
-		return nil, nil
+	}
+
+	ambiguousByCaller := make(map[string][]string)
+	for _, call := range ambiguous {
+		ambiguousByCaller[call.CallerID] = append(ambiguousByCaller[call.CallerID], call.Name)
+	}
+	ambiguousRows := make([]map[string]any, 0, len(ambiguousByCaller))
+	for callerID, names := range ambiguousByCaller {
+		ambiguousRows = append(ambiguousRows, map[string]any{"callerId": callerID, "names": names})
+	}
+
+	unresolvedByCaller := make(map[string][]string)
+	for _, call := range unresolved {
+		unresolvedByCaller[call.CallerID] = append(unresolvedByCaller[call.CallerID], encodeUnresolvedCall(call))
+	}
+	unresolvedRows := make([]map[string]any, 0, len(unresolvedByCaller))
+	for callerID, calls := range unresolvedByCaller {
+		unresolvedRows = append(unresolvedRows, map[string]any{"callerId": callerID, "calls": calls})
+	}
+
+	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			UNWIND $rows AS row
+			MATCH (caller:Function|Method {id: row.callerId})
+			MATCH (callee:Function|Method {id: row.calleeId})
+			MERGE (caller)-[c:CALLS]->(callee)
+			SET c.line = row.line, c.lines = row.lines, c.count = row.count
+		`
+		if _, err := tx.Run(ctx, query, map[string]any{"rows": rows}); err != nil {
+			return nil, err
+		}
+
+		query = `
+			UNWIND $rows AS row
+			MATCH (caller:Function|Method {id: row.callerId})
+			SET caller.ambiguousCalls = row.names
+		`
+		if _, err := tx.Run(ctx, query, map[string]any{"rows": ambiguousRows}); err != nil {
+			return nil, err
+		}
+
+		query = `
+			UNWIND $rows AS row
+			MATCH (caller:Function|Method {id: row.callerId})
+			SET caller.unresolvedCalls = row.calls
+		`
+		_, err := tx.Run(ctx, query, map[string]any{"rows": unresolvedRows})
+		return nil, err
 	})
 
 	return err
 }
 
+// UpdateRepositoryStats records the counts on the repository and marks it
+// ready. It always writes to the shared database the repository list is read
+// from.
 func (w *GraphWriter) UpdateRepositoryStats(ctx context.Context, repoID string, filesCount, entitiesCount int) error {
+	ctx = catalog(ctx)
 	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		query := `
 			MATCH (r:Repository {id: $id})
@@ -247,17 +530,37 @@ func (w *GraphWriter) UpdateRepositoryStats(ctx context.Context, repoID string,
 	return err
 }
 
+// repositoryGraphQueries delete a repository's indexed data, one family of
+// nodes per statement: matched together, their rows would multiply
+var repositoryGraphQueries = []string{
+	`MATCH (r:Repository {id: $id})
+	OPTIONAL MATCH (r)-[:CONTAINS*]->(n)
+	OPTIONAL MATCH (n)-[:DECLARES]->(e)
+	OPTIONAL MATCH (e)-[:DOCUMENTED_BY]->(doc:Docstring)
+	DETACH DELETE doc, e, n`,
+	`MATCH (r:Repository {id: $id})-[:HAS_FINDING]->(finding:Finding)
+	DETACH DELETE finding`,
+	`MATCH (r:Repository {id: $id})-[:HAS_COMMIT]->(commit:Commit)
+	DETACH DELETE commit`,
+	`MATCH (r:Repository {id: $id})-[:HAS_AUTHOR]->(author:Author)
+	DETACH DELETE author`,
+}
+
+// runEach runs queries in turn in one transaction with the same parameters
+func runEach(ctx context.Context, tx neo4j.ManagedTransaction, queries []string, params map[string]any) error {
+	for _, query := range queries {
+		if _, err := tx.Run(ctx, query, params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ClearRepository removes all indexed data for a repository
 func (w *GraphWriter) ClearRepository(ctx context.Context, repoID string) error {
+	ctx = WithRepository(ctx, repoID)
 	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		query := `
-			MATCH (r:Repository {id: $id})
-			OPTIONAL MATCH (r)-[:CONTAINS]->(f:File)
-			OPTIONAL MATCH (f)-[:DECLARES]->(e)
-			DETACH DELETE e, f
-		`
-		_, err := tx.Run(ctx, query, map[string]any{"id": repoID})
-		return nil, err
+		return nil, runEach(ctx, tx, repositoryGraphQueries, map[string]any{"id": repoID})
 	})
 
 	return err
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDirectoriesFor tests that the directory hierarchy is derived parents first
+func TestDirectoriesFor(t *testing.T) {
+	files := []*models.File{
+		{Path: "main.go"},
+		{Path: "internal/api/handlers.go"},
+		{Path: "internal/api/routes.go"},
+		{Path: "internal/db/client.go"},
+		{Path: "pkg/util/strings/trim.go"},
+	}
+
+	got := directoriesFor(files)
+
+	assert.Equal(t, []directoryRow{
+		{Path: "internal", Name: "internal", Parent: "."},
+		{Path: "pkg", Name: "pkg", Parent: "."},
+		{Path: "internal/api", Name: "api", Parent: "internal", IsPackage: true},
+		{Path: "internal/db", Name: "db", Parent: "internal", IsPackage: true},
+		{Path: "pkg/util", Name: "util", Parent: "pkg"},
+		{Path: "pkg/util/strings", Name: "strings", Parent: "pkg/util", IsPackage: true},
+	}, got)
+}
+
+// TestDirectoriesForRootOnly tests that root-level files produce no directories
+func TestDirectoriesForRootOnly(t *testing.T) {
+	got := directoriesFor([]*models.File{{Path: "README.md"}, {Path: "main.go"}})
+	assert.Empty(t, got)
+}
+
+// TestFileDir tests that files hang off the directories directoriesFor
+// derives, whatever their separators
+func TestFileDir(t *testing.T) {
+	assert.Equal(t, "internal/api", fileDir("internal/api/handlers.go"))
+	assert.Equal(t, "internal/api", fileDir(`internal\api\handlers.go`))
+	assert.Equal(t, ".", fileDir("main.go"))
+
+	dirs := directoriesFor([]*models.File{{Path: `internal\api\handlers.go`}})
+	assert.Equal(t, fileDir(`internal\api\handlers.go`), dirs[len(dirs)-1].Path)
+}
+
+// TestMemberships tests that methods resolve to classes in the same file first,
+// then in the same directory
+func TestMemberships(t *testing.T) {
+	entities := []models.CodeEntity{
+		{ID: "c1", Type: models.EntityClass, Name: "Server", FilePath: "api/server.go"},
+		{ID: "c2", Type: models.EntityClass, Name: "Server", FilePath: "api/v2/server.go"},
+		{ID: "c3", Type: models.EntityClass, Name: "Store", FilePath: "db/store.py"},
+		{ID: "m1", Type: models.EntityMethod, Name: "Start", ClassName: "Server", FilePath: "api/server.go"},
+		{ID: "m2", Type: models.EntityMethod, Name: "Stop", ClassName: "Server", FilePath: "api/lifecycle.go"},
+		{ID: "m3", Type: models.EntityMethod, Name: "load", ClassName: "Store", FilePath: "db/store.py"},
+		{ID: "m4", Type: models.EntityMethod, Name: "orphan", ClassName: "Missing", FilePath: "db/store.py"},
+		{ID: "f1", Type: models.EntityFunction, Name: "main", FilePath: "main.go"},
+	}
+
+	assert.Equal(t, [][2]string{
+		{"m1", "c1"},
+		{"m2", "c1"},
+		{"m3", "c3"},
+	}, Memberships(entities))
+}
+
+// TestTruncateContent tests that stored source is capped on a rune boundary
+func TestTruncateContent(t *testing.T) {
+	content, truncated := truncateContent("func main() {}", 100)
+	assert.Equal(t, "func main() {}", content)
+	assert.False(t, truncated)
+
+	content, truncated = truncateContent("func main() {}", 4)
+	assert.Equal(t, "func", content)
+	assert.True(t, truncated)
+
+	// "é" is two bytes; cutting inside it drops the whole rune
+	content, truncated = truncateContent("aé", 2)
+	assert.Equal(t, "a", content)
+	assert.True(t, truncated)
+}
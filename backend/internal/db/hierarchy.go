@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// HierarchyNode is a class or interface with the types implementing or
+// extending it
+type HierarchyNode struct {
+	ID        string           `json:"id"`
+	Name      string           `json:"name"`
+	FilePath  string           `json:"filePath"`
+	StartLine int              `json:"startLine,omitempty"`
+	Subtypes  []*HierarchyNode `json:"subtypes"`
+}
+
+// hierarchyClass is a class of a hierarchy with its direct supertypes in it
+type hierarchyClass struct {
+	node    HierarchyNode
+	parents []string
+}
+
+// GetClassHierarchy returns the tree of classes implementing or extending a
+// class through IMPLEMENTS edges, directly or not. A class with several
+// supertypes in the tree appears under each. Returns nil if the class does
+// not exist.
+func (r *GraphReader) GetClassHierarchy(ctx context.Context, repoID, rootID string) (*HierarchyNode, error) {
+	ctx = WithRepository(ctx, repoID)
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (root:Class {id: $rootId, repoId: $repoId})
+			OPTIONAL MATCH (sub:Class)-[:IMPLEMENTS*1..]->(root)
+			WITH root, collect(DISTINCT sub) AS subs
+			WITH [root] + subs AS classes
+			UNWIND classes AS c
+			OPTIONAL MATCH (c)-[:IMPLEMENTS]->(parent:Class)
+			WHERE parent IN classes
+			RETURN c.id AS id, c.name AS name, c.filePath AS filePath, c.startLine AS startLine,
+			       collect(DISTINCT parent.id) AS parents
+			ORDER BY c.filePath, c.startLine, c.id
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "rootId": rootID})
+		if err != nil {
+			return nil, err
+		}
+
+		var classes []hierarchyClass
+		for records.Next(ctx) {
+			rec := records.Record()
+			class := hierarchyClass{
+				node: HierarchyNode{
+					ID:       recordString(rec, "id"),
+					Name:     recordString(rec, "name"),
+					FilePath: recordString(rec, "filePath"),
+				},
+				parents: recordStrings(rec, "parents"),
+			}
+			if sl, _ := rec.Get("startLine"); sl != nil {
+				class.node.StartLine = int(sl.(int64))
+			}
+			classes = append(classes, class)
+		}
+		return classes, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return buildHierarchy(rootID, result.([]hierarchyClass)), nil
+}
+
+// buildHierarchy nests classes under their supertypes starting at the root,
+// keeping their order. An edge back to a class already on the path is
+// dropped, so the tree is finite even when IMPLEMENTS edges form a cycle.
+func buildHierarchy(rootID string, classes []hierarchyClass) *HierarchyNode {
+	byID := make(map[string]HierarchyNode, len(classes))
+	children := make(map[string][]string)
+	for _, class := range classes {
+		byID[class.node.ID] = class.node
+		for _, parent := range class.parents {
+			children[parent] = append(children[parent], class.node.ID)
+		}
+	}
+	if _, ok := byID[rootID]; !ok {
+		return nil
+	}
+
+	onPath := make(map[string]bool)
+	var build func(id string) *HierarchyNode
+	build = func(id string) *HierarchyNode {
+		node := byID[id]
+		node.Subtypes = []*HierarchyNode{}
+		onPath[id] = true
+		for _, child := range children[id] {
+			if !onPath[child] {
+				node.Subtypes = append(node.Subtypes, build(child))
+			}
+		}
+		onPath[id] = false
+		return &node
+	}
+	return build(rootID)
+}
+
+// mermaidUnsafe matches what may not appear in a Mermaid class ID
+var mermaidUnsafe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// HierarchyMermaid renders a class hierarchy as a Mermaid class diagram,
+// for embedding in wiki pages. Classes are keyed by ID, so classes sharing
+// a name stay apart.
+func HierarchyMermaid(root *HierarchyNode) string {
+	var b strings.Builder
+	b.WriteString("classDiagram\n")
+
+	ids := make(map[string]string)
+	key := func(node *HierarchyNode) string {
+		if id, ok := ids[node.ID]; ok {
+			return id
+		}
+		id := fmt.Sprintf("c%d_%s", len(ids), mermaidUnsafe.ReplaceAllString(node.Name, "_"))
+		ids[node.ID] = id
+		fmt.Fprintf(&b, "  class %s[\"%s\"]\n", id, strings.ReplaceAll(node.Name, `"`, "'"))
+		return id
+	}
+
+	edges := make(map[[2]string]bool)
+	var walk func(node *HierarchyNode)
+	walk = func(node *HierarchyNode) {
+		parent := key(node)
+		for _, sub := range node.Subtypes {
+			child := key(sub)
+			if !edges[[2]string{parent, child}] {
+				edges[[2]string{parent, child}] = true
+				fmt.Fprintf(&b, "  %s <|-- %s\n", parent, child)
+			}
+			walk(sub)
+		}
+	}
+	walk(root)
+	return b.String()
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hierarchyTestClasses() []hierarchyClass {
+	class := func(id, name string, parents ...string) hierarchyClass {
+		return hierarchyClass{node: HierarchyNode{ID: id, Name: name, FilePath: "shapes.ts"}, parents: parents}
+	}
+	return []hierarchyClass{
+		class("shape", "Shape", "square"), // cycle back to the root
+		class("polygon", "Polygon", "shape"),
+		class("rect", "Rect", "polygon", "drawable"),
+		class("square", "Square", "rect"),
+		class("drawable", "Drawable", "shape"),
+	}
+}
+
+// TestBuildHierarchy tests nesting subtypes, repeating diamonds and
+// stopping at cycles
+func TestBuildHierarchy(t *testing.T) {
+	root := buildHierarchy("shape", hierarchyTestClasses())
+	require.NotNil(t, root)
+	assert.Equal(t, "Shape", root.Name)
+	require.Len(t, root.Subtypes, 2)
+
+	polygon, drawable := root.Subtypes[0], root.Subtypes[1]
+	assert.Equal(t, "polygon", polygon.ID)
+	assert.Equal(t, "drawable", drawable.ID)
+	// Rect implements both, so it appears under each
+	require.Len(t, polygon.Subtypes, 1)
+	require.Len(t, drawable.Subtypes, 1)
+	assert.Equal(t, "rect", drawable.Subtypes[0].ID)
+
+	square := polygon.Subtypes[0].Subtypes[0]
+	assert.Equal(t, "square", square.ID)
+	assert.Empty(t, square.Subtypes)
+	assert.NotNil(t, square.Subtypes, "leaves serialize as []")
+
+	assert.Nil(t, buildHierarchy("missing", hierarchyTestClasses()))
+}
+
+// TestHierarchyMermaid tests declaring each class once, with its name quoted
+func TestHierarchyMermaid(t *testing.T) {
+	square := &HierarchyNode{ID: "square", Name: "Square"}
+	root := &HierarchyNode{ID: "polygon", Name: "Polygon", Subtypes: []*HierarchyNode{
+		{ID: "rect", Name: "Rect", Subtypes: []*HierarchyNode{square}},
+		{ID: "gen", Name: `Box<"T">`, Subtypes: []*HierarchyNode{square}},
+	}}
+
+	assert.Equal(t, `classDiagram
+  class c0_Polygon["Polygon"]
+  class c1_Rect["Rect"]
+  c0_Polygon <|-- c1_Rect
+  class c2_Square["Square"]
+  c1_Rect <|-- c2_Square
+  class c3_Box__T__["Box<'T'>"]
+  c0_Polygon <|-- c3_Box__T__
+  c3_Box__T__ <|-- c2_Square
+`, HierarchyMermaid(root))
+}
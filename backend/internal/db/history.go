@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// historyBatchSize bounds how many commits are written per transaction
+const historyBatchSize = 200
+
+// FileChurn is how often and by whom a file was changed in the indexed
+// history
+type FileChurn struct {
+	Path         string    `json:"path"`
+	Commits      int       `json:"commits"`
+	Additions    int       `json:"additions"`
+	Deletions    int       `json:"deletions"`
+	Authors      int       `json:"authors"`
+	Owner        string    `json:"owner"`      // author of most commits to the file
+	OwnerShare   float64   `json:"ownerShare"` // share of the commits by the owner, 0 to 1
+	LastModified time.Time `json:"lastModified"`
+}
+
+// WriteHistory replaces a repository's Commit and Author nodes with the
+// given commits. Authors are identified by email and link to their commits
+// with AUTHORED; commits link to the indexed files they changed with
+// MODIFIED edges carrying line counts.
+func (w *GraphWriter) WriteHistory(ctx context.Context, repoID string, commits []models.Commit) error {
+	ctx = WithRepository(ctx, repoID)
+
+	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})
+			OPTIONAL MATCH (r)-[:HAS_COMMIT]->(c:Commit)
+			OPTIONAL MATCH (r)-[:HAS_AUTHOR]->(a:Author)
+			DETACH DELETE c, a
+		`
+		_, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		return nil, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clear history: %w", err)
+	}
+
+	for start := 0; start < len(commits); start += historyBatchSize {
+		end := min(start+historyBatchSize, len(commits))
+
+		rows := make([]map[string]any, 0, end-start)
+		for _, commit := range commits[start:end] {
+			files := make([]map[string]any, len(commit.Files))
+			for i, change := range commit.Files {
+				files[i] = map[string]any{
+					"path":      change.Path,
+					"additions": change.Additions,
+					"deletions": change.Deletions,
+				}
+			}
+			rows = append(rows, map[string]any{
+				"hash":        commit.Hash,
+				"message":     commit.Message,
+				"time":        commit.Time,
+				"authorName":  commit.AuthorName,
+				"authorEmail": commit.AuthorEmail,
+				"files":       files,
+			})
+		}
+
+		_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			query := `
+				MATCH (r:Repository {id: $repoId})
+				UNWIND $rows AS row
+				MERGE (a:Author {repoId: $repoId, email: row.authorEmail})
+				ON CREATE SET a.name = row.authorName
+				MERGE (r)-[:HAS_AUTHOR]->(a)
+				CREATE (r)-[:HAS_COMMIT]->(c:Commit {
+					hash: row.hash, repoId: $repoId, message: row.message, time: row.time
+				})
+				CREATE (a)-[:AUTHORED]->(c)
+				WITH c, row
+				UNWIND row.files AS change
+				MATCH (f:File {repoId: $repoId, path: change.path})
+				CREATE (c)-[:MODIFIED {additions: change.additions, deletions: change.deletions}]->(f)
+			`
+			_, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "rows": rows})
+			return nil, err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write commits %d-%d of %d: %w", start+1, end, len(commits), err)
+		}
+	}
+	return nil
+}
+
+// GetFileChurn returns the files changed by the most commits in the indexed
+// history, with their line churn and the author owning most of the changes
+func (r *GraphReader) GetFileChurn(ctx context.Context, repoID string, limit int) ([]FileChurn, error) {
+	ctx = WithRepository(ctx, repoID)
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:HAS_COMMIT]->(c:Commit)-[m:MODIFIED]->(f:File)
+			MATCH (a:Author)-[:AUTHORED]->(c)
+			WITH f, a, count(c) AS authorCommits, sum(m.additions) AS additions,
+			     sum(m.deletions) AS deletions, max(c.time) AS lastModified
+			ORDER BY authorCommits DESC, a.email
+			WITH f, collect({name: coalesce(a.name, a.email), commits: authorCommits}) AS authors,
+			     sum(authorCommits) AS commits, sum(additions) AS additions,
+			     sum(deletions) AS deletions, max(lastModified) AS lastModified
+			RETURN f.path AS path, commits, additions, deletions, lastModified,
+			       size(authors) AS authorCount, authors[0].name AS owner,
+			       toFloat(authors[0].commits) / commits AS ownerShare
+			ORDER BY commits DESC, additions + deletions DESC, path
+			LIMIT $limit
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+
+		churn := []FileChurn{}
+		for records.Next(ctx) {
+			rec := records.Record()
+			file := FileChurn{
+				Path:  recordString(rec, "path"),
+				Owner: recordString(rec, "owner"),
+			}
+			if v, _ := rec.Get("commits"); v != nil {
+				file.Commits = int(v.(int64))
+			}
+			if v, _ := rec.Get("additions"); v != nil {
+				file.Additions = int(v.(int64))
+			}
+			if v, _ := rec.Get("deletions"); v != nil {
+				file.Deletions = int(v.(int64))
+			}
+			if v, _ := rec.Get("authorCount"); v != nil {
+				file.Authors = int(v.(int64))
+			}
+			if v, _ := rec.Get("ownerShare"); v != nil {
+				file.OwnerShare = v.(float64)
+			}
+			if v, _ := rec.Get("lastModified"); v != nil {
+				if t, ok := v.(time.Time); ok {
+					file.LastModified = t
+				}
+			}
+			churn = append(churn, file)
+		}
+		return churn, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]FileChurn), nil
+}
@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// FileHotspot is a file, or a directory, that changes often and holds
+// complex code, with its most complex function as the place to start
+// refactoring
+type FileHotspot struct {
+	Path        string           `json:"path"`       // "." for files at the top of the repository folded by depth
+	Commits     int              `json:"commits"`    // in the indexed history
+	Complexity  int              `json:"complexity"` // summed over its functions
+	Functions   int              `json:"functions"`
+	Score       float64          `json:"score"` // 0 to 1
+	TopFunction *ComplexFunction `json:"topFunction,omitempty"`
+}
+
+// hotspotFile is a file's hotspot with the commits modifying it, so files
+// folded into a directory count the commits they share once
+type hotspotFile struct {
+	FileHotspot
+	commits []string
+}
+
+// GetHotspots returns the files ranked by analysis.RankHotspots, combining
+// the commits of the indexed history with the complexity scored while
+// indexing. With depth above 0, files are folded into their directory at
+// that depth and directories are ranked instead. Repositories indexed
+// without history have no hotspots.
+func (r *GraphReader) GetHotspots(ctx context.Context, repoID string, depth, limit int) ([]FileHotspot, error) {
+	ctx = WithRepository(ctx, repoID)
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:HAS_COMMIT]->(c:Commit)-[:MODIFIED]->(f:File)
+			WITH f, collect(c.hash) AS commits
+			MATCH (f)-[:DECLARES]->(e:Function|Method)
+			WHERE e.complexity IS NOT NULL
+			WITH f, commits, e
+			ORDER BY e.complexity DESC, e.startLine
+			WITH f, commits, sum(e.complexity) AS complexity, count(e) AS functions, collect(e)[0] AS top
+			RETURN f.path AS path, commits, complexity, functions,
+			       top.id AS topId, top.name AS topName, labels(top) AS topLabels,
+			       top.startLine AS topStartLine, top.endLine AS topEndLine,
+			       top.complexity AS topComplexity
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+
+		var files []hotspotFile
+		for records.Next(ctx) {
+			rec := records.Record()
+			file := hotspotFile{FileHotspot: FileHotspot{Path: recordString(rec, "path")}}
+			file.commits = recordStrings(rec, "commits")
+			if v, _ := rec.Get("complexity"); v != nil {
+				file.Complexity = int(v.(int64))
+			}
+			if v, _ := rec.Get("functions"); v != nil {
+				file.Functions = int(v.(int64))
+			}
+			top := &ComplexFunction{
+				ID:       recordString(rec, "topId"),
+				Name:     recordString(rec, "topName"),
+				FilePath: file.Path,
+				Type:     "Function",
+			}
+			if v, _ := rec.Get("topStartLine"); v != nil {
+				top.StartLine = int(v.(int64))
+			}
+			if v, _ := rec.Get("topEndLine"); v != nil {
+				top.EndLine = int(v.(int64))
+			}
+			if v, _ := rec.Get("topComplexity"); v != nil {
+				top.Complexity = v.(int64)
+			}
+			for _, label := range recordStrings(rec, "topLabels") {
+				if label == "Method" {
+					top.Type = "Method"
+				}
+			}
+			file.TopFunction = top
+			files = append(files, file)
+		}
+		return files, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	files := foldHotspots(result.([]hotspotFile), depth)
+	inputs := make([]analysis.Hotspot, 0, len(files))
+	for _, file := range files {
+		inputs = append(inputs, analysis.Hotspot{Path: file.Path, Commits: file.Commits, Complexity: file.Complexity})
+	}
+
+	hotspots := []FileHotspot{}
+	for _, ranked := range analysis.RankHotspots(inputs) {
+		if len(hotspots) == limit {
+			break
+		}
+		file := files[ranked.Path]
+		file.Score = ranked.Score
+		hotspots = append(hotspots, file)
+	}
+	return hotspots, nil
+}
+
+// foldHotspots keys file hotspots by path or, with depth above 0, sums them
+// up per directory at that depth. Commits are counted once per key; the
+// most complex function is kept.
+func foldHotspots(files []hotspotFile, depth int) map[string]FileHotspot {
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	folded := make(map[string]FileHotspot)
+	commits := make(map[string]map[string]bool)
+	for _, file := range files {
+		key := file.Path
+		if depth > 0 {
+			key = path.Dir(strings.ReplaceAll(file.Path, "\\", "/"))
+			if parts := strings.Split(key, "/"); len(parts) > depth {
+				key = strings.Join(parts[:depth], "/")
+			}
+		}
+
+		spot, ok := folded[key]
+		if !ok {
+			spot = FileHotspot{Path: key}
+			commits[key] = make(map[string]bool)
+		}
+		for _, hash := range file.commits {
+			if !commits[key][hash] {
+				commits[key][hash] = true
+				spot.Commits++
+			}
+		}
+		spot.Complexity += file.Complexity
+		spot.Functions += file.Functions
+		if top := file.TopFunction; top != nil && (spot.TopFunction == nil || top.Complexity > spot.TopFunction.Complexity) {
+			spot.TopFunction = top
+		}
+		folded[key] = spot
+	}
+	return folded
+}
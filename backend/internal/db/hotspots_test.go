@@ -0,0 +1,33 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFoldHotspots(t *testing.T) {
+	files := []hotspotFile{
+		{FileHotspot: FileHotspot{Path: "internal/db/graph.go", Complexity: 30, Functions: 3,
+			TopFunction: &ComplexFunction{Name: "GetGraph", Complexity: 12}}, commits: []string{"a", "b"}},
+		{FileHotspot: FileHotspot{Path: "internal/db/wiki/pages.go", Complexity: 10, Functions: 2,
+			TopFunction: &ComplexFunction{Name: "WritePage", Complexity: 15}}, commits: []string{"b", "c"}},
+		{FileHotspot: FileHotspot{Path: "internal/api/routes.go", Complexity: 5, Functions: 1}, commits: []string{"a"}},
+		{FileHotspot: FileHotspot{Path: "main.go", Complexity: 2, Functions: 1}, commits: []string{"c"}},
+	}
+
+	byFile := foldHotspots(files, 0)
+	assert.Len(t, byFile, 4)
+	assert.Equal(t, 2, byFile["internal/db/graph.go"].Commits)
+
+	byDir := foldHotspots(files, 2)
+	if assert.Len(t, byDir, 3) {
+		dir := byDir["internal/db"]
+		assert.Equal(t, 3, dir.Commits, "shared commits count once")
+		assert.Equal(t, 40, dir.Complexity)
+		assert.Equal(t, 5, dir.Functions)
+		assert.Equal(t, "WritePage", dir.TopFunction.Name, "the most complex function is kept")
+		assert.Equal(t, 1, byDir["internal/api"].Commits)
+		assert.Equal(t, 2, byDir["."].Complexity, "top files fold into the repository root")
+	}
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"sort"
+)
+
+// rrfK damps the weight of top ranks in reciprocal rank fusion; 60 is the
+// constant of the original paper and works without tuning
+const rrfK = 60
+
+// hybridCandidates is how many results per requested one each search
+// contributes before fusion
+const hybridCandidates = 2
+
+// HybridSearch runs semantic search with embedding and keyword search with
+// text, and fuses their rankings, so exact identifier matches the vector
+// index misses still rank. Scores are reciprocal rank fusion scores, see
+// FuseRanks.
+func (r *GraphReader) HybridSearch(ctx context.Context, space VectorSpace, embedding []float32, text string, limit int, repoID string, filter SearchFilter) ([]SearchResult, error) {
+	semantic, err := r.VectorSearch(ctx, space, embedding, limit*hybridCandidates, repoID, filter)
+	if err != nil {
+		return nil, err
+	}
+	keyword, err := r.KeywordSearch(ctx, text, limit*hybridCandidates, repoID, filter)
+	if err != nil {
+		return nil, err
+	}
+	return FuseRanks([][]SearchResult{semantic, keyword}, limit), nil
+}
+
+// FuseRanks merges ranked result lists by reciprocal rank fusion: a result
+// scores the sum of 1/(rrfK+rank) over the lists it appears in, rank
+// starting at 1, relative to a result first in every non-empty list. Lists
+// are expected best first; ties keep the order results were first seen in.
+// Returns at most limit results.
+func FuseRanks(lists [][]SearchResult, limit int) []SearchResult {
+	fused := []SearchResult{}
+	index := make(map[string]int) // ID -> index in fused
+	best := 0.0
+	for _, list := range lists {
+		if len(list) > 0 {
+			best += 1 / float64(rrfK+1)
+		}
+		for rank, result := range list {
+			score := 1 / float64(rrfK+rank+1)
+			if i, ok := index[result.ID]; ok {
+				fused[i].Score += score
+				continue
+			}
+			index[result.ID] = len(fused)
+			result.Score = score
+			fused = append(fused, result)
+		}
+	}
+	for i := range fused {
+		fused[i].Score /= best
+	}
+	sort.SliceStable(fused, func(i, j int) bool { return fused[i].Score > fused[j].Score })
+	if limit > 0 && len(fused) > limit {
+		fused = fused[:limit]
+	}
+	return fused
+}
@@ -0,0 +1,29 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuseRanks(t *testing.T) {
+	semantic := []SearchResult{{ID: "a", Score: 0.9}, {ID: "b", Score: 0.8}, {ID: "c", Score: 0.7}}
+	keyword := []SearchResult{{ID: "c", Score: 12}, {ID: "d", Score: 5}}
+
+	fused := FuseRanks([][]SearchResult{semantic, keyword}, 10)
+
+	var ids []string
+	for _, result := range fused {
+		ids = append(ids, result.ID)
+	}
+	// c is found by both searches; b and d tie, each second in one list, and
+	// keep the order they were seen in
+	assert.Equal(t, []string{"c", "a", "b", "d"}, ids)
+	// Relative to a result first in both lists
+	assert.InDelta(t, (1.0/63+1.0/61)/(2.0/61), fused[0].Score, 1e-12)
+	assert.InDelta(t, 0.5, fused[1].Score, 1e-12)
+	assert.InDelta(t, 1.0, FuseRanks([][]SearchResult{semantic, nil}, 10)[0].Score, 1e-12)
+
+	assert.Len(t, FuseRanks([][]SearchResult{semantic, keyword}, 2), 2)
+	assert.Empty(t, FuseRanks([][]SearchResult{nil, {}}, 10))
+}
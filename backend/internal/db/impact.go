@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// GetImpact reports the functions, files and packages of a repository that
+// changing the given functions or files may affect, through callers and
+// importers, with the test files among them. maxDepth bounds how many calls
+// away callers are followed; 0 follows them all.
+func (r *GraphReader) GetImpact(ctx context.Context, repoID string, nodeIDs, files []string, maxDepth int) (*analysis.ImpactReport, error) {
+	layers, err := r.GetFileLayers(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = WithRepository(ctx, repoID)
+	type callGraph struct {
+		functions []callGraphFunction
+		calls     []models.CallRelation
+	}
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		functions, calls, err := readCallGraph(ctx, tx, repoID)
+		return callGraph{functions, calls}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	graph := result.(callGraph)
+	functions := make([]analysis.CodeFunction, len(graph.functions))
+	for i, fn := range graph.functions {
+		functions[i] = analysis.CodeFunction{
+			ID:        fn.ID,
+			Name:      fn.Name,
+			Type:      fn.Type,
+			FilePath:  fn.FilePath,
+			StartLine: fn.StartLine,
+		}
+	}
+	return analysis.FindImpact(layers, functions, graph.calls, nodeIDs, files, maxDepth), nil
+}
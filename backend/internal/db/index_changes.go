@@ -0,0 +1,220 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// indexedFile is a file as the graph holds it, with the keys and IDs of the
+// entities it declares
+type indexedFile struct {
+	ID       string
+	Hash     string
+	Entities map[string][]string // entityKey to IDs
+}
+
+// entityKey identifies an entity within its file across indexes of the
+// same content
+func entityKey(label, className, name string, startLine int) string {
+	return label + "\x00" + className + "\x00" + name + "\x00" + strconv.Itoa(startLine)
+}
+
+// WriteIndexChanges writes an index result over the graph of the index it
+// follows, given the paths of the files changed since. Files that did not
+// change keep their nodes, along with their embeddings, descriptions and
+// collected stats; the others are replaced and deleted files dropped. The
+// relationships between entities are then written again, as changed files
+// affect how the calls of unchanged ones resolve.
+func (w *GraphWriter) WriteIndexChanges(ctx context.Context, result *models.IndexResult, changed []string) error {
+	ctx = WithRepository(ctx, result.RepoID)
+
+	indexed, err := w.readIndexedFiles(ctx, result.RepoID)
+	if err != nil {
+		return fmt.Errorf("failed to read indexed files: %w", err)
+	}
+	stale := reuseIndexedEntities(result, indexed, changed)
+
+	_, err = w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		return nil, runEach(ctx, tx, changedFileQueries, map[string]any{"id": result.RepoID, "paths": stale})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to drop %d changed files: %w", len(stale), err)
+	}
+
+	if err := w.WriteDirectories(ctx, result.RepoID, result.Files); err != nil {
+		return fmt.Errorf("failed to write directories of %d files: %w", len(result.Files), err)
+	}
+
+	rewrite := make(map[string]bool, len(stale))
+	for _, path := range stale {
+		rewrite[path] = true
+	}
+	for _, file := range result.Files {
+		if !rewrite[file.Path] {
+			continue
+		}
+		if err := w.WriteFile(ctx, file); err != nil {
+			return fmt.Errorf("failed to write file %s: %w", file.Path, err)
+		}
+	}
+	for i := range result.Entities {
+		entity := &result.Entities[i]
+		if !rewrite[entity.FilePath] {
+			continue
+		}
+		if err := w.WriteEntity(ctx, result.RepoID, entity); err != nil {
+			return fmt.Errorf("failed to write %s %s at %s:%d: %w",
+				entity.Type, entity.Name, entity.FilePath, entity.StartLine, err)
+		}
+	}
+
+	return w.writeRelationships(ctx, result)
+}
+
+// reuseIndexedEntities gives the files and entities of an index result
+// that the graph already holds the IDs of their nodes, and returns the
+// paths of the files to write again, or to drop when they were deleted. A
+// file is written again when it changed, when the graph holds another
+// version of it or none, or when its entities no longer match the graph's.
+func reuseIndexedEntities(result *models.IndexResult, indexed map[string]indexedFile, changed []string) []string {
+	stale := make(map[string]bool)
+	for _, path := range changed {
+		stale[path] = true
+	}
+
+	byFile := make(map[string][]*models.CodeEntity)
+	for i := range result.Entities {
+		entity := &result.Entities[i]
+		if _, ok := entityLabels[entity.Type]; ok {
+			byFile[entity.FilePath] = append(byFile[entity.FilePath], entity)
+		}
+	}
+
+	parsed := make(map[string]bool, len(result.Files))
+	for _, file := range result.Files {
+		parsed[file.Path] = true
+		graph, ok := indexed[file.Path]
+		if stale[file.Path] || !ok || graph.Hash != file.Hash || !sameEntities(byFile[file.Path], graph.Entities) {
+			stale[file.Path] = true
+			continue
+		}
+
+		file.ID = graph.ID
+		taken := make(map[string]int)
+		for _, entity := range byFile[file.Path] {
+			key := entityKey(entityLabels[entity.Type], entity.ClassName, entity.Name, entity.StartLine)
+			entity.ID = graph.Entities[key][taken[key]]
+			taken[key]++
+		}
+	}
+	for path := range indexed {
+		if !parsed[path] {
+			stale[path] = true
+		}
+	}
+
+	paths := make([]string, 0, len(stale))
+	for path := range stale {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// sameEntities reports whether parsed entities are those the graph holds
+// for their file
+func sameEntities(entities []*models.CodeEntity, graph map[string][]string) bool {
+	counts := make(map[string]int, len(graph))
+	for _, entity := range entities {
+		counts[entityKey(entityLabels[entity.Type], entity.ClassName, entity.Name, entity.StartLine)]++
+	}
+	if len(counts) != len(graph) {
+		return false
+	}
+	for key, ids := range graph {
+		if counts[key] != len(ids) {
+			return false
+		}
+	}
+	return true
+}
+
+// readIndexedFiles returns the files of a repository in the graph by path
+func (w *GraphWriter) readIndexedFiles(ctx context.Context, repoID string) (map[string]indexedFile, error) {
+	labels := make([]string, 0, len(entityLabels))
+	for _, label := range entityLabels {
+		labels = append(labels, label)
+	}
+
+	result, err := w.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(f:File)
+			OPTIONAL MATCH (f)-[:DECLARES]->(e)
+			WHERE any(label IN labels(e) WHERE label IN $labels)
+			RETURN f.id AS id, f.path AS path, f.hash AS hash,
+			       collect(CASE WHEN e IS NULL THEN null ELSE {
+			           label: [label IN labels(e) WHERE label IN $labels][0],
+			           className: coalesce(e.className, ''),
+			           name: e.name,
+			           startLine: e.startLine,
+			           id: e.id
+			       } END) AS entities
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "labels": labels})
+		if err != nil {
+			return nil, err
+		}
+
+		files := make(map[string]indexedFile)
+		for records.Next(ctx) {
+			rec := records.Record()
+			file := indexedFile{
+				ID:       recordString(rec, "id"),
+				Hash:     recordString(rec, "hash"),
+				Entities: make(map[string][]string),
+			}
+			items, _ := recordValue(rec, "entities").([]any)
+			for _, item := range items {
+				e, _ := item.(map[string]any)
+				label, _ := e["label"].(string)
+				className, _ := e["className"].(string)
+				name, _ := e["name"].(string)
+				startLine, _ := e["startLine"].(int64)
+				id, _ := e["id"].(string)
+				key := entityKey(label, className, name, int(startLine))
+				file.Entities[key] = append(file.Entities[key], id)
+			}
+			files[recordString(rec, "path")] = file
+		}
+		return files, records.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]indexedFile), nil
+}
+
+// changedFileQueries drop the changed and deleted files of a repository
+// with their entities, the directories left empty, and the relationships
+// and call lists of the remaining entities, which are written again
+var changedFileQueries = []string{
+	`MATCH (r:Repository {id: $id})-[:CONTAINS*]->(f:File)
+	WHERE f.path IN $paths
+	OPTIONAL MATCH (f)-[:DECLARES]->(e)
+	OPTIONAL MATCH (e)-[:DOCUMENTED_BY]->(doc:Docstring)
+	DETACH DELETE doc, e, f`,
+	`MATCH (r:Repository {id: $id})-[:CONTAINS*]->(d:Directory)
+	WHERE NOT (d)-[:CONTAINS*]->(:File)
+	DETACH DELETE d`,
+	`MATCH (r:Repository {id: $id})-[:CONTAINS*]->(d:Package)
+	REMOVE d:Package`,
+	`MATCH (r:Repository {id: $id})-[:CONTAINS*]->(:File)-[:DECLARES]->(e)-[rel:CALLS|MEMBER_OF|IMPLEMENTS|READS|WRITES]->()
+	DELETE rel`,
+	`MATCH (r:Repository {id: $id})-[:CONTAINS*]->(:File)-[:DECLARES]->(e:Function|Method)
+	REMOVE e.ambiguousCalls, e.unresolvedCalls, e.externalCalls`,
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReuseIndexedEntities tests that unchanged files keep the IDs the
+// graph holds, and that changed, new, deleted and mismatched files are
+// written again
+func TestReuseIndexedEntities(t *testing.T) {
+	result := &models.IndexResult{
+		Files: []*models.File{
+			{Path: "kept.go", Hash: "k"},
+			{Path: "changed.go", Hash: "c2"},
+			{Path: "added.go", Hash: "a"},
+			{Path: "rehashed.go", Hash: "r2"},
+			{Path: "reparsed.go", Hash: "p"},
+		},
+		Entities: []models.CodeEntity{
+			{Type: models.EntityClass, Name: "Server", FilePath: "kept.go", StartLine: 3},
+			{Type: models.EntityMethod, Name: "Start", ClassName: "Server", FilePath: "kept.go", StartLine: 8},
+			{Type: models.EntityFunction, Name: "Run", FilePath: "changed.go", StartLine: 1},
+			{Type: models.EntityFunction, Name: "New", FilePath: "added.go", StartLine: 1},
+			{Type: models.EntityFunction, Name: "Parse", FilePath: "reparsed.go", StartLine: 5},
+		},
+	}
+	indexed := map[string]indexedFile{
+		"kept.go": {ID: "fk", Hash: "k", Entities: map[string][]string{
+			entityKey("Class", "", "Server", 3):       {"e1"},
+			entityKey("Method", "Server", "Start", 8): {"e2"},
+		}},
+		"changed.go":  {ID: "fc", Hash: "c1", Entities: map[string][]string{entityKey("Function", "", "Run", 1): {"e3"}}},
+		"rehashed.go": {ID: "fr", Hash: "r1", Entities: map[string][]string{}},
+		"reparsed.go": {ID: "fp", Hash: "p", Entities: map[string][]string{entityKey("Function", "", "Parse", 4): {"e4"}}},
+		"deleted.go":  {ID: "fd", Hash: "d", Entities: map[string][]string{}},
+	}
+
+	stale := reuseIndexedEntities(result, indexed, []string{"changed.go", "added.go", "deleted.go"})
+
+	assert.Equal(t, []string{"added.go", "changed.go", "deleted.go", "rehashed.go", "reparsed.go"}, stale)
+	assert.Equal(t, "fk", result.Files[0].ID)
+	assert.Equal(t, "e1", result.Entities[0].ID)
+	assert.Equal(t, "e2", result.Entities[1].ID)
+	for _, entity := range result.Entities[2:] {
+		assert.Empty(t, entity.ID, entity.Name)
+	}
+	for _, file := range result.Files[1:] {
+		assert.Empty(t, file.ID, file.Path)
+	}
+}
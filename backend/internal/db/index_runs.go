@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// CreateIndexRun records the start of an index run on a repository
+func CreateIndexRun(ctx context.Context, client *Neo4jClient, run *models.IndexRun) error {
+	run.ID = uuid.New().String()
+	run.Status = "running"
+	run.StartedAt = time.Now().UTC()
+
+	_, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})
+			CREATE (r)-[:HAS_RUN]->(run:IndexRun {
+				id: $id,
+				repoId: $repoId,
+				status: $status,
+				startedAt: $startedAt
+			})
+		`
+		_, err := tx.Run(ctx, query, map[string]any{
+			"id":        run.ID,
+			"repoId":    run.RepoID,
+			"status":    run.Status,
+			"startedAt": run.StartedAt,
+		})
+		return nil, err
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to create index run: %w", err)
+	}
+	return nil
+}
+
+// FinishIndexRun records the outcome of an index run. Successful runs also
+// update the repository's indexed commit.
+func FinishIndexRun(ctx context.Context, client *Neo4jClient, run *models.IndexRun) error {
+	run.FinishedAt = time.Now().UTC()
+
+	_, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:HAS_RUN]->(run:IndexRun {id: $id})
+			SET run.status = $status,
+			    run.commit = $commit,
+			    run.finishedAt = $finishedAt,
+			    run.filesCount = $filesCount,
+			    run.entitiesCount = $entitiesCount,
+			    run.error = $error,
+			    run.artifact = $artifact
+			FOREACH (_ IN CASE WHEN $status = 'ready' THEN [1] ELSE [] END |
+				SET r.commit = $commit
+			)
+		`
+		_, err := tx.Run(ctx, query, map[string]any{
+			"id":            run.ID,
+			"repoId":        run.RepoID,
+			"status":        run.Status,
+			"commit":        run.Commit,
+			"finishedAt":    run.FinishedAt,
+			"filesCount":    run.FilesCount,
+			"entitiesCount": run.EntitiesCount,
+			"error":         run.Error,
+			"artifact":      run.Artifact,
+		})
+		return nil, err
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to finish index run: %w", err)
+	}
+	return nil
+}
+
+// ListIndexRuns returns a repository's index runs, newest first
+func ListIndexRuns(ctx context.Context, client *Neo4jClient, repoID string, limit int) ([]models.IndexRun, error) {
+	result, err := client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:HAS_RUN]->(run:IndexRun)
+			RETURN run
+			ORDER BY run.startedAt DESC
+			LIMIT $limit
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+
+		runs := []models.IndexRun{}
+		for records.Next(ctx) {
+			runRaw, _ := records.Record().Get("run")
+			runs = append(runs, nodeToIndexRun(runRaw.(neo4j.Node)))
+		}
+		return runs, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]models.IndexRun), nil
+}
+
+func nodeToIndexRun(node neo4j.Node) models.IndexRun {
+	props := node.GetProperties()
+	run := models.IndexRun{
+		ID:     stringProp(props, "id"),
+		RepoID: stringProp(props, "repoId"),
+		Commit: stringProp(props, "commit"),
+		Status: stringProp(props, "status"),
+		Error:  stringProp(props, "error"),
+	}
+	if t, ok := props["startedAt"].(time.Time); ok {
+		run.StartedAt = t
+	}
+	if t, ok := props["finishedAt"].(time.Time); ok {
+		run.FinishedAt = t
+	}
+	if n, ok := props["filesCount"].(int64); ok {
+		run.FilesCount = int(n)
+	}
+	if n, ok := props["entitiesCount"].(int64); ok {
+		run.EntitiesCount = int(n)
+	}
+	if b, ok := props["artifact"].(bool); ok {
+		run.Artifact = b
+	}
+	return run
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNodeToIndexRun tests conversion of stored IndexRun properties
+func TestNodeToIndexRun(t *testing.T) {
+	started := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	finished := started.Add(time.Minute)
+
+	run := nodeToIndexRun(neo4j.Node{Props: map[string]any{
+		"id":            "run-1",
+		"repoId":        "repo-1",
+		"commit":        "abc123",
+		"status":        "ready",
+		"startedAt":     started,
+		"finishedAt":    finished,
+		"filesCount":    int64(12),
+		"entitiesCount": int64(80),
+		"artifact":      true,
+	}})
+
+	assert.Equal(t, "run-1", run.ID)
+	assert.Equal(t, "repo-1", run.RepoID)
+	assert.Equal(t, "abc123", run.Commit)
+	assert.Equal(t, "ready", run.Status)
+	assert.Equal(t, started, run.StartedAt)
+	assert.Equal(t, finished, run.FinishedAt)
+	assert.Equal(t, 12, run.FilesCount)
+	assert.Equal(t, 80, run.EntitiesCount)
+	assert.True(t, run.Artifact)
+	assert.Empty(t, run.Error)
+}
+
+// TestNodeToIndexRunRunning tests a run that has not finished yet
+func TestNodeToIndexRunRunning(t *testing.T) {
+	run := nodeToIndexRun(neo4j.Node{Props: map[string]any{
+		"id":     "run-2",
+		"status": "running",
+	}})
+
+	assert.Equal(t, "running", run.Status)
+	assert.True(t, run.FinishedAt.IsZero())
+	assert.Empty(t, run.Commit)
+}
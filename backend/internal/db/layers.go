@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// GetFileLayers returns the layer and imports of every file in a repository
+func (r *GraphReader) GetFileLayers(ctx context.Context, repoID string) ([]analysis.FileLayer, error) {
+	ctx = WithRepository(ctx, repoID)
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(f:File)
+			RETURN f.path AS path, f.language AS language,
+			       coalesce(f.layer, '') AS layer, coalesce(f.imports, []) AS imports
+			ORDER BY f.path
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+
+		files := []analysis.FileLayer{}
+		for records.Next(ctx) {
+			rec := records.Record()
+			path, _ := rec.Get("path")
+			language, _ := rec.Get("language")
+			layer, _ := rec.Get("layer")
+			importsRaw, _ := rec.Get("imports")
+
+			file := analysis.FileLayer{
+				Path:  path.(string),
+				Layer: layer.(string),
+			}
+			if language != nil {
+				file.Language = language.(string)
+			}
+			for _, imp := range importsRaw.([]any) {
+				if s, ok := imp.(string); ok {
+					file.Imports = append(file.Imports, s)
+				}
+			}
+			files = append(files, file)
+		}
+
+		return files, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]analysis.FileLayer), nil
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// orphanBatchSize bounds how many orphans are deleted per transaction
+const orphanBatchSize = 1000
+
+// orphanRules describes, per label, how a node hangs off its Repository.
+// Nodes of the label without such a path are orphans. Parents come before
+// children so a deleted orphan's descendants are collected in the same pass;
+// a dry run therefore only counts the top-most orphans.
+var orphanRules = []struct {
+	label    string
+	ancestry string
+}{
+	{"Directory", "(:Repository)-[:CONTAINS*]->(n)"},
+	{"File", "(:Repository)-[:CONTAINS*]->(n)"},
+	{"Function", "(:Repository)-[:CONTAINS*]->(:File)-[:DECLARES]->(n)"},
+	{"Method", "(:Repository)-[:CONTAINS*]->(:File)-[:DECLARES]->(n)"},
+	{"Class", "(:Repository)-[:CONTAINS*]->(:File)-[:DECLARES]->(n)"},
+	{"Variable", "(:Repository)-[:CONTAINS*]->(:File)-[:DECLARES]->(n)"},
+	{"Docstring", "(:Repository)-[:CONTAINS*]->(:File)-[:DECLARES]->()-[:DOCUMENTED_BY]->(n)"},
+	{"WikiPage", "(:Repository)-[:HAS_WIKI]->(n)"},
+	{"IndexRun", "(:Repository)-[:HAS_RUN]->(n)"},
+	{"Finding", "(:Repository)-[:HAS_FINDING]->(n)"},
+	{"Commit", "(:Repository)-[:HAS_COMMIT]->(n)"},
+	{"Author", "(:Repository)-[:HAS_AUTHOR]->(n)"},
+	{"Report", "(:Repository)-[:HAS_REPORT]->(n)"},
+	{"ReportRun", "(:Repository)-[:HAS_REPORT]->(:Report)-[:HAS_RESULT]->(n)"},
+	{"Conversation", "(:Repository)-[:HAS_CONVERSATION]->(n)"},
+	{"ConversationMessage", "(:Repository)-[:HAS_CONVERSATION]->(:Conversation)-[:HAS_MESSAGE]->(n)"},
+}
+
+// OrphanReport lists orphaned nodes found (and deleted unless DryRun) by label
+type OrphanReport struct {
+	DryRun bool             `json:"dryRun"`
+	Nodes  map[string]int64 `json:"nodes"`
+	Total  int64            `json:"total"`
+}
+
+// orphanQuery builds the query counting or deleting one batch of orphans
+func orphanQuery(dialect Dialect, label, ancestry string, dryRun bool) string {
+	match := `MATCH (n:` + label + `) WHERE NOT ` + dialect.patternExists(ancestry)
+	if dryRun {
+		return match + ` RETURN count(n) AS removed`
+	}
+	return match + ` WITH n LIMIT $batch DETACH DELETE n RETURN count(*) AS removed`
+}
+
+// CollectOrphans finds nodes with no Repository ancestor, such as entities
+// left behind when relationships were created in unexpected ways, and deletes
+// them unless dryRun is set
+func CollectOrphans(ctx context.Context, client *Neo4jClient, dryRun bool) (*OrphanReport, error) {
+	report := &OrphanReport{DryRun: dryRun, Nodes: make(map[string]int64)}
+
+	for _, rule := range orphanRules {
+		// Labels and patterns come from the fixed orphanRules table
+		query := orphanQuery(client.dialect, rule.label, rule.ancestry, dryRun)
+
+		for {
+			run := client.ExecuteWrite
+			if dryRun {
+				run = client.ExecuteRead
+			}
+			result, err := run(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+				records, err := tx.Run(ctx, query, map[string]any{"batch": orphanBatchSize})
+				if err != nil {
+					return nil, err
+				}
+				rec, err := records.Single(ctx)
+				if err != nil {
+					return nil, err
+				}
+				removed, _ := rec.Get("removed")
+				return removed.(int64), nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to collect orphan %s nodes: %w", rule.label, err)
+			}
+
+			removed := result.(int64)
+			report.Nodes[rule.label] += removed
+			report.Total += removed
+			if dryRun || removed < orphanBatchSize {
+				break
+			}
+		}
+	}
+
+	return report, nil
+}
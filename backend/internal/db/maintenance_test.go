@@ -0,0 +1,37 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOrphanQuery tests the count and batched delete forms of an orphan query
+// and the Memgraph existence predicate
+func TestOrphanQuery(t *testing.T) {
+	ancestry := "(:Repository)-[:HAS_WIKI]->(n)"
+
+	assert.Equal(t,
+		"MATCH (n:WikiPage) WHERE NOT EXISTS { MATCH (:Repository)-[:HAS_WIKI]->(n) } RETURN count(n) AS removed",
+		orphanQuery(DialectNeo4j, "WikiPage", ancestry, true))
+	assert.Equal(t,
+		"MATCH (n:WikiPage) WHERE NOT EXISTS { MATCH (:Repository)-[:HAS_WIKI]->(n) } WITH n LIMIT $batch DETACH DELETE n RETURN count(*) AS removed",
+		orphanQuery(DialectNeo4j, "WikiPage", ancestry, false))
+	assert.Equal(t,
+		"MATCH (n:WikiPage) WHERE NOT exists((:Repository)-[:HAS_WIKI]->(n)) RETURN count(n) AS removed",
+		orphanQuery(DialectMemgraph, "WikiPage", ancestry, true))
+}
+
+// TestOrphanRulesOrder tests that containers are collected before their contents
+func TestOrphanRulesOrder(t *testing.T) {
+	position := make(map[string]int)
+	for i, rule := range orphanRules {
+		position[rule.label] = i
+	}
+
+	assert.Less(t, position["Directory"], position["File"])
+	for _, label := range []string{"Function", "Method", "Class"} {
+		assert.Less(t, position["File"], position[label], label)
+	}
+	assert.Less(t, position["Conversation"], position["ConversationMessage"])
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// neighborhoodRels are the relationships a neighborhood expands along
+const neighborhoodRels = "CONTAINS|DECLARES|CALLS|MEMBER_OF|READS|WRITES"
+
+// MaxNeighborhoodDepth bounds how many hops a neighborhood reaches
+const MaxNeighborhoodDepth = 3
+
+// GetNeighborhood returns the nodes within depth hops of a node, closest
+// first and at most limit of them, with every edge between them. Each node
+// carries its distance from the center in its props. Returns nil if the node
+// is not in the repository.
+func (r *GraphReader) GetNeighborhood(ctx context.Context, repoID, nodeID string, depth, limit int) (*GraphData, error) {
+	ctx = WithRepository(ctx, repoID)
+	depth = min(max(depth, 1), MaxNeighborhoodDepth)
+
+	// Depth is a bounded int, so it can be part of the pattern
+	query := fmt.Sprintf(`
+		MATCH (r:Repository {id: $repoId})-[:CONTAINS|DECLARES*]->(center {id: $nodeId})
+		MATCH p = (center)-[:%[1]s*0..%[2]d]-(n)
+		WHERE NOT n:Repository
+		WITH n, min(length(p)) AS distance
+		ORDER BY distance, n.id
+		WITH collect({node: n, distance: distance}) AS found
+		WITH found[0..$limit] AS rows, size(found) > $limit AS truncated, size(found) AS total
+		WITH rows, truncated, total, [row IN rows | row.node] AS nodes
+		UNWIND rows AS row
+		WITH row, nodes, truncated, total, row.node AS a
+		OPTIONAL MATCH (a)-[e:%[1]s]->(b)
+		WHERE b IN nodes
+		RETURN a AS node, labels(a) AS labels, row.distance AS distance,
+		       e AS rel, type(e) AS relType, b.id AS target, truncated, total
+	`, neighborhoodRels, depth)
+
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, query, map[string]any{
+			"repoId": repoID,
+			"nodeId": nodeID,
+			"limit":  limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var graph *GraphData
+		seen := make(map[string]bool)
+		for records.Next(ctx) {
+			rec := records.Record()
+			if graph == nil {
+				graph = &GraphData{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+				if v, _ := rec.Get("truncated"); v == true {
+					graph.Truncated = true
+				}
+				if v, _ := rec.Get("total"); v != nil {
+					graph.TotalNodes = int(v.(int64))
+				}
+			}
+
+			nodeRaw, _ := rec.Get("node")
+			node := nodeRaw.(neo4j.Node)
+			props := node.GetProperties()
+			id, _ := props["id"].(string)
+			if !seen[id] {
+				seen[id] = true
+				distance, _ := rec.Get("distance")
+				graph.Nodes = append(graph.Nodes, neighborhoodNode(id, recordStrings(rec, "labels"), props, distance))
+			}
+
+			if target := recordString(rec, "target"); target != "" {
+				relType := recordString(rec, "relType")
+				edge := GraphEdge{
+					ID:     fmt.Sprintf("%s->%s", id, target),
+					Source: id,
+					Target: target,
+					Type:   relType,
+				}
+				if relType == "CALLS" {
+					relRaw, _ := rec.Get("rel")
+					relProps := relRaw.(neo4j.Relationship).GetProperties()
+					edge.Props = map[string]any{
+						"line":  relProps["line"],
+						"count": relProps["count"],
+					}
+				}
+				graph.Edges = append(graph.Edges, edge)
+			}
+		}
+		return graph, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(*GraphData), nil
+}
+
+// neighborhoodNode converts a node of any kind for a neighborhood graph
+func neighborhoodNode(id string, labels []string, props map[string]any, distance any) GraphNode {
+	var nodeType string
+	for _, label := range labels {
+		switch label {
+		case "File", "Class", "Function", "Method", "Variable", "Directory", "Package":
+			// Package directories also carry the Directory label
+			if nodeType == "" || label == "Package" {
+				nodeType = label
+			}
+		}
+	}
+
+	node := GraphNode{
+		ID:    id,
+		Type:  nodeType,
+		Props: map[string]any{"distance": distance},
+	}
+	if name, ok := props["name"].(string); ok && nodeType != "File" {
+		node.Label = name
+	} else if path, ok := props["path"].(string); ok {
+		node.Label = path
+	}
+
+	switch nodeType {
+	case "File":
+		node.Props["language"] = props["language"]
+		node.Props = withCoverage(node.Props, props)
+	case "Function", "Method", "Class", "Variable":
+		node.Props["signature"] = props["signature"]
+		node.Props["filePath"] = props["filePath"]
+		node.Props = withCoverage(withRuntime(node.Props, props), props)
+	}
+	return node
+}
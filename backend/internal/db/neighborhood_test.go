@@ -0,0 +1,35 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNeighborhoodNode tests converting nodes of each kind for a neighborhood
+func TestNeighborhoodNode(t *testing.T) {
+	fn := neighborhoodNode("fn-1", []string{"Function"}, map[string]any{
+		"name":      "Parse",
+		"signature": "func Parse() error",
+		"filePath":  "parser.go",
+	}, int64(1))
+	assert.Equal(t, "Parse", fn.Label)
+	assert.Equal(t, "Function", fn.Type)
+	assert.Equal(t, int64(1), fn.Props["distance"])
+	assert.Equal(t, "parser.go", fn.Props["filePath"])
+
+	file := neighborhoodNode("file-1", []string{"File"}, map[string]any{
+		"name":     "parser.go",
+		"path":     "internal/parser.go",
+		"language": "go",
+	}, int64(0))
+	assert.Equal(t, "internal/parser.go", file.Label)
+	assert.Equal(t, "go", file.Props["language"])
+
+	pkg := neighborhoodNode("dir-1", []string{"Directory", "Package"}, map[string]any{
+		"name": "internal",
+		"path": "internal",
+	}, int64(2))
+	assert.Equal(t, "Package", pkg.Type)
+	assert.Equal(t, "internal", pkg.Label)
+}
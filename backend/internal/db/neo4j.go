@@ -2,22 +2,70 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
+// defaultDatabase is the database used when none is configured
+const defaultDatabase = "neo4j"
+
+// defaultWriteAttempts is how many times a write transaction is run when
+// transient errors outlast the driver's own retries
+const defaultWriteAttempts = 3
+
+// writeRetryBackoff is the pause before the second attempt, doubled after each
+var writeRetryBackoff = time.Second
+
 type Neo4jConfig struct {
 	URI      string
 	Username string
 	Password string
+
+	// WriteAttempts bounds how often a write transaction is run; 0 uses
+	// defaultWriteAttempts
+	WriteAttempts int
+
+	// Database holds repositories, index runs and wikis, and the code graph
+	// unless DatabasePerRepository is set; empty uses defaultDatabase
+	Database string
+
+	// DatabasePerRepository places each repository's code graph in its own
+	// database, which requires Neo4j Enterprise
+	DatabasePerRepository bool
+
+	// Dialect is the Cypher flavor of the database; empty means Neo4j. On
+	// Memgraph an empty Database uses the server's default database.
+	Dialect Dialect
 }
 
 type Neo4jClient struct {
-	driver neo4j.DriverWithContext
+	driver        neo4j.DriverWithContext
+	writeAttempts int
+	database      string
+	perRepository bool
+	dialect       Dialect
+
+	// Vector spaces searched and being built, see LoadVectorSpaces
+	spaceMu  sync.RWMutex
+	space    VectorSpace
+	building *VectorSpace
 }
 
 func NewNeo4jClient(ctx context.Context, cfg Neo4jConfig) (*Neo4jClient, error) {
+	dialect, err := ParseDialect(string(cfg.Dialect))
+	if err != nil {
+		return nil, err
+	}
+	if dialect == DialectMemgraph && cfg.DatabasePerRepository {
+		return nil, errors.New("per-repository databases are only supported on Neo4j")
+	}
+
 	driver, err := neo4j.NewDriverWithContext(
 		cfg.URI,
 		neo4j.BasicAuth(cfg.Username, cfg.Password, ""),
@@ -31,7 +79,22 @@ func NewNeo4jClient(ctx context.Context, cfg Neo4jConfig) (*Neo4jClient, error)
 		return nil, fmt.Errorf("failed to connect to neo4j: %w", err)
 	}
 
-	return &Neo4jClient{driver: driver}, nil
+	attempts := cfg.WriteAttempts
+	if attempts <= 0 {
+		attempts = defaultWriteAttempts
+	}
+	database := cfg.Database
+	if database == "" && dialect == DialectNeo4j {
+		database = defaultDatabase
+	}
+	return &Neo4jClient{
+		driver:        driver,
+		writeAttempts: attempts,
+		database:      database,
+		perRepository: cfg.DatabasePerRepository,
+		dialect:       dialect,
+		space:         legacyVectorSpace("", defaultVectorDimension),
+	}, nil
 }
 
 func (c *Neo4jClient) Close() error {
@@ -42,18 +105,80 @@ func (c *Neo4jClient) Ping(ctx context.Context) error {
 	return c.driver.VerifyConnectivity(ctx)
 }
 
+// Session opens a session on the database ctx is scoped to, see
+// WithRepository
 func (c *Neo4jClient) Session(ctx context.Context) neo4j.SessionWithContext {
 	return c.driver.NewSession(ctx, neo4j.SessionConfig{
-		DatabaseName: "neo4j",
+		DatabaseName: c.databaseFor(ctx),
 	})
 }
 
-// ExecuteWrite runs a write transaction
+// ExecuteWrite runs a write transaction. The driver retries transient errors
+// such as deadlocks and leader switches for a while; if they persist the
+// transaction is run again from scratch with backoff, up to the client's
+// write attempts.
 func (c *Neo4jClient) ExecuteWrite(ctx context.Context, work func(tx neo4j.ManagedTransaction) (any, error)) (any, error) {
-	session := c.Session(ctx)
-	defer session.Close(ctx)
+	return retryTransient(ctx, c.writeAttempts, func() (any, error) {
+		session := c.Session(ctx)
+		defer session.Close(ctx)
+
+		return session.ExecuteWrite(ctx, work)
+	})
+}
+
+// retryTransient runs fn up to attempts times while it fails with a
+// transient error, backing off between attempts
+func retryTransient(ctx context.Context, attempts int, fn func() (any, error)) (any, error) {
+	backoff := writeRetryBackoff
+	for attempt := 1; ; attempt++ {
+		result, err := fn()
+		if err == nil || !isTransient(err) {
+			return result, err
+		}
+		if attempt >= attempts {
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		log.Printf("Transient Neo4j error on attempt %d, retrying in %s: %v", attempt, backoff, err)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
 
-	return session.ExecuteWrite(ctx, work)
+// isTransient reports whether an error is worth retrying, as classified by
+// the driver, including when the driver already gave up retrying it
+func isTransient(err error) bool {
+	var limit *neo4j.TransactionExecutionLimit
+	if errors.As(err, &limit) && len(limit.Errors) > 0 {
+		err = limit.Errors[len(limit.Errors)-1]
+	}
+	for ; err != nil; err = errors.Unwrap(err) {
+		if neo4j.IsRetryable(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUnavailable reports whether an error is the database being unreachable
+// or too busy, rather than a failing query
+func IsUnavailable(err error) bool {
+	return neo4j.IsConnectivityError(err) || isTransient(err)
+}
+
+// IsConflict reports whether an error is a write violating a constraint,
+// such as a uniqueness one
+func IsConflict(err error) bool {
+	var limit *neo4j.TransactionExecutionLimit
+	if errors.As(err, &limit) && len(limit.Errors) > 0 {
+		err = limit.Errors[len(limit.Errors)-1]
+	}
+	var neoErr *neo4j.Neo4jError
+	return errors.As(err, &neoErr) && strings.HasSuffix(neoErr.Code, ".ConstraintValidationFailed")
 }
 
 // ExecuteRead runs a read transaction
@@ -2,7 +2,14 @@ package db
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestNewNeo4jClient(t *testing.T) {
@@ -30,3 +37,112 @@ func TestNewNeo4jClient(t *testing.T) {
 		t.Fatalf("Failed to ping: %v", err)
 	}
 }
+
+// TestRetryTransient tests that transient errors are retried and others are not
+func TestRetryTransient(t *testing.T) {
+	defer func(backoff time.Duration) { writeRetryBackoff = backoff }(writeRetryBackoff)
+	writeRetryBackoff = time.Millisecond
+
+	deadlock := &neo4j.Neo4jError{Code: "Neo.TransientError.Transaction.DeadlockDetected"}
+	syntax := &neo4j.Neo4jError{Code: "Neo.ClientError.Statement.SyntaxError"}
+
+	calls := 0
+	result, err := retryTransient(context.Background(), 3, func() (any, error) {
+		calls++
+		if calls < 3 {
+			return nil, deadlock
+		}
+		return "ok", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", result)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	_, err = retryTransient(context.Background(), 2, func() (any, error) {
+		calls++
+		return nil, deadlock
+	})
+	assert.ErrorIs(t, err, deadlock)
+	assert.Contains(t, err.Error(), "giving up after 2 attempts")
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	_, err = retryTransient(context.Background(), 3, func() (any, error) {
+		calls++
+		return nil, syntax
+	})
+	assert.ErrorIs(t, err, syntax)
+	assert.Equal(t, 1, calls)
+}
+
+// TestIsTransient tests error classification, including wrapped errors and
+// errors the driver already gave up retrying
+func TestIsTransient(t *testing.T) {
+	deadlock := &neo4j.Neo4jError{Code: "Neo.TransientError.Transaction.DeadlockDetected"}
+	notLeader := &neo4j.Neo4jError{Code: "Neo.ClientError.Cluster.NotALeader"}
+
+	assert.True(t, isTransient(deadlock))
+	assert.True(t, isTransient(notLeader))
+	assert.True(t, isTransient(fmt.Errorf("write entity: %w", deadlock)))
+	assert.True(t, isTransient(&neo4j.TransactionExecutionLimit{Errors: []error{deadlock}}))
+	assert.True(t, isTransient(&neo4j.Neo4jError{Code: "Memgraph.TransientError.MemgraphError.MemgraphError"}))
+	assert.False(t, isTransient(&neo4j.Neo4jError{Code: "Neo.ClientError.Schema.ConstraintValidationFailed"}))
+	assert.False(t, isTransient(errors.New("boom")))
+	assert.False(t, isTransient(context.Canceled))
+}
+
+// TestRepositoryDatabase tests that repository IDs map to valid database names
+func TestRepositoryDatabase(t *testing.T) {
+	assert.Equal(t, "repo-3f2a9c1e-7b4d-4e8a-9c0f-1a2b3c4d5e6f", RepositoryDatabase("3F2A9C1E-7B4D-4E8A-9C0F-1A2B3C4D5E6F"))
+	assert.Equal(t, "repo-a-b", RepositoryDatabase("a_b"))
+	assert.Len(t, RepositoryDatabase(strings.Repeat("x", 100)), 63)
+}
+
+// TestDatabaseFor tests which database sessions use for scoped contexts
+func TestDatabaseFor(t *testing.T) {
+	ctx := context.Background()
+	repoCtx := WithRepository(ctx, "repo-1")
+
+	shared := &Neo4jClient{database: "neo4j"}
+	assert.Equal(t, "neo4j", shared.databaseFor(ctx))
+	assert.Equal(t, "neo4j", shared.databaseFor(repoCtx))
+
+	perRepo := &Neo4jClient{database: "catalog", perRepository: true}
+	assert.Equal(t, "catalog", perRepo.databaseFor(ctx))
+	assert.Equal(t, "repo-repo-1", perRepo.databaseFor(repoCtx))
+	assert.Equal(t, "catalog", perRepo.databaseFor(catalog(repoCtx)))
+}
+
+// TestParseDialect tests dialect validation
+func TestParseDialect(t *testing.T) {
+	for input, want := range map[string]Dialect{"": DialectNeo4j, "neo4j": DialectNeo4j, " Memgraph ": DialectMemgraph} {
+		got, err := ParseDialect(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	_, err := ParseDialect("falkordb")
+	assert.Error(t, err)
+}
+
+// TestDialectVectorQueries tests that each dialect uses its own vector index
+// syntax and yields a score
+func TestDialectVectorQueries(t *testing.T) {
+	space := legacyVectorSpace("", defaultVectorDimension)
+	assert.Contains(t, DialectNeo4j.vectorIndexQuery(space, "Function"), "IF NOT EXISTS")
+	assert.Contains(t, DialectNeo4j.vectorQueryCall(space, "Function"), "db.index.vector.queryNodes('function_embeddings'")
+	assert.Contains(t, DialectNeo4j.vectorQueryCall(space, "Function"), "2 * indexScore - 1 AS score")
+	assert.Contains(t, DialectMemgraph.vectorIndexQuery(space, "Function"), "WITH CONFIG")
+	assert.Contains(t, DialectMemgraph.vectorQueryCall(space, "Function"), "similarity AS score")
+	assert.Contains(t, DialectNeo4j.dropVectorIndexQuery(space, "Function"), "IF EXISTS")
+
+	// Methods and classes have indexes of their own
+	assert.Contains(t, DialectNeo4j.vectorIndexQuery(space, "Method"), "function_embeddings_method IF NOT EXISTS")
+	assert.Contains(t, DialectNeo4j.vectorIndexQuery(space, "Method"), "FOR (n:Method) ON (n.embedding)")
+	assert.Contains(t, DialectMemgraph.vectorIndexQuery(space, "Class"), "CREATE VECTOR INDEX function_embeddings_class ON :Class(embedding)")
+	assert.Contains(t, DialectNeo4j.vectorQueryCall(space, "Class"), "queryNodes('function_embeddings_class'")
+
+	assert.True(t, isExistingIndex(errors.New("Index function_embeddings already exists.")))
+	assert.False(t, isExistingIndex(nil))
+}
@@ -0,0 +1,333 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+var (
+	// ErrOrganizationExists is returned when an organization's ID is taken
+	ErrOrganizationExists = errors.New("an organization with this ID already exists")
+	// ErrOrganizationNotEmpty is returned when deleting an organization
+	// that still has projects
+	ErrOrganizationNotEmpty = errors.New("the organization still has projects")
+	// ErrProjectExists is returned when an organization already has a
+	// project of the given name
+	ErrProjectExists = errors.New("a project with this name already exists")
+	// ErrProjectNotEmpty is returned when deleting a project that still has
+	// repositories
+	ErrProjectNotEmpty = errors.New("the project still has repositories")
+)
+
+// organizationID is the form of organization IDs: lowercase slugs
+var organizationID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// ValidOrganizationID reports whether id is a slug of lowercase letters,
+// digits and dashes, at most 63 characters long
+func ValidOrganizationID(id string) bool {
+	return organizationID.MatchString(id)
+}
+
+// CreateOrganization stores an Organization node under its chosen ID
+func CreateOrganization(ctx context.Context, client *Neo4jClient, org *models.Organization) error {
+	org.CreatedAt = time.Now().UTC()
+
+	result, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			OPTIONAL MATCH (existing:Organization {id: $id})
+			WITH existing WHERE existing IS NULL
+			CREATE (o:Organization {id: $id, name: $name, createdAt: $createdAt})
+			RETURN o.id AS id
+		`
+		records, err := tx.Run(ctx, query, map[string]any{
+			"id":        org.ID,
+			"name":      org.Name,
+			"createdAt": org.CreatedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return records.Next(ctx), records.Err()
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to create organization: %w", err)
+	}
+	if created, _ := result.(bool); !created {
+		return ErrOrganizationExists
+	}
+	return nil
+}
+
+// ListOrganizations returns the organizations, by ID
+func ListOrganizations(ctx context.Context, client *Neo4jClient) ([]models.Organization, error) {
+	result, err := client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, `MATCH (o:Organization) RETURN o ORDER BY o.id`, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		orgs := []models.Organization{}
+		for records.Next(ctx) {
+			raw, _ := records.Record().Get("o")
+			orgs = append(orgs, nodeToOrganization(raw.(neo4j.Node)))
+		}
+		return orgs, records.Err()
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	return result.([]models.Organization), nil
+}
+
+// GetOrganization returns an organization, or nil if none has the ID
+func GetOrganization(ctx context.Context, client *Neo4jClient, id string) (*models.Organization, error) {
+	result, err := client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, `MATCH (o:Organization {id: $id}) RETURN o`, map[string]any{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		if !records.Next(ctx) {
+			return nil, records.Err()
+		}
+		raw, _ := records.Record().Get("o")
+		org := nodeToOrganization(raw.(neo4j.Node))
+		return &org, nil
+	})
+
+	if err != nil || result == nil {
+		return nil, err
+	}
+	return result.(*models.Organization), nil
+}
+
+// DeleteOrganization deletes an organization without projects along with
+// the API keys scoped to it, returning whether it existed and the hashes
+// of the deleted keys
+func DeleteOrganization(ctx context.Context, client *Neo4jClient, id string) (bool, []string, error) {
+	type deletion struct {
+		found  bool
+		hashes []string
+	}
+	result, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (o:Organization {id: $id})
+			OPTIONAL MATCH (o)-[:HAS_PROJECT]->(p:Project)
+			RETURN count(p) AS projects
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		if !records.Next(ctx) {
+			return deletion{}, records.Err()
+		}
+		if projects, _ := records.Record().Get("projects"); projects.(int64) > 0 {
+			return nil, ErrOrganizationNotEmpty
+		}
+
+		query = `
+			MATCH (o:Organization {id: $id})
+			OPTIONAL MATCH (k:ApiKey {orgId: $id})
+			WITH o, collect(k) AS keys, collect(k.hash) AS hashes
+			FOREACH (k IN keys | DELETE k)
+			DELETE o
+			RETURN hashes
+		`
+		records, err = tx.Run(ctx, query, map[string]any{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		if !records.Next(ctx) {
+			return deletion{}, records.Err()
+		}
+		return deletion{found: true, hashes: recordStrings(records.Record(), "hashes")}, nil
+	})
+
+	if errors.Is(err, ErrOrganizationNotEmpty) {
+		return false, nil, err
+	}
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to delete organization: %w", err)
+	}
+	d := result.(deletion)
+	return d.found, d.hashes, nil
+}
+
+// CreateProject stores a Project node in its organization, which must
+// exist
+func CreateProject(ctx context.Context, client *Neo4jClient, project *models.Project) error {
+	project.ID = uuid.New().String()
+	project.CreatedAt = time.Now().UTC()
+
+	result, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (o:Organization {id: $orgId})
+			WHERE NOT ` + client.dialect.patternExists("(o)-[:HAS_PROJECT]->(:Project {name: $name})") + `
+			CREATE (o)-[:HAS_PROJECT]->(p:Project {
+				id: $id,
+				orgId: $orgId,
+				name: $name,
+				createdAt: $createdAt
+			})
+			RETURN p.id AS id
+		`
+		records, err := tx.Run(ctx, query, map[string]any{
+			"id":        project.ID,
+			"orgId":     project.OrgID,
+			"name":      project.Name,
+			"createdAt": project.CreatedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return records.Next(ctx), records.Err()
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to create project: %w", err)
+	}
+	if created, _ := result.(bool); !created {
+		return ErrProjectExists
+	}
+	return nil
+}
+
+// ListProjects returns the projects of an organization, or of all of them
+// when orgID is empty, by name
+func ListProjects(ctx context.Context, client *Neo4jClient, orgID string) ([]models.Project, error) {
+	result, err := client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (p:Project)
+			WHERE $orgId = '' OR p.orgId = $orgId
+			RETURN p
+			ORDER BY p.orgId, p.name
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"orgId": orgID})
+		if err != nil {
+			return nil, err
+		}
+
+		projects := []models.Project{}
+		for records.Next(ctx) {
+			raw, _ := records.Record().Get("p")
+			projects = append(projects, nodeToProject(raw.(neo4j.Node)))
+		}
+		return projects, records.Err()
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	return result.([]models.Project), nil
+}
+
+// GetProject returns a project, or nil if none has the ID
+func GetProject(ctx context.Context, client *Neo4jClient, id string) (*models.Project, error) {
+	result, err := client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, `MATCH (p:Project {id: $id}) RETURN p`, map[string]any{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		if !records.Next(ctx) {
+			return nil, records.Err()
+		}
+		raw, _ := records.Record().Get("p")
+		project := nodeToProject(raw.(neo4j.Node))
+		return &project, nil
+	})
+
+	if err != nil || result == nil {
+		return nil, err
+	}
+	return result.(*models.Project), nil
+}
+
+// DeleteProject deletes a project without repositories, returning whether
+// it existed
+func DeleteProject(ctx context.Context, client *Neo4jClient, id string) (bool, error) {
+	result, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (p:Project {id: $id})
+			OPTIONAL MATCH (r:Repository {projectId: $id})
+			RETURN count(r) AS repositories
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		if !records.Next(ctx) {
+			return false, records.Err()
+		}
+		if repos, _ := records.Record().Get("repositories"); repos.(int64) > 0 {
+			return nil, ErrProjectNotEmpty
+		}
+
+		_, err = tx.Run(ctx, `MATCH (p:Project {id: $id}) DETACH DELETE p`, map[string]any{"id": id})
+		return true, err
+	})
+
+	if errors.Is(err, ErrProjectNotEmpty) {
+		return false, err
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete project: %w", err)
+	}
+	return result.(bool), nil
+}
+
+// ListOrganizationRepositoryIDs returns the IDs of the repositories of an
+// organization, which searches across repositories are narrowed to, or
+// those of no organization when orgID is empty
+func ListOrganizationRepositoryIDs(ctx context.Context, client *Neo4jClient, orgID string) ([]string, error) {
+	result, err := client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, `MATCH (r:Repository) WHERE coalesce(r.orgId, '') = $orgId RETURN r.id AS id`, map[string]any{"orgId": orgID})
+		if err != nil {
+			return nil, err
+		}
+
+		ids := []string{}
+		for records.Next(ctx) {
+			ids = append(ids, recordString(records.Record(), "id"))
+		}
+		return ids, records.Err()
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories of organization: %w", err)
+	}
+	return result.([]string), nil
+}
+
+func nodeToOrganization(node neo4j.Node) models.Organization {
+	props := node.GetProperties()
+	org := models.Organization{
+		ID:   stringProp(props, "id"),
+		Name: stringProp(props, "name"),
+	}
+	if t, ok := props["createdAt"].(time.Time); ok {
+		org.CreatedAt = t
+	}
+	return org
+}
+
+func nodeToProject(node neo4j.Node) models.Project {
+	props := node.GetProperties()
+	project := models.Project{
+		ID:    stringProp(props, "id"),
+		OrgID: stringProp(props, "orgId"),
+		Name:  stringProp(props, "name"),
+	}
+	if t, ok := props["createdAt"].(time.Time); ok {
+		project.CreatedAt = t
+	}
+	return project
+}
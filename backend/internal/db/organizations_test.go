@@ -0,0 +1,17 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestValidOrganizationID tests which organization IDs are accepted
+func TestValidOrganizationID(t *testing.T) {
+	for _, id := range []string{"acme", "team-42", "a"} {
+		assert.True(t, ValidOrganizationID(id), id)
+	}
+	for _, id := range []string{"", "Acme", "-acme", "acme corp", "acme/dev", string(make([]byte, 64))} {
+		assert.False(t, ValidOrganizationID(id), id)
+	}
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// GetOrientation returns the limit most called functions of a repository
+// and its entry points, see analysis.BuildOrientation
+func (r *GraphReader) GetOrientation(ctx context.Context, repoID string, limit int) (*analysis.OrientationReport, error) {
+	ctx = WithRepository(ctx, repoID)
+	type callGraph struct {
+		functions []callGraphFunction
+		calls     []models.CallRelation
+	}
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		functions, calls, err := readCallGraph(ctx, tx, repoID)
+		return callGraph{functions, calls}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	graph := result.(callGraph)
+	functions := make([]analysis.CodeFunction, len(graph.functions))
+	for i, fn := range graph.functions {
+		functions[i] = analysis.CodeFunction{
+			ID:        fn.ID,
+			Name:      fn.Name,
+			Type:      fn.Type,
+			FilePath:  fn.FilePath,
+			StartLine: fn.StartLine,
+			Signature: fn.Signature,
+		}
+	}
+	return analysis.BuildOrientation(functions, graph.calls, limit), nil
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+)
+
+// GetPackageDependencyGraph returns the repository collapsed to the
+// directories holding its files, its packages, with a DEPENDS_ON edge
+// wherever files of one import or call into another. Edges carry the number
+// of importing files and of calls; nodes and edges in a dependency cycle are
+// marked cyclic and the cycles listed, since packages should form a DAG.
+func (r *GraphReader) GetPackageDependencyGraph(ctx context.Context, repoID string) (*GraphData, error) {
+	files, err := r.GetFileLayers(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	found, err := r.readArchitecture(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	return packageDependencyGraph(repoID, found, files), nil
+}
+
+// packageDependencyGraph builds the package nodes as architectureGraph does
+// and links them with the dependencies found between their files
+func packageDependencyGraph(repoID string, found *architecture, files []analysis.FileLayer) *GraphData {
+	graph := architectureGraph(repoID, found.dirs, found.files, nil, 0)
+	ids := make(map[string]string, len(graph.Nodes)) // path -> node ID
+	for _, node := range graph.Nodes {
+		ids[node.Props["path"].(string)] = node.ID
+	}
+
+	calls := make([]analysis.FileCalls, len(found.calls))
+	for i, c := range found.calls {
+		calls[i] = analysis.FileCalls{Source: c.source, Target: c.target, Calls: int(c.calls)}
+	}
+	deps, cycles := analysis.PackageDependencies(files, calls)
+
+	cyclic := make(map[string]bool)
+	for _, cycle := range cycles {
+		var nodeIDs []string
+		for _, pkg := range cycle {
+			if id, ok := ids[pkg]; ok {
+				cyclic[id] = true
+				nodeIDs = append(nodeIDs, id)
+			}
+		}
+		graph.Cycles = append(graph.Cycles, nodeIDs)
+	}
+	for i := range graph.Nodes {
+		graph.Nodes[i].Props["cyclic"] = cyclic[graph.Nodes[i].ID]
+	}
+
+	for _, dep := range deps {
+		source, okSource := ids[dep.Source]
+		target, okTarget := ids[dep.Target]
+		if !okSource || !okTarget {
+			continue
+		}
+		graph.Edges = append(graph.Edges, GraphEdge{
+			ID:     fmt.Sprintf("%s->%s", source, target),
+			Source: source,
+			Target: target,
+			Type:   "DEPENDS_ON",
+			Props: map[string]any{
+				"imports": dep.Imports,
+				"calls":   dep.Calls,
+				"weight":  dep.Imports + dep.Calls,
+				"cyclic":  dep.Cyclic,
+			},
+		})
+	}
+	return graph
+}
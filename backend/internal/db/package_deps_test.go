@@ -0,0 +1,61 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPackageDependencyGraph tests linking packages by imports and calls and
+// marking the cycles between them
+func TestPackageDependencyGraph(t *testing.T) {
+	found := &architecture{
+		dirs: map[string]architectureDir{
+			"api":   {id: "d-api", isPackage: true},
+			"store": {id: "d-store", isPackage: true},
+			"util":  {id: "d-util", isPackage: true},
+		},
+		files: map[string]int64{
+			"main.go":         1,
+			"api/handlers.go": 4,
+			"store/store.go":  3,
+			"util/strings.go": 2,
+		},
+		calls: []fileCalls{
+			{source: "api/handlers.go", target: "store/store.go", calls: 3},
+			{source: "store/store.go", target: "api/handlers.go", calls: 1},
+			{source: "store/store.go", target: "util/strings.go", calls: 2},
+		},
+	}
+	files := []analysis.FileLayer{
+		{Path: "main.go", Language: "go", Imports: []string{"github.com/acme/app/api"}},
+		{Path: "api/handlers.go", Language: "go", Imports: []string{"github.com/acme/app/store"}},
+		{Path: "store/store.go", Language: "go", Imports: []string{"github.com/acme/app/api", "github.com/acme/app/util"}},
+		{Path: "util/strings.go", Language: "go"},
+	}
+
+	graph := packageDependencyGraph("repo", found, files)
+	require.Len(t, graph.Nodes, 4)
+	cyclic := make(map[string]any)
+	for _, node := range graph.Nodes {
+		cyclic[node.ID] = node.Props["cyclic"]
+	}
+	assert.Equal(t, map[string]any{"repo": false, "d-api": true, "d-store": true, "d-util": false}, cyclic)
+	assert.Equal(t, [][]string{{"d-api", "d-store"}}, graph.Cycles)
+
+	require.Len(t, graph.Edges, 4)
+	assert.Equal(t, GraphEdge{
+		ID: "repo->d-api", Source: "repo", Target: "d-api", Type: "DEPENDS_ON",
+		Props: map[string]any{"imports": 1, "calls": 0, "weight": 1, "cyclic": false},
+	}, graph.Edges[0])
+	assert.Equal(t, GraphEdge{
+		ID: "d-api->d-store", Source: "d-api", Target: "d-store", Type: "DEPENDS_ON",
+		Props: map[string]any{"imports": 1, "calls": 3, "weight": 4, "cyclic": true},
+	}, graph.Edges[1])
+	assert.Equal(t, GraphEdge{
+		ID: "d-store->d-util", Source: "d-store", Target: "d-util", Type: "DEPENDS_ON",
+		Props: map[string]any{"imports": 1, "calls": 2, "weight": 3, "cyclic": false},
+	}, graph.Edges[3])
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// GetPackageGraph returns the structure graph collapsed to directories and
+// packages, each carrying the number of files and functions directly inside it
+func (r *GraphReader) GetPackageGraph(ctx context.Context, repoID string) (*GraphData, error) {
+	ctx = WithRepository(ctx, repoID)
+	query := `
+		MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(d:Directory)
+		OPTIONAL MATCH (p:Directory)-[:CONTAINS]->(d)
+		OPTIONAL MATCH (d)-[:CONTAINS]->(f:File)
+		OPTIONAL MATCH (f)-[:DECLARES]->(fn:Function|Method)
+		RETURN d, p.id AS parentId, count(DISTINCT f) AS files, count(DISTINCT fn) AS functions
+	`
+
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+
+		graph := &GraphData{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+		for records.Next(ctx) {
+			rec := records.Record()
+
+			dirRaw, _ := rec.Get("d")
+			dirNode := dirRaw.(neo4j.Node)
+			props := dirNode.GetProperties()
+
+			nodeType := "Directory"
+			for _, label := range dirNode.Labels {
+				if label == "Package" {
+					nodeType = "Package"
+				}
+			}
+
+			files, _ := rec.Get("files")
+			functions, _ := rec.Get("functions")
+
+			dirID := props["id"].(string)
+			graph.Nodes = append(graph.Nodes, GraphNode{
+				ID:    dirID,
+				Label: props["name"].(string),
+				Type:  nodeType,
+				Props: map[string]any{
+					"path":      props["path"],
+					"files":     files,
+					"functions": functions,
+				},
+			})
+
+			if parentID, _ := rec.Get("parentId"); parentID != nil {
+				graph.Edges = append(graph.Edges, GraphEdge{
+					ID:     fmt.Sprintf("%s->%s", parentID.(string), dirID),
+					Source: parentID.(string),
+					Target: dirID,
+					Type:   "CONTAINS",
+				})
+			}
+		}
+
+		if err := records.Err(); err != nil {
+			return nil, err
+		}
+		return graph, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(*GraphData), nil
+}
@@ -0,0 +1,277 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// ErrReportExists is returned when a repository already has a report of
+// the given name
+var ErrReportExists = errors.New("a report with this name already exists")
+
+// CreateReport saves a named analysis request on a repository
+func CreateReport(ctx context.Context, client *Neo4jClient, report *models.Report) error {
+	report.ID = uuid.New().String()
+	report.CreatedAt = time.Now().UTC()
+	if report.Params == nil {
+		report.Params = map[string]string{}
+	}
+	params, err := json.Marshal(report.Params)
+	if err != nil {
+		return err
+	}
+
+	result, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})
+			WHERE NOT ` + client.dialect.patternExists("(r)-[:HAS_REPORT]->(:Report {name: $name})") + `
+			CREATE (r)-[:HAS_REPORT]->(q:Report {
+				id: $id,
+				repoId: $repoId,
+				name: $name,
+				analysis: $analysis,
+				params: $params,
+				createdAt: $createdAt
+			})
+			RETURN q.id AS id
+		`
+		records, err := tx.Run(ctx, query, map[string]any{
+			"id":        report.ID,
+			"repoId":    report.RepoID,
+			"name":      report.Name,
+			"analysis":  report.Analysis,
+			"params":    string(params),
+			"createdAt": report.CreatedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return records.Next(ctx), records.Err()
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	if created, _ := result.(bool); !created {
+		return ErrReportExists
+	}
+	return nil
+}
+
+// ListReports returns a repository's saved reports, by name
+func ListReports(ctx context.Context, client *Neo4jClient, repoID string) ([]models.Report, error) {
+	result, err := client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:HAS_REPORT]->(q:Report)
+			RETURN q
+			ORDER BY q.name
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+
+		reports := []models.Report{}
+		for records.Next(ctx) {
+			raw, _ := records.Record().Get("q")
+			reports = append(reports, nodeToReport(raw.(neo4j.Node)))
+		}
+		return reports, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]models.Report), nil
+}
+
+// GetReport returns a saved report, or nil if the repository has none with
+// that ID
+func GetReport(ctx context.Context, client *Neo4jClient, repoID, reportID string) (*models.Report, error) {
+	result, err := client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:HAS_REPORT]->(q:Report {id: $id})
+			RETURN q
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "id": reportID})
+		if err != nil {
+			return nil, err
+		}
+		if !records.Next(ctx) {
+			return nil, records.Err()
+		}
+		raw, _ := records.Record().Get("q")
+		report := nodeToReport(raw.(neo4j.Node))
+		return &report, nil
+	})
+
+	if err != nil || result == nil {
+		return nil, err
+	}
+	return result.(*models.Report), nil
+}
+
+// DeleteReport deletes a saved report and its runs
+func DeleteReport(ctx context.Context, client *Neo4jClient, repoID, reportID string) error {
+	_, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:HAS_REPORT]->(q:Report {id: $id})
+			OPTIONAL MATCH (q)-[:HAS_RESULT]->(run:ReportRun)
+			DETACH DELETE run, q
+		`
+		_, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "id": reportID})
+		return nil, err
+	})
+	return err
+}
+
+// SaveReportRun records the result of running a report
+func SaveReportRun(ctx context.Context, client *Neo4jClient, run *models.ReportRun) error {
+	run.ID = uuid.New().String()
+	run.RanAt = time.Now().UTC()
+	if run.Items == nil {
+		run.Items = []string{}
+	}
+
+	_, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (q:Report {id: $reportId})
+			SET q.lastRunAt = $ranAt
+			CREATE (q)-[:HAS_RESULT]->(run:ReportRun {
+				id: $id,
+				reportId: $reportId,
+				commit: $commit,
+				ranAt: $ranAt,
+				items: $items,
+				result: $result
+			})
+		`
+		_, err := tx.Run(ctx, query, map[string]any{
+			"id":       run.ID,
+			"reportId": run.ReportID,
+			"commit":   run.Commit,
+			"ranAt":    run.RanAt,
+			"items":    run.Items,
+			"result":   string(run.Result),
+		})
+		return nil, err
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to save report run: %w", err)
+	}
+	return nil
+}
+
+// ListReportRuns returns the runs of a report, newest first
+func ListReportRuns(ctx context.Context, client *Neo4jClient, reportID string, limit int) ([]models.ReportRun, error) {
+	result, err := client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (:Report {id: $reportId})-[:HAS_RESULT]->(run:ReportRun)
+			RETURN run
+			ORDER BY run.ranAt DESC
+			LIMIT $limit
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"reportId": reportID, "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+
+		runs := []models.ReportRun{}
+		for records.Next(ctx) {
+			raw, _ := records.Record().Get("run")
+			runs = append(runs, nodeToReportRun(raw.(neo4j.Node)))
+		}
+		return runs, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]models.ReportRun), nil
+}
+
+// CompareReportRuns lists the items of the later run that the earlier one
+// did not report, and those it no longer reports. Results are left out of
+// the runs returned.
+func CompareReportRuns(from, to models.ReportRun) models.ReportComparison {
+	before := make(map[string]bool, len(from.Items))
+	for _, item := range from.Items {
+		before[item] = true
+	}
+	after := make(map[string]bool, len(to.Items))
+	for _, item := range to.Items {
+		after[item] = true
+	}
+
+	from.Result, to.Result = nil, nil
+	comparison := models.ReportComparison{From: from, To: to, Added: []string{}, Removed: []string{}}
+	for item := range after {
+		if before[item] {
+			comparison.Unchanged++
+		} else {
+			comparison.Added = append(comparison.Added, item)
+		}
+	}
+	for item := range before {
+		if !after[item] {
+			comparison.Removed = append(comparison.Removed, item)
+		}
+	}
+	sort.Strings(comparison.Added)
+	sort.Strings(comparison.Removed)
+	return comparison
+}
+
+func nodeToReport(node neo4j.Node) models.Report {
+	props := node.GetProperties()
+	report := models.Report{
+		ID:       stringProp(props, "id"),
+		RepoID:   stringProp(props, "repoId"),
+		Name:     stringProp(props, "name"),
+		Analysis: stringProp(props, "analysis"),
+		Params:   map[string]string{},
+	}
+	if params := stringProp(props, "params"); params != "" {
+		_ = json.Unmarshal([]byte(params), &report.Params)
+	}
+	if t, ok := props["createdAt"].(time.Time); ok {
+		report.CreatedAt = t
+	}
+	if t, ok := props["lastRunAt"].(time.Time); ok {
+		report.LastRunAt = t
+	}
+	return report
+}
+
+func nodeToReportRun(node neo4j.Node) models.ReportRun {
+	props := node.GetProperties()
+	run := models.ReportRun{
+		ID:       stringProp(props, "id"),
+		ReportID: stringProp(props, "reportId"),
+		Commit:   stringProp(props, "commit"),
+		Items:    []string{},
+	}
+	if t, ok := props["ranAt"].(time.Time); ok {
+		run.RanAt = t
+	}
+	if items, ok := props["items"].([]any); ok {
+		for _, item := range items {
+			if s, ok := item.(string); ok {
+				run.Items = append(run.Items, s)
+			}
+		}
+	}
+	if result := stringProp(props, "result"); result != "" {
+		run.Result = json.RawMessage(result)
+	}
+	return run
+}
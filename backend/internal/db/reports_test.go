@@ -0,0 +1,69 @@
+package db
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNodeToReport tests conversion of stored Report properties
+func TestNodeToReport(t *testing.T) {
+	created := time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)
+
+	report := nodeToReport(neo4j.Node{Props: map[string]any{
+		"id":        "report-1",
+		"repoId":    "repo-1",
+		"name":      "Untested core",
+		"analysis":  "coverage-gaps",
+		"params":    `{"maxCoverage":"20","limit":"10"}`,
+		"createdAt": created,
+	}})
+
+	assert.Equal(t, "Untested core", report.Name)
+	assert.Equal(t, "coverage-gaps", report.Analysis)
+	assert.Equal(t, map[string]string{"maxCoverage": "20", "limit": "10"}, report.Params)
+	assert.Equal(t, created, report.CreatedAt)
+	assert.True(t, report.LastRunAt.IsZero())
+}
+
+// TestNodeToReportRun tests conversion of stored ReportRun properties
+func TestNodeToReportRun(t *testing.T) {
+	run := nodeToReportRun(neo4j.Node{Props: map[string]any{
+		"id":       "run-1",
+		"reportId": "report-1",
+		"commit":   "abc123",
+		"items":    []any{"a.go:A", "b.go:B"},
+		"result":   `[{"name":"A"}]`,
+	}})
+
+	assert.Equal(t, "abc123", run.Commit)
+	assert.Equal(t, []string{"a.go:A", "b.go:B"}, run.Items)
+	assert.Equal(t, json.RawMessage(`[{"name":"A"}]`), run.Result)
+
+	empty := nodeToReportRun(neo4j.Node{Props: map[string]any{"id": "run-2"}})
+	assert.Equal(t, []string{}, empty.Items)
+	assert.Nil(t, empty.Result)
+}
+
+// TestCompareReportRuns tests listing items added and removed between runs
+func TestCompareReportRuns(t *testing.T) {
+	from := models.ReportRun{ID: "run-1", Items: []string{"a", "b", "c"}, Result: json.RawMessage(`[]`)}
+	to := models.ReportRun{ID: "run-2", Items: []string{"d", "b", "a"}, Result: json.RawMessage(`[]`)}
+
+	comparison := CompareReportRuns(from, to)
+
+	assert.Equal(t, []string{"d"}, comparison.Added)
+	assert.Equal(t, []string{"c"}, comparison.Removed)
+	assert.Equal(t, 2, comparison.Unchanged)
+	assert.Equal(t, "run-1", comparison.From.ID)
+	assert.Nil(t, comparison.To.Result)
+
+	same := CompareReportRuns(to, to)
+	assert.Empty(t, same.Added)
+	assert.Empty(t, same.Removed)
+	assert.Equal(t, 3, same.Unchanged)
+}
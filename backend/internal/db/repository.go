@@ -2,7 +2,11 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/dpolishuk/neograph/backend/internal/models"
@@ -10,7 +14,83 @@ import (
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
+// ErrIdempotencyKeyReused is returned for an idempotency key sent before to
+// create another repository
+var ErrIdempotencyKeyReused = errors.New("idempotency key was used for another repository")
+
+// createRepositoryMu serializes looking for an existing repository and
+// creating one, so concurrent requests for a URL create it once
+var createRepositoryMu sync.Mutex
+
+// RepositoryURLKey normalizes a repository URL so the ways of writing the
+// same one compare equal: case, a trailing slash and .git suffix aside
+func RepositoryURLKey(url string) string {
+	url = strings.ToLower(strings.TrimSpace(url))
+	return strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+}
+
+// repositoryURLVariants are the ways of writing a URL with a key, lowercased
+func repositoryURLVariants(url string) []string {
+	key := RepositoryURLKey(url)
+	return []string{key, key + "/", key + ".git", key + ".git/"}
+}
+
 func CreateRepository(ctx context.Context, client *Neo4jClient, repo *models.Repository) (*models.Repository, error) {
+	return createRepository(ctx, client, repo, "")
+}
+
+// CreateRepositoryOnce creates a repository unless one with the same URL,
+// or one created with the same idempotency key, exists in its organization;
+// that one is returned instead, with created false. A key sent to create
+// another URL is rejected with ErrIdempotencyKeyReused. Organizations do
+// not see each other's repositories, so each may register the same URL.
+func CreateRepositoryOnce(ctx context.Context, client *Neo4jClient, repo *models.Repository, idempotencyKey string) (*models.Repository, bool, error) {
+	createRepositoryMu.Lock()
+	defer createRepositoryMu.Unlock()
+
+	result, err := client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository)
+			WHERE coalesce(r.orgId, '') = $orgId
+			  AND (toLower(r.url) IN $urls
+			       OR ($idempotencyKey <> '' AND r.idempotencyKey = $idempotencyKey))
+			RETURN r.id AS id, r.url AS url, r.name AS name,
+			       r.defaultBranch AS defaultBranch, r.status AS status,
+			       r.lastIndexed AS lastIndexed, r.filesCount AS filesCount,
+			       r.functionsCount AS functionsCount,
+			       coalesce(r.wikiAutoRefresh, false) AS wikiAutoRefresh,
+			       r.commit AS commit, r.projectId AS projectId, r.orgId AS orgId
+			ORDER BY coalesce(r.idempotencyKey = $idempotencyKey, false) DESC
+			LIMIT 1
+		`
+		records, err := tx.Run(ctx, query, map[string]any{
+			"urls":           repositoryURLVariants(repo.URL),
+			"idempotencyKey": idempotencyKey,
+			"orgId":          repo.OrgID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !records.Next(ctx) {
+			return nil, records.Err()
+		}
+		return recordToRepository(records.Record()), nil
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to look up repository: %w", err)
+	}
+	if existing, _ := result.(*models.Repository); existing != nil {
+		if RepositoryURLKey(existing.URL) != RepositoryURLKey(repo.URL) {
+			return nil, false, ErrIdempotencyKeyReused
+		}
+		return existing, false, nil
+	}
+
+	created, err := createRepository(ctx, client, repo, idempotencyKey)
+	return created, err == nil, err
+}
+
+func createRepository(ctx context.Context, client *Neo4jClient, repo *models.Repository, idempotencyKey string) (*models.Repository, error) {
 	repo.ID = uuid.New().String()
 
 	_, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
@@ -23,17 +103,23 @@ func CreateRepository(ctx context.Context, client *Neo4jClient, repo *models.Rep
 				status: $status,
 				lastIndexed: $lastIndexed,
 				filesCount: 0,
-				functionsCount: 0
+				functionsCount: 0,
+				idempotencyKey: $idempotencyKey,
+				projectId: $projectId,
+				orgId: $orgId
 			})
 			RETURN r
 		`
 		_, err := tx.Run(ctx, query, map[string]any{
-			"id":            repo.ID,
-			"url":           repo.URL,
-			"name":          repo.Name,
-			"defaultBranch": repo.DefaultBranch,
-			"status":        repo.Status,
-			"lastIndexed":   time.Now().UTC(),
+			"id":             repo.ID,
+			"url":            repo.URL,
+			"name":           repo.Name,
+			"defaultBranch":  repo.DefaultBranch,
+			"status":         repo.Status,
+			"lastIndexed":    time.Now().UTC(),
+			"idempotencyKey": nullIfEmpty(idempotencyKey),
+			"projectId":      nullIfEmpty(repo.ProjectID),
+			"orgId":          nullIfEmpty(repo.OrgID),
 		})
 		return nil, err
 	})
@@ -41,10 +127,21 @@ func CreateRepository(ctx context.Context, client *Neo4jClient, repo *models.Rep
 	if err != nil {
 		return nil, fmt.Errorf("failed to create repository: %w", err)
 	}
+	if err := CreateRepositoryDatabase(ctx, client, repo); err != nil {
+		return nil, err
+	}
 
 	return repo, nil
 }
 
+// nullIfEmpty leaves a property unset rather than storing an empty string
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
 func GetRepository(ctx context.Context, client *Neo4jClient, id string) (*models.Repository, error) {
 	result, err := client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		query := `
@@ -52,7 +149,9 @@ func GetRepository(ctx context.Context, client *Neo4jClient, id string) (*models
 			RETURN r.id AS id, r.url AS url, r.name AS name,
 			       r.defaultBranch AS defaultBranch, r.status AS status,
 			       r.lastIndexed AS lastIndexed, r.filesCount AS filesCount,
-			       r.functionsCount AS functionsCount
+			       r.functionsCount AS functionsCount,
+			       coalesce(r.wikiAutoRefresh, false) AS wikiAutoRefresh,
+			       r.commit AS commit, r.projectId AS projectId, r.orgId AS orgId
 		`
 		result, err := tx.Run(ctx, query, map[string]any{"id": id})
 		if err != nil {
@@ -82,7 +181,9 @@ func ListRepositories(ctx context.Context, client *Neo4jClient) ([]*models.Repos
 			RETURN r.id AS id, r.url AS url, r.name AS name,
 			       r.defaultBranch AS defaultBranch, r.status AS status,
 			       r.lastIndexed AS lastIndexed, r.filesCount AS filesCount,
-			       r.functionsCount AS functionsCount
+			       r.functionsCount AS functionsCount,
+			       coalesce(r.wikiAutoRefresh, false) AS wikiAutoRefresh,
+			       r.commit AS commit, r.projectId AS projectId, r.orgId AS orgId
 			ORDER BY r.lastIndexed DESC
 		`
 		result, err := tx.Run(ctx, query, nil)
@@ -119,21 +220,160 @@ func UpdateRepositoryStatus(ctx context.Context, client *Neo4jClient, id, status
 	return err
 }
 
-func DeleteRepository(ctx context.Context, client *Neo4jClient, id string) error {
+// GetRepositorySettings returns the options of a repository, or nil if it
+// does not exist
+func GetRepositorySettings(ctx context.Context, client *Neo4jClient, id string) (*models.RepositorySettings, error) {
+	result, err := client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $id})
+			RETURN coalesce(r.wikiAutoRefresh, false) AS wikiAutoRefresh,
+			       r.ignoreGlobs AS ignoreGlobs, r.languages AS languages,
+			       r.maxFileSize AS maxFileSize,
+			       coalesce(r.skipEmbeddings, false) AS skipEmbeddings
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		if !records.Next(ctx) {
+			return nil, records.Err()
+		}
+		rec := records.Record()
+		wikiAutoRefresh, _ := recordValue(rec, "wikiAutoRefresh").(bool)
+		maxFileSize, _ := recordValue(rec, "maxFileSize").(int64)
+		skipEmbeddings, _ := recordValue(rec, "skipEmbeddings").(bool)
+		return &models.RepositorySettings{
+			WikiAutoRefresh: wikiAutoRefresh,
+			IndexSettings: models.IndexSettings{
+				IgnoreGlobs:    recordStrings(rec, "ignoreGlobs"),
+				Languages:      recordStrings(rec, "languages"),
+				MaxFileSize:    maxFileSize,
+				SkipEmbeddings: skipEmbeddings,
+			},
+		}, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository settings: %w", err)
+	}
+	if result == nil {
+		return nil, nil
+	}
+	return result.(*models.RepositorySettings), nil
+}
+
+func UpdateRepositorySettings(ctx context.Context, client *Neo4jClient, id string, settings *models.RepositorySettings) error {
+	_, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $id})
+			SET r.wikiAutoRefresh = $wikiAutoRefresh,
+			    r.ignoreGlobs = $ignoreGlobs, r.languages = $languages,
+			    r.maxFileSize = $maxFileSize, r.skipEmbeddings = $skipEmbeddings
+		`
+		_, err := tx.Run(ctx, query, map[string]any{
+			"id":              id,
+			"wikiAutoRefresh": settings.WikiAutoRefresh,
+			"ignoreGlobs":     settings.IgnoreGlobs,
+			"languages":       settings.Languages,
+			"maxFileSize":     settings.MaxFileSize,
+			"skipEmbeddings":  settings.SkipEmbeddings,
+		})
+		return nil, err
+	})
+	return err
+}
+
+// GetRepositorySummary returns the cached summary, or nil if none is stored
+func GetRepositorySummary(ctx context.Context, client *Neo4jClient, id string) (*models.RepositorySummary, error) {
+	result, err := client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $id})
+			WHERE r.summary IS NOT NULL
+			RETURN r.summary AS summary, r.summarySource AS source,
+			       r.summaryCommit AS commit, r.summaryGeneratedAt AS generatedAt
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		if !records.Next(ctx) {
+			return nil, records.Err()
+		}
+
+		rec := records.Record()
+		summary := &models.RepositorySummary{}
+		if v, _ := rec.Get("summary"); v != nil {
+			summary.Summary = v.(string)
+		}
+		if v, _ := rec.Get("source"); v != nil {
+			summary.Source = v.(string)
+		}
+		if v, _ := rec.Get("commit"); v != nil {
+			summary.Commit = v.(string)
+		}
+		if v, _ := rec.Get("generatedAt"); v != nil {
+			if t, ok := v.(time.Time); ok {
+				summary.GeneratedAt = t
+			}
+		}
+		return summary, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, nil
+	}
+	return result.(*models.RepositorySummary), nil
+}
+
+// SaveRepositorySummary caches a generated summary on the repository
+func SaveRepositorySummary(ctx context.Context, client *Neo4jClient, id string, summary *models.RepositorySummary) error {
 	_, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		// Delete all related nodes first
 		query := `
 			MATCH (r:Repository {id: $id})
-			OPTIONAL MATCH (r)-[:CONTAINS]->(f:File)
-			OPTIONAL MATCH (f)-[:DECLARES]->(e)
-			DETACH DELETE e, f, r
+			SET r.summary = $summary,
+			    r.summarySource = $source,
+			    r.summaryCommit = $commit,
+			    r.summaryGeneratedAt = $generatedAt
 		`
-		_, err := tx.Run(ctx, query, map[string]any{"id": id})
+		_, err := tx.Run(ctx, query, map[string]any{
+			"id":          id,
+			"summary":     summary.Summary,
+			"source":      summary.Source,
+			"commit":      summary.Commit,
+			"generatedAt": summary.GeneratedAt,
+		})
 		return nil, err
 	})
 	return err
 }
 
+func DeleteRepository(ctx context.Context, client *Neo4jClient, id string) error {
+	_, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		// Delete all related nodes first
+		queries := slices.Concat(repositoryGraphQueries, []string{
+			`MATCH (r:Repository {id: $id})-[:HAS_RUN]->(run:IndexRun)
+			DETACH DELETE run`,
+			`MATCH (r:Repository {id: $id})-[:HAS_WIKI]->(page:WikiPage)
+			DETACH DELETE page`,
+			`MATCH (r:Repository {id: $id})-[:HAS_REPORT]->(report:Report)
+			OPTIONAL MATCH (report)-[:HAS_RESULT]->(result:ReportRun)
+			DETACH DELETE result, report`,
+			`MATCH (r:Repository {id: $id})-[:HAS_CONVERSATION]->(conv:Conversation)
+			OPTIONAL MATCH (conv)-[:HAS_MESSAGE]->(message:ConversationMessage)
+			DETACH DELETE message, conv`,
+			`MATCH (r:Repository {id: $id})
+			DETACH DELETE r`,
+		})
+		return nil, runEach(ctx, tx, queries, map[string]any{"id": id})
+	})
+	if err != nil {
+		return err
+	}
+	return DropRepositoryDatabase(ctx, client, id)
+}
+
 func recordToRepository(record *neo4j.Record) *models.Repository {
 	repo := &models.Repository{}
 
@@ -163,6 +403,14 @@ func recordToRepository(record *neo4j.Record) *models.Repository {
 	if functionsCount, ok := record.Get("functionsCount"); ok && functionsCount != nil {
 		repo.FunctionsCount = int(functionsCount.(int64))
 	}
+	if commit, ok := record.Get("commit"); ok && commit != nil {
+		repo.Commit = commit.(string)
+	}
+	if autoRefresh, ok := record.Get("wikiAutoRefresh"); ok && autoRefresh != nil {
+		repo.WikiAutoRefresh = autoRefresh.(bool)
+	}
+	repo.ProjectID = recordString(record, "projectId")
+	repo.OrgID = recordString(record, "orgId")
 
 	return repo
 }
@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// DefaultRepositorySort lists the most recently indexed repositories first
+const DefaultRepositorySort = "-lastIndexed"
+
+// repositorySortKeys maps the fields repositories may be sorted by to their
+// properties
+var repositorySortKeys = map[string]string{
+	"name":           "toLower(r.name)",
+	"status":         "r.status",
+	"lastIndexed":    "r.lastIndexed",
+	"filesCount":     "r.filesCount",
+	"functionsCount": "r.functionsCount",
+}
+
+// RepositoryQuery filters, sorts and pages the repositories listed
+type RepositoryQuery struct {
+	Status string // pending, indexing, ready or error
+	Name   string // substring of the name, ignoring case
+	// OrgID and ProjectID narrow the list to an organization and a
+	// project; all repositories are listed when empty
+	OrgID     string
+	ProjectID string
+	// Unorganized narrows the list to repositories of no organization, the
+	// only ones anonymous callers see once callers authenticate
+	Unorganized bool
+	// Sort is a field of Repository such as name or lastIndexed, prefixed
+	// with - to sort descending; DefaultRepositorySort when empty
+	Sort   string
+	Offset int
+	Limit  int // 0 for all
+}
+
+// ValidRepositorySort reports whether repositories can be sorted by sort
+func ValidRepositorySort(sort string) bool {
+	_, ok := repositorySortKeys[strings.TrimPrefix(sort, "-")]
+	return ok || sort == ""
+}
+
+// whereClause returns the condition on a repository, "true" when
+// unfiltered, with the parameters it uses
+func (q RepositoryQuery) whereClause() (string, map[string]any) {
+	params := map[string]any{}
+
+	var conds []string
+	if q.Status != "" {
+		conds = append(conds, "r.status = $status")
+		params["status"] = q.Status
+	}
+	if q.Name != "" {
+		conds = append(conds, "toLower(r.name) CONTAINS $name")
+		params["name"] = strings.ToLower(q.Name)
+	}
+	if q.OrgID != "" {
+		conds = append(conds, "r.orgId = $orgId")
+		params["orgId"] = q.OrgID
+	}
+	if q.Unorganized {
+		conds = append(conds, "coalesce(r.orgId, '') = ''")
+	}
+	if q.ProjectID != "" {
+		conds = append(conds, "r.projectId = $projectId")
+		params["projectId"] = q.ProjectID
+	}
+	return joinConds(conds), params
+}
+
+// orderClause returns the ORDER BY expressions of the sort, ending with the
+// ID so pages of equal keys are stable
+func (q RepositoryQuery) orderClause() string {
+	sort := q.Sort
+	if !ValidRepositorySort(sort) || sort == "" {
+		sort = DefaultRepositorySort
+	}
+	field, descending := strings.CutPrefix(sort, "-")
+	order := repositorySortKeys[field]
+	if descending {
+		order += " DESC"
+	}
+	return order + ", r.id"
+}
+
+// QueryRepositories returns a page of the repositories matching a query,
+// with how many match in all
+func QueryRepositories(ctx context.Context, client *Neo4jClient, q RepositoryQuery) ([]*models.Repository, int, error) {
+	where, params := q.whereClause()
+	params["offset"] = max(q.Offset, 0)
+	page := "SKIP $offset"
+	if q.Limit > 0 {
+		page += " LIMIT $limit"
+		params["limit"] = q.Limit
+	}
+
+	type result struct {
+		repos []*models.Repository
+		total int
+	}
+	res, err := client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		counted, err := tx.Run(ctx, `MATCH (r:Repository) WHERE `+where+` RETURN count(r) AS total`, params)
+		if err != nil {
+			return nil, err
+		}
+		record, err := counted.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		total, _ := record.Get("total")
+
+		query := `
+			MATCH (r:Repository)
+			WHERE ` + where + `
+			RETURN r.id AS id, r.url AS url, r.name AS name,
+			       r.defaultBranch AS defaultBranch, r.status AS status,
+			       r.lastIndexed AS lastIndexed, r.filesCount AS filesCount,
+			       r.functionsCount AS functionsCount,
+			       coalesce(r.wikiAutoRefresh, false) AS wikiAutoRefresh,
+			       r.commit AS commit, r.projectId AS projectId, r.orgId AS orgId
+			ORDER BY ` + q.orderClause() + `
+			` + page
+		listed, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+		repos := []*models.Repository{}
+		for listed.Next(ctx) {
+			repos = append(repos, recordToRepository(listed.Record()))
+		}
+		return result{repos: repos, total: int(total.(int64))}, listed.Err()
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list repositories: %w", err)
+	}
+	r := res.(result)
+	return r.repos, r.total, nil
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRepositoryQueryWhereClause tests translating repository filters to a
+// Cypher condition
+func TestRepositoryQueryWhereClause(t *testing.T) {
+	where, params := RepositoryQuery{}.whereClause()
+	assert.Equal(t, "true", where)
+	assert.Empty(t, params)
+
+	where, params = RepositoryQuery{Status: "ready", Name: "NeoGraph"}.whereClause()
+	assert.Equal(t, "r.status = $status AND toLower(r.name) CONTAINS $name", where)
+	assert.Equal(t, "ready", params["status"])
+	assert.Equal(t, "neograph", params["name"])
+
+	where, params = RepositoryQuery{OrgID: "acme", ProjectID: "p1"}.whereClause()
+	assert.Equal(t, "r.orgId = $orgId AND r.projectId = $projectId", where)
+	assert.Equal(t, "acme", params["orgId"])
+	assert.Equal(t, "p1", params["projectId"])
+
+	where, _ = RepositoryQuery{Unorganized: true}.whereClause()
+	assert.Equal(t, "coalesce(r.orgId, '') = ''", where)
+}
+
+// TestRepositoryQueryOrderClause tests sorting repositories by a field
+func TestRepositoryQueryOrderClause(t *testing.T) {
+	assert.Equal(t, "r.lastIndexed DESC, r.id", RepositoryQuery{}.orderClause())
+	assert.Equal(t, "toLower(r.name), r.id", RepositoryQuery{Sort: "name"}.orderClause())
+	assert.Equal(t, "r.filesCount DESC, r.id", RepositoryQuery{Sort: "-filesCount"}.orderClause())
+	assert.Equal(t, "r.lastIndexed DESC, r.id", RepositoryQuery{Sort: "url; DROP"}.orderClause())
+
+	assert.True(t, ValidRepositorySort(""))
+	assert.True(t, ValidRepositorySort("-status"))
+	assert.False(t, ValidRepositorySort("url"))
+}
+
+// TestRepositoryURLKey tests equating the ways of writing a repository URL
+func TestRepositoryURLKey(t *testing.T) {
+	key := RepositoryURLKey("https://github.com/acme/app")
+	for _, url := range []string{"https://github.com/Acme/App.git", "https://github.com/acme/app/", " https://github.com/acme/app.git/ "} {
+		assert.Equal(t, key, RepositoryURLKey(url), url)
+	}
+	assert.NotEqual(t, key, RepositoryURLKey("https://github.com/acme/app2"))
+	assert.Contains(t, repositoryURLVariants("https://github.com/Acme/App"), "https://github.com/acme/app.git")
+}
@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/dpolishuk/neograph/backend/internal/traces"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// RuntimeStats is the production behavior observed for an entity in the last
+// uploaded profile or trace
+type RuntimeStats struct {
+	Count      int64     `json:"count"`   // samples or spans
+	TotalMs    float64   `json:"totalMs"` // time across all of them
+	AvgMs      float64   `json:"avgMs"`
+	Source     string    `json:"source"` // pprof or otlp
+	ObservedAt time.Time `json:"observedAt"`
+}
+
+// runtimeStats reads the runtime properties of an entity node, or nil if
+// none were recorded
+func runtimeStats(props map[string]any) *RuntimeStats {
+	count, ok := props["runtimeCount"].(int64)
+	if !ok {
+		return nil
+	}
+	stats := &RuntimeStats{Count: count, Source: stringProp(props, "runtimeSource")}
+	if total, ok := props["runtimeTotalMs"].(float64); ok {
+		stats.TotalMs = total
+		if count > 0 {
+			stats.AvgMs = total / float64(count)
+		}
+	}
+	if t, ok := props["runtimeObservedAt"].(time.Time); ok {
+		stats.ObservedAt = t
+	}
+	return stats
+}
+
+// withRuntime adds the runtime stats of an entity, if any, to the props of
+// its graph node
+func withRuntime(nodeProps, props map[string]any) map[string]any {
+	if stats := runtimeStats(props); stats != nil {
+		nodeProps["runtimeCount"] = stats.Count
+		nodeProps["runtimeAvgMs"] = stats.AvgMs
+	}
+	return nodeProps
+}
+
+// GetEntityLocations returns the functions, methods and classes of a
+// repository with just what is needed to match runtime frames to them
+func (r *GraphReader) GetEntityLocations(ctx context.Context, repoID string) ([]models.CodeEntity, error) {
+	ctx = WithRepository(ctx, repoID)
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(:File)-[:DECLARES]->(e:Function|Method|Class)
+			RETURN e.id AS id, e.name AS name, e.className AS className, e.filePath AS filePath,
+			       e.startLine AS startLine, e.endLine AS endLine, labels(e) AS labels
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+
+		entities := []models.CodeEntity{}
+		for records.Next(ctx) {
+			rec := records.Record()
+			entity := models.CodeEntity{
+				ID:        recordString(rec, "id"),
+				Name:      recordString(rec, "name"),
+				ClassName: recordString(rec, "className"),
+				FilePath:  recordString(rec, "filePath"),
+				RepoID:    repoID,
+			}
+			if sl, _ := rec.Get("startLine"); sl != nil {
+				entity.StartLine = int(sl.(int64))
+			}
+			if el, _ := rec.Get("endLine"); el != nil {
+				entity.EndLine = int(el.(int64))
+			}
+			labels, _ := rec.Get("labels")
+			for _, label := range labels.([]any) {
+				entity.Type = models.CodeEntityType(label.(string))
+			}
+			entities = append(entities, entity)
+		}
+		return entities, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]models.CodeEntity), nil
+}
+
+// WriteRuntimeStats replaces the runtime behavior recorded on a repository's
+// entities with stats from one profile or trace
+func (w *GraphWriter) WriteRuntimeStats(ctx context.Context, repoID, source string, stats []traces.EntityStats) error {
+	ctx = WithRepository(ctx, repoID)
+
+	rows := make([]map[string]any, len(stats))
+	for i, s := range stats {
+		rows[i] = map[string]any{
+			"id":      s.EntityID,
+			"count":   s.Count,
+			"totalMs": float64(s.Duration) / float64(time.Millisecond),
+		}
+	}
+
+	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(:File)-[:DECLARES]->(e)
+			WHERE e.runtimeCount IS NOT NULL
+			REMOVE e.runtimeCount, e.runtimeTotalMs, e.runtimeSource, e.runtimeObservedAt
+		`
+		if _, err := tx.Run(ctx, query, map[string]any{"repoId": repoID}); err != nil {
+			return nil, err
+		}
+
+		query = `
+			UNWIND $rows AS row
+			MATCH (e:Function|Method|Class {id: row.id, repoId: $repoId})
+			SET e.runtimeCount = row.count,
+			    e.runtimeTotalMs = row.totalMs,
+			    e.runtimeSource = $source,
+			    e.runtimeObservedAt = $observedAt
+		`
+		_, err := tx.Run(ctx, query, map[string]any{
+			"repoId":     repoID,
+			"rows":       rows,
+			"source":     source,
+			"observedAt": time.Now().UTC(),
+		})
+		return nil, err
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to write runtime stats: %w", err)
+	}
+	return nil
+}
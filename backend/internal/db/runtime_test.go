@@ -0,0 +1,28 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRuntimeStats tests reading runtime annotations off entity properties
+func TestRuntimeStats(t *testing.T) {
+	observed := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	stats := runtimeStats(map[string]any{
+		"runtimeCount":      int64(4),
+		"runtimeTotalMs":    10.0,
+		"runtimeSource":     "otlp",
+		"runtimeObservedAt": observed,
+	})
+
+	assert.Equal(t, &RuntimeStats{Count: 4, TotalMs: 10, AvgMs: 2.5, Source: "otlp", ObservedAt: observed}, stats)
+	assert.Nil(t, runtimeStats(map[string]any{"name": "main"}))
+
+	props := withRuntime(map[string]any{"signature": "func main()"}, map[string]any{
+		"runtimeCount":   int64(4),
+		"runtimeTotalMs": 10.0,
+	})
+	assert.Equal(t, map[string]any{"signature": "func main()", "runtimeCount": int64(4), "runtimeAvgMs": 2.5}, props)
+}
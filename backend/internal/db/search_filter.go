@@ -0,0 +1,98 @@
+package db
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// filteredSearchOversample is how many candidates per requested result a
+// filtered search takes from an index, as the filter is applied after it
+const filteredSearchOversample = 10
+
+// maxSearchCandidates caps the candidates taken from an index
+const maxSearchCandidates = 1000
+
+// SearchFilter narrows search results to a language, entity types and a
+// path prefix, and to those scoring at least MinScore
+type SearchFilter struct {
+	Language   string
+	Types      []string // Function, Method or Class
+	PathPrefix string
+	// RepoIDs narrows results to the repositories of an organization; nil
+	// keeps every repository, empty none
+	RepoIDs []string
+	// From 0 to 1, see SearchResult.Score; applied to each search whose
+	// rankings are fused rather than to the fused scores
+	MinScore float64
+}
+
+// IsZero reports whether the filter keeps every result
+func (f SearchFilter) IsZero() bool {
+	return !f.matchesNodes() && f.MinScore == 0
+}
+
+// matchesNodes reports whether the filter has conditions on the nodes of
+// results, which are applied to the candidates taken from an index
+func (f SearchFilter) matchesNodes() bool {
+	return f.Language != "" || len(f.Types) > 0 || f.PathPrefix != "" || f.RepoIDs != nil
+}
+
+// aboveMinScore drops the results of a ranking scoring below MinScore
+func (f SearchFilter) aboveMinScore(results []SearchResult) []SearchResult {
+	for i, result := range results {
+		if result.Score < f.MinScore {
+			return results[:i]
+		}
+	}
+	return results
+}
+
+// whereClause returns the condition on a search result node, "true" when
+// unfiltered, with the parameters it uses. Entities do not record their
+// language, so it is matched by the extension of their file.
+func (f SearchFilter) whereClause() (string, map[string]any) {
+	params := map[string]any{}
+
+	var conds []string
+	if f.Language != "" {
+		conds = append(conds, "any(ext IN $extensions WHERE node.filePath ENDS WITH ext)")
+		params["extensions"] = LanguageExtensions(f.Language)
+	}
+	if len(f.Types) > 0 {
+		conds = append(conds, "any(l IN labels(node) WHERE l IN $types)")
+		params["types"] = f.Types
+	}
+	if f.PathPrefix != "" {
+		conds = append(conds, "node.filePath STARTS WITH $pathPrefix")
+		params["pathPrefix"] = strings.TrimPrefix(f.PathPrefix, "/")
+	}
+	if f.RepoIDs != nil {
+		conds = append(conds, "node.repoId IN $repoIds")
+		params["repoIds"] = f.RepoIDs
+	}
+	return joinConds(conds), params
+}
+
+// candidates returns how many results to take from an index for limit
+// results passing the filter
+func (f SearchFilter) candidates(limit int) int {
+	if !f.matchesNodes() {
+		return limit
+	}
+	return min(limit*filteredSearchOversample, max(limit, maxSearchCandidates))
+}
+
+// LanguageExtensions returns the file extensions of a language, empty for
+// an unknown one
+func LanguageExtensions(language string) []string {
+	extensions := []string{}
+	for ext, lang := range models.LanguageByExtension {
+		if lang == language {
+			extensions = append(extensions, ext)
+		}
+	}
+	sort.Strings(extensions)
+	return extensions
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSearchFilterWhereClause tests translating search filters to a Cypher
+// condition on the result node
+func TestSearchFilterWhereClause(t *testing.T) {
+	where, params := SearchFilter{}.whereClause()
+	assert.Equal(t, "true", where)
+	assert.Empty(t, params)
+
+	filter := SearchFilter{Language: "typescript", Types: []string{"Method"}, PathPrefix: "/src/api"}
+	where, params = filter.whereClause()
+	assert.Equal(t, "any(ext IN $extensions WHERE node.filePath ENDS WITH ext) AND "+
+		"any(l IN labels(node) WHERE l IN $types) AND node.filePath STARTS WITH $pathPrefix", where)
+	assert.Equal(t, []string{".ts", ".tsx"}, params["extensions"])
+	assert.Equal(t, []string{"Method"}, params["types"])
+	assert.Equal(t, "src/api", params["pathPrefix"])
+
+	where, params = SearchFilter{RepoIDs: []string{}}.whereClause()
+	assert.Equal(t, "node.repoId IN $repoIds", where)
+	assert.Equal(t, []string{}, params["repoIds"])
+}
+
+// TestSearchFilterCandidates tests oversampling indexes for filtered searches
+func TestSearchFilterCandidates(t *testing.T) {
+	assert.Equal(t, 10, SearchFilter{}.candidates(10))
+	assert.Equal(t, 10, SearchFilter{MinScore: 0.5}.candidates(10))
+	assert.Equal(t, 100, SearchFilter{Language: "go"}.candidates(10))
+	assert.Equal(t, 1000, SearchFilter{Language: "go"}.candidates(200))
+	assert.Empty(t, LanguageExtensions("cobol"))
+}
+
+// TestSearchFilterMinScore tests cutting rankings at the minimum score
+func TestSearchFilterMinScore(t *testing.T) {
+	results := []SearchResult{{ID: "a", Score: 0.9}, {ID: "b", Score: 0.5}, {ID: "c", Score: 0.2}}
+	assert.Len(t, SearchFilter{}.aboveMinScore(results), 3)
+	assert.Len(t, SearchFilter{MinScore: 0.5}.aboveMinScore(results), 2)
+	assert.Empty(t, SearchFilter{MinScore: 0.95}.aboveMinScore(results))
+	assert.False(t, SearchFilter{MinScore: 0.5}.IsZero())
+
+	relative := relativeScores([]SearchResult{{Score: 8}, {Score: 2}})
+	assert.Equal(t, []float64{1, 0.25}, []float64{relative[0].Score, relative[1].Score})
+	assert.Empty(t, relativeScores(nil))
+}
@@ -0,0 +1,269 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// ErrSearchNotFound is returned when a selection names a search that was not
+// logged
+var ErrSearchNotFound = errors.New("search not found")
+
+// LogSearch records a search as a SearchQuery node. Queries are also stored
+// lowercased and trimmed so analytics group them case-insensitively.
+func LogSearch(ctx context.Context, client *Neo4jClient, log *models.SearchLog) error {
+	log.ID = uuid.New().String()
+	log.CreatedAt = time.Now().UTC()
+
+	_, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			CREATE (:SearchQuery {
+				id: $id,
+				query: $query,
+				normalized: $normalized,
+				mode: $mode,
+				scope: $scope,
+				repoId: $repoId,
+				results: $results,
+				createdAt: $createdAt
+			})
+		`
+		_, err := tx.Run(ctx, query, map[string]any{
+			"id":         log.ID,
+			"query":      log.Query,
+			"normalized": normalizeSearchQuery(log.Query),
+			"mode":       log.Mode,
+			"scope":      log.Scope,
+			"repoId":     log.RepoID,
+			"results":    log.Results,
+			"createdAt":  log.CreatedAt,
+		})
+		return nil, err
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to log search: %w", err)
+	}
+	return nil
+}
+
+// LogSearchSelection records that the result at rank (1 for the first) of a
+// logged search was opened
+func LogSearchSelection(ctx context.Context, client *Neo4jClient, searchID, resultID string, rank int) error {
+	result, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (q:SearchQuery {id: $searchId})
+			CREATE (q)-[:SELECTED]->(:SearchSelection {
+				resultId: $resultId,
+				rank: $rank,
+				selectedAt: $selectedAt
+			})
+			RETURN count(q) AS logged
+		`
+		records, err := tx.Run(ctx, query, map[string]any{
+			"searchId":   searchID,
+			"resultId":   resultID,
+			"rank":       rank,
+			"selectedAt": time.Now().UTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		rec, err := records.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		logged, _ := rec.Get("logged")
+		return logged.(int64) > 0, nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to log search selection: %w", err)
+	}
+	if !result.(bool) {
+		return ErrSearchNotFound
+	}
+	return nil
+}
+
+// GetSearchAnalytics summarizes the searches logged since a time, of one
+// repository or, when repoID is empty, all searches. Lists hold at most
+// limit entries.
+func GetSearchAnalytics(ctx context.Context, client *Neo4jClient, since time.Time, repoID string, limit int) (*models.SearchAnalytics, error) {
+	params := map[string]any{"since": since.UTC(), "repoId": nil}
+	if repoID != "" {
+		params["repoId"] = repoID
+	}
+	match := `
+		MATCH (q:SearchQuery)
+		WHERE q.createdAt >= $since AND ($repoId IS NULL OR q.repoId = $repoId)
+	`
+
+	result, err := client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, match+`
+			OPTIONAL MATCH (q)-[:SELECTED]->(s:SearchSelection)
+			WITH q, count(s) AS selections
+			RETURN q.normalized AS query,
+			       count(q) AS searches,
+			       sum(CASE WHEN q.results = 0 THEN 1 ELSE 0 END) AS zeroResults,
+			       sum(CASE WHEN selections > 0 THEN 1 ELSE 0 END) AS selected,
+			       max(q.createdAt) AS lastSearchedAt
+		`, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read search queries: %w", err)
+		}
+		queries := []models.SearchQueryStats{}
+		for records.Next(ctx) {
+			rec := records.Record()
+			stats := models.SearchQueryStats{
+				Query:       recordString(rec, "query"),
+				Searches:    recordInt(rec, "searches"),
+				ZeroResults: recordInt(rec, "zeroResults"),
+				Selected:    recordInt(rec, "selected"),
+			}
+			if t, ok := recordValue(rec, "lastSearchedAt").(time.Time); ok {
+				stats.LastSearchedAt = t
+			}
+			queries = append(queries, stats)
+		}
+		if err := records.Err(); err != nil {
+			return nil, err
+		}
+
+		records, err = tx.Run(ctx, match+`
+			MATCH (q)-[:SELECTED]->(s:SearchSelection)
+			RETURN s.resultId AS resultId, count(s) AS selections, avg(s.rank) AS averageRank
+		`, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read search selections: %w", err)
+		}
+		selected := []models.SelectedResultStats{}
+		for records.Next(ctx) {
+			rec := records.Record()
+			stats := models.SelectedResultStats{
+				ResultID:   recordString(rec, "resultId"),
+				Selections: recordInt(rec, "selections"),
+			}
+			if rank, ok := recordValue(rec, "averageRank").(float64); ok {
+				stats.AverageRank = rank
+			}
+			selected = append(selected, stats)
+		}
+		if err := records.Err(); err != nil {
+			return nil, err
+		}
+
+		analytics := summarizeSearches(queries, selected, limit)
+		analytics.Since = since.UTC()
+		return analytics, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.(*models.SearchAnalytics), nil
+}
+
+// DeleteSearchLogs deletes the searches logged before a time and their
+// selections, returning how many searches were deleted
+func DeleteSearchLogs(ctx context.Context, client *Neo4jClient, before time.Time) (int64, error) {
+	result, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (q:SearchQuery)
+			WHERE q.createdAt < $before
+			OPTIONAL MATCH (q)-[:SELECTED]->(s:SearchSelection)
+			WITH q, collect(s) AS selections
+			FOREACH (s IN selections | DETACH DELETE s)
+			DETACH DELETE q
+			RETURN count(*) AS deleted
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"before": before.UTC()})
+		if err != nil {
+			return nil, err
+		}
+		rec, err := records.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		deleted, _ := rec.Get("deleted")
+		return deleted.(int64), nil
+	})
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete search logs: %w", err)
+	}
+	return result.(int64), nil
+}
+
+// summarizeSearches totals per-query statistics and ranks the most frequent
+// queries, the queries that most often found nothing and the results most
+// often opened, keeping limit of each
+func summarizeSearches(queries []models.SearchQueryStats, selected []models.SelectedResultStats, limit int) *models.SearchAnalytics {
+	analytics := &models.SearchAnalytics{
+		TopQueries:        []models.SearchQueryStats{},
+		ZeroResultQueries: []models.SearchQueryStats{},
+		TopResults:        selected,
+	}
+	for _, q := range queries {
+		analytics.Searches += q.Searches
+		analytics.ZeroResults += q.ZeroResults
+		analytics.Selected += q.Selected
+		if q.ZeroResults > 0 {
+			analytics.ZeroResultQueries = append(analytics.ZeroResultQueries, q)
+		}
+	}
+	analytics.TopQueries = append(analytics.TopQueries, queries...)
+
+	// Most frequent first, then most recent, then alphabetical
+	byCount := func(list []models.SearchQueryStats, count func(models.SearchQueryStats) int) {
+		sort.SliceStable(list, func(i, j int) bool {
+			a, b := list[i], list[j]
+			if count(a) != count(b) {
+				return count(a) > count(b)
+			}
+			if !a.LastSearchedAt.Equal(b.LastSearchedAt) {
+				return a.LastSearchedAt.After(b.LastSearchedAt)
+			}
+			return a.Query < b.Query
+		})
+	}
+	byCount(analytics.TopQueries, func(q models.SearchQueryStats) int { return q.Searches })
+	byCount(analytics.ZeroResultQueries, func(q models.SearchQueryStats) int { return q.ZeroResults })
+	sort.SliceStable(analytics.TopResults, func(i, j int) bool {
+		a, b := analytics.TopResults[i], analytics.TopResults[j]
+		if a.Selections != b.Selections {
+			return a.Selections > b.Selections
+		}
+		return a.ResultID < b.ResultID
+	})
+
+	analytics.TopQueries = analytics.TopQueries[:min(limit, len(analytics.TopQueries))]
+	analytics.ZeroResultQueries = analytics.ZeroResultQueries[:min(limit, len(analytics.ZeroResultQueries))]
+	analytics.TopResults = analytics.TopResults[:min(limit, len(analytics.TopResults))]
+	return analytics
+}
+
+// normalizeSearchQuery is the form queries are grouped by
+func normalizeSearchQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
+
+// recordInt reads an integer column, 0 when null
+func recordInt(rec *neo4j.Record, key string) int {
+	n, _ := recordValue(rec, key).(int64)
+	return int(n)
+}
+
+// recordValue reads a column, nil when the record has none
+func recordValue(rec *neo4j.Record, key string) any {
+	v, _ := rec.Get(key)
+	return v
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSummarizeSearches tests ranking logged queries and opened results
+func TestSummarizeSearches(t *testing.T) {
+	earlier := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+
+	queries := []models.SearchQueryStats{
+		{Query: "parse config", Searches: 2, Selected: 2, LastSearchedAt: earlier},
+		{Query: "retry backoff", Searches: 5, ZeroResults: 1, Selected: 3, LastSearchedAt: earlier},
+		{Query: "kafka consumer", Searches: 2, ZeroResults: 2, LastSearchedAt: later},
+	}
+	selected := []models.SelectedResultStats{
+		{ResultID: "b", Selections: 1, AverageRank: 3},
+		{ResultID: "a", Selections: 4, AverageRank: 1.5},
+	}
+
+	analytics := summarizeSearches(queries, selected, 2)
+	assert.Equal(t, 9, analytics.Searches)
+	assert.Equal(t, 3, analytics.ZeroResults)
+	assert.Equal(t, 5, analytics.Selected)
+
+	// Ties go to the most recent query
+	assert.Len(t, analytics.TopQueries, 2)
+	assert.Equal(t, "retry backoff", analytics.TopQueries[0].Query)
+	assert.Equal(t, "kafka consumer", analytics.TopQueries[1].Query)
+
+	assert.Len(t, analytics.ZeroResultQueries, 2)
+	assert.Equal(t, "kafka consumer", analytics.ZeroResultQueries[0].Query)
+	assert.Equal(t, "retry backoff", analytics.ZeroResultQueries[1].Query)
+
+	assert.Equal(t, "a", analytics.TopResults[0].ResultID)
+}
+
+// TestSummarizeSearchesEmpty tests a period without searches
+func TestSummarizeSearchesEmpty(t *testing.T) {
+	analytics := summarizeSearches(nil, []models.SelectedResultStats{}, 10)
+	assert.Zero(t, analytics.Searches)
+	assert.NotNil(t, analytics.TopQueries)
+	assert.NotNil(t, analytics.ZeroResultQueries)
+	assert.NotNil(t, analytics.TopResults)
+}
+
+// TestNormalizeSearchQuery tests grouping queries regardless of case and
+// spacing
+func TestNormalizeSearchQuery(t *testing.T) {
+	assert.Equal(t, "parse config", normalizeSearchQuery("  Parse   CONFIG "))
+}
@@ -0,0 +1,556 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// SnapshotVersion is the snapshot format ExportSnapshot writes and
+// ImportSnapshot reads
+const SnapshotVersion = 1
+
+// importBatchSize bounds how many nodes or relationships are written per
+// transaction
+const importBatchSize = 500
+
+// ErrInvalidSnapshot is returned for input that is not a snapshot this
+// version can import
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+// snapshotLabels are the labels of the code graph and history nodes a
+// snapshot carries, all of which have a repoId. Package only ever labels
+// Directory nodes.
+var snapshotLabels = []string{"Directory", "Package", "File", "Function", "Method", "Class", "Variable", "Commit", "Author"}
+
+// relationshipType matches relationship types a snapshot may create
+var relationshipType = regexp.MustCompile(`^[A-Z][A-Z_]*$`)
+
+// SnapshotHeader is the first record of a snapshot
+type SnapshotHeader struct {
+	Kind       string            `json:"kind"` // "snapshot"
+	Version    int               `json:"version"`
+	Repository models.Repository `json:"repository"`
+}
+
+// SnapshotNode is a node of a repository's graph in a snapshot
+type SnapshotNode struct {
+	Kind   string         `json:"kind"` // "node"
+	Key    string         `json:"key"`  // identifies the node within the snapshot
+	Labels []string       `json:"labels"`
+	Props  map[string]any `json:"props"`
+	// Types of the props JSON does not keep: float, float[] or datetime
+	Types map[string]string `json:"types,omitempty"`
+}
+
+// SnapshotRelationship is a relationship between two snapshot nodes, or
+// from the repository when Start is empty
+type SnapshotRelationship struct {
+	Kind  string            `json:"kind"` // "relationship"
+	Type  string            `json:"type"`
+	Start string            `json:"start"`
+	End   string            `json:"end"`
+	Props map[string]any    `json:"props,omitempty"`
+	Types map[string]string `json:"types,omitempty"`
+}
+
+// ExportSnapshot passes a header describing the repository to emit, then
+// every node of its code graph and history, then the relationships between
+// them and from the repository. Records are read in batches like
+// ExportEmbeddings does.
+func (r *GraphReader) ExportSnapshot(ctx context.Context, repo *models.Repository, emit func(record any) error) error {
+	if err := emit(SnapshotHeader{Kind: "snapshot", Version: SnapshotVersion, Repository: *repo}); err != nil {
+		return err
+	}
+
+	ctx = WithRepository(ctx, repo.ID)
+	key := r.client.dialect.elementID
+	labels := strings.Join(snapshotLabels, "|")
+
+	nodeQuery := `
+		MATCH (n:` + labels + ` {repoId: $repoId})
+		RETURN ` + key("n") + ` AS key, labels(n) AS labels, properties(n) AS props
+		ORDER BY key
+		SKIP $skip LIMIT $limit
+	`
+	err := r.exportBatches(ctx, repo.ID, nodeQuery, emit, func(rec *neo4j.Record) any {
+		props, _ := rec.Get("props")
+		node := SnapshotNode{Kind: "node", Key: recordString(rec, "key"), Labels: recordStrings(rec, "labels")}
+		node.Props, node.Types = snapshotProps(props.(map[string]any))
+		return node
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export nodes: %w", err)
+	}
+
+	relationships := func(rec *neo4j.Record) any {
+		props, _ := rec.Get("props")
+		rel := SnapshotRelationship{
+			Kind:  "relationship",
+			Type:  recordString(rec, "type"),
+			Start: recordString(rec, "start"),
+			End:   recordString(rec, "end"),
+		}
+		rel.Props, rel.Types = snapshotProps(props.(map[string]any))
+		return rel
+	}
+	repoQuery := `
+		MATCH (:Repository {id: $repoId})-[e]->(b:` + labels + ` {repoId: $repoId})
+		RETURN '' AS start, ` + key("b") + ` AS end, type(e) AS type, properties(e) AS props
+		ORDER BY ` + key("e") + `
+		SKIP $skip LIMIT $limit
+	`
+	if err := r.exportBatches(ctx, repo.ID, repoQuery, emit, relationships); err != nil {
+		return fmt.Errorf("failed to export relationships: %w", err)
+	}
+	relQuery := `
+		MATCH (a:` + labels + ` {repoId: $repoId})-[e]->(b:` + labels + ` {repoId: $repoId})
+		RETURN ` + key("a") + ` AS start, ` + key("b") + ` AS end, type(e) AS type, properties(e) AS props
+		ORDER BY ` + key("e") + `
+		SKIP $skip LIMIT $limit
+	`
+	if err := r.exportBatches(ctx, repo.ID, relQuery, emit, relationships); err != nil {
+		return fmt.Errorf("failed to export relationships: %w", err)
+	}
+	return nil
+}
+
+// snapshotProps prepares node or relationship properties for JSON, noting
+// the types JSON would lose
+func snapshotProps(props map[string]any) (map[string]any, map[string]string) {
+	var types map[string]string
+	setType := func(name, kind string) {
+		if types == nil {
+			types = make(map[string]string)
+		}
+		types[name] = kind
+	}
+	out := make(map[string]any, len(props))
+	for name, value := range props {
+		switch v := value.(type) {
+		case time.Time:
+			out[name] = v.Format(time.RFC3339Nano)
+			setType(name, "datetime")
+			continue
+		case float64:
+			setType(name, "float")
+		case []any:
+			if len(v) > 0 {
+				if _, ok := v[0].(float64); ok {
+					setType(name, "float[]")
+				}
+			}
+		}
+		out[name] = value
+	}
+	return out, types
+}
+
+// SnapshotReader reads a snapshot record by record
+type SnapshotReader struct {
+	Header SnapshotHeader
+	dec    *json.Decoder
+}
+
+// NewSnapshotReader reads and checks the header of a snapshot
+func NewSnapshotReader(r io.Reader) (*SnapshotReader, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	s := &SnapshotReader{dec: dec}
+	if err := dec.Decode(&s.Header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	if s.Header.Kind != "snapshot" {
+		return nil, fmt.Errorf("%w: missing snapshot header", ErrInvalidSnapshot)
+	}
+	if s.Header.Version != SnapshotVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d, expected %d", ErrInvalidSnapshot, s.Header.Version, SnapshotVersion)
+	}
+	return s, nil
+}
+
+// next returns the next node or relationship, or io.EOF after the last
+func (s *SnapshotReader) next() (any, error) {
+	var raw json.RawMessage
+	if err := s.dec.Decode(&raw); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+
+	var kind struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(raw, &kind); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	switch kind.Kind {
+	case "node":
+		var node SnapshotNode
+		if err := dec.Decode(&node); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+		}
+		props, err := restoreProps(node.Props, node.Types)
+		node.Props = props
+		return &node, err
+	case "relationship":
+		var rel SnapshotRelationship
+		if err := dec.Decode(&rel); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+		}
+		props, err := restoreProps(rel.Props, rel.Types)
+		rel.Props = props
+		return &rel, err
+	default:
+		return nil, fmt.Errorf("%w: unknown record kind %q", ErrInvalidSnapshot, kind.Kind)
+	}
+}
+
+// restoreProps turns decoded JSON back into property values: numbers are
+// integers unless typed float, lists hold a single type
+func restoreProps(props map[string]any, types map[string]string) (map[string]any, error) {
+	out := make(map[string]any, len(props))
+	for name, value := range props {
+		kind := types[name]
+		switch v := value.(type) {
+		case nil:
+			continue
+		case json.Number:
+			n, err := restoreNumber(v, kind == "float")
+			if err != nil {
+				return nil, fmt.Errorf("%w: property %s: %v", ErrInvalidSnapshot, name, err)
+			}
+			out[name] = n
+		case string:
+			if kind != "datetime" {
+				out[name] = v
+				continue
+			}
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return nil, fmt.Errorf("%w: property %s: %v", ErrInvalidSnapshot, name, err)
+			}
+			out[name] = t
+		case []any:
+			list, err := restoreList(v, kind == "float[]")
+			if err != nil {
+				return nil, fmt.Errorf("%w: property %s: %v", ErrInvalidSnapshot, name, err)
+			}
+			out[name] = list
+		case bool:
+			out[name] = v
+		default:
+			return nil, fmt.Errorf("%w: property %s is not a scalar or list", ErrInvalidSnapshot, name)
+		}
+	}
+	return out, nil
+}
+
+func restoreNumber(n json.Number, float bool) (any, error) {
+	if !float {
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+	}
+	return n.Float64()
+}
+
+func restoreList(list []any, float bool) (any, error) {
+	if len(list) == 0 {
+		return []string{}, nil
+	}
+	switch list[0].(type) {
+	case json.Number:
+		if float {
+			out := make([]float64, len(list))
+			for i, item := range list {
+				n, ok := item.(json.Number)
+				if !ok {
+					return nil, errors.New("mixed list")
+				}
+				f, err := n.Float64()
+				if err != nil {
+					return nil, err
+				}
+				out[i] = f
+			}
+			return out, nil
+		}
+		out := make([]int64, len(list))
+		for i, item := range list {
+			n, ok := item.(json.Number)
+			if !ok {
+				return nil, errors.New("mixed list")
+			}
+			v, err := n.Int64()
+			if err != nil {
+				return nil, err
+			}
+			out[i] = v
+		}
+		return out, nil
+	case string:
+		out := make([]string, len(list))
+		for i, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return nil, errors.New("mixed list")
+			}
+			out[i] = s
+		}
+		return out, nil
+	case bool:
+		out := make([]bool, len(list))
+		for i, item := range list {
+			b, ok := item.(bool)
+			if !ok {
+				return nil, errors.New("mixed list")
+			}
+			out[i] = b
+		}
+		return out, nil
+	}
+	return nil, errors.New("unsupported list")
+}
+
+// SnapshotImport counts what a snapshot import wrote
+type SnapshotImport struct {
+	Nodes         int `json:"nodes"`
+	Relationships int `json:"relationships"`
+}
+
+// ImportSnapshot writes the nodes and relationships of a snapshot into the
+// graph of repoID, a repository created for the import. Nodes get new IDs
+// so a snapshot can be imported next to the repository it came from.
+func (w *GraphWriter) ImportSnapshot(ctx context.Context, repoID string, snapshot *SnapshotReader) (*SnapshotImport, error) {
+	ctx = WithRepository(ctx, repoID)
+	imp := &snapshotImport{
+		w:      w,
+		repoID: repoID,
+		labels: make(map[string]string),
+		nodes:  make(map[string][]map[string]any),
+		rels:   make(map[relationshipBatchKey][]map[string]any),
+	}
+
+	for {
+		record, err := snapshot.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch r := record.(type) {
+		case *SnapshotNode:
+			err = imp.addNode(ctx, r)
+		case *SnapshotRelationship:
+			err = imp.addRelationship(ctx, r)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := imp.flush(ctx); err != nil {
+		return nil, err
+	}
+
+	// The keys only served to match relationship ends
+	for _, label := range snapshotLabels {
+		if label == "Package" {
+			continue
+		}
+		_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			query := `
+				MATCH (n:` + label + ` {repoId: $repoId})
+				WHERE n._snapshotKey IS NOT NULL
+				REMOVE n._snapshotKey
+			`
+			_, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+			return nil, err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to finish import: %w", err)
+		}
+	}
+	return &imp.stats, nil
+}
+
+// relationshipBatchKey groups relationships written by one query
+type relationshipBatchKey struct {
+	relType    string
+	startLabel string // "" for the repository
+	endLabel   string
+}
+
+// snapshotImport batches the records of a snapshot being imported
+type snapshotImport struct {
+	w      *GraphWriter
+	repoID string
+	labels map[string]string           // node key -> label to match it by
+	nodes  map[string][]map[string]any // label list -> node rows
+	rels   map[relationshipBatchKey][]map[string]any
+	stats  SnapshotImport
+}
+
+func (s *snapshotImport) addNode(ctx context.Context, node *SnapshotNode) error {
+	if node.Key == "" || len(node.Labels) == 0 {
+		return fmt.Errorf("%w: node without key or labels", ErrInvalidSnapshot)
+	}
+	if _, ok := s.labels[node.Key]; ok {
+		return fmt.Errorf("%w: duplicate node %s", ErrInvalidSnapshot, node.Key)
+	}
+	if len(s.rels) > 0 || s.stats.Relationships > 0 {
+		return fmt.Errorf("%w: node %s after relationships", ErrInvalidSnapshot, node.Key)
+	}
+
+	matchLabel := ""
+	for _, label := range node.Labels {
+		if !isSnapshotLabel(label) {
+			return fmt.Errorf("%w: unsupported label %q", ErrInvalidSnapshot, label)
+		}
+		if matchLabel == "" && label != "Package" {
+			matchLabel = label
+		}
+	}
+	if matchLabel == "" {
+		return fmt.Errorf("%w: node %s has only the Package label", ErrInvalidSnapshot, node.Key)
+	}
+	s.labels[node.Key] = matchLabel
+
+	props := node.Props
+	if _, ok := props["id"].(string); ok {
+		props["id"] = uuid.New().String()
+	}
+	props["repoId"] = s.repoID
+	props["_snapshotKey"] = node.Key
+
+	labels := strings.Join(node.Labels, ":")
+	s.nodes[labels] = append(s.nodes[labels], props)
+	if len(s.nodes[labels]) >= importBatchSize {
+		return s.writeNodes(ctx, labels)
+	}
+	return nil
+}
+
+func (s *snapshotImport) addRelationship(ctx context.Context, rel *SnapshotRelationship) error {
+	if !relationshipType.MatchString(rel.Type) {
+		return fmt.Errorf("%w: unsupported relationship type %q", ErrInvalidSnapshot, rel.Type)
+	}
+	// Every node comes before the first relationship
+	for labels := range s.nodes {
+		if err := s.writeNodes(ctx, labels); err != nil {
+			return err
+		}
+	}
+
+	key := relationshipBatchKey{relType: rel.Type}
+	var ok bool
+	if rel.Start != "" {
+		if key.startLabel, ok = s.labels[rel.Start]; !ok {
+			return fmt.Errorf("%w: relationship from unknown node %s", ErrInvalidSnapshot, rel.Start)
+		}
+	}
+	if key.endLabel, ok = s.labels[rel.End]; !ok {
+		return fmt.Errorf("%w: relationship to unknown node %s", ErrInvalidSnapshot, rel.End)
+	}
+
+	props := rel.Props
+	if props == nil {
+		props = map[string]any{}
+	}
+	s.rels[key] = append(s.rels[key], map[string]any{"start": rel.Start, "end": rel.End, "props": props})
+	if len(s.rels[key]) >= importBatchSize {
+		return s.writeRelationships(ctx, key)
+	}
+	return nil
+}
+
+func (s *snapshotImport) flush(ctx context.Context) error {
+	for labels := range s.nodes {
+		if err := s.writeNodes(ctx, labels); err != nil {
+			return err
+		}
+	}
+	for key := range s.rels {
+		if err := s.writeRelationships(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeNodes creates the batched nodes with the given labels. Labels were
+// checked against snapshotLabels.
+func (s *snapshotImport) writeNodes(ctx context.Context, labels string) error {
+	rows := s.nodes[labels]
+	delete(s.nodes, labels)
+	if len(rows) == 0 {
+		return nil
+	}
+	_, err := s.w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			UNWIND $rows AS row
+			CREATE (n:` + labels + `)
+			SET n = row
+		`
+		_, err := tx.Run(ctx, query, map[string]any{"rows": rows})
+		return nil, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import %s nodes: %w", labels, err)
+	}
+	s.stats.Nodes += len(rows)
+	return nil
+}
+
+// writeRelationships creates a batch of relationships. Types were checked
+// against relationshipType and labels against snapshotLabels.
+func (s *snapshotImport) writeRelationships(ctx context.Context, key relationshipBatchKey) error {
+	rows := s.rels[key]
+	delete(s.rels, key)
+	if len(rows) == 0 {
+		return nil
+	}
+	start := `MATCH (a:Repository {id: $repoId})`
+	if key.startLabel != "" {
+		start = `MATCH (a:` + key.startLabel + ` {repoId: $repoId, _snapshotKey: row.start})`
+	}
+	_, err := s.w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			UNWIND $rows AS row
+			` + start + `
+			MATCH (b:` + key.endLabel + ` {repoId: $repoId, _snapshotKey: row.end})
+			CREATE (a)-[e:` + key.relType + `]->(b)
+			SET e = row.props
+		`
+		_, err := tx.Run(ctx, query, map[string]any{"repoId": s.repoID, "rows": rows})
+		return nil, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import %s relationships: %w", key.relType, err)
+	}
+	s.stats.Relationships += len(rows)
+	return nil
+}
+
+func isSnapshotLabel(label string) bool {
+	for _, l := range snapshotLabels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSnapshotPropsRoundTrip tests that property types survive JSON
+func TestSnapshotPropsRoundTrip(t *testing.T) {
+	observed := time.Date(2026, 3, 4, 5, 6, 7, 8, time.UTC)
+	props := map[string]any{
+		"name":      "main",
+		"startLine": int64(3),
+		"pageRank":  1.0,
+		"embedding": []any{0.5, 1.0, -2.0},
+		"imports":   []any{"fmt", "os"},
+		"lines":     []any{int64(1), int64(2)},
+		"exported":  true,
+		"time":      observed,
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	require.NoError(t, enc.Encode(SnapshotHeader{Kind: "snapshot", Version: SnapshotVersion, Repository: models.Repository{Name: "app"}}))
+	node := SnapshotNode{Kind: "node", Key: "4:abc:1", Labels: []string{"Function"}}
+	node.Props, node.Types = snapshotProps(props)
+	require.NoError(t, enc.Encode(node))
+	rel := SnapshotRelationship{Kind: "relationship", Type: "CALLS", Start: "4:abc:1", End: "4:abc:1"}
+	rel.Props, rel.Types = snapshotProps(map[string]any{"line": int64(7)})
+	require.NoError(t, enc.Encode(rel))
+
+	reader, err := NewSnapshotReader(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, "app", reader.Header.Repository.Name)
+
+	record, err := reader.next()
+	require.NoError(t, err)
+	got := record.(*SnapshotNode)
+	assert.Equal(t, []string{"Function"}, got.Labels)
+	assert.Equal(t, map[string]any{
+		"name":      "main",
+		"startLine": int64(3),
+		"pageRank":  1.0,
+		"embedding": []float64{0.5, 1.0, -2.0},
+		"imports":   []string{"fmt", "os"},
+		"lines":     []int64{1, 2},
+		"exported":  true,
+		"time":      observed,
+	}, got.Props)
+
+	record, err = reader.next()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"line": int64(7)}, record.(*SnapshotRelationship).Props)
+
+	_, err = reader.next()
+	assert.Equal(t, io.EOF, err)
+}
+
+// TestSnapshotReaderRejects tests rejecting what is not a snapshot
+func TestSnapshotReaderRejects(t *testing.T) {
+	for _, input := range []string{
+		``,
+		`{"kind":"node"}`,
+		`{"kind":"snapshot","version":99}`,
+		`not json`,
+	} {
+		_, err := NewSnapshotReader(strings.NewReader(input))
+		assert.ErrorIs(t, err, ErrInvalidSnapshot, input)
+	}
+
+	reader, err := NewSnapshotReader(strings.NewReader(`{"kind":"snapshot","version":1}
+{"kind":"index"}`))
+	require.NoError(t, err)
+	_, err = reader.next()
+	assert.ErrorIs(t, err, ErrInvalidSnapshot)
+}
+
+// TestSnapshotImportChecks tests the checks made before anything is written
+func TestSnapshotImportChecks(t *testing.T) {
+	imp := &snapshotImport{
+		labels: make(map[string]string),
+		nodes:  make(map[string][]map[string]any),
+		rels:   make(map[relationshipBatchKey][]map[string]any),
+	}
+	ctx := t.Context()
+
+	err := imp.addNode(ctx, &SnapshotNode{Key: "1", Labels: []string{"Wiki"}, Props: map[string]any{}})
+	assert.ErrorIs(t, err, ErrInvalidSnapshot)
+
+	props := map[string]any{"id": "old", "path": "pkg"}
+	require.NoError(t, imp.addNode(ctx, &SnapshotNode{Key: "1", Labels: []string{"Package", "Directory"}, Props: props}))
+	assert.Equal(t, "Directory", imp.labels["1"])
+	assert.NotEqual(t, "old", props["id"])
+	assert.Equal(t, "1", props["_snapshotKey"])
+
+	err = imp.addNode(ctx, &SnapshotNode{Key: "1", Labels: []string{"File"}, Props: map[string]any{}})
+	assert.ErrorIs(t, err, ErrInvalidSnapshot)
+
+	err = imp.addRelationship(ctx, &SnapshotRelationship{Type: "CALLS`]->() DETACH DELETE (", Start: "1", End: "1"})
+	assert.ErrorIs(t, err, ErrInvalidSnapshot)
+}
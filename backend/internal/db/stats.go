@@ -0,0 +1,253 @@
+package db
+
+import (
+	"context"
+	"path"
+	"sort"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// RepositoryStats summarizes the indexed graph of a repository
+type RepositoryStats struct {
+	Files       int            `json:"files"`
+	Functions   int            `json:"functions"`
+	Methods     int            `json:"methods"`
+	Classes     int            `json:"classes"`
+	Variables   int            `json:"variables"` // package-level variables
+	Languages   map[string]int `json:"languages"` // language -> file count
+	EntryPoints []EntryPoint   `json:"entryPoints"`
+	Findings    map[string]int `json:"findings"` // level -> static analysis findings
+
+	// Line counts cover files indexed since lines were recorded
+	Lines            int            `json:"lines"`
+	LanguageLines    map[string]int `json:"languageLines"`    // language -> lines
+	AvgFunctionLines float64        `json:"avgFunctionLines"` // over functions and methods
+	TopPackages      []PackageStats `json:"topPackages"`      // largest by lines
+	// of the latest completed index run
+	IndexDurationSeconds float64 `json:"indexDurationSeconds,omitempty"`
+}
+
+// PackageStats sizes a directory of source files
+type PackageStats struct {
+	Path      string `json:"path"`
+	Files     int    `json:"files"`
+	Lines     int    `json:"lines"`
+	Functions int    `json:"functions"` // functions and methods
+}
+
+// fileSize is what a file adds to the stats
+type fileSize struct {
+	path          string
+	language      string
+	lines         int
+	functions     int
+	functionLines int
+}
+
+// EntryPoint is a function where execution likely starts
+type EntryPoint struct {
+	Name     string `json:"name"`
+	FilePath string `json:"filePath"`
+}
+
+// maxEntryPoints caps the entry points returned in stats
+const maxEntryPoints = 10
+
+// maxTopPackages caps the packages returned in stats
+const maxTopPackages = 10
+
+// GetRepositoryStats returns file, entity and language counts, line counts
+// by language and package, likely entry points (main functions and functions
+// in main/index/app/server files) and how long the last index took
+func (r *GraphReader) GetRepositoryStats(ctx context.Context, repoID string) (*RepositoryStats, error) {
+	ctx = WithRepository(ctx, repoID)
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		stats := &RepositoryStats{
+			Languages:     make(map[string]int),
+			EntryPoints:   []EntryPoint{},
+			Findings:      make(map[string]int),
+			LanguageLines: make(map[string]int),
+			TopPackages:   []PackageStats{},
+		}
+
+		records, err := tx.Run(ctx, `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(f:File)
+			RETURN f.language AS language, count(f) AS files
+		`, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+		for records.Next(ctx) {
+			rec := records.Record()
+			language, _ := rec.Get("language")
+			files, _ := rec.Get("files")
+			if lang, ok := language.(string); ok {
+				stats.Languages[lang] = int(files.(int64))
+			}
+			stats.Files += int(files.(int64))
+		}
+		if err := records.Err(); err != nil {
+			return nil, err
+		}
+
+		records, err = tx.Run(ctx, `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(:File)-[:DECLARES]->(e)
+			RETURN
+			  count(CASE WHEN e:Function THEN 1 END) AS functions,
+			  count(CASE WHEN e:Method THEN 1 END) AS methods,
+			  count(CASE WHEN e:Class THEN 1 END) AS classes,
+			  count(CASE WHEN e:Variable THEN 1 END) AS variables
+		`, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+		if records.Next(ctx) {
+			rec := records.Record()
+			functions, _ := rec.Get("functions")
+			methods, _ := rec.Get("methods")
+			classes, _ := rec.Get("classes")
+			variables, _ := rec.Get("variables")
+			stats.Functions = int(functions.(int64))
+			stats.Methods = int(methods.(int64))
+			stats.Classes = int(classes.(int64))
+			stats.Variables = int(variables.(int64))
+		}
+		if err := records.Err(); err != nil {
+			return nil, err
+		}
+
+		records, err = tx.Run(ctx, `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(f:File)
+			OPTIONAL MATCH (f)-[:DECLARES]->(e:Function|Method)
+			RETURN f.path AS path, f.language AS language, f.lines AS lines,
+			       count(e) AS functions, sum(e.endLine - e.startLine + 1) AS functionLines
+		`, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+		var files []fileSize
+		for records.Next(ctx) {
+			rec := records.Record()
+			file := fileSize{path: recordString(rec, "path"), language: recordString(rec, "language")}
+			if v, _ := rec.Get("lines"); v != nil {
+				file.lines = int(v.(int64))
+			}
+			if v, _ := rec.Get("functions"); v != nil {
+				file.functions = int(v.(int64))
+			}
+			if v, _ := rec.Get("functionLines"); v != nil {
+				file.functionLines = int(v.(int64))
+			}
+			files = append(files, file)
+		}
+		if err := records.Err(); err != nil {
+			return nil, err
+		}
+		stats.addFileSizes(files)
+
+		records, err = tx.Run(ctx, `
+			MATCH (r:Repository {id: $repoId})-[:HAS_FINDING]->(f:Finding)
+			RETURN f.level AS level, count(f) AS findings
+		`, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+		for records.Next(ctx) {
+			rec := records.Record()
+			level, _ := rec.Get("level")
+			count, _ := rec.Get("findings")
+			if l, ok := level.(string); ok {
+				stats.Findings[l] = int(count.(int64))
+			}
+		}
+		if err := records.Err(); err != nil {
+			return nil, err
+		}
+
+		records, err = tx.Run(ctx, `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(f:File)-[:DECLARES]->(fn:Function)
+			WHERE fn.name IN ['main', 'Main']
+			   OR (fn.name IN ['run', 'start', 'serve', 'app', 'cli']
+			       AND f.path =~ '(.*/)?(main|index|app|server|cli|__main__)\\.[a-z]+')
+			RETURN fn.name AS name, f.path AS filePath
+			ORDER BY CASE WHEN fn.name IN ['main', 'Main'] THEN 0 ELSE 1 END, size(f.path), f.path
+			LIMIT $limit
+		`, map[string]any{"repoId": repoID, "limit": maxEntryPoints})
+		if err != nil {
+			return nil, err
+		}
+		for records.Next(ctx) {
+			rec := records.Record()
+			name, _ := rec.Get("name")
+			filePath, _ := rec.Get("filePath")
+			stats.EntryPoints = append(stats.EntryPoints, EntryPoint{
+				Name:     name.(string),
+				FilePath: filePath.(string),
+			})
+		}
+		return stats, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	stats := result.(*RepositoryStats)
+	runs, err := ListIndexRuns(catalog(ctx), r.client, repoID, 10)
+	if err != nil {
+		return nil, err
+	}
+	for _, run := range runs {
+		if run.Status == "ready" && !run.FinishedAt.IsZero() {
+			stats.IndexDurationSeconds = run.FinishedAt.Sub(run.StartedAt).Seconds()
+			break
+		}
+	}
+	return stats, nil
+}
+
+// addFileSizes sums the lines of the files by language and directory and
+// averages the length of their functions
+func (s *RepositoryStats) addFileSizes(files []fileSize) {
+	packages := make(map[string]*PackageStats)
+	functions, functionLines := 0, 0
+	for _, file := range files {
+		s.Lines += file.lines
+		if file.language != "" {
+			s.LanguageLines[file.language] += file.lines
+		}
+		functions += file.functions
+		functionLines += file.functionLines
+
+		dir := path.Dir(file.path)
+		pkg, ok := packages[dir]
+		if !ok {
+			pkg = &PackageStats{Path: dir}
+			packages[dir] = pkg
+		}
+		pkg.Files++
+		pkg.Lines += file.lines
+		pkg.Functions += file.functions
+	}
+	if functions > 0 {
+		s.AvgFunctionLines = float64(functionLines) / float64(functions)
+	}
+
+	for _, pkg := range packages {
+		s.TopPackages = append(s.TopPackages, *pkg)
+	}
+	sort.Slice(s.TopPackages, func(i, j int) bool {
+		a, b := s.TopPackages[i], s.TopPackages[j]
+		if a.Lines != b.Lines {
+			return a.Lines > b.Lines
+		}
+		if a.Functions != b.Functions {
+			return a.Functions > b.Functions
+		}
+		return a.Path < b.Path
+	})
+	if len(s.TopPackages) > maxTopPackages {
+		s.TopPackages = s.TopPackages[:maxTopPackages]
+	}
+}
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddFileSizes(t *testing.T) {
+	stats := &RepositoryStats{LanguageLines: make(map[string]int), TopPackages: []PackageStats{}}
+	stats.addFileSizes([]fileSize{
+		{path: "main.go", language: "go", lines: 40, functions: 1, functionLines: 10},
+		{path: "internal/db/reader.go", language: "go", lines: 300, functions: 4, functionLines: 200},
+		{path: "internal/db/writer.go", language: "go", lines: 200, functions: 3, functionLines: 90},
+		{path: "web/app.ts", language: "typescript", lines: 120, functions: 2, functionLines: 60},
+		// indexed before line counts were recorded
+		{path: "web/old.ts", language: "typescript", functions: 0},
+	})
+
+	assert.Equal(t, 660, stats.Lines)
+	assert.Equal(t, map[string]int{"go": 540, "typescript": 120}, stats.LanguageLines)
+	assert.InDelta(t, 36.0, stats.AvgFunctionLines, 1e-9)
+	assert.Equal(t, []PackageStats{
+		{Path: "internal/db", Files: 2, Lines: 500, Functions: 7},
+		{Path: "web", Files: 2, Lines: 120, Functions: 2},
+		{Path: ".", Files: 1, Lines: 40, Functions: 1},
+	}, stats.TopPackages)
+}
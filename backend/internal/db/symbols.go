@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Symbol is a function, method or class to jump to
+type Symbol struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	QualifiedName string `json:"qualifiedName"` // methods are prefixed with their class
+	Type          string `json:"type"`          // "Class", "Function" or "Method"
+	FilePath      string `json:"filePath"`
+	StartLine     int    `json:"startLine"`
+}
+
+// SymbolMatch is a symbol matching a go-to-symbol query
+type SymbolMatch struct {
+	Symbol
+	Score int `json:"score"`
+	// rune indexes into QualifiedName of the matched characters
+	Positions []int `json:"positions"`
+}
+
+// ListSymbols returns the functions, methods and classes of a repository
+func (r *GraphReader) ListSymbols(ctx context.Context, repoID string) ([]Symbol, error) {
+	ctx = WithRepository(ctx, repoID)
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(:File)-[:DECLARES]->(e:Function|Method|Class)
+			RETURN e.id AS id, e.name AS name, e.className AS className, labels(e) AS labels,
+			       e.filePath AS filePath, e.startLine AS startLine
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+
+		symbols := []Symbol{}
+		for records.Next(ctx) {
+			rec := records.Record()
+			symbol := Symbol{
+				ID:       recordString(rec, "id"),
+				Name:     recordString(rec, "name"),
+				FilePath: recordString(rec, "filePath"),
+				Type:     "Function",
+			}
+			for _, label := range recordStrings(rec, "labels") {
+				if label == "Method" || label == "Class" {
+					symbol.Type = label
+				}
+			}
+			symbol.QualifiedName = symbol.Name
+			if className := recordString(rec, "className"); className != "" && symbol.Type == "Method" {
+				symbol.QualifiedName = className + "." + symbol.Name
+			}
+			if v, _ := rec.Get("startLine"); v != nil {
+				symbol.StartLine = int(v.(int64))
+			}
+			symbols = append(symbols, symbol)
+		}
+		return symbols, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]Symbol), nil
+}
+
+// MatchSymbols ranks the symbols whose qualified names fuzzily match query,
+// see analysis.RankFuzzy, returning at most limit
+func MatchSymbols(symbols []Symbol, query string, limit int) []SymbolMatch {
+	names := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		names[i] = symbol.QualifiedName
+	}
+
+	matches := []SymbolMatch{}
+	for _, match := range analysis.RankFuzzy(query, names, limit) {
+		matches = append(matches, SymbolMatch{
+			Symbol:    symbols[match.Index],
+			Score:     match.Score,
+			Positions: match.Positions,
+		})
+	}
+	return matches
+}
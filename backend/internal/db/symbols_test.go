@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchSymbols(t *testing.T) {
+	symbols := []Symbol{
+		{ID: "1", Name: "GetGraph", QualifiedName: "GraphReader.GetGraph", Type: "Method"},
+		{ID: "2", Name: "GraphReader", QualifiedName: "GraphReader", Type: "Class"},
+		{ID: "3", Name: "NewGraphWriter", QualifiedName: "NewGraphWriter", Type: "Function"},
+	}
+
+	matches := MatchSymbols(symbols, "grphRdr", 10)
+	if assert.Len(t, matches, 2) {
+		assert.Equal(t, "2", matches[0].ID, "the class is the shorter name")
+		assert.Equal(t, "1", matches[1].ID)
+		assert.Equal(t, []int{0, 1, 3, 4, 5, 8, 10}, matches[0].Positions)
+	}
+
+	assert.Empty(t, MatchSymbols(symbols, "xyz", 10))
+	assert.Len(t, MatchSymbols(symbols, "g", 1), 1)
+}
+
+func TestGraphReader_ListSymbols_NestedFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	ctx := context.Background()
+	client := setupTestNeo4j(t)
+	defer client.Close()
+
+	repoID := setupTestRepository(t, ctx, client)
+	defer cleanupTestRepository(t, ctx, client, repoID)
+
+	// A file under a directory, as the indexer writes every file not at
+	// the repository root
+	_, err := client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})
+			CREATE (d:Directory {id: 'dir1', repoId: $repoId, path: 'internal/db'})
+			CREATE (f:File {id: 'file3', repoId: $repoId, path: 'internal/db/symbols.go', language: 'go'})
+			CREATE (fn:Function {id: 'fn3', name: 'ListSymbols', filePath: 'internal/db/symbols.go', startLine: 29, repoId: $repoId})
+			CREATE (r)-[:CONTAINS]->(d)-[:CONTAINS]->(f)-[:DECLARES]->(fn)
+		`
+		_, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		return nil, err
+	})
+	require.NoError(t, err)
+	defer client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		_, err := tx.Run(ctx, `MATCH (n {repoId: $repoId}) WHERE n.id IN ['dir1', 'file3', 'fn3'] DETACH DELETE n`,
+			map[string]any{"repoId": repoID})
+		return nil, err
+	})
+
+	symbols, err := NewGraphReader(client).ListSymbols(ctx, repoID)
+	require.NoError(t, err)
+
+	paths := map[string]string{}
+	for _, symbol := range symbols {
+		paths[symbol.Name] = symbol.FilePath
+	}
+	assert.Equal(t, "main.go", paths["main"], "root files are listed")
+	assert.Equal(t, "internal/db/symbols.go", paths["ListSymbols"], "nested files are listed")
+}
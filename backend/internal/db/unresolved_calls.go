@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// encodeUnresolvedCall stores an unresolved call as one string property item
+func encodeUnresolvedCall(call analysis.UnresolvedCall) string {
+	return call.ImportPath + "\t" + call.Name + "\t" + strconv.Itoa(call.Count)
+}
+
+func decodeUnresolvedCall(s string) (analysis.UnresolvedCall, bool) {
+	parts := strings.Split(s, "\t")
+	if len(parts) != 3 || parts[1] == "" {
+		return analysis.UnresolvedCall{}, false
+	}
+	count, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return analysis.UnresolvedCall{}, false
+	}
+	return analysis.UnresolvedCall{ImportPath: parts[0], Name: parts[1], Count: count}, true
+}
+
+// GetUnresolvedCalls reports the calls of a repository that matched none of
+// its entities, by name and by the import they go through, keeping the
+// limit most called of each
+func (r *GraphReader) GetUnresolvedCalls(ctx context.Context, repoID string, limit int) (*analysis.UnresolvedCallReport, error) {
+	ctx = WithRepository(ctx, repoID)
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:CONTAINS*]->(:File)-[:DECLARES]->(e:Function|Method)
+			WHERE e.unresolvedCalls IS NOT NULL
+			RETURN e.id AS id, e.unresolvedCalls AS calls
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+
+		var calls []analysis.UnresolvedCall
+		for records.Next(ctx) {
+			rec := records.Record()
+			callerID := recordString(rec, "id")
+			for _, s := range recordStrings(rec, "calls") {
+				if call, ok := decodeUnresolvedCall(s); ok {
+					call.CallerID = callerID
+					calls = append(calls, call)
+				}
+			}
+		}
+		return calls, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return analysis.SummarizeUnresolved(result.([]analysis.UnresolvedCall), limit), nil
+}
@@ -0,0 +1,25 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestUnresolvedCallEncoding tests that unresolved calls survive storage
+func TestUnresolvedCallEncoding(t *testing.T) {
+	for _, call := range []analysis.UnresolvedCall{
+		{ImportPath: "encoding/json", Name: "json.Marshal", Count: 3},
+		{Name: "len", Count: 1},
+	} {
+		decoded, ok := decodeUnresolvedCall(encodeUnresolvedCall(call))
+		assert.True(t, ok)
+		assert.Equal(t, call, decoded)
+	}
+
+	_, ok := decodeUnresolvedCall("garbage")
+	assert.False(t, ok)
+	_, ok = decodeUnresolvedCall("fmt\tfmt.Println\tmany")
+	assert.False(t, ok)
+}
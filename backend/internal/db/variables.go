@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"sort"
+
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// VariableAccessDetail is a READS or WRITES edge of a node, seen from the
+// other end
+type VariableAccessDetail struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	FilePath string `json:"filePath,omitempty"`
+	Lines    []int  `json:"lines,omitempty"`
+	Count    int    `json:"count"`
+}
+
+// WriteVariableAccesses resolves each entity's variable accesses against the
+// indexed variables and writes READS and WRITES edges carrying the lines and
+// count of the accesses
+func (w *GraphWriter) WriteVariableAccesses(ctx context.Context, files []*models.File, entities []models.CodeEntity) error {
+	relations := analysis.ResolveVariableAccesses(files, entities)
+	if len(relations) == 0 {
+		return nil
+	}
+
+	var reads, writes []map[string]any
+	for _, rel := range relations {
+		row := map[string]any{
+			"entityId":   rel.EntityID,
+			"variableId": rel.VariableID,
+			"lines":      rel.Lines,
+			"count":      rel.Count,
+		}
+		if rel.Write {
+			writes = append(writes, row)
+		} else {
+			reads = append(reads, row)
+		}
+	}
+
+	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		// Relationship types are fixed strings, never from input
+		for relType, rows := range map[string][]map[string]any{"READS": reads, "WRITES": writes} {
+			query := `
+				UNWIND $rows AS row
+				MATCH (e:Function|Method {id: row.entityId})
+				MATCH (v:Variable {id: row.variableId})
+				MERGE (e)-[a:` + relType + `]->(v)
+				SET a.lines = row.lines, a.count = row.count
+			`
+			if _, err := tx.Run(ctx, query, map[string]any{"rows": rows}); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+
+	return err
+}
+
+// variableAccesses holds the READS and WRITES edges of a node in both
+// directions
+type variableAccesses struct {
+	reads, writes, readBy, writtenBy []VariableAccessDetail
+}
+
+// readVariableAccesses returns the variables a function reads and writes,
+// or for a variable the functions reading and writing it
+func readVariableAccesses(ctx context.Context, tx neo4j.ManagedTransaction, nodeID string) (*variableAccesses, error) {
+	query := `
+		MATCH (node {id: $nodeId})-[a:READS|WRITES]-(other)
+		RETURN type(a) AS kind, startNode(a) = node AS outgoing,
+		       other.id AS id, other.name AS name, other.filePath AS filePath,
+		       a.lines AS lines, a.count AS count
+	`
+	records, err := tx.Run(ctx, query, map[string]any{"nodeId": nodeID})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &variableAccesses{}
+	for records.Next(ctx) {
+		rec := records.Record()
+		access := VariableAccessDetail{
+			ID:       recordString(rec, "id"),
+			Name:     recordString(rec, "name"),
+			FilePath: recordString(rec, "filePath"),
+		}
+		if lines, _ := rec.Get("lines"); lines != nil {
+			for _, line := range lines.([]any) {
+				access.Lines = append(access.Lines, int(line.(int64)))
+			}
+		}
+		if count, _ := rec.Get("count"); count != nil {
+			access.Count = int(count.(int64))
+		}
+
+		outgoing, _ := rec.Get("outgoing")
+		switch kind := recordString(rec, "kind"); {
+		case kind == "READS" && outgoing == true:
+			result.reads = append(result.reads, access)
+		case kind == "READS":
+			result.readBy = append(result.readBy, access)
+		case outgoing == true:
+			result.writes = append(result.writes, access)
+		default:
+			result.writtenBy = append(result.writtenBy, access)
+		}
+	}
+	for _, list := range [][]VariableAccessDetail{result.reads, result.writes, result.readBy, result.writtenBy} {
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	}
+	return result, records.Err()
+}
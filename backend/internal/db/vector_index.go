@@ -3,55 +3,147 @@ package db
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sort"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
-// CreateVectorIndex creates a vector index for function embeddings
+// CreateVectorIndex creates the vector indexes of the searched space and of
+// the space a migration is building
 func (c *Neo4jClient) CreateVectorIndex(ctx context.Context) error {
-	_, err := c.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		query := `
-			CREATE VECTOR INDEX function_embeddings IF NOT EXISTS
-			FOR (f:Function) ON (f.embedding)
-			OPTIONS {indexConfig: {
-				` + "`" + `vector.dimensions` + "`" + `: 1536,
-				` + "`" + `vector.similarity_function` + "`" + `: 'cosine'
-			}}
-		`
-		_, err := tx.Run(ctx, query, nil)
-		return nil, err
-	})
-	return err
+	active, building := c.VectorSpaces()
+	if err := c.createVectorIndex(ctx, active); err != nil {
+		return err
+	}
+	if building != nil {
+		return c.createVectorIndex(ctx, *building)
+	}
+	return nil
+}
+
+// createVectorIndex creates a space's index of every label in vectorLabels
+// and docLabels
+func (c *Neo4jClient) createVectorIndex(ctx context.Context, space VectorSpace) error {
+	for _, label := range slices.Concat(vectorLabels, docLabels) {
+		query := c.dialect.vectorIndexQuery(space, label)
+		if c.dialect == DialectMemgraph {
+			// Index changes cannot run in a transaction on Memgraph
+			if err := c.runAutoCommit(ctx, query); err != nil && !isExistingIndex(err) {
+				return err
+			}
+			continue
+		}
+
+		_, err := c.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			_, err := tx.Run(ctx, query, nil)
+			return nil, err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Neo4jClient) dropVectorIndex(ctx context.Context, space VectorSpace) error {
+	for _, label := range slices.Concat(vectorLabels, docLabels) {
+		query := c.dialect.dropVectorIndexQuery(space, label)
+		if c.dialect == DialectMemgraph {
+			if err := c.runAutoCommit(ctx, query); err != nil && !isMissingIndex(err) {
+				return err
+			}
+			continue
+		}
+
+		_, err := c.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			_, err := tx.Run(ctx, query, nil)
+			return nil, err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // SearchResult represents a single search result
 type SearchResult struct {
-	ID        string  `json:"id"`
-	Name      string  `json:"name"`
-	Signature string  `json:"signature"`
-	FilePath  string  `json:"filePath"`
-	RepoID    string  `json:"repoId"`
-	RepoName  string  `json:"repoName"`
-	Score     float64 `json:"score"`
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Signature string `json:"signature"`
+	FilePath  string `json:"filePath"`
+	RepoID    string `json:"repoId"`
+	RepoName  string `json:"repoName"`
+	// Relevance from 0 to 1: the cosine similarity of semantic results,
+	// relative to the best match for keyword ones and reciprocal rank
+	// fusion relative to the best possible for fused ones
+	Score float64 `json:"score"`
+	// ResultWiki or ResultDocstring for documentation, empty for code
+	Kind     string `json:"kind,omitempty"`
+	WikiSlug string `json:"wikiSlug,omitempty"`
+
+	Docstring string `json:"docstring,omitempty"`
+	// generated sentence saying what the entity does, see ENTITY_DESCRIPTIONS
+	Description string `json:"description,omitempty"`
+	StartLine   int    `json:"startLine"`
+	EndLine     int    `json:"endLine"`
+	// Lines of the entity around what matched the query, starting at
+	// SnippetLine, cut from Content by the caller knowing the query
+	Snippet     string `json:"snippet,omitempty"`
+	SnippetLine int    `json:"snippetLine,omitempty"`
+	Content     string `json:"-"` // stored source the snippet is cut from
 }
 
-// VectorSearch performs semantic search using vector embeddings
-func (r *GraphReader) VectorSearch(ctx context.Context, embedding []float32, limit int, repoID string) ([]SearchResult, error) {
-	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
-		query := `
-			CALL db.index.vector.queryNodes('function_embeddings', $limit, $embedding)
-			YIELD node, score
-			MATCH (node)<-[:DECLARES]-(f:File)<-[:CONTAINS]-(r:Repository)
-			WHERE ($repoId IS NULL OR r.id = $repoId)
-			RETURN node.id, node.name, node.signature, node.filePath, r.id, r.name, score
-			ORDER BY score DESC
-		`
+// readSearchDetails fills the docstring, description, lines and content of
+// a search result from a record returning them under those names
+func readSearchDetails(rec *neo4j.Record, result *SearchResult) {
+	result.Docstring = recordString(rec, "docstring")
+	result.Description = recordString(rec, "description")
+	result.Content = recordString(rec, "content")
+	if v, _ := rec.Get("startLine"); v != nil {
+		result.StartLine = int(v.(int64))
+	}
+	if v, _ := rec.Get("endLine"); v != nil {
+		result.EndLine = int(v.(int64))
+	}
+}
+
+// VectorSearch performs semantic search using vector embeddings of a
+// vector space, see Neo4jClient.VectorSpaces, keeping results passing filter
+func (r *GraphReader) VectorSearch(ctx context.Context, space VectorSpace, embedding []float32, limit int, repoID string, filter SearchFilter) ([]SearchResult, error) {
+	if err := space.CheckDimension(embedding); err != nil {
+		return nil, err
+	}
+	if repoID != "" || !r.client.PerRepositoryDatabases() {
+		return r.vectorSearch(WithRepository(ctx, repoID), space, embedding, limit, repoID, filter)
+	}
 
+	// Every repository database has its own index; keep the best of all
+	results := []SearchResult{}
+	err := r.client.forEachRepositoryDatabase(ctx, func(ctx context.Context) error {
+		found, err := r.vectorSearch(ctx, space, embedding, limit, "", filter)
+		results = append(results, found...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// vectorSearch queries the index of each label in vectorLabels and keeps the
+// best limit results of all; scores of one space are comparable across them
+func (r *GraphReader) vectorSearch(ctx context.Context, space VectorSpace, embedding []float32, limit int, repoID string, filter SearchFilter) ([]SearchResult, error) {
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		// Prepare parameters
-		params := map[string]any{
-			"embedding": embedding,
-			"limit":     limit,
-		}
+		where, params := filter.whereClause()
+		params["embedding"] = embedding
+		params["limit"] = filter.candidates(limit)
 
 		// Handle optional repoId filter
 		if repoID == "" {
@@ -60,61 +152,85 @@ func (r *GraphReader) VectorSearch(ctx context.Context, embedding []float32, lim
 			params["repoId"] = repoID
 		}
 
-		records, err := tx.Run(ctx, query, params)
-		if err != nil {
-			return nil, fmt.Errorf("failed to run vector search query: %w", err)
-		}
-
-		var results []SearchResult
-		for records.Next(ctx) {
-			rec := records.Record()
-
-			// Extract values safely
-			id, _ := rec.Get("node.id")
-			name, _ := rec.Get("node.name")
-			signature, _ := rec.Get("node.signature")
-			filePath, _ := rec.Get("node.filePath")
-			repoID, _ := rec.Get("r.id")
-			repoName, _ := rec.Get("r.name")
-			score, _ := rec.Get("score")
-
-			result := SearchResult{
-				ID:        fmt.Sprintf("%v", id),
-				Name:      fmt.Sprintf("%v", name),
-				Signature: fmt.Sprintf("%v", signature),
-				FilePath:  fmt.Sprintf("%v", filePath),
-				RepoID:    fmt.Sprintf("%v", repoID),
-				RepoName:  fmt.Sprintf("%v", repoName),
-				Score:     0.0,
+		results := []SearchResult{}
+		for _, label := range vectorLabels {
+			if len(filter.Types) > 0 && !slices.Contains(filter.Types, label) {
+				continue
 			}
-
-			// Handle score conversion
-			if score != nil {
-				switch v := score.(type) {
-				case float64:
-					result.Score = v
-				case int64:
-					result.Score = float64(v)
-				}
+			found, err := runVectorQuery(ctx, tx, r.client.dialect.vectorQueryCall(space, label), where, params)
+			if err != nil {
+				return nil, err
 			}
-
-			results = append(results, result)
+			results = append(results, found...)
 		}
-
-		if err := records.Err(); err != nil {
-			return nil, fmt.Errorf("error iterating search results: %w", err)
+		sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+		if len(results) > limit {
+			results = results[:limit]
 		}
-
-		return results, nil
+		return filter.aboveMinScore(results), nil
 	})
 
 	if err != nil {
 		return nil, err
 	}
+	return result.([]SearchResult), nil
+}
 
-	if result == nil {
-		return []SearchResult{}, nil
+// runVectorQuery runs a vector index call and returns its nodes passing
+// where with their repository
+func runVectorQuery(ctx context.Context, tx neo4j.ManagedTransaction, call, where string, params map[string]any) ([]SearchResult, error) {
+	query := call + `
+		MATCH (r:Repository {id: node.repoId})
+		WHERE ($repoId IS NULL OR r.id = $repoId) AND ` + where + `
+		RETURN node.id, node.name, node.signature, node.filePath, r.id, r.name, score,
+		       node.docstring AS docstring, node.description AS description, node.startLine AS startLine,
+		       node.endLine AS endLine, node.content AS content
+		ORDER BY score DESC
+	`
+	records, err := tx.Run(ctx, query, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run vector search query: %w", err)
 	}
 
-	return result.([]SearchResult), nil
+	var results []SearchResult
+	for records.Next(ctx) {
+		rec := records.Record()
+
+		// Extract values safely
+		id, _ := rec.Get("node.id")
+		name, _ := rec.Get("node.name")
+		signature, _ := rec.Get("node.signature")
+		filePath, _ := rec.Get("node.filePath")
+		repoID, _ := rec.Get("r.id")
+		repoName, _ := rec.Get("r.name")
+		score, _ := rec.Get("score")
+
+		result := SearchResult{
+			ID:        fmt.Sprintf("%v", id),
+			Name:      fmt.Sprintf("%v", name),
+			Signature: fmt.Sprintf("%v", signature),
+			FilePath:  fmt.Sprintf("%v", filePath),
+			RepoID:    fmt.Sprintf("%v", repoID),
+			RepoName:  fmt.Sprintf("%v", repoName),
+			Score:     0.0,
+		}
+
+		// Handle score conversion
+		if score != nil {
+			switch v := score.(type) {
+			case float64:
+				result.Score = v
+			case int64:
+				result.Score = float64(v)
+			}
+		}
+
+		readSearchDetails(rec, &result)
+		results = append(results, result)
+	}
+
+	if err := records.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search results: %w", err)
+	}
+	return results, nil
 }
@@ -0,0 +1,510 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// defaultVectorDimension is the dimension of the function_embeddings index
+// created before vector spaces were recorded
+const defaultVectorDimension = 1536
+
+// States of a recorded vector space
+const (
+	vectorSpaceActive   = "active"   // searched
+	vectorSpaceBuilding = "building" // being filled by a migration
+	vectorSpaceRetired  = "retired"  // replaced, waiting to be dropped
+)
+
+// removeEmbeddingsBatch bounds how many nodes lose a retired embedding
+// property per transaction
+const removeEmbeddingsBatch = 1000
+
+// vectorSpaceNameInvalid matches characters not allowed in the property and
+// index names derived from a model
+var vectorSpaceNameInvalid = regexp.MustCompile(`[^a-z0-9]+`)
+
+// VectorSpace is the embeddings of one model and dimension: the node
+// property holding them and the vector indexes searching them. Changing the
+// model builds a new space next to the searched one, so search keeps working
+// until every entity has been re-embedded.
+type VectorSpace struct {
+	Model     string `json:"model"`
+	Dimension int    `json:"dimension"`
+	Property  string `json:"property"`
+	Index     string `json:"index"`
+}
+
+// NewVectorSpace names the property and index of a model and dimension
+func NewVectorSpace(model string, dimension int) VectorSpace {
+	slug := strings.Trim(vectorSpaceNameInvalid.ReplaceAllString(strings.ToLower(model), "_"), "_")
+	if len(slug) > 40 {
+		slug = slug[:40]
+	}
+	suffix := fmt.Sprintf("%s_%d", slug, dimension)
+	return VectorSpace{
+		Model:     model,
+		Dimension: dimension,
+		Property:  "embedding_" + suffix,
+		Index:     "function_embeddings_" + suffix,
+	}
+}
+
+// legacyVectorSpace is the embedding property and index used before vector
+// spaces were recorded
+func legacyVectorSpace(model string, dimension int) VectorSpace {
+	return VectorSpace{
+		Model:     model,
+		Dimension: dimension,
+		Property:  "embedding",
+		Index:     "function_embeddings",
+	}
+}
+
+// vectorLabels are the entity labels with a vector index in every space.
+// Vector indexes cover a single label, so each has its own.
+var vectorLabels = []string{"Function", "Method", "Class"}
+
+// LabelIndex names a space's vector index of one entity label. Functions
+// keep the space's own index name, which predates the others.
+func (s VectorSpace) LabelIndex(label string) string {
+	if label == "Function" {
+		return s.Index
+	}
+	return s.Index + "_" + strings.ToLower(label)
+}
+
+// ErrDimensionMismatch is returned for a vector whose dimension differs from
+// its vector space's
+var ErrDimensionMismatch = errors.New("embedding dimension mismatch")
+
+// CheckDimension returns ErrDimensionMismatch unless vector has the space's
+// dimension, which the vector index would silently skip or reject
+func (s VectorSpace) CheckDimension(vector []float32) error {
+	if len(vector) != s.Dimension {
+		return fmt.Errorf("%w: got %d dimensions, %s expects %d; set EMBEDDING_DIMENSION to the model's",
+			ErrDimensionMismatch, len(vector), s.Index, s.Dimension)
+	}
+	return nil
+}
+
+// Same reports whether two spaces hold vectors of the same model and
+// dimension
+func (s VectorSpace) Same(other VectorSpace) bool {
+	return s.Model == other.Model && s.Dimension == other.Dimension
+}
+
+// VectorSpaces returns the space searched and the space a migration is
+// building, nil when none is running
+func (c *Neo4jClient) VectorSpaces() (VectorSpace, *VectorSpace) {
+	c.spaceMu.RLock()
+	defer c.spaceMu.RUnlock()
+	return c.space, c.building
+}
+
+// WriteSpace is where newly indexed embeddings are stored: the space being
+// built while a migration runs, as the embedding service already serves its
+// model
+func (c *Neo4jClient) WriteSpace() VectorSpace {
+	active, building := c.VectorSpaces()
+	if building != nil {
+		return *building
+	}
+	return active
+}
+
+func (c *Neo4jClient) setVectorSpaces(active VectorSpace, building *VectorSpace) {
+	c.spaceMu.Lock()
+	defer c.spaceMu.Unlock()
+	c.space = active
+	c.building = building
+}
+
+// LoadVectorSpaces reads the recorded vector spaces and makes sure their
+// indexes exist in every database. When the configured model or dimension
+// differs from the searched space it starts a migration: the configured
+// space is recorded as building and returned, for the caller to re-embed
+// every entity into it and then CompleteVectorMigration. Spaces left over
+// from earlier migrations are dropped.
+//
+// With nothing recorded, the function_embeddings index is adopted as the
+// configured model's, as long as the dimension matches. A configured
+// dimension of 0, when it is unknown, keeps the searched space.
+func (c *Neo4jClient) LoadVectorSpaces(ctx context.Context, configured VectorSpace) (*VectorSpace, error) {
+	ctx = catalog(ctx)
+	recorded, err := c.readVectorSpaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vector spaces: %w", err)
+	}
+
+	var active, building *VectorSpace
+	var stale []VectorSpace
+	for state, spaces := range recorded {
+		for i := range spaces {
+			switch state {
+			case vectorSpaceActive:
+				active = &spaces[i]
+			case vectorSpaceBuilding:
+				building = &spaces[i]
+			default:
+				stale = append(stale, spaces[i])
+			}
+		}
+	}
+	if active == nil {
+		legacy := legacyVectorSpace(configured.Model, defaultVectorDimension)
+		if err := c.recordVectorSpace(ctx, legacy, vectorSpaceActive); err != nil {
+			return nil, err
+		}
+		active = &legacy
+	}
+
+	if configured.Dimension == 0 {
+		configured = *active
+	}
+
+	// A migration towards a model that is no longer configured is abandoned
+	if building != nil && (active.Same(configured) || !building.Same(configured)) {
+		stale = append(stale, *building)
+		building = nil
+	}
+	c.setVectorSpaces(*active, building)
+	for _, space := range stale {
+		if err := c.DropVectorSpace(ctx, space); err != nil {
+			return nil, err
+		}
+	}
+
+	if building == nil && !active.Same(configured) {
+		if err := c.recordVectorSpace(ctx, configured, vectorSpaceBuilding); err != nil {
+			return nil, err
+		}
+		building = &configured
+		c.setVectorSpaces(*active, building)
+	}
+	if err := c.forEachVectorDatabase(ctx, c.CreateVectorIndex); err != nil {
+		return nil, fmt.Errorf("failed to create vector indexes: %w", err)
+	}
+	return building, nil
+}
+
+// forEachVectorDatabase runs fn in every database with vector indexes: the
+// repository databases and, when separate, the catalog holding wiki pages
+func (c *Neo4jClient) forEachVectorDatabase(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := c.forEachRepositoryDatabase(ctx, fn); err != nil {
+		return err
+	}
+	if c.perRepository {
+		return fn(catalog(ctx))
+	}
+	return nil
+}
+
+// CompleteVectorMigration switches search to the space being built, once
+// every entity has been re-embedded into it, and returns the replaced space
+// for DropVectorSpace
+func (c *Neo4jClient) CompleteVectorMigration(ctx context.Context) (VectorSpace, error) {
+	active, building := c.VectorSpaces()
+	if building == nil {
+		return VectorSpace{}, fmt.Errorf("no vector migration is running")
+	}
+
+	_, err := c.ExecuteWrite(catalog(ctx), func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (old:VectorSpace {index: $old}), (new:VectorSpace {index: $new})
+			SET old.state = $retired, new.state = $active
+		`
+		_, err := tx.Run(ctx, query, map[string]any{
+			"old":     active.Index,
+			"new":     building.Index,
+			"retired": vectorSpaceRetired,
+			"active":  vectorSpaceActive,
+		})
+		return nil, err
+	})
+	if err != nil {
+		return VectorSpace{}, fmt.Errorf("failed to switch vector space: %w", err)
+	}
+
+	c.setVectorSpaces(*building, nil)
+	return active, nil
+}
+
+// DropVectorSpace drops a space's index and removes its property from every
+// node, in batches, then forgets the space. It must not be searched anymore.
+func (c *Neo4jClient) DropVectorSpace(ctx context.Context, space VectorSpace) error {
+	err := c.forEachVectorDatabase(ctx, func(ctx context.Context) error {
+		if err := c.dropVectorIndex(ctx, space); err != nil {
+			return err
+		}
+		return c.removeEmbeddings(ctx, "MATCH (n)", space, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to drop vector space %s: %w", space.Index, err)
+	}
+
+	_, err = c.ExecuteWrite(catalog(ctx), func(tx neo4j.ManagedTransaction) (any, error) {
+		_, err := tx.Run(ctx, `MATCH (s:VectorSpace {index: $index}) DELETE s`, map[string]any{"index": space.Index})
+		return nil, err
+	})
+	return err
+}
+
+// RemoveRepositoryEmbeddings removes a space's property from the nodes and
+// wiki pages of one repository, such as vectors an imported archive carried
+// that this instance does not search
+func (c *Neo4jClient) RemoveRepositoryEmbeddings(ctx context.Context, repoID string, space VectorSpace) error {
+	params := map[string]any{"repoId": repoID}
+	err := c.removeEmbeddings(WithRepository(ctx, repoID), "MATCH (n {repoId: $repoId})", space, params)
+	if err == nil && c.perRepository {
+		err = c.removeEmbeddings(catalog(ctx), "MATCH (n:WikiPage {repoId: $repoId})", space, params)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove %s embeddings of %s: %w", space.Property, repoID, err)
+	}
+	return nil
+}
+
+// removeEmbeddings removes a space's property from the nodes match finds,
+// in batches
+func (c *Neo4jClient) removeEmbeddings(ctx context.Context, match string, space VectorSpace, params map[string]any) error {
+	// Property names are derived from [a-z0-9_] only
+	query := match + ` WHERE n.` + "`" + space.Property + "`" + ` IS NOT NULL
+		WITH n LIMIT $limit
+		REMOVE n.` + "`" + space.Property + "`" + `
+		RETURN count(n) AS removed
+	`
+	params = maps.Clone(params)
+	if params == nil {
+		params = make(map[string]any, 1)
+	}
+	params["limit"] = removeEmbeddingsBatch
+	for {
+		result, err := c.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			rec, err := tx.Run(ctx, query, params)
+			if err != nil {
+				return nil, err
+			}
+			single, err := rec.Single(ctx)
+			if err != nil {
+				return nil, err
+			}
+			removed, _ := single.Get("removed")
+			return removed, nil
+		})
+		if err != nil {
+			return err
+		}
+		if removed, _ := result.(int64); removed == 0 {
+			return nil
+		}
+	}
+}
+
+// CountEmbeddings counts the entities with a vector in a space across all
+// repositories
+func (c *Neo4jClient) CountEmbeddings(ctx context.Context, space VectorSpace) (int, error) {
+	query := `
+		MATCH (n:Function|Method|Class|Variable)
+		WHERE n.` + "`" + space.Property + "`" + ` IS NOT NULL
+		RETURN count(n) AS count
+	`
+	total := 0
+	err := c.forEachRepositoryDatabase(ctx, func(ctx context.Context) error {
+		result, err := c.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			rec, err := tx.Run(ctx, query, nil)
+			if err != nil {
+				return nil, err
+			}
+			single, err := rec.Single(ctx)
+			if err != nil {
+				return nil, err
+			}
+			count, _ := single.Get("count")
+			return count, nil
+		})
+		if err != nil {
+			return err
+		}
+		n, _ := result.(int64)
+		total += int(n)
+		return nil
+	})
+	return total, err
+}
+
+// readVectorSpaces returns the recorded spaces by state
+func (c *Neo4jClient) readVectorSpaces(ctx context.Context) (map[string][]VectorSpace, error) {
+	result, err := c.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (s:VectorSpace)
+			RETURN s.model AS model, s.dimension AS dimension, s.property AS property,
+			       s.index AS index, s.state AS state
+		`
+		records, err := tx.Run(ctx, query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		spaces := make(map[string][]VectorSpace)
+		for records.Next(ctx) {
+			rec := records.Record()
+			space := VectorSpace{
+				Model:    recordString(rec, "model"),
+				Property: recordString(rec, "property"),
+				Index:    recordString(rec, "index"),
+			}
+			if v, _ := rec.Get("dimension"); v != nil {
+				space.Dimension = int(v.(int64))
+			}
+			state := recordString(rec, "state")
+			spaces[state] = append(spaces[state], space)
+		}
+		return spaces, records.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string][]VectorSpace), nil
+}
+
+func (c *Neo4jClient) recordVectorSpace(ctx context.Context, space VectorSpace, state string) error {
+	_, err := c.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MERGE (s:VectorSpace {index: $index})
+			SET s.model = $model, s.dimension = $dimension, s.property = $property,
+			    s.state = $state, s.createdAt = $createdAt
+		`
+		_, err := tx.Run(ctx, query, map[string]any{
+			"index":     space.Index,
+			"model":     space.Model,
+			"dimension": space.Dimension,
+			"property":  space.Property,
+			"state":     state,
+			"createdAt": time.Now().UTC(),
+		})
+		return nil, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record vector space %s: %w", space.Index, err)
+	}
+	return nil
+}
+
+// PendingEmbeddings returns up to limit entities of a repository with a
+// vector in one space but not yet in another, with the fields their
+// embedding text is made of
+func (r *GraphReader) PendingEmbeddings(ctx context.Context, repoID string, from, to VectorSpace, limit int) ([]models.CodeEntity, error) {
+	ctx = WithRepository(ctx, repoID)
+
+	query := `
+		MATCH (n:Function|Method|Class|Variable {repoId: $repoId})
+		WHERE n.` + "`" + from.Property + "`" + ` IS NOT NULL AND n.` + "`" + to.Property + "`" + ` IS NULL
+		RETURN n.id AS id, n.name AS name, n.signature AS signature, n.docstring AS docstring,
+		       n.description AS description
+		LIMIT $limit
+	`
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+
+		var entities []models.CodeEntity
+		for records.Next(ctx) {
+			rec := records.Record()
+			entities = append(entities, models.CodeEntity{
+				ID:            recordString(rec, "id"),
+				Name:          recordString(rec, "name"),
+				Signature:     recordString(rec, "signature"),
+				Docstring:     recordString(rec, "docstring"),
+				NLDescription: recordString(rec, "description"),
+			})
+		}
+		return entities, records.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending embeddings: %w", err)
+	}
+	return result.([]models.CodeEntity), nil
+}
+
+// PendingDocEmbeddings returns up to limit entities of a repository whose
+// docstring has a vector in one space but not yet in another, with their
+// docstrings
+func (r *GraphReader) PendingDocEmbeddings(ctx context.Context, repoID string, from, to VectorSpace, limit int) ([]models.CodeEntity, error) {
+	ctx = WithRepository(ctx, repoID)
+
+	query := `
+		MATCH (d:Docstring {repoId: $repoId})
+		WHERE d.` + "`" + from.Property + "`" + ` IS NOT NULL AND d.` + "`" + to.Property + "`" + ` IS NULL
+		RETURN d.entityId AS id, d.docstring AS docstring
+		LIMIT $limit
+	`
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+
+		var entities []models.CodeEntity
+		for records.Next(ctx) {
+			rec := records.Record()
+			entities = append(entities, models.CodeEntity{
+				ID:        recordString(rec, "id"),
+				Docstring: recordString(rec, "docstring"),
+			})
+		}
+		return entities, records.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending docstring embeddings: %w", err)
+	}
+	return result.([]models.CodeEntity), nil
+}
+
+// SetEmbeddings stores the vectors of entities in a space, vectors[i]
+// belonging to ids[i]
+func (w *GraphWriter) SetEmbeddings(ctx context.Context, repoID string, space VectorSpace, ids []string, vectors [][]float32) error {
+	return w.setEmbeddings(ctx, repoID, space, "MATCH (n:Function|Method|Class|Variable {id: row.id, repoId: $repoId})", ids, vectors)
+}
+
+// SetDocEmbeddings stores the vectors of the docstrings of entities in a
+// space, vectors[i] belonging to the docstring of ids[i]
+func (w *GraphWriter) SetDocEmbeddings(ctx context.Context, repoID string, space VectorSpace, ids []string, vectors [][]float32) error {
+	return w.setEmbeddings(ctx, repoID, space, "MATCH (n:Docstring {entityId: row.id, repoId: $repoId})", ids, vectors)
+}
+
+// setEmbeddings stores vectors[i] on the node match finds for row.id ids[i]
+func (w *GraphWriter) setEmbeddings(ctx context.Context, repoID string, space VectorSpace, match string, ids []string, vectors [][]float32) error {
+	if len(ids) != len(vectors) {
+		return fmt.Errorf("got %d vectors for %d entities", len(vectors), len(ids))
+	}
+	ctx = WithRepository(ctx, repoID)
+
+	rows := make([]map[string]any, len(ids))
+	for i, id := range ids {
+		if err := space.CheckDimension(vectors[i]); err != nil {
+			return err
+		}
+		rows[i] = map[string]any{"id": id, "embedding": vectors[i]}
+	}
+	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			UNWIND $rows AS row
+			` + match + `
+			SET n.` + "`" + space.Property + "`" + ` = row.embedding
+		`
+		_, err := tx.Run(ctx, query, map[string]any{"rows": rows, "repoId": repoID})
+		return nil, err
+	})
+	return err
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewVectorSpace tests naming the property and index of a model
+func TestNewVectorSpace(t *testing.T) {
+	space := NewVectorSpace("Qodo/Qodo-Embed-1-1.5B", 1536)
+	assert.Equal(t, "embedding_qodo_qodo_embed_1_1_5b_1536", space.Property)
+	assert.Equal(t, "function_embeddings_qodo_qodo_embed_1_1_5b_1536", space.Index)
+
+	// Another dimension of the same model is another space
+	other := NewVectorSpace("Qodo/Qodo-Embed-1-1.5B", 768)
+	assert.NotEqual(t, space.Index, other.Index)
+	assert.False(t, space.Same(other))
+
+	// The index created before spaces were recorded counts as the same model
+	assert.True(t, legacyVectorSpace(space.Model, 1536).Same(space))
+}
+
+// TestCheckDimension tests rejecting vectors of another dimension
+func TestCheckDimension(t *testing.T) {
+	space := NewVectorSpace("BAAI/bge-small-en-v1.5", 384)
+	assert.NoError(t, space.CheckDimension(make([]float32, 384)))
+
+	err := space.CheckDimension(make([]float32, 1536))
+	assert.ErrorIs(t, err, ErrDimensionMismatch)
+	assert.Contains(t, err.Error(), "got 1536 dimensions")
+}
+
+// TestLabelIndex tests naming the vector index of each entity label
+func TestLabelIndex(t *testing.T) {
+	space := NewVectorSpace("BAAI/bge-small-en-v1.5", 384)
+	assert.Equal(t, space.Index, space.LabelIndex("Function"))
+	assert.Equal(t, space.Index+"_method", space.LabelIndex("Method"))
+	assert.Equal(t, "function_embeddings_class", legacyVectorSpace("", 1536).LabelIndex("Class"))
+
+	// Documentation has indexes of its own
+	for _, label := range docLabels {
+		assert.NotContains(t, vectorLabels, label)
+		assert.NotEqual(t, space.Index, space.LabelIndex(label))
+	}
+}
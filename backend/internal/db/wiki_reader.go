@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"regexp"
 	"sort"
 	"strings"
@@ -119,7 +120,8 @@ func (r *WikiReader) GetPage(ctx context.Context, repoID, slug string) (*models.
 			MATCH (r:Repository {id: $repoId})-[:HAS_WIKI]->(w:WikiPage {slug: $slug})
 			RETURN w.id as id, w.repoId as repoId, w.slug as slug, w.title as title,
 			       w.content as content, w.order as order, w.parentSlug as parentSlug,
-			       w.diagrams as diagrams, w.generatedAt as generatedAt
+			       w.diagrams as diagrams, w.generatedAt as generatedAt,
+			       w.sources as sources, w.staleFiles as staleFiles
 		`
 		records, err := tx.Run(ctx, query, map[string]any{
 			"repoId": repoID,
@@ -158,6 +160,8 @@ func (r *WikiReader) GetPage(ctx context.Context, repoID, slug string) (*models.
 		if parentSlug != nil {
 			page.ParentSlug = parentSlug.(string)
 		}
+		page.Sources = recordStrings(rec, "sources")
+		page.StaleFiles = recordStrings(rec, "staleFiles")
 
 		if generatedAt != nil {
 			// Handle both time.Time and neo4j.Time
@@ -204,6 +208,110 @@ func (r *WikiReader) GetPage(ctx context.Context, repoID, slug string) (*models.
 	return result.(*models.WikiPageResponse), nil
 }
 
+// GetStalePages returns the wiki pages whose sources changed since they
+// were generated, in navigation order
+func (r *WikiReader) GetStalePages(ctx context.Context, repoID string) ([]models.StalePage, error) {
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:HAS_WIKI]->(w:WikiPage)
+			WHERE size(coalesce(w.staleFiles, [])) > 0
+			RETURN w.slug as slug, w.title as title, w.staleFiles as staleFiles
+			ORDER BY w.order, w.slug
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+
+		pages := []models.StalePage{}
+		for records.Next(ctx) {
+			rec := records.Record()
+			pages = append(pages, models.StalePage{
+				Slug:       recordString(rec, "slug"),
+				Title:      recordString(rec, "title"),
+				StaleFiles: recordStrings(rec, "staleFiles"),
+			})
+		}
+		return pages, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]models.StalePage), nil
+}
+
+// PendingPageEmbeddings returns up to limit wiki pages of a repository with
+// a vector in one space but not yet in another, with their title and content
+func (r *WikiReader) PendingPageEmbeddings(ctx context.Context, repoID string, from, to VectorSpace, limit int) ([]models.WikiPage, error) {
+	result, err := r.client.ExecuteRead(catalog(ctx), func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:HAS_WIKI]->(w:WikiPage)
+			WHERE w.` + "`" + from.Property + "`" + ` IS NOT NULL AND w.` + "`" + to.Property + "`" + ` IS NULL
+			RETURN w.slug as slug, w.title as title, w.content as content
+			LIMIT $limit
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+
+		var pages []models.WikiPage
+		for records.Next(ctx) {
+			rec := records.Record()
+			pages = append(pages, models.WikiPage{
+				RepoID:  repoID,
+				Slug:    recordString(rec, "slug"),
+				Title:   recordString(rec, "title"),
+				Content: recordString(rec, "content"),
+			})
+		}
+		return pages, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return result.([]models.WikiPage), nil
+}
+
+// ExportWiki passes every wiki page of a repository to emit as a snapshot
+// node keyed by its slug, with all its properties, embeddings included, so
+// an archive can carry the wiki along with the code graph
+func (r *WikiReader) ExportWiki(ctx context.Context, repoID string, emit func(record any) error) error {
+	result, err := r.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:HAS_WIKI]->(w:WikiPage)
+			RETURN w.slug AS slug, properties(w) AS props
+			ORDER BY w.order, w.slug
+		`
+		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		if err != nil {
+			return nil, err
+		}
+
+		var pages []SnapshotNode
+		for records.Next(ctx) {
+			rec := records.Record()
+			props, _ := rec.Get("props")
+			page := SnapshotNode{Kind: "node", Key: recordString(rec, "slug"), Labels: []string{"WikiPage"}}
+			page.Props, page.Types = snapshotProps(props.(map[string]any))
+			pages = append(pages, page)
+		}
+		return pages, records.Err()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export wiki: %w", err)
+	}
+
+	for _, page := range result.([]SnapshotNode) {
+		if err := emit(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // extractTOC parses markdown headings to build table of contents
 func extractTOC(content string) []models.TOCItem {
 	var toc []models.TOCItem
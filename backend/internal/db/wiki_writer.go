@@ -3,7 +3,10 @@ package db
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"slices"
 	"time"
 
 	"github.com/dpolishuk/neograph/backend/internal/models"
@@ -26,6 +29,10 @@ func (w *WikiWriter) WritePage(ctx context.Context, page *models.WikiPage) error
 	}
 	page.GeneratedAt = time.Now()
 
+	if page.Sources == nil {
+		page.Sources = []string{}
+	}
+
 	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		// Serialize diagrams to JSON
 		diagramsJSON, err := json.Marshal(page.Diagrams)
@@ -42,18 +49,22 @@ func (w *WikiWriter) WritePage(ctx context.Context, page *models.WikiPage) error
 			    w.order = $order,
 			    w.parentSlug = $parentSlug,
 			    w.diagrams = $diagrams,
-			    w.generatedAt = datetime()
+			    w.generatedAt = $generatedAt,
+			    w.sources = $sources,
+			    w.staleFiles = []
 			MERGE (r)-[:HAS_WIKI]->(w)
 		`
 		_, err = tx.Run(ctx, query, map[string]any{
-			"id":         page.ID,
-			"repoId":     page.RepoID,
-			"slug":       page.Slug,
-			"title":      page.Title,
-			"content":    page.Content,
-			"order":      page.Order,
-			"parentSlug": page.ParentSlug,
-			"diagrams":   string(diagramsJSON),
+			"id":          page.ID,
+			"repoId":      page.RepoID,
+			"slug":        page.Slug,
+			"title":       page.Title,
+			"content":     page.Content,
+			"order":       page.Order,
+			"parentSlug":  page.ParentSlug,
+			"diagrams":    string(diagramsJSON),
+			"generatedAt": time.Now().UTC(),
+			"sources":     page.Sources,
 		})
 		return nil, err
 	})
@@ -61,14 +72,110 @@ func (w *WikiWriter) WritePage(ctx context.Context, page *models.WikiPage) error
 	return err
 }
 
-// ClearWiki removes all wiki pages for a repository
+// ChangelogSlug is the wiki page reindexes append graph changes to. It is
+// kept when the rest of the wiki is regenerated.
+const ChangelogSlug = "graph-changelog"
+
+// SetPageEmbeddings stores the vectors of a repository's wiki pages in a
+// space, vectors[i] belonging to the page slugs[i], for documentation search
+func (w *WikiWriter) SetPageEmbeddings(ctx context.Context, repoID string, space VectorSpace, slugs []string, vectors [][]float32) error {
+	if len(slugs) != len(vectors) {
+		return fmt.Errorf("got %d vectors for %d pages", len(vectors), len(slugs))
+	}
+
+	rows := make([]map[string]any, len(slugs))
+	for i, slug := range slugs {
+		if err := space.CheckDimension(vectors[i]); err != nil {
+			return err
+		}
+		rows[i] = map[string]any{"slug": slug, "embedding": vectors[i]}
+	}
+	_, err := w.client.ExecuteWrite(catalog(ctx), func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			UNWIND $rows AS row
+			MATCH (w:WikiPage {repoId: $repoId, slug: row.slug})
+			SET w.` + "`" + space.Property + "`" + ` = row.embedding
+		`
+		_, err := tx.Run(ctx, query, map[string]any{"rows": rows, "repoId": repoID})
+		return nil, err
+	})
+	return err
+}
+
+// ImportWiki writes the wiki pages ExportWiki emitted, read from r one
+// record per line, as the wiki of repoID, a repository created for the
+// import. Pages get new IDs; their embeddings are kept. Returns how many
+// pages were written.
+func (w *WikiWriter) ImportWiki(ctx context.Context, repoID string, r io.Reader) (int, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	imported := 0
+	var rows []map[string]any
+	for {
+		var page SnapshotNode
+		err := dec.Decode(&page)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return imported, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+		}
+		if page.Kind != "node" || page.Key == "" || !slices.Equal(page.Labels, []string{"WikiPage"}) {
+			return imported, fmt.Errorf("%w: record %q is not a wiki page", ErrInvalidSnapshot, page.Key)
+		}
+		props, err := restoreProps(page.Props, page.Types)
+		if err != nil {
+			return imported, err
+		}
+		props["id"] = uuid.New().String()
+		props["repoId"] = repoID
+		props["slug"] = page.Key
+		rows = append(rows, props)
+
+		if len(rows) >= importBatchSize {
+			if err := w.writePages(ctx, repoID, rows); err != nil {
+				return imported, err
+			}
+			imported += len(rows)
+			rows = rows[:0]
+		}
+	}
+	if len(rows) > 0 {
+		if err := w.writePages(ctx, repoID, rows); err != nil {
+			return imported, err
+		}
+		imported += len(rows)
+	}
+	return imported, nil
+}
+
+// writePages creates imported wiki pages from their properties
+func (w *WikiWriter) writePages(ctx context.Context, repoID string, rows []map[string]any) error {
+	_, err := w.client.ExecuteWrite(catalog(ctx), func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})
+			UNWIND $rows AS row
+			CREATE (r)-[:HAS_WIKI]->(w:WikiPage)
+			SET w = row
+		`
+		_, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "rows": rows})
+		return nil, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import wiki: %w", err)
+	}
+	return nil
+}
+
+// ClearWiki removes all generated wiki pages for a repository
 func (w *WikiWriter) ClearWiki(ctx context.Context, repoID string) error {
 	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		query := `
 			MATCH (r:Repository {id: $repoId})-[:HAS_WIKI]->(w:WikiPage)
+			WHERE w.slug <> $changelog
 			DETACH DELETE w
 		`
-		_, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		_, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "changelog": ChangelogSlug})
 		return nil, err
 	})
 
@@ -84,7 +191,8 @@ func (w *WikiWriter) UpdateWikiStatus(ctx context.Context, repoID string, status
 			    r.wikiProgress = $progress,
 			    r.wikiCurrentPage = $currentPage,
 			    r.wikiTotalPages = $totalPages,
-			    r.wikiError = $errorMessage
+			    r.wikiError = $errorMessage,
+			    r.wikiStale = $stale
 		`
 		_, err := tx.Run(ctx, query, map[string]any{
 			"repoId":       repoID,
@@ -93,6 +201,7 @@ func (w *WikiWriter) UpdateWikiStatus(ctx context.Context, repoID string, status
 			"currentPage":  status.CurrentPage,
 			"totalPages":   status.TotalPages,
 			"errorMessage": status.ErrorMessage,
+			"stale":        status.Stale,
 		})
 		return nil, err
 	})
@@ -100,6 +209,21 @@ func (w *WikiWriter) UpdateWikiStatus(ctx context.Context, repoID string, status
 	return err
 }
 
+// MarkWikiStale flags a generated wiki as out of date with the indexed code
+func (w *WikiWriter) MarkWikiStale(ctx context.Context, repoID string) error {
+	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})
+			WHERE r.wikiStatus = 'ready'
+			SET r.wikiStale = true
+		`
+		_, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
+		return nil, err
+	})
+
+	return err
+}
+
 // GetWikiStatus retrieves the wiki generation status for a repository
 func (w *WikiWriter) GetWikiStatus(ctx context.Context, repoID string) (*models.WikiStatus, error) {
 	result, err := w.client.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
@@ -107,7 +231,7 @@ func (w *WikiWriter) GetWikiStatus(ctx context.Context, repoID string) (*models.
 			MATCH (r:Repository {id: $repoId})
 			RETURN r.wikiStatus as status, r.wikiProgress as progress,
 			       r.wikiCurrentPage as currentPage, r.wikiTotalPages as totalPages,
-			       r.wikiError as errorMessage
+			       r.wikiError as errorMessage, r.wikiStale as stale
 		`
 		records, err := tx.Run(ctx, query, map[string]any{"repoId": repoID})
 		if err != nil {
@@ -143,6 +267,10 @@ func (w *WikiWriter) GetWikiStatus(ctx context.Context, repoID string) (*models.
 			status.ErrorMessage = em.(string)
 		}
 
+		if st, _ := rec.Get("stale"); st != nil {
+			status.Stale = st.(bool)
+		}
+
 		return status, records.Err()
 	})
 
@@ -154,3 +282,24 @@ func (w *WikiWriter) GetWikiStatus(ctx context.Context, repoID string) (*models.
 	}
 	return result.(*models.WikiStatus), nil
 }
+
+// MarkPagesStale records on each wiki page which of its sources are among
+// the changed files, keeping those recorded by earlier reindexes
+func (w *WikiWriter) MarkPagesStale(ctx context.Context, repoID string, changed []string) error {
+	if len(changed) == 0 {
+		return nil
+	}
+	_, err := w.client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (r:Repository {id: $repoId})-[:HAS_WIKI]->(w:WikiPage)
+			WITH w, [s IN coalesce(w.sources, []) WHERE s IN $changed
+			         AND NOT s IN coalesce(w.staleFiles, [])] AS stale
+			WHERE size(stale) > 0
+			SET w.staleFiles = coalesce(w.staleFiles, []) + stale
+		`
+		_, err := tx.Run(ctx, query, map[string]any{"repoId": repoID, "changed": changed})
+		return nil, err
+	})
+
+	return err
+}
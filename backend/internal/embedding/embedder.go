@@ -0,0 +1,55 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Embedding providers
+const (
+	ProviderTEI    = "tei"    // Hugging Face Text Embeddings Inference
+	ProviderOpenAI = "openai" // OpenAI-compatible /v1/embeddings: OpenAI, Azure OpenAI, vLLM
+	ProviderOllama = "ollama" // a local Ollama server
+)
+
+// Base URLs used when none is configured
+const (
+	defaultOpenAIURL = "https://api.openai.com/v1"
+	defaultOllamaURL = "http://localhost:11434"
+)
+
+// Embedder turns texts into embedding vectors, one per text in order
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// Config selects and configures an embedding provider
+type Config struct {
+	Provider string // ProviderTEI, ProviderOpenAI or ProviderOllama; empty means TEI
+	TEIURL   string // server of ProviderTEI
+	URL      string // base URL of the other providers, see OpenAIClient; empty for their default
+	Model    string // sent to providers serving several models
+	APIKey   string
+}
+
+// New returns the embedder of the configured provider
+func New(cfg Config) (Embedder, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Provider)) {
+	case "", ProviderTEI:
+		return NewTEIClient(cfg.TEIURL), nil
+	case ProviderOpenAI:
+		return NewOpenAIClient(orDefault(cfg.URL, defaultOpenAIURL), cfg.Model, cfg.APIKey), nil
+	case ProviderOllama:
+		return NewOllamaClient(orDefault(cfg.URL, defaultOllamaURL), cfg.Model), nil
+	default:
+		return nil, fmt.Errorf("unknown embeddings provider %q, expected tei, openai or ollama", cfg.Provider)
+	}
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
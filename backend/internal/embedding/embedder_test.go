@@ -0,0 +1,24 @@
+package embedding
+
+import "testing"
+
+func TestNew(t *testing.T) {
+	if e, err := New(Config{TEIURL: "http://tei"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if _, ok := e.(*TEIClient); !ok {
+		t.Errorf("expected a TEI client by default, got %T", e)
+	}
+	if e, err := New(Config{Provider: "OpenAI", URL: "https://api.openai.com/v1"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if _, ok := e.(*OpenAIClient); !ok {
+		t.Errorf("expected an OpenAI client, got %T", e)
+	}
+	if e, err := New(Config{Provider: "ollama"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if c, ok := e.(*OllamaClient); !ok || c.baseURL != defaultOllamaURL {
+		t.Errorf("expected an Ollama client at the default URL, got %#v", e)
+	}
+	if _, err := New(Config{Provider: "cohere"}); err == nil {
+		t.Error("expected error for an unknown provider")
+	}
+}
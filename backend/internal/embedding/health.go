@@ -0,0 +1,51 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Pinger is an embedder able to check its service is up without embedding
+// anything
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping checks the service behind an embedder answers, looking through the
+// embedders wrapping it. Services that can't be pinged are asked to embed a
+// single word instead.
+func Ping(ctx context.Context, embedder Embedder) error {
+	for {
+		if pinger, ok := embedder.(Pinger); ok {
+			return pinger.Ping(ctx)
+		}
+		wrapper, ok := embedder.(interface{ Unwrap() Embedder })
+		if !ok {
+			break
+		}
+		embedder = wrapper.Unwrap()
+	}
+	_, err := embedder.Embed(ctx, []string{"ping"})
+	return err
+}
+
+// ping checks a GET of url is answered with 200 OK
+func ping(ctx context.Context, httpClient *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("failed to send request: %w", err)
+		}
+		return fmt.Errorf("failed to send request: %w: %w", ErrUnavailable, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: status %d", ErrUnavailable, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/health" {
+			t.Errorf("expected GET /health, got %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	// Pinged through the wrapping embedders, without embedding
+	embedder := NewLimited(NewTEIClient(server.URL), 1, 1)
+	if err := Ping(context.Background(), embedder); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := Ping(context.Background(), embedder); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable, got %v", err)
+	}
+
+	if err := Ping(context.Background(), NewTEIClient("")); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable for an unconfigured client, got %v", err)
+	}
+}
+
+func TestPingEmbeds(t *testing.T) {
+	fake := &fakeEmbedder{}
+	if err := Ping(context.Background(), NewLimited(fake, 1, 1)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls := fake.calls.Load(); calls != 1 {
+		t.Errorf("expected 1 embedding request, got %d", calls)
+	}
+}
@@ -0,0 +1,76 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// retryBackoff is the pause before the second attempt, doubled after each
+var retryBackoff = 500 * time.Millisecond
+
+// maxRetryBackoff caps the pause between attempts
+const maxRetryBackoff = 30 * time.Second
+
+// Limited wraps an embedder shared by indexing, migrations and searches so
+// that at most a given number of requests are in flight at once, and
+// requests failing transiently, while the service is unreachable,
+// overloaded or rate limiting, are retried with exponential backoff
+type Limited struct {
+	embedder Embedder
+	attempts int
+	slots    chan struct{}
+}
+
+// NewLimited wraps embedder, running each request up to attempts times and
+// at most concurrency at once. Values below 1 are taken as 1.
+func NewLimited(embedder Embedder, attempts, concurrency int) *Limited {
+	return &Limited{
+		embedder: embedder,
+		attempts: max(attempts, 1),
+		slots:    make(chan struct{}, max(concurrency, 1)),
+	}
+}
+
+// Unwrap returns the embedder requests are limited to
+func (l *Limited) Unwrap() Embedder {
+	return l.embedder
+}
+
+func (l *Limited) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	// The slot is held while backing off, easing the load on the service
+	defer func() { <-l.slots }()
+
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		embeddings, err := l.embedder.Embed(ctx, texts)
+		if err == nil || !retryable(err) {
+			return embeddings, err
+		}
+		if attempt >= l.attempts {
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+
+		log.Printf("Embedding request failed on attempt %d, retrying in %s: %v", attempt, backoff, err)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// retryable reports whether an embedding request may succeed when retried:
+// the service is configured but was unreachable, overloaded or rate limiting
+func retryable(err error) bool {
+	var unconfigured *unconfiguredError
+	return errors.Is(err, ErrUnavailable) && !errors.As(err, &unconfigured)
+}
@@ -0,0 +1,115 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeEmbedder fails its first failures calls with err
+type fakeEmbedder struct {
+	failures int
+	err      error
+	calls    atomic.Int32
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (f *fakeEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	f.mu.Lock()
+	f.inFlight++
+	f.peak = max(f.peak, f.inFlight)
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+
+	if int(f.calls.Add(1)) <= f.failures {
+		return nil, f.err
+	}
+	time.Sleep(5 * time.Millisecond)
+	return make([][]float32, len(texts)), nil
+}
+
+func withoutBackoff(t *testing.T) {
+	saved := retryBackoff
+	retryBackoff = time.Millisecond
+	t.Cleanup(func() { retryBackoff = saved })
+}
+
+func TestLimited_RetriesTransientErrors(t *testing.T) {
+	withoutBackoff(t)
+	rateLimited := fmt.Errorf("%w: %w: status 429", ErrUnavailable, ErrRateLimited)
+
+	fake := &fakeEmbedder{failures: 2, err: rateLimited}
+	if _, err := NewLimited(fake, 3, 1).Embed(context.Background(), []string{"a"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := fake.calls.Load(); n != 3 {
+		t.Errorf("expected 3 calls, got %d", n)
+	}
+
+	fake = &fakeEmbedder{failures: 5, err: rateLimited}
+	_, err := NewLimited(fake, 2, 1).Embed(context.Background(), []string{"a"})
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited after giving up, got %v", err)
+	}
+	if n := fake.calls.Load(); n != 2 {
+		t.Errorf("expected 2 calls, got %d", n)
+	}
+}
+
+func TestLimited_DoesNotRetryPermanentErrors(t *testing.T) {
+	withoutBackoff(t)
+
+	for _, err := range []error{errors.New("TEI error (status 413)"), &unconfiguredError{"TEI_URL"}} {
+		fake := &fakeEmbedder{failures: 1, err: err}
+		if _, got := NewLimited(fake, 3, 1).Embed(context.Background(), []string{"a"}); got == nil {
+			t.Errorf("expected %v to be returned", err)
+		}
+		if n := fake.calls.Load(); n != 1 {
+			t.Errorf("%v: expected 1 call, got %d", err, n)
+		}
+	}
+}
+
+func TestLimited_Concurrency(t *testing.T) {
+	fake := &fakeEmbedder{}
+	limited := NewLimited(fake, 1, 2)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limited.Embed(context.Background(), []string{"a"})
+		}()
+	}
+	wg.Wait()
+
+	if fake.peak > 2 {
+		t.Errorf("expected at most 2 requests in flight, got %d", fake.peak)
+	}
+}
+
+func TestEmbed_RateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := NewTEIClient(server.URL).Embed(context.Background(), []string{"text1"})
+	if !errors.Is(err, ErrRateLimited) || !retryable(err) {
+		t.Errorf("expected a retryable ErrRateLimited, got %v", err)
+	}
+}
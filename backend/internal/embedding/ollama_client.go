@@ -0,0 +1,98 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OllamaClient embeds texts with a local Ollama server's /api/embed, for
+// self-hosted setups without TEI or an external API. The model must have
+// been pulled, e.g. `ollama pull nomic-embed-text`.
+type OllamaClient struct {
+	baseURL    string
+	model      string
+	httpClient *http.Client
+}
+
+func NewOllamaClient(baseURL, model string) *OllamaClient {
+	return &OllamaClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		model:   model,
+		httpClient: &http.Client{
+			// Ollama loads the model on the first request
+			Timeout: 2 * time.Minute,
+		},
+	}
+}
+
+// Ping checks the Ollama server answers
+func (c *OllamaClient) Ping(ctx context.Context) error {
+	if c.baseURL == "" {
+		return &unconfiguredError{"EMBEDDINGS_URL"}
+	}
+	return ping(ctx, c.httpClient, c.baseURL+"/api/version")
+}
+
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+func (c *OllamaClient) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+	if c.baseURL == "" {
+		return nil, &unconfiguredError{"EMBEDDINGS_URL"}
+	}
+
+	reqBody, err := json.Marshal(ollamaEmbedRequest{Model: c.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/embed", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("failed to send request: %w", err)
+		}
+		return nil, fmt.Errorf("failed to send request: %w: %w", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return nil, fmt.Errorf("%w: status %d", ErrUnavailable, resp.StatusCode)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Ollama error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var decoded ollamaEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(decoded.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("got %d embeddings for %d inputs", len(decoded.Embeddings), len(texts))
+	}
+
+	return decoded.Embeddings, nil
+}
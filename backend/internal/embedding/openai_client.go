@@ -0,0 +1,130 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OpenAIClient embeds texts through an OpenAI-compatible embeddings API.
+// The base URL includes the version, such as https://api.openai.com/v1 or a
+// vLLM server's http://host:8000/v1; for Azure OpenAI it is the deployment,
+// https://<resource>.openai.azure.com/openai/deployments/<name>?api-version=<version>.
+type OpenAIClient struct {
+	baseURL    string
+	model      string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func NewOpenAIClient(baseURL, model, apiKey string) *OpenAIClient {
+	return &OpenAIClient{
+		baseURL: baseURL,
+		model:   model,
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+type openAIEmbedRequest struct {
+	Input []string `json:"input"`
+	Model string   `json:"model,omitempty"`
+}
+
+type openAIEmbedResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// endpoint returns the embeddings URL under the base URL, keeping its query
+// for Azure's api-version
+func (c *OpenAIClient) endpoint() (string, error) {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return "", err
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/embeddings"
+	return u.String(), nil
+}
+
+func (c *OpenAIClient) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+	if c.baseURL == "" {
+		return nil, &unconfiguredError{"EMBEDDINGS_URL"}
+	}
+
+	endpoint, err := c.endpoint()
+	if err != nil {
+		return nil, fmt.Errorf("invalid embeddings URL: %w", err)
+	}
+
+	reqBody, err := json.Marshal(openAIEmbedRequest{Input: texts, Model: c.model})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		// Azure authenticates keys by its own header, the others by bearer
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		req.Header.Set("api-key", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("failed to send request: %w", err)
+		}
+		return nil, fmt.Errorf("failed to send request: %w: %w", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return nil, fmt.Errorf("%w: %w: status %d", ErrUnavailable, ErrRateLimited, resp.StatusCode)
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return nil, fmt.Errorf("%w: status %d", ErrUnavailable, resp.StatusCode)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("embeddings API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var decoded openAIEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	// Results carry the index of their input and need not arrive in order
+	embeddings := make([][]float32, len(texts))
+	for _, d := range decoded.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range for %d inputs", d.Index, len(texts))
+		}
+		embeddings[d.Index] = d.Embedding
+	}
+	for i, e := range embeddings {
+		if e == nil {
+			return nil, fmt.Errorf("no embedding returned for input %d", i)
+		}
+	}
+
+	return embeddings, nil
+}
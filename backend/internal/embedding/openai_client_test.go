@@ -0,0 +1,90 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIEmbed_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("expected /v1/embeddings, got %s", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer secret" {
+			t.Errorf("expected bearer authorization, got %q", auth)
+		}
+
+		var req openAIEmbedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if req.Model != "text-embedding-3-small" || len(req.Input) != 2 {
+			t.Errorf("unexpected request %+v", req)
+		}
+
+		// Out of order, as the API allows
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"object":"list","data":[
+			{"object":"embedding","index":1,"embedding":[0.4,0.5]},
+			{"object":"embedding","index":0,"embedding":[0.1,0.2]}
+		],"model":"text-embedding-3-small"}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient(server.URL+"/v1/", "text-embedding-3-small", "secret")
+	embeddings, err := client.Embed(context.Background(), []string{"text1", "text2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(embeddings) != 2 || embeddings[0][0] != 0.1 || embeddings[1][0] != 0.4 {
+		t.Errorf("expected embeddings in input order, got %v", embeddings)
+	}
+}
+
+func TestOpenAIEmbed_AzureQuery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/embed/embeddings" || r.URL.Query().Get("api-version") != "2024-02-01" {
+			t.Errorf("unexpected URL %s", r.URL)
+		}
+		if key := r.Header.Get("api-key"); key != "secret" {
+			t.Errorf("expected api-key header, got %q", key)
+		}
+		w.Write([]byte(`{"data":[{"index":0,"embedding":[1]}]}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient(server.URL+"/openai/deployments/embed?api-version=2024-02-01", "", "secret")
+	if _, err := client.Embed(context.Background(), []string{"text"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestOpenAIEmbed_MissingEmbedding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"index":0,"embedding":[1]}]}`))
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient(server.URL, "model", "")
+	if _, err := client.Embed(context.Background(), []string{"a", "b"}); err == nil {
+		t.Fatal("expected error for a missing embedding, got nil")
+	}
+}
+
+func TestOpenAIEmbed_Unavailable(t *testing.T) {
+	limited := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer limited.Close()
+
+	for _, baseURL := range []string{"", limited.URL} {
+		client := NewOpenAIClient(baseURL, "model", "")
+		if _, err := client.Embed(context.Background(), []string{"text"}); !errors.Is(err, ErrUnavailable) {
+			t.Errorf("%q: expected ErrUnavailable, got %v", baseURL, err)
+		}
+	}
+}
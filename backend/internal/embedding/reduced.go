@@ -0,0 +1,160 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+// Dimensionality reductions
+const (
+	// ReductionTruncate keeps the leading dimensions, for models trained to
+	// front-load them (Matryoshka embeddings such as OpenAI's
+	// text-embedding-3 and nomic-embed-text)
+	ReductionTruncate = "truncate"
+	// ReductionProjection multiplies vectors by a fixed random matrix, which
+	// roughly preserves their cosine similarities for any model
+	ReductionProjection = "projection"
+)
+
+// projectionSeed seeds the projection matrix. Stored vectors were projected
+// with it, so changing it invalidates every vector index.
+const projectionSeed = 0x6e656f6772617068
+
+// Reduced wraps an embedder to shorten its vectors to a smaller dimension,
+// shrinking what is stored and indexed per entity. Indexing and searching
+// embed through the same Reduced, so queries are reduced like the vectors
+// they are compared with. Reduced vectors are normalized to unit length.
+type Reduced struct {
+	embedder  Embedder
+	method    string
+	dimension int
+
+	mu         sync.Mutex
+	projection [][]float32 // dimension rows of the input dimension, built on first use
+}
+
+// NewReduced wraps embedder to reduce its vectors to dimension by
+// ReductionTruncate or ReductionProjection
+func NewReduced(embedder Embedder, method string, dimension int) (*Reduced, error) {
+	method = strings.ToLower(strings.TrimSpace(method))
+	if method != ReductionTruncate && method != ReductionProjection {
+		return nil, fmt.Errorf("unknown embedding reduction %q, expected truncate or projection", method)
+	}
+	if dimension < 1 {
+		return nil, fmt.Errorf("embedding reduction needs a dimension of at least 1, got %d", dimension)
+	}
+	return &Reduced{embedder: embedder, method: method, dimension: dimension}, nil
+}
+
+// Unwrap returns the embedder whose vectors are reduced
+func (r *Reduced) Unwrap() Embedder {
+	return r.embedder
+}
+
+// Dimension returns the dimension vectors of input dimensions are reduced
+// to, failing when they are shorter
+func (r *Reduced) Dimension(input int) (int, error) {
+	if input < r.dimension {
+		return 0, fmt.Errorf("cannot reduce %d-dimensional embeddings to %d dimensions", input, r.dimension)
+	}
+	return r.dimension, nil
+}
+
+// Model names the reduced vectors of a model, so vector spaces of other
+// reductions are told apart
+func (r *Reduced) Model(model string) string {
+	return model + "+" + r.method
+}
+
+func (r *Reduced) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings, err := r.embedder.Embed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+
+	reduced := make([][]float32, len(embeddings))
+	for i, vector := range embeddings {
+		if _, err := r.Dimension(len(vector)); err != nil {
+			return nil, err
+		}
+		if r.method == ReductionTruncate {
+			reduced[i] = normalize(append([]float32(nil), vector[:r.dimension]...))
+		} else {
+			reduced[i] = normalize(project(r.projectionFor(len(vector)), vector))
+		}
+	}
+	return reduced, nil
+}
+
+// projectionFor returns the projection matrix of vectors of input
+// dimensions, rebuilt if the model's dimension changed
+func (r *Reduced) projectionFor(input int) [][]float32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.projection) == 0 || len(r.projection[0]) != input {
+		r.projection = projectionMatrix(input, r.dimension)
+	}
+	return r.projection
+}
+
+// projectionMatrix builds a random projection from input to output
+// dimensions with entries of ±1/√output (Achlioptas). The signs come from
+// splitmix64 seeded with projectionSeed rather than math/rand, whose
+// sequences are not guaranteed to stay the same across Go releases.
+func projectionMatrix(input, output int) [][]float32 {
+	state := uint64(projectionSeed)
+	scale := float32(1 / math.Sqrt(float64(output)))
+
+	matrix := make([][]float32, output)
+	for i := range matrix {
+		row := make([]float32, input)
+		for j := 0; j < input; j += 64 {
+			state += 0x9e3779b97f4a7c15
+			z := state
+			z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+			z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+			bits := z ^ (z >> 31)
+			for k := j; k < min(j+64, input); k++ {
+				if bits&(1<<(k-j)) != 0 {
+					row[k] = scale
+				} else {
+					row[k] = -scale
+				}
+			}
+		}
+		matrix[i] = row
+	}
+	return matrix
+}
+
+// project multiplies vector by matrix
+func project(matrix [][]float32, vector []float32) []float32 {
+	projected := make([]float32, len(matrix))
+	for i, row := range matrix {
+		var sum float32
+		for j, v := range vector {
+			sum += row[j] * v
+		}
+		projected[i] = sum
+	}
+	return projected
+}
+
+// normalize scales vector to unit length in place, leaving zero vectors
+func normalize(vector []float32) []float32 {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return vector
+	}
+	scale := float32(1 / math.Sqrt(sum))
+	for i := range vector {
+		vector[i] *= scale
+	}
+	return vector
+}
@@ -0,0 +1,96 @@
+package embedding
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// vectorEmbedder returns its vectors for any texts
+type vectorEmbedder struct {
+	vectors [][]float32
+}
+
+func (v *vectorEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return v.vectors, nil
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+func TestReduced_Truncate(t *testing.T) {
+	reduced, err := NewReduced(&vectorEmbedder{[][]float32{{3, 4, 12}}}, "truncate", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	vectors, err := reduced.Embed(context.Background(), []string{"a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := vectors[0]; len(got) != 2 || math.Abs(float64(got[0])-0.6) > 1e-6 || math.Abs(float64(got[1])-0.8) > 1e-6 {
+		t.Errorf("expected [0.6 0.8], got %v", got)
+	}
+}
+
+func TestReduced_ProjectionKeepsSimilarities(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	base := make([]float32, 768)
+	for i := range base {
+		base[i] = float32(rng.NormFloat64())
+	}
+	// A near-duplicate of base and an unrelated vector
+	similar, unrelated := make([]float32, 768), make([]float32, 768)
+	for i := range base {
+		similar[i] = base[i] + 0.3*float32(rng.NormFloat64())
+		unrelated[i] = float32(rng.NormFloat64())
+	}
+
+	reduced, err := NewReduced(&vectorEmbedder{[][]float32{base, similar, unrelated}}, "projection", 256)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	vectors, err := reduced.Embed(context.Background(), []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := len(vectors[0]); n != 256 {
+		t.Fatalf("expected 256 dimensions, got %d", n)
+	}
+	if diff := math.Abs(cosine(vectors[0], vectors[1]) - cosine(base, similar)); diff > 0.1 {
+		t.Errorf("similar vectors' cosine moved by %.3f", diff)
+	}
+	if diff := math.Abs(cosine(vectors[0], vectors[2]) - cosine(base, unrelated)); diff > 0.15 {
+		t.Errorf("unrelated vectors' cosine moved by %.3f", diff)
+	}
+
+	// Queries must be projected like the stored vectors
+	again, _ := reduced.Embed(context.Background(), []string{"a", "b", "c"})
+	if cosine(vectors[0], again[0]) < 0.999999 {
+		t.Error("expected the same projection on every call")
+	}
+	if projectionMatrix(768, 256)[5][700] != reduced.projectionFor(768)[5][700] {
+		t.Error("expected a fixed projection matrix")
+	}
+}
+
+func TestReduced_RejectsShortVectors(t *testing.T) {
+	reduced, _ := NewReduced(&vectorEmbedder{[][]float32{{1, 2}}}, "truncate", 4)
+	if _, err := reduced.Embed(context.Background(), []string{"a"}); err == nil {
+		t.Error("expected an error for vectors shorter than the reduced dimension")
+	}
+	if _, err := NewReduced(reduced, "pca", 4); err == nil {
+		t.Error("expected an error for an unknown reduction")
+	}
+	if got := reduced.Model("nomic-embed-text"); got != "nomic-embed-text+truncate" {
+		t.Errorf("unexpected model name %q", got)
+	}
+}
@@ -4,12 +4,40 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
 )
 
+// ErrUnavailable is returned when TEI is not configured or cannot be reached
+var ErrUnavailable = errors.New("embedding service unavailable")
+
+// ErrRateLimited is returned, wrapped in ErrUnavailable, when the embedding
+// service asks to slow down
+var ErrRateLimited = errors.New("rate limited")
+
+// unconfiguredError is an ErrUnavailable no retry can fix
+type unconfiguredError struct {
+	setting string
+}
+
+func (e *unconfiguredError) Error() string {
+	return ErrUnavailable.Error() + ": " + e.setting + " is not set"
+}
+
+func (e *unconfiguredError) Is(target error) bool {
+	return target == ErrUnavailable
+}
+
+// IsUnconfigured reports whether err is from an embedder whose service is
+// not configured, rather than down
+func IsUnconfigured(err error) bool {
+	var unconfigured *unconfiguredError
+	return errors.As(err, &unconfigured)
+}
+
 type TEIClient struct {
 	baseURL    string
 	httpClient *http.Client
@@ -24,6 +52,14 @@ func NewTEIClient(baseURL string) *TEIClient {
 	}
 }
 
+// Ping checks TEI reports itself healthy
+func (c *TEIClient) Ping(ctx context.Context) error {
+	if c.baseURL == "" {
+		return &unconfiguredError{"TEI_URL"}
+	}
+	return ping(ctx, c.httpClient, c.baseURL+"/health")
+}
+
 type EmbedRequest struct {
 	Inputs []string `json:"inputs"`
 }
@@ -32,6 +68,9 @@ func (c *TEIClient) Embed(ctx context.Context, texts []string) ([][]float32, err
 	if len(texts) == 0 {
 		return [][]float32{}, nil
 	}
+	if c.baseURL == "" {
+		return nil, &unconfiguredError{"TEI_URL"}
+	}
 
 	reqBody, err := json.Marshal(EmbedRequest{Inputs: texts})
 	if err != nil {
@@ -46,10 +85,20 @@ func (c *TEIClient) Embed(ctx context.Context, texts []string) ([][]float32, err
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("failed to send request: %w", err)
+		}
+		return nil, fmt.Errorf("failed to send request: %w: %w", ErrUnavailable, err)
 	}
 	defer resp.Body.Close()
 
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return nil, fmt.Errorf("%w: %w: status %d", ErrUnavailable, ErrRateLimited, resp.StatusCode)
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return nil, fmt.Errorf("%w: status %d", ErrUnavailable, resp.StatusCode)
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		return nil, fmt.Errorf("TEI error (status %d): %s", resp.StatusCode, string(body))
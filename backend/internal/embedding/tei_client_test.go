@@ -3,6 +3,7 @@ package embedding
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -154,3 +155,42 @@ func TestEmbed_NetworkError(t *testing.T) {
 		t.Errorf("expected error message to start with %q, got %q", expectedMsg, err.Error())
 	}
 }
+
+func TestEmbed_Unavailable(t *testing.T) {
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	tests := []struct {
+		name    string
+		baseURL string
+	}{
+		{"unconfigured", ""},
+		{"unreachable", "http://invalid-host-that-does-not-exist:9999"},
+		{"service unavailable", unavailable.URL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewTEIClient(tt.baseURL)
+			_, err := client.Embed(context.Background(), []string{"text1"})
+			if !errors.Is(err, ErrUnavailable) {
+				t.Errorf("expected ErrUnavailable, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEmbed_ServerErrorIsNotUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewTEIClient(server.URL)
+	_, err := client.Embed(context.Background(), []string{"text1"})
+	if err == nil || errors.Is(err, ErrUnavailable) {
+		t.Errorf("expected a plain error, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Types of events
+const (
+	TypeRepository = "repository" // the repository's status changed
+	TypeIndex      = "index"      // indexing reached a stage or made progress in one
+	TypeWiki       = "wiki"       // wiki generation status or progress
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before
+// further events are dropped for it
+const subscriberBuffer = 64
+
+// Event is a status transition or progress report of a repository
+type Event struct {
+	Type   string    `json:"type"`
+	RepoID string    `json:"repoId"`
+	Time   time.Time `json:"time"`
+	// Repository or wiki status, e.g. indexing, ready, error
+	Status string `json:"status,omitempty"`
+	// Index stage, e.g. clone, parse, embed, and how far it got, when known
+	Stage string `json:"stage,omitempty"`
+	Done  int    `json:"done,omitempty"`
+	Total int    `json:"total,omitempty"`
+	// Wiki generation: percent done and the page being written
+	Progress    int    `json:"progress,omitempty"`
+	CurrentPage string `json:"currentPage,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Broker fans out the events of repositories to their subscribers within
+// this process. Publishing never blocks: a subscriber not keeping up misses
+// events, which only report progress anyway.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan Event]struct{} // repository ID -> channels
+	closed      bool
+}
+
+// NewBroker creates a broker without subscribers
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[string]map[chan Event]struct{})}
+}
+
+// Subscribe returns a channel receiving the events of a repository and a
+// function ending the subscription. The channel is closed when the
+// subscription ends or the broker is closed.
+func (b *Broker) Subscribe(repoID string) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if b.subscribers[repoID] == nil {
+		b.subscribers[repoID] = make(map[chan Event]struct{})
+	}
+	b.subscribers[repoID][ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[repoID][ch]; !ok {
+			return
+		}
+		delete(b.subscribers[repoID], ch)
+		if len(b.subscribers[repoID]) == 0 {
+			delete(b.subscribers, repoID)
+		}
+		close(ch)
+	}
+}
+
+// Publish sends an event to the subscribers of its repository, stamping it
+// with the current time when it has none
+func (b *Broker) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers[event.RepoID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Close ends every subscription; later subscriptions end at once
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for _, channels := range b.subscribers {
+		for ch := range channels {
+			close(ch)
+		}
+	}
+	b.subscribers = make(map[string]map[chan Event]struct{})
+}
@@ -0,0 +1,65 @@
+package events
+
+import "testing"
+
+func TestBrokerDeliversToRepositorySubscribers(t *testing.T) {
+	b := NewBroker()
+	defer b.Close()
+
+	one, unsubscribe := b.Subscribe("r1")
+	defer unsubscribe()
+	other, unsubscribeOther := b.Subscribe("r2")
+	defer unsubscribeOther()
+
+	b.Publish(Event{Type: TypeRepository, RepoID: "r1", Status: "indexing"})
+
+	select {
+	case event := <-one:
+		if event.Status != "indexing" || event.Time.IsZero() {
+			t.Errorf("unexpected event %+v", event)
+		}
+	default:
+		t.Fatal("expected an event for r1")
+	}
+	select {
+	case event := <-other:
+		t.Errorf("r2 received an event of r1: %+v", event)
+	default:
+	}
+}
+
+func TestBrokerDropsEventsForSlowSubscribers(t *testing.T) {
+	b := NewBroker()
+	defer b.Close()
+
+	events, unsubscribe := b.Subscribe("r1")
+	defer unsubscribe()
+	for i := 0; i < subscriberBuffer+10; i++ {
+		b.Publish(Event{Type: TypeIndex, RepoID: "r1", Done: i})
+	}
+	if n := len(events); n != subscriberBuffer {
+		t.Errorf("expected %d buffered events, got %d", subscriberBuffer, n)
+	}
+}
+
+func TestBrokerClosesSubscriptions(t *testing.T) {
+	b := NewBroker()
+	events, unsubscribe := b.Subscribe("r1")
+	unsubscribe()
+	unsubscribe() // ending twice is harmless
+	if _, open := <-events; open {
+		t.Error("expected the channel closed after unsubscribing")
+	}
+
+	events, unsubscribe = b.Subscribe("r1")
+	b.Close()
+	if _, open := <-events; open {
+		t.Error("expected the channel closed with the broker")
+	}
+	unsubscribe()
+
+	events, _ = b.Subscribe("r1")
+	if _, open := <-events; open {
+		t.Error("expected subscriptions to a closed broker to end at once")
+	}
+}
@@ -0,0 +1,65 @@
+package findings
+
+import (
+	"github.com/dpolishuk/neograph/backend/internal/coverage"
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// Located is a finding placed on a repository file and, when its line falls
+// inside one, the innermost entity there
+type Located struct {
+	Finding
+	FilePath string
+	EntityID string
+}
+
+// Locate places findings on the repository's files and entities. Findings in
+// files the repository does not have are returned apart.
+func Locate(findings []Finding, files []string, entities []models.CodeEntity) ([]Located, []Finding) {
+	byFile := make(map[string][]models.CodeEntity)
+	for _, entity := range entities {
+		if entity.ID != "" {
+			byFile[entity.FilePath] = append(byFile[entity.FilePath], entity)
+		}
+	}
+
+	resolved := make(map[string]string)
+	var located []Located
+	var unmatched []Finding
+	for _, f := range findings {
+		path, ok := resolved[f.File]
+		if !ok {
+			path = coverage.ResolveFile(f.File, files)
+			resolved[f.File] = path
+		}
+		if path == "" {
+			unmatched = append(unmatched, f)
+			continue
+		}
+
+		loc := Located{Finding: f, FilePath: path}
+		if entity := innermost(byFile[path], f.StartLine); entity != nil {
+			loc.EntityID = entity.ID
+		}
+		located = append(located, loc)
+	}
+	return located, unmatched
+}
+
+// innermost returns the smallest entity spanning line
+func innermost(entities []models.CodeEntity, line int) *models.CodeEntity {
+	if line <= 0 {
+		return nil
+	}
+	var best *models.CodeEntity
+	for i := range entities {
+		e := &entities[i]
+		if line < e.StartLine || line > e.EndLine {
+			continue
+		}
+		if best == nil || e.EndLine-e.StartLine < best.EndLine-best.StartLine {
+			best = e
+		}
+	}
+	return best
+}
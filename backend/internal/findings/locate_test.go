@@ -0,0 +1,40 @@
+package findings
+
+import (
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+func TestLocate(t *testing.T) {
+	files := []string{"store/store.go", "main.go"}
+	entities := []models.CodeEntity{
+		{ID: "Store", Name: "Store", FilePath: "store/store.go", StartLine: 5, EndLine: 40},
+		{ID: "Get", Name: "Get", FilePath: "store/store.go", StartLine: 10, EndLine: 15},
+	}
+	findings := []Finding{
+		{Tool: "golangci-lint", File: "/ci/app/store/store.go", StartLine: 12},
+		{Tool: "golangci-lint", File: "store/store.go", StartLine: 2},
+		{Tool: "Semgrep", File: "main.go"},
+		{Tool: "Semgrep", File: "vendor/lib.go", StartLine: 3},
+	}
+
+	located, unmatched := Locate(findings, files, entities)
+
+	if len(located) != 3 || len(unmatched) != 1 {
+		t.Fatalf("located %d, unmatched %d; want 3, 1", len(located), len(unmatched))
+	}
+	wants := []struct{ path, entity string }{
+		{"store/store.go", "Get"}, // innermost
+		{"store/store.go", ""},    // outside every entity
+		{"main.go", ""},           // no line
+	}
+	for i, want := range wants {
+		if located[i].FilePath != want.path || located[i].EntityID != want.entity {
+			t.Errorf("located[%d] = %q, %q; want %q, %q", i, located[i].FilePath, located[i].EntityID, want.path, want.entity)
+		}
+	}
+	if unmatched[0].File != "vendor/lib.go" {
+		t.Errorf("unmatched = %+v", unmatched)
+	}
+}
@@ -0,0 +1,137 @@
+// Package findings reads static analysis results in SARIF and locates them
+// on indexed files and code entities.
+package findings
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Levels of a finding, as defined by SARIF
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelNote    = "note"
+	LevelNone    = "none"
+)
+
+// Finding is one result reported by an analysis tool
+type Finding struct {
+	Tool      string // e.g. golangci-lint, ESLint, Semgrep
+	RuleID    string
+	Level     string
+	Message   string
+	File      string // as the report wrote it, usually relative to the repository
+	StartLine int    // 0 when the result has no region
+	EndLine   int
+}
+
+// sarifLog is the part of a SARIF 2.1.0 log that is read
+type sarifLog struct {
+	Version string `json:"version"`
+	Runs    []struct {
+		Tool struct {
+			Driver struct {
+				Name  string `json:"name"`
+				Rules []struct {
+					ID                   string `json:"id"`
+					DefaultConfiguration struct {
+						Level string `json:"level"`
+					} `json:"defaultConfiguration"`
+				} `json:"rules"`
+			} `json:"driver"`
+		} `json:"tool"`
+		Results []struct {
+			RuleID    string `json:"ruleId"`
+			RuleIndex *int   `json:"ruleIndex"`
+			Level     string `json:"level"`
+			Message   struct {
+				Text string `json:"text"`
+			} `json:"message"`
+			Locations []struct {
+				PhysicalLocation struct {
+					ArtifactLocation struct {
+						URI string `json:"uri"`
+					} `json:"artifactLocation"`
+					Region struct {
+						StartLine int `json:"startLine"`
+						EndLine   int `json:"endLine"`
+					} `json:"region"`
+				} `json:"physicalLocation"`
+			} `json:"locations"`
+			Suppressions []json.RawMessage `json:"suppressions"`
+		} `json:"results"`
+	} `json:"runs"`
+}
+
+// ParseSARIF reads the results of every run in a SARIF log. Suppressed
+// results and results without a location are skipped; a result's level
+// falls back to its rule's default level, then to warning.
+func ParseSARIF(data []byte) ([]Finding, error) {
+	var doc sarifLog
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid SARIF: %w", err)
+	}
+	if doc.Version != "" && !strings.HasPrefix(doc.Version, "2.") {
+		return nil, fmt.Errorf("unsupported SARIF version %s", doc.Version)
+	}
+
+	findings := []Finding{}
+	for _, run := range doc.Runs {
+		driver := run.Tool.Driver
+		ruleLevels := make(map[string]string, len(driver.Rules))
+		for _, rule := range driver.Rules {
+			ruleLevels[rule.ID] = rule.DefaultConfiguration.Level
+		}
+
+		for _, result := range run.Results {
+			if len(result.Suppressions) > 0 || len(result.Locations) == 0 {
+				continue
+			}
+
+			ruleID := result.RuleID
+			if ruleID == "" && result.RuleIndex != nil && *result.RuleIndex >= 0 && *result.RuleIndex < len(driver.Rules) {
+				ruleID = driver.Rules[*result.RuleIndex].ID
+			}
+			level := result.Level
+			if level == "" {
+				level = ruleLevels[ruleID]
+			}
+			if level == "" {
+				level = LevelWarning
+			}
+
+			location := result.Locations[0].PhysicalLocation
+			file := artifactPath(location.ArtifactLocation.URI)
+			if file == "" {
+				continue
+			}
+			finding := Finding{
+				Tool:      driver.Name,
+				RuleID:    ruleID,
+				Level:     level,
+				Message:   result.Message.Text,
+				File:      file,
+				StartLine: location.Region.StartLine,
+				EndLine:   location.Region.EndLine,
+			}
+			if finding.EndLine < finding.StartLine {
+				finding.EndLine = finding.StartLine
+			}
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
+
+// artifactPath turns an artifact URI, relative or file://, into a path
+func artifactPath(uri string) string {
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		uri = u.Path
+	} else if unescaped, err := url.PathUnescape(uri); err == nil {
+		uri = unescaped
+	}
+	return strings.TrimPrefix(strings.ReplaceAll(uri, "\\", "/"), "./")
+}
@@ -0,0 +1,85 @@
+package findings
+
+import (
+	"reflect"
+	"testing"
+)
+
+const sampleSARIF = `{
+  "version": "2.1.0",
+  "runs": [
+    {
+      "tool": {"driver": {"name": "golangci-lint", "rules": [
+        {"id": "errcheck", "defaultConfiguration": {"level": "error"}},
+        {"id": "unused"}
+      ]}},
+      "results": [
+        {
+          "ruleId": "errcheck",
+          "message": {"text": "Error return value is not checked"},
+          "locations": [{"physicalLocation": {
+            "artifactLocation": {"uri": "internal/store/store.go"},
+            "region": {"startLine": 12, "startColumn": 3}
+          }}]
+        },
+        {
+          "ruleIndex": 1,
+          "message": {"text": "func helper is unused"},
+          "locations": [{"physicalLocation": {
+            "artifactLocation": {"uri": "file:///home/ci/app/util%20s/util.go"},
+            "region": {"startLine": 4, "endLine": 9}
+          }}]
+        },
+        {
+          "ruleId": "errcheck",
+          "message": {"text": "suppressed"},
+          "suppressions": [{"kind": "inSource"}],
+          "locations": [{"physicalLocation": {"artifactLocation": {"uri": "main.go"}}}]
+        },
+        {"ruleId": "errcheck", "message": {"text": "no location"}}
+      ]
+    },
+    {
+      "tool": {"driver": {"name": "ESLint"}},
+      "results": [
+        {
+          "ruleId": "no-unused-vars",
+          "level": "note",
+          "message": {"text": "'x' is defined but never used"},
+          "locations": [{"physicalLocation": {
+            "artifactLocation": {"uri": "./web/app.js"},
+            "region": {"startLine": 1}
+          }}]
+        }
+      ]
+    }
+  ]
+}`
+
+func TestParseSARIF(t *testing.T) {
+	findings, err := ParseSARIF([]byte(sampleSARIF))
+	if err != nil {
+		t.Fatalf("ParseSARIF: %v", err)
+	}
+
+	want := []Finding{
+		{Tool: "golangci-lint", RuleID: "errcheck", Level: LevelError, Message: "Error return value is not checked",
+			File: "internal/store/store.go", StartLine: 12, EndLine: 12},
+		{Tool: "golangci-lint", RuleID: "unused", Level: LevelWarning, Message: "func helper is unused",
+			File: "/home/ci/app/util s/util.go", StartLine: 4, EndLine: 9},
+		{Tool: "ESLint", RuleID: "no-unused-vars", Level: LevelNote, Message: "'x' is defined but never used",
+			File: "web/app.js", StartLine: 1, EndLine: 1},
+	}
+	if !reflect.DeepEqual(findings, want) {
+		t.Errorf("findings = %+v\nwant %+v", findings, want)
+	}
+}
+
+func TestParseSARIFErrors(t *testing.T) {
+	if _, err := ParseSARIF([]byte("not json")); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+	if _, err := ParseSARIF([]byte(`{"version": "1.0.0", "runs": []}`)); err == nil {
+		t.Error("expected an error for SARIF 1")
+	}
+}
@@ -0,0 +1,45 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// ErrUnknownCommit is returned for a commit the clone does not have, such
+// as one a force push dropped or one older than a shallow clone reaches
+var ErrUnknownCommit = errors.New("commit not in the clone")
+
+// ChangedFiles returns the paths of the files added, modified or deleted
+// between a commit and the checked out one; renamed files are listed under
+// both paths. The list is empty, not nil, when nothing changed.
+func (s *GitService) ChangedFiles(ctx context.Context, repoPath, since string) ([]string, error) {
+	exists := exec.CommandContext(ctx, "git", "cat-file", "-e", since+"^{commit}")
+	exists.Dir = repoPath
+	if err := exists.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCommit, since)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "diff", "--name-only", "--no-renames", "-z", since, "HEAD", "--")
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff from %s: %w", since, err)
+	}
+	return ParseNameList(output), nil
+}
+
+// ParseNameList reads the NUL-separated paths git diff -z --name-only
+// prints
+func ParseNameList(output []byte) []string {
+	paths := []string{}
+	for _, name := range bytes.Split(output, []byte{0}) {
+		if len(name) > 0 {
+			paths = append(paths, string(name))
+		}
+	}
+	return paths
+}
@@ -0,0 +1,81 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseNameList(t *testing.T) {
+	got := ParseNameList([]byte("a.go\x00dir/b c.py\x00"))
+	if want := []string{"a.go", "dir/b c.py"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseNameList = %q, want %q", got, want)
+	}
+	if got := ParseNameList(nil); got == nil || len(got) != 0 {
+		t.Errorf("ParseNameList(nil) = %#v, want an empty list", got)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("init", "-q")
+	write("kept.go", "package a\n")
+	write("modified.go", "package a\n")
+	write("deleted.go", "package a\n")
+	write("old.go", "package a\n\nfunc Renamed() {}\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "first")
+	first := run("rev-parse", "HEAD")
+
+	write("modified.go", "package a\n\nfunc Changed() {}\n")
+	write("added.go", "package a\n")
+	run("rm", "-q", "deleted.go")
+	run("mv", "old.go", "new.go")
+	run("add", ".")
+	run("commit", "-q", "-m", "second")
+
+	svc := NewGitService(t.TempDir())
+	changed, err := svc.ChangedFiles(context.Background(), dir, first)
+	if err != nil {
+		t.Fatalf("ChangedFiles failed: %v", err)
+	}
+	if want := []string{"added.go", "deleted.go", "modified.go", "new.go", "old.go"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("ChangedFiles = %q, want %q", changed, want)
+	}
+
+	unchanged, err := svc.ChangedFiles(context.Background(), dir, run("rev-parse", "HEAD"))
+	if err != nil || unchanged == nil || len(unchanged) != 0 {
+		t.Errorf("ChangedFiles(HEAD) = %#v, %v; want an empty list", unchanged, err)
+	}
+
+	if _, err := svc.ChangedFiles(context.Background(), dir, strings.Repeat("0", 40)); !errors.Is(err, ErrUnknownCommit) {
+		t.Errorf("ChangedFiles of an unknown commit = %v, want ErrUnknownCommit", err)
+	}
+}
@@ -0,0 +1,107 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// Separators of the git log format History reads: a record separator
+// before each commit and a unit separator between its fields
+const (
+	logRecordSep = "\x1e"
+	logFieldSep  = "\x1f"
+)
+
+// History returns up to depth of the most recent non-merge commits of the
+// checked out branch, with the files each modified. Shallow clones are
+// deepened first so the history is there to read.
+func (s *GitService) History(ctx context.Context, repoPath string, depth int) ([]models.Commit, error) {
+	if depth <= 0 {
+		return nil, nil
+	}
+
+	shallow := exec.CommandContext(ctx, "git", "rev-parse", "--is-shallow-repository")
+	shallow.Dir = repoPath
+	if out, err := shallow.Output(); err == nil && strings.TrimSpace(string(out)) == "true" {
+		fetch := exec.CommandContext(ctx, "git", "fetch", "--depth", strconv.Itoa(depth))
+		fetch.Dir = repoPath
+		if err := fetch.Run(); err != nil {
+			// Index the history there is
+			log.Printf("Failed to deepen %s to %d commits: %v", repoPath, depth, err)
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "-c", "core.quotePath=false", "log",
+		"-n", strconv.Itoa(depth),
+		"--no-merges", "--no-renames", "--numstat",
+		"--format="+logRecordSep+"%H"+logFieldSep+"%an"+logFieldSep+"%ae"+logFieldSep+"%aI"+logFieldSep+"%s",
+	)
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return ParseLog(output)
+}
+
+// ParseLog reads the output of git log in the format History asks for
+func ParseLog(output []byte) ([]models.Commit, error) {
+	var commits []models.Commit
+	for _, record := range bytes.Split(output, []byte(logRecordSep)) {
+		if len(bytes.TrimSpace(record)) == 0 {
+			continue
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(record))
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		if !scanner.Scan() {
+			continue
+		}
+		fields := strings.Split(scanner.Text(), logFieldSep)
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("unexpected log header %q", scanner.Text())
+		}
+		when, err := time.Parse(time.RFC3339, fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid date of commit %s: %w", fields[0], err)
+		}
+		commit := models.Commit{
+			Hash:        fields[0],
+			AuthorName:  fields[1],
+			AuthorEmail: strings.ToLower(fields[2]),
+			Time:        when.UTC(),
+			Message:     fields[4],
+			Files:       []models.CommitChange{},
+		}
+
+		// numstat lines: additions, deletions, path; "-" counts for binaries
+		for scanner.Scan() {
+			parts := strings.SplitN(scanner.Text(), "\t", 3)
+			if len(parts) != 3 {
+				continue
+			}
+			additions, _ := strconv.Atoi(parts[0])
+			deletions, _ := strconv.Atoi(parts[1])
+			commit.Files = append(commit.Files, models.CommitChange{
+				Path:      parts[2],
+				Additions: additions,
+				Deletions: deletions,
+			})
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
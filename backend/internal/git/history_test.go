@@ -0,0 +1,95 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+func TestParseLog(t *testing.T) {
+	output := "\x1eabc123\x1fAda Lovelace\x1fAda@Example.com\x1f2025-03-01T10:00:00+01:00\x1fAdd parser\n" +
+		"\n" +
+		"10\t2\tparser/parse.go\n" +
+		"-\t-\tassets/logo.png\n" +
+		"\x1edef456\x1fAlan Turing\x1falan@example.com\x1f2025-02-28T09:30:00Z\x1fInitial commit\n"
+
+	commits, err := ParseLog([]byte(output))
+	if err != nil {
+		t.Fatalf("ParseLog failed: %v", err)
+	}
+
+	expected := []models.Commit{
+		{
+			Hash:        "abc123",
+			AuthorName:  "Ada Lovelace",
+			AuthorEmail: "ada@example.com",
+			Time:        time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC),
+			Message:     "Add parser",
+			Files: []models.CommitChange{
+				{Path: "parser/parse.go", Additions: 10, Deletions: 2},
+				{Path: "assets/logo.png"},
+			},
+		},
+		{
+			Hash:        "def456",
+			AuthorName:  "Alan Turing",
+			AuthorEmail: "alan@example.com",
+			Time:        time.Date(2025, 2, 28, 9, 30, 0, 0, time.UTC),
+			Message:     "Initial commit",
+			Files:       []models.CommitChange{},
+		},
+	}
+	if !reflect.DeepEqual(commits, expected) {
+		t.Errorf("ParseLog() = %+v, want %+v", commits, expected)
+	}
+
+	if _, err := ParseLog([]byte("\x1enot a header\n")); err == nil {
+		t.Error("Expected error for malformed header")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-q")
+	for i, content := range []string{"one\n", "one\ntwo\n"} {
+		if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		run("add", ".")
+		run("commit", "-q", "-m", "change "+string(rune('a'+i)))
+	}
+
+	commits, err := NewGitService(t.TempDir()).History(context.Background(), dir, 10)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("Expected 2 commits, got %d", len(commits))
+	}
+	if commits[0].Message != "change b" || commits[0].AuthorEmail != "test@example.com" {
+		t.Errorf("Unexpected latest commit %+v", commits[0])
+	}
+	if want := []models.CommitChange{{Path: "notes.txt", Additions: 1}}; !reflect.DeepEqual(commits[0].Files, want) {
+		t.Errorf("Files = %+v, want %+v", commits[0].Files, want)
+	}
+}
@@ -0,0 +1,115 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the REST API of github.com; GitHub Enterprise Server
+// serves it at https://HOST/api/v3
+const DefaultAPIURL = "https://api.github.com"
+
+// pageSize is how many repositories are requested per page, GitHub's most
+const pageSize = 100
+
+// maxOrgRepos caps the repositories listed of an organization
+const maxOrgRepos = 2000
+
+// Repo is a repository as GitHub lists it
+type Repo struct {
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	CloneURL      string `json:"clone_url"`
+	DefaultBranch string `json:"default_branch"`
+	Fork          bool   `json:"fork"`
+	Archived      bool   `json:"archived"`
+}
+
+// Client calls the GitHub REST API, authenticated by a token when set, which
+// private repositories need and which raises the rate limit
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+func NewClient(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// ListOrgRepos lists the repositories of an organization, or of a user when
+// no organization has the name, up to maxOrgRepos
+func (c *Client) ListOrgRepos(ctx context.Context, org string) ([]Repo, error) {
+	repos, err := c.listRepos(ctx, "/orgs/"+url.PathEscape(org)+"/repos")
+	if errors.Is(err, ErrNotFound) {
+		repos, err = c.listRepos(ctx, "/users/"+url.PathEscape(org)+"/repos")
+	}
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("%w: no GitHub organization or user %q", ErrNotFound, org)
+	}
+	return repos, err
+}
+
+// ErrNotFound is returned for organizations and paths GitHub does not know
+var ErrNotFound = errors.New("not found")
+
+func (c *Client) listRepos(ctx context.Context, path string) ([]Repo, error) {
+	var repos []Repo
+	for page := 1; len(repos) < maxOrgRepos; page++ {
+		var batch []Repo
+		if err := c.get(ctx, fmt.Sprintf("%s?per_page=%d&page=%d", path, pageSize, page), &batch); err != nil {
+			return nil, err
+		}
+		repos = append(repos, batch...)
+		if len(batch) < pageSize {
+			break
+		}
+	}
+	return repos[:min(len(repos), maxOrgRepos)], nil
+}
+
+func (c *Client) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("GitHub API error (status %d): %s", resp.StatusCode, string(body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestListOrgRepos_Pages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/orgs/acme/repos" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer secret" {
+			t.Errorf("expected the token to be sent, got %q", auth)
+		}
+		// A full first page and a partial second one
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		count := map[int]int{1: pageSize, 2: 3}[page]
+		fmt.Fprint(w, "[")
+		for i := range count {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"name":"svc-%d-%d","clone_url":"https://github.com/acme/svc-%d-%d.git","default_branch":"main"}`, page, i, page, i)
+		}
+		fmt.Fprint(w, "]")
+	}))
+	defer server.Close()
+
+	repos, err := NewClient(server.URL, "secret").ListOrgRepos(context.Background(), "acme")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repos) != pageSize+3 {
+		t.Fatalf("expected %d repositories, got %d", pageSize+3, len(repos))
+	}
+	if repos[0].CloneURL != "https://github.com/acme/svc-1-0.git" || repos[0].DefaultBranch != "main" {
+		t.Errorf("unexpected repository %+v", repos[0])
+	}
+}
+
+func TestListOrgRepos_User(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/users/octocat/repos" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `[{"name":"hello","clone_url":"https://github.com/octocat/hello.git"}]`)
+	}))
+	defer server.Close()
+
+	repos, err := NewClient(server.URL, "").ListOrgRepos(context.Background(), "octocat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repos) != 1 || repos[0].Name != "hello" {
+		t.Errorf("unexpected repositories %+v", repos)
+	}
+
+	if _, err := NewClient(server.URL, "").ListOrgRepos(context.Background(), "nobody"); err == nil {
+		t.Error("expected an error for an unknown organization")
+	}
+}
+
+func TestListOrgRepos_ServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	if _, err := NewClient(server.URL, "").ListOrgRepos(context.Background(), "acme"); err == nil {
+		t.Fatal("expected error for status 403")
+	}
+}
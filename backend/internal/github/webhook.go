@@ -0,0 +1,69 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Headers of webhook deliveries
+const (
+	EventHeader     = "X-GitHub-Event"
+	SignatureHeader = "X-Hub-Signature-256"
+	DeliveryHeader  = "X-GitHub-Delivery"
+)
+
+// PushEvent is the part of a push delivery NeoGraph reads
+type PushEvent struct {
+	Ref        string `json:"ref"`    // refs/heads/BRANCH for branches
+	Before     string `json:"before"` // the commit the branch was at
+	After      string `json:"after"`  // the pushed commit
+	Forced     bool   `json:"forced"`
+	Deleted    bool   `json:"deleted"`
+	Repository struct {
+		FullName      string `json:"full_name"`
+		CloneURL      string `json:"clone_url"`
+		HTMLURL       string `json:"html_url"`
+		SSHURL        string `json:"ssh_url"`
+		GitURL        string `json:"git_url"`
+		DefaultBranch string `json:"default_branch"`
+	} `json:"repository"`
+}
+
+// Branch returns the branch pushed to, "" for tags
+func (e *PushEvent) Branch() string {
+	branch, ok := strings.CutPrefix(e.Ref, "refs/heads/")
+	if !ok {
+		return ""
+	}
+	return branch
+}
+
+// URLs returns the ways the pushed repository's URL may be written
+func (e *PushEvent) URLs() []string {
+	r := e.Repository
+	var urls []string
+	for _, url := range []string{r.CloneURL, r.HTMLURL, r.SSHURL, r.GitURL} {
+		if url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// VerifySignature reports whether the X-Hub-Signature-256 header of a
+// delivery, sha256=HEX, is the HMAC of its body with the webhook's secret
+func VerifySignature(secret string, body []byte, signature string) bool {
+	hexDigest, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok || secret == "" {
+		return false
+	}
+	got, err := hex.DecodeString(hexDigest)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -0,0 +1,72 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+)
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"ref":"refs/heads/main"}`)
+
+	if !VerifySignature("s3cret", body, sign("s3cret", body)) {
+		t.Error("expected a signature with the secret to verify")
+	}
+	for name, signature := range map[string]string{
+		"other secret": sign("other", body),
+		"other body":   sign("s3cret", []byte(`{}`)),
+		"no prefix":    sign("s3cret", body)[len("sha256="):],
+		"not hex":      "sha256=zz",
+		"empty":        "",
+	} {
+		if VerifySignature("s3cret", body, signature) {
+			t.Errorf("expected %s not to verify", name)
+		}
+	}
+	if VerifySignature("", body, sign("", body)) {
+		t.Error("expected nothing to verify without a secret")
+	}
+}
+
+func TestPushEvent(t *testing.T) {
+	var event PushEvent
+	payload := `{
+		"ref": "refs/heads/main",
+		"before": "9f8e7d",
+		"after": "abc123",
+		"forced": true,
+		"repository": {
+			"full_name": "acme/app",
+			"clone_url": "https://github.com/acme/app.git",
+			"html_url": "https://github.com/acme/app",
+			"ssh_url": "git@github.com:acme/app.git",
+			"default_branch": "main"
+		}
+	}`
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if event.Before != "9f8e7d" || event.After != "abc123" || !event.Forced {
+		t.Errorf("unexpected commits %q..%q, forced %v", event.Before, event.After, event.Forced)
+	}
+	if event.Branch() != "main" {
+		t.Errorf("expected branch main, got %q", event.Branch())
+	}
+	if urls := event.URLs(); len(urls) != 3 || urls[2] != "git@github.com:acme/app.git" {
+		t.Errorf("unexpected URLs %v", urls)
+	}
+
+	event.Ref = "refs/tags/v1.0.0"
+	if event.Branch() != "" {
+		t.Errorf("expected no branch for a tag, got %q", event.Branch())
+	}
+}
@@ -0,0 +1,16 @@
+package indexer
+
+import "github.com/dpolishuk/neograph/backend/internal/models"
+
+// callNames returns the distinct names called, in order of first call
+func callNames(sites []models.CallSite) []string {
+	var names []string
+	seen := make(map[string]bool) // To avoid duplicates
+	for _, site := range sites {
+		if !seen[site.Name] {
+			names = append(names, site.Name)
+			seen[site.Name] = true
+		}
+	}
+	return names
+}
@@ -0,0 +1,79 @@
+//go:build !purego
+
+package indexer
+
+import (
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// decisionNodes are, per language, the node types that add a path through
+// a function: conditionals, loops, cases and exception handlers
+var decisionNodes = map[string]map[string]bool{
+	"go": {
+		"if_statement": true, "for_statement": true,
+		"expression_case": true, "type_case": true, "communication_case": true,
+	},
+	"python": {
+		"if_statement": true, "elif_clause": true, "for_statement": true, "while_statement": true,
+		"except_clause": true, "conditional_expression": true, "boolean_operator": true,
+		"for_in_clause": true, "if_clause": true, "case_clause": true,
+	},
+	"typescript": {
+		"if_statement": true, "for_statement": true, "for_in_statement": true,
+		"while_statement": true, "do_statement": true, "switch_case": true,
+		"catch_clause": true, "ternary_expression": true,
+	},
+	"java": {
+		"if_statement": true, "for_statement": true, "enhanced_for_statement": true,
+		"while_statement": true, "do_statement": true, "catch_clause": true,
+		"ternary_expression": true,
+	},
+	"kotlin": {
+		"if_expression": true, "for_statement": true, "while_statement": true,
+		"do_while_statement": true, "catch_block": true, "conjunction_expression": true,
+		"disjunction_expression": true, "elvis_expression": true,
+	},
+}
+
+// shortCircuitOperators are the binary operators that branch
+var shortCircuitOperators = map[string]bool{"&&": true, "||": true, "??": true}
+
+// complexity computes the cyclomatic complexity of a function node: one
+// plus each branch point in its body, short-circuit operators included
+func complexity(node *sitter.Node, content []byte, language string) int {
+	decisions := decisionNodes[language]
+	count := 1
+
+	var traverse func(*sitter.Node)
+	traverse = func(n *sitter.Node) {
+		if n == nil {
+			return
+		}
+
+		switch nodeType := n.Type(); {
+		case decisions[nodeType]:
+			count++
+		case nodeType == "binary_expression":
+			if op := n.ChildByFieldName("operator"); op != nil && shortCircuitOperators[getNodeContent(op, content)] {
+				count++
+			}
+		case nodeType == "switch_label": // Java; default labels do not branch
+			if strings.HasPrefix(getNodeContent(n, content), "case") {
+				count++
+			}
+		case nodeType == "when_entry": // Kotlin; else entries do not branch
+			if !strings.HasPrefix(strings.TrimSpace(getNodeContent(n, content)), "else") {
+				count++
+			}
+		}
+
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			traverse(n.NamedChild(i))
+		}
+	}
+
+	traverse(node)
+	return count
+}
@@ -0,0 +1,110 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// describeBatchSize is how many entities are described per prompt
+const describeBatchSize = 20
+
+// maxDescribedLines caps the source lines of an entity shown to the model
+const maxDescribedLines = 30
+
+// numberedLine matches a line of an answer to DescriptionPrompt: the number
+// of the entity it describes and the description
+var numberedLine = regexp.MustCompile(`^\s*(\d+)[.):]\s*(.+)$`)
+
+// Completer answers a prompt, such as a model of the agent service
+type Completer interface {
+	Complete(ctx context.Context, prompt string) (string, error)
+}
+
+// SetDescriber optionally has a model describe functions, methods and
+// classes in a sentence while indexing. The descriptions are stored and
+// embedded with the signatures, so searches in plain words find code whose
+// names do not use them.
+func (p *Pipeline) SetDescriber(describer Completer) {
+	p.describer = describer
+}
+
+// DescriptionPrompt asks for a one-sentence description of each entity of
+// a batch, numbered from 1 in order
+func DescriptionPrompt(batch []models.CodeEntity) string {
+	var b strings.Builder
+	b.WriteString("Describe what each of these code entities does in one sentence of plain English.\n")
+	b.WriteString("Answer with one line per entity, starting with its number, e.g. \"1. Parses the configuration file.\"\n\n")
+	for i, entity := range batch {
+		fmt.Fprintf(&b, "%d. %s %s in %s\n", i+1, entity.Type, entity.Name, entity.FilePath)
+		source := entity.Content
+		if source == "" {
+			source = entity.Signature
+		}
+		if lines := strings.Split(source, "\n"); len(lines) > maxDescribedLines {
+			source = strings.Join(lines[:maxDescribedLines], "\n") + "\n..."
+		}
+		fmt.Fprintf(&b, "```\n%s\n```\n\n", source)
+	}
+	return b.String()
+}
+
+// ParseDescriptions reads the answer to DescriptionPrompt for n entities,
+// returning their descriptions in order, empty where the answer has none
+func ParseDescriptions(response string, n int) []string {
+	descriptions := make([]string, n)
+	for _, line := range strings.Split(response, "\n") {
+		match := numberedLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		number, err := strconv.Atoi(match[1])
+		if err != nil || number < 1 || number > n || descriptions[number-1] != "" {
+			continue
+		}
+		descriptions[number-1] = strings.Trim(strings.TrimSpace(match[2]), "\"*`")
+	}
+	return descriptions
+}
+
+// describeEntities sets the NLDescription of the functions, methods and
+// classes among entities, a batch per prompt. Batches the model fails are
+// left undescribed; returns how many entities were described.
+func (p *Pipeline) describeEntities(ctx context.Context, entities []models.CodeEntity) int {
+	var described []int // indexes of the entities to describe
+	for i, entity := range entities {
+		if entity.Type != models.EntityVariable {
+			described = append(described, i)
+		}
+	}
+
+	count := 0
+	for start := 0; start < len(described); start += describeBatchSize {
+		if ctx.Err() != nil {
+			break
+		}
+		indexes := described[start:min(start+describeBatchSize, len(described))]
+		batch := make([]models.CodeEntity, len(indexes))
+		for i, index := range indexes {
+			batch[i] = entities[index]
+		}
+
+		response, err := p.describer.Complete(ctx, DescriptionPrompt(batch))
+		if err != nil {
+			log.Printf("Warning: skipping descriptions of %d entities: %v", len(batch), err)
+			continue
+		}
+		for i, description := range ParseDescriptions(response, len(batch)) {
+			if description != "" {
+				entities[indexes[i]].NLDescription = description
+				count++
+			}
+		}
+	}
+	return count
+}
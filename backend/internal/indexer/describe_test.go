@@ -0,0 +1,90 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+func TestDescriptionPrompt(t *testing.T) {
+	long := strings.Repeat("x++\n", maxDescribedLines+5)
+	prompt := DescriptionPrompt([]models.CodeEntity{
+		{Type: models.EntityFunction, Name: "parse", FilePath: "config.go", Signature: "func parse(path string) error"},
+		{Type: models.EntityMethod, Name: "Run", FilePath: "job.go", Content: "func (j *Job) Run() {\n" + long + "}"},
+	})
+
+	for _, want := range []string{"1. Function parse in config.go\n```\nfunc parse(path string) error\n```", "2. Method Run in job.go", "\n...\n```"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt lacks %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Count(prompt, "x++") != maxDescribedLines-1 {
+		t.Errorf("expected the source cut at %d lines", maxDescribedLines)
+	}
+}
+
+func TestParseDescriptions(t *testing.T) {
+	response := "Here you go:\n" +
+		"1. Parses the configuration file.\n" +
+		"3) **Runs the job** \n" +
+		"3. Runs it again.\n" +
+		"7. Out of range.\n"
+
+	got := ParseDescriptions(response, 3)
+	want := []string{"Parses the configuration file.", "", "Runs the job"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseDescriptions = %q, want %q", got, want)
+	}
+}
+
+// batchCompleter answers each prompt numbering the entities it lists, and
+// fails the prompts whose index is in fail
+type batchCompleter struct {
+	fail    map[int]bool
+	prompts int
+}
+
+func (c *batchCompleter) Complete(ctx context.Context, prompt string) (string, error) {
+	c.prompts++
+	if c.fail[c.prompts-1] {
+		return "", errors.New("agent service returned status 500")
+	}
+	var answer []string
+	for _, line := range strings.Split(prompt, "\n") {
+		if match := numberedLine.FindStringSubmatch(line); match != nil && strings.Contains(line, " in ") {
+			answer = append(answer, match[1]+". Describes "+strings.Fields(match[2])[1]+".")
+		}
+	}
+	return strings.Join(answer, "\n"), nil
+}
+
+func TestDescribeEntities(t *testing.T) {
+	entities := make([]models.CodeEntity, 45)
+	for i := range entities {
+		entities[i] = models.CodeEntity{Type: models.EntityFunction, Name: "f" + strings.Repeat("x", i)}
+	}
+	entities[0].Type = models.EntityVariable
+
+	completer := &batchCompleter{fail: map[int]bool{1: true}}
+	p := &Pipeline{describer: completer}
+	if got := p.describeEntities(context.Background(), entities); got != 24 {
+		t.Errorf("described %d entities, want 24", got)
+	}
+	if completer.prompts != 3 {
+		t.Errorf("sent %d prompts, want 3", completer.prompts)
+	}
+
+	if entities[0].NLDescription != "" {
+		t.Error("variables are not described")
+	}
+	if want := "Describes " + entities[1].Name + "."; entities[1].NLDescription != want {
+		t.Errorf("got %q, want %q", entities[1].NLDescription, want)
+	}
+	if entities[21].NLDescription != "" || entities[44].NLDescription == "" {
+		t.Error("expected only the second batch undescribed")
+	}
+}
@@ -1,3 +1,5 @@
+//go:build !purego
+
 package indexer
 
 import (
@@ -27,30 +29,44 @@ func (e *Extractor) Close() {
 	e.parser.Close()
 }
 
+// Supports reports whether the extractor can parse a language
+func (e *Extractor) Supports(language string) bool {
+	return treesitter.GetLanguage(language) != nil
+}
+
 // Extract extracts code entities from the given source code
 func (e *Extractor) Extract(ctx context.Context, content []byte, language string, filePath string) ([]models.CodeEntity, error) {
+	entities, _, err := e.ExtractAll(ctx, content, language, filePath)
+	return entities, err
+}
+
+// ExtractAll extracts code entities and import declarations in a single parse
+func (e *Extractor) ExtractAll(ctx context.Context, content []byte, language string, filePath string) ([]models.CodeEntity, []models.ImportRelation, error) {
 	tree, err := e.parser.Parse(ctx, content, language)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse code: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse code: %w", err)
 	}
 	defer tree.Close()
 
 	root := tree.RootNode()
 
+	var entities []models.CodeEntity
 	switch language {
 	case "go":
-		return e.extractGo(root, content, filePath), nil
+		entities = e.extractGo(root, content, filePath)
 	case "python":
-		return e.extractPython(root, content, filePath), nil
+		entities = e.extractPython(root, content, filePath)
 	case "typescript", "javascript":
-		return e.extractTypeScript(root, content, filePath), nil
+		entities = e.extractTypeScript(root, content, filePath)
 	case "java":
-		return e.extractJava(root, content, filePath), nil
+		entities = e.extractJava(root, content, filePath)
 	case "kotlin":
-		return e.extractKotlin(root, content, filePath), nil
+		entities = e.extractKotlin(root, content, filePath)
 	default:
-		return nil, fmt.Errorf("unsupported language: %s", language)
+		return nil, nil, fmt.Errorf("unsupported language: %s", language)
 	}
+
+	return entities, e.extractImports(root, content, language), nil
 }
 
 // extractGo extracts entities from Go code
@@ -68,8 +84,14 @@ func (e *Extractor) extractGo(root *sitter.Node, content []byte, filePath string
 		case "method_declaration":
 			entity := e.extractGoFunction(node, content, filePath, "method")
 			if entity != nil {
+				entity.ClassName = goReceiverType(node, content)
 				entities = append(entities, *entity)
 			}
+		case "var_declaration":
+			// Only package-level variables; locals are function internals
+			if parent := node.Parent(); parent != nil && parent.Type() == "source_file" {
+				entities = append(entities, e.extractGoVariables(node, content, filePath)...)
+			}
 		case "type_declaration":
 			// Extract struct declarations
 			// Look for struct_type within the type_declaration
@@ -104,7 +126,7 @@ func (e *Extractor) extractGoFunction(node *sitter.Node, content []byte, filePat
 	name := getNodeContent(nameNode, content)
 	signature := getNodeContent(node, content)
 	docstring := getPrecedingComment(node, content)
-	calls := extractCalls(node, content)
+	callSites := extractCalls(node, content)
 
 	var entityTypeCode models.CodeEntityType
 	if entityType == "function" {
@@ -114,15 +136,18 @@ func (e *Extractor) extractGoFunction(node *sitter.Node, content []byte, filePat
 	}
 
 	return &models.CodeEntity{
-		Type:      entityTypeCode,
-		Name:      name,
-		Signature: signature,
-		Docstring: docstring,
-		StartLine: int(node.StartPoint().Row) + 1,
-		EndLine:   int(node.EndPoint().Row) + 1,
-		FilePath:  filePath,
-		Calls:     calls,
-		Content:   signature,
+		Type:       entityTypeCode,
+		Name:       name,
+		Signature:  signature,
+		Docstring:  docstring,
+		StartLine:  int(node.StartPoint().Row) + 1,
+		EndLine:    int(node.EndPoint().Row) + 1,
+		FilePath:   filePath,
+		Calls:      callNames(callSites),
+		CallSites:  callSites,
+		Complexity: complexity(node, content, "go"),
+		Accesses:   extractGoAccesses(node, content),
+		Content:    signature,
 	}
 }
 
@@ -162,6 +187,7 @@ func (e *Extractor) extractGoStruct(declNode *sitter.Node, typeSpec *sitter.Node
 // extractPython extracts entities from Python code
 func (e *Extractor) extractPython(root *sitter.Node, content []byte, filePath string) []models.CodeEntity {
 	var entities []models.CodeEntity
+	assigned := make(map[string]bool)
 	e.traverseNode(root, content, func(node *sitter.Node) {
 		nodeType := node.Type()
 
@@ -185,6 +211,9 @@ func (e *Extractor) extractPython(root *sitter.Node, content []byte, filePath st
 
 			entity := e.extractPythonFunction(node, content, filePath, entityType)
 			if entity != nil {
+				if isMethod {
+					entity.ClassName = enclosingClassName(node, content, "class_definition")
+				}
 				entities = append(entities, *entity)
 			}
 		case "class_definition":
@@ -192,6 +221,17 @@ func (e *Extractor) extractPython(root *sitter.Node, content []byte, filePath st
 			if entity != nil {
 				entities = append(entities, *entity)
 			}
+		case "expression_statement":
+			// Module-level assignments are the module's variables, declared
+			// where first assigned
+			if parent := node.Parent(); parent != nil && parent.Type() == "module" {
+				for _, variable := range e.extractPythonVariables(node, content, filePath) {
+					if !assigned[variable.Name] {
+						assigned[variable.Name] = true
+						entities = append(entities, variable)
+					}
+				}
+			}
 		}
 	})
 	return entities
@@ -207,7 +247,7 @@ func (e *Extractor) extractPythonFunction(node *sitter.Node, content []byte, fil
 	name := getNodeContent(nameNode, content)
 	signature := e.getPythonSignature(node, content)
 	docstring := getPythonDocstring(node, content)
-	calls := extractCalls(node, content)
+	callSites := extractCalls(node, content)
 
 	var entityTypeCode models.CodeEntityType
 	if entityType == "function" {
@@ -217,15 +257,18 @@ func (e *Extractor) extractPythonFunction(node *sitter.Node, content []byte, fil
 	}
 
 	return &models.CodeEntity{
-		Type:      entityTypeCode,
-		Name:      name,
-		Signature: signature,
-		Docstring: docstring,
-		StartLine: int(node.StartPoint().Row) + 1,
-		EndLine:   int(node.EndPoint().Row) + 1,
-		FilePath:  filePath,
-		Calls:     calls,
-		Content:   getNodeContent(node, content),
+		Type:       entityTypeCode,
+		Name:       name,
+		Signature:  signature,
+		Docstring:  docstring,
+		StartLine:  int(node.StartPoint().Row) + 1,
+		EndLine:    int(node.EndPoint().Row) + 1,
+		FilePath:   filePath,
+		Calls:      callNames(callSites),
+		CallSites:  callSites,
+		Complexity: complexity(node, content, "python"),
+		Accesses:   extractPythonAccesses(node, content),
+		Content:    getNodeContent(node, content),
 	}
 }
 
@@ -283,6 +326,7 @@ func (e *Extractor) extractTypeScript(root *sitter.Node, content []byte, filePat
 		case "method_definition":
 			entity := e.extractTSMethod(node, content, filePath)
 			if entity != nil {
+				entity.ClassName = enclosingClassName(node, content, "class_declaration", "class", "abstract_class_declaration")
 				entities = append(entities, *entity)
 			}
 		}
@@ -300,7 +344,7 @@ func (e *Extractor) extractTSFunction(node *sitter.Node, content []byte, filePat
 	name := getNodeContent(nameNode, content)
 	signature := e.getTSSignature(node, content)
 	docstring := getPrecedingComment(node, content)
-	calls := extractCalls(node, content)
+	callSites := extractCalls(node, content)
 
 	var entityTypeCode models.CodeEntityType
 	if entityType == "function" {
@@ -310,15 +354,17 @@ func (e *Extractor) extractTSFunction(node *sitter.Node, content []byte, filePat
 	}
 
 	return &models.CodeEntity{
-		Type:      entityTypeCode,
-		Name:      name,
-		Signature: signature,
-		Docstring: docstring,
-		StartLine: int(node.StartPoint().Row) + 1,
-		EndLine:   int(node.EndPoint().Row) + 1,
-		FilePath:  filePath,
-		Calls:     calls,
-		Content:   getNodeContent(node, content),
+		Type:       entityTypeCode,
+		Name:       name,
+		Signature:  signature,
+		Docstring:  docstring,
+		StartLine:  int(node.StartPoint().Row) + 1,
+		EndLine:    int(node.EndPoint().Row) + 1,
+		FilePath:   filePath,
+		Calls:      callNames(callSites),
+		CallSites:  callSites,
+		Complexity: complexity(node, content, "typescript"),
+		Content:    getNodeContent(node, content),
 	}
 }
 
@@ -356,18 +402,20 @@ func (e *Extractor) extractTSMethod(node *sitter.Node, content []byte, filePath
 	name := getNodeContent(nameNode, content)
 	signature := e.getTSSignature(node, content)
 	docstring := getPrecedingComment(node, content)
-	calls := extractCalls(node, content)
+	callSites := extractCalls(node, content)
 
 	return &models.CodeEntity{
-		Type:      models.EntityMethod,
-		Name:      name,
-		Signature: signature,
-		Docstring: docstring,
-		StartLine: int(node.StartPoint().Row) + 1,
-		EndLine:   int(node.EndPoint().Row) + 1,
-		FilePath:  filePath,
-		Calls:     calls,
-		Content:   getNodeContent(node, content),
+		Type:       models.EntityMethod,
+		Name:       name,
+		Signature:  signature,
+		Docstring:  docstring,
+		StartLine:  int(node.StartPoint().Row) + 1,
+		EndLine:    int(node.EndPoint().Row) + 1,
+		FilePath:   filePath,
+		Calls:      callNames(callSites),
+		CallSites:  callSites,
+		Complexity: complexity(node, content, "typescript"),
+		Content:    getNodeContent(node, content),
 	}
 }
 
@@ -405,6 +453,7 @@ func (e *Extractor) extractJava(root *sitter.Node, content []byte, filePath stri
 		case "method_declaration":
 			entity := e.extractJavaMethod(node, content, filePath)
 			if entity != nil {
+				entity.ClassName = enclosingClassName(node, content, "class_declaration", "interface_declaration", "enum_declaration", "record_declaration")
 				entities = append(entities, *entity)
 			}
 		case "class_declaration":
@@ -432,18 +481,20 @@ func (e *Extractor) extractJavaMethod(node *sitter.Node, content []byte, filePat
 	name := getNodeContent(nameNode, content)
 	signature := e.getJavaSignature(node, content)
 	docstring := getPrecedingComment(node, content)
-	calls := extractCalls(node, content)
+	callSites := extractCalls(node, content)
 
 	return &models.CodeEntity{
-		Type:      models.EntityMethod,
-		Name:      name,
-		Signature: signature,
-		Docstring: docstring,
-		StartLine: int(node.StartPoint().Row) + 1,
-		EndLine:   int(node.EndPoint().Row) + 1,
-		FilePath:  filePath,
-		Calls:     calls,
-		Content:   getNodeContent(node, content),
+		Type:       models.EntityMethod,
+		Name:       name,
+		Signature:  signature,
+		Docstring:  docstring,
+		StartLine:  int(node.StartPoint().Row) + 1,
+		EndLine:    int(node.EndPoint().Row) + 1,
+		FilePath:   filePath,
+		Calls:      callNames(callSites),
+		CallSites:  callSites,
+		Complexity: complexity(node, content, "java"),
+		Content:    getNodeContent(node, content),
 	}
 }
 
@@ -548,25 +599,30 @@ func (e *Extractor) extractKotlinFunction(node *sitter.Node, content []byte, fil
 	name := getNodeContent(nameNode, content)
 	signature := e.getKotlinSignature(node, content)
 	docstring := getPrecedingComment(node, content)
-	calls := extractCalls(node, content)
+	callSites := extractCalls(node, content)
 
 	var entityTypeCode models.CodeEntityType
+	var className string
 	if entityType == "function" {
 		entityTypeCode = models.EntityFunction
 	} else {
 		entityTypeCode = models.EntityMethod
+		className = enclosingClassName(node, content, "class_declaration", "object_declaration")
 	}
 
 	return &models.CodeEntity{
-		Type:      entityTypeCode,
-		Name:      name,
-		Signature: signature,
-		Docstring: docstring,
-		StartLine: int(node.StartPoint().Row) + 1,
-		EndLine:   int(node.EndPoint().Row) + 1,
-		FilePath:  filePath,
-		Calls:     calls,
-		Content:   getNodeContent(node, content),
+		Type:       entityTypeCode,
+		Name:       name,
+		Signature:  signature,
+		Docstring:  docstring,
+		StartLine:  int(node.StartPoint().Row) + 1,
+		EndLine:    int(node.EndPoint().Row) + 1,
+		FilePath:   filePath,
+		ClassName:  className,
+		Calls:      callNames(callSites),
+		CallSites:  callSites,
+		Complexity: complexity(node, content, "kotlin"),
+		Content:    getNodeContent(node, content),
 	}
 }
 
@@ -653,6 +709,51 @@ func (e *Extractor) traverseNode(node *sitter.Node, content []byte, callback fun
 	}
 }
 
+// enclosingClassName returns the name of the nearest ancestor of one of the
+// given class node types, or an empty string if there is none
+func enclosingClassName(node *sitter.Node, content []byte, classTypes ...string) string {
+	for parent := node.Parent(); parent != nil; parent = parent.Parent() {
+		for _, classType := range classTypes {
+			if parent.Type() != classType {
+				continue
+			}
+			if nameNode := parent.ChildByFieldName("name"); nameNode != nil {
+				return getNodeContent(nameNode, content)
+			}
+			// Kotlin declarations have no name field
+			for i := 0; i < int(parent.NamedChildCount()); i++ {
+				if child := parent.NamedChild(i); child.Type() == "type_identifier" {
+					return getNodeContent(child, content)
+				}
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// goReceiverType returns the receiver type name of a Go method, without
+// pointer or type parameters
+func goReceiverType(node *sitter.Node, content []byte) string {
+	receiver := node.ChildByFieldName("receiver")
+	if receiver == nil {
+		return ""
+	}
+	var find func(*sitter.Node) string
+	find = func(n *sitter.Node) string {
+		if n.Type() == "type_identifier" {
+			return getNodeContent(n, content)
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			if name := find(n.NamedChild(i)); name != "" {
+				return name
+			}
+		}
+		return ""
+	}
+	return find(receiver)
+}
+
 // getNodeContent extracts the text content of a node
 func getNodeContent(node *sitter.Node, content []byte) string {
 	if node == nil {
@@ -724,10 +825,10 @@ func getPythonDocstring(node *sitter.Node, content []byte) string {
 	return ""
 }
 
-// extractCalls extracts function/method calls within a node
-func extractCalls(node *sitter.Node, content []byte) []string {
-	var calls []string
-	callsMap := make(map[string]bool) // To avoid duplicates
+// extractCalls extracts every function/method call within a node, with the
+// line it is made on
+func extractCalls(node *sitter.Node, content []byte) []models.CallSite {
+	var calls []models.CallSite
 
 	var traverse func(*sitter.Node)
 	traverse = func(n *sitter.Node) {
@@ -741,9 +842,11 @@ func extractCalls(node *sitter.Node, content []byte) []string {
 			funcNode := n.ChildByFieldName("function")
 			if funcNode != nil {
 				callName := getNodeContent(funcNode, content)
-				if callName != "" && !callsMap[callName] {
-					calls = append(calls, callName)
-					callsMap[callName] = true
+				if callName != "" {
+					calls = append(calls, models.CallSite{
+						Name: callName,
+						Line: int(n.StartPoint().Row) + 1,
+					})
 				}
 			}
 		}
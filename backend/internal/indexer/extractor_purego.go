@@ -0,0 +1,431 @@
+//go:build purego
+
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// Extractor parses Go with the standard library's go/parser, so the binary
+// needs no CGO and cross-compiles anywhere. Other languages need the
+// tree-sitter extractor of the default build.
+type Extractor struct{}
+
+// NewExtractor creates a new code entity extractor
+func NewExtractor() *Extractor {
+	return &Extractor{}
+}
+
+// Close releases resources used by the extractor
+func (e *Extractor) Close() {}
+
+// Supports reports whether the extractor can parse a language
+func (e *Extractor) Supports(language string) bool {
+	return language == "go"
+}
+
+// Extract extracts code entities from the given source code
+func (e *Extractor) Extract(ctx context.Context, content []byte, language string, filePath string) ([]models.CodeEntity, error) {
+	entities, _, err := e.ExtractAll(ctx, content, language, filePath)
+	return entities, err
+}
+
+// ExtractAll extracts code entities and import declarations in a single parse
+func (e *Extractor) ExtractAll(ctx context.Context, content []byte, language string, filePath string) ([]models.CodeEntity, []models.ImportRelation, error) {
+	if !e.Supports(language) {
+		return nil, nil, fmt.Errorf("unsupported language: %s (this build parses only Go)", language)
+	}
+
+	// Like tree-sitter, keep what parsed of a file with syntax errors
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filePath, content, parser.ParseComments)
+	if file == nil {
+		return nil, nil, fmt.Errorf("failed to parse code: %w", err)
+	}
+
+	src := goSource{fset: fset, content: content}
+	var entities []models.CodeEntity
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			entities = append(entities, src.function(decl, filePath))
+		case *ast.GenDecl:
+			switch decl.Tok {
+			case token.TYPE:
+				entities = append(entities, src.structs(decl, filePath)...)
+			case token.VAR:
+				entities = append(entities, src.variables(decl, filePath)...)
+			}
+		}
+	}
+
+	var imports []models.ImportRelation
+	for _, spec := range file.Imports {
+		imp := models.ImportRelation{ImportPath: strings.Trim(spec.Path.Value, "\"`")}
+		if spec.Name != nil {
+			imp.Alias = spec.Name.Name
+		}
+		imports = append(imports, imp)
+	}
+	return entities, imports, nil
+}
+
+// goSource is a parsed Go file with its text
+type goSource struct {
+	fset    *token.FileSet
+	content []byte
+}
+
+func (s goSource) text(node ast.Node) string {
+	start, end := s.fset.Position(node.Pos()).Offset, s.fset.Position(node.End()).Offset
+	if start < 0 || end > len(s.content) || start > end {
+		return ""
+	}
+	return string(s.content[start:end])
+}
+
+func (s goSource) line(pos token.Pos) int {
+	return s.fset.Position(pos).Line
+}
+
+// function extracts a Go function or method
+func (s goSource) function(decl *ast.FuncDecl, filePath string) models.CodeEntity {
+	signature := s.text(decl)
+	callSites := s.calls(decl)
+
+	entity := models.CodeEntity{
+		Type:       models.EntityFunction,
+		Name:       decl.Name.Name,
+		Signature:  signature,
+		Docstring:  strings.TrimSpace(decl.Doc.Text()),
+		StartLine:  s.line(decl.Pos()),
+		EndLine:    s.line(decl.End()),
+		FilePath:   filePath,
+		Calls:      callNames(callSites),
+		CallSites:  callSites,
+		Complexity: complexity(decl),
+		Accesses:   s.accesses(decl),
+		Content:    signature,
+	}
+	if decl.Recv != nil && len(decl.Recv.List) > 0 {
+		entity.Type = models.EntityMethod
+		entity.ClassName = receiverTypeName(decl.Recv.List[0].Type)
+	}
+	return entity
+}
+
+// receiverTypeName returns a receiver type name without pointer or type
+// parameters
+func receiverTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return receiverTypeName(t.X)
+	case *ast.ParenExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(t.X)
+	}
+	return ""
+}
+
+// structs extracts the struct types of a type declaration
+func (s goSource) structs(decl *ast.GenDecl, filePath string) []models.CodeEntity {
+	var entities []models.CodeEntity
+	signature := s.text(decl)
+	for _, spec := range decl.Specs {
+		typeSpec, ok := spec.(*ast.TypeSpec)
+		if !ok {
+			continue
+		}
+		if _, ok := typeSpec.Type.(*ast.StructType); !ok {
+			continue
+		}
+		entities = append(entities, models.CodeEntity{
+			Type:      models.EntityClass,
+			Name:      typeSpec.Name.Name,
+			Signature: signature,
+			Docstring: strings.TrimSpace(decl.Doc.Text()),
+			StartLine: s.line(decl.Pos()),
+			EndLine:   s.line(decl.End()),
+			FilePath:  filePath,
+			Calls:     []string{},
+			Content:   signature,
+		})
+	}
+	return entities
+}
+
+// variables extracts the variables of a package-level var declaration
+func (s goSource) variables(decl *ast.GenDecl, filePath string) []models.CodeEntity {
+	var entities []models.CodeEntity
+	for _, spec := range decl.Specs {
+		valueSpec, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		// A spec inside var ( ... ) carries its own comment
+		doc := decl.Doc
+		if decl.Lparen.IsValid() {
+			doc = valueSpec.Doc
+		}
+		signature := "var " + s.text(valueSpec)
+		for _, name := range valueSpec.Names {
+			if name.Name == "_" {
+				continue
+			}
+			entities = append(entities, models.CodeEntity{
+				Type:      models.EntityVariable,
+				Name:      name.Name,
+				Signature: signature,
+				Docstring: strings.TrimSpace(doc.Text()),
+				StartLine: s.line(valueSpec.Pos()),
+				EndLine:   s.line(valueSpec.End()),
+				FilePath:  filePath,
+				Calls:     []string{},
+				Content:   signature,
+			})
+		}
+	}
+	return entities
+}
+
+// calls extracts every call within a function, with the line it is made on
+func (s goSource) calls(decl *ast.FuncDecl) []models.CallSite {
+	var calls []models.CallSite
+	ast.Inspect(decl, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if name := s.text(call.Fun); name != "" {
+				calls = append(calls, models.CallSite{Name: name, Line: s.line(call.Pos())})
+			}
+		}
+		return true
+	})
+	return calls
+}
+
+// complexity computes the cyclomatic complexity of a function, counting the
+// same branch points as the tree-sitter extractor
+func complexity(decl *ast.FuncDecl) int {
+	count := 1
+	ast.Inspect(decl, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			count++
+		case *ast.CaseClause:
+			if n.List != nil { // not default
+				count++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				count++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				count++
+			}
+		}
+		return true
+	})
+	return count
+}
+
+// predeclared values that are never variables
+var goPredeclared = map[string]bool{"nil": true, "true": true, "false": true, "iota": true}
+
+// accesses records the names a Go function uses that it does not declare
+// itself, with the same flat scoping, qualification and write detection as
+// the tree-sitter extractor. Names in type positions are not accesses.
+func (s goSource) accesses(decl *ast.FuncDecl) []models.VariableAccess {
+	if decl.Body == nil {
+		return nil
+	}
+	locals := goDeclaredNames(decl)
+
+	var accesses []models.VariableAccess
+	seen := make(map[models.VariableAccess]bool)
+	add := func(name string, pos token.Pos, write bool) {
+		access := models.VariableAccess{Name: name, Line: s.line(pos), Write: write}
+		if !seen[access] {
+			seen[access] = true
+			accesses = append(accesses, access)
+		}
+	}
+
+	var parents []ast.Node
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		if n == nil {
+			parents = parents[:len(parents)-1]
+			return true
+		}
+		if skipGoTypeNode(n, parents) {
+			return false
+		}
+		if ident, ok := n.(*ast.Ident); ok {
+			recordGoIdent(ident, parents, locals, add)
+		}
+		parents = append(parents, n)
+		return true
+	})
+	return accesses
+}
+
+// skipGoTypeNode reports whether a node is a type expression, label or
+// field name, which tree-sitter does not parse as identifiers
+func skipGoTypeNode(n ast.Node, parents []ast.Node) bool {
+	switch n.(type) {
+	case *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.StructType, *ast.InterfaceType:
+		return true
+	}
+	if len(parents) == 0 {
+		return false
+	}
+	switch parent := parents[len(parents)-1].(type) {
+	case *ast.CompositeLit:
+		return n == parent.Type
+	case *ast.ValueSpec:
+		return n == parent.Type
+	case *ast.TypeAssertExpr:
+		return n == parent.Type
+	case *ast.TypeSpec:
+		return true
+	case *ast.SelectorExpr:
+		return n == parent.Sel
+	case *ast.BranchStmt:
+		return n == parent.Label
+	case *ast.LabeledStmt:
+		return n == parent.Label
+	case *ast.KeyValueExpr:
+		// The key of a keyed struct literal element names a field
+		return n == parent.Key && isIdent(parent.Key)
+	}
+	return false
+}
+
+func isIdent(expr ast.Expr) bool {
+	_, ok := expr.(*ast.Ident)
+	return ok
+}
+
+func recordGoIdent(ident *ast.Ident, parents []ast.Node, locals map[string]bool, add func(string, token.Pos, bool)) {
+	name := ident.Name
+	if name == "_" || locals[name] || goPredeclared[name] {
+		return
+	}
+
+	parent := parents[len(parents)-1]
+	if call, ok := parent.(*ast.CallExpr); ok && call.Fun == ident {
+		return // a call, not a variable
+	}
+
+	target := ast.Node(ident)
+	depth := len(parents) - 1
+	if sel, ok := parent.(*ast.SelectorExpr); ok && sel.X == ident {
+		if depth > 0 {
+			if call, ok := parents[depth-1].(*ast.CallExpr); ok && call.Fun == sel {
+				// pkg.Func() or value.Method(): only the operand is used
+				add(name, ident.Pos(), false)
+				return
+			}
+		}
+		name += "." + sel.Sel.Name
+		target = sel
+		depth--
+	}
+
+	add(name, ident.Pos(), goAssignedTarget(target, parents[:depth+1]))
+}
+
+// goAssignedTarget reports whether an expression is the root of an
+// assignment, increment or decrement target, such as x in x.f[i] = v or *x++.
+// parents ends with the expression's parent.
+func goAssignedTarget(node ast.Node, parents []ast.Node) bool {
+	for i := len(parents) - 1; i >= 0; node, i = parents[i], i-1 {
+		switch parent := parents[i].(type) {
+		case *ast.SelectorExpr:
+			if parent.X != node {
+				return false
+			}
+		case *ast.IndexExpr:
+			if parent.X != node {
+				return false
+			}
+		case *ast.ParenExpr, *ast.StarExpr, *ast.UnaryExpr:
+		case *ast.IncDecStmt:
+			return true
+		case *ast.AssignStmt:
+			if parent.Tok == token.DEFINE {
+				return false
+			}
+			for _, lhs := range parent.Lhs {
+				if lhs == node {
+					return true
+				}
+			}
+			return false
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// goDeclaredNames collects every name a Go function declares: its receiver,
+// parameters, results, type parameters and the variables, constants and
+// range values declared in its body
+func goDeclaredNames(decl *ast.FuncDecl) map[string]bool {
+	locals := make(map[string]bool)
+	addFields := func(fields *ast.FieldList) {
+		if fields == nil {
+			return
+		}
+		for _, field := range fields.List {
+			for _, name := range field.Names {
+				locals[name.Name] = true
+			}
+		}
+	}
+	addIdent := func(expr ast.Expr) {
+		if ident, ok := expr.(*ast.Ident); ok {
+			locals[ident.Name] = true
+		}
+	}
+
+	addFields(decl.Recv)
+	ast.Inspect(decl, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncType:
+			addFields(n.TypeParams)
+			addFields(n.Params)
+			addFields(n.Results)
+		case *ast.ValueSpec:
+			for _, name := range n.Names {
+				locals[name.Name] = true
+			}
+		case *ast.AssignStmt:
+			if n.Tok == token.DEFINE {
+				for _, lhs := range n.Lhs {
+					addIdent(lhs)
+				}
+			}
+		case *ast.RangeStmt:
+			// for k, v := range only; for k = range assigns existing names
+			if n.Tok == token.DEFINE {
+				addIdent(n.Key)
+				addIdent(n.Value)
+			}
+		}
+		return true
+	})
+	return locals
+}
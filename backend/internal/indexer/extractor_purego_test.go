@@ -0,0 +1,156 @@
+//go:build purego
+
+package indexer
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+func TestExtractGoWithoutCGO(t *testing.T) {
+	extractor := NewExtractor()
+	defer extractor.Close()
+
+	goCode := `package calc
+
+import (
+	"fmt"
+	str "strings"
+)
+
+// Debug enables verbose output
+var Debug bool
+
+var (
+	// counter counts operations
+	counter int
+	_       = 0
+)
+
+// Calculator is a simple calculator
+type Calculator struct {
+	result int
+}
+
+// Add adds two numbers together
+func Add(a, b int) int {
+	counter++
+	if Debug {
+		fmt.Println(str.TrimSpace("add"))
+	}
+	return a + b
+}
+
+// Multiply multiplies two numbers
+func (c *Calculator) Multiply(a, b int) int {
+	result := a * b
+	c.result = result
+	cfg := Calculator{result: result}
+	labels := []Calculator{cfg}
+	_ = labels
+	return Add(result, 0)
+}
+`
+
+	entities, imports, err := extractor.ExtractAll(context.Background(), []byte(goCode), "go", "calc.go")
+	if err != nil {
+		t.Fatalf("ExtractAll failed: %v", err)
+	}
+
+	byName := make(map[string]models.CodeEntity)
+	for _, entity := range entities {
+		byName[entity.Name] = entity
+	}
+	if len(entities) != 5 {
+		t.Errorf("Expected 5 entities, got %d: %v", len(entities), byName)
+	}
+
+	add := byName["Add"]
+	if add.Type != models.EntityFunction || add.StartLine != 23 || add.EndLine != 29 {
+		t.Errorf("Add = %s at %d-%d, want Function at 23-29", add.Type, add.StartLine, add.EndLine)
+	}
+	if add.Docstring != "Add adds two numbers together" {
+		t.Errorf("Add docstring = %q", add.Docstring)
+	}
+	if want := []string{"fmt.Println", "str.TrimSpace"}; !reflect.DeepEqual(add.Calls, want) {
+		t.Errorf("Add calls = %v, want %v", add.Calls, want)
+	}
+	wantAccesses := []models.VariableAccess{
+		{Name: "counter", Line: 24, Write: true},
+		{Name: "Debug", Line: 25},
+		{Name: "fmt", Line: 26},
+		{Name: "str", Line: 26},
+	}
+	if !reflect.DeepEqual(add.Accesses, wantAccesses) {
+		t.Errorf("Add accesses = %v, want %v", add.Accesses, wantAccesses)
+	}
+
+	multiply := byName["Multiply"]
+	if multiply.Type != models.EntityMethod || multiply.ClassName != "Calculator" {
+		t.Errorf("Multiply = %s of %q, want Method of Calculator", multiply.Type, multiply.ClassName)
+	}
+	if len(multiply.Accesses) != 0 {
+		t.Errorf("Multiply should only use locals, got %v", multiply.Accesses)
+	}
+
+	if calc := byName["Calculator"]; calc.Type != models.EntityClass {
+		t.Errorf("Calculator type = %s, want Class", calc.Type)
+	}
+	if counter := byName["counter"]; counter.Type != models.EntityVariable || counter.Docstring != "counter counts operations" {
+		t.Errorf("counter = %s %q", counter.Type, counter.Docstring)
+	}
+
+	wantImports := []models.ImportRelation{{ImportPath: "fmt"}, {ImportPath: "strings", Alias: "str"}}
+	if !reflect.DeepEqual(imports, wantImports) {
+		t.Errorf("imports = %v, want %v", imports, wantImports)
+	}
+}
+
+func TestPureGoUnsupportedLanguage(t *testing.T) {
+	extractor := NewExtractor()
+	defer extractor.Close()
+
+	if extractor.Supports("python") {
+		t.Error("Expected python to be unsupported without tree-sitter")
+	}
+	if _, err := extractor.Extract(context.Background(), []byte("def f(): pass"), "python", "f.py"); err == nil {
+		t.Error("Expected error for python")
+	}
+}
+
+func TestExtractGoComplexityWithoutCGO(t *testing.T) {
+	extractor := NewExtractor()
+	defer extractor.Close()
+
+	code := `package a
+
+func f(xs []int, ok bool, ch chan int) int {
+	for _, x := range xs {
+		if x > 0 && ok || x < -10 {
+			return x
+		}
+	}
+	select {
+	case v := <-ch:
+		return v
+	default:
+	}
+	switch len(xs) {
+	case 0:
+		return 0
+	default:
+		return 2
+	}
+}
+`
+	entities, err := extractor.Extract(context.Background(), []byte(code), "go", "a.go")
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if len(entities) != 1 || entities[0].Complexity != 7 {
+		t.Fatalf("expected f with complexity 7, got %+v", entities)
+	}
+}
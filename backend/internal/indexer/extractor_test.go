@@ -1,7 +1,10 @@
+//go:build !purego
+
 package indexer
 
 import (
 	"context"
+	"reflect"
 	"testing"
 
 	"github.com/dpolishuk/neograph/backend/internal/models"
@@ -461,6 +464,246 @@ func main() {
 	}
 }
 
+func TestExtractMethodClassName(t *testing.T) {
+	extractor := NewExtractor()
+	defer extractor.Close()
+
+	tests := []struct {
+		language string
+		code     string
+		method   string
+		expected string
+	}{
+		{
+			language: "go",
+			code: `package main
+
+type Server struct{}
+
+func (s *Server[T]) Start() {}
+`,
+			method:   "Start",
+			expected: "Server",
+		},
+		{
+			language: "python",
+			code: `class Greeter:
+    def greet(self):
+        pass
+`,
+			method:   "greet",
+			expected: "Greeter",
+		},
+		{
+			language: "typescript",
+			code: `class Store {
+  load(): void {}
+}
+`,
+			method:   "load",
+			expected: "Store",
+		},
+		{
+			language: "java",
+			code: `public class Account {
+    public void close() {}
+}
+`,
+			method:   "close",
+			expected: "Account",
+		},
+		{
+			language: "kotlin",
+			code: `class Cart {
+    fun checkout() {}
+}
+`,
+			method:   "checkout",
+			expected: "Cart",
+		},
+	}
+
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			entities, err := extractor.Extract(ctx, []byte(tt.code), tt.language, "test")
+			if err != nil {
+				t.Fatalf("Extract failed: %v", err)
+			}
+
+			for _, entity := range entities {
+				if entity.Name == tt.method {
+					if entity.ClassName != tt.expected {
+						t.Errorf("Expected class %q for %s, got %q", tt.expected, tt.method, entity.ClassName)
+					}
+					return
+				}
+			}
+			t.Fatalf("method %s not found", tt.method)
+		})
+	}
+}
+
+func TestExtractCallSites(t *testing.T) {
+	extractor := NewExtractor()
+	defer extractor.Close()
+
+	code := `package main
+
+func main() {
+	run()
+	fmt.Println("x")
+	run()
+}
+`
+	ctx := context.Background()
+	entities, err := extractor.Extract(ctx, []byte(code), "go", "main.go")
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if len(entities) != 1 {
+		t.Fatalf("Expected 1 entity, got %d", len(entities))
+	}
+
+	expectedSites := []models.CallSite{
+		{Name: "run", Line: 4},
+		{Name: "fmt.Println", Line: 5},
+		{Name: "run", Line: 6},
+	}
+	if !reflect.DeepEqual(entities[0].CallSites, expectedSites) {
+		t.Errorf("CallSites = %v, want %v", entities[0].CallSites, expectedSites)
+	}
+
+	expectedCalls := []string{"run", "fmt.Println"}
+	if !reflect.DeepEqual(entities[0].Calls, expectedCalls) {
+		t.Errorf("Calls = %v, want %v", entities[0].Calls, expectedCalls)
+	}
+}
+
+func TestExtractGoVariableAccesses(t *testing.T) {
+	extractor := NewExtractor()
+	defer extractor.Close()
+
+	code := `package config
+
+// Debug turns on verbose logging
+var Debug bool
+
+var (
+	limit, _ = 10, 0
+	cache    = map[string]int{}
+)
+
+func Configure(debug bool, opts Options) {
+	Debug = debug
+	cache[opts.Name]++
+	settings.Level = opts.Level
+	n := limit
+	log.Printf("%d", n)
+	_ = Options{Name: name}
+	for k := range cache {
+		use(k)
+	}
+}
+`
+	entities, err := extractor.Extract(context.Background(), []byte(code), "go", "config/config.go")
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+
+	var variables []string
+	var configure *models.CodeEntity
+	for i, entity := range entities {
+		switch entity.Type {
+		case models.EntityVariable:
+			variables = append(variables, entity.Name)
+		case models.EntityFunction:
+			configure = &entities[i]
+		}
+	}
+	if want := []string{"Debug", "limit", "cache"}; !reflect.DeepEqual(variables, want) {
+		t.Errorf("variables = %v, want %v", variables, want)
+	}
+	if entities[0].Docstring != "Debug turns on verbose logging" {
+		t.Errorf("Debug docstring = %q", entities[0].Docstring)
+	}
+	if configure == nil {
+		t.Fatal("Configure not found")
+	}
+
+	expected := []models.VariableAccess{
+		{Name: "Debug", Line: 12, Write: true},
+		{Name: "cache", Line: 13, Write: true},
+		{Name: "settings.Level", Line: 14, Write: true},
+		{Name: "limit", Line: 15},
+		{Name: "log", Line: 16},
+		{Name: "name", Line: 17},
+		{Name: "cache", Line: 18},
+	}
+	if !reflect.DeepEqual(configure.Accesses, expected) {
+		t.Errorf("Accesses = %+v, want %+v", configure.Accesses, expected)
+	}
+}
+
+func TestExtractPythonVariableAccesses(t *testing.T) {
+	extractor := NewExtractor()
+	defer extractor.Close()
+
+	code := `import settings
+
+COUNT = 0
+registry, aliases = {}, {}
+
+def track(name, *args, retries=3, **kwargs):
+    global COUNT
+    COUNT += 1
+    registry[name] = args
+    settings.debug = True
+    total = len(aliases)
+    with open(name) as fh:
+        fh.read()
+    print(retries, key=settings.level)
+    helper()
+    return [x for x in registry]
+
+    def inner():
+        return COUNT
+`
+	entities, err := extractor.Extract(context.Background(), []byte(code), "python", "app/track.py")
+	if err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+
+	var variables []string
+	var track *models.CodeEntity
+	for i, entity := range entities {
+		switch {
+		case entity.Type == models.EntityVariable:
+			variables = append(variables, entity.Name)
+		case entity.Name == "track":
+			track = &entities[i]
+		}
+	}
+	if want := []string{"COUNT", "registry", "aliases"}; !reflect.DeepEqual(variables, want) {
+		t.Errorf("variables = %v, want %v", variables, want)
+	}
+	if track == nil {
+		t.Fatal("track not found")
+	}
+
+	expected := []models.VariableAccess{
+		{Name: "COUNT", Line: 8, Write: true},
+		{Name: "registry", Line: 9, Write: true},
+		{Name: "settings.debug", Line: 10, Write: true},
+		{Name: "aliases", Line: 11},
+		{Name: "settings.level", Line: 14},
+		{Name: "registry", Line: 16},
+	}
+	if !reflect.DeepEqual(track.Accesses, expected) {
+		t.Errorf("Accesses = %+v, want %+v", track.Accesses, expected)
+	}
+}
+
 func TestUnsupportedLanguage(t *testing.T) {
 	extractor := NewExtractor()
 	defer extractor.Close()
@@ -471,3 +714,95 @@ func TestUnsupportedLanguage(t *testing.T) {
 		t.Error("Expected error for unsupported language")
 	}
 }
+
+func TestExtractComplexity(t *testing.T) {
+	extractor := NewExtractor()
+	defer extractor.Close()
+
+	tests := []struct {
+		language string
+		path     string
+		code     string
+		want     int
+	}{
+		{"go", "a.go", `package a
+
+func f(xs []int, ok bool) int {
+	for _, x := range xs {
+		if x > 0 && ok || x < -10 {
+			return x
+		}
+	}
+	switch len(xs) {
+	case 0:
+		return 0
+	case 1, 2:
+		return 1
+	default:
+		return 2
+	}
+}
+`, 7},
+		{"python", "a.py", `def f(xs, ok):
+    for x in xs:
+        if x > 0 and ok:
+            return x
+        elif x < 0:
+            return -x
+    try:
+        return xs[0] if xs else None
+    except IndexError:
+        return None
+`, 7},
+		{"typescript", "a.ts", `function f(xs: number[]): number {
+  for (const x of xs) {
+    if (x > 0 || x === -1) return x
+  }
+  switch (xs.length) {
+    case 0: return 0
+    default: return xs[0] ?? 1
+  }
+}
+`, 6},
+		{"java", "A.java", `class A {
+  int f(int[] xs) {
+    for (int x : xs) {
+      if (x > 0 && x < 10) return x;
+    }
+    switch (xs.length) {
+      case 0: return 0;
+      default: return xs.length > 1 ? 1 : 2;
+    }
+  }
+}
+`, 6},
+		{"kotlin", "a.kt", `fun f(xs: List<Int>): Int {
+    for (x in xs) {
+        if (x > 0 && x < 10) return x
+    }
+    return when (xs.size) {
+        0 -> 0
+        else -> xs.firstOrNull() ?: 1
+    }
+}
+`, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			entities, err := extractor.Extract(context.Background(), []byte(tt.code), tt.language, tt.path)
+			if err != nil {
+				t.Fatalf("Extract failed: %v", err)
+			}
+			for _, entity := range entities {
+				if entity.Name == "f" {
+					if entity.Complexity != tt.want {
+						t.Errorf("Complexity = %d, want %d", entity.Complexity, tt.want)
+					}
+					return
+				}
+			}
+			t.Fatal("function f not found")
+		})
+	}
+}
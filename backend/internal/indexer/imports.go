@@ -0,0 +1,197 @@
+//go:build !purego
+
+package indexer
+
+import (
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// extractImports collects the import declarations of a file
+func (e *Extractor) extractImports(root *sitter.Node, content []byte, language string) []models.ImportRelation {
+	var imports []models.ImportRelation
+	e.traverseNode(root, content, func(node *sitter.Node) {
+		switch language {
+		case "go":
+			if node.Type() == "import_spec" {
+				imports = append(imports, goImport(node, content))
+			}
+		case "python":
+			switch node.Type() {
+			case "import_statement":
+				imports = append(imports, pythonImport(node, content)...)
+			case "import_from_statement":
+				imports = append(imports, pythonFromImport(node, content)...)
+			}
+		case "typescript", "javascript":
+			if node.Type() == "import_statement" {
+				imports = append(imports, e.tsImport(node, content)...)
+			}
+		case "java":
+			if node.Type() == "import_declaration" {
+				imports = append(imports, javaImport(node, content))
+			}
+		case "kotlin":
+			if node.Type() == "import_header" {
+				imports = append(imports, kotlinImport(node, content))
+			}
+		}
+	})
+	return imports
+}
+
+// goImport handles `alias "path"` import specs
+func goImport(node *sitter.Node, content []byte) models.ImportRelation {
+	imp := models.ImportRelation{
+		ImportPath: strings.Trim(getNodeContent(node.ChildByFieldName("path"), content), "\"`"),
+	}
+	if name := node.ChildByFieldName("name"); name != nil {
+		imp.Alias = getNodeContent(name, content)
+	}
+	return imp
+}
+
+// pythonImport handles `import a.b` and `import a.b as c`
+func pythonImport(node *sitter.Node, content []byte) []models.ImportRelation {
+	var imports []models.ImportRelation
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+		switch child.Type() {
+		case "dotted_name":
+			imports = append(imports, models.ImportRelation{ImportPath: getNodeContent(child, content)})
+		case "aliased_import":
+			imports = append(imports, models.ImportRelation{
+				ImportPath: getNodeContent(child.ChildByFieldName("name"), content),
+				Alias:      getNodeContent(child.ChildByFieldName("alias"), content),
+			})
+		}
+	}
+	return imports
+}
+
+// pythonFromImport handles `from a.b import c, d as e`
+func pythonFromImport(node *sitter.Node, content []byte) []models.ImportRelation {
+	module := getNodeContent(node.ChildByFieldName("module_name"), content)
+
+	var imports []models.ImportRelation
+	for i := 0; i < int(node.ChildCount()); i++ {
+		if node.FieldNameForChild(i) != "name" {
+			continue
+		}
+		child := node.Child(i)
+		switch child.Type() {
+		case "dotted_name":
+			imports = append(imports, models.ImportRelation{
+				ImportPath: module,
+				Symbol:     getNodeContent(child, content),
+			})
+		case "aliased_import":
+			imports = append(imports, models.ImportRelation{
+				ImportPath: module,
+				Symbol:     getNodeContent(child.ChildByFieldName("name"), content),
+				Alias:      getNodeContent(child.ChildByFieldName("alias"), content),
+			})
+		}
+	}
+	if len(imports) == 0 {
+		// from a import *
+		imports = append(imports, models.ImportRelation{ImportPath: module})
+	}
+	return imports
+}
+
+// tsImport handles default, namespace and named ES module imports
+func (e *Extractor) tsImport(node *sitter.Node, content []byte) []models.ImportRelation {
+	source := node.ChildByFieldName("source")
+	if source == nil {
+		return nil
+	}
+	path := strings.Trim(getNodeContent(source, content), "'\"`")
+
+	var imports []models.ImportRelation
+	e.traverseNode(node, content, func(n *sitter.Node) {
+		switch n.Type() {
+		case "import_clause":
+			for i := 0; i < int(n.NamedChildCount()); i++ {
+				if child := n.NamedChild(i); child.Type() == "identifier" {
+					imports = append(imports, models.ImportRelation{
+						ImportPath: path,
+						Symbol:     "default",
+						Alias:      getNodeContent(child, content),
+					})
+				}
+			}
+		case "namespace_import":
+			for i := 0; i < int(n.NamedChildCount()); i++ {
+				if child := n.NamedChild(i); child.Type() == "identifier" {
+					imports = append(imports, models.ImportRelation{
+						ImportPath: path,
+						Alias:      getNodeContent(child, content),
+					})
+				}
+			}
+		case "import_specifier":
+			imp := models.ImportRelation{
+				ImportPath: path,
+				Symbol:     getNodeContent(n.ChildByFieldName("name"), content),
+			}
+			if alias := n.ChildByFieldName("alias"); alias != nil {
+				imp.Alias = getNodeContent(alias, content)
+			}
+			imports = append(imports, imp)
+		}
+	})
+	if len(imports) == 0 {
+		// Side-effect import: import './polyfill'
+		imports = append(imports, models.ImportRelation{ImportPath: path})
+	}
+	return imports
+}
+
+// javaImport handles `import a.b.C;` and `import a.b.*;`
+func javaImport(node *sitter.Node, content []byte) models.ImportRelation {
+	var name string
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+		if child.Type() == "scoped_identifier" || child.Type() == "identifier" {
+			name = getNodeContent(child, content)
+			break
+		}
+	}
+	return splitQualifiedImport(name)
+}
+
+// kotlinImport handles `import a.b.C`, `import a.b.*` and `import a.b.C as D`
+func kotlinImport(node *sitter.Node, content []byte) models.ImportRelation {
+	var imp models.ImportRelation
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+		switch child.Type() {
+		case "identifier":
+			imp = splitQualifiedImport(getNodeContent(child, content))
+		case "import_alias":
+			for j := 0; j < int(child.NamedChildCount()); j++ {
+				if alias := child.NamedChild(j); alias.Type() == "type_identifier" || alias.Type() == "simple_identifier" {
+					imp.Alias = getNodeContent(alias, content)
+				}
+			}
+		}
+	}
+	return imp
+}
+
+// splitQualifiedImport turns "a.b.C" into package "a.b" and symbol "C" when
+// the last segment looks like a type name, otherwise keeps it as a package
+func splitQualifiedImport(name string) models.ImportRelation {
+	idx := strings.LastIndex(name, ".")
+	if idx < 0 {
+		return models.ImportRelation{ImportPath: name}
+	}
+	last := name[idx+1:]
+	if last != "" && strings.ToUpper(last[:1]) == last[:1] {
+		return models.ImportRelation{ImportPath: name[:idx], Symbol: last}
+	}
+	return models.ImportRelation{ImportPath: name}
+}
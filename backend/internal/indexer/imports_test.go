@@ -0,0 +1,80 @@
+//go:build !purego
+
+package indexer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+func TestExtractImports(t *testing.T) {
+	extractor := NewExtractor()
+	defer extractor.Close()
+
+	tests := []struct {
+		language string
+		code     string
+		expected []models.ImportRelation
+	}{
+		{
+			language: "go",
+			code:     "package main\n\nimport (\n\tf \"fmt\"\n\t\"github.com/acme/app/internal/api\"\n)\n",
+			expected: []models.ImportRelation{
+				{ImportPath: "fmt", Alias: "f"},
+				{ImportPath: "github.com/acme/app/internal/api"},
+			},
+		},
+		{
+			language: "python",
+			code:     "import os.path as p\nfrom app.services import users as u, orders\n",
+			expected: []models.ImportRelation{
+				{ImportPath: "os.path", Alias: "p"},
+				{ImportPath: "app.services", Symbol: "users", Alias: "u"},
+				{ImportPath: "app.services", Symbol: "orders"},
+			},
+		},
+		{
+			language: "typescript",
+			code:     "import React, { useState as us } from 'react';\nimport * as api from './api';\n",
+			expected: []models.ImportRelation{
+				{ImportPath: "react", Symbol: "default", Alias: "React"},
+				{ImportPath: "react", Symbol: "useState", Alias: "us"},
+				{ImportPath: "./api", Alias: "api"},
+			},
+		},
+		{
+			language: "java",
+			code:     "import com.acme.api.Handler;\nimport java.util.*;\n",
+			expected: []models.ImportRelation{
+				{ImportPath: "com.acme.api", Symbol: "Handler"},
+				{ImportPath: "java.util"},
+			},
+		},
+		{
+			language: "kotlin",
+			code:     "import com.acme.api.Handler as H\n",
+			expected: []models.ImportRelation{
+				{ImportPath: "com.acme.api", Symbol: "Handler", Alias: "H"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			_, imports, err := extractor.ExtractAll(context.Background(), []byte(tt.code), tt.language, "test")
+			if err != nil {
+				t.Fatalf("ExtractAll failed: %v", err)
+			}
+			if len(imports) != len(tt.expected) {
+				t.Fatalf("Expected %d imports, got %d: %+v", len(tt.expected), len(imports), imports)
+			}
+			for i, imp := range imports {
+				if imp != tt.expected[i] {
+					t.Errorf("Import %d: got %+v, want %+v", i, imp, tt.expected[i])
+				}
+			}
+		})
+	}
+}
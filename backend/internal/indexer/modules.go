@@ -0,0 +1,117 @@
+package indexer
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DetectModules returns the import paths other repositories can use to refer
+// to this one: the Go module, the npm package and the Python package
+// declared at the repository root, plus the host/owner/name path of the
+// clone URL, which is what Go module paths usually are.
+func DetectModules(repoPath, repoURL string) []string {
+	var modules []string
+	seen := make(map[string]bool)
+	add := func(module string) {
+		if module != "" && !seen[module] {
+			seen[module] = true
+			modules = append(modules, module)
+		}
+	}
+
+	add(goModulePath(filepath.Join(repoPath, "go.mod")))
+	add(npmPackageName(filepath.Join(repoPath, "package.json")))
+	add(pythonPackageName(filepath.Join(repoPath, "pyproject.toml")))
+	add(urlModulePath(repoURL))
+	return modules
+}
+
+// goModulePath reads the module directive of a go.mod file
+func goModulePath(goModPath string) string {
+	f, err := os.Open(goModPath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if rest, ok := strings.CutPrefix(line, "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
+
+// npmPackageName reads the name of a package.json file
+func npmPackageName(packageJSONPath string) string {
+	data, err := os.ReadFile(packageJSONPath)
+	if err != nil {
+		return ""
+	}
+	var pkg struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return ""
+	}
+	return pkg.Name
+}
+
+// pythonPackageName reads the project name of a pyproject.toml file as it is
+// imported: lowercase with dashes turned into underscores
+func pythonPackageName(pyprojectPath string) string {
+	f, err := os.Open(pyprojectPath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			continue
+		}
+		if section != "project" && section != "tool.poetry" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(key) != "name" {
+			continue
+		}
+		name := strings.Trim(strings.TrimSpace(value), `"'`)
+		return strings.ToLower(strings.ReplaceAll(name, "-", "_"))
+	}
+	return ""
+}
+
+// urlModulePath turns a clone URL into host/owner/name, as in
+// github.com/acme/billing
+func urlModulePath(url string) string {
+	url = strings.TrimSuffix(strings.TrimSpace(url), ".git")
+	if url == "" {
+		return ""
+	}
+
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+		// Drop credentials
+		if at := strings.Index(url, "@"); at >= 0 && at < strings.Index(url+"/", "/") {
+			url = url[at+1:]
+		}
+	} else if at := strings.Index(url, "@"); at >= 0 {
+		// SSH form: git@github.com:acme/billing
+		url = strings.Replace(url[at+1:], ":", "/", 1)
+	} else {
+		// Local paths are not importable
+		return ""
+	}
+	return strings.Trim(url, "/")
+}
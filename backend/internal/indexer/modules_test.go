@@ -0,0 +1,49 @@
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetectModules(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "// comment\nmodule github.com/acme/billing/v2\n\ngo 1.22\n")
+	write("package.json", `{"name": "@acme/billing-ui", "version": "1.0.0"}`)
+	write("pyproject.toml", "[build-system]\nrequires = [\"hatchling\"]\n\n[project]\nname = \"Billing-Client\"\n")
+
+	got := DetectModules(dir, "https://github.com/acme/billing.git")
+	expected := []string{
+		"github.com/acme/billing/v2",
+		"@acme/billing-ui",
+		"billing_client",
+		"github.com/acme/billing",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("DetectModules() = %v, want %v", got, expected)
+	}
+}
+
+func TestURLModulePath(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://github.com/acme/billing.git", "github.com/acme/billing"},
+		{"https://token@gitlab.example.com/group/sub/svc", "gitlab.example.com/group/sub/svc"},
+		{"git@github.com:acme/billing.git", "github.com/acme/billing"},
+		{"/srv/repos/billing", ""},
+	}
+
+	for _, tt := range tests {
+		if got := urlModulePath(tt.url); got != tt.expected {
+			t.Errorf("urlModulePath(%q) = %q, want %q", tt.url, got, tt.expected)
+		}
+	}
+}
@@ -1,21 +1,37 @@
 package indexer
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/dpolishuk/neograph/backend/internal/analysis"
 	"github.com/dpolishuk/neograph/backend/internal/db"
 	"github.com/dpolishuk/neograph/backend/internal/embedding"
+	"github.com/dpolishuk/neograph/backend/internal/lsp"
+	"github.com/dpolishuk/neograph/backend/internal/metrics"
 	"github.com/dpolishuk/neograph/backend/internal/models"
 )
 
 type Pipeline struct {
 	dbClient  *db.Neo4jClient
 	extractor *Extractor
-	teiClient *embedding.TEIClient
+	teiClient embedding.Embedder
+	// Embedding batches requested at once, at least 1
+	embedConcurrency int
+	// Optional model describing entities, set with SetDescriber
+	describer Completer
+
+	// Optional language servers resolving calls precisely
+	servers       lsp.Servers
+	serverTimeout time.Duration
 }
 
 func NewPipeline(dbClient *db.Neo4jClient) *Pipeline {
@@ -26,16 +42,50 @@ func NewPipeline(dbClient *db.Neo4jClient) *Pipeline {
 	}
 }
 
-// SetTEIClient optionally enables embedding generation
-func (p *Pipeline) SetTEIClient(client *embedding.TEIClient) {
+// SetTEIClient optionally enables embedding generation, requesting up to
+// concurrency batches at once
+func (p *Pipeline) SetTEIClient(client embedding.Embedder, concurrency int) {
 	p.teiClient = client
+	p.embedConcurrency = concurrency
+}
+
+// SetLanguageServers optionally runs language servers while indexing, each
+// request bounded by timeout
+func (p *Pipeline) SetLanguageServers(servers lsp.Servers, timeout time.Duration) {
+	p.servers = servers
+	p.serverTimeout = timeout
 }
 
 func (p *Pipeline) Close() {
 	p.extractor.Close()
 }
 
-func (p *Pipeline) IndexDirectory(ctx context.Context, dirPath, repoID string) (*models.IndexResult, error) {
+// IndexDirectory indexes the supported files of a directory, narrowed by
+// the repository's settings
+func (p *Pipeline) IndexDirectory(ctx context.Context, dirPath, repoID string, settings models.IndexSettings) (*models.IndexResult, error) {
+	return p.index(ctx, dirPath, repoID, settings, nil)
+}
+
+// IndexChanges indexes a directory indexed before, given the paths of the
+// files added, modified or deleted since and the hashes of the indexed
+// files. Every file is parsed again to resolve calls across them, but only
+// the entities of changed files, and of files the graph holds another
+// version of or none, as after the repository's settings changed, are
+// described and embedded; they come first in the result.
+func (p *Pipeline) IndexChanges(ctx context.Context, dirPath, repoID string, settings models.IndexSettings, changed []string, indexed map[string]string) (*models.IndexResult, error) {
+	paths := make(map[string]bool, len(changed))
+	for _, path := range changed {
+		paths[path] = true
+	}
+	return p.index(ctx, dirPath, repoID, settings, func(file *models.File) bool {
+		hash, ok := indexed[file.Path]
+		return paths[file.Path] || !ok || hash != file.Hash
+	})
+}
+
+// index indexes a directory, describing and embedding the entities of the
+// files fresh reports, or of all files when it is nil
+func (p *Pipeline) index(ctx context.Context, dirPath, repoID string, settings models.IndexSettings, fresh func(*models.File) bool) (*models.IndexResult, error) {
 	result := &models.IndexResult{
 		RepoID: repoID,
 	}
@@ -47,21 +97,26 @@ func (p *Pipeline) IndexDirectory(ctx context.Context, dirPath, repoID string) (
 			return err
 		}
 
-		// Skip hidden directories and common non-code directories
+		relPath, _ := filepath.Rel(dirPath, path)
+		ignored := relPath != "." && settings.Ignores(filepath.ToSlash(relPath))
+
+		// Skip hidden directories, common non-code directories and ignored ones
 		if info.IsDir() {
 			name := info.Name()
 			if name == ".git" || name == "node_modules" || name == "vendor" ||
 				name == "__pycache__" || name == ".venv" || name == "dist" ||
-				name == "build" || name == "target" {
+				name == "build" || name == "target" || ignored {
 				return filepath.SkipDir
 			}
 			return nil
 		}
+		if ignored || (settings.MaxFileSize > 0 && info.Size() > settings.MaxFileSize) {
+			return nil
+		}
 
-		// Check if file is supported
-		relPath, _ := filepath.Rel(dirPath, path)
+		// Check if file is supported, by this build's parser too
 		lang := models.DetectLanguage(path)
-		if lang != "" {
+		if lang != "" && p.extractor.Supports(lang) && settings.IndexesLanguage(lang) {
 			files = append(files, relPath)
 		}
 
@@ -73,7 +128,9 @@ func (p *Pipeline) IndexDirectory(ctx context.Context, dirPath, repoID string) (
 	}
 
 	// Process files sequentially to avoid tree-sitter CGO concurrency issues
-	for _, relPath := range files {
+	var kept []models.CodeEntity // of unchanged files, already in the graph
+	for i, relPath := range files {
+		reportProgress(ctx, StageParse, i, len(files))
 		fullPath := filepath.Join(dirPath, relPath)
 		file, entities, err := p.processFile(ctx, fullPath, relPath, repoID)
 
@@ -84,16 +141,44 @@ func (p *Pipeline) IndexDirectory(ctx context.Context, dirPath, repoID string) (
 
 		result.FilesProcessed++
 		result.Files = append(result.Files, file)
-		result.Entities = append(result.Entities, entities...)
 		result.EntitiesFound += len(entities)
+		if fresh != nil && !fresh(file) {
+			kept = append(kept, entities...)
+		} else {
+			result.Entities = append(result.Entities, entities...)
+		}
+	}
+	reportProgress(ctx, StageParse, len(files), len(files))
+
+	// Only the entities of fresh files are described and embedded
+	described := len(result.Entities)
+	result.Entities = append(result.Entities, kept...)
+
+	// Resolve definitions with language servers where configured
+	if len(p.servers) > 0 {
+		reportProgress(ctx, StageResolve, 0, 0)
+		stats := lsp.Annotate(ctx, p.servers, dirPath, result.Files, result.Entities, p.serverTimeout)
+		log.Printf("Language servers resolved %d call sites and %d implementations", stats.CallSites, stats.Implementations)
+	}
+
+	// Describe entities before embedding, which includes the descriptions
+	if p.describer != nil && described > 0 {
+		reportProgress(ctx, StageDescribe, 0, 0)
+		n := p.describeEntities(ctx, result.Entities[:described])
+		log.Printf("Described %d entities", n)
 	}
 
-	// Generate embeddings for all entities if TEIClient is available
-	if p.teiClient != nil && len(result.Entities) > 0 {
-		if err := p.generateEmbeddings(ctx, result.Entities); err != nil {
+	// Generate embeddings for the entities if TEIClient is available and the
+	// repository wants them
+	if p.teiClient != nil && !settings.SkipEmbeddings && described > 0 {
+		if err := p.generateEmbeddings(ctx, result.Entities[:described]); err != nil {
+			metrics.TEIErrors.Inc(repoID)
 			log.Printf("Warning: failed to generate embeddings: %v", err)
 			// Don't fail the entire indexing if embeddings fail
 		}
+		if err := p.embedDocstrings(ctx, result.Entities[:described]); err != nil {
+			log.Printf("Warning: failed to embed docstrings: %v", err)
+		}
 	}
 
 	return result, nil
@@ -117,18 +202,39 @@ func (p *Pipeline) processFile(ctx context.Context, fullPath, relPath, repoID st
 		Path:     relPath,
 		Language: lang,
 		Size:     info.Size(),
+		Lines:    countLines(content),
 		Hash:     hashContent(content),
 	}
 
-	// Extract code entities
-	entities, err := p.extractor.Extract(ctx, content, lang, relPath)
+	// Extract code entities and imports
+	entities, imports, err := p.extractor.ExtractAll(ctx, content, lang, relPath)
 	if err != nil {
 		return file, nil, fmt.Errorf("extraction failed: %w", err)
 	}
 
+	// Classify the architecture layer from path and imports
+	file.Imports = imports
+	file.Layer = analysis.ClassifyLayer(relPath, imports)
+	for i := range entities {
+		entities[i].Layer = file.Layer
+	}
+
 	return file, entities, nil
 }
 
+// countLines counts the lines of a file, the last one with or without a
+// trailing newline
+func countLines(content []byte) int {
+	if len(content) == 0 {
+		return 0
+	}
+	lines := bytes.Count(content, []byte("\n"))
+	if content[len(content)-1] != '\n' {
+		lines++
+	}
+	return lines
+}
+
 func hashContent(content []byte) string {
 	// Simple hash for change detection
 	var h uint64 = 5381
@@ -138,43 +244,171 @@ func hashContent(content []byte) string {
 	return fmt.Sprintf("%x", h)
 }
 
-// generateEmbeddings generates embeddings for entities in batches
-func (p *Pipeline) generateEmbeddings(ctx context.Context, entities []models.CodeEntity) error {
-	const batchSize = 32
+// EmbeddingText is what an entity's embedding is computed from: signature,
+// docstring, description and name
+func EmbeddingText(entity models.CodeEntity) string {
+	text := entity.Signature
+	if entity.Docstring != "" {
+		text += " " + entity.Docstring
+	}
+	if entity.NLDescription != "" {
+		text += " " + entity.NLDescription
+	}
+	return text + " " + entity.Name
+}
 
-	for i := 0; i < len(entities); i += batchSize {
-		end := i + batchSize
-		if end > len(entities) {
-			end = len(entities)
-		}
+// maxWikiEmbeddingBytes caps the start of a wiki page that is embedded,
+// within the input limits of common embedding models
+const maxWikiEmbeddingBytes = 6000
 
-		batch := entities[i:end]
+// WikiEmbeddingText is what a wiki page's embedding is computed from: its
+// title and the start of its content
+func WikiEmbeddingText(page models.WikiPage) string {
+	content := page.Content
+	if len(content) > maxWikiEmbeddingBytes {
+		content = strings.ToValidUTF8(content[:maxWikiEmbeddingBytes], "")
+	}
+	return page.Title + "\n\n" + content
+}
+
+// embedBatchSize is how many entities are embedded per request
+const embedBatchSize = 32
+
+// generateEmbeddings generates embeddings for entities in batches, several
+// at once, each stored in its own entities. A batch that fails is skipped,
+// leaving its entities without vectors, unless the embedding service is
+// unavailable or its vectors do not fit the vector index, when the remaining
+// batches are too.
+func (p *Pipeline) generateEmbeddings(ctx context.Context, entities []models.CodeEntity) error {
+	var space *db.VectorSpace
+	if p.dbClient != nil {
+		s := p.dbClient.WriteSpace()
+		space = &s
+	}
 
-		// Prepare embedding texts
-		texts := make([]string, len(batch))
-		for j, entity := range batch {
-			// Create embedding text from: signature + " " + docstring + " " + name
-			text := entity.Signature
-			if entity.Docstring != "" {
-				text += " " + entity.Docstring
+	reportProgress(ctx, StageEmbed, 0, len(entities))
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		embedded int
+		stopErr  error // why the remaining batches were dropped
+		lastErr  error
+		wg       sync.WaitGroup
+	)
+	batches := make(chan int) // start of each batch
+	for range max(p.embedConcurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range batches {
+				if ctx.Err() != nil {
+					continue
+				}
+				end := min(i+embedBatchSize, len(entities))
+				err := p.embedBatch(ctx, space, entities[i:end])
+
+				mu.Lock()
+				switch {
+				case err == nil:
+					embedded += end - i
+					log.Printf("Generated embeddings for entities %d-%d", i, end)
+					reportProgress(ctx, StageEmbed, embedded, len(entities))
+				case stopErr != nil:
+					// Cancelled by the batch that stopped the rest
+				case errors.Is(err, embedding.ErrUnavailable) || errors.Is(err, db.ErrDimensionMismatch) || ctx.Err() != nil:
+					stopErr = fmt.Errorf("stopped at batch %d-%d: %w", i, end, err)
+					cancel()
+				default:
+					log.Printf("Warning: skipping embeddings for entities %d-%d: %v", i, end, err)
+					lastErr = err
+				}
+				mu.Unlock()
 			}
-			text += " " + entity.Name
-			texts[j] = text
-		}
+		}()
+	}
 
-		// Generate embeddings
-		embeddings, err := p.teiClient.Embed(ctx, texts)
-		if err != nil {
-			return fmt.Errorf("failed to generate embeddings for batch %d-%d: %w", i, end, err)
+feed:
+	for i := 0; i < len(entities); i += embedBatchSize {
+		select {
+		case batches <- i:
+		case <-ctx.Done():
+			break feed
 		}
+	}
+	close(batches)
+	wg.Wait()
 
-		// Store embeddings back in entities
-		for j, embedding := range embeddings {
-			entities[i+j].Embedding = embedding
+	failed := len(entities) - embedded
+	if stopErr != nil {
+		return fmt.Errorf("failed to embed %d of %d entities, %w", failed, len(entities), stopErr)
+	}
+	if failed > 0 {
+		if lastErr == nil {
+			lastErr = ctx.Err()
 		}
+		return fmt.Errorf("failed to embed %d of %d entities: %w", failed, len(entities), lastErr)
+	}
+	return nil
+}
 
-		log.Printf("Generated embeddings for entities %d-%d", i, end)
+// embedBatch stores the embeddings of a batch of entities in them, checking
+// they fit space when set
+func (p *Pipeline) embedBatch(ctx context.Context, space *db.VectorSpace, batch []models.CodeEntity) error {
+	texts := make([]string, len(batch))
+	for i, entity := range batch {
+		texts[i] = EmbeddingText(entity)
 	}
 
+	embeddings, err := p.teiClient.Embed(ctx, texts)
+	if err == nil && len(embeddings) != len(batch) {
+		err = fmt.Errorf("got %d embeddings for %d entities", len(embeddings), len(batch))
+	}
+	if err == nil && space != nil {
+		err = space.CheckDimension(embeddings[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	for i, vector := range embeddings {
+		batch[i].Embedding = vector
+	}
+	return nil
+}
+
+// MinDocEmbeddingLength is the length from which a docstring is embedded on
+// its own as well, for documentation search; shorter ones add little to the
+// embedding of their entity
+const MinDocEmbeddingLength = 200
+
+// embedDocstrings sets the DocEmbedding of the entities with long
+// docstrings, a batch per request, stopping at the first failure
+func (p *Pipeline) embedDocstrings(ctx context.Context, entities []models.CodeEntity) error {
+	var documented []int // indexes of the entities with long docstrings
+	for i, entity := range entities {
+		if len(entity.Docstring) >= MinDocEmbeddingLength {
+			documented = append(documented, i)
+		}
+	}
+
+	for start := 0; start < len(documented); start += embedBatchSize {
+		indexes := documented[start:min(start+embedBatchSize, len(documented))]
+		texts := make([]string, len(indexes))
+		for i, index := range indexes {
+			texts[i] = entities[index].Docstring
+		}
+		vectors, err := p.teiClient.Embed(ctx, texts)
+		if err == nil && len(vectors) != len(texts) {
+			err = fmt.Errorf("got %d embeddings for %d docstrings", len(vectors), len(texts))
+		}
+		if err != nil {
+			return fmt.Errorf("embedded %d of %d docstrings: %w", start, len(documented), err)
+		}
+		for i, index := range indexes {
+			entities[index].DocEmbedding = vectors[i]
+		}
+	}
 	return nil
 }
@@ -1,10 +1,22 @@
+//go:build !purego
+
 package indexer
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dpolishuk/neograph/backend/internal/embedding"
+	"github.com/dpolishuk/neograph/backend/internal/models"
 )
 
 func TestIndexRepository(t *testing.T) {
@@ -44,7 +56,7 @@ func main() {
 	pipeline := NewPipeline(nil) // nil db client for unit test
 	defer pipeline.Close()
 
-	result, err := pipeline.IndexDirectory(context.Background(), tmpDir, "test-repo")
+	result, err := pipeline.IndexDirectory(context.Background(), tmpDir, "test-repo", models.IndexSettings{})
 	if err != nil {
 		t.Fatalf("IndexDirectory failed: %v", err)
 	}
@@ -76,9 +88,164 @@ func TestSkipIgnoredDirectories(t *testing.T) {
 	pipeline := NewPipeline(nil)
 	defer pipeline.Close()
 
-	result, _ := pipeline.IndexDirectory(context.Background(), tmpDir, "test-repo")
+	result, _ := pipeline.IndexDirectory(context.Background(), tmpDir, "test-repo", models.IndexSettings{})
 
 	if result.FilesProcessed != 1 {
 		t.Errorf("Expected 1 file (node_modules should be skipped), got %d", result.FilesProcessed)
 	}
 }
+
+func TestIndexSettings(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "gen"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "gen", "models.go"), []byte("package gen\nfunc A() {}\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "app.go"), []byte("package app\nfunc Main() {}\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "app_test.go"), []byte("package app\nfunc TestMain() {}\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "big.go"), []byte("package app\n// "+strings.Repeat("x", 1024)+"\nfunc Big() {}\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "app.js"), []byte("function main(){}"), 0644)
+
+	pipeline := NewPipeline(nil)
+	defer pipeline.Close()
+
+	result, err := pipeline.IndexDirectory(context.Background(), tmpDir, "test-repo", models.IndexSettings{
+		IgnoreGlobs: []string{"gen", "*_test.go"},
+		Languages:   []string{"go"},
+		MaxFileSize: 512,
+	})
+	if err != nil {
+		t.Fatalf("IndexDirectory failed: %v", err)
+	}
+
+	if len(result.Files) != 1 || result.Files[0].Path != "app.go" {
+		var paths []string
+		for _, file := range result.Files {
+			paths = append(paths, file.Path)
+		}
+		t.Errorf("Expected only app.go to be indexed, got %v", paths)
+	}
+}
+
+func TestCountLines(t *testing.T) {
+	for content, want := range map[string]int{
+		"":                    0,
+		"package main":        1,
+		"package main\n":      1,
+		"package main\n\nx\n": 3,
+		"a\r\nb":              2,
+	} {
+		if got := countLines([]byte(content)); got != want {
+			t.Errorf("countLines(%q) = %d, want %d", content, got, want)
+		}
+	}
+}
+
+// batchEmbedder fails the requests whose index is in fail with its error
+type batchEmbedder struct {
+	fail     map[int]error
+	requests int
+}
+
+func (e *batchEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.requests++
+	if err := e.fail[e.requests-1]; err != nil {
+		return nil, err
+	}
+	vectors := make([][]float32, len(texts))
+	for i := range vectors {
+		vectors[i] = []float32{1}
+	}
+	return vectors, nil
+}
+
+func TestGenerateEmbeddingsPartialFailure(t *testing.T) {
+	entities := make([]models.CodeEntity, 100) // batches 0-32, 32-64, 64-96, 96-100
+	p := &Pipeline{teiClient: &batchEmbedder{fail: map[int]error{1: errors.New("TEI error (status 413)")}}}
+
+	err := p.generateEmbeddings(context.Background(), entities)
+	if err == nil {
+		t.Fatal("expected an error for the failed batch")
+	}
+	for i, entity := range entities {
+		if failed := i >= 32 && i < 64; failed != (entity.Embedding == nil) {
+			t.Errorf("entity %d: embedding %v", i, entity.Embedding)
+		}
+	}
+
+	// An unavailable service stops the remaining batches
+	entities = make([]models.CodeEntity, 100)
+	unavailable := &batchEmbedder{fail: map[int]error{1: fmt.Errorf("%w: status 503", embedding.ErrUnavailable)}}
+	p = &Pipeline{teiClient: unavailable}
+	if err := p.generateEmbeddings(context.Background(), entities); !errors.Is(err, embedding.ErrUnavailable) {
+		t.Errorf("expected ErrUnavailable, got %v", err)
+	}
+	if unavailable.requests != 2 || entities[31].Embedding == nil || entities[99].Embedding != nil {
+		t.Errorf("expected to stop after the second of %d requests", unavailable.requests)
+	}
+}
+
+// numberEmbedder embeds "... e<n>" as {n}, tracking the requests in flight
+type numberEmbedder struct {
+	inFlight, maxInFlight atomic.Int32
+}
+
+func (e *numberEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	n := e.inFlight.Add(1)
+	defer e.inFlight.Add(-1)
+	for {
+		seen := e.maxInFlight.Load()
+		if n <= seen || e.maxInFlight.CompareAndSwap(seen, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		number, _ := strconv.Atoi(text[strings.LastIndex(text, " e")+2:])
+		vectors[i] = []float32{float32(number)}
+	}
+	return vectors, nil
+}
+
+func TestGenerateEmbeddingsConcurrently(t *testing.T) {
+	entities := make([]models.CodeEntity, 200)
+	for i := range entities {
+		entities[i].Name = fmt.Sprintf("e%d", i)
+	}
+	embedder := &numberEmbedder{}
+	p := &Pipeline{teiClient: embedder, embedConcurrency: 3}
+
+	var reported []int
+	ctx := WithProgress(context.Background(), func(stage string, done, total int) {
+		if stage == StageEmbed && total == len(entities) {
+			reported = append(reported, done)
+		}
+	})
+	if err := p.generateEmbeddings(ctx, entities); err != nil {
+		t.Fatal(err)
+	}
+	for i, entity := range entities {
+		if len(entity.Embedding) != 1 || entity.Embedding[0] != float32(i) {
+			t.Fatalf("entity %d got embedding %v", i, entity.Embedding)
+		}
+	}
+	if got := embedder.maxInFlight.Load(); got < 2 || got > 3 {
+		t.Errorf("%d requests in flight at most, want 2 to 3", got)
+	}
+	// Progress is reported before the first batch and after each
+	if len(reported) != 1+(len(entities)+embedBatchSize-1)/embedBatchSize || reported[0] != 0 || reported[len(reported)-1] != len(entities) {
+		t.Errorf("unexpected embedding progress %v", reported)
+	}
+}
+
+func TestWikiEmbeddingText(t *testing.T) {
+	page := models.WikiPage{Title: "Configuration", Content: "Set AUTH_TOKEN to enable auth."}
+	if got, want := WikiEmbeddingText(page), "Configuration\n\nSet AUTH_TOKEN to enable auth."; got != want {
+		t.Errorf("WikiEmbeddingText = %q, want %q", got, want)
+	}
+
+	page.Content = strings.Repeat("é", maxWikiEmbeddingBytes) // two bytes each
+	if got := WikiEmbeddingText(page); len(got) > len("Configuration\n\n")+maxWikiEmbeddingBytes || !utf8.ValidString(got) {
+		t.Errorf("expected the content cut at %d bytes on a rune boundary, got %d bytes", maxWikiEmbeddingBytes, len(got))
+	}
+}
@@ -0,0 +1,32 @@
+package indexer
+
+import "context"
+
+// Stages of indexing a directory reported to a ProgressFunc
+const (
+	StageParse    = "parse"    // extracting entities, per file
+	StageResolve  = "resolve"  // resolving calls with language servers
+	StageDescribe = "describe" // describing entities with the agent's model
+	StageEmbed    = "embed"    // embedding entities, per entity
+)
+
+// ProgressFunc is told how far indexing got in a stage: done of total items,
+// both 0 when the stage is not counted. It is called from the indexing
+// goroutines and must not block.
+type ProgressFunc func(stage string, done, total int)
+
+type progressKey struct{}
+
+// WithProgress returns a context whose indexing reports its progress to fn.
+// The pipeline is shared by concurrent index jobs, so the listener travels
+// with each job's context.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// reportProgress tells the listener of ctx, if any, how far indexing got
+func reportProgress(ctx context.Context, stage string, done, total int) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok {
+		fn(stage, done, total)
+	}
+}
@@ -0,0 +1,425 @@
+//go:build !purego
+
+package indexer
+
+import (
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// extractGoVariables extracts the variables of a package-level var declaration
+func (e *Extractor) extractGoVariables(declNode *sitter.Node, content []byte, filePath string) []models.CodeEntity {
+	var specs []*sitter.Node
+	for i := 0; i < int(declNode.NamedChildCount()); i++ {
+		child := declNode.NamedChild(i)
+		switch child.Type() {
+		case "var_spec":
+			specs = append(specs, child)
+		case "var_spec_list":
+			for j := 0; j < int(child.NamedChildCount()); j++ {
+				if spec := child.NamedChild(j); spec.Type() == "var_spec" {
+					specs = append(specs, spec)
+				}
+			}
+		}
+	}
+
+	var entities []models.CodeEntity
+	for _, spec := range specs {
+		// A spec inside var ( ... ) carries its own comment
+		docNode := spec
+		if spec.Parent().Equal(declNode) {
+			docNode = declNode
+		}
+		signature := "var " + getNodeContent(spec, content)
+		for i := 0; i < int(spec.NamedChildCount()); i++ {
+			nameNode := spec.NamedChild(i)
+			if nameNode.Type() != "identifier" {
+				continue
+			}
+			name := getNodeContent(nameNode, content)
+			if name == "_" {
+				continue
+			}
+			entities = append(entities, models.CodeEntity{
+				Type:      models.EntityVariable,
+				Name:      name,
+				Signature: signature,
+				Docstring: getPrecedingComment(docNode, content),
+				StartLine: int(spec.StartPoint().Row) + 1,
+				EndLine:   int(spec.EndPoint().Row) + 1,
+				FilePath:  filePath,
+				Calls:     []string{},
+				Content:   signature,
+			})
+		}
+	}
+	return entities
+}
+
+// extractPythonVariables extracts the names a module-level assignment binds
+func (e *Extractor) extractPythonVariables(stmt *sitter.Node, content []byte, filePath string) []models.CodeEntity {
+	if stmt.NamedChildCount() == 0 || stmt.NamedChild(0).Type() != "assignment" {
+		return nil
+	}
+	assignment := stmt.NamedChild(0)
+	signature := e.getPythonSignature(assignment, content)
+
+	var entities []models.CodeEntity
+	for _, nameNode := range boundIdentifiers(assignment.ChildByFieldName("left")) {
+		entities = append(entities, models.CodeEntity{
+			Type:      models.EntityVariable,
+			Name:      getNodeContent(nameNode, content),
+			Signature: signature,
+			Docstring: getPrecedingComment(stmt, content),
+			StartLine: int(stmt.StartPoint().Row) + 1,
+			EndLine:   int(stmt.EndPoint().Row) + 1,
+			FilePath:  filePath,
+			Calls:     []string{},
+			Content:   getNodeContent(stmt, content),
+		})
+	}
+	return entities
+}
+
+// boundIdentifiers returns the identifiers an assignment target binds,
+// looking into tuple and list patterns but not attributes or subscripts
+func boundIdentifiers(target *sitter.Node) []*sitter.Node {
+	if target == nil {
+		return nil
+	}
+	switch target.Type() {
+	case "identifier":
+		return []*sitter.Node{target}
+	case "expression_list", "pattern_list", "tuple_pattern", "list_pattern", "list_splat_pattern", "parenthesized_expression":
+		var names []*sitter.Node
+		for i := 0; i < int(target.NamedChildCount()); i++ {
+			names = append(names, boundIdentifiers(target.NamedChild(i))...)
+		}
+		return names
+	}
+	return nil
+}
+
+// accessRecorder collects variable accesses, once per name, line and kind
+type accessRecorder struct {
+	accesses []models.VariableAccess
+	seen     map[models.VariableAccess]bool
+}
+
+func (r *accessRecorder) add(name string, node *sitter.Node, write bool) {
+	access := models.VariableAccess{Name: name, Line: int(node.StartPoint().Row) + 1, Write: write}
+	if r.seen == nil {
+		r.seen = make(map[models.VariableAccess]bool)
+	}
+	if !r.seen[access] {
+		r.seen[access] = true
+		r.accesses = append(r.accesses, access)
+	}
+}
+
+// isField reports whether node is the child of parent under the given field
+func isField(parent, node *sitter.Node, field string) bool {
+	child := parent.ChildByFieldName(field)
+	return child != nil && child.Equal(node)
+}
+
+// extractGoAccesses records the names a Go function uses that it does not
+// declare itself. Scoping is flat: a name declared anywhere in the function
+// is local throughout it. A selector on such a name is recorded qualified,
+// as package.Variable or variable.field, and a name is written when it is
+// at the root of an assignment or increment target.
+func extractGoAccesses(fn *sitter.Node, content []byte) []models.VariableAccess {
+	locals := goLocals(fn, content)
+	var rec accessRecorder
+
+	var traverse func(*sitter.Node)
+	traverse = func(n *sitter.Node) {
+		if n.Type() == "identifier" {
+			recordGoAccess(&rec, n, content, locals)
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			traverse(n.NamedChild(i))
+		}
+	}
+	if body := fn.ChildByFieldName("body"); body != nil {
+		traverse(body)
+	}
+	return rec.accesses
+}
+
+func recordGoAccess(rec *accessRecorder, ident *sitter.Node, content []byte, locals map[string]bool) {
+	name := getNodeContent(ident, content)
+	if name == "_" || locals[name] {
+		return
+	}
+
+	parent := ident.Parent()
+	switch parent.Type() {
+	case "call_expression":
+		if isField(parent, ident, "function") {
+			return // a call, not a variable
+		}
+	case "literal_element":
+		// The key of a keyed struct literal element names a field
+		if kv := parent.Parent(); kv != nil && kv.Type() == "keyed_element" && kv.NamedChild(0).Equal(parent) {
+			return
+		}
+	}
+
+	target := ident
+	if parent.Type() == "selector_expression" && isField(parent, ident, "operand") {
+		field := parent.ChildByFieldName("field")
+		if outer := parent.Parent(); outer != nil && outer.Type() == "call_expression" && isField(outer, parent, "function") {
+			// pkg.Func() or value.Method(): only the operand is used
+			rec.add(name, ident, false)
+			return
+		}
+		name += "." + getNodeContent(field, content)
+		target = parent
+	}
+
+	rec.add(name, ident, goAssigned(target))
+}
+
+// goAssigned reports whether an expression is the root of an assignment,
+// increment or decrement target, such as x in x.f[i] = v or *x++
+func goAssigned(node *sitter.Node) bool {
+	for parent := node.Parent(); parent != nil; node, parent = parent, parent.Parent() {
+		switch parent.Type() {
+		case "selector_expression":
+			if !isField(parent, node, "operand") {
+				return false
+			}
+		case "index_expression":
+			if !isField(parent, node, "operand") {
+				return false
+			}
+		case "parenthesized_expression", "unary_expression":
+		case "inc_statement", "dec_statement":
+			return true
+		case "expression_list":
+			statement := parent.Parent()
+			return statement != nil && statement.Type() == "assignment_statement" && isField(statement, parent, "left")
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// goLocals collects every name a Go function declares: its receiver,
+// parameters, results, type parameters and the variables, constants and
+// range values declared in its body
+func goLocals(fn *sitter.Node, content []byte) map[string]bool {
+	locals := make(map[string]bool)
+	addNames := func(n *sitter.Node) {
+		if n == nil {
+			return
+		}
+		if n.Type() == "identifier" {
+			locals[getNodeContent(n, content)] = true
+			return
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			if child := n.NamedChild(i); child.Type() == "identifier" {
+				locals[getNodeContent(child, content)] = true
+			}
+		}
+	}
+
+	var traverse func(*sitter.Node)
+	traverse = func(n *sitter.Node) {
+		switch n.Type() {
+		case "parameter_declaration", "variadic_parameter_declaration", "type_parameter_declaration",
+			"var_spec", "const_spec":
+			addNames(n)
+		case "short_var_declaration":
+			addNames(n.ChildByFieldName("left"))
+		case "range_clause":
+			// for k, v := range only; for k = range assigns existing names
+			if strings.Contains(strings.SplitN(getNodeContent(n, content), "range", 2)[0], ":=") {
+				addNames(n.ChildByFieldName("left"))
+			}
+		case "type_switch_statement":
+			addNames(n.ChildByFieldName("alias"))
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			traverse(n.NamedChild(i))
+		}
+	}
+	traverse(fn)
+	return locals
+}
+
+// extractPythonAccesses records the names a Python function uses that it
+// does not bind itself. Names declared global are not local even when
+// assigned, and writing one is a write; an assignment to an attribute or
+// item of a non-local name is a write to that name. Nested functions and
+// classes are left to their own entities.
+func extractPythonAccesses(fn *sitter.Node, content []byte) []models.VariableAccess {
+	locals, globals := pythonLocals(fn, content)
+	var rec accessRecorder
+
+	var traverse func(*sitter.Node)
+	traverse = func(n *sitter.Node) {
+		switch n.Type() {
+		case "function_definition", "class_definition":
+			if !n.Equal(fn) {
+				return
+			}
+		case "global_statement", "nonlocal_statement", "import_statement", "import_from_statement":
+			return
+		case "identifier":
+			recordPythonAccess(&rec, n, content, locals, globals)
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			traverse(n.NamedChild(i))
+		}
+	}
+	if body := fn.ChildByFieldName("body"); body != nil {
+		traverse(body)
+	}
+	return rec.accesses
+}
+
+func recordPythonAccess(rec *accessRecorder, ident *sitter.Node, content []byte, locals, globals map[string]bool) {
+	name := getNodeContent(ident, content)
+	if locals[name] && !globals[name] {
+		return
+	}
+
+	parent := ident.Parent()
+	switch parent.Type() {
+	case "call":
+		if isField(parent, ident, "function") {
+			return
+		}
+	case "attribute":
+		if !isField(parent, ident, "object") {
+			return // the attribute name, recorded with its object
+		}
+	case "keyword_argument":
+		if isField(parent, ident, "name") {
+			return
+		}
+	}
+
+	if parent.Type() == "attribute" {
+		if outer := parent.Parent(); outer != nil && outer.Type() == "call" && isField(outer, parent, "function") {
+			// module.func() or value.method(): only the object is used
+			rec.add(name, ident, false)
+			return
+		}
+		name += "." + getNodeContent(parent.ChildByFieldName("attribute"), content)
+	}
+
+	target, mutated := ident, false
+	if parent.Type() == "attribute" {
+		target, mutated = parent, true
+	}
+	for outer := target.Parent(); outer != nil; target, outer = outer, outer.Parent() {
+		if outer.Type() == "attribute" && isField(outer, target, "object") ||
+			outer.Type() == "subscript" && isField(outer, target, "value") {
+			mutated = true
+			continue
+		}
+		if outer.Type() == "pattern_list" || outer.Type() == "tuple_pattern" || outer.Type() == "expression_list" {
+			continue
+		}
+		break
+	}
+	write := false
+	if assignment := target.Parent(); assignment != nil &&
+		(assignment.Type() == "assignment" || assignment.Type() == "augmented_assignment") &&
+		isField(assignment, target, "left") {
+		// Rebinding a name writes it only when it is declared global
+		write = mutated || globals[getNodeContent(ident, content)]
+	}
+	rec.add(name, ident, write)
+}
+
+// pythonLocals collects the names a Python function binds, and those it
+// declares global. Nested functions and classes bind their name only.
+func pythonLocals(fn *sitter.Node, content []byte) (map[string]bool, map[string]bool) {
+	locals := make(map[string]bool)
+	globals := make(map[string]bool)
+	bind := func(nodes ...*sitter.Node) {
+		for _, n := range nodes {
+			locals[getNodeContent(n, content)] = true
+		}
+	}
+
+	if params := fn.ChildByFieldName("parameters"); params != nil {
+		bindParameters(params, bind)
+	}
+
+	var traverse func(*sitter.Node)
+	traverse = func(n *sitter.Node) {
+		switch n.Type() {
+		case "function_definition", "class_definition":
+			if !n.Equal(fn) {
+				bind(n.ChildByFieldName("name"))
+				return
+			}
+		case "lambda":
+			if params := n.ChildByFieldName("parameters"); params != nil {
+				bindParameters(params, bind)
+			}
+		case "global_statement":
+			for i := 0; i < int(n.NamedChildCount()); i++ {
+				globals[getNodeContent(n.NamedChild(i), content)] = true
+			}
+			return
+		case "assignment", "augmented_assignment", "for_statement", "for_in_clause":
+			bind(boundIdentifiers(n.ChildByFieldName("left"))...)
+		case "as_pattern_target", "nonlocal_statement":
+			for i := 0; i < int(n.NamedChildCount()); i++ {
+				bind(boundIdentifiers(n.NamedChild(i))...)
+			}
+		case "named_expression":
+			bind(boundIdentifiers(n.ChildByFieldName("name"))...)
+		case "aliased_import":
+			bind(n.ChildByFieldName("alias"))
+		case "dotted_name":
+			if parent := n.Parent(); parent != nil && parent.Type() == "import_statement" && n.NamedChildCount() > 0 {
+				bind(n.NamedChild(0))
+			}
+			if parent := n.Parent(); parent != nil && parent.Type() == "import_from_statement" &&
+				!isField(parent, n, "module_name") && n.NamedChildCount() > 0 {
+				bind(n.NamedChild(0))
+			}
+		}
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			traverse(n.NamedChild(i))
+		}
+	}
+	if body := fn.ChildByFieldName("body"); body != nil {
+		traverse(body)
+	}
+	return locals, globals
+}
+
+// bindParameters binds the names in a Python parameter list
+func bindParameters(params *sitter.Node, bind func(...*sitter.Node)) {
+	for i := 0; i < int(params.NamedChildCount()); i++ {
+		param := params.NamedChild(i)
+		switch param.Type() {
+		case "identifier":
+			bind(param)
+		case "default_parameter", "typed_default_parameter":
+			if name := param.ChildByFieldName("name"); name != nil {
+				bind(name)
+			}
+		case "typed_parameter", "list_splat_pattern", "dictionary_splat_pattern":
+			for j := 0; j < int(param.NamedChildCount()); j++ {
+				if child := param.NamedChild(j); child.Type() == "identifier" {
+					bind(child)
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of background work
+const (
+	KindIndex      = "index"
+	KindWiki       = "wiki"
+	KindEmbeddings = "embeddings" // re-embedding for a new vector index
+	KindDuplicates = "duplicates" // similar-function detection
+)
+
+// States of a job
+const (
+	StateQueued  = "queued"
+	StateRunning = "running"
+	StateDone    = "done"
+	StateFailed  = "failed"
+)
+
+// finishedKept bounds how many finished jobs are listed
+const finishedKept = 50
+
+// Job is a unit of background work on a repository
+type Job struct {
+	ID         string    `json:"id"`
+	Kind       string    `json:"kind"`
+	RepoID     string    `json:"repoId"`
+	RepoName   string    `json:"repoName"`
+	State      string    `json:"state"`
+	Error      string    `json:"error,omitempty"`
+	EnqueuedAt time.Time `json:"enqueuedAt"`
+	StartedAt  time.Time `json:"startedAt,omitempty"`
+	FinishedAt time.Time `json:"finishedAt,omitempty"`
+}
+
+// Status is the state of the queue as a whole
+type Status struct {
+	Paused      bool `json:"paused"`
+	Draining    bool `json:"draining"`
+	Concurrency int  `json:"concurrency"`
+	Running     int  `json:"running"`
+	Queued      int  `json:"queued"`
+}
+
+type entry struct {
+	job  Job
+	run  func(ctx context.Context) error
+	done chan error
+}
+
+// Queue runs jobs in the order they were enqueued, at most concurrency at a
+// time. It can be paused, resized and drained while running.
+type Queue struct {
+	mu          sync.Mutex
+	concurrency int
+	paused      bool
+	draining    int
+	pending     []*entry
+	running     map[string]*entry
+	finished    []Job
+	idle        []chan struct{} // closed once no job is running
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// New creates a queue running up to concurrency jobs at once
+func New(concurrency int) *Queue {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Queue{
+		concurrency: max(concurrency, 1),
+		running:     make(map[string]*entry),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Enqueue adds a job and returns it with a channel receiving its result
+// once it has run
+func (q *Queue) Enqueue(kind, repoID, repoName string, run func(ctx context.Context) error) (Job, <-chan error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e := &entry{
+		job: Job{
+			ID:         uuid.New().String(),
+			Kind:       kind,
+			RepoID:     repoID,
+			RepoName:   repoName,
+			State:      StateQueued,
+			EnqueuedAt: time.Now().UTC(),
+		},
+		run:  run,
+		done: make(chan error, 1),
+	}
+	q.pending = append(q.pending, e)
+	q.dispatchLocked()
+	return e.job, e.done
+}
+
+// Jobs lists running jobs, then queued ones in the order they will run,
+// then the most recently finished
+func (q *Queue) Jobs() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs := make([]Job, 0, len(q.running)+len(q.pending)+len(q.finished))
+	for _, e := range q.running {
+		jobs = append(jobs, e.job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].StartedAt.Before(jobs[j].StartedAt) })
+	for _, e := range q.pending {
+		jobs = append(jobs, e.job)
+	}
+	for i := len(q.finished) - 1; i >= 0; i-- {
+		jobs = append(jobs, q.finished[i])
+	}
+	return jobs
+}
+
+// Status returns the queue's settings and load
+func (q *Queue) Status() Status {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return Status{
+		Paused:      q.paused,
+		Draining:    q.draining > 0,
+		Concurrency: q.concurrency,
+		Running:     len(q.running),
+		Queued:      len(q.pending),
+	}
+}
+
+// Pause stops queued jobs from starting; running jobs continue
+func (q *Queue) Pause() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.paused = true
+}
+
+// Resume starts queued jobs again
+func (q *Queue) Resume() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.paused = false
+	q.dispatchLocked()
+}
+
+// SetConcurrency changes how many jobs run at once. Lowering it lets
+// running jobs finish rather than stopping them.
+func (q *Queue) SetConcurrency(n int) error {
+	if n < 1 {
+		return errors.New("concurrency must be at least 1")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.concurrency = n
+	q.dispatchLocked()
+	return nil
+}
+
+// Drain pauses the queue and waits until no job is running, so the process
+// can be stopped without interrupting work. Queued jobs stay queued until
+// Resume.
+func (q *Queue) Drain(ctx context.Context) error {
+	q.mu.Lock()
+	q.paused = true
+	if len(q.running) == 0 {
+		q.mu.Unlock()
+		return nil
+	}
+	idle := make(chan struct{})
+	q.idle = append(q.idle, idle)
+	q.draining++
+	q.mu.Unlock()
+
+	defer func() {
+		q.mu.Lock()
+		q.draining--
+		q.mu.Unlock()
+	}()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close cancels the running jobs
+func (q *Queue) Close() {
+	q.cancel()
+}
+
+// dispatchLocked starts queued jobs while there is room
+func (q *Queue) dispatchLocked() {
+	for !q.paused && len(q.pending) > 0 && len(q.running) < q.concurrency {
+		e := q.pending[0]
+		q.pending = q.pending[1:]
+
+		e.job.State = StateRunning
+		e.job.StartedAt = time.Now().UTC()
+		q.running[e.job.ID] = e
+		go q.execute(e)
+	}
+}
+
+func (q *Queue) execute(e *entry) {
+	err := e.run(q.ctx)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.running, e.job.ID)
+	e.job.FinishedAt = time.Now().UTC()
+	e.job.State = StateDone
+	if err != nil {
+		e.job.State = StateFailed
+		e.job.Error = err.Error()
+	}
+	q.finished = append(q.finished, e.job)
+	if len(q.finished) > finishedKept {
+		q.finished = q.finished[len(q.finished)-finishedKept:]
+	}
+	e.done <- err
+
+	if len(q.running) == 0 {
+		for _, idle := range q.idle {
+			close(idle)
+		}
+		q.idle = nil
+	}
+	q.dispatchLocked()
+}
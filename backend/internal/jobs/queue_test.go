@@ -0,0 +1,122 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// blockingJob returns a job that runs until release is closed
+func blockingJob(started chan<- string, name string, release <-chan struct{}) func(context.Context) error {
+	return func(ctx context.Context) error {
+		started <- name
+		<-release
+		return nil
+	}
+}
+
+func waitStarted(t *testing.T, started <-chan string) string {
+	t.Helper()
+	select {
+	case name := <-started:
+		return name
+	case <-time.After(time.Second):
+		t.Fatal("job did not start")
+		return ""
+	}
+}
+
+func TestQueueConcurrency(t *testing.T) {
+	q := New(1)
+	defer q.Close()
+
+	started := make(chan string, 3)
+	release := make(chan struct{})
+	q.Enqueue(KindIndex, "r1", "one", blockingJob(started, "one", release))
+	q.Enqueue(KindIndex, "r2", "two", blockingJob(started, "two", release))
+
+	if got := waitStarted(t, started); got != "one" {
+		t.Errorf("expected job one to start first, got %s", got)
+	}
+	if status := q.Status(); status.Running != 1 || status.Queued != 1 {
+		t.Errorf("expected 1 running and 1 queued, got %+v", status)
+	}
+
+	// Raising concurrency starts the queued job at once
+	if err := q.SetConcurrency(2); err != nil {
+		t.Fatal(err)
+	}
+	if got := waitStarted(t, started); got != "two" {
+		t.Errorf("expected job two to start, got %s", got)
+	}
+	close(release)
+
+	if err := q.SetConcurrency(0); err == nil {
+		t.Error("expected error for concurrency 0")
+	}
+}
+
+func TestQueuePauseResume(t *testing.T) {
+	q := New(2)
+	defer q.Close()
+	q.Pause()
+
+	_, done := q.Enqueue(KindWiki, "r1", "one", func(ctx context.Context) error {
+		return errors.New("agent unavailable")
+	})
+	if status := q.Status(); !status.Paused || status.Queued != 1 || status.Running != 0 {
+		t.Errorf("expected paused queue holding the job, got %+v", status)
+	}
+
+	q.Resume()
+	select {
+	case err := <-done:
+		if err == nil || err.Error() != "agent unavailable" {
+			t.Errorf("expected the job's error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("job did not run after resume")
+	}
+
+	jobs := q.Jobs()
+	if len(jobs) != 1 || jobs[0].State != StateFailed || jobs[0].Error != "agent unavailable" {
+		t.Errorf("expected one failed job, got %+v", jobs)
+	}
+}
+
+func TestQueueDrain(t *testing.T) {
+	q := New(1)
+	defer q.Close()
+
+	started := make(chan string, 2)
+	release := make(chan struct{})
+	q.Enqueue(KindIndex, "r1", "one", blockingJob(started, "one", release))
+	q.Enqueue(KindIndex, "r2", "two", blockingJob(started, "two", release))
+	waitStarted(t, started)
+
+	// A drain that times out leaves the running job alone
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	drained := make(chan error, 1)
+	go func() { drained <- q.Drain(context.Background()) }()
+	close(release)
+
+	select {
+	case err := <-drained:
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("drain did not return once the running job finished")
+	}
+
+	// The queued job waits for Resume
+	if status := q.Status(); !status.Paused || status.Queued != 1 || status.Running != 0 {
+		t.Errorf("expected drained queue holding the queued job, got %+v", status)
+	}
+}
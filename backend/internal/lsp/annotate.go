@@ -0,0 +1,231 @@
+package lsp
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// maxFailures is how many requests in a row may fail before a language
+// server is given up on
+const maxFailures = 20
+
+// languageIDs maps indexed languages to LSP language identifiers
+var languageIDs = map[string]string{
+	"go":         "go",
+	"python":     "python",
+	"typescript": "typescript",
+	"javascript": "javascript",
+	"java":       "java",
+	"kotlin":     "kotlin",
+}
+
+// Servers maps languages to the command line of their language server
+type Servers map[string][]string
+
+// ParseServers reads `language=command args` entries such as go=gopls or
+// python=pyright-langserver --stdio, skipping unknown languages
+func ParseServers(entries []string) Servers {
+	servers := make(Servers)
+	for _, entry := range entries {
+		language, command, ok := strings.Cut(entry, "=")
+		language = strings.ToLower(strings.TrimSpace(language))
+		if _, known := languageIDs[language]; !ok || !known {
+			log.Printf("Ignoring language server %q: expected language=command of an indexed language", entry)
+			continue
+		}
+		if fields := strings.Fields(command); len(fields) > 0 {
+			servers[language] = fields
+		}
+	}
+	return servers
+}
+
+// Stats counts what the language servers resolved
+type Stats struct {
+	CallSites       int // call sites whose callee definition was found
+	Implementations int // implementations found for classes
+}
+
+// Annotate runs the language server of each language among files on the
+// project at dir, recording on the entities where each call site's callee
+// is defined and which types implement each class. Each request gets up to
+// timeout. Languages without a server, or whose server fails, are left to
+// name-based call resolution.
+func Annotate(ctx context.Context, servers Servers, dir string, files []*models.File, entities []models.CodeEntity, timeout time.Duration) Stats {
+	var stats Stats
+	byLanguage := make(map[string][]*models.File)
+	for _, file := range files {
+		if _, ok := servers[file.Language]; ok {
+			byLanguage[file.Language] = append(byLanguage[file.Language], file)
+		}
+	}
+
+	for language, langFiles := range byLanguage {
+		client, err := Start(ctx, servers[language], dir)
+		if err != nil {
+			log.Printf("Language server for %s unavailable, resolving calls by name: %v", language, err)
+			continue
+		}
+		a := &annotator{client: client, timeout: timeout, lines: make(map[string][]string)}
+		for _, file := range langFiles {
+			content, err := os.ReadFile(filepath.Join(dir, file.Path))
+			if err != nil {
+				continue
+			}
+			if err := client.Open(file.Path, languageIDs[language], string(content)); err != nil {
+				break
+			}
+			a.lines[file.Path] = strings.Split(string(content), "\n")
+		}
+
+		for i := range entities {
+			if _, ok := a.lines[entities[i].FilePath]; ok && !a.failed() {
+				a.annotate(ctx, &entities[i], &stats)
+			}
+		}
+		if a.failed() {
+			log.Printf("Language server for %s kept failing, resolving the rest by name", language)
+		}
+		client.Close()
+	}
+	return stats
+}
+
+// annotator queries one language server about the files it opened
+type annotator struct {
+	client   *Client
+	timeout  time.Duration
+	lines    map[string][]string // path -> source lines
+	failures int                 // consecutive failed requests
+}
+
+func (a *annotator) failed() bool {
+	return a.failures >= maxFailures
+}
+
+func (a *annotator) annotate(ctx context.Context, entity *models.CodeEntity, stats *Stats) {
+	lines := a.lines[entity.FilePath]
+	for j := range entity.CallSites {
+		site := &entity.CallSites[j]
+		if site.Line < 1 || site.Line > len(lines) {
+			continue
+		}
+		col := callColumn(lines[site.Line-1], site.Name)
+		if col < 0 {
+			continue
+		}
+		locations, ok := a.query(ctx, a.client.Definition, entity.FilePath, site.Line, col)
+		if !ok || len(locations) == 0 {
+			continue
+		}
+		definition := &models.SourceLocation{}
+		if path, inside := a.client.RelPath(locations[0].URI); inside {
+			definition = &models.SourceLocation{FilePath: path, Line: locations[0].Range.Start.Line + 1}
+		}
+		site.Definition = definition
+		stats.CallSites++
+	}
+
+	if entity.Type != models.EntityClass {
+		return
+	}
+	line, col := nameColumn(lines, entity.StartLine, entity.EndLine, entity.Name)
+	if col < 0 {
+		return
+	}
+	locations, _ := a.query(ctx, a.client.Implementation, entity.FilePath, line, col)
+	for _, location := range locations {
+		if path, inside := a.client.RelPath(location.URI); inside {
+			entity.Implementations = append(entity.Implementations, models.SourceLocation{
+				FilePath: path,
+				Line:     location.Range.Start.Line + 1,
+			})
+			stats.Implementations++
+		}
+	}
+}
+
+// query runs a position request with the request timeout, counting failures
+func (a *annotator) query(ctx context.Context, request func(context.Context, string, Position) ([]Location, error), path string, line, col int) ([]Location, bool) {
+	reqCtx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	locations, err := request(reqCtx, path, Position{Line: line - 1, Character: col})
+	if err != nil {
+		a.failures++
+		return nil, false
+	}
+	a.failures = 0
+	return locations, true
+}
+
+// callColumn returns the UTF-16 column of the called name's last segment,
+// e.g. Println in fmt.Println, on a source line, or -1 if it is not there
+func callColumn(line, call string) int {
+	call = strings.TrimSpace(call)
+	if i := strings.IndexAny(call, "[<"); i > 0 {
+		call = call[:i] // type arguments
+	}
+	last := call
+	if i := strings.LastIndexAny(call, ".:"); i >= 0 {
+		last = call[i+1:]
+	}
+	if last == "" {
+		return -1
+	}
+
+	if i := wordIndex(line, call); i >= 0 {
+		return utf16Column(line, i+len(call)-len(last))
+	}
+	if i := wordIndex(line, last); i >= 0 {
+		return utf16Column(line, i)
+	}
+	return -1
+}
+
+// nameColumn finds the line and UTF-16 column of a declared name within the
+// first lines of its declaration, which may start with annotations
+func nameColumn(lines []string, startLine, endLine int, name string) (int, int) {
+	for line := startLine; line <= endLine && line <= len(lines) && line < startLine+5; line++ {
+		if line < 1 {
+			continue
+		}
+		if i := wordIndex(lines[line-1], name); i >= 0 {
+			return line, utf16Column(lines[line-1], i)
+		}
+	}
+	return 0, -1
+}
+
+// wordIndex returns the byte offset of word in s where it is not part of a
+// longer identifier, or -1
+func wordIndex(s, word string) int {
+	isIdent := func(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+	for offset := 0; offset < len(s); {
+		i := strings.Index(s[offset:], word)
+		if i < 0 {
+			return -1
+		}
+		start, end := offset+i, offset+i+len(word)
+		before := start == 0 || !isIdent(rune(s[start-1]))
+		after := end == len(s) || !isIdent(rune(s[end]))
+		if before && after {
+			return start
+		}
+		offset = start + 1
+	}
+	return -1
+}
+
+// utf16Column converts a byte offset in a line to the UTF-16 code units
+// LSP positions count
+func utf16Column(line string, byteOffset int) int {
+	return len(utf16.Encode([]rune(line[:byteOffset])))
+}
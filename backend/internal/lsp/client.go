@@ -0,0 +1,367 @@
+// Package lsp talks to language servers (gopls, pyright, tsserver, ...) over
+// stdio to resolve definitions and implementations more precisely than
+// name matching can
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// shutdownTimeout bounds how long a server gets to shut down cleanly
+const shutdownTimeout = 5 * time.Second
+
+// Position is a zero-based line and UTF-16 character offset
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a span between two positions
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a range in a document
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// message is a JSON-RPC request, notification or response
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  any              `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *responseError   `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("language server error %d: %s", e.Code, e.Message)
+}
+
+// Client is a connection to one language server
+type Client struct {
+	in   io.WriteCloser
+	out  *bufio.Reader
+	cmd  *exec.Cmd
+	root string
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]chan *message
+	err     error // set once the server's output ends
+	done    chan struct{}
+}
+
+// Start runs a language server for the project at rootDir and initializes it
+func Start(ctx context.Context, command []string, rootDir string) (*Client, error) {
+	if len(command) == 0 {
+		return nil, errors.New("empty language server command")
+	}
+	root, err := filepath.Abs(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Dir = root
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", command[0], err)
+	}
+
+	c := newClient(out, in, root)
+	c.cmd = cmd
+	if err := c.initialize(ctx); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to initialize %s: %w", command[0], err)
+	}
+	return c, nil
+}
+
+// newClient speaks the protocol over a server's stdout and stdin
+func newClient(out io.Reader, in io.WriteCloser, root string) *Client {
+	c := &Client{
+		in:      in,
+		out:     bufio.NewReader(out),
+		root:    root,
+		pending: make(map[int]chan *message),
+		done:    make(chan struct{}),
+	}
+	go c.read()
+	return c
+}
+
+func (c *Client) initialize(ctx context.Context) error {
+	params := map[string]any{
+		"processId": nil,
+		"rootUri":   c.URI(""),
+		"workspaceFolders": []map[string]string{
+			{"uri": c.URI(""), "name": filepath.Base(c.root)},
+		},
+		"capabilities": map[string]any{
+			"textDocument": map[string]any{
+				"definition":     map[string]any{"linkSupport": false},
+				"implementation": map[string]any{"linkSupport": false},
+			},
+		},
+	}
+	if err := c.call(ctx, "initialize", params, nil); err != nil {
+		return err
+	}
+	return c.notify("initialized", map[string]any{})
+}
+
+// URI returns the file URI of a path relative to the project root
+func (c *Client) URI(relPath string) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(c.root, relPath))}
+	return u.String()
+}
+
+// RelPath returns the path of a file URI relative to the project root, or
+// false if it lies outside the project
+func (c *Client) RelPath(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	rel, err := filepath.Rel(c.root, filepath.FromSlash(u.Path))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// Open tells the server about a file so it can be queried
+func (c *Client) Open(relPath, languageID, text string) error {
+	return c.notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{
+			"uri":        c.URI(relPath),
+			"languageId": languageID,
+			"version":    1,
+			"text":       text,
+		},
+	})
+}
+
+// Definition returns where the symbol at a position is defined
+func (c *Client) Definition(ctx context.Context, relPath string, pos Position) ([]Location, error) {
+	return c.locations(ctx, "textDocument/definition", relPath, pos)
+}
+
+// Implementation returns the implementations of the type or method at a
+// position
+func (c *Client) Implementation(ctx context.Context, relPath string, pos Position) ([]Location, error) {
+	return c.locations(ctx, "textDocument/implementation", relPath, pos)
+}
+
+func (c *Client) locations(ctx context.Context, method, relPath string, pos Position) ([]Location, error) {
+	params := map[string]any{
+		"textDocument": map[string]string{"uri": c.URI(relPath)},
+		"position":     pos,
+	}
+	var raw json.RawMessage
+	if err := c.call(ctx, method, params, &raw); err != nil {
+		return nil, err
+	}
+
+	// The result is null, a Location or a list of them
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var locations []Location
+	if raw[0] == '[' {
+		err := json.Unmarshal(raw, &locations)
+		return locations, err
+	}
+	var location Location
+	if err := json.Unmarshal(raw, &location); err != nil {
+		return nil, err
+	}
+	return []Location{location}, nil
+}
+
+// Close shuts the server down and waits for it to exit, killing it if it
+// does not within shutdownTimeout
+func (c *Client) Close() error {
+	select {
+	case <-c.done:
+	default:
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		c.call(ctx, "shutdown", nil, nil)
+		cancel()
+		c.notify("exit", nil)
+	}
+	c.in.Close()
+	if c.cmd == nil {
+		return nil
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- c.cmd.Wait() }()
+	select {
+	case err := <-exited:
+		return err
+	case <-time.After(shutdownTimeout):
+		c.cmd.Process.Kill()
+		return <-exited
+	}
+}
+
+// call sends a request and decodes its result into result, if not nil
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.nextID++
+	id := c.nextID
+	reply := make(chan *message, 1)
+	c.pending[id] = reply
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	rawID := json.RawMessage(strconv.Itoa(id))
+	if err := c.write(&message{JSONRPC: "2.0", ID: &rawID, Method: method, Params: params}); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return c.err
+	case msg := <-reply:
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result == nil || len(msg.Result) == 0 {
+			return nil
+		}
+		return json.Unmarshal(msg.Result, result)
+	}
+}
+
+func (c *Client) notify(method string, params any) error {
+	return c.write(&message{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (c *Client) write(msg *message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := fmt.Fprintf(c.in, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = c.in.Write(body)
+	return err
+}
+
+// read dispatches responses to their callers until the server's output ends
+func (c *Client) read() {
+	headers := textproto.NewReader(c.out)
+	var err error
+	for {
+		var msg *message
+		if msg, err = readMessage(headers, c.out); err != nil {
+			break
+		}
+		switch {
+		case msg.ID != nil && msg.Method != "":
+			// A request from the server, such as workspace/configuration;
+			// answer so it does not wait on us, without blocking reading
+			// on a server that is itself blocked writing to us
+			go c.write(&message{JSONRPC: "2.0", ID: msg.ID, Result: serverRequestResult(msg)})
+		case msg.ID != nil:
+			id, convErr := strconv.Atoi(string(*msg.ID))
+			c.mu.Lock()
+			reply, ok := c.pending[id]
+			c.mu.Unlock()
+			if convErr == nil && ok {
+				reply <- msg
+			}
+		}
+		// Notifications such as diagnostics and progress are ignored
+	}
+
+	c.mu.Lock()
+	if errors.Is(err, io.EOF) {
+		err = errors.New("language server exited")
+	}
+	c.err = err
+	c.mu.Unlock()
+	close(c.done)
+}
+
+// serverRequestResult answers a request from the server: one empty setting
+// per item asked for by workspace/configuration, null otherwise
+func serverRequestResult(msg *message) json.RawMessage {
+	if msg.Method == "workspace/configuration" {
+		var params struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		if raw, err := json.Marshal(msg.Params); err == nil && json.Unmarshal(raw, &params) == nil {
+			return json.RawMessage("[" + strings.TrimSuffix(strings.Repeat("null,", len(params.Items)), ",") + "]")
+		}
+	}
+	return json.RawMessage("null")
+}
+
+// readMessage reads one Content-Length framed message
+func readMessage(headers *textproto.Reader, out *bufio.Reader) (*message, error) {
+	header, err := headers.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length: %w", err)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(out, body); err != nil {
+		return nil, err
+	}
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
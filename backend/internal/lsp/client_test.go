@@ -0,0 +1,160 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/textproto"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeServer answers definition requests with a fixed location, asks the
+// client for its configuration once and fails every other request
+func fakeServer(t *testing.T, in io.Reader, out io.WriteCloser, root string) {
+	t.Helper()
+	reader := bufio.NewReader(in)
+	headers := textproto.NewReader(reader)
+	write := func(msg map[string]any) {
+		body, _ := json.Marshal(msg)
+		io.WriteString(out, "Content-Length: "+itoa(len(body))+"\r\n\r\n")
+		out.Write(body)
+	}
+
+	go func() {
+		defer out.Close()
+		for {
+			msg, err := readMessage(headers, reader)
+			if err != nil {
+				return
+			}
+			switch msg.Method {
+			case "initialize":
+				write(map[string]any{"jsonrpc": "2.0", "id": msg.ID, "result": map[string]any{"capabilities": map[string]any{}}})
+			case "initialized":
+				write(map[string]any{"jsonrpc": "2.0", "id": 99, "method": "workspace/configuration",
+					"params": map[string]any{"items": []any{map[string]any{}, map[string]any{}}}})
+			case "textDocument/definition":
+				write(map[string]any{"jsonrpc": "2.0", "method": "window/logMessage", "params": map[string]any{"message": "working"}})
+				write(map[string]any{"jsonrpc": "2.0", "id": msg.ID, "result": map[string]any{
+					"uri":   "file://" + root + "/pkg/store.go",
+					"range": map[string]any{"start": map[string]any{"line": 11, "character": 5}, "end": map[string]any{"line": 11, "character": 8}},
+				}})
+			case "textDocument/implementation":
+				write(map[string]any{"jsonrpc": "2.0", "id": msg.ID, "result": nil})
+			case "":
+				// the answer to workspace/configuration
+				if string(*msg.ID) != "99" || string(msg.Result) != "[null,null]" {
+					t.Errorf("unexpected configuration answer %s to %s", msg.Result, *msg.ID)
+				}
+			case "exit":
+				return
+			default:
+				if msg.ID != nil {
+					write(map[string]any{"jsonrpc": "2.0", "id": msg.ID, "error": map[string]any{"code": -32601, "message": "unsupported"}})
+				}
+			}
+		}
+	}()
+}
+
+func itoa(n int) string {
+	b, _ := json.Marshal(n)
+	return string(b)
+}
+
+func TestClient(t *testing.T) {
+	const root = "/src/app"
+	clientIn, serverIn := io.Pipe()
+	serverOut, clientOut := io.Pipe()
+	fakeServer(t, clientIn, clientOut, root)
+
+	c := newClient(serverOut, serverIn, root)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.initialize(ctx); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+	if err := c.Open("cmd/main.go", "go", "package main"); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	locations, err := c.Definition(ctx, "cmd/main.go", Position{Line: 2, Character: 4})
+	if err != nil {
+		t.Fatalf("Definition failed: %v", err)
+	}
+	if len(locations) != 1 || locations[0].Range.Start.Line != 11 {
+		t.Fatalf("unexpected definition %+v", locations)
+	}
+	if path, ok := c.RelPath(locations[0].URI); !ok || path != "pkg/store.go" {
+		t.Errorf("RelPath() = %q, %v", path, ok)
+	}
+
+	if locations, err := c.Implementation(ctx, "cmd/main.go", Position{}); err != nil || locations != nil {
+		t.Errorf("Implementation() = %+v, %v; want none", locations, err)
+	}
+
+	if err := c.call(ctx, "textDocument/hover", nil, nil); err == nil {
+		t.Error("expected the server's error")
+	}
+}
+
+func TestRelPath(t *testing.T) {
+	c := &Client{root: "/src/app"}
+	for uri, want := range map[string]string{
+		"file:///src/app/a/b.go":        "a/b.go",
+		"file:///src/app/with%20sp.py":  "with sp.py",
+		"file:///usr/lib/go/src/fmt.go": "",
+		"file:///src/application/x.go":  "",
+		"untitled:Untitled-1":           "",
+	} {
+		got, ok := c.RelPath(uri)
+		if got != want || ok != (want != "") {
+			t.Errorf("RelPath(%q) = %q, %v; want %q", uri, got, ok, want)
+		}
+	}
+	if got := c.URI("a/b c.go"); got != "file:///src/app/a/b%20c.go" {
+		t.Errorf("URI() = %q", got)
+	}
+}
+
+func TestParseServers(t *testing.T) {
+	servers := ParseServers([]string{
+		"go=gopls",
+		"Python = pyright-langserver --stdio",
+		"cobol=cobol-ls",
+		"typescript=",
+		"nonsense",
+	})
+	want := Servers{
+		"go":     {"gopls"},
+		"python": {"pyright-langserver", "--stdio"},
+	}
+	if !reflect.DeepEqual(servers, want) {
+		t.Errorf("ParseServers() = %v, want %v", servers, want)
+	}
+}
+
+func TestCallColumn(t *testing.T) {
+	tests := []struct {
+		line, call string
+		want       int
+	}{
+		{"\tfmt.Println(x)", "fmt.Println", 5},
+		{"\tresult := s.cache.Get(key)", "s.cache.Get", 19},
+		{"    self.helper()", "self.helper", 9},
+		{"\tv := New[int]()", "New[int]", 6},
+		{"\tx := \"é\" + run()", "run", 12}, // é is one UTF-16 unit but two bytes
+		{"\treturn rerun() + run()", "run", 18},
+		{"\tnothing()", "missing", -1},
+	}
+	for _, tt := range tests {
+		if got := callColumn(tt.line, tt.call); got != tt.want {
+			t.Errorf("callColumn(%q, %q) = %d, want %d", tt.line, tt.call, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Failure counters operators can alert on. The repo label holds the
+// repository ID, or is empty for requests not scoped to a repository.
+var (
+	IndexRunsFailed = NewCounterVec(
+		"neograph_index_runs_failed_total",
+		"Index runs that failed, by repository and stage (clone, index, write).",
+		"repo", "stage",
+	)
+	WikiGenerationsFailed = NewCounterVec(
+		"neograph_wiki_generations_failed_total",
+		"Wiki generations that failed, by repository.",
+		"repo",
+	)
+	TEIErrors = NewCounterVec(
+		"neograph_tei_errors_total",
+		"Failed requests to the text embeddings service, by repository.",
+		"repo",
+	)
+	AgentErrors = NewCounterVec(
+		"neograph_agent_errors_total",
+		"Failed requests to the agent service, by repository.",
+		"repo",
+	)
+	RateLimited = NewCounterVec(
+		"neograph_rate_limited_total",
+		"Requests refused for exceeding a rate limit, by limit (search, graph).",
+		"limit",
+	)
+)
+
+var (
+	registryMu sync.Mutex
+	registry   []*CounterVec
+)
+
+// CounterVec is a monotonically increasing counter partitioned by labels
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounterVec creates a counter and registers it for exposition
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]*series),
+	}
+
+	registryMu.Lock()
+	registry = append(registry, c)
+	registryMu.Unlock()
+
+	return c
+}
+
+// Inc adds one to the series with the given label values
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v to the series with the given label values. Missing values are
+// treated as empty and extra values are ignored.
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	values := make([]string, len(c.labels))
+	copy(values, labelValues)
+	key := strings.Join(values, "\xff")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.values[key]
+	if !ok {
+		s = &series{labelValues: values}
+		c.values[key] = s
+	}
+	s.value += v
+}
+
+// Value returns the current value of the series with the given label values
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	values := make([]string, len(c.labels))
+	copy(values, labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if s, ok := c.values[strings.Join(values, "\xff")]; ok {
+		return s.value
+	}
+	return 0
+}
+
+// writeText writes the counter in the Prometheus text exposition format
+func (c *CounterVec) writeText(w io.Writer) error {
+	c.mu.Lock()
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP %s %s\n", c.name, c.help)
+	fmt.Fprintf(&b, "# TYPE %s counter\n", c.name)
+	for _, key := range keys {
+		s := c.values[key]
+		b.WriteString(c.name)
+		if len(c.labels) > 0 {
+			b.WriteByte('{')
+			for i, label := range c.labels {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				fmt.Fprintf(&b, "%s=\"%s\"", label, escapeLabelValue(s.labelValues[i]))
+			}
+			b.WriteByte('}')
+		}
+		fmt.Fprintf(&b, " %g\n", s.value)
+	}
+	c.mu.Unlock()
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteText writes all registered metrics in the Prometheus text format
+func WriteText(w io.Writer) error {
+	registryMu.Lock()
+	counters := make([]*CounterVec, len(registry))
+	copy(counters, registry)
+	registryMu.Unlock()
+
+	for _, c := range counters {
+		if err := c.writeText(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ContentType is the content type of the text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+func escapeLabelValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	return strings.ReplaceAll(v, "\n", `\n`)
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCounterVec(t *testing.T) {
+	c := &CounterVec{
+		name:   "test_failures_total",
+		help:   "Test failures.",
+		labels: []string{"repo", "stage"},
+		values: make(map[string]*series),
+	}
+
+	c.Inc("repo-b", "clone")
+	c.Inc("repo-a", "write")
+	c.Add(2, "repo-a", "write")
+	c.Inc(`we"ird\`)
+
+	if got := c.Value("repo-a", "write"); got != 3 {
+		t.Errorf("Value(repo-a, write) = %v, want 3", got)
+	}
+	if got := c.Value("repo-c", "clone"); got != 0 {
+		t.Errorf("Value(repo-c, clone) = %v, want 0", got)
+	}
+
+	var b strings.Builder
+	if err := c.writeText(&b); err != nil {
+		t.Fatalf("writeText failed: %v", err)
+	}
+
+	expected := `# HELP test_failures_total Test failures.
+# TYPE test_failures_total counter
+test_failures_total{repo="repo-a",stage="write"} 3
+test_failures_total{repo="repo-b",stage="clone"} 1
+test_failures_total{repo="we\"ird\\",stage=""} 1
+`
+	if b.String() != expected {
+		t.Errorf("exposition:\n%s\nwant:\n%s", b.String(), expected)
+	}
+}
+
+func TestWriteTextIncludesRegistered(t *testing.T) {
+	IndexRunsFailed.Inc("repo-1", "clone")
+
+	var b strings.Builder
+	if err := WriteText(&b); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+
+	for _, name := range []string{
+		"neograph_index_runs_failed_total",
+		"neograph_wiki_generations_failed_total",
+		"neograph_tei_errors_total",
+		"neograph_agent_errors_total",
+	} {
+		if !strings.Contains(b.String(), "# TYPE "+name+" counter") {
+			t.Errorf("missing %s in exposition", name)
+		}
+	}
+	if !strings.Contains(b.String(), `neograph_index_runs_failed_total{repo="repo-1",stage="clone"} 1`) {
+		t.Errorf("missing index failure series:\n%s", b.String())
+	}
+}
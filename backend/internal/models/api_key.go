@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// APIKey is a key provisioned through the admin API. Only a hash of the key
+// is stored; the key itself is returned once, when it is created.
+type APIKey struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Scope     string    `json:"scope"`  // read, write or admin
+	Prefix    string    `json:"prefix"` // leading characters, to recognize the key
+	CreatedAt time.Time `json:"createdAt"`
+
+	// OrgID scopes the key to an organization; empty for keys of the whole
+	// instance
+	OrgID string `json:"orgId,omitempty"`
+}
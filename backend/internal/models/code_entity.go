@@ -21,24 +21,78 @@ type CodeEntity struct {
 	FileID    string         `json:"fileId"`
 	RepoID    string         `json:"repoId"`
 	Content   string         `json:"content,omitempty"`
+	Layer     string         `json:"layer,omitempty"`
+	ClassName string         `json:"className,omitempty"` // enclosing class or receiver type of a method
+	// cyclomatic complexity of a function or method: 1 plus its branch points
+	Complexity int `json:"complexity,omitempty"`
 
 	// For embeddings
 	NLDescription string    `json:"nlDescription,omitempty"`
 	Embedding     []float32 `json:"embedding,omitempty"`
+	// of a long docstring alone, for documentation search
+	DocEmbedding []float32 `json:"docEmbedding,omitempty"`
 
 	// Relationships (populated on query)
-	Calls   []string `json:"calls,omitempty"`
-	Imports []string `json:"imports,omitempty"`
+	Calls     []string   `json:"calls,omitempty"`
+	CallSites []CallSite `json:"callSites,omitempty"` // every call expression, in source order
+	Imports   []string   `json:"imports,omitempty"`
+	// uses of names that may be package-level variables, in source order
+	Accesses []VariableAccess `json:"accesses,omitempty"`
+	// types a language server found implementing this class or interface
+	Implementations []SourceLocation `json:"implementations,omitempty"`
+}
+
+// CallSite is a single call expression inside an entity
+type CallSite struct {
+	Name string `json:"name"`
+	Line int    `json:"line"`
+	// where a language server found the callee defined; nil when none ran
+	// or it could not tell
+	Definition *SourceLocation `json:"definition,omitempty"`
+}
+
+// SourceLocation is a line of a file in the repository. An empty FilePath
+// is a location outside the repository, such as a standard library.
+type SourceLocation struct {
+	FilePath string `json:"filePath,omitempty"`
+	Line     int    `json:"line,omitempty"`
+}
+
+// VariableAccess is a use of a name inside an entity that is not declared
+// locally, so it may refer to a package-level variable
+type VariableAccess struct {
+	Name  string `json:"name"` // as written, possibly qualified, e.g. config.Debug
+	Line  int    `json:"line"`
+	Write bool   `json:"write,omitempty"` // assigned or mutated rather than read
+}
+
+// VariableRelation is a READS or WRITES edge from an entity to a variable
+type VariableRelation struct {
+	EntityID   string `json:"entityId"`
+	VariableID string `json:"variableId"`
+	Write      bool   `json:"write"`
+	Lines      []int  `json:"lines"`
+	Count      int    `json:"count"`
 }
 
 type CallRelation struct {
 	CallerID string `json:"callerId"`
 	CalleeID string `json:"calleeId"`
-	Line     int    `json:"line"`
+	Line     int    `json:"line"`  // first call site
+	Lines    []int  `json:"lines"` // every call site
+	Count    int    `json:"count"`
+}
+
+// ImplementsRelation is an IMPLEMENTS edge from a class to an interface or
+// base class it implements
+type ImplementsRelation struct {
+	TypeID      string `json:"typeId"`
+	InterfaceID string `json:"interfaceId"`
 }
 
 type ImportRelation struct {
 	FileID     string `json:"fileId"`
 	ImportPath string `json:"importPath"`
+	Symbol     string `json:"symbol,omitempty"` // imported name for from/named imports
 	Alias      string `json:"alias,omitempty"`
 }
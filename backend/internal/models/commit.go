@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Commit is one commit of a repository's history
+type Commit struct {
+	Hash        string         `json:"hash"`
+	AuthorName  string         `json:"authorName"`
+	AuthorEmail string         `json:"authorEmail"`
+	Time        time.Time      `json:"time"`
+	Message     string         `json:"message"` // subject line
+	Files       []CommitChange `json:"files"`
+}
+
+// CommitChange is a file a commit modified, with its line counts; binary
+// files have none
+type CommitChange struct {
+	Path      string `json:"path"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Conversation is a chat with an agent about a repository, kept so it can
+// be read again and continued
+type Conversation struct {
+	ID           string    `json:"id"`
+	RepoID       string    `json:"repoId"`
+	Title        string    `json:"title"` // the first message, shortened
+	AgentType    string    `json:"agentType"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+	MessageCount int       `json:"messageCount"`
+	// only when a single conversation is read
+	Messages []ConversationMessage `json:"messages,omitempty"`
+}
+
+// ConversationMessage is a message of a conversation: the user's, or the
+// agent's answer with the tools it ran to write it
+type ConversationMessage struct {
+	ID        string          `json:"id"`
+	Role      string          `json:"role"` // user or assistant
+	Content   string          `json:"content"`
+	ToolCalls json.RawMessage `json:"toolCalls,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// conversationTitleLength bounds the titles of conversations, in runes
+const conversationTitleLength = 80
+
+// ConversationTitle names a conversation after its first message: the first
+// line, cut at a word to at most 80 characters
+func ConversationTitle(message string) string {
+	title := strings.TrimSpace(message)
+	if i := strings.IndexByte(title, '\n'); i >= 0 {
+		title = strings.TrimSpace(title[:i])
+	}
+	if utf8.RuneCountInString(title) <= conversationTitleLength {
+		return title
+	}
+	title = string([]rune(title)[:conversationTitleLength-1])
+	if i := strings.LastIndexByte(title, ' '); i > conversationTitleLength/2 {
+		title = title[:i]
+	}
+	return strings.TrimSpace(title) + "…"
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestConversationTitle tests naming conversations after their first message
+func TestConversationTitle(t *testing.T) {
+	for message, want := range map[string]string{
+		"  How is auth handled?  ":               "How is auth handled?",
+		"Explain this error:\npanic: nil map":    "Explain this error:",
+		"":                                       "",
+		strings.Repeat("word ", 10) + "end here": strings.Repeat("word ", 10) + "end here",
+	} {
+		if got := ConversationTitle(message); got != want {
+			t.Errorf("ConversationTitle(%q) = %q, want %q", message, got, want)
+		}
+	}
+
+	long := ConversationTitle(strings.Repeat("lookup ", 30))
+	if utf8.RuneCountInString(long) > conversationTitleLength {
+		t.Errorf("expected at most %d characters, got %q", conversationTitleLength, long)
+	}
+	if !strings.HasSuffix(long, "lookup…") {
+		t.Errorf("expected the title cut after a word, got %q", long)
+	}
+
+	unbroken := ConversationTitle(strings.Repeat("é", 200))
+	if utf8.RuneCountInString(unbroken) != conversationTitleLength {
+		t.Errorf("expected a word longer than a title cut at %d characters, got %q", conversationTitleLength, unbroken)
+	}
+}
@@ -7,6 +7,10 @@ type File struct {
 	Language string `json:"language"`
 	Hash     string `json:"hash"`
 	Size     int64  `json:"size"`
+	Lines    int    `json:"lines,omitempty"`
+	Layer    string `json:"layer,omitempty"`
+
+	Imports []ImportRelation `json:"imports,omitempty"`
 }
 
 // Language detection by extension
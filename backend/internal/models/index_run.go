@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// IndexRun records one indexing of a repository at a specific commit
+type IndexRun struct {
+	ID            string    `json:"id"`
+	RepoID        string    `json:"repoId"`
+	Commit        string    `json:"commit,omitempty"`
+	Status        string    `json:"status"` // running, ready, error
+	StartedAt     time.Time `json:"startedAt"`
+	FinishedAt    time.Time `json:"finishedAt,omitempty"`
+	FilesCount    int       `json:"filesCount"`
+	EntitiesCount int       `json:"entitiesCount"`
+	Error         string    `json:"error,omitempty"`
+	Artifact      bool      `json:"artifact"` // an SBOM/graph export is stored for the run
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// IndexSettings narrow what is indexed of a repository. The zero value
+// indexes every supported file and embeds its entities.
+type IndexSettings struct {
+	// IgnoreGlobs are skipped files and directories, such as *_test.go,
+	// docs/** or internal/gen. Globs without a slash match names at any
+	// depth, others paths from the root; ** matches any directories.
+	IgnoreGlobs []string `json:"ignoreGlobs"`
+	// Languages are those indexed, such as go or typescript; all when empty
+	Languages []string `json:"languages"`
+	// MaxFileSize skips larger files, in bytes; 0 for any size
+	MaxFileSize int64 `json:"maxFileSize"`
+	// SkipEmbeddings indexes without computing embeddings, leaving the
+	// repository to keyword search only
+	SkipEmbeddings bool `json:"skipEmbeddings"`
+}
+
+// Validate reports the first glob or language that cannot be used
+func (s IndexSettings) Validate() error {
+	for _, glob := range s.IgnoreGlobs {
+		if strings.TrimSpace(glob) == "" {
+			return fmt.Errorf("ignore globs must not be empty")
+		}
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid ignore glob %q: %w", glob, err)
+		}
+	}
+	for _, lang := range s.Languages {
+		if !slices.Contains(Languages(), lang) {
+			return fmt.Errorf("unknown language %q, expected one of %s", lang, strings.Join(Languages(), ", "))
+		}
+	}
+	if s.MaxFileSize < 0 {
+		return fmt.Errorf("maxFileSize must not be negative")
+	}
+	return nil
+}
+
+// Ignores reports whether a file or directory, by its slash-separated path
+// from the repository root, matches an ignore glob
+func (s IndexSettings) Ignores(relPath string) bool {
+	segments := strings.Split(strings.Trim(relPath, "/"), "/")
+	for _, glob := range s.IgnoreGlobs {
+		glob = strings.Trim(glob, "/")
+		if !strings.Contains(glob, "/") {
+			// Matches a name at any depth
+			glob = "**/" + glob
+		}
+		if matchSegments(strings.Split(glob, "/"), segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// IndexesLanguage reports whether files of a language are indexed
+func (s IndexSettings) IndexesLanguage(lang string) bool {
+	return len(s.Languages) == 0 || slices.Contains(s.Languages, lang)
+}
+
+// matchSegments matches path segments against glob segments, ** matching
+// any number of segments
+func matchSegments(glob, segments []string) bool {
+	if len(glob) == 0 {
+		return len(segments) == 0
+	}
+	if glob[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(glob[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	ok, _ := path.Match(glob[0], segments[0])
+	return ok && matchSegments(glob[1:], segments[1:])
+}
+
+// Languages returns the languages files are detected as, sorted
+func Languages() []string {
+	var langs []string
+	for _, lang := range LanguageByExtension {
+		if !slices.Contains(langs, lang) {
+			langs = append(langs, lang)
+		}
+	}
+	slices.Sort(langs)
+	return langs
+}
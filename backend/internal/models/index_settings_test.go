@@ -0,0 +1,61 @@
+package models
+
+import "testing"
+
+// TestIndexSettingsIgnores tests matching paths against ignore globs
+func TestIndexSettingsIgnores(t *testing.T) {
+	settings := IndexSettings{IgnoreGlobs: []string{"*_test.go", "docs/**", "/internal/gen/", "web/**/*.js"}}
+
+	for path, want := range map[string]bool{
+		"main.go":                  false,
+		"main_test.go":             true,
+		"pkg/store/store_test.go":  true,
+		"docs":                     true,
+		"docs/api/index.ts":        true,
+		"pkg/docs/index.ts":        false,
+		"internal/gen":             true,
+		"internal/gen/models.go":   false, // its directory is skipped instead
+		"internal/generated.go":    false,
+		"web/app.js":               true,
+		"web/static/vendor/lib.js": true,
+		"web/app.ts":               false,
+	} {
+		if got := settings.Ignores(path); got != want {
+			t.Errorf("Ignores(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	if (IndexSettings{}).Ignores("main.go") {
+		t.Error("expected no globs to ignore nothing")
+	}
+}
+
+// TestIndexSettingsIndexesLanguage tests the language allowlist
+func TestIndexSettingsIndexesLanguage(t *testing.T) {
+	if !(IndexSettings{}).IndexesLanguage("go") {
+		t.Error("expected every language to be indexed without an allowlist")
+	}
+	settings := IndexSettings{Languages: []string{"go", "python"}}
+	if !settings.IndexesLanguage("python") || settings.IndexesLanguage("java") {
+		t.Errorf("unexpected languages indexed by %v", settings.Languages)
+	}
+}
+
+// TestIndexSettingsValidate tests rejecting unusable settings
+func TestIndexSettingsValidate(t *testing.T) {
+	valid := IndexSettings{IgnoreGlobs: []string{"vendor/**"}, Languages: []string{"typescript"}, MaxFileSize: 1 << 20}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for _, invalid := range []IndexSettings{
+		{IgnoreGlobs: []string{"[a-"}},
+		{IgnoreGlobs: []string{" "}},
+		{Languages: []string{"cobol"}},
+		{MaxFileSize: -1},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("expected an error for %+v", invalid)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Organization is a tenant: a team whose projects, repositories and API
+// keys are kept apart from other organizations'. Its ID is a slug chosen
+// when it is created, which OIDC tokens name it by.
+type Organization struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Project groups repositories of an organization
+type Project struct {
+	ID        string    `json:"id"`
+	OrgID     string    `json:"orgId"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Report is a named analysis request saved on a repository so it can be
+// re-run and its results compared over time
+type Report struct {
+	ID        string            `json:"id"`
+	RepoID    string            `json:"repoId"`
+	Name      string            `json:"name"`
+	Analysis  string            `json:"analysis"` // e.g. layers, coverage-gaps
+	Params    map[string]string `json:"params"`
+	CreatedAt time.Time         `json:"createdAt"`
+	LastRunAt time.Time         `json:"lastRunAt,omitempty"`
+}
+
+// ReportRun is the result of one run of a report
+type ReportRun struct {
+	ID       string    `json:"id"`
+	ReportID string    `json:"reportId"`
+	Commit   string    `json:"commit,omitempty"` // indexed commit the run saw
+	RanAt    time.Time `json:"ranAt"`
+	// keys of the items the analysis reported, used to compare runs
+	Items  []string        `json:"items"`
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+// ReportComparison lists the items that appeared and disappeared between
+// two runs of a report
+type ReportComparison struct {
+	From      ReportRun `json:"from"`
+	To        ReportRun `json:"to"`
+	Added     []string  `json:"added"`
+	Removed   []string  `json:"removed"`
+	Unchanged int       `json:"unchanged"`
+}
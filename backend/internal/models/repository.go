@@ -11,11 +11,58 @@ type Repository struct {
 	Status         string    `json:"status"` // pending, indexing, ready, error
 	FilesCount     int       `json:"filesCount"`
 	FunctionsCount int       `json:"functionsCount"`
+	Commit         string    `json:"commit,omitempty"` // commit of the last successful index
+
+	// The project a repository belongs to and its organization; empty for
+	// repositories registered before organizations, which only callers
+	// not scoped to an organization see
+	ProjectID string `json:"projectId,omitempty"`
+	OrgID     string `json:"orgId,omitempty"`
+
+	// WikiAutoRefresh regenerates the wiki after scheduled reindexes
+	// instead of only marking it stale
+	WikiAutoRefresh bool `json:"wikiAutoRefresh"`
 }
 
 type CreateRepositoryInput struct {
 	URL           string `json:"url" validate:"required,url"`
 	DefaultBranch string `json:"defaultBranch"`
+	ProjectID     string `json:"projectId,omitempty"`
+}
+
+// BulkRepositoryInput registers many repositories at once: the URLs, or
+// those of a GitHub organization or user, skipping its forks and archived
+// repositories unless included
+type BulkRepositoryInput struct {
+	URLs            []string `json:"urls,omitempty"`
+	Org             string   `json:"org,omitempty"`
+	IncludeForks    bool     `json:"includeForks,omitempty"`
+	IncludeArchived bool     `json:"includeArchived,omitempty"`
+	DefaultBranch   string   `json:"defaultBranch,omitempty"` // of URLs; org repositories use their own
+	ProjectID       string   `json:"projectId,omitempty"`
+}
+
+// BulkRepositoryResult is the outcome of registering one repository in bulk
+type BulkRepositoryResult struct {
+	URL        string      `json:"url"`
+	Status     string      `json:"status"` // created, exists or error
+	Repository *Repository `json:"repository,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// RepositorySummary is a short generated description of a repository
+type RepositorySummary struct {
+	Summary     string    `json:"summary"`
+	Source      string    `json:"source"` // agent or fallback
+	Commit      string    `json:"commit,omitempty"`
+	GeneratedAt time.Time `json:"generatedAt"`
+}
+
+// RepositorySettings are the options of a repository; indexing options
+// apply from its next index
+type RepositorySettings struct {
+	WikiAutoRefresh bool `json:"wikiAutoRefresh"`
+	IndexSettings
 }
 
 type IndexResult struct {
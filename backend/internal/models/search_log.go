@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// SearchLog records one search, logged when search analytics are enabled
+type SearchLog struct {
+	ID        string    `json:"id"`
+	Query     string    `json:"query"`
+	Mode      string    `json:"mode"`             // semantic, keyword or hybrid
+	Scope     string    `json:"scope"`            // code, docs or all
+	RepoID    string    `json:"repoId,omitempty"` // empty for global search
+	Results   int       `json:"results"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// SearchQueryStats aggregates the searches for one query, compared
+// case-insensitively
+type SearchQueryStats struct {
+	Query          string    `json:"query"`
+	Searches       int       `json:"searches"`
+	ZeroResults    int       `json:"zeroResults"` // searches that found nothing
+	Selected       int       `json:"selected"`    // searches with a result opened
+	LastSearchedAt time.Time `json:"lastSearchedAt"`
+}
+
+// SelectedResultStats counts how often a search result was opened
+type SelectedResultStats struct {
+	ResultID    string  `json:"resultId"`
+	Selections  int     `json:"selections"`
+	AverageRank float64 `json:"averageRank"` // 1 is the first result
+}
+
+// SearchAnalytics summarizes the searches logged over a period
+type SearchAnalytics struct {
+	Since             time.Time             `json:"since"`
+	Searches          int                   `json:"searches"`
+	ZeroResults       int                   `json:"zeroResults"`
+	Selected          int                   `json:"selected"`
+	TopQueries        []SearchQueryStats    `json:"topQueries"`
+	ZeroResultQueries []SearchQueryStats    `json:"zeroResultQueries"`
+	TopResults        []SelectedResultStats `json:"topResults"`
+}
@@ -13,6 +13,15 @@ type WikiPage struct {
 	ParentSlug  string    `json:"parentSlug"` // For nested navigation (empty = root)
 	Diagrams    []Diagram `json:"diagrams"`
 	GeneratedAt time.Time `json:"generatedAt"`
+	Sources     []string  `json:"sources,omitempty"`    // indexed files the content mentions
+	StaleFiles  []string  `json:"staleFiles,omitempty"` // sources changed by a reindex since generation
+}
+
+// StalePage is a wiki page whose sources changed since it was generated
+type StalePage struct {
+	Slug       string   `json:"slug"`
+	Title      string   `json:"title"`
+	StaleFiles []string `json:"staleFiles"`
 }
 
 // Diagram represents a Mermaid diagram
@@ -55,4 +64,5 @@ type WikiStatus struct {
 	CurrentPage  string `json:"currentPage,omitempty"`
 	TotalPages   int    `json:"totalPages"`
 	ErrorMessage string `json:"errorMessage,omitempty"`
+	Stale        bool   `json:"stale,omitempty"` // code was reindexed since generation
 }
@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// EventWikiStale is sent when a reindex changed files wiki pages mention
+const EventWikiStale = "wiki.stale"
+
+// WikiStaleEvent lists the wiki pages of a repository to regenerate
+type WikiStaleEvent struct {
+	Event      string             `json:"event"`
+	Repository WebhookRepository  `json:"repository"`
+	Commit     string             `json:"commit,omitempty"` // indexed commit the pages are stale against
+	Pages      []models.StalePage `json:"pages"`
+	SentAt     time.Time          `json:"sentAt"`
+}
+
+// WebhookRepository identifies the repository an event is about
+type WebhookRepository struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Webhook POSTs events as JSON to a configured URL
+type Webhook struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{
+		url: url,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Enabled reports whether a URL is configured to send events to
+func (w *Webhook) Enabled() bool {
+	return w.url != ""
+}
+
+// WikiStale notifies the webhook of a repository's stale wiki pages
+func (w *Webhook) WikiStale(ctx context.Context, repo *models.Repository, pages []models.StalePage) error {
+	return w.send(ctx, WikiStaleEvent{
+		Event:      EventWikiStale,
+		Repository: WebhookRepository{ID: repo.ID, Name: repo.Name, URL: repo.URL},
+		Commit:     repo.Commit,
+		Pages:      pages,
+		SentAt:     time.Now().UTC(),
+	})
+}
+
+func (w *Webhook) send(ctx context.Context, event any) error {
+	if !w.Enabled() {
+		return nil
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook error (status %d): %s", resp.StatusCode, string(msg))
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+func TestWikiStale_Success(t *testing.T) {
+	var got WikiStaleEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected Content-Type application/json, got %s", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode event: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	repo := &models.Repository{ID: "repo-1", Name: "neograph", URL: "https://example.com/neograph.git", Commit: "abc123"}
+	pages := []models.StalePage{{Slug: "api", Title: "API", StaleFiles: []string{"internal/api/handlers.go"}}}
+
+	if err := NewWebhook(server.URL).WikiStale(context.Background(), repo, pages); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Event != EventWikiStale {
+		t.Errorf("expected event %s, got %s", EventWikiStale, got.Event)
+	}
+	if got.Repository.ID != "repo-1" || got.Commit != "abc123" {
+		t.Errorf("unexpected repository %+v at commit %s", got.Repository, got.Commit)
+	}
+	if len(got.Pages) != 1 || got.Pages[0].StaleFiles[0] != "internal/api/handlers.go" {
+		t.Errorf("unexpected pages %+v", got.Pages)
+	}
+}
+
+func TestWikiStale_ServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := NewWebhook(server.URL).WikiStale(context.Background(), &models.Repository{ID: "repo-1"}, nil)
+	if err == nil {
+		t.Fatal("expected error for status 500")
+	}
+}
+
+func TestWikiStale_Disabled(t *testing.T) {
+	webhook := NewWebhook("")
+	if webhook.Enabled() {
+		t.Error("expected webhook without URL to be disabled")
+	}
+	if err := webhook.WikiStale(context.Background(), &models.Repository{ID: "repo-1"}, nil); err != nil {
+		t.Errorf("expected no error when disabled, got %v", err)
+	}
+}
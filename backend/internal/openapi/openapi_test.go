@@ -0,0 +1,98 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type node struct {
+	ID       string          `json:"id"`
+	Name     string          `json:"name,omitempty"`
+	Children []*node         `json:"children"`
+	Props    map[string]any  `json:"props,omitempty"`
+	Raw      json.RawMessage `json:"raw,omitempty"`
+	At       time.Time       `json:"at"`
+	Count    int64           `json:"count"`
+	internal string
+	Skipped  string `json:"-"`
+}
+
+type base struct {
+	Kind string `json:"kind"`
+}
+
+type wrapper struct {
+	base
+	Node *node `json:"node"`
+}
+
+func TestSchemaOf_Struct(t *testing.T) {
+	b := NewBuilder(Info{Title: "test", Version: "1"})
+	schema := b.SchemaOf(reflect.TypeFor[wrapper]())
+	if schema.Ref != "#/components/schemas/wrapper" {
+		t.Fatalf("expected a reference to wrapper, got %+v", schema)
+	}
+
+	w := b.Document().Components.Schemas["wrapper"]
+	if w.Properties["kind"] == nil || w.Properties["node"].Ref != "#/components/schemas/node" {
+		t.Errorf("expected the embedded kind and a node reference, got %+v", w.Properties)
+	}
+
+	n := b.Document().Components.Schemas["node"]
+	if got := n.Properties["children"]; got.Type != "array" || got.Items.Ref != "#/components/schemas/node" {
+		t.Errorf("expected children to refer to node recursively, got %+v", got)
+	}
+	if got := n.Properties["at"]; got.Type != "string" || got.Format != "date-time" {
+		t.Errorf("expected a date-time, got %+v", got)
+	}
+	if got := n.Properties["count"]; got.Type != "integer" || got.Format != "int64" {
+		t.Errorf("expected an int64, got %+v", got)
+	}
+	if got := n.Properties["props"]; got.Type != "object" || got.AdditionalProperties == nil {
+		t.Errorf("expected a map, got %+v", got)
+	}
+	if _, ok := n.Properties["Skipped"]; ok {
+		t.Error("expected json:\"-\" fields to be skipped")
+	}
+	if _, ok := n.Properties["internal"]; ok {
+		t.Error("expected unexported fields to be skipped")
+	}
+	if want := []string{"at", "children", "count", "id"}; !reflect.DeepEqual(n.Required, want) {
+		t.Errorf("expected required %v, got %v", want, n.Required)
+	}
+}
+
+func TestBuilder_Add(t *testing.T) {
+	b := NewBuilder(Info{Title: "test", Version: "1"})
+	b.Add("GET", "/api/repositories/:id/nodes/:nodeId", Operation{
+		Summary:    "Node",
+		Parameters: []Parameter{{Name: "depth", In: "query", Schema: &Schema{Type: "integer"}}},
+	})
+
+	op := b.Document().Paths["/api/repositories/{id}/nodes/{nodeId}"]["get"]
+	if op == nil {
+		t.Fatalf("expected the route's path to be converted, got %v", b.Document().Paths)
+	}
+	var names []string
+	for _, p := range op.Parameters {
+		names = append(names, p.In+":"+p.Name)
+	}
+	if want := []string{"path:nodeId", "path:id", "query:depth"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected parameters %v, got %v", want, names)
+	}
+	if _, ok := op.Responses["200"]; !ok {
+		t.Error("expected a default 200 response")
+	}
+}
+
+func TestBuilder_Security(t *testing.T) {
+	b := NewBuilder(Info{Title: "test", Version: "1"})
+	b.AddSecurityScheme("apiKey", SecurityScheme{Type: "apiKey", In: "header", Name: "X-API-Key"}, true)
+	b.AddSecurityScheme("bearer", SecurityScheme{Type: "http", Scheme: "bearer"}, true)
+
+	if got := len(b.Document().Security); got != 3 {
+		t.Errorf("expected anonymous, key and bearer requirements, got %d", got)
+	}
+}
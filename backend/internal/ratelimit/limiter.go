@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// idleAfter is how long a client's bucket is kept after it refilled; a
+// client returning later starts with a full bucket, as it would have anyway
+const idleAfter = time.Minute
+
+// Limiter rate limits clients with a token bucket each: a bucket holds up to
+// burst tokens and refills at rate per second, and every request takes one.
+// Clients may so send bursts while their sustained rate stays bounded.
+type Limiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	filled time.Time // when tokens was last brought up to date
+}
+
+// New creates a limiter allowing perMinute requests a minute per client, which
+// must be positive, in bursts of up to burst, which is at least 1
+func New(perMinute, burst int) *Limiter {
+	return &Limiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(max(burst, 1)),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Burst returns how many requests a client may send at once
+func (l *Limiter) Burst() int {
+	return int(l.burst)
+}
+
+// Allow takes a token from the client's bucket. When it is empty, the
+// request is refused and retryAfter says when the next token is available.
+// remaining is the tokens left to the client either way.
+func (l *Limiter) Allow(client string) (allowed bool, remaining int, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, filled: now}
+		l.buckets[client] = b
+	}
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.filled).Seconds()*l.rate)
+	b.filled = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, 0, wait
+	}
+	b.tokens--
+	return true, int(b.tokens), 0
+}
+
+// sweep drops the buckets of clients idle long enough for their buckets to
+// be full again, at most once every idleAfter
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < idleAfter {
+		return
+	}
+	l.swept = now
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.filled).Seconds()*l.rate >= l.burst && now.Sub(b.filled) > idleAfter {
+			delete(l.buckets, client)
+		}
+	}
+}
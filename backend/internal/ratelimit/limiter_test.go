@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter_Burst(t *testing.T) {
+	now := time.Now()
+	l := New(60, 3)
+	l.now = func() time.Time { return now }
+
+	for i := range 3 {
+		allowed, remaining, _ := l.Allow("a")
+		if !allowed || remaining != 2-i {
+			t.Fatalf("request %d: allowed %v, remaining %d", i, allowed, remaining)
+		}
+	}
+	allowed, _, retryAfter := l.Allow("a")
+	if allowed {
+		t.Fatal("expected the fourth request of a burst of 3 to be refused")
+	}
+	if retryAfter <= 0 || retryAfter > time.Second {
+		t.Errorf("expected to retry within a second, got %s", retryAfter)
+	}
+
+	// Other clients have buckets of their own
+	if allowed, _, _ := l.Allow("b"); !allowed {
+		t.Error("expected another client to be allowed")
+	}
+
+	// One token a second refills
+	now = now.Add(time.Second)
+	if allowed, _, _ := l.Allow("a"); !allowed {
+		t.Error("expected a request after a second to be allowed")
+	}
+	if allowed, _, _ := l.Allow("a"); allowed {
+		t.Error("expected only one token to have refilled")
+	}
+}
+
+func TestLimiter_RefillCapsAtBurst(t *testing.T) {
+	now := time.Now()
+	l := New(600, 2)
+	l.now = func() time.Time { return now }
+
+	l.Allow("a")
+	now = now.Add(time.Hour)
+	allowed := 0
+	for range 5 {
+		if ok, _, _ := l.Allow("a"); ok {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("expected a refilled bucket to hold the burst of 2, allowed %d", allowed)
+	}
+}
+
+func TestLimiter_SweepsIdleClients(t *testing.T) {
+	now := time.Now()
+	l := New(60, 5)
+	l.now = func() time.Time { return now }
+
+	l.Allow("a")
+	now = now.Add(2 * idleAfter)
+	l.Allow("b")
+	if _, ok := l.buckets["a"]; ok {
+		t.Error("expected the idle client's bucket to be dropped")
+	}
+	if _, ok := l.buckets["b"]; !ok {
+		t.Error("expected the active client's bucket to be kept")
+	}
+}
@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// Runner performs the work triggered by the scheduler
+type Runner interface {
+	ListRepositories(ctx context.Context) ([]*models.Repository, error)
+	Reindex(ctx context.Context, repo *models.Repository) error
+	RefreshWiki(ctx context.Context, repo *models.Repository) error
+	MarkWikiStale(ctx context.Context, repo *models.Repository) error
+}
+
+// Scheduler periodically reindexes every repository and then refreshes the
+// wikis of those that opted in, marking the others stale
+type Scheduler struct {
+	interval time.Duration
+	runner   Runner
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a scheduler running every interval
+func New(interval time.Duration, runner Runner) *Scheduler {
+	return &Scheduler{
+		interval: interval,
+		runner:   runner,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start runs the schedule in the background until Stop is called
+func (s *Scheduler) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithCancel(context.Background())
+				go func() {
+					select {
+					case <-s.stop:
+						cancel()
+					case <-ctx.Done():
+					}
+				}()
+				s.RunOnce(ctx)
+				cancel()
+			}
+		}
+	}()
+}
+
+// Stop cancels any run in progress and waits for the schedule to exit
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// RunOnce reindexes all repositories, then works through the wiki queue of
+// the ones that reindexed successfully. Wikis are regenerated one at a time
+// after all reindexing so generation never competes with indexing.
+func (s *Scheduler) RunOnce(ctx context.Context) {
+	repos, err := s.runner.ListRepositories(ctx)
+	if err != nil {
+		log.Printf("Scheduler: failed to list repositories: %v", err)
+		return
+	}
+
+	var reindexed []*models.Repository
+	for _, repo := range repos {
+		if ctx.Err() != nil {
+			return
+		}
+		if repo.Status == "indexing" {
+			continue
+		}
+		if err := s.runner.Reindex(ctx, repo); err != nil {
+			log.Printf("Scheduler: failed to reindex %s: %v", repo.Name, err)
+			continue
+		}
+		reindexed = append(reindexed, repo)
+	}
+
+	for _, repo := range reindexed {
+		if ctx.Err() != nil {
+			return
+		}
+		if !repo.WikiAutoRefresh {
+			if err := s.runner.MarkWikiStale(ctx, repo); err != nil {
+				log.Printf("Scheduler: failed to mark wiki stale for %s: %v", repo.Name, err)
+			}
+			continue
+		}
+		if err := s.runner.RefreshWiki(ctx, repo); err != nil {
+			log.Printf("Scheduler: failed to refresh wiki for %s: %v", repo.Name, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+type fakeRunner struct {
+	mu        sync.Mutex
+	repos     []*models.Repository
+	failIndex map[string]bool
+	calls     []string
+}
+
+func (f *fakeRunner) record(call string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+}
+
+func (f *fakeRunner) ListRepositories(ctx context.Context) ([]*models.Repository, error) {
+	return f.repos, nil
+}
+
+func (f *fakeRunner) Reindex(ctx context.Context, repo *models.Repository) error {
+	f.record("reindex " + repo.Name)
+	if f.failIndex[repo.Name] {
+		return errors.New("clone failed")
+	}
+	return nil
+}
+
+func (f *fakeRunner) RefreshWiki(ctx context.Context, repo *models.Repository) error {
+	f.record("wiki " + repo.Name)
+	return nil
+}
+
+func (f *fakeRunner) MarkWikiStale(ctx context.Context, repo *models.Repository) error {
+	f.record("stale " + repo.Name)
+	return nil
+}
+
+func TestRunOnce(t *testing.T) {
+	runner := &fakeRunner{
+		repos: []*models.Repository{
+			{Name: "api", Status: "ready", WikiAutoRefresh: true},
+			{Name: "web", Status: "ready"},
+			{Name: "busy", Status: "indexing", WikiAutoRefresh: true},
+			{Name: "broken", Status: "error", WikiAutoRefresh: true},
+		},
+		failIndex: map[string]bool{"broken": true},
+	}
+
+	New(time.Hour, runner).RunOnce(context.Background())
+
+	// Wikis are refreshed after all reindexing, and only for successful runs
+	expected := []string{
+		"reindex api",
+		"reindex web",
+		"reindex broken",
+		"wiki api",
+		"stale web",
+	}
+	if !reflect.DeepEqual(runner.calls, expected) {
+		t.Errorf("calls:\n got %v\nwant %v", runner.calls, expected)
+	}
+}
+
+func TestRunOnceCancelled(t *testing.T) {
+	runner := &fakeRunner{
+		repos: []*models.Repository{{Name: "api", Status: "ready"}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	New(time.Hour, runner).RunOnce(ctx)
+
+	if len(runner.calls) != 0 {
+		t.Errorf("expected no work after cancellation, got %v", runner.calls)
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	runner := &fakeRunner{
+		repos: []*models.Repository{{Name: "api", Status: "ready"}},
+	}
+
+	s := New(10*time.Millisecond, runner)
+	s.Start()
+	time.Sleep(50 * time.Millisecond)
+	s.Stop()
+
+	runner.mu.Lock()
+	defer runner.mu.Unlock()
+	if len(runner.calls) == 0 {
+		t.Error("expected at least one scheduled run")
+	}
+}
@@ -0,0 +1,196 @@
+package summary
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dpolishuk/neograph/backend/internal/db"
+)
+
+// maxReadmeBytes caps how much of a README is read
+const maxReadmeBytes = 16 * 1024
+
+// maxExcerptLen caps the README excerpt used for a summary
+const maxExcerptLen = 1200
+
+// readmeNames are the README file names looked for, in order
+var readmeNames = []string{"README.md", "README.markdown", "README.rst", "README.txt", "README", "readme.md"}
+
+var (
+	htmlTag  = regexp.MustCompile(`<[^>]+>`)
+	mdLink   = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdImage  = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	mdMarker = regexp.MustCompile("[*_`]+")
+)
+
+// Input is what a repository summary is generated from
+type Input struct {
+	RepoName string
+	Readme   string
+	Stats    *db.RepositoryStats
+}
+
+// ReadReadme returns the start of the repository's README, or an empty
+// string if it has none
+func ReadReadme(repoPath string) string {
+	for _, name := range readmeNames {
+		f, err := os.Open(filepath.Join(repoPath, name))
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(f, maxReadmeBytes))
+		f.Close()
+		if err == nil {
+			return string(data)
+		}
+	}
+	return ""
+}
+
+// ReadmeExcerpt extracts the descriptive prose at the top of a README,
+// skipping headings, badges, code blocks and HTML
+func ReadmeExcerpt(readme string) string {
+	var paragraphs []string
+	var current []string
+	inCode := false
+
+	flush := func() {
+		if len(current) > 0 {
+			paragraphs = append(paragraphs, strings.Join(current, " "))
+			current = nil
+		}
+	}
+
+	for _, line := range strings.Split(readme, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+			flush()
+			continue
+		}
+		if inCode {
+			continue
+		}
+
+		// Headings, rules, tables, lists and rst underlines end a paragraph
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "|") ||
+			strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ") ||
+			strings.Trim(trimmed, "=-*_") == "" {
+			flush()
+			continue
+		}
+
+		text := mdImage.ReplaceAllString(trimmed, "")
+		text = htmlTag.ReplaceAllString(text, "")
+		text = mdLink.ReplaceAllString(text, "$1")
+		text = mdMarker.ReplaceAllString(text, "")
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		current = append(current, text)
+	}
+	flush()
+
+	var b strings.Builder
+	for _, p := range paragraphs {
+		// Join leading paragraphs until there is enough prose
+		if b.Len() > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(p)
+		if b.Len() >= maxExcerptLen/2 {
+			break
+		}
+	}
+
+	excerpt := b.String()
+	if len(excerpt) > maxExcerptLen {
+		excerpt = excerpt[:maxExcerptLen]
+		if i := strings.LastIndex(excerpt, " "); i > 0 {
+			excerpt = excerpt[:i]
+		}
+		excerpt += "..."
+	}
+	return excerpt
+}
+
+// Prompt builds the request asking the agent for a two-paragraph summary
+func Prompt(in Input) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Write a two-paragraph summary of the %s repository for a repository card. ", in.RepoName)
+	b.WriteString("The first paragraph says what the project is and does; the second describes its ")
+	b.WriteString("structure: languages, size and where execution starts. Use plain prose, no headings or lists.\n\n")
+
+	if excerpt := ReadmeExcerpt(in.Readme); excerpt != "" {
+		fmt.Fprintf(&b, "README excerpt:\n%s\n\n", excerpt)
+	}
+	b.WriteString("Graph statistics:\n")
+	b.WriteString(statsLine(in.Stats))
+	b.WriteString("\n")
+	if points := entryPoints(in.Stats); points != "" {
+		fmt.Fprintf(&b, "Entry points: %s\n", points)
+	}
+	return b.String()
+}
+
+// Fallback builds a summary without the agent, from the README and stats
+func Fallback(in Input) string {
+	first := ReadmeExcerpt(in.Readme)
+	if first == "" {
+		first = fmt.Sprintf("%s has no README describing the project.", in.RepoName)
+	}
+
+	second := fmt.Sprintf("The indexed code of %s has %s", in.RepoName, statsLine(in.Stats))
+	if points := entryPoints(in.Stats); points != "" {
+		second += " Likely entry points are " + points + "."
+	}
+
+	return first + "\n\n" + second
+}
+
+// statsLine describes size and language mix in one sentence
+func statsLine(stats *db.RepositoryStats) string {
+	if stats == nil {
+		return "no indexed files."
+	}
+
+	line := fmt.Sprintf("%d files, %d functions, %d methods and %d classes", stats.Files, stats.Functions, stats.Methods, stats.Classes)
+
+	languages := make([]string, 0, len(stats.Languages))
+	for lang := range stats.Languages {
+		languages = append(languages, lang)
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		li, lj := stats.Languages[languages[i]], stats.Languages[languages[j]]
+		if li != lj {
+			return li > lj
+		}
+		return languages[i] < languages[j]
+	})
+	if len(languages) > 0 {
+		parts := make([]string, len(languages))
+		for i, lang := range languages {
+			parts[i] = fmt.Sprintf("%s (%d)", lang, stats.Languages[lang])
+		}
+		line += ", written in " + strings.Join(parts, ", ")
+	}
+	return line + "."
+}
+
+// entryPoints lists entry points as name (path)
+func entryPoints(stats *db.RepositoryStats) string {
+	if stats == nil || len(stats.EntryPoints) == 0 {
+		return ""
+	}
+	parts := make([]string, len(stats.EntryPoints))
+	for i, ep := range stats.EntryPoints {
+		parts[i] = fmt.Sprintf("%s (%s)", ep.Name, ep.FilePath)
+	}
+	return strings.Join(parts, ", ")
+}
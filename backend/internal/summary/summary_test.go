@@ -0,0 +1,98 @@
+package summary
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dpolishuk/neograph/backend/internal/db"
+)
+
+func TestReadmeExcerpt(t *testing.T) {
+	readme := "# Project\n\n" +
+		"[![Build](https://ci/badge.svg)](https://ci)\n\n" +
+		"Project is a **fast** graph indexer\nfor [source code](https://example.com).\n\n" +
+		"```sh\nmake install\n```\n\n" +
+		"## Usage\n\n" +
+		"- item\n"
+
+	got := ReadmeExcerpt(readme)
+	want := "Project is a fast graph indexer for source code."
+	if got != want {
+		t.Errorf("ReadmeExcerpt() = %q, want %q", got, want)
+	}
+}
+
+func TestReadmeExcerpt_Truncates(t *testing.T) {
+	readme := strings.Repeat("word ", maxExcerptLen)
+
+	got := ReadmeExcerpt(readme)
+	if len(got) > maxExcerptLen+3 {
+		t.Errorf("excerpt length = %d, want at most %d", len(got), maxExcerptLen+3)
+	}
+	if !strings.HasSuffix(got, "...") {
+		t.Errorf("truncated excerpt should end with ellipsis, got %q", got[len(got)-10:])
+	}
+}
+
+func TestReadReadme(t *testing.T) {
+	dir := t.TempDir()
+	if got := ReadReadme(dir); got != "" {
+		t.Errorf("ReadReadme() without README = %q, want empty", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := ReadReadme(dir); got != "hello" {
+		t.Errorf("ReadReadme() = %q, want %q", got, "hello")
+	}
+}
+
+func TestFallback(t *testing.T) {
+	in := Input{
+		RepoName: "demo",
+		Readme:   "Demo does things.",
+		Stats: &db.RepositoryStats{
+			Files:       3,
+			Functions:   5,
+			Languages:   map[string]int{"go": 2, "python": 1},
+			EntryPoints: []db.EntryPoint{{Name: "main", FilePath: "cmd/main.go"}},
+		},
+	}
+
+	got := Fallback(in)
+	paragraphs := strings.Split(got, "\n\n")
+	if len(paragraphs) != 2 {
+		t.Fatalf("Fallback() returned %d paragraphs, want 2: %q", len(paragraphs), got)
+	}
+	if paragraphs[0] != "Demo does things." {
+		t.Errorf("first paragraph = %q", paragraphs[0])
+	}
+	for _, want := range []string{"3 files", "5 functions", "go (2), python (1)", "main (cmd/main.go)"} {
+		if !strings.Contains(paragraphs[1], want) {
+			t.Errorf("second paragraph %q missing %q", paragraphs[1], want)
+		}
+	}
+}
+
+func TestFallback_NoReadme(t *testing.T) {
+	got := Fallback(Input{RepoName: "demo"})
+	if !strings.HasPrefix(got, "demo has no README") {
+		t.Errorf("Fallback() = %q", got)
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	got := Prompt(Input{
+		RepoName: "demo",
+		Readme:   "Demo does things.",
+		Stats:    &db.RepositoryStats{Files: 1},
+	})
+	for _, want := range []string{"two-paragraph summary of the demo repository", "Demo does things.", "1 files"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Prompt() missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package traces
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+// EntityStats is the runtime behavior observed for one entity
+type EntityStats struct {
+	EntityID string
+	Count    int64
+	Duration time.Duration
+}
+
+// closureSuffix matches what the Go runtime appends to the names of
+// closures and wrappers: .func1, .func1.2, .gowrap1, .deferwrap1
+var closureSuffix = regexp.MustCompile(`(\.(func|gowrap|deferwrap)\d+)+(\.\d+)*$`)
+
+// typeParams matches instantiated type parameters, as in Map[...]
+var typeParams = regexp.MustCompile(`\[[^\]]*\]`)
+
+// stripClosure names a closure after the function declaring it. Go closures
+// always keep a package-qualified name, so mod.func1 is left alone.
+func stripClosure(name string) string {
+	name = typeParams.ReplaceAllString(name, "")
+	if stripped := closureSuffix.ReplaceAllString(name, ""); strings.Contains(stripped, ".") {
+		return stripped
+	}
+	return name
+}
+
+// splitFrame splits a runtime function name into the entity name and its
+// qualifier: the class or receiver type for methods, the package or module
+// otherwise. It handles Go (github.com/acme/app/store.(*Store).Get), dotted
+// (app.services.UserService.get) and Java-style (com.acme.Svc::run) names.
+func splitFrame(name string) (qualifier, entity string) {
+	name = stripClosure(name)
+	name = strings.ReplaceAll(name, "::", ".")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	parts := strings.Split(name, ".")
+	entity = parts[len(parts)-1]
+	if len(parts) > 1 {
+		qualifier = strings.Trim(parts[len(parts)-2], "(*)")
+	}
+	return qualifier, entity
+}
+
+// Match maps observations onto entities. A file and line inside an entity
+// decide the match; otherwise the name has to identify a single entity, using
+// the class, receiver, package directory or module file the runtime reported
+// to tell apart entities sharing a name. Observations mapping to the same
+// entity, such as a function and its closures, are added up.
+func Match(observations []Observation, entities []models.CodeEntity) ([]EntityStats, []Observation) {
+	byName := make(map[string][]*models.CodeEntity)
+	for i := range entities {
+		entity := &entities[i]
+		if entity.ID != "" {
+			byName[entity.Name] = append(byName[entity.Name], entity)
+		}
+	}
+
+	stats := make(map[string]*EntityStats)
+	var order []string
+	var unmatched []Observation
+	for _, obs := range observations {
+		entity := matchLocation(obs, entities)
+		if entity == nil {
+			entity = matchName(obs, byName)
+		}
+		if entity == nil {
+			unmatched = append(unmatched, obs)
+			continue
+		}
+
+		s, ok := stats[entity.ID]
+		if !ok {
+			s = &EntityStats{EntityID: entity.ID}
+			stats[entity.ID] = s
+			order = append(order, entity.ID)
+		}
+		s.Count += obs.Count
+		s.Duration += obs.Duration
+	}
+
+	result := make([]EntityStats, 0, len(order))
+	for _, id := range order {
+		result = append(result, *stats[id])
+	}
+	return result, unmatched
+}
+
+// matchLocation returns the innermost entity of the observed file containing
+// the observed line
+func matchLocation(obs Observation, entities []models.CodeEntity) *models.CodeEntity {
+	if obs.File == "" || obs.Line <= 0 {
+		return nil
+	}
+
+	var best *models.CodeEntity
+	for i := range entities {
+		entity := &entities[i]
+		if entity.ID == "" || !sameFile(obs.File, entity.FilePath) {
+			continue
+		}
+		if obs.Line < entity.StartLine || obs.Line > entity.EndLine {
+			continue
+		}
+		if best == nil || entity.EndLine-entity.StartLine < best.EndLine-best.StartLine {
+			best = entity
+		}
+	}
+	return best
+}
+
+// matchName returns the single entity the observed name can refer to
+func matchName(obs Observation, byName map[string][]*models.CodeEntity) *models.CodeEntity {
+	qualifier, name := splitFrame(obs.Function)
+	if obs.Class != "" {
+		_, qualifier = splitFrame(obs.Class)
+	}
+
+	candidates := byName[name]
+	if obs.File != "" {
+		candidates = filter(candidates, func(e *models.CodeEntity) bool { return sameFile(obs.File, e.FilePath) })
+	}
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	if qualifier == "" {
+		return nil
+	}
+
+	// A method of the reported class, or a function of the reported package
+	for _, narrowed := range [][]*models.CodeEntity{
+		filter(candidates, func(e *models.CodeEntity) bool { return e.ClassName == qualifier }),
+		filter(candidates, func(e *models.CodeEntity) bool {
+			return e.ClassName == "" && (path.Base(path.Dir(e.FilePath)) == qualifier ||
+				strings.TrimSuffix(path.Base(e.FilePath), path.Ext(e.FilePath)) == qualifier)
+		}),
+	} {
+		if len(narrowed) == 1 {
+			return narrowed[0]
+		}
+	}
+	return nil
+}
+
+// sameFile reports whether a path recorded at runtime, usually absolute,
+// refers to a repository-relative file path
+func sameFile(runtimePath, filePath string) bool {
+	runtimePath = strings.ReplaceAll(runtimePath, "\\", "/")
+	return runtimePath == filePath || strings.HasSuffix(runtimePath, "/"+filePath)
+}
+
+func filter(entities []*models.CodeEntity, keep func(*models.CodeEntity) bool) []*models.CodeEntity {
+	var kept []*models.CodeEntity
+	for _, e := range entities {
+		if keep(e) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
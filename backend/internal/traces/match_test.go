@@ -0,0 +1,73 @@
+package traces
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dpolishuk/neograph/backend/internal/models"
+)
+
+func TestSplitFrame(t *testing.T) {
+	tests := []struct {
+		name      string
+		qualifier string
+		entity    string
+	}{
+		{"github.com/acme/app/store.(*Store).Get", "Store", "Get"},
+		{"github.com/acme/app/store.Open.func1.2", "store", "Open"},
+		{"github.com/acme/app/cache.(*LRU[...]).Put", "LRU", "Put"},
+		{"main.main", "main", "main"},
+		{"app.views.UserView.list_users", "UserView", "list_users"},
+		{"com.acme.Billing::charge", "Billing", "charge"},
+		{"mod.func1", "mod", "func1"},
+		{"handler", "", "handler"},
+	}
+
+	for _, tt := range tests {
+		qualifier, entity := splitFrame(tt.name)
+		if qualifier != tt.qualifier || entity != tt.entity {
+			t.Errorf("splitFrame(%q) = %q, %q; want %q, %q", tt.name, qualifier, entity, tt.qualifier, tt.entity)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	entities := []models.CodeEntity{
+		{ID: "main", Name: "main", FilePath: "main.go", StartLine: 10, EndLine: 20},
+		{ID: "store.Get", Name: "Get", ClassName: "Store", FilePath: "store/store.go", StartLine: 38, EndLine: 50},
+		{ID: "cache.Get", Name: "Get", ClassName: "Cache", FilePath: "cache/cache.go", StartLine: 5, EndLine: 9},
+		{ID: "cache.Open", Name: "Open", FilePath: "cache/cache.go", StartLine: 11, EndLine: 15},
+		{ID: "store.Open", Name: "Open", FilePath: "store/open.go", StartLine: 1, EndLine: 4},
+		{ID: "views.list_users", Name: "list_users", ClassName: "UserView", FilePath: "app/views.py", StartLine: 12, EndLine: 30},
+	}
+
+	observations := []Observation{
+		// By location, whatever the name
+		{Function: "main.main", File: "/build/app/main.go", Line: 10, Count: 6, Duration: 60 * time.Millisecond},
+		// By receiver
+		{Function: "github.com/acme/app/store.(*Store).Get", Count: 5},
+		// By package directory, closures added to their function
+		{Function: "github.com/acme/app/store.Open", Count: 2},
+		{Function: "github.com/acme/app/store.Open.func1", Count: 1},
+		// By reported class
+		{Function: "list_users", Class: "app.views.UserView", Count: 2, Duration: 40 * time.Millisecond},
+		// Ambiguous and unknown
+		{Function: "Get", Count: 1},
+		{Function: "runtime.mallocgc", Count: 9},
+	}
+
+	stats, unmatched := Match(observations, entities)
+	expected := []EntityStats{
+		{EntityID: "main", Count: 6, Duration: 60 * time.Millisecond},
+		{EntityID: "store.Get", Count: 5},
+		{EntityID: "store.Open", Count: 3},
+		{EntityID: "views.list_users", Count: 2, Duration: 40 * time.Millisecond},
+	}
+	if !reflect.DeepEqual(stats, expected) {
+		t.Errorf("Match stats:\n got %+v\nwant %+v", stats, expected)
+	}
+	if len(unmatched) != 2 || unmatched[0].Function != "Get" || unmatched[1].Function != "runtime.mallocgc" {
+		t.Errorf("unexpected unmatched %+v", unmatched)
+	}
+}
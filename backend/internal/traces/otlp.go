@@ -0,0 +1,131 @@
+package traces
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// otlpTraces is the OTLP/JSON encoding of exported spans. Older exporters
+// name scope spans instrumentationLibrarySpans.
+type otlpTraces struct {
+	ResourceSpans []struct {
+		ScopeSpans                  []otlpScopeSpans `json:"scopeSpans"`
+		InstrumentationLibrarySpans []otlpScopeSpans `json:"instrumentationLibrarySpans"`
+	} `json:"resourceSpans"`
+}
+
+type otlpScopeSpans struct {
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	Name              string          `json:"name"`
+	StartTimeUnixNano otlpInt         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   otlpInt         `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes"`
+}
+
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string  `json:"stringValue"`
+		IntValue    otlpInt `json:"intValue"`
+	} `json:"value"`
+}
+
+// otlpInt is a 64-bit integer, which OTLP/JSON writes as a string
+type otlpInt int64
+
+func (n *otlpInt) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	v, err := strconv.ParseInt(string(bytes.Trim(data, `"`)), 10, 64)
+	if err != nil {
+		return err
+	}
+	*n = otlpInt(v)
+	return nil
+}
+
+// Code attributes naming the function a span measures, under both the
+// older and the current semantic conventions
+var (
+	otlpFunctionKeys  = []string{"code.function.name", "code.function"}
+	otlpNamespaceKeys = []string{"code.namespace"}
+	otlpFileKeys      = []string{"code.file.path", "code.filepath"}
+	otlpLineKeys      = []string{"code.line.number", "code.lineno"}
+)
+
+// ParseOTLP reads OTLP/JSON traces. Spans are attributed to the function in
+// their code.* attributes, or to their name when they have none; each span
+// counts as one call lasting from its start to its end.
+func ParseOTLP(data []byte) ([]Observation, error) {
+	var doc otlpTraces
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OTLP JSON: %w", err)
+	}
+
+	byKey := make(map[Observation]*Observation)
+	var order []Observation
+	for _, resource := range doc.ResourceSpans {
+		for _, scope := range append(resource.ScopeSpans, resource.InstrumentationLibrarySpans...) {
+			for _, span := range scope.Spans {
+				key := Observation{
+					Function: spanString(span, otlpFunctionKeys),
+					Class:    spanString(span, otlpNamespaceKeys),
+					File:     spanString(span, otlpFileKeys),
+					Line:     int(spanInt(span, otlpLineKeys)),
+				}
+				if key.Function == "" {
+					key.Function = span.Name
+				}
+				if key.Function == "" {
+					continue
+				}
+
+				obs, ok := byKey[key]
+				if !ok {
+					obs = &Observation{Function: key.Function, Class: key.Class, File: key.File, Line: key.Line}
+					byKey[key] = obs
+					order = append(order, key)
+				}
+				obs.Count++
+				if span.EndTimeUnixNano > span.StartTimeUnixNano {
+					obs.Duration += time.Duration(span.EndTimeUnixNano - span.StartTimeUnixNano)
+				}
+			}
+		}
+	}
+
+	observations := make([]Observation, 0, len(order))
+	for _, key := range order {
+		observations = append(observations, *byKey[key])
+	}
+	return observations, nil
+}
+
+func spanString(span otlpSpan, keys []string) string {
+	for _, key := range keys {
+		for _, attr := range span.Attributes {
+			if attr.Key == key && attr.Value.StringValue != "" {
+				return attr.Value.StringValue
+			}
+		}
+	}
+	return ""
+}
+
+func spanInt(span otlpSpan, keys []string) int64 {
+	for _, key := range keys {
+		for _, attr := range span.Attributes {
+			if attr.Key == key {
+				return int64(attr.Value.IntValue)
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,54 @@
+package traces
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseOTLP(t *testing.T) {
+	data := []byte(`{"resourceSpans": [{"scopeSpans": [{"spans": [
+		{"name": "GET /users", "startTimeUnixNano": "1000000000", "endTimeUnixNano": "1030000000",
+		 "attributes": [{"key": "code.function", "value": {"stringValue": "list_users"}},
+		                {"key": "code.namespace", "value": {"stringValue": "app.views.UserView"}},
+		                {"key": "code.lineno", "value": {"intValue": "14"}}]},
+		{"name": "GET /users", "startTimeUnixNano": "2000000000", "endTimeUnixNano": "2010000000",
+		 "attributes": [{"key": "code.function", "value": {"stringValue": "list_users"}},
+		                {"key": "code.namespace", "value": {"stringValue": "app.views.UserView"}},
+		                {"key": "code.lineno", "value": {"intValue": 14}}]}
+	]}]}, {"instrumentationLibrarySpans": [{"spans": [
+		{"name": "db.query", "startTimeUnixNano": 5, "endTimeUnixNano": 3}
+	]}]}]}`)
+
+	observations, err := ParseOTLP(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(observations) != 2 {
+		t.Fatalf("expected 2 observations, got %+v", observations)
+	}
+
+	users := observations[0]
+	if users.Function != "list_users" || users.Class != "app.views.UserView" || users.Line != 14 {
+		t.Errorf("unexpected observation %+v", users)
+	}
+	if users.Count != 2 || users.Duration != 40*time.Millisecond {
+		t.Errorf("count %d, duration %s; want 2, 40ms", users.Count, users.Duration)
+	}
+
+	// Spans without code attributes fall back to their name; clock skew is ignored
+	if query := observations[1]; query.Function != "db.query" || query.Count != 1 || query.Duration != 0 {
+		t.Errorf("unexpected observation %+v", query)
+	}
+}
+
+func TestParseFormats(t *testing.T) {
+	if format := DetectFormat([]byte(" \n{\"resourceSpans\": []}")); format != FormatOTLP {
+		t.Errorf("DetectFormat(json) = %q", format)
+	}
+	if format := DetectFormat(testProfile()); format != FormatPprof {
+		t.Errorf("DetectFormat(profile) = %q", format)
+	}
+	if _, _, err := Parse([]byte("{}"), "jfr"); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
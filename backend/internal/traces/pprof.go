@@ -0,0 +1,308 @@
+package traces
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// maxProfileBytes caps the decompressed size of a profile
+const maxProfileBytes = 64 * 1024 * 1024
+
+var errTruncated = errors.New("truncated protobuf message")
+
+// Field numbers of the pprof profile.proto messages that are read
+const (
+	profileSampleType  = 1
+	profileSample      = 2
+	profileLocation    = 4
+	profileFunction    = 5
+	profileStringTable = 6
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+
+	locationID   = 1
+	locationLine = 4
+
+	lineFunctionID = 1
+	lineLine       = 2
+
+	functionID        = 1
+	functionName      = 2
+	functionFilename  = 4
+	functionStartLine = 5
+)
+
+type pprofFunction struct {
+	name, filename int64
+	startLine      int64
+}
+
+type pprofLine struct {
+	functionID uint64
+	line       int64
+}
+
+type pprofSample struct {
+	locationIDs []uint64
+	values      []int64
+}
+
+// ParsePprof reads a pprof profile, gzipped or not. Every function on a
+// sample's stack is credited with the sample once, so counts and durations
+// are cumulative; closures are credited to the function declaring them.
+func ParsePprof(data []byte) ([]Observation, error) {
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip profile: %w", err)
+		}
+		data, err = io.ReadAll(io.LimitReader(zr, maxProfileBytes))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip profile: %w", err)
+		}
+	}
+
+	var (
+		sampleTypes [][2]int64 // type and unit string indexes
+		samples     []pprofSample
+		locations   = make(map[uint64][]pprofLine)
+		functions   = make(map[uint64]pprofFunction)
+		strs        []string
+	)
+	err := eachField(data, func(field int, wire int, v uint64, b []byte) error {
+		switch field {
+		case profileSampleType:
+			var st [2]int64
+			err := eachField(b, func(field int, wire int, v uint64, _ []byte) error {
+				switch field {
+				case valueTypeType:
+					st[0] = int64(v)
+				case valueTypeUnit:
+					st[1] = int64(v)
+				}
+				return nil
+			})
+			sampleTypes = append(sampleTypes, st)
+			return err
+		case profileSample:
+			var s pprofSample
+			err := eachField(b, func(field int, wire int, v uint64, b []byte) error {
+				switch field {
+				case sampleLocationID:
+					return appendVarints(wire, v, b, func(n uint64) { s.locationIDs = append(s.locationIDs, n) })
+				case sampleValue:
+					return appendVarints(wire, v, b, func(n uint64) { s.values = append(s.values, int64(n)) })
+				}
+				return nil
+			})
+			samples = append(samples, s)
+			return err
+		case profileLocation:
+			var id uint64
+			var lines []pprofLine
+			err := eachField(b, func(field int, wire int, v uint64, b []byte) error {
+				switch field {
+				case locationID:
+					id = v
+				case locationLine:
+					var l pprofLine
+					err := eachField(b, func(field int, wire int, v uint64, _ []byte) error {
+						switch field {
+						case lineFunctionID:
+							l.functionID = v
+						case lineLine:
+							l.line = int64(v)
+						}
+						return nil
+					})
+					lines = append(lines, l)
+					return err
+				}
+				return nil
+			})
+			locations[id] = lines
+			return err
+		case profileFunction:
+			var id uint64
+			var fn pprofFunction
+			err := eachField(b, func(field int, wire int, v uint64, _ []byte) error {
+				switch field {
+				case functionID:
+					id = v
+				case functionName:
+					fn.name = int64(v)
+				case functionFilename:
+					fn.filename = int64(v)
+				case functionStartLine:
+					fn.startLine = int64(v)
+				}
+				return nil
+			})
+			functions[id] = fn
+			return err
+		case profileStringTable:
+			strs = append(strs, string(b))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid pprof profile: %w", err)
+	}
+
+	str := func(i int64) string {
+		if i < 0 || int(i) >= len(strs) {
+			return ""
+		}
+		return strs[i]
+	}
+
+	// Samples are counted from a count-typed value, time from a
+	// nanosecond-typed one, as in samples/count and cpu/nanoseconds
+	countIdx, timeIdx := -1, -1
+	for i, st := range sampleTypes {
+		switch str(st[1]) {
+		case "count":
+			if countIdx < 0 {
+				countIdx = i
+			}
+		case "nanoseconds":
+			if timeIdx < 0 {
+				timeIdx = i
+			}
+		}
+	}
+
+	byKey := make(map[[2]string]*Observation)
+	var order [][2]string
+	for _, s := range samples {
+		count := int64(1)
+		if countIdx >= 0 && countIdx < len(s.values) {
+			count = s.values[countIdx]
+		}
+		var duration time.Duration
+		if timeIdx >= 0 && timeIdx < len(s.values) {
+			duration = time.Duration(s.values[timeIdx])
+		}
+
+		seen := make(map[[2]string]bool)
+		for _, locID := range s.locationIDs {
+			for _, l := range locations[locID] {
+				fn, ok := functions[l.functionID]
+				if !ok {
+					continue
+				}
+				name := stripClosure(str(fn.name))
+				key := [2]string{name, str(fn.filename)}
+				if name == "" || seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				obs, ok := byKey[key]
+				if !ok {
+					line := fn.startLine
+					if line == 0 {
+						line = l.line
+					}
+					obs = &Observation{Function: name, File: key[1], Line: int(line)}
+					byKey[key] = obs
+					order = append(order, key)
+				}
+				obs.Count += count
+				obs.Duration += duration
+			}
+		}
+	}
+
+	observations := make([]Observation, 0, len(order))
+	for _, key := range order {
+		observations = append(observations, *byKey[key])
+	}
+	return observations, nil
+}
+
+// eachField walks the fields of a protobuf message. v holds varint and fixed
+// values, b the bytes of length-delimited ones.
+func eachField(data []byte, fn func(field int, wire int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := readVarint(data)
+		if n == 0 {
+			return errTruncated
+		}
+		data = data[n:]
+		field, wire := int(key>>3), int(key&7)
+
+		var v uint64
+		var b []byte
+		switch wire {
+		case 0:
+			v, n = readVarint(data)
+			if n == 0 {
+				return errTruncated
+			}
+			data = data[n:]
+		case 1:
+			if len(data) < 8 {
+				return errTruncated
+			}
+			data = data[8:]
+		case 2:
+			size, n := readVarint(data)
+			if n == 0 || uint64(len(data)-n) < size {
+				return errTruncated
+			}
+			b = data[n : n+int(size)]
+			data = data[n+int(size):]
+		case 5:
+			if len(data) < 4 {
+				return errTruncated
+			}
+			data = data[4:]
+		default:
+			return fmt.Errorf("unsupported wire type %d", wire)
+		}
+
+		if err := fn(field, wire, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendVarints handles a repeated varint field, either packed into one
+// length-delimited field or written one value per field
+func appendVarints(wire int, v uint64, b []byte, add func(uint64)) error {
+	if wire == 0 {
+		add(v)
+		return nil
+	}
+	for len(b) > 0 {
+		n, size := readVarint(b)
+		if size == 0 {
+			return errTruncated
+		}
+		add(n)
+		b = b[size:]
+	}
+	return nil
+}
+
+// readVarint decodes a varint, returning 0 bytes read when data ends early
+func readVarint(data []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < len(data) && i < 10; i++ {
+		v |= uint64(data[i]&0x7f) << (7 * i)
+		if data[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,105 @@
+package traces
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"time"
+)
+
+// protoBuf encodes just enough protobuf to build test profiles
+type protoBuf []byte
+
+func (b protoBuf) varint(v uint64) protoBuf {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func (b protoBuf) uint(field int, v uint64) protoBuf {
+	return b.varint(uint64(field) << 3).varint(v)
+}
+
+func (b protoBuf) bytes(field int, data []byte) protoBuf {
+	b = b.varint(uint64(field)<<3 | 2).varint(uint64(len(data)))
+	return append(b, data...)
+}
+
+func (b protoBuf) packed(field int, values ...uint64) protoBuf {
+	var p protoBuf
+	for _, v := range values {
+		p = p.varint(v)
+	}
+	return b.bytes(field, p)
+}
+
+// testProfile is a CPU profile of main calling store.(*Store).Get, once
+// directly and once through a closure, plus a sample of main alone
+func testProfile() []byte {
+	strs := []string{"", "samples", "count", "cpu", "nanoseconds",
+		"main.main", "/src/app/main.go",
+		"github.com/acme/app/store.(*Store).Get", "/src/app/store/store.go",
+		"github.com/acme/app/store.(*Store).Get.func1"}
+
+	var p protoBuf
+	p = p.bytes(profileSampleType, protoBuf{}.uint(valueTypeType, 1).uint(valueTypeUnit, 2))
+	p = p.bytes(profileSampleType, protoBuf{}.uint(valueTypeType, 3).uint(valueTypeUnit, 4))
+
+	// Leaf first: Get <- main, Get.func1 <- Get <- main, main
+	p = p.bytes(profileSample, protoBuf{}.packed(sampleLocationID, 2, 1).packed(sampleValue, 3, 30_000_000))
+	p = p.bytes(profileSample, protoBuf{}.packed(sampleLocationID, 3, 2, 1).packed(sampleValue, 2, 20_000_000))
+	p = p.bytes(profileSample, protoBuf{}.uint(sampleLocationID, 1).uint(sampleValue, 1).uint(sampleValue, 10_000_000))
+
+	p = p.bytes(profileLocation, protoBuf{}.uint(locationID, 1).bytes(locationLine, protoBuf{}.uint(lineFunctionID, 1).uint(lineLine, 12)))
+	p = p.bytes(profileLocation, protoBuf{}.uint(locationID, 2).bytes(locationLine, protoBuf{}.uint(lineFunctionID, 2).uint(lineLine, 40)))
+	p = p.bytes(profileLocation, protoBuf{}.uint(locationID, 3).bytes(locationLine, protoBuf{}.uint(lineFunctionID, 3).uint(lineLine, 44)))
+
+	p = p.bytes(profileFunction, protoBuf{}.uint(functionID, 1).uint(functionName, 5).uint(functionFilename, 6).uint(functionStartLine, 10))
+	p = p.bytes(profileFunction, protoBuf{}.uint(functionID, 2).uint(functionName, 7).uint(functionFilename, 8).uint(functionStartLine, 38))
+	p = p.bytes(profileFunction, protoBuf{}.uint(functionID, 3).uint(functionName, 9).uint(functionFilename, 8).uint(functionStartLine, 43))
+
+	for _, s := range strs {
+		p = p.bytes(profileStringTable, []byte(s))
+	}
+	return p
+}
+
+func TestParsePprof(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(testProfile())
+	zw.Close()
+
+	for name, data := range map[string][]byte{"raw": testProfile(), "gzip": gz.Bytes()} {
+		observations, err := ParsePprof(data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(observations) != 2 {
+			t.Fatalf("%s: expected Get and main, got %+v", name, observations)
+		}
+
+		get, main := observations[0], observations[1]
+		if get.Function != "github.com/acme/app/store.(*Store).Get" || get.File != "/src/app/store/store.go" || get.Line != 38 {
+			t.Errorf("%s: unexpected Get observation %+v", name, get)
+		}
+		// The closure's sample is credited to Get once
+		if get.Count != 5 || get.Duration != 50*time.Millisecond {
+			t.Errorf("%s: Get count %d, duration %s; want 5, 50ms", name, get.Count, get.Duration)
+		}
+		if main.Function != "main.main" || main.Count != 6 || main.Duration != 60*time.Millisecond {
+			t.Errorf("%s: unexpected main observation %+v", name, main)
+		}
+	}
+}
+
+func TestParsePprofInvalid(t *testing.T) {
+	if _, err := ParsePprof([]byte{0x0a, 0x05, 0x01}); err == nil {
+		t.Error("expected an error for a truncated profile")
+	}
+	if _, err := ParsePprof([]byte{0x1f, 0x8b, 0x00}); err == nil {
+		t.Error("expected an error for broken gzip")
+	}
+}
@@ -0,0 +1,58 @@
+// Package traces reads runtime profiles and traces and maps the functions
+// they observed onto indexed code entities, so the static graph can be
+// overlaid with production behavior.
+package traces
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// Formats accepted by Parse
+const (
+	FormatPprof = "pprof"
+	FormatOTLP  = "otlp"
+)
+
+// Observation is what a profile or trace recorded about one function
+type Observation struct {
+	Function string // as named by the runtime, e.g. pkg.(*Type).Method
+	Class    string // enclosing class when reported apart from Function
+	File     string
+	Line     int // a line inside the function, 0 when unknown
+
+	// Count is the number of samples the function was on the stack of, or
+	// the number of spans recorded for it
+	Count    int64
+	Duration time.Duration // total time across Count
+}
+
+// DetectFormat guesses the format of an upload: OTLP traces are JSON,
+// anything else is taken for a (possibly gzipped) pprof profile
+func DetectFormat(data []byte) string {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return FormatOTLP
+	}
+	return FormatPprof
+}
+
+// Parse reads a profile or trace in the given format, detecting it when
+// format is empty, and returns the format used
+func Parse(data []byte, format string) ([]Observation, string, error) {
+	if format == "" {
+		format = DetectFormat(data)
+	}
+
+	var observations []Observation
+	var err error
+	switch format {
+	case FormatPprof:
+		observations, err = ParsePprof(data)
+	case FormatOTLP:
+		observations, err = ParseOTLP(data)
+	default:
+		return nil, format, fmt.Errorf("unsupported format %q, must be %q or %q", format, FormatPprof, FormatOTLP)
+	}
+	return observations, format, err
+}
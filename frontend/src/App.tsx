@@ -1,4 +1,4 @@
-import { Routes, Route, Link } from 'react-router-dom'
+import { Routes, Route, Link, useMatch } from 'react-router-dom'
 import { useState } from 'react'
 import RepositoryListPage from './pages/RepositoryListPage'
 import RepositoryDetailPage from './pages/RepositoryDetailPage'
@@ -12,6 +12,8 @@ import { Button } from './components/ui/button'
 function App() {
   const [chatOpen, setChatOpen] = useState(false)
   const [chatInitialMessage, setChatInitialMessage] = useState<string | undefined>()
+  // Chats on a repository's pages are about it, and kept as conversations
+  const repositoryMatch = useMatch('/repository/:id/*')
 
   const handleOpenChat = (initialMessage?: string) => {
     setChatInitialMessage(initialMessage)
@@ -67,6 +69,7 @@ function App() {
       <ChatPanel
         open={chatOpen}
         onClose={() => setChatOpen(false)}
+        repoId={repositoryMatch?.params.id}
         initialMessage={chatInitialMessage}
       />
     </div>
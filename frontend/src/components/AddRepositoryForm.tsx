@@ -1,28 +1,56 @@
 import { useState } from 'react'
 import { useMutation, useQueryClient } from '@tanstack/react-query'
-import { repositoryApi } from '@/lib/api'
+import { repositoryApi, BulkRepositoryInput } from '@/lib/api'
 import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
 import { Plus } from 'lucide-react'
 
+type Mode = 'single' | 'urls' | 'org'
+
 export function AddRepositoryForm() {
   const [url, setUrl] = useState('')
+  const [urls, setUrls] = useState('')
+  const [org, setOrg] = useState('')
+  const [mode, setMode] = useState<Mode>('single')
   const [isOpen, setIsOpen] = useState(false)
   const queryClient = useQueryClient()
 
+  const close = () => {
+    setIsOpen(false)
+    setUrl('')
+    setUrls('')
+    setOrg('')
+    mutation.reset()
+    bulkMutation.reset()
+  }
+
   const mutation = useMutation({
     mutationFn: repositoryApi.create,
     onSuccess: () => {
       queryClient.invalidateQueries({ queryKey: ['repositories'] })
-      setUrl('')
-      setIsOpen(false)
+      close()
+    },
+  })
+
+  // Stays open to show the result of each repository
+  const bulkMutation = useMutation({
+    mutationFn: (input: BulkRepositoryInput) => repositoryApi.bulkCreate(input),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['repositories'] })
     },
   })
 
   const handleSubmit = (e: React.FormEvent) => {
     e.preventDefault()
-    if (url.trim()) {
+    if (mode === 'single' && url.trim()) {
       mutation.mutate({ url: url.trim() })
+    } else if (mode === 'urls') {
+      const list = urls.split(/\s+/).filter(Boolean)
+      if (list.length) {
+        bulkMutation.mutate({ urls: list })
+      }
+    } else if (mode === 'org' && org.trim()) {
+      bulkMutation.mutate({ org: org.trim() })
     }
   }
 
@@ -35,37 +63,78 @@ export function AddRepositoryForm() {
     )
   }
 
+  const pending = mutation.isPending || bulkMutation.isPending
+  const error = mutation.error || bulkMutation.error
+
   return (
     <form onSubmit={handleSubmit} className="space-y-2">
       <div className="flex gap-2">
-        <Input
-          type="url"
-          placeholder="https://github.com/user/repo"
-          value={url}
-          onChange={(e) => setUrl(e.target.value)}
-          className="flex-1"
-          autoFocus
-        />
-        <Button type="submit" disabled={mutation.isPending}>
-          {mutation.isPending ? 'Adding...' : 'Add'}
-        </Button>
-        <Button
-          type="button"
-          variant="outline"
-          onClick={() => {
-            setIsOpen(false)
-            setUrl('')
-            mutation.reset()
-          }}
+        <select
+          value={mode}
+          onChange={(e) => setMode(e.target.value as Mode)}
+          className="border rounded-md px-2 text-sm"
         >
+          <option value="single">URL</option>
+          <option value="urls">Many URLs</option>
+          <option value="org">GitHub organization</option>
+        </select>
+        {mode === 'single' && (
+          <Input
+            type="url"
+            placeholder="https://github.com/user/repo"
+            value={url}
+            onChange={(e) => setUrl(e.target.value)}
+            className="flex-1"
+            autoFocus
+          />
+        )}
+        {mode === 'org' && (
+          <Input
+            placeholder="Organization or user, e.g. acme"
+            value={org}
+            onChange={(e) => setOrg(e.target.value)}
+            className="flex-1"
+            autoFocus
+          />
+        )}
+        <Button type="submit" disabled={pending}>
+          {pending ? 'Adding...' : 'Add'}
+        </Button>
+        <Button type="button" variant="outline" onClick={close}>
           Cancel
         </Button>
       </div>
-      {mutation.error && (
+      {mode === 'urls' && (
+        <textarea
+          placeholder="One repository URL per line"
+          value={urls}
+          onChange={(e) => setUrls(e.target.value)}
+          rows={6}
+          className="w-full border rounded-md p-2 text-sm font-mono"
+          autoFocus
+        />
+      )}
+      {error && (
         <p className="text-sm text-red-600">
-          {mutation.error instanceof Error ? mutation.error.message : 'Failed to add repository'}
+          {error instanceof Error ? error.message : 'Failed to add repository'}
         </p>
       )}
+      {bulkMutation.data && (
+        <div className="text-sm">
+          <p className="text-gray-600 mb-1">
+            Added {bulkMutation.data.created} of {bulkMutation.data.results.length} repositories
+          </p>
+          <ul className="space-y-0.5">
+            {bulkMutation.data.results
+              .filter((result) => result.status !== 'created')
+              .map((result) => (
+                <li key={result.url} className={result.status === 'error' ? 'text-red-600' : 'text-gray-500'}>
+                  {result.url}: {result.status === 'error' ? result.error : 'already registered'}
+                </li>
+              ))}
+          </ul>
+        </div>
+      )}
     </form>
   )
 }
@@ -1,9 +1,9 @@
 import { useState, useRef, useEffect } from 'react'
-import { X, Send, MessageSquare, Loader2 } from 'lucide-react'
+import { X, Send, MessageSquare, Loader2, Plus, Trash2 } from 'lucide-react'
 import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
 import { cn } from '@/lib/utils'
-import { agentApi } from '@/lib/api'
+import { agentApi, conversationApi } from '@/lib/api'
 
 interface Message {
   role: 'user' | 'assistant'
@@ -21,12 +21,37 @@ export function ChatPanel({ open, onClose, repoId, initialMessage }: ChatPanelPr
   const [messages, setMessages] = useState<Message[]>([])
   const [input, setInput] = useState('')
   const [isLoading, setIsLoading] = useState(false)
+  // The conversation kept on the repository, once one is started
+  const [conversationId, setConversationId] = useState<string | undefined>()
   const messagesEndRef = useRef<HTMLDivElement>(null)
 
-  // Handle initial message
+  // Pick up the latest conversation on the repository, so it survives reloads
+  useEffect(() => {
+    setConversationId(undefined)
+    setMessages([])
+    if (!repoId || initialMessage) return
+    let cancelled = false
+    conversationApi
+      .list(repoId)
+      .then(async (conversations) => {
+        if (!conversations.length) return
+        const latest = await conversationApi.get(repoId, conversations[0].id)
+        if (cancelled) return
+        setConversationId(latest.id)
+        setMessages((latest.messages ?? []).map(({ role, content }) => ({ role, content })))
+      })
+      .catch(() => {})
+    return () => {
+      cancelled = true
+    }
+  }, [repoId])
+
+  // Handle initial message, which starts a new conversation
   useEffect(() => {
     if (initialMessage && open) {
-      sendMessage(initialMessage)
+      setConversationId(undefined)
+      setMessages([])
+      sendMessage(initialMessage, true)
     }
   }, [initialMessage, open])
 
@@ -35,7 +60,7 @@ export function ChatPanel({ open, onClose, repoId, initialMessage }: ChatPanelPr
     messagesEndRef.current?.scrollIntoView({ behavior: 'smooth' })
   }, [messages])
 
-  const sendMessage = async (messageText?: string) => {
+  const sendMessage = async (messageText?: string, newConversation = false) => {
     const text = messageText || input
     if (!text.trim() || isLoading) return
 
@@ -44,19 +69,45 @@ export function ChatPanel({ open, onClose, repoId, initialMessage }: ChatPanelPr
     setMessages((prev) => [...prev, { role: 'user', content: userMessage }])
     setIsLoading(true)
 
-    try {
-      const response = await agentApi.chat(userMessage, repoId)
-      setMessages((prev) => [...prev, { role: 'assistant', content: response.response }])
-    } catch (error) {
+    // The answer is drawn into its message as the agent writes it
+    const updateAnswer = (content: (prev: string) => string) =>
       setMessages((prev) => [
-        ...prev,
-        { role: 'assistant', content: 'Error: Failed to get response from agent' },
+        ...prev.slice(0, -1),
+        { role: 'assistant', content: content(prev[prev.length - 1].content) },
       ])
+    setMessages((prev) => [...prev, { role: 'assistant', content: '' }])
+
+    const onToken = (text: string) => updateAnswer((prev) => prev + text)
+    try {
+      if (repoId) {
+        let id = newConversation ? undefined : conversationId
+        if (!id) {
+          id = (await conversationApi.create(repoId)).id
+          setConversationId(id)
+        }
+        await conversationApi.sendStream(repoId, id, userMessage, onToken)
+      } else {
+        await agentApi.chatStream(userMessage, onToken)
+      }
+    } catch (error) {
+      updateAnswer((prev) => (prev ? `${prev}\n\n` : '') + 'Error: Failed to get response from agent')
     } finally {
       setIsLoading(false)
     }
   }
 
+  const startConversation = () => {
+    setConversationId(undefined)
+    setMessages([])
+  }
+
+  const deleteConversation = async () => {
+    if (repoId && conversationId) {
+      await conversationApi.delete(repoId, conversationId).catch(() => {})
+    }
+    startConversation()
+  }
+
   const handleKeyDown = (e: React.KeyboardEvent<HTMLInputElement>) => {
     if (e.key === 'Enter' && !e.shiftKey) {
       e.preventDefault()
@@ -77,9 +128,27 @@ export function ChatPanel({ open, onClose, repoId, initialMessage }: ChatPanelPr
           <MessageSquare className="w-5 h-5" />
           Chat
         </h3>
-        <Button variant="ghost" size="sm" onClick={onClose}>
-          <X className="w-4 h-4" />
-        </Button>
+        <div className="flex items-center gap-1">
+          {repoId && (
+            <>
+              <Button variant="ghost" size="sm" onClick={startConversation} disabled={isLoading} title="New conversation">
+                <Plus className="w-4 h-4" />
+              </Button>
+              <Button
+                variant="ghost"
+                size="sm"
+                onClick={deleteConversation}
+                disabled={isLoading || !conversationId}
+                title="Delete conversation"
+              >
+                <Trash2 className="w-4 h-4" />
+              </Button>
+            </>
+          )}
+          <Button variant="ghost" size="sm" onClick={onClose}>
+            <X className="w-4 h-4" />
+          </Button>
+        </div>
       </div>
 
       {/* Messages Area */}
@@ -92,7 +161,7 @@ export function ChatPanel({ open, onClose, repoId, initialMessage }: ChatPanelPr
           </div>
         )}
 
-        {messages.map((msg, i) => (
+        {messages.filter((msg) => msg.content).map((msg, i) => (
           <div
             key={i}
             className={cn(
@@ -106,7 +175,7 @@ export function ChatPanel({ open, onClose, repoId, initialMessage }: ChatPanelPr
           </div>
         ))}
 
-        {isLoading && (
+        {isLoading && !messages[messages.length - 1]?.content && (
           <div className="flex items-center gap-2 text-gray-500 text-sm">
             <Loader2 className="w-4 h-4 animate-spin" />
             <span>Thinking...</span>
@@ -1,6 +1,6 @@
-import { useQuery } from '@tanstack/react-query'
-import { repositoryApi, searchApi } from '@/lib/api'
-import { ChevronRight, ChevronDown, FileCode, Box, Search } from 'lucide-react'
+import { useQuery, useQueryClient } from '@tanstack/react-query'
+import { repositoryApi, searchApi, type DirectoryNode, type FileNode } from '@/lib/api'
+import { ChevronRight, ChevronDown, FileCode, Box, Search, Folder, FolderOpen } from 'lucide-react'
 import { useState } from 'react'
 import { Input } from '@/components/ui/input'
 
@@ -10,40 +10,62 @@ interface FileTreeProps {
   onSearchResults?: (nodeIds: string[]) => void
 }
 
-interface FileNode {
-  id: string
-  path: string
-  language: string
-  functions: Array<{
-    id: string
-    name: string
-    signature: string
-    startLine: number
-    endLine: number
-  }>
+// filterTree keeps the files and functions matching a search, and the
+// directories leading to them
+function filterTree(dir: DirectoryNode, ids: Set<string>): DirectoryNode | null {
+  const files = dir.files
+    .map((file) => ({ ...file, functions: file.functions.filter((fn) => ids.has(fn.id)) }))
+    .filter((file) => file.functions.length > 0 || ids.has(file.id))
+  const directories = dir.directories
+    .map((child) => filterTree(child, ids))
+    .filter((child): child is DirectoryNode => child !== null)
+
+  if (files.length === 0 && directories.length === 0) return null
+  return { ...dir, files, directories }
 }
 
+// Directories load a level at a time as they are opened; a search loads the
+// whole tree to show every match
 export function FileTree({ repoId, onNodeSelect, onSearchResults }: FileTreeProps) {
+  const queryClient = useQueryClient()
   const [expanded, setExpanded] = useState<Set<string>>(new Set())
   const [searchQuery, setSearchQuery] = useState('')
+  // Levels fetched for truncated directories, by repository and path
+  const [levels, setLevels] = useState<Record<string, DirectoryNode>>({})
 
-  const { data: files, isLoading } = useQuery({
-    queryKey: ['repository-files', repoId],
+  const { data: root, isLoading } = useQuery({
+    queryKey: ['repository-files', repoId, ''],
     queryFn: () => repositoryApi.getFiles(repoId),
   })
 
+  const { data: fullTree } = useQuery({
+    queryKey: ['repository-tree', repoId],
+    queryFn: () => repositoryApi.getTree(repoId),
+    enabled: searchQuery.length > 2,
+  })
+
+  const loadLevel = (path: string) => {
+    if (levels[`${repoId}:${path}`]) return
+    queryClient
+      .fetchQuery({
+        queryKey: ['repository-files', repoId, path],
+        queryFn: () => repositoryApi.getFiles(repoId, path),
+      })
+      .then((level) => setLevels((prev) => ({ ...prev, [`${repoId}:${path}`]: level })))
+  }
+
   const { data: searchResults, isLoading: isSearching } = useQuery({
     queryKey: ['repository-search', repoId, searchQuery],
     queryFn: () => searchApi.repo(repoId, searchQuery),
     enabled: searchQuery.length > 2,
   })
 
-  const toggleExpand = (fileId: string) => {
+  const toggleExpand = (key: string) => {
     const next = new Set(expanded)
-    if (next.has(fileId)) {
-      next.delete(fileId)
+    if (next.has(key)) {
+      next.delete(key)
     } else {
-      next.add(fileId)
+      next.add(key)
     }
     setExpanded(next)
   }
@@ -58,18 +80,101 @@ export function FileTree({ repoId, onNodeSelect, onSearchResults }: FileTreeProp
   const searchResultIds = new Set(searchResults?.map(r => r.id) || [])
   const hasActiveSearch = searchQuery.length > 2 && searchResults
 
-  const filteredFiles = hasActiveSearch
-    ? files?.map((file: FileNode) => ({
-        ...file,
-        functions: file.functions.filter(fn => searchResultIds.has(fn.id))
-      })).filter((file: FileNode) => file.functions.length > 0 || searchResultIds.has(file.id))
-    : files
+  const tree = hasActiveSearch && fullTree ? fullTree : root
+  const filteredTree = tree && hasActiveSearch ? filterTree(tree, searchResultIds) : tree
 
   if (isLoading) return <div className="p-4">Loading files...</div>
 
+  const renderFile = (file: FileNode) => {
+    const isFileMatched = searchResultIds.has(file.id)
+    const hasMatchedFunctions = file.functions.some(fn => searchResultIds.has(fn.id))
+
+    return (
+      <div key={file.id}>
+        <button
+          className={`flex items-center gap-1 w-full p-1.5 rounded hover:bg-gray-100 text-left text-sm ${
+            isFileMatched && hasActiveSearch ? 'bg-orange-50 border border-orange-200' : ''
+          }`}
+          onClick={() => {
+            toggleExpand(file.id)
+            onNodeSelect(file.id)
+          }}
+        >
+          {file.functions.length > 0 ? (
+            expanded.has(file.id) ? <ChevronDown className="w-4 h-4" /> : <ChevronRight className="w-4 h-4" />
+          ) : <span className="w-4" />}
+          <FileCode className="w-4 h-4 text-blue-500" />
+          <span className="truncate">{file.path.split('/').pop()}</span>
+        </button>
+
+        {(expanded.has(file.id) || (hasActiveSearch && hasMatchedFunctions)) && (
+          <div className="ml-6">
+            {file.functions.map((fn) => {
+              const isFunctionMatched = searchResultIds.has(fn.id)
+              return (
+                <button
+                  key={fn.id}
+                  className={`flex items-center gap-1 w-full p-1.5 rounded hover:bg-gray-100 text-left text-sm ${
+                    isFunctionMatched && hasActiveSearch ? 'bg-orange-50 border border-orange-200 font-medium' : ''
+                  }`}
+                  onClick={() => onNodeSelect(fn.id)}
+                >
+                  <Box className="w-4 h-4 text-green-500" />
+                  <span className="truncate">{fn.name}</span>
+                </button>
+              )
+            })}
+          </div>
+        )}
+      </div>
+    )
+  }
+
+  // Directories start collapsed, but open up to show search matches
+  const renderDirectory = (dir: DirectoryNode) => {
+    const key = `dir:${dir.path}`
+    const isOpen = expanded.has(key) || Boolean(hasActiveSearch)
+    const contents = dir.truncated ? levels[`${repoId}:${dir.path}`] : dir
+
+    return (
+      <div key={key}>
+        <button
+          className="flex items-center gap-1 w-full p-1.5 rounded hover:bg-gray-100 text-left text-sm"
+          onClick={() => {
+            toggleExpand(key)
+            if (dir.truncated) loadLevel(dir.path)
+            if (dir.id) onNodeSelect(dir.id)
+          }}
+        >
+          {isOpen ? <ChevronDown className="w-4 h-4" /> : <ChevronRight className="w-4 h-4" />}
+          {isOpen
+            ? <FolderOpen className="w-4 h-4 text-amber-500" />
+            : <Folder className="w-4 h-4 text-amber-500" />}
+          <span className="truncate">{dir.name}</span>
+          <span className="ml-auto text-xs text-gray-400">{dir.fileCount}</span>
+        </button>
+        {isOpen && (
+          <div className="ml-4">
+            {contents ? renderContents(contents) : <div className="p-1.5 text-xs text-gray-400">Loading...</div>}
+          </div>
+        )}
+      </div>
+    )
+  }
+
+  const renderContents = (dir: DirectoryNode) => (
+    <>
+      {dir.directories.map(renderDirectory)}
+      {dir.files.map(renderFile)}
+    </>
+  )
+
   return (
     <div className="bg-white rounded-lg border overflow-auto flex flex-col">
-      <div className="p-3 border-b font-medium text-sm">Files</div>
+      <div className="p-3 border-b font-medium text-sm">
+        Files
+        {tree && <span className="ml-2 text-xs font-normal text-gray-400">{tree.fileCount}</span>}
+      </div>
       <div className="p-2 border-b">
         <div className="relative">
           <Search className="absolute left-2 top-2.5 w-4 h-4 text-gray-400" />
@@ -86,55 +191,12 @@ export function FileTree({ repoId, onNodeSelect, onSearchResults }: FileTreeProp
         )}
       </div>
       <div className="p-2 flex-1 overflow-auto">
-        {filteredFiles?.length === 0 && hasActiveSearch && (
+        {!filteredTree && hasActiveSearch && (
           <div className="text-sm text-gray-500 text-center py-4">
             No matches found
           </div>
         )}
-        {filteredFiles?.map((file: FileNode) => {
-          const isFileMatched = searchResultIds.has(file.id)
-          const hasMatchedFunctions = file.functions.some(fn => searchResultIds.has(fn.id))
-
-          return (
-            <div key={file.id}>
-              <button
-                className={`flex items-center gap-1 w-full p-1.5 rounded hover:bg-gray-100 text-left text-sm ${
-                  isFileMatched && hasActiveSearch ? 'bg-orange-50 border border-orange-200' : ''
-                }`}
-                onClick={() => {
-                  toggleExpand(file.id)
-                  onNodeSelect(file.id)
-                }}
-              >
-                {file.functions.length > 0 ? (
-                  expanded.has(file.id) ? <ChevronDown className="w-4 h-4" /> : <ChevronRight className="w-4 h-4" />
-                ) : <span className="w-4" />}
-                <FileCode className="w-4 h-4 text-blue-500" />
-                <span className="truncate">{file.path.split('/').pop()}</span>
-              </button>
-
-              {(expanded.has(file.id) || (hasActiveSearch && hasMatchedFunctions)) && (
-                <div className="ml-6">
-                  {file.functions.map((fn) => {
-                    const isFunctionMatched = searchResultIds.has(fn.id)
-                    return (
-                      <button
-                        key={fn.id}
-                        className={`flex items-center gap-1 w-full p-1.5 rounded hover:bg-gray-100 text-left text-sm ${
-                          isFunctionMatched && hasActiveSearch ? 'bg-orange-50 border border-orange-200 font-medium' : ''
-                        }`}
-                        onClick={() => onNodeSelect(fn.id)}
-                      >
-                        <Box className="w-4 h-4 text-green-500" />
-                        <span className="truncate">{fn.name}</span>
-                      </button>
-                    )
-                  })}
-                </div>
-              )}
-            </div>
-          )
-        })}
+        {filteredTree && renderContents(filteredTree)}
       </div>
     </div>
   )
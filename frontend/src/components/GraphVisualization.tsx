@@ -1,14 +1,14 @@
-import { useEffect, useRef } from 'react'
+import { useEffect, useRef, useState } from 'react'
 import { useQuery } from '@tanstack/react-query'
-import { repositoryApi } from '@/lib/api'
+import { repositoryApi, type GraphType } from '@/lib/api'
 import { Button } from '@/components/ui/button'
 import { Network } from 'vis-network/standalone'
 import { DataSet } from 'vis-data/standalone'
 
 interface GraphVisualizationProps {
   repoId: string
-  type: 'structure' | 'calls'
-  onTypeChange: (type: 'structure' | 'calls') => void
+  type: GraphType
+  onTypeChange: (type: GraphType) => void
   selectedNode: string | null
   onNodeClick: (nodeId: string) => void
   highlightedNodes?: string[]
@@ -26,7 +26,80 @@ interface GraphData {
     source: string
     target: string
     type: string
+    props?: Record<string, any>
   }>
+  truncated?: boolean // more nodes follow from nextOffset
+  nextOffset?: number
+  totalNodes?: number
+  clustered?: boolean // nodes folded into Cluster nodes per directory
+  cycles?: string[][] // packages graph: node IDs depending on each other
+}
+
+type ColorMode = 'type' | 'coverage'
+
+// coverageColor grades line coverage from red (untested) to green; nodes
+// the last coverage report did not reach are grey
+function coverageColor(pct: number | undefined): string {
+  if (pct === undefined) return '#d1d5db'
+  if (pct < 50) return '#ef4444'
+  if (pct < 80) return '#f59e0b'
+  return '#22c55e'
+}
+
+function nodeColor(n: GraphData['nodes'][number], mode: ColorMode): string {
+  if (n.type === 'Cluster') return '#a78bfa'
+  if (n.props?.cyclic) return '#f87171'
+  if (mode === 'coverage') return coverageColor(n.props?.coveragePct)
+  return n.type === 'File' ? '#3b82f6' : '#22c55e'
+}
+
+function visNode(n: GraphData['nodes'][number]) {
+  const cluster = n.type === 'Cluster'
+  return {
+    id: n.id,
+    type: n.type,
+    path: n.props?.path,
+    label: cluster ? `${n.label} (${n.props.nodes})` : n.label,
+    title: n.props?.coveragePct !== undefined ? `${n.props.coveragePct.toFixed(1)}% covered` : undefined,
+    color: nodeColor(n, 'type'),
+    shape: cluster || n.type === 'File' || n.type === 'Directory' || n.type === 'Package' ? 'box' : 'ellipse',
+    font: {
+      color: '#333333',
+      size: 14,
+    },
+    borderWidth: 2,
+    borderWidthSelected: 3,
+  }
+}
+
+// edgeLabel names what an edge sums: the imports and calls between
+// packages, the calls between architecture modules or the merged edges of
+// a cluster
+function edgeLabel(e: GraphData['edges'][number], merged: number | undefined): string {
+  if (e.props?.imports !== undefined) return `${e.props.imports} imports, ${e.props.calls} calls`
+  if (e.props?.weight) return `${e.props.weight} calls`
+  return merged ? `${e.type} ×${merged}` : e.type
+}
+
+// Architecture and package edges are as thick as the dependencies they
+// sum, and edges to clusters as the edges they merge; edges in a package
+// cycle are red
+function visEdge(e: GraphData['edges'][number]) {
+  const merged = e.source.startsWith('cluster:') || e.target.startsWith('cluster:') ? e.props?.count : undefined
+  const weight = e.props?.weight ?? merged
+  return {
+    id: e.id,
+    from: e.source,
+    to: e.target,
+    arrows: 'to',
+    label: edgeLabel(e, merged),
+    color: e.props?.cyclic ? { color: '#ef4444' } : undefined,
+    width: weight ? Math.min(1 + Math.log2(weight), 8) : 1,
+    font: {
+      size: 10,
+      align: 'middle',
+    },
+  }
 }
 
 export function GraphVisualization({
@@ -40,6 +113,10 @@ export function GraphVisualization({
   const containerRef = useRef<HTMLDivElement>(null)
   const networkRef = useRef<Network | null>(null)
   const nodesDataSetRef = useRef<DataSet<any> | null>(null)
+  const edgesDataSetRef = useRef<DataSet<any> | null>(null)
+  // Clusters expanded in place, whose nodes are now drawn
+  const expandedRef = useRef<string[]>([])
+  const [colorMode, setColorMode] = useState<ColorMode>('type')
 
   const { data: graphData, isLoading } = useQuery<GraphData>({
     queryKey: ['repository-graph', repoId, type],
@@ -49,39 +126,16 @@ export function GraphVisualization({
   useEffect(() => {
     if (!containerRef.current || !graphData) return
 
-    // Prepare nodes with colors based on type
-    const nodes = graphData.nodes.map((n) => ({
-      id: n.id,
-      label: n.label,
-      color: n.type === 'File' ? '#3b82f6' : '#22c55e',
-      shape: n.type === 'File' ? 'box' : 'ellipse',
-      font: {
-        color: '#333333',
-        size: 14,
-      },
-      borderWidth: 2,
-      borderWidthSelected: 3,
-    }))
-
-    // Prepare edges
-    const edges = graphData.edges.map((e) => ({
-      id: e.id,
-      from: e.source,
-      to: e.target,
-      arrows: 'to',
-      label: e.type,
-      font: {
-        size: 10,
-        align: 'middle',
-      },
-    }))
-
-    // Create vis-network datasets
-    const nodesDS = new DataSet(nodes)
-    const edgesDS = new DataSet(edges)
+    // Create vis-network datasets; nodes are colored by type and the
+    // highlight effect below recolors them for the chosen color mode
+    // without a new layout
+    const nodesDS = new DataSet(graphData.nodes.map(visNode))
+    const edgesDS = new DataSet(graphData.edges.map(visEdge))
 
     // Store reference for later updates
     nodesDataSetRef.current = nodesDS
+    edgesDataSetRef.current = edgesDS
+    expandedRef.current = []
 
     // Destroy existing network if it exists
     if (networkRef.current) {
@@ -113,11 +167,26 @@ export function GraphVisualization({
       }
     )
 
-    // Handle click events on nodes
-    networkRef.current.on('click', (params) => {
-      if (params.nodes.length > 0) {
+    // Handle click events on nodes; clusters expand in place
+    networkRef.current.on('click', async (params) => {
+      if (params.nodes.length === 0) return
+      const node = nodesDS.get(params.nodes[0]) as any
+      if (node?.type !== 'Cluster') {
         onNodeClick(params.nodes[0])
+        return
       }
+
+      const cluster: GraphData = await repositoryApi.getGraphCluster(
+        repoId,
+        node.path,
+        type === 'calls' ? 'calls' : 'structure',
+        expandedRef.current
+      )
+      expandedRef.current = [...expandedRef.current, node.path]
+      edgesDS.remove(edgesDS.getIds({ filter: (e: any) => e.from === node.id || e.to === node.id }))
+      nodesDS.remove(node.id)
+      nodesDS.update(cluster.nodes.map(visNode))
+      edgesDS.update(cluster.edges.map(visEdge))
     })
 
     return () => {
@@ -126,8 +195,9 @@ export function GraphVisualization({
         networkRef.current = null
       }
       nodesDataSetRef.current = null
+      edgesDataSetRef.current = null
     }
-  }, [graphData, onNodeClick])
+  }, [graphData, onNodeClick, repoId, type])
 
   // Highlight selected node
   useEffect(() => {
@@ -151,6 +221,8 @@ export function GraphVisualization({
 
     // Update all nodes to either highlight or reset
     graphData.nodes.forEach((n) => {
+      // Expanded clusters are no longer drawn
+      if (!nodesDataSetRef.current?.get(n.id)) return
       const isHighlighted = highlightedSet.has(n.id)
       const update: any = {
         id: n.id,
@@ -161,27 +233,35 @@ export function GraphVisualization({
         update.borderWidth = 4
         update.color = {
           border: '#f97316', // orange-500
-          background: n.type === 'File' ? '#3b82f6' : '#22c55e',
+          background: nodeColor(n, colorMode),
           highlight: {
             border: '#ea580c', // orange-600
-            background: n.type === 'File' ? '#3b82f6' : '#22c55e',
+            background: nodeColor(n, colorMode),
           },
         }
       } else {
         // Reset to default
         update.borderWidth = 2
-        update.color = n.type === 'File' ? '#3b82f6' : '#22c55e'
+        update.color = nodeColor(n, colorMode)
       }
 
       nodesDataSetRef.current?.update(update)
     })
-  }, [highlightedNodes, graphData])
+  }, [highlightedNodes, graphData, colorMode])
 
   return (
     <div className="bg-white rounded-lg border flex flex-col">
       <div className="p-3 border-b flex items-center justify-between">
         <span className="font-medium text-sm">Graph</span>
         <div className="flex gap-1">
+          <Button
+            variant={colorMode === 'coverage' ? 'default' : 'outline'}
+            size="sm"
+            onClick={() => setColorMode(colorMode === 'coverage' ? 'type' : 'coverage')}
+            title="Color functions and files by test coverage"
+          >
+            Coverage
+          </Button>
           <Button
             variant={type === 'structure' ? 'default' : 'outline'}
             size="sm"
@@ -196,8 +276,39 @@ export function GraphVisualization({
           >
             Calls
           </Button>
+          <Button
+            variant={type === 'architecture' ? 'default' : 'outline'}
+            size="sm"
+            onClick={() => onTypeChange('architecture')}
+            title="Directories and the calls between them"
+          >
+            Architecture
+          </Button>
+          <Button
+            variant={type === 'packages' ? 'default' : 'outline'}
+            size="sm"
+            onClick={() => onTypeChange('packages')}
+            title="Packages and their imports and calls, dependency cycles in red"
+          >
+            Packages
+          </Button>
         </div>
       </div>
+      {graphData?.clustered && (
+        <div className="px-3 py-1.5 border-b bg-violet-50 text-xs text-violet-800">
+          Large graph grouped by directory; click a cluster to expand it
+        </div>
+      )}
+      {graphData?.cycles && graphData.cycles.length > 0 && (
+        <div className="px-3 py-1.5 border-b bg-red-50 text-xs text-red-800">
+          {graphData.cycles.length} dependency {graphData.cycles.length === 1 ? 'cycle' : 'cycles'} between packages, shown in red
+        </div>
+      )}
+      {graphData?.truncated && (
+        <div className="px-3 py-1.5 border-b bg-amber-50 text-xs text-amber-800">
+          Showing {graphData.nodes.length} of {graphData.totalNodes} nodes
+        </div>
+      )}
       <div ref={containerRef} className="flex-1 min-h-[400px]">
         {isLoading && (
           <div className="flex items-center justify-center h-full p-4 text-gray-500">
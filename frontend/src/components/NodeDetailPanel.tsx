@@ -1,4 +1,6 @@
 import { useQuery } from '@tanstack/react-query'
+import ReactMarkdown from 'react-markdown'
+import rehypeHighlight from 'rehype-highlight'
 import { repositoryApi } from '@/lib/api'
 import { FileCode, Box, ArrowRight, ArrowLeft } from 'lucide-react'
 
@@ -7,6 +9,14 @@ interface NodeDetailPanelProps {
   repoId: string
 }
 
+// Fences source as a markdown code block, with a fence longer than any
+// backtick run in it
+function codeBlock(content: string, language?: string) {
+  const longest = Math.max(2, ...(content.match(/`+/g) ?? []).map((run) => run.length))
+  const fence = '`'.repeat(longest + 1)
+  return `${fence}${language ?? ''}\n${content}\n${fence}`
+}
+
 export function NodeDetailPanel({ nodeId, repoId }: NodeDetailPanelProps) {
   const { data: nodeDetail, isLoading } = useQuery({
     queryKey: ['node-detail', repoId, nodeId],
@@ -42,6 +52,9 @@ export function NodeDetailPanel({ nodeId, repoId }: NodeDetailPanelProps) {
               {nodeDetail.signature}
             </code>
           )}
+          {nodeDetail?.description && (
+            <p className="text-sm text-gray-700 mt-2 italic">{nodeDetail.description}</p>
+          )}
         </div>
 
         {nodeDetail?.filePath && (
@@ -56,6 +69,27 @@ export function NodeDetailPanel({ nodeId, repoId }: NodeDetailPanelProps) {
           </div>
         )}
 
+        {nodeDetail?.content && (
+          <div>
+            <h4 className="text-sm font-medium text-gray-500">Source</h4>
+            <div className="text-xs mt-1">
+              <ReactMarkdown
+                rehypePlugins={[rehypeHighlight]}
+                components={{
+                  pre: ({ children }) => (
+                    <pre className="bg-gray-900 text-gray-100 rounded p-2 overflow-auto max-h-96">{children}</pre>
+                  ),
+                }}
+              >
+                {codeBlock(nodeDetail.content, nodeDetail.language)}
+              </ReactMarkdown>
+            </div>
+            {nodeDetail.contentTruncated && (
+              <p className="text-xs text-gray-400 mt-1">Source truncated</p>
+            )}
+          </div>
+        )}
+
         {nodeDetail?.calls && nodeDetail.calls.length > 0 && (
           <div>
             <h4 className="text-sm font-medium text-gray-500 flex items-center gap-1">
@@ -85,6 +119,27 @@ export function NodeDetailPanel({ nodeId, repoId }: NodeDetailPanelProps) {
             </ul>
           </div>
         )}
+
+        {([
+          ['Reads', nodeDetail?.reads],
+          ['Writes', nodeDetail?.writes],
+          ['Read By', nodeDetail?.readBy],
+          ['Written By', nodeDetail?.writtenBy],
+        ] as const).map(([title, accesses]) => accesses && accesses.length > 0 && (
+          <div key={title}>
+            <h4 className="text-sm font-medium text-gray-500">{title}</h4>
+            <ul className="text-sm mt-1 space-y-1">
+              {accesses.map((access) => (
+                <li key={access.id} className="text-blue-600">
+                  {access.name}
+                  {access.lines && access.lines.length > 0 && (
+                    <span className="ml-2 text-xs text-gray-400">line {access.lines.join(', ')}</span>
+                  )}
+                </li>
+              ))}
+            </ul>
+          </div>
+        ))}
       </div>
     </div>
   )
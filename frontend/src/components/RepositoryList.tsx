@@ -1,9 +1,11 @@
+import { useState } from 'react'
 import { Link } from 'react-router-dom'
-import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query'
+import { useQuery, useMutation, useQueryClient, keepPreviousData } from '@tanstack/react-query'
 import { repositoryApi, Repository } from '@/lib/api'
 import { Card, CardHeader, CardTitle, CardContent } from '@/components/ui/card'
 import { Button } from '@/components/ui/button'
 import { Badge } from '@/components/ui/badge'
+import { Input } from '@/components/ui/input'
 import { Trash2, RefreshCw, GitBranch, FileCode, Box } from 'lucide-react'
 
 function StatusBadge({ status }: { status: Repository['status'] }) {
@@ -95,38 +97,104 @@ function RepositoryCard({ repo }: { repo: Repository }) {
   )
 }
 
+const PAGE_SIZE = 24
+
 export function RepositoryList() {
-  const { data: repositories, isLoading, error } = useQuery({
-    queryKey: ['repositories'],
-    queryFn: repositoryApi.list,
+  const [q, setQ] = useState('')
+  const [status, setStatus] = useState<Repository['status'] | ''>('')
+  const [sort, setSort] = useState('-lastIndexed')
+  const [page, setPage] = useState(1)
+
+  const { data, isLoading, error } = useQuery({
+    queryKey: ['repositories', { q, status, sort, page }],
+    queryFn: () =>
+      repositoryApi.list({ q: q.trim() || undefined, status: status || undefined, sort, page, limit: PAGE_SIZE }),
+    placeholderData: keepPreviousData,
     refetchInterval: 5000, // Poll for status updates
   })
 
-  if (isLoading) {
-    return <div className="text-center py-8 text-gray-500">Loading repositories...</div>
+  // Changing the filters starts over from the first page
+  const filter = (update: () => void) => {
+    update()
+    setPage(1)
   }
 
-  if (error) {
-    return (
+  const filtered = q.trim() !== '' || status !== ''
+  const pages = Math.max(1, Math.ceil((data?.total ?? 0) / PAGE_SIZE))
+
+  let content
+  if (isLoading) {
+    content = <div className="text-center py-8 text-gray-500">Loading repositories...</div>
+  } else if (error) {
+    content = (
       <div className="text-center py-8 text-red-500">
         Error loading repositories. Is the backend running?
       </div>
     )
-  }
-
-  if (!repositories?.length) {
-    return (
+  } else if (!data?.repositories.length) {
+    content = (
       <div className="text-center py-8 text-gray-500">
-        No repositories yet. Add one to get started!
+        {filtered ? 'No repositories match these filters.' : 'No repositories yet. Add one to get started!'}
+      </div>
+    )
+  } else {
+    content = (
+      <div className="grid gap-4 md:grid-cols-2 lg:grid-cols-3">
+        {data.repositories.map((repo) => (
+          <RepositoryCard key={repo.id} repo={repo} />
+        ))}
       </div>
     )
   }
 
   return (
-    <div className="grid gap-4 md:grid-cols-2 lg:grid-cols-3">
-      {repositories.map((repo) => (
-        <RepositoryCard key={repo.id} repo={repo} />
-      ))}
+    <div>
+      <div className="flex gap-2 mb-4">
+        <Input
+          value={q}
+          onChange={(e) => filter(() => setQ(e.target.value))}
+          placeholder="Filter by name..."
+          className="flex-1"
+        />
+        <select
+          value={status}
+          onChange={(e) => filter(() => setStatus(e.target.value as Repository['status'] | ''))}
+          className="border rounded-md px-2 text-sm"
+        >
+          <option value="">Any status</option>
+          <option value="ready">Ready</option>
+          <option value="indexing">Indexing</option>
+          <option value="pending">Pending</option>
+          <option value="error">Error</option>
+        </select>
+        <select
+          value={sort}
+          onChange={(e) => filter(() => setSort(e.target.value))}
+          className="border rounded-md px-2 text-sm"
+        >
+          <option value="-lastIndexed">Recently indexed</option>
+          <option value="name">Name</option>
+          <option value="status">Status</option>
+          <option value="-filesCount">Most files</option>
+          <option value="-functionsCount">Most functions</option>
+        </select>
+      </div>
+
+      {content}
+
+      {pages > 1 && (
+        <div className="flex items-center justify-center gap-4 mt-6 text-sm text-gray-600">
+          <Button variant="outline" size="sm" onClick={() => setPage(page - 1)} disabled={page <= 1}>
+            Previous
+          </Button>
+          <span>
+            Page {page} of {pages} ({data?.total} repositories)
+          </span>
+          <Button variant="outline" size="sm" onClick={() => setPage(page + 1)} disabled={page >= pages}>
+            Next
+          </Button>
+        </div>
+      )}
     </div>
   )
 }
@@ -216,6 +216,17 @@ export function WikiContent({ repoId, slug }: WikiContentProps) {
           </div>
         )}
 
+        {page.staleFiles && page.staleFiles.length > 0 && (
+          <div className="mb-4 p-3 rounded border border-amber-200 bg-amber-50 text-sm text-amber-800">
+            <div className="font-medium">This page may be out of date. These files changed since it was generated:</div>
+            <ul className="mt-1 list-disc list-inside font-mono text-xs">
+              {page.staleFiles.map((file) => (
+                <li key={file}>{file}</li>
+              ))}
+            </ul>
+          </div>
+        )}
+
         {page.tableOfContents && page.tableOfContents.length > 0 && (
           <TableOfContents items={page.tableOfContents} />
         )}
@@ -2,13 +2,41 @@ import axios from 'axios'
 
 const API_URL = import.meta.env.VITE_API_URL || 'http://localhost:3001'
 
+// Sent with every request when the backend requires API keys
+const API_KEY = import.meta.env.VITE_API_KEY || ''
+const authHeaders: Record<string, string> = API_KEY ? { 'X-API-Key': API_KEY } : {}
+
 export const api = axios.create({
   baseURL: API_URL,
   headers: {
     'Content-Type': 'application/json',
+    ...authHeaders,
   },
 })
 
+// Errors of the API, whose message describes what went wrong
+export interface ApiErrorBody {
+  code: string // e.g. invalid_request, not_found, rate_limited
+  message: string
+  details?: unknown
+}
+
+// Reports the API's message rather than axios' generic one, keeping the
+// error code on the error for callers telling errors apart
+api.interceptors.response.use(undefined, (error) => {
+  const body: ApiErrorBody | undefined = error.response?.data?.error
+  if (body?.message) {
+    error.message = body.message
+    error.code = body.code
+  }
+  return Promise.reject(error)
+})
+
+// Adds the API key to URLs opened without our headers: event streams and
+// download links
+const withApiKey = (url: string): string =>
+  API_KEY ? `${url}${url.includes('?') ? '&' : '?'}apiKey=${encodeURIComponent(API_KEY)}` : url
+
 export interface Repository {
   id: string
   url: string
@@ -18,6 +46,119 @@ export interface Repository {
   filesCount: number
   functionsCount: number
   lastIndexed: string
+  commit?: string
+  projectId?: string
+  orgId?: string
+  wikiAutoRefresh: boolean
+}
+
+// Indexing options apply from the next index
+export interface RepositorySettings {
+  wikiAutoRefresh: boolean
+  ignoreGlobs: string[] | null // e.g. *_test.go, docs/**
+  languages: string[] | null // indexed languages, all when empty
+  maxFileSize: number // bytes, 0 for any size
+  skipEmbeddings: boolean
+}
+
+export interface IndexRun {
+  id: string
+  repoId: string
+  commit?: string
+  status: 'running' | 'ready' | 'error'
+  startedAt: string
+  finishedAt?: string
+  filesCount: number
+  entitiesCount: number
+  error?: string
+  artifact: boolean
+}
+
+// A function or method of an index run, matched across runs by file and name
+export interface RunSymbol {
+  name: string
+  type: string
+  filePath: string
+  signature?: string
+}
+
+export interface GraphDiff {
+  fromRunId: string
+  toRunId: string
+  fromCommit?: string
+  toCommit?: string
+  addedFunctions: RunSymbol[]
+  removedFunctions: RunSymbol[]
+  changedFunctions: (RunSymbol & { fromSignature?: string; fromLines: number; toLines: number })[]
+  addedCalls: { caller: RunSymbol; callee: RunSymbol }[]
+  removedCalls: { caller: RunSymbol; callee: RunSymbol }[]
+}
+
+// How often and by whom a file changed in the indexed git history
+export interface FileChurn {
+  path: string
+  commits: number
+  additions: number
+  deletions: number
+  authors: number
+  owner: string
+  ownerShare: number
+  lastModified: string
+}
+
+export type ReportAnalysis = 'layers' | 'churn' | 'hotspots' | 'dead-code' | 'coverage-gaps'
+
+// A named analysis saved on a repository, re-run and compared over time
+export interface Report {
+  id: string
+  repoId: string
+  name: string
+  analysis: ReportAnalysis
+  params: Record<string, string>
+  createdAt: string
+  lastRunAt?: string
+}
+
+export interface ReportRun {
+  id: string
+  reportId: string
+  commit?: string
+  ranAt: string
+  items: string[]
+  result?: unknown
+}
+
+export interface ReportComparison {
+  from: ReportRun
+  to: ReportRun
+  added: string[]
+  removed: string[]
+  unchanged: number
+}
+
+export interface RepositorySummary {
+  summary: string
+  source: 'agent' | 'fallback'
+  commit?: string
+  generatedAt: string
+}
+
+// Numbers for a repository dashboard; line counts cover files indexed since
+// lines were recorded
+export interface RepositoryStats {
+  files: number
+  functions: number
+  methods: number
+  classes: number
+  variables: number
+  languages: Record<string, number> // language -> files
+  entryPoints: { name: string; filePath: string }[]
+  findings: Record<string, number> // level -> findings
+  lines: number
+  languageLines: Record<string, number> // language -> lines
+  avgFunctionLines: number
+  topPackages: { path: string; files: number; lines: number; functions: number }[]
+  indexDurationSeconds?: number
 }
 
 export interface CreateRepositoryInput {
@@ -26,6 +167,22 @@ export interface CreateRepositoryInput {
   defaultBranch?: string
 }
 
+// Either urls or org, a GitHub organization or user
+export interface BulkRepositoryInput {
+  urls?: string[]
+  org?: string
+  includeForks?: boolean
+  includeArchived?: boolean
+  defaultBranch?: string
+}
+
+export interface BulkRepositoryResult {
+  url: string
+  status: 'created' | 'exists' | 'error'
+  repository?: Repository
+  error?: string
+}
+
 export interface FileNode {
   id: string
   path: string
@@ -39,42 +196,457 @@ export interface FileNode {
   }>
 }
 
+// A directory of a repository's file tree; counts cover everything below it
+export interface DirectoryNode {
+  id?: string
+  name: string
+  path: string
+  isPackage?: boolean
+  fileCount: number
+  functionCount: number
+  directories: DirectoryNode[]
+  files: FileNode[]
+  // contents left out to limit depth
+  truncated?: boolean
+}
+
+// A status transition or progress report streamed by repositoryApi.subscribe
+export interface RepositoryEvent {
+  type: 'repository' | 'index' | 'wiki'
+  repoId: string
+  time: string
+  status?: string // repository or wiki status
+  stage?: string // index stage, e.g. clone, parse, embed
+  done?: number
+  total?: number // of the index stage, or wiki pages
+  progress?: number // wiki generation percent
+  currentPage?: string
+  error?: string
+}
+
+export interface RepositoryListParams {
+  status?: Repository['status']
+  q?: string // substring of the name
+  sort?: string // name, status, lastIndexed, filesCount or functionsCount; prefix - to sort descending
+  page?: number // from 1
+  limit?: number // all when unset
+}
+
+export interface RepositoryPage {
+  repositories: Repository[]
+  total: number // matching the filters, across all pages
+}
+
 export const repositoryApi = {
-  list: async (): Promise<Repository[]> => {
-    const { data } = await api.get('/api/repositories')
-    return data
+  list: async (params: RepositoryListParams = {}): Promise<RepositoryPage> => {
+    const { data, headers } = await api.get('/api/v1/repositories', { params })
+    const total = Number(headers['x-total-count'])
+    return { repositories: data, total: Number.isNaN(total) ? data.length : total }
   },
 
   get: async (id: string): Promise<Repository> => {
-    const { data } = await api.get(`/api/repositories/${id}`)
+    const { data } = await api.get(`/api/v1/repositories/${id}`)
     return data
   },
 
   create: async (input: CreateRepositoryInput): Promise<Repository> => {
-    const { data } = await api.post('/api/repositories', input)
+    const { data } = await api.post('/api/v1/repositories', input)
+    return data
+  },
+
+  bulkCreate: async (input: BulkRepositoryInput): Promise<{ created: number; results: BulkRepositoryResult[] }> => {
+    const { data } = await api.post('/api/v1/repositories/bulk', input)
     return data
   },
 
   delete: async (id: string): Promise<void> => {
-    await api.delete(`/api/repositories/${id}`)
+    await api.delete(`/api/v1/repositories/${id}`)
+  },
+
+  // Creates a repository from a snapshot exported by another instance
+  importSnapshot: async (file: Blob): Promise<SnapshotImportResult> => {
+    const { data } = await api.post('/api/v1/repositories/import', file, {
+      headers: { 'Content-Type': 'application/x-ndjson' },
+    })
+    return data
+  },
+
+  // Creates a repository, wiki included, from an archive exported by
+  // another instance
+  importArchive: async (file: Blob): Promise<SnapshotImportResult> => {
+    const { data } = await api.post('/api/v1/repositories/import', file, {
+      headers: { 'Content-Type': 'application/gzip' },
+    })
+    return data
+  },
+
+  getSettings: async (id: string): Promise<RepositorySettings> => {
+    const { data } = await api.get(`/api/v1/repositories/${id}/settings`)
+    return data
+  },
+
+  // Settings left out keep their values
+  updateSettings: async (id: string, settings: Partial<RepositorySettings>): Promise<RepositorySettings> => {
+    const { data } = await api.put(`/api/v1/repositories/${id}/settings`, settings)
+    return data
+  },
+
+  // Streams the repository's events, starting with its current repository
+  // and wiki statuses; returns a function closing the stream
+  subscribe: (id: string, onEvent: (event: RepositoryEvent) => void): (() => void) => {
+    const source = new EventSource(withApiKey(`${API_URL}/api/v1/repositories/${id}/events`))
+    const listener = (e: MessageEvent) => onEvent(JSON.parse(e.data))
+    for (const type of ['repository', 'index', 'wiki']) {
+      source.addEventListener(type, listener)
+    }
+    return () => source.close()
+  },
+
+  getRuns: async (id: string): Promise<IndexRun[]> => {
+    const { data } = await api.get(`/api/v1/repositories/${id}/runs`)
+    return data
+  },
+
+  // Functions and calls that changed between two index runs, by default the two latest
+  getGraphDiff: async (id: string, from?: string, to?: string): Promise<GraphDiff> => {
+    const { data } = await api.get(`/api/v1/repositories/${id}/graph/diff`, {
+      params: { from, to },
+    })
+    return data
+  },
+
+  // SBOM and graph export of an index run, for download links
+  artifactUrl: (id: string, runId: string): string =>
+    withApiKey(`${API_URL}/api/v1/repositories/${id}/runs/${runId}/artifact`),
+
+  // NDJSON of entities with their embedding vectors, then the edges between them
+  embeddingsExportUrl: (id: string): string =>
+    withApiKey(`${API_URL}/api/v1/repositories/${id}/export/embeddings`),
+
+  // tar.gz archive of the full index: metadata, entities, relationships and
+  // wiki pages, for backups and air-gapped instances; see importArchive
+  archiveExportUrl: (id: string): string =>
+    withApiKey(`${API_URL}/api/v1/repositories/${id}/export`),
+
+  // NDJSON snapshot of the code graph and history, for importSnapshot
+  snapshotExportUrl: (id: string): string =>
+    withApiKey(`${API_URL}/api/v1/repositories/${id}/export/snapshot`),
+
+  // The structure or call graph for Graphviz, yEd or Gephi
+  graphExportUrl: (
+    id: string,
+    format: 'dot' | 'graphml' | 'gexf',
+    type: GraphType = 'structure'
+  ): string =>
+    withApiKey(`${API_URL}/api/v1/repositories/${id}/graph/export?format=${format}&type=${type}`),
+
+  getStats: async (id: string): Promise<RepositoryStats> => {
+    const { data } = await api.get(`/api/v1/repositories/${id}/stats`)
+    return data
+  },
+
+  getSummary: async (id: string, refresh = false): Promise<RepositorySummary> => {
+    const { data } = await api.get(`/api/v1/repositories/${id}/summary`, {
+      params: refresh ? { refresh: true } : undefined,
+    })
+    return data
   },
 
   reindex: async (id: string): Promise<void> => {
-    await api.post(`/api/repositories/${id}/reindex`)
+    await api.post(`/api/v1/repositories/${id}/reindex`)
+  },
+
+  // One directory level: the files of path (the root by default) with their
+  // functions, and its subdirectories truncated to be fetched in turn
+  getFiles: async (id: string, path?: string): Promise<DirectoryNode> => {
+    const { data } = await api.get(`/api/v1/repositories/${id}/files`, {
+      params: { path: path || undefined },
+    })
+    return data
+  },
+
+  getTree: async (id: string, path?: string, depth?: number): Promise<DirectoryNode> => {
+    const { data } = await api.get(`/api/v1/repositories/${id}/tree`, {
+      params: { path: path || undefined, depth: depth || undefined },
+    })
+    return data
+  },
+
+  // limit and offset page large graphs; the server caps nodes per response
+  getGraph: async (id: string, type: GraphType = 'structure', options?: GraphQueryOptions) => {
+    const { data } = await api.get(`/api/v1/repositories/${id}/graph`, { params: { type, ...options } })
+    return data
+  },
+
+  // Streams a graph as NDJSON chunks of nodes and the edges they complete,
+  // calling onChunk as each arrives; resolves with the cursor to resume
+  // from, which equals totalNodes once the whole graph is received
+  streamGraph: async (
+    id: string,
+    type: GraphType,
+    onChunk: (chunk: GraphChunk) => void,
+    options?: { cursor?: number; batch?: number; signal?: AbortSignal }
+  ): Promise<number> => {
+    const params = new URLSearchParams({ type })
+    if (options?.cursor) params.set('cursor', String(options.cursor))
+    if (options?.batch) params.set('batch', String(options.batch))
+    const response = await fetch(`${API_URL}/api/v1/repositories/${id}/graph/stream?${params}`, {
+      headers: authHeaders,
+      signal: options?.signal,
+    })
+    if (!response.ok || !response.body) {
+      const body = await response.json().catch(() => undefined)
+      throw new Error(body?.error?.message ?? `Failed to stream graph: ${response.status}`)
+    }
+
+    const reader = response.body.pipeThrough(new TextDecoderStream()).getReader()
+    let cursor = options?.cursor ?? 0
+    let buffered = ''
+    for (;;) {
+      const { value, done } = await reader.read()
+      if (done) break
+      buffered += value
+      const lines = buffered.split('\n')
+      buffered = lines.pop() ?? ''
+      for (const line of lines) {
+        if (!line.trim()) continue
+        const chunk: GraphChunk = JSON.parse(line)
+        cursor = chunk.cursor
+        onChunk(chunk)
+      }
+    }
+    return cursor
+  },
+
+  // Nodes of a directory cluster of a clustered graph and the edges touching
+  // them; edges lead to the nodes of the expanded clusters, else to clusters
+  getGraphCluster: async (
+    id: string,
+    path: string,
+    type: 'structure' | 'calls' = 'structure',
+    expanded: string[] = []
+  ) => {
+    const { data } = await api.get(`/api/v1/repositories/${id}/graph/cluster`, {
+      params: { path, type, expanded: expanded.join(',') || undefined },
+    })
+    return data
   },
 
-  getFiles: async (id: string): Promise<FileNode[]> => {
-    const { data } = await api.get(`/api/repositories/${id}/files`)
+  // Subgraph within depth hops of a node, for expanding the graph around it;
+  // nodes carry their distance from it in props.distance
+  getNeighborhood: async (repoId: string, nodeId: string, depth = 2, limit?: number) => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/nodes/${nodeId}/neighborhood`, {
+      params: { depth, limit },
+    })
     return data
   },
 
-  getGraph: async (id: string, type: 'structure' | 'calls' = 'structure') => {
-    const { data } = await api.get(`/api/repositories/${id}/graph?type=${type}`)
+  // Transitive callers (upstream) or callees (downstream) of a function
+  getCallChain: async (
+    repoId: string,
+    nodeId: string,
+    direction: 'upstream' | 'downstream' = 'downstream',
+    depth = 5,
+  ): Promise<CallChain> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/nodes/${nodeId}/call-chain`, {
+      params: { direction, depth },
+    })
+    return data
+  },
+
+  // Classes implementing or extending a class, nested by supertype
+  getClassHierarchy: async (repoId: string, rootId: string): Promise<HierarchyNode> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/hierarchy`, { params: { root: rootId } })
+    return data
+  },
+
+  // The same tree as a Mermaid class diagram
+  getClassHierarchyMermaid: async (repoId: string, rootId: string): Promise<string> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/hierarchy`, {
+      params: { root: rootId, format: 'mermaid' },
+      responseType: 'text',
+    })
     return data
   },
 
   getNodeDetail: async (repoId: string, nodeId: string): Promise<NodeDetail> => {
-    const { data } = await api.get(`/api/repositories/${repoId}/nodes/${nodeId}`)
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/nodes/${nodeId}`)
+    return data
+  },
+
+  uploadTraces: async (
+    repoId: string,
+    file: Blob,
+    format?: 'pprof' | 'otlp'
+  ): Promise<TraceUploadResult> => {
+    const { data } = await api.post(`/api/v1/repositories/${repoId}/traces`, file, {
+      params: format ? { format } : undefined,
+      headers: { 'Content-Type': 'application/octet-stream' },
+    })
+    return data
+  },
+
+  uploadCoverage: async (
+    repoId: string,
+    file: Blob,
+    format?: CoverageFormat
+  ): Promise<CoverageUploadResult> => {
+    const { data } = await api.post(`/api/v1/repositories/${repoId}/coverage`, file, {
+      params: format ? { format } : undefined,
+      headers: { 'Content-Type': 'application/octet-stream' },
+    })
+    return data
+  },
+
+  uploadFindings: async (repoId: string, file: Blob): Promise<FindingsUploadResult> => {
+    const { data } = await api.post(`/api/v1/repositories/${repoId}/findings`, file, {
+      headers: { 'Content-Type': 'application/json' },
+    })
+    return data
+  },
+
+  getCoverageGaps: async (
+    repoId: string,
+    maxCoverage = 0,
+    limit = 20
+  ): Promise<CoverageGap[]> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/analysis/coverage-gaps`, {
+      params: { maxCoverage, limit },
+    })
+    return data
+  },
+
+  getChurn: async (repoId: string, limit = 20): Promise<FileChurn[]> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/analysis/churn`, {
+      params: { limit },
+    })
+    return data
+  },
+
+  // Functions with the highest PageRank over the call graph
+  // Files changing most often with the most complex code, or directories
+  // at depth when above 0
+  getHotspots: async (repoId: string, limit = 20, depth = 0): Promise<FileHotspot[]> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/analysis/hotspots`, {
+      params: { limit, depth },
+    })
+    return data
+  },
+
+  getTopCentral: async (repoId: string, limit = 20): Promise<CentralFunction[]> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/analysis/top-central`, {
+      params: { limit },
+    })
+    return data
+  },
+
+  // Most called functions and entry points, to find one's way around a codebase
+  getOrientation: async (repoId: string, limit = 20): Promise<OrientationReport> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/analysis/orientation`, {
+      params: { limit },
+    })
+    return data
+  },
+
+  // Functions with unusually many callers or callees
+  getCouplingOutliers: async (repoId: string, limit = 20): Promise<CouplingReport> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/analysis/coupling`, {
+      params: { limit },
+    })
+    return data
+  },
+
+  getMostComplex: async (repoId: string, limit = 20): Promise<ComplexFunction[]> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/analysis/complexity`, {
+      params: { limit },
+    })
+    return data
+  },
+
+  // Groups of functions calling each other in a cycle, largest first
+  getCallCycles: async (repoId: string, limit = 50): Promise<CallCycles> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/analysis/cycles`, {
+      params: { limit },
+    })
+    return data
+  },
+
+  // includeTests also reports test helpers nothing calls
+  getDeadCode: async (repoId: string, includeTests = false): Promise<DeadCodeReport> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/analysis/dead-code`, {
+      params: { includeTests },
+    })
+    return data
+  },
+
+  // Calls matching no function of the repository: builtins and libraries
+  getUnresolvedCalls: async (repoId: string, limit = 50): Promise<UnresolvedCallReport> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/analysis/unresolved-calls`, {
+      params: { limit },
+    })
+    return data
+  },
+
+  // Pairs of functions with nearly the same embeddings, most similar first
+  getDuplicates: async (repoId: string, limit = 50, minScore?: number): Promise<DuplicatePair[]> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/analysis/duplicates`, {
+      params: { limit, minScore },
+    })
+    return data
+  },
+
+  // Queue finding duplicates again; they are also found after every index
+  findDuplicates: async (repoId: string): Promise<{ id: string; kind: string; state: string }> => {
+    const { data } = await api.post(`/api/v1/repositories/${repoId}/analysis/duplicates`)
+    return data
+  },
+
+  // Functions, files and packages a change may affect, with the tests to run
+  getImpact: async (
+    repoId: string,
+    change: { nodeIds?: string[]; files?: string[]; maxDepth?: number },
+  ): Promise<ImpactReport> => {
+    const { data } = await api.post(`/api/v1/repositories/${repoId}/analysis/impact`, change)
+    return data
+  },
+
+  getReports: async (repoId: string): Promise<Report[]> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/reports`)
+    return data
+  },
+
+  createReport: async (
+    repoId: string,
+    report: { name: string; analysis: ReportAnalysis; params?: Record<string, string> }
+  ): Promise<Report> => {
+    const { data } = await api.post(`/api/v1/repositories/${repoId}/reports`, report)
+    return data
+  },
+
+  deleteReport: async (repoId: string, reportId: string): Promise<void> => {
+    await api.delete(`/api/v1/repositories/${repoId}/reports/${reportId}`)
+  },
+
+  runReport: async (repoId: string, reportId: string): Promise<ReportRun> => {
+    const { data } = await api.post(`/api/v1/repositories/${repoId}/reports/${reportId}/run`)
+    return data
+  },
+
+  getReportRuns: async (repoId: string, reportId: string): Promise<ReportRun[]> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/reports/${reportId}/runs`)
+    return data
+  },
+
+  // Compares two runs, by default the two latest
+  compareReportRuns: async (
+    repoId: string,
+    reportId: string,
+    from?: string,
+    to?: string
+  ): Promise<ReportComparison> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/reports/${reportId}/compare`, {
+      params: { from, to },
+    })
     return data
   },
 }
@@ -82,13 +654,247 @@ export const repositoryApi = {
 export interface NodeDetail {
   id: string
   name: string
-  type: 'File' | 'Function' | 'Method'
+  type: 'Directory' | 'Package' | 'File' | 'Class' | 'Function' | 'Method' | 'Variable'
   signature?: string
+  description?: string // generated one-sentence summary
   filePath?: string
   startLine?: number
   endLine?: number
   calls?: string[]
   calledBy?: string[]
+  memberOf?: string
+  members?: string[]
+  commit?: string
+  ambiguousCalls?: string[]
+  content?: string
+  contentTruncated?: boolean
+  language?: string // for syntax highlighting
+  callSites?: CallSite[]
+  fanIn?: number // distinct callers
+  fanOut?: number // distinct callees
+  runtime?: RuntimeStats
+  coverage?: CoverageStats
+  findings?: Finding[]
+  reads?: VariableAccess[]
+  writes?: VariableAccess[]
+  readBy?: VariableAccess[]
+  writtenBy?: VariableAccess[]
+}
+
+// A READS or WRITES edge between a function and a package-level variable,
+// seen from the other end
+export interface VariableAccess {
+  id: string
+  name: string
+  filePath?: string
+  lines?: number[]
+  count: number
+}
+
+// Behavior observed in the last uploaded profile or trace
+export interface RuntimeStats {
+  count: number
+  totalMs: number
+  avgMs: number
+  source: 'pprof' | 'otlp'
+  observedAt: string
+}
+
+export interface TraceUploadResult {
+  format: 'pprof' | 'otlp'
+  observations: number
+  matched: number
+  unmatched: number
+  topUnmatched: string[]
+}
+
+export type FindingLevel = 'error' | 'warning' | 'note' | 'none'
+
+// A static analysis result from an uploaded SARIF log
+export interface Finding {
+  id: string
+  tool: string
+  ruleId: string
+  level: FindingLevel
+  message: string
+  filePath: string
+  startLine?: number
+  endLine?: number
+}
+
+export interface FindingsUploadResult {
+  tools: string[]
+  findings: number
+  located: number
+  inEntities: number
+  unmatched: number
+}
+
+export type CoverageFormat = 'go' | 'lcov' | 'cobertura'
+
+// Line coverage from the last uploaded coverage report
+export interface CoverageStats {
+  covered: number
+  lines: number
+  percent: number
+  source: CoverageFormat
+  observedAt: string
+}
+
+export interface SnapshotImportResult {
+  repository: Repository
+  nodes: number
+  relationships: number
+  wikiPages?: number
+  // embeddings of another model are being rebuilt in the background
+  reembedding?: boolean
+}
+
+export interface CoverageUploadResult {
+  format: CoverageFormat
+  files: number
+  entities: number
+  unmatched: number
+  unmatchedFiles: string[]
+}
+
+// A poorly covered function ranked by its callers
+export interface CoverageGap {
+  id: string
+  name: string
+  type: 'Function' | 'Method'
+  filePath: string
+  startLine?: number
+  coverage: number
+  callers: number
+  runtimeCount?: number
+}
+
+export interface CentralFunction {
+  id: string
+  name: string
+  type: 'Function' | 'Method'
+  filePath: string
+  startLine?: number
+  pageRank: number
+  inDegree: number // distinct callers
+  outDegree: number // distinct callees
+}
+
+export interface ComplexFunction {
+  id: string
+  name: string
+  type: 'Function' | 'Method'
+  filePath: string
+  startLine?: number
+  endLine?: number
+  complexity: number // cyclomatic: 1 plus branch points
+}
+
+export interface FileHotspot {
+  path: string
+  commits: number // in the indexed history
+  complexity: number // summed over its functions
+  functions: number
+  score: number // commits times complexity, relative to the repository's highest; 0 to 1
+  topFunction?: ComplexFunction
+}
+
+export interface CouplingReport {
+  fanInFence: number // fan-in above this is an outlier
+  fanOutFence: number
+  functions: Array<{
+    id: string
+    name: string
+    type: 'Function' | 'Method'
+    filePath: string
+    startLine?: number
+    fanIn: number // distinct callers
+    fanOut: number // distinct callees
+  }>
+}
+
+export interface CallCycle {
+  size: number
+  functions: Array<{ id: string; name: string; type: 'Function' | 'Method'; filePath: string; startLine?: number }>
+  edges: Array<{ id: string; source: string; target: string; type: string }>
+}
+
+export interface CallCycles {
+  cycles: CallCycle[]
+  total: number // cycles found, even past the limit
+  functions: number // functions in any cycle
+}
+
+export interface DeadFunction {
+  id: string
+  name: string
+  type: string
+  filePath: string
+  startLine?: number
+  signature?: string
+}
+
+export interface CalledFunction extends DeadFunction {
+  callers: number // distinct functions calling it
+  calls: number // call sites across them
+}
+
+export interface EntryPoint extends DeadFunction {
+  kind: 'main' | 'http' | 'cli'
+}
+
+export interface OrientationReport {
+  mostCalled: CalledFunction[]
+  entryPoints: EntryPoint[] // main first, then HTTP handlers and CLI commands
+}
+
+export interface DeadCodeReport {
+  functions: DeadFunction[] // exported but never called
+  files: { path: string; language: string }[] // imported and called by no other file
+}
+
+export interface UnresolvedCallReport {
+  calls: number // call sites in all
+  names: { name: string; importPath?: string; calls: number; callers: number }[]
+  modules: { importPath: string; names: number; calls: number; callers: number }[] // hot libraries
+}
+
+export interface DuplicateFunction {
+  id: string
+  name: string
+  type: 'Function' | 'Method'
+  filePath: string
+  startLine?: number
+  endLine?: number
+}
+
+export interface DuplicatePair {
+  score: number // cosine similarity of the embeddings
+  a: DuplicateFunction
+  b: DuplicateFunction
+}
+
+export interface ImpactReport {
+  functions: Array<{
+    id: string
+    name: string
+    type: 'Function' | 'Method'
+    filePath: string
+    startLine?: number
+    depth: number // calls away from a changed function
+  }>
+  files: string[]
+  packages: string[]
+  tests: string[] // test files among files
+}
+
+export interface CallSite {
+  id: string
+  name: string
+  line?: number
+  lines?: number[]
+  count: number
 }
 
 export interface SearchResult {
@@ -99,18 +905,104 @@ export interface SearchResult {
   repoId: string
   repoName: string
   score: number
+  kind?: 'wiki' | 'docstring' // documentation; unset for code
+  wikiSlug?: string
+  docstring?: string
+  description?: string // generated one-sentence summary
+  startLine: number
+  endLine: number
+  snippet?: string // source lines around the match, from snippetLine
+  snippetLine?: number
+  searchId?: string // the logged search, when search analytics are enabled
+  searchMode?: SearchMode // the mode searched: keyword while semantic search is unavailable
+}
+
+// Tags results with the ID of their logged search, if it was logged, and
+// the mode they were searched in
+const withSearchId = (results: SearchResult[], headers: Record<string, unknown>): SearchResult[] => {
+  const searchId = headers['x-search-id']
+  const searchMode = headers['x-search-mode'] as SearchMode | undefined
+  return results.map((result) => ({
+    ...result,
+    searchMode,
+    ...(typeof searchId === 'string' ? { searchId } : {}),
+  }))
+}
+
+export const systemApi = {
+  // Repositories and the DEPENDS_ON edges between them
+  getGraph: async () => {
+    const { data } = await api.get('/api/v1/graph/system')
+    return data
+  },
+}
+
+export interface SymbolMatch {
+  id: string
+  name: string
+  qualifiedName: string // methods are prefixed with their class
+  type: 'Class' | 'Function' | 'Method'
+  filePath: string
+  startLine: number
+  score: number
+  positions: number[] // indexes into qualifiedName of the matched characters
+}
+
+// Semantic search compares embeddings; keyword search matches the words of
+// names, signatures and docstrings, for exact identifiers; hybrid search
+// fuses the rankings of both
+export type SearchMode = 'semantic' | 'keyword' | 'hybrid'
+
+// Narrows search results; unset fields keep everything
+export interface SearchFilters {
+  lang?: string // go, python, typescript, javascript, java or kotlin
+  type?: string // comma-separated: function, method, class
+  path?: string // path prefix
+  diversity?: string // 0 to 1, how strongly near-identical results are skipped
+  expand?: string // 'true' to also search variants of the query the agent suggests
+  scope?: string // code (default), docs for wiki pages and docstrings, or all
+  minScore?: string // 0 to 1, results scoring lower are left out
 }
 
 export const searchApi = {
-  global: async (query: string): Promise<SearchResult[]> => {
-    const { data } = await api.get(`/api/search?q=${encodeURIComponent(query)}`)
+  global: async (query: string, mode: SearchMode = 'semantic', filters: SearchFilters = {}): Promise<SearchResult[]> => {
+    const { data, headers } = await api.get('/api/v1/search', { params: { q: query, mode, ...filters } })
+    return withSearchId(data, headers)
+  },
+
+  repo: async (repoId: string, query: string, mode: SearchMode = 'semantic', filters: SearchFilters = {}): Promise<SearchResult[]> => {
+    const { data, headers } = await api.get(`/api/v1/repositories/${repoId}/search`, {
+      params: { q: query, mode, ...filters },
+    })
+    return withSearchId(data, headers)
+  },
+
+  // Reports opening the result at rank (1 for the first) of a logged search
+  select: async (result: SearchResult, rank: number) => {
+    if (!result.searchId) return
+    await api.post('/api/v1/search/selections', { searchId: result.searchId, resultId: result.id, rank })
+  },
+
+  // Go-to-symbol: fuzzy matches over qualified names, best first
+  symbols: async (repoId: string, query: string, limit?: number): Promise<SymbolMatch[]> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/symbols`, {
+      params: { q: query, limit },
+    })
     return data
   },
 
-  repo: async (repoId: string, query: string): Promise<SearchResult[]> => {
-    const { data } = await api.get(
-      `/api/repositories/${repoId}/search?q=${encodeURIComponent(query)}`
-    )
+  chat: async (
+    query: string,
+    resultIds: string[],
+    message?: string,
+    repoId?: string
+  ): Promise<AgentChatResponse> => {
+    const { data } = await api.post('/api/v1/search/chat', {
+      query,
+      message,
+      result_ids: resultIds,
+      repo_id: repoId,
+    })
     return data
   },
 }
@@ -121,8 +1013,15 @@ export interface AgentChatRequest {
   agentType?: 'explorer' | 'analyzer' | 'doc_writer'
 }
 
+export interface AgentToolCall {
+  tool: string
+  input: Record<string, unknown>
+  result: unknown
+}
+
 export interface AgentChatResponse {
   response: string
+  tool_calls?: AgentToolCall[]
 }
 
 export const agentApi = {
@@ -131,13 +1030,144 @@ export const agentApi = {
     repoId?: string,
     agentType: 'explorer' | 'analyzer' | 'doc_writer' = 'explorer'
   ): Promise<AgentChatResponse> => {
-    const { data } = await api.post('/api/agents/chat', {
+    const { data } = await api.post('/api/v1/agents/chat', {
       message,
       repo_id: repoId,
       agent_type: agentType,
     })
     return data
   },
+
+  // Streams the answer, calling onToken with each piece as the agent writes
+  // it; resolves to the whole answer once done
+  chatStream: (
+    message: string,
+    onToken: (text: string) => void,
+    options?: {
+      repoId?: string
+      agentType?: 'explorer' | 'analyzer' | 'doc_writer'
+      onToolCall?: (call: AgentToolCall) => void
+      signal?: AbortSignal
+    }
+  ): Promise<AgentChatResponse> =>
+    streamChat(
+      '/api/v1/agents/chat/stream',
+      { message, repo_id: options?.repoId, agent_type: options?.agentType ?? 'explorer' },
+      onToken,
+      options
+    ),
+}
+
+// Posts a chat message to a streaming endpoint, reading its server-sent
+// events: token, tool_call, and done or error last
+const streamChat = async (
+  path: string,
+  body: unknown,
+  onToken: (text: string) => void,
+  options?: { onToolCall?: (call: AgentToolCall) => void; signal?: AbortSignal }
+): Promise<AgentChatResponse> => {
+  const response = await fetch(`${API_URL}${path}`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json', ...authHeaders },
+    body: JSON.stringify(body),
+    signal: options?.signal,
+  })
+  if (!response.ok || !response.body) {
+    const error = await response.json().catch(() => undefined)
+    throw new Error(error?.error?.message ?? `Failed to chat: ${response.status}`)
+  }
+
+  // Server-sent events are separated by a blank line, each an event name
+  // and a JSON data line
+  const reader = response.body.pipeThrough(new TextDecoderStream()).getReader()
+  let buffered = ''
+  for (;;) {
+    const { value, done } = await reader.read()
+    if (done) break
+    buffered += value
+    const events = buffered.split('\n\n')
+    buffered = events.pop() ?? ''
+    for (const raw of events) {
+      let event = 'message'
+      let data = ''
+      for (const line of raw.split('\n')) {
+        if (line.startsWith('event: ')) event = line.slice(7)
+        else if (line.startsWith('data: ')) data += line.slice(6)
+      }
+      if (!data) continue
+      const payload = JSON.parse(data)
+      switch (event) {
+        case 'token':
+          onToken(payload.text)
+          break
+        case 'tool_call':
+          options?.onToolCall?.(payload)
+          break
+        case 'done':
+          return payload
+        case 'error':
+          throw new Error(payload.message)
+      }
+    }
+  }
+  throw new Error('The agent stopped answering')
+}
+
+// Conversations with the agent about a repository, kept so they survive
+// reloads and can be continued
+export interface ConversationMessage {
+  id: string
+  role: 'user' | 'assistant'
+  content: string
+  toolCalls?: AgentToolCall[]
+  createdAt: string
+}
+
+export interface Conversation {
+  id: string
+  repoId: string
+  title: string
+  agentType: 'explorer' | 'analyzer' | 'doc_writer'
+  createdAt: string
+  updatedAt: string
+  messageCount: number
+  messages?: ConversationMessage[] // when a single conversation is read
+}
+
+export const conversationApi = {
+  // Most recently continued first, without messages
+  list: async (repoId: string): Promise<Conversation[]> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/conversations`)
+    return data
+  },
+
+  create: async (
+    repoId: string,
+    agentType: 'explorer' | 'analyzer' | 'doc_writer' = 'explorer'
+  ): Promise<Conversation> => {
+    const { data } = await api.post(`/api/v1/repositories/${repoId}/conversations`, { agentType })
+    return data
+  },
+
+  get: async (repoId: string, id: string): Promise<Conversation> => {
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/conversations/${id}`)
+    return data
+  },
+
+  delete: async (repoId: string, id: string) => {
+    await api.delete(`/api/v1/repositories/${repoId}/conversations/${id}`)
+  },
+
+  // Streams the answer like agentApi.chatStream; the message and answer are
+  // saved once the agent is done
+  sendStream: (
+    repoId: string,
+    id: string,
+    message: string,
+    onToken: (text: string) => void,
+    options?: { onToolCall?: (call: AgentToolCall) => void; signal?: AbortSignal }
+  ): Promise<AgentChatResponse> =>
+    streamChat(`/api/v1/repositories/${repoId}/conversations/${id}/messages/stream`, { message }, onToken, options),
 }
 
 // Wiki types
@@ -164,6 +1194,58 @@ export interface Diagram {
   code: string
 }
 
+export interface CallChainNode {
+  id: string
+  name: string
+  type: string
+  filePath: string
+  signature?: string
+  depth: number // calls away from the root, which has 0
+}
+
+export interface HierarchyNode {
+  id: string
+  name: string
+  filePath: string
+  startLine?: number
+  subtypes: HierarchyNode[]
+}
+
+export interface CallChain {
+  root: string
+  direction: 'upstream' | 'downstream'
+  maxDepth: number
+  nodes: CallChainNode[]
+  edges: Array<{ id: string; source: string; target: string; type: string; props?: Record<string, any> }>
+  truncated?: boolean
+}
+
+// architecture collapses functions into their directories, with
+// DEPENDS_ON edges weighted by the calls between them; packages adds the
+// imports between them and marks dependency cycles as cyclic
+export type GraphType = 'structure' | 'calls' | 'architecture' | 'packages'
+
+export interface GraphChunk {
+  nodes: Array<{ id: string; label: string; type: string; props: Record<string, any> }>
+  edges: Array<{ id: string; source: string; target: string; type: string; props?: Record<string, any> }>
+  cursor: number // where the next chunk starts
+  totalNodes: number
+  done?: boolean
+}
+
+export interface GraphQueryOptions {
+  limit?: number
+  offset?: number
+  depth?: number // architecture only: fold directories below this depth
+  path?: string // file path prefix
+  language?: string
+  entityType?: string // comma-separated Function, Method, Class
+  name?: string // glob, * and ? wildcards
+  minDegree?: number // calls made or received
+  cluster?: boolean // false pages huge graphs instead of clustering them
+  cache?: boolean // false recomputes the graph instead of serving it cached
+}
+
 export interface WikiPage {
   id: string
   repoId: string
@@ -175,6 +1257,8 @@ export interface WikiPage {
   diagrams?: Diagram[]
   tableOfContents?: TOCItem[]
   generatedAt?: string
+  sources?: string[]
+  staleFiles?: string[] // sources changed by a reindex since generation
 }
 
 export interface WikiStatus {
@@ -187,21 +1271,21 @@ export interface WikiStatus {
 
 export const wikiApi = {
   getNavigation: async (repoId: string): Promise<WikiNavigation> => {
-    const { data } = await api.get(`/api/repositories/${repoId}/wiki`)
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/wiki`)
     return data
   },
 
   getPage: async (repoId: string, slug: string): Promise<WikiPage> => {
-    const { data } = await api.get(`/api/repositories/${repoId}/wiki/${slug}`)
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/wiki/${slug}`)
     return data
   },
 
   getStatus: async (repoId: string): Promise<WikiStatus> => {
-    const { data } = await api.get(`/api/repositories/${repoId}/wiki/status`)
+    const { data } = await api.get(`/api/v1/repositories/${repoId}/wiki/status`)
     return data
   },
 
   generate: async (repoId: string): Promise<void> => {
-    await api.post(`/api/repositories/${repoId}/wiki/generate`)
+    await api.post(`/api/v1/repositories/${repoId}/wiki/generate`)
   },
 }
@@ -1,6 +1,6 @@
 import { useParams, Link, useSearchParams } from 'react-router-dom'
 import { useQuery } from '@tanstack/react-query'
-import { repositoryApi } from '@/lib/api'
+import { repositoryApi, type GraphType } from '@/lib/api'
 import { ArrowLeft, Book } from 'lucide-react'
 import { Button } from '@/components/ui/button'
 import { useState, useEffect } from 'react'
@@ -11,7 +11,7 @@ import { NodeDetailPanel } from '@/components/NodeDetailPanel'
 export default function RepositoryDetailPage() {
   const { id } = useParams<{ id: string }>()
   const [searchParams] = useSearchParams()
-  const [graphType, setGraphType] = useState<'structure' | 'calls'>('structure')
+  const [graphType, setGraphType] = useState<GraphType>('structure')
   const [selectedNode, setSelectedNode] = useState<string | null>(null)
   const [highlightedNodes, setHighlightedNodes] = useState<string[]>([])
 
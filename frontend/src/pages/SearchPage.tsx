@@ -1,37 +1,45 @@
 import { useState } from 'react'
 import { useQuery } from '@tanstack/react-query'
 import { Link, useSearchParams } from 'react-router-dom'
-import { searchApi } from '@/lib/api'
+import { searchApi, SearchFilters, SearchMode, SearchResult } from '@/lib/api'
 import { Input } from '@/components/ui/input'
 import { Button } from '@/components/ui/button'
 import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card'
-import { Search, FileCode } from 'lucide-react'
-
-interface SearchResult {
-  id: string
-  name: string
-  signature: string
-  filePath: string
-  repoId: string
-  repoName: string
-  score: number
-}
+import { Search, FileCode, BookOpen } from 'lucide-react'
 
 export default function SearchPage() {
   const [searchParams, setSearchParams] = useSearchParams()
   const query = searchParams.get('q') || ''
+  const modeParam = searchParams.get('mode')
+  const mode: SearchMode = modeParam === 'keyword' || modeParam === 'hybrid' ? modeParam : 'semantic'
+  const filters: SearchFilters = {
+    lang: searchParams.get('lang') || undefined,
+    type: searchParams.get('type') || undefined,
+    path: searchParams.get('path') || undefined,
+    diversity: searchParams.get('diversity') || undefined,
+    expand: searchParams.get('expand') || undefined,
+    scope: searchParams.get('scope') || undefined,
+    minScore: searchParams.get('minScore') || undefined,
+  }
   const [inputValue, setInputValue] = useState(query)
+  const [pathValue, setPathValue] = useState(filters.path || '')
 
   const { data: results, isLoading } = useQuery({
-    queryKey: ['search', query],
-    queryFn: () => searchApi.global(query),
+    queryKey: ['search', query, mode, filters],
+    queryFn: () => searchApi.global(query, mode, filters),
     enabled: query.length > 2,
   })
 
+  // Keeps the query, mode and filters in the URL, dropping empty ones
+  const updateParams = (changes: Record<string, string>) => {
+    const next = { q: query, mode, ...filters, path: pathValue, ...changes }
+    setSearchParams(Object.fromEntries(Object.entries(next).filter(([, v]) => v)) as Record<string, string>)
+  }
+
   const handleSearch = (e: React.FormEvent) => {
     e.preventDefault()
     if (inputValue.trim()) {
-      setSearchParams({ q: inputValue })
+      updateParams({ q: inputValue })
     }
   }
 
@@ -46,10 +54,88 @@ export default function SearchPage() {
             placeholder="Search code across all repositories..."
             className="flex-1"
           />
+          <select
+            value={mode}
+            onChange={(e) => updateParams({ mode: e.target.value })}
+            className="border rounded-md px-2 text-sm"
+            title="Semantic search finds similar code; keyword search finds exact names; hybrid does both"
+          >
+            <option value="semantic">Semantic</option>
+            <option value="keyword">Keyword</option>
+            <option value="hybrid">Hybrid</option>
+          </select>
           <Button type="submit">
             <Search className="w-4 h-4 mr-2" /> Search
           </Button>
         </form>
+        <div className="flex gap-2 mt-2">
+          <select
+            value={filters.lang || ''}
+            onChange={(e) => updateParams({ lang: e.target.value })}
+            className="border rounded-md px-2 text-sm"
+          >
+            <option value="">Any language</option>
+            <option value="go">Go</option>
+            <option value="python">Python</option>
+            <option value="typescript">TypeScript</option>
+            <option value="javascript">JavaScript</option>
+            <option value="java">Java</option>
+            <option value="kotlin">Kotlin</option>
+          </select>
+          <select
+            value={filters.type || ''}
+            onChange={(e) => updateParams({ type: e.target.value })}
+            className="border rounded-md px-2 text-sm"
+          >
+            <option value="">Any type</option>
+            <option value="function">Functions</option>
+            <option value="method">Methods</option>
+            <option value="class">Classes</option>
+          </select>
+          <select
+            value={filters.scope || ''}
+            onChange={(e) => updateParams({ scope: e.target.value })}
+            className="border rounded-md px-2 text-sm"
+          >
+            <option value="">Code</option>
+            <option value="docs">Docs</option>
+            <option value="all">Code and docs</option>
+          </select>
+          <select
+            value={filters.diversity || ''}
+            onChange={(e) => updateParams({ diversity: e.target.value })}
+            className="border rounded-md px-2 text-sm"
+          >
+            <option value="">Most relevant</option>
+            <option value="0.5">Skip near-duplicates</option>
+          </select>
+          <select
+            value={filters.minScore || ''}
+            onChange={(e) => updateParams({ minScore: e.target.value })}
+            className="border rounded-md px-2 text-sm"
+            title="Leaves out results scoring below the threshold"
+          >
+            <option value="">All matches</option>
+            <option value="0.3">Hide weak matches</option>
+            <option value="0.5">Strong matches only</option>
+          </select>
+          <label className="flex items-center gap-1 text-sm whitespace-nowrap">
+            <input
+              type="checkbox"
+              checked={filters.expand === 'true'}
+              onChange={(e) => updateParams({ expand: e.target.checked ? 'true' : '' })}
+            />
+            Expand query
+          </label>
+          <Input
+            value={pathValue}
+            onChange={(e) => setPathValue(e.target.value)}
+            onBlur={() => updateParams({})}
+            onKeyDown={(e) => e.key === 'Enter' && updateParams({})}
+            placeholder="Path prefix, e.g. internal/api"
+            className="flex-1 text-sm"
+          />
+        </div>
       </div>
 
       {isLoading && (
@@ -74,16 +160,28 @@ export default function SearchPage() {
         <div className="space-y-4">
           <div className="text-sm text-gray-500 mb-2">
             Found {results.length} result{results.length !== 1 ? 's' : ''}
+            {mode !== 'keyword' && results[0].searchMode === 'keyword' && (
+              <span> by keyword, as semantic search is unavailable</span>
+            )}
           </div>
-          {results.map((result: SearchResult) => (
+          {results.map((result: SearchResult, index: number) => (
             <Link
               key={result.id}
-              to={`/repository/${result.repoId}?node=${result.id}`}
+              onClick={() => searchApi.select(result, index + 1).catch(() => {})}
+              to={
+                result.kind === 'wiki'
+                  ? `/repository/${result.repoId}/wiki/${result.wikiSlug}`
+                  : `/repository/${result.repoId}?node=${result.id}`
+              }
             >
               <Card className="hover:shadow-md transition-shadow cursor-pointer">
                 <CardHeader className="pb-3">
                   <CardTitle className="flex items-center gap-2 text-base">
-                    <FileCode className="w-4 h-4 text-blue-500" />
+                    {result.kind ? (
+                      <BookOpen className="w-4 h-4 text-green-600" />
+                    ) : (
+                      <FileCode className="w-4 h-4 text-blue-500" />
+                    )}
                     <span className="font-medium">{result.name}</span>
                     <span className="text-gray-400 text-sm font-normal">
                       in {result.repoName}
@@ -91,10 +189,27 @@ export default function SearchPage() {
                   </CardTitle>
                 </CardHeader>
                 <CardContent>
-                  <code className="text-sm text-gray-600 block mb-2 bg-gray-50 p-2 rounded">
-                    {result.signature}
-                  </code>
-                  <p className="text-sm text-gray-500">{result.filePath}</p>
+                  {result.description && (
+                    <p className="text-sm text-gray-700 mb-1 italic">{result.description}</p>
+                  )}
+                  {result.docstring && (
+                    <p className="text-sm text-gray-700 mb-2 line-clamp-2">{result.docstring}</p>
+                  )}
+                  {result.snippet ? (
+                    <pre className="text-xs text-gray-600 mb-2 bg-gray-50 p-2 rounded overflow-x-auto">
+                      {result.snippet}
+                    </pre>
+                  ) : (
+                    result.signature && (
+                      <code className="text-sm text-gray-600 block mb-2 bg-gray-50 p-2 rounded">
+                        {result.signature}
+                      </code>
+                    )
+                  )}
+                  <p className="text-sm text-gray-500">
+                    {result.filePath}
+                    {result.startLine > 0 && `:${result.snippetLine || result.startLine}`}
+                  </p>
                   <p className="text-xs text-gray-400 mt-1">
                     Score: {result.score.toFixed(3)}
                   </p>
@@ -1,6 +1,6 @@
 import { useParams, Link, useNavigate } from 'react-router-dom'
 import { useQuery, useQueryClient } from '@tanstack/react-query'
-import { repositoryApi, wikiApi } from '@/lib/api'
+import { repositoryApi, wikiApi, WikiStatus } from '@/lib/api'
 import { ArrowLeft, RefreshCw, Loader2 } from 'lucide-react'
 import { Button } from '@/components/ui/button'
 import { WikiSidebar } from '@/components/WikiSidebar'
@@ -23,9 +23,24 @@ export default function WikiPage() {
     queryKey: ['wiki-status', id],
     queryFn: () => wikiApi.getStatus(id!),
     enabled: !!id,
-    refetchInterval: isGenerating ? 2000 : false,
   })
 
+  // Generation progress arrives as events rather than by polling
+  useEffect(() => {
+    if (!id) return
+    return repositoryApi.subscribe(id, (event) => {
+      if (event.type !== 'wiki') return
+      queryClient.setQueryData(['wiki-status', id], (prev: WikiStatus | undefined) => ({
+        ...prev,
+        status: event.status as WikiStatus['status'],
+        progress: event.progress ?? 0,
+        currentPage: event.currentPage,
+        totalPages: event.total,
+        errorMessage: event.error,
+      }))
+    })
+  }, [id, queryClient])
+
   useEffect(() => {
     if (status?.status === 'ready' || status?.status === 'error') {
       setIsGenerating(false)